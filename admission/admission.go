@@ -20,7 +20,6 @@ import (
 	"context"
 	"fmt"
 	"reflect"
-	"sort"
 	"strings"
 	"time"
 
@@ -35,6 +34,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	admissionapi "k8s.io/pod-security-admission/admission/api"
 	"k8s.io/pod-security-admission/admission/api/validation"
@@ -65,13 +65,150 @@ type Admission struct {
 	// API connections
 	NamespaceGetter NamespaceGetter
 	PodLister       PodLister
+	// PodControllerGetter is required if SubresourceWarnings is set.
+	PodControllerGetter PodControllerGetter
+
+	// WarnUnevaluatedFields adds a warning for each securityContext field set in an evaluated pod
+	// that is not evaluated by any policy version (see policy.UnevaluatedFields).
+	WarnUnevaluatedFields bool
+
+	// WarnVersionSkew adds a warning for audit and warn versions newer or older than the enforce version
+	// to the requests of evaluated pods, and of namespaces created or updated with such versions.
+	// Evaluations of such policies are recorded if the Metrics implement metrics.VersionSkewRecorder.
+	WarnVersionSkew bool
+
+	// WarnDeprecatedFields adds a warning for each deprecated field set in an admitted pod, like the seccomp alpha
+	// and AppArmor beta annotations, naming the field replacing it (see policy.DeprecatedFields).
+	// Evaluated pods setting such fields are recorded if the Metrics implement metrics.DeprecatedFieldRecorder.
+	WarnDeprecatedFields bool
+
+	// NamespaceRolloutGuard determines how namespace label updates that tighten the enforce level unsafely are handled.
+	NamespaceRolloutGuard NamespaceRolloutGuard
+
+	// UnknownLabels determines how namespaces with unknown labels under the pod-security.kubernetes.io/ prefix are handled.
+	UnknownLabels UnknownLabelsAction
+
+	// NamespaceEvaluation configures the evaluation of existing pods when a namespace enforce level is tightened.
+	NamespaceEvaluation NamespaceEvaluationOptions
+
+	// ChecksSchemaVersion is optional, and recorded in the audit annotations of evaluated pods
+	// to identify the checks enforced by this instance (see policy.SchemaVersion).
+	ChecksSchemaVersion string
+
+	// AuditViolationsDetail records the audit violations of evaluated pods as a versioned JSON api.AuditViolationsDetail
+	// in the api.AuditViolationsDetailAnnotationKey audit annotation, in addition to the api.AuditViolationsAnnotationKey.
+	// The offending containers and fields are only included if the Evaluator collects field errors (see policy.WithFieldErrors).
+	AuditViolationsDetail bool
+
+	// ViolationRecorder is optional, and records evaluated pods violating the policy of their namespace.
+	ViolationRecorder ViolationRecorder
+
+	// DecisionRecorder is optional, and records the enforce decisions of evaluated pods.
+	DecisionRecorder DecisionRecorder
+
+	// IdentityExtractor is optional, and derives the identities of the requesting user matched against
+	// the exempt usernames of the Configuration. Defaults to UsernameIdentity.
+	IdentityExtractor IdentityExtractor
+
+	// CheckOptOutVerifier is optional, and verifies the api.CheckOptOutAnnotation of pods excluding them from specific checks.
+	// The annotation is ignored with a warning if it cannot be verified.
+	CheckOptOutVerifier CheckOptOutVerifier
+
+	// EnforcementAction determines how pods violating the enforce policy of their namespace are handled.
+	EnforcementAction EnforcementAction
+
+	// FailurePolicies determines how requests that cannot be evaluated are handled.
+	FailurePolicies FailurePolicies
+
+	// CheckErrorPolicies determines how pods that some checks failed to evaluate are handled in each mode.
+	CheckErrorPolicies CheckErrorPolicies
+
+	// NamespaceLookup configures the handling of requests whose namespace cannot be fetched.
+	NamespaceLookup NamespaceLookupOptions
+
+	// LenientLabelParsing parses namespace level and version labels ignoring surrounding whitespace and case,
+	// and warns about the normalized labels on namespace requests (see api.PolicyToEvaluateLenient).
+	LenientLabelParsing bool
+
+	// WarningLimits caps the number of warnings returned per request.
+	WarningLimits WarningLimits
+
+	// NamespaceWarnings configures the warnings about the existing pods violating a new namespace enforce level.
+	NamespaceWarnings NamespaceWarningOptions
+
+	// DeterminismGuard is optional, and re-evaluates a sample of the evaluated pods to detect nondeterministic decisions.
+	DeterminismGuard *DeterminismGuard
+
+	// NamespaceCheckExemptions exempts the pods of namespaces from the checks listed in their api.ExemptChecksAnnotation,
+	// except the checks evaluated at the level of the enforce floor. Exempt checks are recorded in the audit annotations.
+	NamespaceCheckExemptions bool
+
+	// ShortCircuitEnforce stops the enforce evaluation of pods at the first violated check, if the Evaluator implements
+	// policy.ShortCircuitEvaluator, reducing the latency of denied requests. Denied pods are then reported with the first
+	// violated check only, and audit and warn policies are still evaluated against all the checks.
+	// It is ignored with EnforcementActionAnnotate, which reports all the enforce violations.
+	ShortCircuitEnforce bool
+
+	// DenialSnippets are the checks whose violations append a minimal example of the fields satisfying them to the
+	// message of enforce denials, e.g. the securityContext of a container, so pod authors can fix denied pods.
+	// Each check must have a snippet (see policy.CheckSnippetFor).
+	DenialSnippets []policy.CheckID
+
+	// SubresourceWarnings determines the warnings returned for scale requests of pod controllers,
+	// evaluating the pod template of the scaled controller fetched with the PodControllerGetter.
+	SubresourceWarnings SubresourceWarnings
 
 	defaultPolicy api.Policy
+	enforceFloor  *api.LevelVersion
+	// exemptions are the matchers of the exemptions of the Configuration, compiled by CompleteConfiguration.
+	exemptions *exemptionMatchers
+	// ephemeralContainers are the indexes of the ephemeral containers evaluated by a copy of the Admission
+	// scoped to an ephemeral container update, or nil to evaluate all the containers (see scopeToEphemeralContainers).
+	ephemeralContainers []int
+	// resourceChecks are the checks evaluated by a copy of the Admission scoped to an in-place resize,
+	// or nil to evaluate all the checks (see scopeToResourceChecks).
+	resourceChecks sets.Set[policy.CheckID]
+	// resizedFrom is the pod before the in-place resize evaluated by a copy of the Admission scoped to the resize,
+	// whose violations are not denied again (see scopeToResourceChecks).
+	resizedFrom *corev1.Pod
+	// abandon is closed when a copy of the Admission evaluating a request times out, or nil if the evaluation
+	// is not bounded (see validateWithTimeout).
+	abandon <-chan struct{}
 
 	namespaceMaxPodsToCheck  int
 	namespacePodCheckTimeout time.Duration
 }
 
+// NamespaceEvaluationOptions configures which existing pods are evaluated when a namespace enforce level is tightened,
+// so the resulting warnings reflect the workloads that would actually break.
+type NamespaceEvaluationOptions struct {
+	// SkipCompletedPods skips pods in the Succeeded or Failed phase, which will not be restarted.
+	SkipCompletedPods bool
+	// MaxPodAge skips pods created more than MaxPodAge ago, if non-zero.
+	MaxPodAge time.Duration
+	// WeightByReplicas evaluates a single pod per controller, and counts violations
+	// by the number of pods of that controller in the namespace.
+	WeightByReplicas bool
+	// Parallelism is the number of pods evaluated concurrently. Pods are evaluated serially if unset.
+	Parallelism int
+}
+
+// ViolationRecorder records pods violating a policy, e.g. to build a corpus of violating pods for replay.
+type ViolationRecorder interface {
+	// RecordViolation is called with the first violated level & version of the enforce, audit and warn policy.
+	// Implementations must not mutate the pod metadata or spec.
+	RecordViolation(ctx context.Context, lv api.LevelVersion, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, attrs api.Attributes)
+}
+
+// DecisionRecorder records the enforce decisions of evaluated pods, e.g. to persist them for later queries.
+type DecisionRecorder interface {
+	// RecordDecision is called with the decision of the enforce policy and the IDs of the checks violated by the pod,
+	// which are not empty for pods admitted with the Annotate enforcement action.
+	// Pods that are exempt or cannot be evaluated are not recorded.
+	// Implementations must not mutate the pod metadata or spec.
+	RecordDecision(ctx context.Context, decision Decision, lv api.LevelVersion, checks []policy.CheckID, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, attrs api.Attributes)
+}
+
 type NamespaceGetter interface {
 	GetNamespace(ctx context.Context, name string) (*corev1.Namespace, error)
 }
@@ -110,28 +247,11 @@ func (DefaultPodSpecExtractor) HasPodSpec(gr schema.GroupResource) bool {
 }
 
 func (DefaultPodSpecExtractor) ExtractPodSpec(obj runtime.Object) (*metav1.ObjectMeta, *corev1.PodSpec, error) {
-	switch o := obj.(type) {
-	case *corev1.Pod:
-		return &o.ObjectMeta, &o.Spec, nil
-	case *corev1.PodTemplate:
-		return extractPodSpecFromTemplate(&o.Template)
-	case *corev1.ReplicationController:
-		return extractPodSpecFromTemplate(o.Spec.Template)
-	case *appsv1.ReplicaSet:
-		return extractPodSpecFromTemplate(&o.Spec.Template)
-	case *appsv1.Deployment:
-		return extractPodSpecFromTemplate(&o.Spec.Template)
-	case *appsv1.DaemonSet:
-		return extractPodSpecFromTemplate(&o.Spec.Template)
-	case *appsv1.StatefulSet:
-		return extractPodSpecFromTemplate(&o.Spec.Template)
-	case *batchv1.Job:
-		return extractPodSpecFromTemplate(&o.Spec.Template)
-	case *batchv1.CronJob:
-		return extractPodSpecFromTemplate(&o.Spec.JobTemplate.Spec.Template)
-	default:
-		return nil, nil, fmt.Errorf("unexpected object type: %s", obj.GetObjectKind().GroupVersionKind().String())
+	template, err := policy.ExtractPodTemplate(obj)
+	if err != nil {
+		return nil, nil, err
 	}
+	return template.Metadata, template.Spec, nil
 }
 
 func (DefaultPodSpecExtractor) PodSpecResources() []schema.GroupResource {
@@ -142,13 +262,6 @@ func (DefaultPodSpecExtractor) PodSpecResources() []schema.GroupResource {
 	return retval
 }
 
-func extractPodSpecFromTemplate(template *corev1.PodTemplateSpec) (*metav1.ObjectMeta, *corev1.PodSpec, error) {
-	if template == nil {
-		return nil, nil, nil
-	}
-	return &template.ObjectMeta, &template.Spec, nil
-}
-
 // CompleteConfiguration sets up default or derived configuration.
 func (a *Admission) CompleteConfiguration() error {
 	if a.Configuration != nil {
@@ -157,6 +270,12 @@ func (a *Admission) CompleteConfiguration() error {
 		} else {
 			a.defaultPolicy = p
 		}
+		if floor, err := admissionapi.ToFloor(a.Configuration.Floor); err != nil {
+			return err
+		} else {
+			a.enforceFloor = floor
+		}
+		a.exemptions = newExemptionMatchers(a.Configuration.Exemptions)
 	}
 	a.namespaceMaxPodsToCheck = defaultNamespaceMaxPodsToCheck
 	a.namespacePodCheckTimeout = defaultNamespacePodCheckTimeout
@@ -180,6 +299,11 @@ func (a *Admission) ValidateConfiguration() error {
 		} else if !reflect.DeepEqual(p, a.defaultPolicy) {
 			return fmt.Errorf("default policy does not match; CompleteConfiguration() was not called before ValidateConfiguration()")
 		}
+		if floor, err := admissionapi.ToFloor(a.Configuration.Floor); err != nil {
+			return err
+		} else if !reflect.DeepEqual(floor, a.enforceFloor) {
+			return fmt.Errorf("enforce floor does not match; CompleteConfiguration() was not called before ValidateConfiguration()")
+		}
 	}
 	if a.namespaceMaxPodsToCheck == 0 || a.namespacePodCheckTimeout == 0 {
 		return fmt.Errorf("namespace configuration not set; CompleteConfiguration() was not called before ValidateConfiguration()")
@@ -199,6 +323,33 @@ func (a *Admission) ValidateConfiguration() error {
 	if a.PodLister == nil {
 		return fmt.Errorf("PodLister required")
 	}
+	for _, failurePolicy := range []FailurePolicy{a.FailurePolicies.Enforce, a.FailurePolicies.Audit, a.CheckErrorPolicies.Enforce, a.CheckErrorPolicies.Audit, a.CheckErrorPolicies.Warn} {
+		if _, err := ParseFailurePolicy(string(failurePolicy)); failurePolicy != "" && err != nil {
+			return fmt.Errorf("invalid failure policy %q: %w", failurePolicy, err)
+		}
+	}
+	if err := a.WarningLimits.Validate(); err != nil {
+		return err
+	}
+	if err := a.NamespaceWarnings.Validate(); err != nil {
+		return err
+	}
+	if err := a.NamespaceLookup.Validate(); err != nil {
+		return err
+	}
+	if a.DeterminismGuard != nil {
+		if err := a.DeterminismGuard.Validate(); err != nil {
+			return err
+		}
+	}
+	if a.SubresourceWarnings != SubresourceWarningsNone && a.PodControllerGetter == nil {
+		return fmt.Errorf("PodControllerGetter required for subresource warnings")
+	}
+	for _, id := range a.DenialSnippets {
+		if _, ok := policy.CheckSnippetFor(id); !ok {
+			return fmt.Errorf("check %q has no denial snippet", id)
+		}
+	}
 	return nil
 }
 
@@ -211,22 +362,41 @@ var (
 // The objects in admission attributes are expected to be external v1 objects that we care about.
 // The returned response may be shared and must not be mutated.
 func (a *Admission) Validate(ctx context.Context, attrs api.Attributes) *admissionv1.AdmissionResponse {
+	start := time.Now()
 	var response *admissionv1.AdmissionResponse
 	switch attrs.GetResource().GroupResource() {
 	case namespacesResource:
 		response = a.ValidateNamespace(ctx, attrs)
 	case podsResource:
-		response = a.ValidatePod(ctx, attrs)
+		response = a.validateWithTimeout(ctx, attrs, true, (*Admission).ValidatePod)
 	default:
-		response = a.ValidatePodController(ctx, attrs)
+		response = a.validateWithTimeout(ctx, attrs, false, (*Admission).ValidatePodController)
 	}
-	return response
+	a.recordEvaluationLatency(ctx, response, time.Since(start), attrs)
+	return a.WarningLimits.limit(response)
 }
 
 // ValidateNamespace evaluates a namespace create or update request to ensure the pod security labels are valid,
 // and checks existing pods in the namespace for violations of the new policy when updating the enforce level on a namespace.
 // The returned response may be shared between evaluations and must not be mutated.
 func (a *Admission) ValidateNamespace(ctx context.Context, attrs api.Attributes) *admissionv1.AdmissionResponse {
+	response := a.validateNamespace(ctx, attrs)
+	if !response.Allowed {
+		return response
+	}
+	if a.WarnVersionSkew {
+		response = a.withVersionSkewWarnings(response, attrs)
+	}
+	if a.LenientLabelParsing {
+		response = withNormalizedLabelsWarnings(response, attrs)
+	}
+	if a.UnknownLabels == UnknownLabelsWarn {
+		response = withUnknownLabelsWarnings(response, attrs)
+	}
+	return response
+}
+
+func (a *Admission) validateNamespace(ctx context.Context, attrs api.Attributes) *admissionv1.AdmissionResponse {
 	// short-circuit on subresources
 	if attrs.GetSubresource() != "" {
 		return sharedAllowedResponse
@@ -243,15 +413,21 @@ func (a *Admission) ValidateNamespace(ctx context.Context, attrs api.Attributes)
 	}
 
 	newPolicy, newErrs := a.PolicyToEvaluate(namespace.Labels)
+	if a.UnknownLabels == UnknownLabelsDeny {
+		newErrs = append(newErrs, api.UnknownLabels(namespace.Labels)...)
+	}
+	newErrs = append(newErrs, a.exemptChecksErrors(namespace)...)
 
 	switch attrs.GetOperation() {
 	case admissionv1.Create:
-		// require valid labels on create
+		// require valid labels and exempt checks on create
 		if len(newErrs) > 0 {
 			return invalidResponse(attrs, newErrs)
 		}
 		if a.exemptNamespace(attrs.GetNamespace()) {
-			if warning := a.exemptNamespaceWarning(namespace.Name, newPolicy, namespace.Labels); warning != "" {
+			// the floor does not apply to exempt namespaces
+			labelPolicy, _ := a.labelPolicy(namespace.Labels)
+			if warning := a.exemptNamespaceWarning(namespace.Name, labelPolicy, namespace.Labels); warning != "" {
 				response := allowedResponse()
 				response.Warnings = append(response.Warnings, warning)
 				return response
@@ -272,12 +448,24 @@ func (a *Admission) ValidateNamespace(ctx context.Context, attrs api.Attributes)
 			return errorResponse(nil, &apierrors.NewBadRequest("failed to decode  old namespace").ErrStatus)
 		}
 		oldPolicy, oldErrs := a.PolicyToEvaluate(oldNamespace.Labels)
+		if a.UnknownLabels == UnknownLabelsDeny {
+			oldErrs = append(oldErrs, api.UnknownLabels(oldNamespace.Labels)...)
+		}
+		oldErrs = append(oldErrs, a.exemptChecksErrors(oldNamespace)...)
 
-		// require valid labels on update if they have changed
+		// require valid labels and exempt checks on update if they have changed
 		if len(newErrs) > 0 && (len(oldErrs) == 0 || !reflect.DeepEqual(newErrs, oldErrs)) {
 			return invalidResponse(attrs, newErrs)
 		}
 
+		// require confirmation to downgrade to privileged or exempt checks if configured
+		if err := a.unconfirmedPrivilegedDowngrade(namespace, oldPolicy, newPolicy); err != nil {
+			return forbiddenResponse(attrs, err)
+		}
+		if err := a.unconfirmedCheckExemptions(namespace, oldNamespace, newPolicy); err != nil {
+			return forbiddenResponse(attrs, err)
+		}
+
 		// Skip dry-running pods:
 		// * if the enforce policy is unchanged
 		// * if the new enforce policy is privileged
@@ -294,7 +482,8 @@ func (a *Admission) ValidateNamespace(ctx context.Context, attrs api.Attributes)
 			return sharedAllowedResponse
 		}
 		if a.exemptNamespace(attrs.GetNamespace()) {
-			if warning := a.exemptNamespaceWarning(namespace.Name, newPolicy, namespace.Labels); warning != "" {
+			labelPolicy, _ := a.labelPolicy(namespace.Labels)
+			if warning := a.exemptNamespaceWarning(namespace.Name, labelPolicy, namespace.Labels); warning != "" {
 				response := allowedResponse()
 				response.Warnings = append(response.Warnings, warning)
 				return response
@@ -302,7 +491,16 @@ func (a *Admission) ValidateNamespace(ctx context.Context, attrs api.Attributes)
 			return sharedAllowedResponse
 		}
 		response := allowedResponse()
-		response.Warnings = a.EvaluatePodsInNamespace(ctx, namespace.Name, newPolicy.Enforce)
+		if a.NamespaceRolloutGuard != NamespaceRolloutGuardNone {
+			if reasons := unsafeRollout(oldPolicy, newPolicy); len(reasons) > 0 {
+				if a.NamespaceRolloutGuard == NamespaceRolloutGuardDeny {
+					return forbiddenResponse(attrs, fmt.Errorf("%s", strings.Join(reasons, "; ")))
+				}
+				response.Warnings = append(response.Warnings, reasons...)
+			}
+		}
+		exemptChecks, _ := a.namespaceExemptChecks(namespace)
+		response.Warnings = append(response.Warnings, a.evaluatePodsInNamespace(ctx, namespace.Name, newPolicy.Enforce, exemptChecks)...)
 		return response
 
 	default:
@@ -333,89 +531,104 @@ func (a *Admission) ValidatePod(ctx context.Context, attrs api.Attributes) *admi
 	}
 	// short-circuit on exempt namespaces and users
 	if a.exemptNamespace(attrs.GetNamespace()) {
-		a.Metrics.RecordExemption(attrs)
+		a.metrics().RecordExemption(attrs)
 		return sharedAllowedByNamespaceExemptionResponse
 	}
 
-	if a.exemptUser(attrs.GetUserName()) {
-		a.Metrics.RecordExemption(attrs)
+	if a.exemptUser(attrs) {
+		a.metrics().RecordExemption(attrs)
 		return sharedAllowedByUserExemptionResponse
 	}
 
 	// short-circuit on privileged enforce+audit+warn namespaces
-	namespace, err := a.NamespaceGetter.GetNamespace(ctx, attrs.GetNamespace())
+	namespace, err := a.lookupNamespace(ctx, attrs)
+	if a.abandoned() {
+		return abandonedResponse()
+	}
 	if err != nil {
-		klog.FromContext(ctx).Error(err, "failed to fetch pod namespace", "namespace", attrs.GetNamespace())
-		a.Metrics.RecordError(true, attrs)
-		return errorResponse(err, &apierrors.NewInternalError(fmt.Errorf("failed to lookup namespace %q", attrs.GetNamespace())).ErrStatus)
+		return a.namespaceLookupFailureResponse(ctx, attrs, true, err)
 	}
 	nsPolicy, nsPolicyErrs := a.PolicyToEvaluate(namespace.Labels)
 	if len(nsPolicyErrs) == 0 && nsPolicy.FullyPrivileged() {
-		a.Metrics.RecordEvaluation(metrics.DecisionAllow, nsPolicy.Enforce, metrics.ModeEnforce, attrs)
+		a.metrics().RecordEvaluation(metrics.DecisionAllow, nsPolicy.Enforce, metrics.ModeEnforce, attrs)
 		return sharedAllowedPrivilegedResponse
 	}
 
 	obj, err := attrs.GetObject()
 	if err != nil {
 		klog.FromContext(ctx).Error(err, "failed to decode object")
-		a.Metrics.RecordError(true, attrs)
+		a.metrics().RecordError(true, attrs)
 		return errorResponse(err, &apierrors.NewBadRequest("failed to decode object").ErrStatus)
 	}
 	pod, ok := obj.(*corev1.Pod)
 	if !ok {
 		klog.FromContext(ctx).Info("failed to assert pod type", "type", reflect.TypeOf(obj))
-		a.Metrics.RecordError(true, attrs)
+		a.metrics().RecordError(true, attrs)
 		return errorResponse(nil, &apierrors.NewBadRequest("failed to decode pod").ErrStatus)
 	}
 	if attrs.GetOperation() == admissionv1.Update {
 		oldObj, err := attrs.GetOldObject()
 		if err != nil {
 			klog.FromContext(ctx).Error(err, "failed to decode old object")
-			a.Metrics.RecordError(true, attrs)
+			a.metrics().RecordError(true, attrs)
 			return errorResponse(err, &apierrors.NewBadRequest("failed to decode old object").ErrStatus)
 		}
 		oldPod, ok := oldObj.(*corev1.Pod)
 		if !ok {
 			klog.FromContext(ctx).Info("failed to assert old pod type", "type", reflect.TypeOf(oldObj))
-			a.Metrics.RecordError(true, attrs)
+			a.metrics().RecordError(true, attrs)
 			return errorResponse(nil, &apierrors.NewBadRequest("failed to decode old pod").ErrStatus)
 		}
 		if !isSignificantPodUpdate(pod, oldPod) {
-			// Nothing we care about changed, so always allow the update.
-			return sharedAllowedResponse
+			if attrs.GetSubresource() != resizeSubresource && !resizedContainers(pod, oldPod) {
+				// Nothing we care about changed, so always allow the update.
+				return sharedAllowedResponse
+			}
+			a = a.scopeToResourceChecks(oldPod)
+		}
+		if attrs.GetSubresource() == ephemeralContainersSubresource {
+			indexes := updatedEphemeralContainers(pod, oldPod)
+			if len(indexes) == 0 {
+				return sharedAllowedResponse
+			}
+			a = a.scopeToEphemeralContainers(indexes)
 		}
 	}
-	return a.EvaluatePod(ctx, nsPolicy, nsPolicyErrs.ToAggregate(), &pod.ObjectMeta, &pod.Spec, attrs, true)
+	enforceSource := describeEnforceStatus(a.enforceStatus(namespace.Labels, nsPolicy.Enforce))
+	exemptChecks, ignoredExemptChecks := a.namespaceExemptChecks(namespace)
+	return a.evaluatePodPolicy(ctx, nsPolicy, nsPolicyErrs.ToAggregate(), enforceSource, exemptChecks, ignoredExemptChecks, &pod.ObjectMeta, &pod.Spec, attrs, true)
 }
 
 // ValidatePodController evaluates a pod controller create or update request against the effective policy for the namespace.
+// Updates that do not change fields of the pod template read by the checks, like scaling, are allowed without evaluation.
+// Scale subresource requests are evaluated according to SubresourceWarnings.
 // The returned response may be shared between evaluations and must not be mutated.
 func (a *Admission) ValidatePodController(ctx context.Context, attrs api.Attributes) *admissionv1.AdmissionResponse {
 	// short-circuit on subresources
+	if attrs.GetSubresource() == "scale" && a.SubresourceWarnings != SubresourceWarningsNone {
+		return a.validateScale(ctx, attrs)
+	}
 	if attrs.GetSubresource() != "" {
 		return sharedAllowedResponse
 	}
 	// short-circuit on exempt namespaces and users
 	if a.exemptNamespace(attrs.GetNamespace()) {
-		a.Metrics.RecordExemption(attrs)
+		a.metrics().RecordExemption(attrs)
 		return sharedAllowedByNamespaceExemptionResponse
 	}
 
-	if a.exemptUser(attrs.GetUserName()) {
-		a.Metrics.RecordExemption(attrs)
+	if a.exemptUser(attrs) {
+		a.metrics().RecordExemption(attrs)
 		return sharedAllowedByUserExemptionResponse
 	}
 
 	// short-circuit on privileged audit+warn namespaces
-	namespace, err := a.NamespaceGetter.GetNamespace(ctx, attrs.GetNamespace())
+	namespace, err := a.lookupNamespace(ctx, attrs)
+	if a.abandoned() {
+		return abandonedResponse()
+	}
 	if err != nil {
-		klog.FromContext(ctx).Error(err, "failed to fetch pod namespace", "namespace", attrs.GetNamespace())
-		a.Metrics.RecordError(true, attrs)
-		response := allowedResponse()
-		response.AuditAnnotations = map[string]string{
-			"error": fmt.Sprintf("failed to lookup namespace %q: %v", attrs.GetNamespace(), err),
-		}
-		return response
+		return a.namespaceLookupFailureResponse(ctx, attrs, false, err)
 	}
 	nsPolicy, nsPolicyErrs := a.PolicyToEvaluate(namespace.Labels)
 	if len(nsPolicyErrs) == 0 && nsPolicy.Warn.Level == api.LevelPrivileged && nsPolicy.Audit.Level == api.LevelPrivileged {
@@ -425,118 +638,332 @@ func (a *Admission) ValidatePodController(ctx context.Context, attrs api.Attribu
 	obj, err := attrs.GetObject()
 	if err != nil {
 		klog.FromContext(ctx).Error(err, "failed to decode object")
-		a.Metrics.RecordError(true, attrs)
-		response := allowedResponse()
-		response.AuditAnnotations = map[string]string{
-			"error": fmt.Sprintf("failed to decode object: %v", err),
-		}
-		return response
+		return a.failureResponse(attrs, false, err, &apierrors.NewBadRequest("failed to decode object").ErrStatus)
 	}
 	podMetadata, podSpec, err := a.PodSpecExtractor.ExtractPodSpec(obj)
 	if err != nil {
 		klog.FromContext(ctx).Error(err, "failed to extract pod spec")
-		a.Metrics.RecordError(true, attrs)
-		response := allowedResponse()
-		response.AuditAnnotations = map[string]string{
-			"error": fmt.Sprintf("failed to extract pod template: %v", err),
-		}
-		return response
+		return a.failureResponse(attrs, false, err, &apierrors.NewBadRequest("failed to extract pod template").ErrStatus)
 	}
 	if podMetadata == nil && podSpec == nil {
 		// if a controller with an optional pod spec does not contain a pod spec, skip validation
 		return sharedAllowedResponse
 	}
-	return a.EvaluatePod(ctx, nsPolicy, nsPolicyErrs.ToAggregate(), podMetadata, podSpec, attrs, false)
+	if attrs.GetOperation() == admissionv1.Update && !a.evaluatedPodTemplateFieldsChanged(attrs, podMetadata, podSpec) {
+		// e.g. scaling, or updating the resources of the pod template
+		return sharedAllowedResponse
+	}
+	exemptChecks, ignoredExemptChecks := a.namespaceExemptChecks(namespace)
+	return a.evaluatePodPolicy(ctx, nsPolicy, nsPolicyErrs.ToAggregate(), "", exemptChecks, ignoredExemptChecks, podMetadata, podSpec, attrs, false)
 }
 
 // EvaluatePod evaluates the given policy against the given pod(-like) object.
 // The enforce policy is only checked if enforce=true.
 // The returned response may be shared between evaluations and must not be mutated.
 func (a *Admission) EvaluatePod(ctx context.Context, nsPolicy api.Policy, nsPolicyErr error, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, attrs api.Attributes, enforce bool) *admissionv1.AdmissionResponse {
+	return a.evaluatePodPolicy(ctx, nsPolicy, nsPolicyErr, "", nil, nil, podMetadata, podSpec, attrs, enforce)
+}
+
+// evaluatePodPolicy evaluates the given policy like EvaluatePod. If set, enforceSource describes where the enforce
+// level & version come from, and is included in the audit annotations and the denial message.
+// The pod is exempt from exemptChecks, and exemptChecks and ignoredExemptChecks are recorded in the audit annotations
+// (see namespaceExemptChecks).
+func (a *Admission) evaluatePodPolicy(ctx context.Context, nsPolicy api.Policy, nsPolicyErr error, enforceSource string, exemptChecks, ignoredExemptChecks []policy.CheckID, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, attrs api.Attributes, enforce bool) *admissionv1.AdmissionResponse {
 	logger := klog.FromContext(ctx)
 	// short-circuit on exempt runtimeclass
 	if a.exemptRuntimeClass(podSpec.RuntimeClassName) {
-		a.Metrics.RecordExemption(attrs)
+		a.metrics().RecordExemption(attrs)
 		return sharedAllowedByRuntimeClassExemptionResponse
 	}
+	// short-circuit on exempt service account
+	if a.exemptServiceAccount(attrs.GetNamespace(), podSpec.ServiceAccountName) {
+		a.metrics().RecordExemption(attrs)
+		return sharedAllowedByServiceAccountExemptionResponse
+	}
 
 	auditAnnotations := map[string]string{}
 	if nsPolicyErr != nil {
 		logger.V(2).Info("failed to parse PodSecurity namespace labels", "err", nsPolicyErr)
 		auditAnnotations["error"] = fmt.Sprintf("Failed to parse policy: %v", nsPolicyErr)
-		a.Metrics.RecordError(false, attrs)
+		a.metrics().RecordError(false, attrs)
 	}
+	a.recordVersionSkew(nsPolicy, attrs)
+	deprecatedFields := a.deprecatedFields(podMetadata, podSpec, attrs)
 
 	if klogV := logger.V(5); klogV.Enabled() {
 		klogV.Info("PodSecurity evaluation", "policy", fmt.Sprintf("%v", nsPolicy), "op", attrs.GetOperation(), "resource", attrs.GetResource(), "namespace", attrs.GetNamespace(), "name", attrs.GetName())
 	}
+	if a.ChecksSchemaVersion != "" {
+		auditAnnotations[api.ChecksSchemaVersionAnnotationKey] = a.ChecksSchemaVersion
+	}
+	optOut, optOutErr := a.checkOptOut(attrs.GetNamespace(), podMetadata)
+	if optOutErr != nil {
+		logger.V(2).Info("ignoring PodSecurity check opt-out", "err", optOutErr)
+		auditAnnotations[api.CheckOptOutAnnotationKey] = fmt.Sprintf("ignored: %v", optOutErr)
+	} else if optOut != nil {
+		auditAnnotations[api.CheckOptOutAnnotationKey] = checkOptOutAuditAnnotation(optOut)
+	}
+	if len(exemptChecks) > 0 || len(ignoredExemptChecks) > 0 {
+		auditAnnotations[api.ExemptChecksAnnotationKey] = exemptChecksAuditAnnotation(exemptChecks, ignoredExemptChecks)
+	}
 	cachedResults := make(map[api.LevelVersion]policy.AggregateCheckResult)
 	response := allowedResponse()
+	annotatedEnforce := false
+	// enforceComplete is false if the enforce evaluation stopped at the first violated check (see ShortCircuitEnforce)
+	enforceComplete := true
+	var enforceViolations []policy.CheckID
+	// checkErrorsReported is true once the errors of checks failing to evaluate the pod were recorded as an error
+	checkErrorsReported := false
+	reportCheckErrors := func(lv api.LevelVersion, result policy.AggregateCheckResult) {
+		appendAuditError(auditAnnotations, checkErrorDetail(lv, result))
+		if !checkErrorsReported {
+			checkErrorsReported = true
+			a.metrics().RecordError(false, attrs)
+		}
+	}
 	if enforce {
 		auditAnnotations[api.EnforcedPolicyAnnotationKey] = nsPolicy.Enforce.String()
 
-		result := policy.AggregateCheckResults(a.Evaluator.EvaluatePod(nsPolicy.Enforce, podMetadata, podSpec))
-		if !result.Allowed {
-			response = forbiddenResponse(attrs, fmt.Errorf(
-				"violates PodSecurity %q: %s",
+		var results []policy.CheckResult
+		if a.ShortCircuitEnforce && a.EnforcementAction != EnforcementActionAnnotate {
+			results, enforceComplete = a.evaluatePodUntilDenied(nsPolicy.Enforce, optOut, exemptChecks, podMetadata, podSpec)
+		} else {
+			results = a.evaluatePod(nsPolicy.Enforce, optOut, exemptChecks, podMetadata, podSpec)
+		}
+		result := policy.AggregateCheckResults(results)
+		if len(result.Errors) > 0 {
+			// the checks failing to evaluate the pod, e.g. exceeding their deadline, are handled by the check error policy
+			if a.checkErrorPolicy(metrics.ModeEnforce) == FailurePolicyFail {
+				return a.enforceErrorResponse(attrs, nsPolicy.Enforce, result)
+			}
+			if !enforceComplete {
+				// the evaluation stopped at the error, so the remaining checks are evaluated
+				results = a.evaluatePod(nsPolicy.Enforce, optOut, exemptChecks, podMetadata, podSpec)
+				result = policy.AggregateCheckResults(results)
+				enforceComplete = true
+			}
+			reportCheckErrors(nsPolicy.Enforce, result)
+			response.Warnings = append(response.Warnings, fmt.Sprintf("PodSecurity %q: %s", nsPolicy.Enforce.String(), checkErrorWarning(result)))
+		}
+		enforceViolations = violatedChecks(results)
+		if violated(result) && a.EnforcementAction == EnforcementActionAnnotate {
+			// admit the pod, which is annotated with the violations by MutatePod
+			annotatedEnforce = true
+			response.Warnings = append(response.Warnings, fmt.Sprintf(
+				"admitted with violations of PodSecurity %q: %s",
 				nsPolicy.Enforce.String(),
 				result.ForbiddenDetail(),
 			))
-			a.Metrics.RecordEvaluation(metrics.DecisionDeny, nsPolicy.Enforce, metrics.ModeEnforce, attrs)
+			a.recordEvaluation(ctx, metrics.DecisionAllow, nsPolicy.Enforce, metrics.ModeEnforce, attrs)
+			a.recordCheckViolations(ctx, result, nsPolicy.Enforce, metrics.ModeEnforce)
+		} else if violated(result) {
+			enforcedPolicy := fmt.Sprintf("%q", nsPolicy.Enforce.String())
+			if enforceSource != "" {
+				auditAnnotations[api.EnforcedPolicySourceAnnotationKey] = enforceSource
+				enforcedPolicy = fmt.Sprintf("%s (from %s)", enforcedPolicy, enforceSource)
+			}
+			response = forbiddenResponse(attrs, fmt.Errorf(
+				"violates PodSecurity %s: %s%s",
+				enforcedPolicy,
+				result.ForbiddenDetail(),
+				a.denialSnippets(result),
+			))
+			a.recordEvaluation(ctx, metrics.DecisionDeny, nsPolicy.Enforce, metrics.ModeEnforce, attrs)
+			a.recordCheckViolations(ctx, result, nsPolicy.Enforce, metrics.ModeEnforce)
 		} else {
-			a.Metrics.RecordEvaluation(metrics.DecisionAllow, nsPolicy.Enforce, metrics.ModeEnforce, attrs)
+			a.recordEvaluation(ctx, metrics.DecisionAllow, nsPolicy.Enforce, metrics.ModeEnforce, attrs)
 		}
 		cachedResults[nsPolicy.Enforce] = result
 	}
 
-	// reuse previous evaluation if audit level+version is the same as enforce level+version
+	if a.abandoned() {
+		return abandonedResponse()
+	}
+	// reuse previous evaluation if audit level+version is the same as enforce level+version,
+	// and the enforce evaluation did not stop at the first violated check
 
 	auditResult, ok := cachedResults[nsPolicy.Audit]
-	if !ok {
-		auditResult = policy.AggregateCheckResults(a.Evaluator.EvaluatePod(nsPolicy.Audit, podMetadata, podSpec))
+	if !ok || (!enforceComplete && nsPolicy.Audit == nsPolicy.Enforce) {
+		auditResult = policy.AggregateCheckResults(a.evaluatePod(nsPolicy.Audit, optOut, exemptChecks, podMetadata, podSpec))
 		cachedResults[nsPolicy.Audit] = auditResult
 	}
-	if !auditResult.Allowed {
+	if len(auditResult.Errors) > 0 && a.checkErrorPolicy(metrics.ModeAudit) == FailurePolicyFail && !(enforce && nsPolicy.Audit == nsPolicy.Enforce) {
+		// the errors of the enforce evaluation were already reported
+		reportCheckErrors(nsPolicy.Audit, auditResult)
+	}
+	if violated(auditResult) {
 		auditAnnotations[api.AuditViolationsAnnotationKey] = fmt.Sprintf(
 			"would violate PodSecurity %q: %s",
 			nsPolicy.Audit.String(),
 			auditResult.ForbiddenDetail(),
 		)
-		a.Metrics.RecordEvaluation(metrics.DecisionDeny, nsPolicy.Audit, metrics.ModeAudit, attrs)
+		if severities := violationSeveritiesAuditAnnotation(auditResult); severities != "" {
+			auditAnnotations[api.AuditViolationSeveritiesAnnotationKey] = severities
+		}
+		if a.AuditViolationsDetail {
+			if detail, err := auditViolationsDetailAuditAnnotation(nsPolicy.Audit, auditResult); err != nil {
+				logger.Error(err, "failed to encode PodSecurity audit violations detail")
+			} else {
+				auditAnnotations[api.AuditViolationsDetailAnnotationKey] = detail
+			}
+		}
+		a.recordEvaluation(ctx, metrics.DecisionDeny, nsPolicy.Audit, metrics.ModeAudit, attrs)
+		a.recordCheckViolations(ctx, auditResult, nsPolicy.Audit, metrics.ModeAudit)
 	}
 
 	// avoid adding warnings to a request we're already going to reject with an error
 	if response.Allowed {
+		// violations allowed by the checks with a warning, e.g. the Linux-only checks of Windows pods;
+		// the warnings about the enforce policy come first, so they are kept by the WarningLimits
+		if enforce {
+			response.Warnings = appendCheckWarnings(response.Warnings, nsPolicy.Enforce, cachedResults[nsPolicy.Enforce])
+		}
+		if optOutErr != nil {
+			response.Warnings = append(response.Warnings, fmt.Sprintf("ignored %s annotation: %v", api.CheckOptOutAnnotation, optOutErr))
+		}
 		// reuse previous evaluation if warn level+version is the same as audit or enforce level+version
 		warnResult, ok := cachedResults[nsPolicy.Warn]
+		if !ok && a.abandoned() {
+			return abandonedResponse()
+		}
 		if !ok {
-			warnResult = policy.AggregateCheckResults(a.Evaluator.EvaluatePod(nsPolicy.Warn, podMetadata, podSpec))
+			warnResult = policy.AggregateCheckResults(a.evaluatePod(nsPolicy.Warn, optOut, exemptChecks, podMetadata, podSpec))
+			cachedResults[nsPolicy.Warn] = warnResult
 		}
-		if !warnResult.Allowed {
-			// TODO: Craft a better user-facing warning message
-			response.Warnings = append(response.Warnings, fmt.Sprintf(
-				"would violate PodSecurity %q: %s",
-				nsPolicy.Warn.String(),
-				warnResult.ForbiddenDetail(),
-			))
-			a.Metrics.RecordEvaluation(metrics.DecisionDeny, nsPolicy.Warn, metrics.ModeWarn, attrs)
+		if len(warnResult.Errors) > 0 && a.checkErrorPolicy(metrics.ModeWarn) == FailurePolicyFail && !(enforce && nsPolicy.Warn == nsPolicy.Enforce) {
+			response.Warnings = append(response.Warnings, fmt.Sprintf("PodSecurity %q: %s", nsPolicy.Warn.String(), checkErrorWarning(warnResult)))
+		}
+		if violated(warnResult) {
+			// skip the warning if the same violations were already reported as admitted with the Annotate enforcement action
+			if !(annotatedEnforce && nsPolicy.Warn == nsPolicy.Enforce) {
+				// TODO: Craft a better user-facing warning message
+				response.Warnings = append(response.Warnings, fmt.Sprintf(
+					"would violate PodSecurity %q: %s",
+					nsPolicy.Warn.String(),
+					warnResult.ForbiddenDetail(),
+				))
+			}
+			a.recordEvaluation(ctx, metrics.DecisionDeny, nsPolicy.Warn, metrics.ModeWarn, attrs)
+			a.recordCheckViolations(ctx, warnResult, nsPolicy.Warn, metrics.ModeWarn)
+		}
+		if !enforce || nsPolicy.Warn != nsPolicy.Enforce {
+			response.Warnings = appendCheckWarnings(response.Warnings, nsPolicy.Warn, warnResult)
+		}
+		if a.WarnUnevaluatedFields && nsPolicy.Warn.Level != api.LevelPrivileged {
+			for _, path := range policy.UnevaluatedFields(podSpec) {
+				response.Warnings = append(response.Warnings, fmt.Sprintf(
+					"field %s not evaluated by PodSecurity policy version %s",
+					path, nsPolicy.Warn.Version,
+				))
+			}
+		}
+		if a.WarnVersionSkew {
+			response.Warnings = append(response.Warnings, versionSkewWarnings(nsPolicy)...)
+		}
+		response.Warnings = append(response.Warnings, deprecatedFieldWarnings(deprecatedFields)...)
+	}
+	// explain the enforce and warn violations of pods expecting the relaxation of policies for user namespaces, also when denied
+	var userFacingResults []policy.AggregateCheckResult
+	if enforce {
+		userFacingResults = append(userFacingResults, cachedResults[nsPolicy.Enforce])
+	}
+	if warnResult, ok := cachedResults[nsPolicy.Warn]; ok {
+		userFacingResults = append(userFacingResults, warnResult)
+	}
+	if checks := a.unrelaxedUserNamespaceChecks(podSpec, attrs, userFacingResults...); len(checks) > 0 {
+		response.Warnings = append(response.Warnings, unrelaxedUserNamespaceWarning(checks))
+	}
+
+	if a.ViolationRecorder != nil && !a.abandoned() {
+		for _, lv := range []api.LevelVersion{nsPolicy.Enforce, nsPolicy.Audit, nsPolicy.Warn} {
+			if result, ok := cachedResults[lv]; ok && violated(result) {
+				a.ViolationRecorder.RecordViolation(ctx, lv, podMetadata, podSpec, attrs)
+				break
+			}
+		}
+	}
+
+	if enforce && a.DecisionRecorder != nil && !a.abandoned() {
+		decision := DecisionAllow
+		if !response.Allowed {
+			decision = DecisionDeny
 		}
+		a.DecisionRecorder.RecordDecision(ctx, decision, nsPolicy.Enforce, enforceViolations, podMetadata, podSpec, attrs)
 	}
 
 	response.AuditAnnotations = auditAnnotations
 	return response
 }
 
-// podCount is used to track the number of pods sharing identical warnings when validating a namespace
-type podCount struct {
-	// podName is the lexically first pod name for the given warning
-	podName string
+// appendCheckWarnings appends the warnings of the checks of the given level & version to warnings.
+func appendCheckWarnings(warnings []string, lv api.LevelVersion, result policy.AggregateCheckResult) []string {
+	for _, warning := range result.Warnings {
+		warnings = append(warnings, fmt.Sprintf("PodSecurity %q: %s", lv.String(), warning))
+	}
+	return warnings
+}
 
-	// podCount is the total number of pods with the same warnings
-	podCount int
+// violatedChecks returns the IDs of the checks that disallowed the pod, excluding the checks that failed to evaluate it.
+func violatedChecks(results []policy.CheckResult) []policy.CheckID {
+	var ids []policy.CheckID
+	for _, result := range results {
+		if !result.Allowed && result.Error == nil {
+			ids = append(ids, result.ID)
+		}
+	}
+	return ids
+}
+
+// recordEvaluation records the evaluation of a pod with the context of its request
+// if the Metrics implement metrics.ExemplarRecorder.
+func (a *Admission) recordEvaluation(ctx context.Context, decision metrics.Decision, lv api.LevelVersion, mode metrics.Mode, attrs api.Attributes) {
+	if recorder, ok := a.metrics().(metrics.ExemplarRecorder); ok {
+		recorder.RecordEvaluationWithContext(ctx, decision, lv, mode, attrs)
+		return
+	}
+	a.metrics().RecordEvaluation(decision, lv, mode, attrs)
+}
+
+// recordEvaluationLatency records the latency of the evaluation of a request with the context of the request,
+// if the Metrics implement metrics.LatencyRecorder.
+func (a *Admission) recordEvaluationLatency(ctx context.Context, response *admissionv1.AdmissionResponse, latency time.Duration, attrs api.Attributes) {
+	recorder, ok := a.metrics().(metrics.LatencyRecorder)
+	if !ok {
+		return
+	}
+	var decision metrics.Decision = metrics.DecisionAllow
+	if !response.Allowed {
+		decision = metrics.DecisionDeny
+	}
+	recorder.RecordEvaluationLatency(ctx, decision, latency, attrs)
+}
+
+// recordCheckViolations records the checks violated by a pod evaluated with a deny decision,
+// if the Metrics implement metrics.CheckViolationRecorder.
+func (a *Admission) recordCheckViolations(ctx context.Context, result policy.AggregateCheckResult, lv api.LevelVersion, mode metrics.Mode) {
+	recorder, ok := a.metrics().(metrics.CheckViolationRecorder)
+	if !ok {
+		return
+	}
+	checks := make([]policy.CheckID, 0, len(result.Violations))
+	for _, v := range result.Violations {
+		if v.Check != "" {
+			checks = append(checks, v.Check)
+		}
+	}
+	if exemplarRecorder, ok := a.metrics().(metrics.ExemplarRecorder); ok {
+		exemplarRecorder.RecordCheckViolationsWithContext(ctx, checks, lv, mode)
+		return
+	}
+	recorder.RecordCheckViolations(checks, lv, mode)
 }
 
 func (a *Admission) EvaluatePodsInNamespace(ctx context.Context, namespace string, enforce api.LevelVersion) []string {
+	return a.evaluatePodsInNamespace(ctx, namespace, enforce, nil)
+}
+
+// evaluatePodsInNamespace evaluates the pods of the namespace like EvaluatePodsInNamespace, exempting them from exemptChecks.
+func (a *Admission) evaluatePodsInNamespace(ctx context.Context, namespace string, enforce api.LevelVersion, exemptChecks []policy.CheckID) []string {
 	// start with the default timeout
 	timeout := a.namespacePodCheckTimeout
 	if deadline, ok := ctx.Deadline(); ok {
@@ -549,86 +976,59 @@ func (a *Admission) EvaluatePodsInNamespace(ctx context.Context, namespace strin
 	ctx, cancel := context.WithDeadline(ctx, deadline)
 	defer cancel()
 
-	pods, err := a.PodLister.ListPods(ctx, namespace)
+	audit, err := a.auditNamespacePods(ctx, namespace, enforce, exemptChecks, a.namespaceMaxPodsToCheck)
 	if err != nil {
 		klog.FromContext(ctx).Error(err, "failed to list pods", "namespace", namespace)
 		return []string{"failed to list pods while checking new PodSecurity enforce level"}
 	}
 
-	var (
-		warnings []string
-
-		podWarnings        []string
-		podWarningsToCount = make(map[string]podCount)
-		prioritizedPods    = a.prioritizePods(pods)
-	)
-
-	totalPods := len(prioritizedPods)
-	if len(prioritizedPods) > a.namespaceMaxPodsToCheck {
-		prioritizedPods = prioritizedPods[0:a.namespaceMaxPodsToCheck]
+	var warnings []string
+	if audit.CheckedPods < audit.TotalPods {
+		warnings = append(warnings, fmt.Sprintf("new PodSecurity enforce level only checked against the first %d of %d existing pods", audit.CheckedPods, audit.TotalPods))
 	}
 
-	checkedPods := len(prioritizedPods)
-	for i, pod := range prioritizedPods {
-		r := policy.AggregateCheckResults(a.Evaluator.EvaluatePod(enforce, &pod.ObjectMeta, &pod.Spec))
-		if !r.Allowed {
-			warning := r.ForbiddenReason()
-			c, seen := podWarningsToCount[warning]
-			if !seen {
-				c.podName = pod.Name
-				podWarnings = append(podWarnings, warning)
-			} else if pod.Name < c.podName {
-				c.podName = pod.Name
-			}
-			c.podCount++
-			podWarningsToCount[warning] = c
-		}
-		if err := ctx.Err(); err != nil { // deadline exceeded or context was cancelled
-			checkedPods = i + 1
-			break
-		}
-	}
-
-	if checkedPods < totalPods {
-		warnings = append(warnings, fmt.Sprintf("new PodSecurity enforce level only checked against the first %d of %d existing pods", checkedPods, totalPods))
-	}
-
-	if len(podWarnings) > 0 {
+	if len(audit.Violations) > 0 {
 		warnings = append(warnings, fmt.Sprintf("existing pods in namespace %q violate the new PodSecurity enforce level %q", namespace, enforce.String()))
 	}
 
-	// prepend pod names to warnings
-	decoratePodWarnings(podWarningsToCount, podWarnings)
-	// put warnings in a deterministic order
-	sort.Strings(podWarnings)
+	return append(warnings, a.NamespaceWarnings.violationWarnings(audit.Violations)...)
+}
 
-	return append(warnings, podWarnings...)
+// PolicyToEvaluate returns the policy evaluated for a namespace with the given labels,
+// with the enforce level & version raised to the configured floor.
+func (a *Admission) PolicyToEvaluate(labels map[string]string) (api.Policy, field.ErrorList) {
+	p, errs := a.labelPolicy(labels)
+	return applyFloor(p, a.enforceFloor), errs
 }
 
-// prefixes warnings with the pod names related to that warning
-func decoratePodWarnings(podWarningsToCount map[string]podCount, warnings []string) {
-	for i, warning := range warnings {
-		c := podWarningsToCount[warning]
-		switch c.podCount {
-		case 0:
-			// unexpected, just leave the warning alone
-		case 1:
-			warnings[i] = fmt.Sprintf("%s: %s", c.podName, warning)
-		case 2:
-			warnings[i] = fmt.Sprintf("%s (and 1 other pod): %s", c.podName, warning)
-		default:
-			warnings[i] = fmt.Sprintf("%s (and %d other pods): %s", c.podName, c.podCount-1, warning)
-		}
+// labelPolicy returns the policy set by the namespace labels and the defaults, ignoring the floor.
+func (a *Admission) labelPolicy(labels map[string]string) (api.Policy, field.ErrorList) {
+	if a.LenientLabelParsing {
+		p, _, errs := api.PolicyToEvaluateLenient(labels, a.defaultPolicy)
+		return p, errs
 	}
+	return api.PolicyToEvaluate(labels, a.defaultPolicy)
 }
 
-func (a *Admission) PolicyToEvaluate(labels map[string]string) (api.Policy, field.ErrorList) {
-	return api.PolicyToEvaluate(labels, a.defaultPolicy)
+// evaluatedPodTemplateFieldsChanged returns true if the pod controller update changes fields of the pod template
+// read by the checks (see policy.EvaluatedFieldsChanged), or if the old pod template cannot be extracted.
+func (a *Admission) evaluatedPodTemplateFieldsChanged(attrs api.Attributes, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec) bool {
+	oldObj, err := attrs.GetOldObject()
+	if err != nil || oldObj == nil {
+		return true
+	}
+	oldPodMetadata, oldPodSpec, err := a.PodSpecExtractor.ExtractPodSpec(oldObj)
+	if err != nil {
+		return true
+	}
+	return policy.EvaluatedFieldsChanged(oldPodMetadata, oldPodSpec, podMetadata, podSpec)
 }
 
 // isSignificantPodUpdate determines whether a pod update should trigger a policy evaluation.
 // Relevant mutable pod fields as of 1.21 are image annotations:
 // * https://github.com/kubernetes/kubernetes/blob/release-1.21/pkg/apis/core/validation/validation.go#L3947-L3949
+// In-place resizes of container resources and resizePolicy are not significant, and are only evaluated by the checks
+// reading resources (see scopeToResourceChecks), so they cannot deny admitted pods for their other fields.
 func isSignificantPodUpdate(pod, oldPod *corev1.Pod) bool {
 	// TODO: invert this logic to only allow specific update types.
 	if len(pod.Spec.Containers) != len(oldPod.Spec.Containers) {
@@ -647,22 +1047,7 @@ func isSignificantPodUpdate(pod, oldPod *corev1.Pod) bool {
 			return true
 		}
 	}
-	for _, c := range pod.Spec.EphemeralContainers {
-		var oldC *corev1.Container
-		for i, oc := range oldPod.Spec.EphemeralContainers {
-			if oc.Name == c.Name {
-				oldC = (*corev1.Container)(&oldPod.Spec.EphemeralContainers[i].EphemeralContainerCommon)
-				break
-			}
-		}
-		if oldC == nil {
-			return true // EphemeralContainer added
-		}
-		if isSignificantContainerUpdate((*corev1.Container)(&c.EphemeralContainerCommon), oldC) {
-			return true
-		}
-	}
-	return false
+	return len(updatedEphemeralContainers(pod, oldPod)) > 0
 }
 
 // isSignificantContainerUpdate determines whether a container update should trigger a policy evaluation.
@@ -670,29 +1055,54 @@ func isSignificantContainerUpdate(container, oldContainer *corev1.Container) boo
 	return container.Image != oldContainer.Image
 }
 
+// exemptionMatchers returns the matchers compiled by CompleteConfiguration,
+// or compiles the exemptions of the Configuration if it was not called.
+func (a *Admission) exemptionMatchers() *exemptionMatchers {
+	if a.exemptions != nil {
+		return a.exemptions
+	}
+	return newExemptionMatchers(a.Configuration.Exemptions)
+}
+
 func (a *Admission) exemptNamespace(namespace string) bool {
 	if len(namespace) == 0 {
 		return false
 	}
-	// TODO: consider optimizing to O(1) lookup
-	return containsString(namespace, a.Configuration.Exemptions.Namespaces)
+	return a.exemptionMatchers().namespaces.Matches(namespace)
 }
-func (a *Admission) exemptUser(username string) bool {
-	if len(username) == 0 {
-		return false
+func (a *Admission) exemptUser(attrs api.Attributes) bool {
+	extractor := a.IdentityExtractor
+	if extractor == nil {
+		extractor = UsernameIdentity
+	}
+	usernames := a.exemptionMatchers().usernames
+	for _, identity := range extractor.Identities(attrs) {
+		if len(identity) > 0 && usernames.Matches(identity) {
+			return true
+		}
 	}
-	// TODO: consider optimizing to O(1) lookup
-	return containsString(username, a.Configuration.Exemptions.Usernames)
+	return false
 }
 func (a *Admission) exemptRuntimeClass(runtimeClass *string) bool {
 	if runtimeClass == nil || len(*runtimeClass) == 0 {
 		return false
 	}
-	// TODO: consider optimizing to O(1) lookup
-	return containsString(*runtimeClass, a.Configuration.Exemptions.RuntimeClasses)
+	return a.exemptionMatchers().runtimeClasses.Matches(*runtimeClass)
 }
 
-// Filter and prioritize pods based on runtimeclass and uniqueness of the controller respectively for evaluation.
+// exemptServiceAccount returns true if the pods of the namespace running as the service account match
+// the serviceAccountNames exemptions. Pods without a service account name run as the default service account.
+func (a *Admission) exemptServiceAccount(namespace, serviceAccountName string) bool {
+	if len(namespace) == 0 || a.Configuration == nil || len(a.Configuration.Exemptions.ServiceAccountNames) == 0 {
+		return false
+	}
+	if len(serviceAccountName) == 0 {
+		serviceAccountName = "default"
+	}
+	return a.exemptionMatchers().serviceAccountNames.Matches(namespace + "/" + serviceAccountName)
+}
+
+// Filter and prioritize pods based on runtimeclass and service account, and uniqueness of the controller respectively for evaluation.
 // The input slice is modified in place and should not be reused.
 func (a *Admission) prioritizePods(pods []*corev1.Pod) []*corev1.Pod {
 	// accumulate the list of prioritized pods in-place to avoid double-allocating
@@ -705,6 +1115,10 @@ func (a *Admission) prioritizePods(pods []*corev1.Pod) []*corev1.Pod {
 		if a.exemptRuntimeClass(pod.Spec.RuntimeClassName) {
 			continue
 		}
+		// short-circuit on exempt service account
+		if a.exemptServiceAccount(pod.Namespace, pod.Spec.ServiceAccountName) {
+			continue
+		}
 		// short-circuit if pod from the same controller is evaluated
 		podOwnerControllerRef := metav1.GetControllerOfNoCopy(pod)
 		if podOwnerControllerRef == nil {
@@ -721,6 +1135,64 @@ func (a *Admission) prioritizePods(pods []*corev1.Pod) []*corev1.Pod {
 	return append(prioritizedPods, duplicateReplicatedPods...)
 }
 
+// filterNamespacePods removes the pods excluded from namespace evaluation by the NamespaceEvaluation options.
+// The input slice is modified in place and should not be reused.
+func (a *Admission) filterNamespacePods(pods []*corev1.Pod, now time.Time) []*corev1.Pod {
+	opts := a.NamespaceEvaluation
+	if !opts.SkipCompletedPods && opts.MaxPodAge == 0 {
+		return pods
+	}
+	filtered := pods[:0]
+	for _, pod := range pods {
+		if opts.SkipCompletedPods && (pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed) {
+			continue
+		}
+		if opts.MaxPodAge > 0 && now.Sub(pod.CreationTimestamp.Time) > opts.MaxPodAge {
+			continue
+		}
+		filtered = append(filtered, pod)
+	}
+	return filtered
+}
+
+// countReplicas returns the number of pods owned by each controller.
+func countReplicas(pods []*corev1.Pod) map[types.UID]int {
+	replicas := make(map[types.UID]int)
+	for _, pod := range pods {
+		if ref := metav1.GetControllerOfNoCopy(pod); ref != nil {
+			replicas[ref.UID]++
+		}
+	}
+	return replicas
+}
+
+// countDuplicateReplicas returns the number of pods from controllers that already have a pod earlier in the list.
+// Since prioritizePods orders duplicate replicas last, these are the trailing pods of the prioritized list.
+func countDuplicateReplicas(prioritizedPods []*corev1.Pod) int {
+	seen := make(map[types.UID]bool)
+	duplicates := 0
+	for _, pod := range prioritizedPods {
+		if ref := metav1.GetControllerOfNoCopy(pod); ref != nil {
+			if seen[ref.UID] {
+				duplicates++
+			}
+			seen[ref.UID] = true
+		}
+	}
+	return duplicates
+}
+
+// podWeight returns the number of pods a violation of the given pod counts for.
+func podWeight(pod *corev1.Pod, replicas map[types.UID]int) int {
+	if replicas == nil {
+		return 1
+	}
+	if ref := metav1.GetControllerOfNoCopy(pod); ref != nil && replicas[ref.UID] > 0 {
+		return replicas[ref.UID]
+	}
+	return 1
+}
+
 func containsString(needle string, haystack []string) bool {
 	for _, s := range haystack {
 		if s == needle {
@@ -18,10 +18,14 @@ package admission
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"math/rand"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -30,18 +34,26 @@ import (
 
 	admissionv1 "k8s.io/api/admission/v1"
 	appsv1 "k8s.io/api/apps/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/component-base/featuregate"
 	"k8s.io/klog/v2/ktesting"
 	admissionapi "k8s.io/pod-security-admission/admission/api"
 	"k8s.io/pod-security-admission/admission/api/load"
 	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/export"
 	"k8s.io/pod-security-admission/metrics"
 	"k8s.io/pod-security-admission/policy"
 	"k8s.io/pod-security-admission/test"
@@ -238,6 +250,8 @@ func TestValidateNamespace(t *testing.T) {
 		subresource string
 		// labels for the new namespace
 		newLabels map[string]string
+		// annotations for the new namespace
+		newAnnotations map[string]string
 		// labels for the old namespace (only used if update=true)
 		oldLabels map[string]string
 		// list of pods to return
@@ -246,6 +260,12 @@ func TestValidateNamespace(t *testing.T) {
 		delayList time.Duration
 		// time to sleep while evaluating
 		delayEvaluation time.Duration
+		// handling of unsafe enforce level updates
+		rolloutGuard NamespaceRolloutGuard
+		// existing pods evaluated on enforce level updates
+		namespaceEvaluation NamespaceEvaluationOptions
+		// confirmation required to downgrade to privileged
+		privilegedConfirmation admissionapi.PodSecurityPrivilegedConfirmation
 
 		expectAllowed  bool
 		expectError    string
@@ -501,6 +521,144 @@ func TestValidateNamespace(t *testing.T) {
 				`uniquepod2: uniquemessage2`,
 			},
 		},
+		{
+			name:           "rollout guard warns on level jump without audit",
+			newLabels:      map[string]string{api.EnforceLevelLabel: string(api.LevelRestricted)},
+			oldLabels:      map[string]string{api.EnforceLevelLabel: string(api.LevelPrivileged)},
+			rolloutGuard:   NamespaceRolloutGuardWarn,
+			expectAllowed:  true,
+			expectListPods: true,
+			expectEvaluate: api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()},
+			expectWarnings: []string{
+				`PodSecurity enforce level raised from "privileged" to "restricted", enforce level should be raised one level at a time`,
+				`PodSecurity enforce level raised to "restricted" without auditing at "restricted" first, set the pod-security.kubernetes.io/audit label before the pod-security.kubernetes.io/enforce label`,
+				`existing pods in namespace "test" violate the new PodSecurity enforce level "restricted:latest"`,
+				"noruntimeclasspod (and 2 other pods): message",
+				"runtimeclass3pod: message, message2",
+			},
+		},
+		{
+			name:           "rollout guard denies level jump",
+			newLabels:      map[string]string{api.EnforceLevelLabel: string(api.LevelRestricted), api.AuditLevelLabel: string(api.LevelRestricted)},
+			oldLabels:      map[string]string{api.EnforceLevelLabel: string(api.LevelPrivileged), api.AuditLevelLabel: string(api.LevelRestricted)},
+			rolloutGuard:   NamespaceRolloutGuardDeny,
+			expectAllowed:  false,
+			expectError:    `enforce level should be raised one level at a time`,
+			expectListPods: false,
+		},
+		{
+			name:           "rollout guard denies skipped audit",
+			newLabels:      map[string]string{api.EnforceLevelLabel: string(api.LevelRestricted), api.AuditLevelLabel: string(api.LevelRestricted)},
+			oldLabels:      map[string]string{api.EnforceLevelLabel: string(api.LevelBaseline)},
+			rolloutGuard:   NamespaceRolloutGuardDeny,
+			expectAllowed:  false,
+			expectError:    `without auditing at "restricted" first`,
+			expectListPods: false,
+		},
+		{
+			name:           "rollout guard allows audited one level update",
+			newLabels:      map[string]string{api.EnforceLevelLabel: string(api.LevelRestricted), api.AuditLevelLabel: string(api.LevelRestricted)},
+			oldLabels:      map[string]string{api.EnforceLevelLabel: string(api.LevelBaseline), api.AuditLevelLabel: string(api.LevelRestricted)},
+			rolloutGuard:   NamespaceRolloutGuardDeny,
+			expectAllowed:  true,
+			expectListPods: true,
+			expectEvaluate: api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()},
+			expectWarnings: []string{
+				`existing pods in namespace "test" violate the new PodSecurity enforce level "restricted:latest"`,
+				"noruntimeclasspod (and 2 other pods): message",
+				"runtimeclass3pod: message, message2",
+			},
+		},
+		{
+			name:                   "privileged downgrade without confirmation",
+			newLabels:              map[string]string{api.EnforceLevelLabel: string(api.LevelPrivileged)},
+			oldLabels:              map[string]string{api.EnforceLevelLabel: string(api.LevelRestricted)},
+			privilegedConfirmation: admissionapi.PodSecurityPrivilegedConfirmation{Required: true},
+			expectAllowed:          false,
+			expectError:            `PodSecurity enforce level lowered from "restricted" to "privileged" without confirmation`,
+			expectListPods:         false,
+		},
+		{
+			name:                   "privileged downgrade with confirmation",
+			newLabels:              map[string]string{api.EnforceLevelLabel: string(api.LevelPrivileged)},
+			newAnnotations:         map[string]string{api.ConfirmPrivilegedAnnotation: "true"},
+			oldLabels:              map[string]string{api.EnforceLevelLabel: string(api.LevelBaseline)},
+			privilegedConfirmation: admissionapi.PodSecurityPrivilegedConfirmation{Required: true},
+			expectAllowed:          true,
+			expectListPods:         false,
+		},
+		{
+			name:                   "privileged downgrade of allowed namespace",
+			newLabels:              map[string]string{api.EnforceLevelLabel: string(api.LevelPrivileged)},
+			oldLabels:              map[string]string{api.EnforceLevelLabel: string(api.LevelRestricted)},
+			privilegedConfirmation: admissionapi.PodSecurityPrivilegedConfirmation{Required: true, AllowedNamespaces: []string{"test"}},
+			expectAllowed:          true,
+			expectListPods:         false,
+		},
+		{
+			name:           "privileged downgrade without required confirmation",
+			newLabels:      map[string]string{api.EnforceLevelLabel: string(api.LevelPrivileged)},
+			oldLabels:      map[string]string{api.EnforceLevelLabel: string(api.LevelRestricted)},
+			expectAllowed:  true,
+			expectListPods: false,
+		},
+		{
+			name:                "skip completed pods",
+			newLabels:           map[string]string{api.EnforceLevelLabel: string(api.LevelRestricted)},
+			oldLabels:           map[string]string{api.EnforceLevelLabel: string(api.LevelBaseline)},
+			namespaceEvaluation: NamespaceEvaluationOptions{SkipCompletedPods: true},
+			pods: []*corev1.Pod{
+				{ObjectMeta: metav1.ObjectMeta{Name: "succeededpod", Annotations: map[string]string{"error": "message"}}, Status: corev1.PodStatus{Phase: corev1.PodSucceeded}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "failedpod", Annotations: map[string]string{"error": "message"}}, Status: corev1.PodStatus{Phase: corev1.PodFailed}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "runningpod", Annotations: map[string]string{"error": "message"}}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+			},
+			expectAllowed:  true,
+			expectListPods: true,
+			expectEvaluate: api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()},
+			expectWarnings: []string{
+				`existing pods in namespace "test" violate the new PodSecurity enforce level "restricted:latest"`,
+				`runningpod: message`,
+			},
+		},
+		{
+			name:                "skip old pods",
+			newLabels:           map[string]string{api.EnforceLevelLabel: string(api.LevelRestricted)},
+			oldLabels:           map[string]string{api.EnforceLevelLabel: string(api.LevelBaseline)},
+			namespaceEvaluation: NamespaceEvaluationOptions{MaxPodAge: time.Hour},
+			pods: []*corev1.Pod{
+				{ObjectMeta: metav1.ObjectMeta{Name: "oldpod", Annotations: map[string]string{"error": "message"}, CreationTimestamp: metav1.NewTime(time.Now().Add(-2 * time.Hour))}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "newpod", Annotations: map[string]string{"error": "message"}, CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Minute))}},
+			},
+			expectAllowed:  true,
+			expectListPods: true,
+			expectEvaluate: api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()},
+			expectWarnings: []string{
+				`existing pods in namespace "test" violate the new PodSecurity enforce level "restricted:latest"`,
+				`newpod: message`,
+			},
+		},
+		{
+			name:                "weight by replicas",
+			newLabels:           map[string]string{api.EnforceLevelLabel: string(api.LevelRestricted)},
+			oldLabels:           map[string]string{api.EnforceLevelLabel: string(api.LevelBaseline)},
+			namespaceEvaluation: NamespaceEvaluationOptions{WeightByReplicas: true},
+			pods: []*corev1.Pod{
+				{ObjectMeta: metav1.ObjectMeta{Name: "replicaset1pod1", Annotations: map[string]string{"error": "replicaset1error"}, OwnerReferences: []metav1.OwnerReference{{UID: types.UID("1"), Controller: pointer.Bool(true)}}}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "replicaset1pod2", Annotations: map[string]string{"error": "replicaset1error"}, OwnerReferences: []metav1.OwnerReference{{UID: types.UID("1"), Controller: pointer.Bool(true)}}}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "replicaset1pod3", Annotations: map[string]string{"error": "replicaset1error"}, OwnerReferences: []metav1.OwnerReference{{UID: types.UID("1"), Controller: pointer.Bool(true)}}}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "replicaset1pod4", Annotations: map[string]string{"error": "replicaset1error"}, OwnerReferences: []metav1.OwnerReference{{UID: types.UID("1"), Controller: pointer.Bool(true)}}}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "replicaset1pod5", Annotations: map[string]string{"error": "replicaset1error"}, OwnerReferences: []metav1.OwnerReference{{UID: types.UID("1"), Controller: pointer.Bool(true)}}}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "uniquepod1", Annotations: map[string]string{"error": "uniquemessage1"}}},
+			},
+			expectAllowed:  true,
+			expectListPods: true,
+			expectEvaluate: api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()},
+			expectWarnings: []string{
+				`existing pods in namespace "test" violate the new PodSecurity enforce level "restricted:latest"`,
+				`replicaset1pod1 (and 4 other pods): replicaset1error`,
+				`uniquepod1: uniquemessage1`,
+			},
+		},
 	}
 
 	for _, tc := range testcases {
@@ -508,8 +666,9 @@ func TestValidateNamespace(t *testing.T) {
 			_, ctx := ktesting.NewTestContext(t)
 			newObject := &corev1.Namespace{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:   "test",
-					Labels: tc.newLabels,
+					Name:        "test",
+					Labels:      tc.newLabels,
+					Annotations: tc.newAnnotations,
 				},
 			}
 			var operation = admissionv1.Create
@@ -582,9 +741,12 @@ func TestValidateNamespace(t *testing.T) {
 						Namespaces:     tc.exemptNamespaces,
 						RuntimeClasses: tc.exemptRuntimeClasses,
 					},
+					PrivilegedConfirmation: tc.privilegedConfirmation,
 				},
-				Metrics:       &FakeRecorder{},
-				defaultPolicy: defaultPolicy,
+				Metrics:               &FakeRecorder{},
+				NamespaceRolloutGuard: tc.rolloutGuard,
+				NamespaceEvaluation:   tc.namespaceEvaluation,
+				defaultPolicy:         defaultPolicy,
 
 				namespacePodCheckTimeout: time.Second,
 				namespaceMaxPodsToCheck:  4,
@@ -630,6 +792,7 @@ func TestValidatePodAndController(t *testing.T) {
 
 		exemptUser         = "exempt-user"
 		exemptRuntimeClass = "exempt-runtimeclass"
+		exemptSA           = "exempt-sa"
 
 		podName = "test-pod"
 	)
@@ -652,11 +815,26 @@ func TestValidatePodAndController(t *testing.T) {
 	exemptRCPod := *privilegedPod.DeepCopy()
 	exemptRCPod.Spec.RuntimeClassName = pointer.String(exemptRuntimeClass)
 
+	exemptSAPod := *privilegedPod.DeepCopy()
+	exemptSAPod.Spec.ServiceAccountName = exemptSA
+
 	tolerantPod := *privilegedPod.DeepCopy()
 	tolerantPod.Spec.Tolerations = []corev1.Toleration{{
 		Operator: corev1.TolerationOpExists,
 	}}
 
+	resizedPod := *privilegedPod.DeepCopy()
+	resizedPod.Spec.Containers[0].Resources = corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+		Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+	}
+	resizedPod.Spec.Containers[0].ResizePolicy = []corev1.ContainerResizePolicy{{ResourceName: corev1.ResourceCPU, RestartPolicy: corev1.NotRequired}}
+	resizedPod.Status.Resize = corev1.PodResizeStatusInProgress
+	resizedPod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:               resizedPod.Spec.Containers[0].Name,
+		AllocatedResources: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+	}}
+
 	differentPrivilegedPod := *privilegedPod.DeepCopy()
 	differentPrivilegedPod.Spec.Containers[0].Image = "https://example.com/a-different-image"
 
@@ -701,6 +879,7 @@ func TestValidatePodAndController(t *testing.T) {
 	config.Exemptions.Namespaces = []string{exemptNs}
 	config.Exemptions.RuntimeClasses = []string{exemptRuntimeClass}
 	config.Exemptions.Usernames = []string{exemptUser}
+	config.Exemptions.ServiceAccountNames = []string{restrictedNs + "/exempt-*"}
 
 	evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
 	assert.NoError(t, err)
@@ -766,6 +945,23 @@ func TestValidatePodAndController(t *testing.T) {
 			expectAllowed: true,
 			expectExempt:  true,
 		},
+		{
+			desc:          "exempt serviceAccount",
+			namespace:     restrictedNs,
+			pod:           exemptSAPod.DeepCopy(),
+			expectAllowed: true,
+			expectExempt:  true,
+		},
+		{
+			desc:          "exempt serviceAccount in other namespace",
+			namespace:     baselineNs,
+			pod:           exemptSAPod.DeepCopy(),
+			expectAllowed: false,
+			expectReason:  metav1.StatusReasonForbidden,
+			expectEnforce: api.LevelBaseline,
+			expectWarning: api.LevelBaseline,
+			expectAudit:   api.LevelBaseline,
+		},
 		{
 			desc:          "namespace not found",
 			namespace:     "missing-ns",
@@ -836,6 +1032,28 @@ func TestValidatePodAndController(t *testing.T) {
 			expectAllowed:  true,
 			skipDeployment: true, // Updates aren't special cased for controller resources.
 		},
+		{
+			// only evaluated by the checks reading resources
+			desc:           "resize update",
+			namespace:      restrictedNs,
+			operation:      admissionv1.Update,
+			pod:            resizedPod.DeepCopy(),
+			oldPod:         privilegedPod.DeepCopy(),
+			expectAllowed:  true,
+			expectEnforce:  api.LevelRestricted,
+			skipDeployment: true, // Updates aren't special cased for controller resources.
+		},
+		{
+			desc:           "resize subresource",
+			namespace:      restrictedNs,
+			operation:      admissionv1.Update,
+			pod:            resizedPod.DeepCopy(),
+			oldPod:         privilegedPod.DeepCopy(),
+			subresource:    "resize",
+			expectAllowed:  true,
+			expectEnforce:  api.LevelRestricted,
+			skipDeployment: true, // Deployments have no resize subresource.
+		},
 		{
 			desc:          "significant update denied",
 			namespace:     restrictedNs,
@@ -1027,6 +1245,7 @@ func TestValidatePodAndController(t *testing.T) {
 				record := MetricsRecord{podName, metrics.DecisionAllow, tc.expectEnforce, metrics.ModeEnforce}
 				if !tc.expectAllowed {
 					record.EvalDecision = metrics.DecisionDeny
+					expectedAuditAnnotationKeys = append(expectedAuditAnnotationKeys, "enforce-policy-source")
 				}
 				expectedEvaluations = append(expectedEvaluations, record)
 			}
@@ -1035,7 +1254,7 @@ func TestValidatePodAndController(t *testing.T) {
 			}
 			if tc.expectAudit != "" {
 				expectedEvaluations = append(expectedEvaluations, MetricsRecord{podName, metrics.DecisionDeny, tc.expectAudit, metrics.ModeAudit})
-				expectedAuditAnnotationKeys = append(expectedAuditAnnotationKeys, "audit-violations")
+				expectedAuditAnnotationKeys = append(expectedAuditAnnotationKeys, "audit-violations", "audit-violation-severities")
 			}
 			if tc.expectError {
 				expectedAuditAnnotationKeys = append(expectedAuditAnnotationKeys, "error")
@@ -1060,6 +1279,160 @@ func TestValidatePodAndController(t *testing.T) {
 	}
 }
 
+func TestUnrelaxedUserNamespaceWarning(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	labels := map[string]string{api.EnforceLevelLabel: "restricted", api.WarnLevelLabel: "restricted"}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "ns"},
+		Spec: corev1.PodSpec{
+			HostUsers: pointer.Bool(false),
+			SecurityContext: &corev1.PodSecurityContext{
+				SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+			},
+			Containers: []corev1.Container{{
+				Name: "a",
+				SecurityContext: &corev1.SecurityContext{
+					AllowPrivilegeEscalation: pointer.Bool(false),
+					Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+				},
+			}},
+		},
+	}
+	attrs := &api.AttributesRecord{
+		Name:      "test-pod",
+		Namespace: "ns",
+		Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+		Operation: admissionv1.Create,
+		Object:    pod,
+	}
+	newAdmission := func(t *testing.T, recorder metrics.Recorder, opts ...policy.Option) *Admission {
+		evaluator, err := policy.NewEvaluator(policy.DefaultChecks(), opts...)
+		require.NoError(t, err)
+		a := &Admission{
+			PodLister:       &testPodLister{},
+			Evaluator:       evaluator,
+			Configuration:   config,
+			Metrics:         recorder,
+			NamespaceGetter: testNamespaceGetter{"ns": {ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: labels}}},
+		}
+		require.NoError(t, a.CompleteConfiguration())
+		require.NoError(t, a.ValidateConfiguration())
+		return a
+	}
+
+	recorder := &unrelaxedUserNamespacePodRecorder{}
+	response := newAdmission(t, recorder).Validate(ctx, attrs)
+	assert.False(t, response.Allowed)
+	assert.Equal(t, []string{
+		"pod sets hostUsers=false, but PodSecurity check runAsNonRoot is not relaxed for pods in user namespaces, since the UserNamespacesPodSecurityStandards feature is disabled",
+	}, response.Warnings)
+	assert.Equal(t, []string{"test-pod"}, recorder.pods)
+
+	// no warning once relaxed
+	gate := featuregate.NewFeatureGate()
+	require.NoError(t, policy.AddFeatureGates(gate))
+	require.NoError(t, gate.SetFromMap(map[string]bool{string(policy.UserNamespacesPodSecurityStandards): true}))
+	recorder = &unrelaxedUserNamespacePodRecorder{}
+	response = newAdmission(t, recorder, policy.WithFeatureGate(gate)).Validate(ctx, attrs)
+	assert.True(t, response.Allowed)
+	assert.Empty(t, response.Warnings)
+	assert.Empty(t, recorder.pods)
+}
+
+func TestViolationSeveritiesAuditAnnotation(t *testing.T) {
+	result := policy.AggregateCheckResult{Violations: []policy.CheckViolation{
+		{Check: "runAsNonRoot", Severity: policy.SeverityMedium},
+		{Check: "privileged", Severity: policy.SeverityCritical},
+		{Check: "hostPathVolumes", Severity: policy.SeverityCritical},
+		{Check: "example.com/unclassified"},
+		{Severity: policy.SeverityHigh},
+	}}
+	assert.Equal(t, "critical=privileged,hostPathVolumes; medium=runAsNonRoot", violationSeveritiesAuditAnnotation(result))
+	assert.Empty(t, violationSeveritiesAuditAnnotation(policy.AggregateCheckResult{}))
+}
+
+func TestAuditViolationsDetail(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks(), policy.WithFieldErrors())
+	require.NoError(t, err)
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	a := &Admission{
+		PodLister:     &testPodLister{},
+		Evaluator:     evaluator,
+		Configuration: config,
+		Metrics:       &FakeRecorder{},
+		NamespaceGetter: testNamespaceGetter{
+			"baseline": {ObjectMeta: metav1.ObjectMeta{Name: "baseline", Labels: map[string]string{
+				api.AuditLevelLabel:   string(api.LevelBaseline),
+				api.AuditVersionLabel: "v1.29",
+			}}},
+		},
+		AuditViolationsDetail: true,
+	}
+	require.NoError(t, a.CompleteConfiguration())
+	require.NoError(t, a.ValidateConfiguration())
+
+	response := a.Validate(ctx, &api.AttributesRecord{
+		Name:      "test-pod",
+		Namespace: "baseline",
+		Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+		Operation: admissionv1.Create,
+		Object: &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "baseline"},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "a"},
+				{Name: "b", SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(true)}},
+			}},
+		},
+	})
+	require.True(t, response.Allowed)
+	require.Contains(t, response.AuditAnnotations, api.AuditViolationsDetailAnnotationKey)
+	detail, err := api.ParseAuditViolationsDetail(response.AuditAnnotations[api.AuditViolationsDetailAnnotationKey])
+	require.NoError(t, err)
+	assert.Equal(t, api.AuditViolationsDetail{
+		SchemaVersion: api.AuditViolationsDetailSchemaVersion,
+		Policy:        "baseline:v1.29",
+		Violations: []api.AuditViolation{{
+			Check:      "privileged",
+			Reason:     "privileged",
+			Severity:   string(policy.SeverityCritical),
+			Containers: []string{"b"},
+			Fields:     []string{"spec.containers[1].securityContext.privileged"},
+		}},
+	}, detail)
+
+	a.AuditViolationsDetail = false
+	response = a.Validate(ctx, &api.AttributesRecord{
+		Name:      "test-pod",
+		Namespace: "baseline",
+		Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+		Operation: admissionv1.Create,
+		Object: &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "baseline"},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "b", SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(true)}},
+			}},
+		},
+	})
+	assert.NotEmpty(t, response.AuditAnnotations[api.AuditViolationsAnnotationKey])
+	assert.NotContains(t, response.AuditAnnotations, api.AuditViolationsDetailAnnotationKey)
+}
+
+type unrelaxedUserNamespacePodRecorder struct {
+	FakeRecorder
+	pods []string
+}
+
+func (r *unrelaxedUserNamespacePodRecorder) RecordUnrelaxedUserNamespacePod(attrs api.Attributes) {
+	r.pods = append(r.pods, attrs.GetName())
+}
+
 type FakeRecorder struct {
 	evaluations []MetricsRecord
 	exemptions  []MetricsRecord
@@ -1268,3 +1641,2881 @@ func (a *testAttributes) GetOldObject() (runtime.Object, error) {
 		return a.AttributesRecord.GetOldObject()
 	}
 }
+
+func TestResultFromResponse(t *testing.T) {
+	attrs := &api.AttributesRecord{
+		Name:     "test",
+		Kind:     schema.GroupVersionKind{Version: "v1", Kind: "Namespace"},
+		Resource: schema.GroupVersionResource{Version: "v1", Resource: "namespaces"},
+	}
+
+	allowed := ResultFromResponse(sharedAllowedByUserExemptionResponse)
+	assert.True(t, allowed.Allowed())
+	assert.Empty(t, allowed.Errors)
+	assert.Equal(t, map[string]string{api.ExemptionReasonAnnotationKey: "user"}, allowed.AuditAnnotations)
+	allowed.AuditAnnotations["mutated"] = "true"
+	assert.NotContains(t, sharedAllowedByUserExemptionResponse.AuditAnnotations, "mutated", "shared response must not be mutated")
+
+	forbidden := forbiddenResponse(attrs, fmt.Errorf("violation"))
+	forbidden.Warnings = []string{"warning"}
+	denied := ResultFromResponse(forbidden)
+	assert.Equal(t, DecisionDeny, denied.Decision)
+	assert.Equal(t, []string{"warning"}, denied.Warnings)
+	require.Len(t, denied.Errors, 1)
+	assert.True(t, apierrors.IsForbidden(denied.Errors[0]))
+	assert.Equal(t, metav1.StatusReasonForbidden, denied.Status.Reason)
+
+	invalid := ResultFromResponse(invalidResponse(attrs, field.ErrorList{
+		field.Invalid(field.NewPath("metadata", "labels", api.EnforceLevelLabel), "foo", "must be one of privileged, baseline, restricted"),
+		field.Invalid(field.NewPath("metadata", "labels", api.AuditLevelLabel), "bar", "must be one of privileged, baseline, restricted"),
+	}))
+	assert.Equal(t, DecisionDeny, invalid.Decision)
+	assert.Len(t, invalid.Errors, 2)
+	assert.Contains(t, invalid.Errors[0].Error(), api.EnforceLevelLabel)
+}
+
+func TestEvaluatePodChecksSchemaVersion(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	attrs := &api.AttributesRecord{
+		Name:      "test-pod",
+		Namespace: "test-ns",
+		Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+		Operation: admissionv1.Create,
+	}
+	nsPolicy := api.Policy{
+		Enforce: api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()},
+		Audit:   api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()},
+		Warn:    api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()},
+	}
+	a := &Admission{
+		Evaluator: &testEvaluator{},
+		Metrics:   &FakeRecorder{},
+	}
+
+	response := a.EvaluatePod(ctx, nsPolicy, nil, &metav1.ObjectMeta{}, &corev1.PodSpec{}, attrs, true)
+	assert.NotContains(t, response.AuditAnnotations, api.ChecksSchemaVersionAnnotationKey)
+
+	a.ChecksSchemaVersion = "0123456789abcdef"
+	response = a.EvaluatePod(ctx, nsPolicy, nil, &metav1.ObjectMeta{}, &corev1.PodSpec{}, attrs, true)
+	assert.Equal(t, "0123456789abcdef", response.AuditAnnotations[api.ChecksSchemaVersionAnnotationKey])
+}
+
+type testViolationRecorder struct {
+	recorded []api.LevelVersion
+}
+
+func (r *testViolationRecorder) RecordViolation(ctx context.Context, lv api.LevelVersion, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, attrs api.Attributes) {
+	r.recorded = append(r.recorded, lv)
+}
+
+func TestEvaluatePodViolationRecorder(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	attrs := &api.AttributesRecord{
+		Name:      "test-pod",
+		Namespace: "test-ns",
+		Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+		Operation: admissionv1.Create,
+	}
+	baseline := api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}
+	restricted := api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()}
+	violating := &metav1.ObjectMeta{Annotations: map[string]string{"error": "violation"}}
+
+	recorder := &testViolationRecorder{}
+	a := &Admission{
+		Evaluator:         &testEvaluator{},
+		Metrics:           &FakeRecorder{},
+		ViolationRecorder: recorder,
+	}
+
+	a.EvaluatePod(ctx, api.Policy{Enforce: baseline, Audit: baseline, Warn: baseline}, nil, &metav1.ObjectMeta{}, &corev1.PodSpec{}, attrs, true)
+	assert.Empty(t, recorder.recorded, "allowed pods should not be recorded")
+
+	a.EvaluatePod(ctx, api.Policy{Enforce: baseline, Audit: restricted, Warn: restricted}, nil, violating, &corev1.PodSpec{}, attrs, true)
+	a.EvaluatePod(ctx, api.Policy{Enforce: baseline, Audit: restricted, Warn: restricted}, nil, violating, &corev1.PodSpec{}, attrs, false)
+	assert.Equal(t, []api.LevelVersion{baseline, restricted}, recorder.recorded)
+}
+
+func TestEnforcementActionAnnotate(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	nsGetter := testNamespaceGetter{
+		"restricted": {ObjectMeta: metav1.ObjectMeta{Name: "restricted", Labels: map[string]string{api.EnforceLevelLabel: string(api.LevelRestricted)}}},
+	}
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	a := &Admission{
+		PodLister:         &testPodLister{},
+		Evaluator:         &testEvaluator{},
+		Configuration:     config,
+		Metrics:           &FakeRecorder{},
+		NamespaceGetter:   nsGetter,
+		EnforcementAction: EnforcementActionAnnotate,
+	}
+	require.NoError(t, a.CompleteConfiguration())
+	require.NoError(t, a.ValidateConfiguration())
+
+	podAttrs := func(annotations map[string]string) *api.AttributesRecord {
+		return &api.AttributesRecord{
+			Name:      "test-pod",
+			Namespace: "restricted",
+			Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+			Operation: admissionv1.Create,
+			Object:    &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "restricted", Annotations: annotations}},
+		}
+	}
+
+	violating := podAttrs(map[string]string{"error": "host ports"})
+	response := a.Validate(ctx, violating)
+	assert.True(t, response.Allowed)
+	assert.Equal(t, []string{`admitted with violations of PodSecurity "restricted:latest": host ports`}, response.Warnings)
+
+	response = a.MutatePod(ctx, violating)
+	assert.True(t, response.Allowed)
+	if assert.NotNil(t, response.PatchType) {
+		assert.Equal(t, admissionv1.PatchTypeJSONPatch, *response.PatchType)
+	}
+	assert.JSONEq(t, `[{"op":"add","path":"/metadata/annotations/pod-security.kubernetes.io~1violations","value":"restricted:latest: host ports"}]`, string(response.Patch))
+
+	response = a.MutatePod(ctx, podAttrs(nil))
+	assert.Nil(t, response.Patch, "allowed pods should not be patched")
+
+	response = a.MutatePod(ctx, podAttrs(map[string]string{api.ViolationsAnnotation: "stale"}))
+	assert.JSONEq(t, `[{"op":"remove","path":"/metadata/annotations/pod-security.kubernetes.io~1violations"}]`, string(response.Patch))
+
+	a.EnforcementAction = EnforcementActionDeny
+	assert.False(t, a.Validate(ctx, violating).Allowed)
+	assert.Nil(t, a.MutatePod(ctx, violating).Patch)
+}
+
+func TestShortCircuitEnforce(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+	require.NoError(t, err)
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	nsGetter := testNamespaceGetter{
+		"restricted": {ObjectMeta: metav1.ObjectMeta{Name: "restricted", Labels: map[string]string{
+			api.EnforceLevelLabel: string(api.LevelRestricted),
+			api.AuditLevelLabel:   string(api.LevelRestricted),
+		}}},
+	}
+	podAttrs := func(spec corev1.PodSpec) *api.AttributesRecord {
+		return &api.AttributesRecord{
+			Name:      "test-pod",
+			Namespace: "restricted",
+			Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+			Operation: admissionv1.Create,
+			Object:    &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "restricted"}, Spec: spec},
+		}
+	}
+	violating := podAttrs(corev1.PodSpec{
+		HostNetwork: true,
+		Containers:  []corev1.Container{{Name: "c", SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(true)}}},
+	})
+	compliant := podAttrs(corev1.PodSpec{
+		SecurityContext: &corev1.PodSecurityContext{
+			RunAsNonRoot:   pointer.Bool(true),
+			SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+		},
+		Containers: []corev1.Container{{Name: "c", SecurityContext: &corev1.SecurityContext{
+			AllowPrivilegeEscalation: pointer.Bool(false),
+			Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+		}}},
+	})
+
+	a := &Admission{
+		PodLister:           &testPodLister{},
+		Evaluator:           evaluator,
+		Configuration:       config,
+		Metrics:             &FakeRecorder{},
+		NamespaceGetter:     nsGetter,
+		ShortCircuitEnforce: true,
+	}
+	require.NoError(t, a.CompleteConfiguration())
+	require.NoError(t, a.ValidateConfiguration())
+
+	response := a.Validate(ctx, violating)
+	assert.False(t, response.Allowed)
+	// the enforce evaluation stops at the first violated check
+	assert.True(t, strings.HasSuffix(response.Result.Message, `: host namespaces (hostNetwork=true)`), response.Result.Message)
+	// the audit policy is evaluated against all the checks
+	assert.Contains(t, response.AuditAnnotations[api.AuditViolationsAnnotationKey], "host namespaces (hostNetwork=true)")
+	assert.Contains(t, response.AuditAnnotations[api.AuditViolationsAnnotationKey], "privileged (container \"c\" must not set securityContext.privileged=true)")
+
+	assert.True(t, a.Validate(ctx, compliant).Allowed)
+
+	// all the enforce violations are reported when admitting violating pods
+	a.EnforcementAction = EnforcementActionAnnotate
+	response = a.Validate(ctx, violating)
+	assert.True(t, response.Allowed)
+	assert.Contains(t, response.Warnings[0], "privileged")
+}
+
+// blockingEvaluator blocks evaluations until unblocked is closed.
+type blockingEvaluator struct {
+	testEvaluator
+	unblocked chan struct{}
+}
+
+func (e *blockingEvaluator) EvaluatePod(lv api.LevelVersion, meta *metav1.ObjectMeta, spec *corev1.PodSpec) []policy.CheckResult {
+	<-e.unblocked
+	return e.testEvaluator.EvaluatePod(lv, meta, spec)
+}
+
+func TestFailurePolicies(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	nsGetter := testNamespaceGetter{
+		"restricted": {ObjectMeta: metav1.ObjectMeta{Name: "restricted", Labels: map[string]string{
+			api.EnforceLevelLabel: string(api.LevelRestricted),
+			api.AuditLevelLabel:   string(api.LevelRestricted),
+		}}},
+	}
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+
+	podAttrs := func(namespace string) *api.AttributesRecord {
+		return &api.AttributesRecord{
+			Name:      "test-pod",
+			Namespace: namespace,
+			Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+			Operation: admissionv1.Create,
+			Object:    &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: namespace}},
+		}
+	}
+	deploymentAttrs := func(namespace string) *api.AttributesRecord {
+		return &api.AttributesRecord{
+			Name:      "test-deployment",
+			Namespace: namespace,
+			Kind:      schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			Resource:  schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			Operation: admissionv1.Create,
+			Object:    &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "test-deployment", Namespace: namespace}},
+		}
+	}
+
+	testCases := []struct {
+		desc            string
+		failurePolicies FailurePolicies
+		attrs           api.Attributes
+		expectAllowed   bool
+		expectReason    metav1.StatusReason
+		expectWarning   bool
+	}{
+		{
+			desc:          "pod, namespace not found, default",
+			attrs:         podAttrs("missing"),
+			expectAllowed: false,
+			expectReason:  metav1.StatusReasonInternalError,
+		},
+		{
+			desc:            "pod, namespace not found, ignore",
+			failurePolicies: FailurePolicies{Enforce: FailurePolicyIgnore},
+			attrs:           podAttrs("missing"),
+			expectAllowed:   true,
+			expectWarning:   true,
+		},
+		{
+			desc:          "deployment, namespace not found, default",
+			attrs:         deploymentAttrs("missing"),
+			expectAllowed: true,
+		},
+		{
+			desc:            "deployment, namespace not found, ignore",
+			failurePolicies: FailurePolicies{Audit: FailurePolicyIgnore},
+			attrs:           deploymentAttrs("missing"),
+			expectAllowed:   true,
+			expectWarning:   true,
+		},
+		{
+			desc:            "deployment, namespace not found, fail",
+			failurePolicies: FailurePolicies{Audit: FailurePolicyFail},
+			attrs:           deploymentAttrs("missing"),
+			expectAllowed:   false,
+			expectReason:    metav1.StatusReasonInternalError,
+		},
+		{
+			desc:            "pod, timeout, fail",
+			failurePolicies: FailurePolicies{Timeout: time.Millisecond},
+			attrs:           podAttrs("restricted"),
+			expectAllowed:   false,
+			expectReason:    metav1.StatusReasonTimeout,
+		},
+		{
+			desc:            "pod, timeout, ignore",
+			failurePolicies: FailurePolicies{Enforce: FailurePolicyIgnore, Timeout: time.Millisecond},
+			attrs:           podAttrs("restricted"),
+			expectAllowed:   true,
+			expectWarning:   true,
+		},
+		{
+			desc:            "deployment, timeout, default",
+			failurePolicies: FailurePolicies{Timeout: time.Millisecond},
+			attrs:           deploymentAttrs("restricted"),
+			expectAllowed:   true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			evaluator := &blockingEvaluator{unblocked: make(chan struct{})}
+			defer close(evaluator.unblocked)
+			recorder := &FakeRecorder{}
+			a := &Admission{
+				PodLister:       &testPodLister{},
+				Evaluator:       evaluator,
+				Configuration:   config,
+				Metrics:         recorder,
+				NamespaceGetter: nsGetter,
+				FailurePolicies: tc.failurePolicies,
+			}
+			require.NoError(t, a.CompleteConfiguration())
+			require.NoError(t, a.ValidateConfiguration())
+
+			response := a.Validate(ctx, tc.attrs)
+			assert.Equal(t, tc.expectAllowed, response.Allowed, "Allowed")
+			if tc.expectWarning {
+				assert.Len(t, response.Warnings, 1, "Warnings")
+			} else {
+				assert.Empty(t, response.Warnings, "Warnings")
+			}
+			if tc.expectAllowed {
+				assert.Nil(t, response.Result)
+			} else if assert.NotNil(t, response.Result) {
+				assert.Equal(t, tc.expectReason, response.Result.Reason)
+			}
+			assert.Contains(t, response.AuditAnnotations, "error")
+			assert.Len(t, recorder.errors, 1, "expected RecordError() calls")
+		})
+	}
+
+	a := &Admission{PodLister: &testPodLister{}, Evaluator: &testEvaluator{}, Configuration: config, Metrics: &FakeRecorder{}, NamespaceGetter: nsGetter, FailurePolicies: FailurePolicies{Enforce: "Open"}}
+	require.NoError(t, a.CompleteConfiguration())
+	assert.ErrorContains(t, a.ValidateConfiguration(), `invalid failure policy "Open"`)
+}
+
+func TestTimeoutAbandonedEvaluation(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	nsGetter := testNamespaceGetter{
+		"restricted": {ObjectMeta: metav1.ObjectMeta{Name: "restricted", Labels: map[string]string{
+			api.EnforceLevelLabel: string(api.LevelRestricted),
+			api.AuditLevelLabel:   string(api.LevelRestricted),
+			api.WarnLevelLabel:    string(api.LevelRestricted),
+		}}},
+	}
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	evaluated := make(chan struct{})
+	evaluator := &blockingEvaluator{unblocked: make(chan struct{})}
+	recorder := &FakeRecorder{}
+	violationRecorder := &testViolationRecorder{}
+	decisionRecorder := &testDecisionRecorder{}
+	a := &Admission{
+		PodLister:         &testPodLister{},
+		Evaluator:         &notifyingEvaluator{Evaluator: evaluator, evaluated: evaluated},
+		Configuration:     config,
+		Metrics:           recorder,
+		NamespaceGetter:   nsGetter,
+		FailurePolicies:   FailurePolicies{Timeout: time.Millisecond},
+		ViolationRecorder: violationRecorder,
+		DecisionRecorder:  decisionRecorder,
+	}
+	require.NoError(t, a.CompleteConfiguration())
+	require.NoError(t, a.ValidateConfiguration())
+
+	response := a.Validate(ctx, &api.AttributesRecord{
+		Name:      "test-pod",
+		Namespace: "restricted",
+		Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+		Operation: admissionv1.Create,
+		Object:    &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "restricted", Annotations: map[string]string{"error": "host ports"}}},
+	})
+	assert.False(t, response.Allowed)
+	assert.Equal(t, metav1.StatusReasonTimeout, response.Result.Reason)
+
+	// the abandoned evaluation completes after the timeout, without recording the violating pod
+	close(evaluator.unblocked)
+	<-evaluated
+	assert.Never(t, func() bool {
+		return len(recorder.evaluations) > 0 || len(violationRecorder.recorded) > 0 || len(decisionRecorder.decisions) > 0
+	}, 100*time.Millisecond, 10*time.Millisecond, "the abandoned evaluation should not be recorded")
+	assert.Len(t, recorder.errors, 1, "the timeout should be recorded once")
+}
+
+// notifyingEvaluator closes evaluated once the Evaluator evaluated a pod.
+type notifyingEvaluator struct {
+	policy.Evaluator
+	evaluated chan struct{}
+}
+
+func (e *notifyingEvaluator) EvaluatePod(lv api.LevelVersion, meta *metav1.ObjectMeta, spec *corev1.PodSpec) []policy.CheckResult {
+	defer close(e.evaluated)
+	return e.Evaluator.EvaluatePod(lv, meta, spec)
+}
+
+func TestTimeoutCancelsAbandonedEvaluation(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	config, err := load.LoadFromData([]byte(`
+apiVersion: pod-security.admission.config.k8s.io/v1
+kind: PodSecurityConfiguration
+defaults:
+  enforce: restricted
+`))
+	require.NoError(t, err)
+	attrs := &api.AttributesRecord{
+		Name:      "test-pod",
+		Namespace: "test",
+		Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+		Operation: admissionv1.Create,
+		Object:    &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "test"}},
+	}
+	closed := make(chan struct{})
+	close(closed)
+
+	testCases := []struct {
+		desc              string
+		nsGetter          NamespaceGetter
+		unblocked         chan struct{}
+		expectEvaluations int
+	}{
+		{
+			desc:              "blocked namespace lookup",
+			nsGetter:          blockingNamespaceGetter{},
+			unblocked:         closed,
+			expectEvaluations: 0,
+		},
+		{
+			desc: "blocked enforce evaluation",
+			nsGetter: testNamespaceGetter{
+				"test": {ObjectMeta: metav1.ObjectMeta{Name: "test", Labels: map[string]string{
+					api.EnforceLevelLabel: string(api.LevelRestricted),
+					api.AuditLevelLabel:   string(api.LevelBaseline),
+				}}},
+			},
+			unblocked:         make(chan struct{}),
+			expectEvaluations: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			evaluator := &countingEvaluator{Evaluator: &blockingEvaluator{unblocked: tc.unblocked}}
+			a := &Admission{
+				PodLister:       &testPodLister{},
+				Evaluator:       evaluator,
+				Configuration:   config,
+				Metrics:         &FakeRecorder{},
+				NamespaceGetter: tc.nsGetter,
+				NamespaceLookup: NamespaceLookupOptions{OnFailure: NamespaceLookupFailureActionDefaults},
+				FailurePolicies: FailurePolicies{Timeout: time.Millisecond},
+			}
+			require.NoError(t, a.CompleteConfiguration())
+			require.NoError(t, a.ValidateConfiguration())
+
+			returned := make(chan struct{})
+			response := a.validateWithTimeout(ctx, attrs, true, func(a *Admission, ctx context.Context, attrs api.Attributes) *admissionv1.AdmissionResponse {
+				defer close(returned)
+				return a.ValidatePod(ctx, attrs)
+			})
+			assert.Equal(t, metav1.StatusReasonTimeout, response.Result.Reason)
+
+			// the abandoned evaluation returns without evaluating the remaining policy levels
+			if tc.unblocked != closed {
+				close(tc.unblocked)
+			}
+			select {
+			case <-returned:
+			case <-time.After(10 * time.Second):
+				t.Fatal("the abandoned evaluation did not return")
+			}
+			assert.Equal(t, tc.expectEvaluations, evaluator.evaluations, "evaluations")
+		})
+	}
+}
+
+// blockingNamespaceGetter blocks namespace lookups until their context is done, like requests to an unresponsive API server.
+type blockingNamespaceGetter struct{}
+
+func (blockingNamespaceGetter) GetNamespace(ctx context.Context, name string) (*corev1.Namespace, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// countingEvaluator counts the pod evaluations of the Evaluator.
+type countingEvaluator struct {
+	policy.Evaluator
+	evaluations int
+}
+
+func (e *countingEvaluator) EvaluatePod(lv api.LevelVersion, meta *metav1.ObjectMeta, spec *corev1.PodSpec) []policy.CheckResult {
+	e.evaluations++
+	return e.Evaluator.EvaluatePod(lv, meta, spec)
+}
+
+// flakyNamespaceGetter fails the first failures lookups of every namespace.
+type flakyNamespaceGetter struct {
+	testNamespaceGetter
+	failures int
+	calls    int
+}
+
+func (g *flakyNamespaceGetter) GetNamespace(ctx context.Context, name string) (*corev1.Namespace, error) {
+	g.calls++
+	if g.calls <= g.failures {
+		return nil, apierrors.NewNotFound(corev1.Resource("namespaces"), name)
+	}
+	return g.testNamespaceGetter.GetNamespace(ctx, name)
+}
+
+type checkErrorRecorder struct {
+	FakeRecorder
+	checks []policy.CheckID
+}
+
+func (r *checkErrorRecorder) RecordCheckError(check policy.CheckID, _ error) {
+	r.checks = append(r.checks, check)
+}
+
+func TestCheckDeadlines(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	unblocked := make(chan struct{})
+	defer close(unblocked)
+	slowCheck := policy.Check{
+		ID:    "example.com/slow",
+		Level: api.LevelBaseline,
+		Versions: []policy.VersionedCheck{{
+			MinimumVersion: api.MajorMinorVersion(1, 0),
+			CheckPod: func(_ *metav1.ObjectMeta, _ *corev1.PodSpec, _ ...policy.Option) policy.CheckResult {
+				<-unblocked
+				return policy.CheckResult{Allowed: true}
+			},
+		}},
+	}
+	evaluator, err := policy.NewEvaluator([]policy.Check{policy.CheckPrivileged(), slowCheck}, policy.WithCheckDeadline(time.Millisecond, slowCheck.ID))
+	require.NoError(t, err)
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	nsGetter := testNamespaceGetter{
+		"baseline": {ObjectMeta: metav1.ObjectMeta{Name: "baseline", Labels: map[string]string{
+			api.EnforceLevelLabel: string(api.LevelBaseline),
+		}}},
+	}
+	podAttrs := &api.AttributesRecord{
+		Name:      "test-pod",
+		Namespace: "baseline",
+		Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+		Operation: admissionv1.Create,
+		Object:    &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "baseline"}},
+	}
+
+	privilegedPodAttrs := *podAttrs
+	privilegedPodAttrs.Object = &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "baseline"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:            "a",
+			SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(true)},
+		}}},
+	}
+
+	for _, tc := range []struct {
+		desc               string
+		failurePolicy      FailurePolicy
+		checkErrorPolicies CheckErrorPolicies
+		attrs              api.Attributes
+		expectAllowed      bool
+		expectForbidden    bool
+		expectWarnings     []string
+	}{
+		{desc: "default", expectAllowed: false},
+		{
+			desc:           "failure policy ignore",
+			failurePolicy:  FailurePolicyIgnore,
+			expectAllowed:  true,
+			expectWarnings: []string{`PodSecurity "baseline:latest": checks could not be evaluated: check example.com/slow: deadline of 1ms exceeded`},
+		},
+		{
+			desc:               "check error policy fail",
+			failurePolicy:      FailurePolicyIgnore,
+			checkErrorPolicies: CheckErrorPolicies{Enforce: FailurePolicyFail},
+			expectAllowed:      false,
+		},
+		{
+			desc:               "check error policy ignore",
+			checkErrorPolicies: CheckErrorPolicies{Enforce: FailurePolicyIgnore},
+			expectAllowed:      true,
+			expectWarnings:     []string{`PodSecurity "baseline:latest": checks could not be evaluated: check example.com/slow: deadline of 1ms exceeded`},
+		},
+		{
+			desc:               "check error policy ignore with violations",
+			checkErrorPolicies: CheckErrorPolicies{Enforce: FailurePolicyIgnore},
+			attrs:              &privilegedPodAttrs,
+			expectAllowed:      false,
+			expectForbidden:    true,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			recorder := &checkErrorRecorder{}
+			a := &Admission{
+				PodLister:          &testPodLister{},
+				Evaluator:          evaluator,
+				Configuration:      config,
+				Metrics:            recorder,
+				NamespaceGetter:    nsGetter,
+				FailurePolicies:    FailurePolicies{Enforce: tc.failurePolicy},
+				CheckErrorPolicies: tc.checkErrorPolicies,
+			}
+			require.NoError(t, a.CompleteConfiguration())
+			require.NoError(t, a.ValidateConfiguration())
+
+			attrs := tc.attrs
+			if attrs == nil {
+				attrs = podAttrs
+			}
+			response := a.Validate(ctx, attrs)
+			assert.Equal(t, tc.expectAllowed, response.Allowed, "Allowed")
+			if !tc.expectAllowed && assert.NotNil(t, response.Result) {
+				if tc.expectForbidden {
+					assert.Equal(t, metav1.StatusReasonForbidden, response.Result.Reason)
+					assert.NotContains(t, response.Result.Message, "example.com/slow", "errors are not violations")
+				} else {
+					assert.Equal(t, metav1.StatusReasonInternalError, response.Result.Reason)
+				}
+			}
+			assert.Equal(t, tc.expectWarnings, response.Warnings)
+			assert.Contains(t, response.AuditAnnotations["error"], "failed to evaluate PodSecurity \"baseline:latest\"")
+			assert.Contains(t, response.AuditAnnotations["error"], "check example.com/slow: deadline of 1ms exceeded")
+			assert.Equal(t, []policy.CheckID{"example.com/slow"}, recorder.checks)
+			assert.Len(t, recorder.errors, 1, "expected RecordError() calls")
+		})
+	}
+}
+
+func TestCheckErrorPoliciesAuditWarn(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	failingCheck := policy.Check{
+		ID:    "example.com/failing",
+		Level: api.LevelBaseline,
+		Versions: []policy.VersionedCheck{{
+			MinimumVersion: api.MajorMinorVersion(1, 0),
+			CheckPod: func(_ *metav1.ObjectMeta, _ *corev1.PodSpec, _ ...policy.Option) policy.CheckResult {
+				return policy.CheckResult{Error: fmt.Errorf("malformed pod")}
+			},
+		}},
+	}
+	evaluator, err := policy.NewEvaluator([]policy.Check{policy.CheckPrivileged(), failingCheck})
+	require.NoError(t, err)
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	nsGetter := testNamespaceGetter{
+		"baseline": {ObjectMeta: metav1.ObjectMeta{Name: "baseline", Labels: map[string]string{
+			api.AuditLevelLabel: string(api.LevelBaseline),
+			api.WarnLevelLabel:  string(api.LevelBaseline),
+		}}},
+	}
+	podAttrs := &api.AttributesRecord{
+		Name:      "test-pod",
+		Namespace: "baseline",
+		Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+		Operation: admissionv1.Create,
+		Object:    &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "baseline"}},
+	}
+
+	for _, tc := range []struct {
+		desc                 string
+		checkErrorPolicies   CheckErrorPolicies
+		expectAuditError     string
+		expectWarnings       []string
+		expectRecordedErrors int
+	}{
+		{
+			desc:                 "default",
+			expectAuditError:     `failed to evaluate PodSecurity "baseline:latest": check example.com/failing: malformed pod`,
+			expectWarnings:       []string{`PodSecurity "baseline:latest": checks could not be evaluated: check example.com/failing: malformed pod`},
+			expectRecordedErrors: 1,
+		},
+		{
+			desc:               "ignore",
+			checkErrorPolicies: CheckErrorPolicies{Audit: FailurePolicyIgnore, Warn: FailurePolicyIgnore},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			recorder := &checkErrorRecorder{}
+			a := &Admission{
+				PodLister:          &testPodLister{},
+				Evaluator:          evaluator,
+				Configuration:      config,
+				Metrics:            recorder,
+				NamespaceGetter:    nsGetter,
+				CheckErrorPolicies: tc.checkErrorPolicies,
+			}
+			require.NoError(t, a.CompleteConfiguration())
+			require.NoError(t, a.ValidateConfiguration())
+
+			response := a.Validate(ctx, podAttrs)
+			assert.True(t, response.Allowed)
+			assert.Equal(t, tc.expectAuditError, response.AuditAnnotations["error"])
+			assert.Empty(t, response.AuditAnnotations[api.AuditViolationsAnnotationKey], "errors are not violations")
+			assert.Equal(t, tc.expectWarnings, response.Warnings)
+			assert.Len(t, recorder.errors, tc.expectRecordedErrors, "expected RecordError() calls")
+			assert.NotEmpty(t, recorder.checks, "check errors are always recorded")
+		})
+	}
+}
+
+type namespaceLookupFailureRecorder struct {
+	FakeRecorder
+	resolutions []metrics.NamespaceLookupResolution
+}
+
+func (r *namespaceLookupFailureRecorder) RecordNamespaceLookupFailure(resolution metrics.NamespaceLookupResolution) {
+	r.resolutions = append(r.resolutions, resolution)
+}
+
+func TestNamespaceLookup(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	config, err := load.LoadFromData([]byte(`
+apiVersion: pod-security.admission.config.k8s.io/v1
+kind: PodSecurityConfiguration
+defaults:
+  enforce: restricted
+`))
+	require.NoError(t, err)
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+	require.NoError(t, err)
+	namespaces := testNamespaceGetter{
+		"privileged": {ObjectMeta: metav1.ObjectMeta{Name: "privileged", Labels: map[string]string{
+			api.EnforceLevelLabel: string(api.LevelPrivileged),
+		}}},
+	}
+	podAttrs := &api.AttributesRecord{
+		Name:      "test-pod",
+		Namespace: "privileged",
+		Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+		Operation: admissionv1.Create,
+		Object: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "privileged"}, Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "c", SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(true)}}},
+		}},
+	}
+
+	testCases := []struct {
+		desc              string
+		failures          int
+		options           NamespaceLookupOptions
+		failurePolicies   FailurePolicies
+		expectAllowed     bool
+		expectReason      metav1.StatusReason
+		expectCalls       int
+		expectResolutions []metrics.NamespaceLookupResolution
+	}{
+		{
+			desc:          "found",
+			expectAllowed: true,
+			expectCalls:   1,
+		},
+		{
+			desc:              "not found, no retries",
+			failures:          1,
+			expectAllowed:     false,
+			expectReason:      metav1.StatusReasonInternalError,
+			expectCalls:       1,
+			expectResolutions: []metrics.NamespaceLookupResolution{metrics.NamespaceLookupResolutionFailurePolicy},
+		},
+		{
+			desc:              "found on retry",
+			failures:          2,
+			options:           NamespaceLookupOptions{Retries: 3, RetryInterval: time.Millisecond},
+			expectAllowed:     true,
+			expectCalls:       3,
+			expectResolutions: []metrics.NamespaceLookupResolution{metrics.NamespaceLookupResolutionRetried},
+		},
+		{
+			desc:              "retries exhausted, failure policy ignore",
+			failures:          5,
+			options:           NamespaceLookupOptions{Retries: 2, RetryInterval: time.Millisecond},
+			failurePolicies:   FailurePolicies{Enforce: FailurePolicyIgnore},
+			expectAllowed:     true,
+			expectCalls:       3,
+			expectResolutions: []metrics.NamespaceLookupResolution{metrics.NamespaceLookupResolutionFailurePolicy},
+		},
+		{
+			desc:              "retries exhausted, defaults",
+			failures:          5,
+			options:           NamespaceLookupOptions{Retries: 1, RetryInterval: time.Millisecond, OnFailure: NamespaceLookupFailureActionDefaults},
+			failurePolicies:   FailurePolicies{Enforce: FailurePolicyIgnore},
+			expectAllowed:     false,
+			expectReason:      metav1.StatusReasonForbidden,
+			expectCalls:       2,
+			expectResolutions: []metrics.NamespaceLookupResolution{metrics.NamespaceLookupResolutionDefaults},
+		},
+		{
+			desc:              "retries exhausted, deny",
+			failures:          5,
+			options:           NamespaceLookupOptions{Retries: 1, RetryInterval: time.Millisecond, OnFailure: NamespaceLookupFailureActionDeny},
+			failurePolicies:   FailurePolicies{Enforce: FailurePolicyIgnore},
+			expectAllowed:     false,
+			expectReason:      metav1.StatusReasonInternalError,
+			expectCalls:       2,
+			expectResolutions: []metrics.NamespaceLookupResolution{metrics.NamespaceLookupResolutionDeny},
+		},
+		{
+			desc:              "retry exceeds deadline",
+			failures:          5,
+			options:           NamespaceLookupOptions{Retries: 3, RetryInterval: time.Hour},
+			failurePolicies:   FailurePolicies{Timeout: time.Minute},
+			expectAllowed:     false,
+			expectReason:      metav1.StatusReasonInternalError,
+			expectCalls:       1,
+			expectResolutions: []metrics.NamespaceLookupResolution{metrics.NamespaceLookupResolutionFailurePolicy},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			getter := &flakyNamespaceGetter{testNamespaceGetter: namespaces, failures: tc.failures}
+			recorder := &namespaceLookupFailureRecorder{}
+			a := &Admission{
+				PodLister:       &testPodLister{},
+				Evaluator:       evaluator,
+				Configuration:   config,
+				Metrics:         recorder,
+				NamespaceGetter: getter,
+				NamespaceLookup: tc.options,
+				FailurePolicies: tc.failurePolicies,
+			}
+			require.NoError(t, a.CompleteConfiguration())
+			require.NoError(t, a.ValidateConfiguration())
+
+			response := a.Validate(ctx, podAttrs)
+			assert.Equal(t, tc.expectAllowed, response.Allowed, "Allowed")
+			if !tc.expectAllowed && assert.NotNil(t, response.Result) {
+				assert.Equal(t, tc.expectReason, response.Result.Reason)
+			}
+			assert.Equal(t, tc.expectCalls, getter.calls, "GetNamespace() calls")
+			assert.Equal(t, tc.expectResolutions, recorder.resolutions)
+		})
+	}
+
+	for _, options := range []NamespaceLookupOptions{
+		{Retries: -1},
+		{RetryInterval: -time.Second},
+		{OnFailure: "Allow"},
+	} {
+		assert.Error(t, options.Validate(), "%#v", options)
+	}
+	for _, action := range []string{"", "FailurePolicy", "Defaults", "Deny"} {
+		_, err := ParseNamespaceLookupFailureAction(action)
+		assert.NoError(t, err, action)
+	}
+}
+
+func TestLenientLabelParsing(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	labels := map[string]string{api.EnforceLevelLabel: "Restricted", api.EnforceVersionLabel: " latest"}
+	nsGetter := testNamespaceGetter{
+		"ns": {ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: labels}},
+	}
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	a := &Admission{
+		PodLister:           &testPodLister{},
+		Evaluator:           &testEvaluator{},
+		Configuration:       config,
+		Metrics:             &FakeRecorder{},
+		NamespaceGetter:     nsGetter,
+		LenientLabelParsing: true,
+	}
+	require.NoError(t, a.CompleteConfiguration())
+	require.NoError(t, a.ValidateConfiguration())
+
+	nsAttrs := &api.AttributesRecord{
+		Name:      "ns",
+		Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Namespace"},
+		Resource:  schema.GroupVersionResource{Version: "v1", Resource: "namespaces"},
+		Operation: admissionv1.Create,
+		Object:    &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: labels}},
+	}
+	response := a.Validate(ctx, nsAttrs)
+	assert.True(t, response.Allowed)
+	assert.Equal(t, []string{
+		`label pod-security.kubernetes.io/enforce="Restricted" is interpreted as "restricted"`,
+		`label pod-security.kubernetes.io/enforce-version=" latest" is interpreted as "latest"`,
+	}, response.Warnings)
+	assert.Empty(t, sharedAllowedResponse.Warnings, "shared responses should not be mutated")
+
+	podAttrs := &api.AttributesRecord{
+		Name:      "test-pod",
+		Namespace: "ns",
+		Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+		Operation: admissionv1.Create,
+		Object:    &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "ns", Annotations: map[string]string{"error": "host ports"}}},
+	}
+	response = a.Validate(ctx, podAttrs)
+	assert.False(t, response.Allowed)
+	if assert.NotNil(t, response.Result) {
+		assert.Contains(t, response.Result.Message, `violates PodSecurity "restricted:latest"`)
+	}
+
+	a.LenientLabelParsing = false
+	response = a.Validate(ctx, nsAttrs)
+	assert.False(t, response.Allowed)
+	if assert.NotNil(t, response.Result) {
+		assert.Equal(t, metav1.StatusReasonInvalid, response.Result.Reason)
+	}
+}
+
+func TestUnknownLabels(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	typo := map[string]string{"pod-security.kubernetes.io/enforces": "restricted", "example.com/enforces": "restricted"}
+	valid := map[string]string{api.EnforceLevelLabel: "restricted"}
+	nsAttrs := func(op admissionv1.Operation, labels, oldLabels map[string]string) *api.AttributesRecord {
+		attrs := &api.AttributesRecord{
+			Name:      "ns",
+			Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Namespace"},
+			Resource:  schema.GroupVersionResource{Version: "v1", Resource: "namespaces"},
+			Operation: op,
+			Object:    &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: labels}},
+		}
+		if op == admissionv1.Update {
+			attrs.OldObject = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: oldLabels}}
+		}
+		return attrs
+	}
+	const warning = `unknown label pod-security.kubernetes.io/enforces is ignored, supported values: "pod-security.kubernetes.io/enforce", "pod-security.kubernetes.io/enforce-version", "pod-security.kubernetes.io/audit", "pod-security.kubernetes.io/audit-version", "pod-security.kubernetes.io/warn", "pod-security.kubernetes.io/warn-version"`
+
+	testCases := []struct {
+		desc           string
+		action         UnknownLabelsAction
+		attrs          api.Attributes
+		expectAllowed  bool
+		expectWarnings []string
+	}{
+		{
+			desc:          "ignore",
+			attrs:         nsAttrs(admissionv1.Create, typo, nil),
+			expectAllowed: true,
+		},
+		{
+			desc:           "warn",
+			action:         UnknownLabelsWarn,
+			attrs:          nsAttrs(admissionv1.Create, typo, nil),
+			expectAllowed:  true,
+			expectWarnings: []string{warning},
+		},
+		{
+			desc:          "warn, known labels",
+			action:        UnknownLabelsWarn,
+			attrs:         nsAttrs(admissionv1.Create, valid, nil),
+			expectAllowed: true,
+		},
+		{
+			desc:   "deny create",
+			action: UnknownLabelsDeny,
+			attrs:  nsAttrs(admissionv1.Create, typo, nil),
+		},
+		{
+			desc:   "deny update adding unknown label",
+			action: UnknownLabelsDeny,
+			attrs:  nsAttrs(admissionv1.Update, typo, valid),
+		},
+		{
+			desc:          "deny update keeping unknown label",
+			action:        UnknownLabelsDeny,
+			attrs:         nsAttrs(admissionv1.Update, typo, typo),
+			expectAllowed: true,
+		},
+		{
+			desc:          "deny, known labels",
+			action:        UnknownLabelsDeny,
+			attrs:         nsAttrs(admissionv1.Create, valid, nil),
+			expectAllowed: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			a := &Admission{
+				PodLister:       &testPodLister{},
+				Evaluator:       &testEvaluator{},
+				Configuration:   config,
+				Metrics:         &FakeRecorder{},
+				NamespaceGetter: testNamespaceGetter{},
+				UnknownLabels:   tc.action,
+			}
+			require.NoError(t, a.CompleteConfiguration())
+			require.NoError(t, a.ValidateConfiguration())
+
+			response := a.Validate(ctx, tc.attrs)
+			assert.Equal(t, tc.expectAllowed, response.Allowed, "Allowed")
+			assert.Equal(t, tc.expectWarnings, response.Warnings)
+			if !tc.expectAllowed && assert.NotNil(t, response.Result) {
+				assert.Equal(t, metav1.StatusReasonInvalid, response.Result.Reason)
+				assert.Contains(t, response.Result.Message, "pod-security.kubernetes.io/enforces")
+			}
+		})
+	}
+	assert.Empty(t, sharedAllowedResponse.Warnings, "shared responses should not be mutated")
+
+	for _, action := range []string{"", "Ignore", "Warn", "Deny"} {
+		_, err := ParseUnknownLabelsAction(action)
+		assert.NoError(t, err, action)
+	}
+	_, err = ParseUnknownLabelsAction("Reject")
+	assert.Error(t, err)
+}
+
+func TestWarningLimits(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	var pods []*corev1.Pod
+	for i := 0; i < 5; i++ {
+		pods = append(pods, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("pod-%d", i),
+			Annotations: map[string]string{"error": fmt.Sprintf("violation %d", i)},
+		}})
+	}
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	a := &Admission{
+		PodLister:           &testPodLister{pods: pods},
+		Evaluator:           &testEvaluator{},
+		Configuration:       config,
+		Metrics:             &FakeRecorder{},
+		NamespaceGetter:     testNamespaceGetter{},
+		LenientLabelParsing: true,
+		WarningLimits:       WarningLimits{MaxWarnings: 4, ReportURL: "https://example.com/report"},
+	}
+	require.NoError(t, a.CompleteConfiguration())
+	require.NoError(t, a.ValidateConfiguration())
+
+	attrs := &api.AttributesRecord{
+		Name:      "ns",
+		Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Namespace"},
+		Resource:  schema.GroupVersionResource{Version: "v1", Resource: "namespaces"},
+		Operation: admissionv1.Update,
+		Object:    &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: map[string]string{api.EnforceLevelLabel: "Restricted"}}},
+		OldObject: &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns"}},
+	}
+	response := a.Validate(ctx, attrs)
+	assert.True(t, response.Allowed)
+	// the existing pods violating the enforce level are kept over the normalized label warning
+	assert.Equal(t, []string{
+		`existing pods in namespace "ns" violate the new PodSecurity enforce level "restricted:latest"`,
+		"pod-0: violation 0",
+		"pod-1: violation 1",
+		"4 more PodSecurity warnings omitted, see https://example.com/report for the full report",
+	}, response.Warnings)
+
+	a.WarningLimits = WarningLimits{MaxWarnings: 7}
+	response = a.Validate(ctx, attrs)
+	assert.Len(t, response.Warnings, 7)
+	assert.Equal(t, `label pod-security.kubernetes.io/enforce="Restricted" is interpreted as "restricted"`, response.Warnings[6])
+
+	a.WarningLimits = WarningLimits{MaxWarnings: 6}
+	response = a.Validate(ctx, attrs)
+	assert.Len(t, response.Warnings, 6)
+	assert.Equal(t, "2 more PodSecurity warnings omitted", response.Warnings[5])
+
+	a.WarningLimits = WarningLimits{MaxWarnings: 1}
+	assert.Error(t, a.ValidateConfiguration())
+
+	// the warnings about the enforce policy are kept over the warnings about the warn policy
+	enforce := api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()}
+	warn := api.LevelVersion{Level: api.LevelRestricted, Version: api.MajorMinorVersion(1, 24)}
+	a.Evaluator = levelVersionEvaluator{
+		enforce: {{Allowed: true, Warning: "enforce warning"}},
+		warn:    {{Allowed: false, ForbiddenReason: "warn violation"}, {Allowed: true, Warning: "warn warning"}},
+	}
+	a.NamespaceGetter = testNamespaceGetter{"ns": {ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: map[string]string{
+		api.EnforceLevelLabel: "restricted",
+		api.WarnLevelLabel:    "restricted",
+		api.WarnVersionLabel:  "v1.24",
+	}}}}
+	a.WarningLimits = WarningLimits{MaxWarnings: 2}
+	podAttrs := &api.AttributesRecord{
+		Name:      "test-pod",
+		Namespace: "ns",
+		Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+		Operation: admissionv1.Create,
+		Object:    &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "ns"}},
+	}
+	response = a.Validate(ctx, podAttrs)
+	assert.True(t, response.Allowed)
+	assert.Equal(t, []string{
+		`PodSecurity "restricted:latest": enforce warning`,
+		"2 more PodSecurity warnings omitted",
+	}, response.Warnings)
+}
+
+// levelVersionEvaluator returns the results registered for the evaluated level & version.
+type levelVersionEvaluator map[api.LevelVersion][]policy.CheckResult
+
+func (e levelVersionEvaluator) EvaluatePod(lv api.LevelVersion, _ *metav1.ObjectMeta, _ *corev1.PodSpec) []policy.CheckResult {
+	return e[lv]
+}
+
+type testDecisionRecorder struct {
+	decisions []Decision
+	checks    [][]policy.CheckID
+}
+
+func (r *testDecisionRecorder) RecordDecision(_ context.Context, decision Decision, _ api.LevelVersion, checks []policy.CheckID, _ *metav1.ObjectMeta, _ *corev1.PodSpec, _ api.Attributes) {
+	r.decisions = append(r.decisions, decision)
+	r.checks = append(r.checks, checks)
+}
+
+func TestDecisionRecorder(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	nsGetter := testNamespaceGetter{
+		"restricted": {ObjectMeta: metav1.ObjectMeta{Name: "restricted", Labels: map[string]string{api.EnforceLevelLabel: string(api.LevelRestricted)}}},
+	}
+	config, err := load.LoadFromData([]byte(`
+apiVersion: pod-security.admission.config.k8s.io/v1
+kind: PodSecurityConfiguration
+exemptions:
+  usernames: ["exempt-user"]
+`))
+	require.NoError(t, err)
+	recorder := &testDecisionRecorder{}
+	a := &Admission{
+		PodLister:        &testPodLister{},
+		Evaluator:        &testEvaluator{},
+		Configuration:    config,
+		Metrics:          &FakeRecorder{},
+		NamespaceGetter:  nsGetter,
+		DecisionRecorder: recorder,
+	}
+	require.NoError(t, a.CompleteConfiguration())
+	require.NoError(t, a.ValidateConfiguration())
+
+	podAttrs := func(annotations map[string]string, username string) *api.AttributesRecord {
+		return &api.AttributesRecord{
+			Name:      "test-pod",
+			Namespace: "restricted",
+			Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+			Operation: admissionv1.Create,
+			Username:  username,
+			Object:    &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "restricted", Annotations: annotations}},
+		}
+	}
+
+	assert.True(t, a.Validate(ctx, podAttrs(nil, "")).Allowed)
+	assert.False(t, a.Validate(ctx, podAttrs(map[string]string{"error": "host ports"}, "")).Allowed)
+	assert.True(t, a.Validate(ctx, podAttrs(map[string]string{"error": "host ports"}, "exempt-user")).Allowed)
+
+	assert.Equal(t, []Decision{DecisionAllow, DecisionDeny}, recorder.decisions, "exempt pods should not be recorded")
+	assert.Empty(t, recorder.checks[0])
+	assert.Len(t, recorder.checks[1], 1)
+}
+
+func TestValidateExemptedUsernames(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "replicaset-controller"}},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "admins"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "admin"}, {Kind: rbacv1.GroupKind, Name: "ops"}},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "deployers"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "deployer"}},
+		},
+	)
+	errs, err := ValidateExemptedUsernames(context.Background(), admissionapi.PodSecurityExemptions{Usernames: []string{
+		"system:serviceaccount:kube-system:replicaset-controller",
+		"system:serviceaccount:kube-system:replicaset-controler",
+		"system:serviceaccount:kube-system",
+		"admin",
+		"deployer",
+		"ops",
+		"system:serviceaccount:team-*:*",
+	}}, client)
+	require.NoError(t, err)
+	require.Len(t, errs, 3)
+	assert.Equal(t, `exemptions.usernames[1]: Not found: "system:serviceaccount:kube-system:replicaset-controler": service account does not exist`, errs[0].Error())
+	assert.Equal(t, field.ErrorTypeInvalid, errs[1].Type)
+	assert.Equal(t, "exemptions.usernames[2]", errs[1].Field)
+	assert.Equal(t, `exemptions.usernames[5]: Not found: "ops": not the subject of any RoleBinding or ClusterRoleBinding`, errs[2].Error())
+}
+
+func TestCheckOptOut(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	_, otherKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	nsGetter := testNamespaceGetter{
+		"baseline": {ObjectMeta: metav1.ObjectMeta{Name: "baseline", Labels: map[string]string{api.EnforceLevelLabel: string(api.LevelBaseline)}}},
+	}
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+	require.NoError(t, err)
+	a := &Admission{
+		PodLister:           &testPodLister{},
+		Evaluator:           evaluator,
+		Configuration:       config,
+		Metrics:             &FakeRecorder{},
+		NamespaceGetter:     nsGetter,
+		CheckOptOutVerifier: NewCheckOptOutVerifier([]ed25519.PublicKey{publicKey}),
+	}
+	require.NoError(t, a.CompleteConfiguration())
+	require.NoError(t, a.ValidateConfiguration())
+
+	sign := func(key ed25519.PrivateKey, optOut CheckOptOut) string {
+		token, err := SignCheckOptOut(key, optOut)
+		require.NoError(t, err)
+		return token
+	}
+	expires := time.Now().Add(time.Hour)
+	podAttrs := func(token string) *api.AttributesRecord {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "baseline"},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name:            "app",
+				SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(true)},
+			}}},
+		}
+		if token != "" {
+			pod.Annotations = map[string]string{api.CheckOptOutAnnotation: token}
+		}
+		return &api.AttributesRecord{
+			Name:      "test-pod",
+			Namespace: "baseline",
+			Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+			Operation: admissionv1.Create,
+			Object:    pod,
+		}
+	}
+
+	testCases := []struct {
+		desc              string
+		token             string
+		expectAllowed     bool
+		expectIgnored     string
+		expectAuditOptOut string
+		disableVerifier   bool
+	}{{
+		desc:          "no opt-out",
+		expectAllowed: false,
+	}, {
+		desc:              "valid opt-out",
+		token:             sign(privateKey, CheckOptOut{Namespace: "baseline", Checks: []policy.CheckID{"privileged"}, Expires: expires, Approver: "security-team"}),
+		expectAllowed:     true,
+		expectAuditOptOut: "privileged until " + expires.UTC().Format(time.RFC3339) + " approved by security-team",
+	}, {
+		desc:              "other check",
+		token:             sign(privateKey, CheckOptOut{Namespace: "baseline", Checks: []policy.CheckID{"hostPorts"}, Expires: expires}),
+		expectAllowed:     false,
+		expectAuditOptOut: "hostPorts until " + expires.UTC().Format(time.RFC3339),
+	}, {
+		desc:          "other namespace",
+		token:         sign(privateKey, CheckOptOut{Namespace: "other", Checks: []policy.CheckID{"privileged"}, Expires: expires}),
+		expectAllowed: false,
+		expectIgnored: `ignored: approved for namespace "other"`,
+	}, {
+		desc:          "expired",
+		token:         sign(privateKey, CheckOptOut{Namespace: "baseline", Checks: []policy.CheckID{"privileged"}, Expires: time.Now().Add(-time.Hour)}),
+		expectAllowed: false,
+		expectIgnored: "ignored: expired at",
+	}, {
+		desc:          "untrusted key",
+		token:         sign(otherKey, CheckOptOut{Namespace: "baseline", Checks: []policy.CheckID{"privileged"}, Expires: expires}),
+		expectAllowed: false,
+		expectIgnored: "ignored: invalid signature",
+	}, {
+		desc:          "malformed",
+		token:         "privileged",
+		expectAllowed: false,
+		expectIgnored: "ignored: malformed token",
+	}, {
+		desc:            "not enabled",
+		token:           sign(privateKey, CheckOptOut{Namespace: "baseline", Checks: []policy.CheckID{"privileged"}, Expires: expires}),
+		disableVerifier: true,
+		expectAllowed:   false,
+		expectIgnored:   "ignored: check opt-outs are not enabled",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			a := *a
+			if tc.disableVerifier {
+				a.CheckOptOutVerifier = nil
+			}
+			response := a.Validate(ctx, podAttrs(tc.token))
+			assert.Equal(t, tc.expectAllowed, response.Allowed)
+			assert.Empty(t, response.Warnings)
+			if tc.expectIgnored != "" {
+				audit := response.AuditAnnotations[api.CheckOptOutAnnotationKey]
+				assert.True(t, strings.HasPrefix(audit, tc.expectIgnored), audit)
+			} else {
+				assert.Equal(t, tc.expectAuditOptOut, response.AuditAnnotations[api.CheckOptOutAnnotationKey])
+			}
+		})
+	}
+
+	t.Run("ignored on allowed pod", func(t *testing.T) {
+		attrs := podAttrs("malformed")
+		attrs.Object.(*corev1.Pod).Spec.Containers[0].SecurityContext = nil
+		response := a.Validate(ctx, attrs)
+		assert.True(t, response.Allowed)
+		assert.Equal(t, []string{"ignored pod-security.kubernetes.io/check-opt-out annotation: malformed token"}, response.Warnings)
+	})
+}
+
+func TestNamespaceExemptChecks(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+	require.NoError(t, err)
+	newAdmission := func(floor admissionapi.PodSecurityFloor, namespace *corev1.Namespace) *Admission {
+		config, err := load.LoadFromData(nil)
+		require.NoError(t, err)
+		config.Floor = floor
+		a := &Admission{
+			PodLister:                &testPodLister{},
+			Evaluator:                evaluator,
+			Configuration:            config,
+			Metrics:                  &FakeRecorder{},
+			NamespaceGetter:          testNamespaceGetter{namespace.Name: namespace},
+			NamespaceCheckExemptions: true,
+		}
+		require.NoError(t, a.CompleteConfiguration())
+		require.NoError(t, a.ValidateConfiguration())
+		return a
+	}
+	namespace := func(level api.Level, exemptChecks string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "ns",
+			Labels:      map[string]string{api.EnforceLevelLabel: string(level)},
+			Annotations: map[string]string{api.ExemptChecksAnnotation: exemptChecks},
+		}}
+	}
+	podAttrs := func(privileged bool) *api.AttributesRecord {
+		return &api.AttributesRecord{
+			Name:      "test-pod",
+			Namespace: "ns",
+			Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+			Operation: admissionv1.Create,
+			Object: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "ns"},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name:            "app",
+					SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(privileged)},
+				}}},
+			},
+		}
+	}
+	restrictedChecks := "allowPrivilegeEscalation,capabilities_restricted,runAsNonRoot,seccompProfile_restricted"
+
+	testCases := []struct {
+		desc             string
+		floor            admissionapi.PodSecurityFloor
+		namespace        *corev1.Namespace
+		privileged       bool
+		disabled         bool
+		expectAllowed    bool
+		expectAuditValue string
+	}{{
+		desc:          "no exemption",
+		namespace:     namespace(api.LevelBaseline, ""),
+		privileged:    true,
+		expectAllowed: false,
+	}, {
+		desc:             "exempt",
+		namespace:        namespace(api.LevelBaseline, "privileged"),
+		privileged:       true,
+		expectAllowed:    true,
+		expectAuditValue: "privileged",
+	}, {
+		desc:             "multiple",
+		namespace:        namespace(api.LevelBaseline, " sysctls, privileged"),
+		privileged:       true,
+		expectAllowed:    true,
+		expectAuditValue: "privileged,sysctls",
+	}, {
+		desc:             "other check",
+		namespace:        namespace(api.LevelBaseline, "hostPorts"),
+		privileged:       true,
+		expectAllowed:    false,
+		expectAuditValue: "hostPorts",
+	}, {
+		desc:          "disabled",
+		namespace:     namespace(api.LevelBaseline, "privileged"),
+		privileged:    true,
+		disabled:      true,
+		expectAllowed: false,
+	}, {
+		desc:             "above floor",
+		floor:            admissionapi.PodSecurityFloor{Enforce: "baseline"},
+		namespace:        namespace(api.LevelRestricted, restrictedChecks),
+		expectAllowed:    true,
+		expectAuditValue: restrictedChecks,
+	}, {
+		desc:             "evaluated by floor",
+		floor:            admissionapi.PodSecurityFloor{Enforce: "baseline"},
+		namespace:        namespace(api.LevelRestricted, restrictedChecks+",privileged"),
+		privileged:       true,
+		expectAllowed:    false,
+		expectAuditValue: restrictedChecks + "; ignored privileged evaluated by the enforce floor",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			a := newAdmission(tc.floor, tc.namespace)
+			a.NamespaceCheckExemptions = !tc.disabled
+			response := a.Validate(ctx, podAttrs(tc.privileged))
+			assert.Equal(t, tc.expectAllowed, response.Allowed)
+			assert.Equal(t, tc.expectAuditValue, response.AuditAnnotations[api.ExemptChecksAnnotationKey])
+
+			// exported policies exempt the same checks as the webhook
+			exempt, _ := a.namespaceExemptChecks(tc.namespace)
+			expectExported := map[policy.CheckID][]string{}
+			for _, id := range exempt {
+				expectExported[id] = []string{tc.namespace.Name}
+			}
+			assert.Equal(t, expectExported, export.ExemptNamespacesByCheck([]*corev1.Namespace{tc.namespace}, export.ExemptionOptions{
+				NamespaceCheckExemptions: a.NamespaceCheckExemptions,
+				Evaluator:                a.Evaluator,
+				EnforceFloor:             a.enforceFloor,
+			}))
+		})
+	}
+}
+
+func TestNamespaceExemptChecksUpdates(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+	require.NoError(t, err)
+	namespace := func(level api.Level, exemptChecks string, confirmed bool) *corev1.Namespace {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:   "ns",
+			Labels: map[string]string{api.EnforceLevelLabel: string(level)},
+		}}
+		if exemptChecks != "" {
+			ns.Annotations = map[string]string{api.ExemptChecksAnnotation: exemptChecks}
+		}
+		if confirmed {
+			metav1.SetMetaDataAnnotation(&ns.ObjectMeta, api.ConfirmPrivilegedAnnotation, "true")
+		}
+		return ns
+	}
+
+	testCases := []struct {
+		desc         string
+		namespace    *corev1.Namespace
+		oldNamespace *corev1.Namespace
+		disabled     bool
+		confirmation admissionapi.PodSecurityPrivilegedConfirmation
+		expectError  string
+	}{{
+		desc:      "create with known checks",
+		namespace: namespace(api.LevelBaseline, "privileged,hostPorts", false),
+	}, {
+		desc:        "create with unknown checks",
+		namespace:   namespace(api.LevelBaseline, "privileged,hostPort,example.com/unknown", false),
+		expectError: `metadata.annotations[pod-security.kubernetes.io/exempt-checks]: Invalid value: "privileged,hostPort,example.com/unknown": unknown checks: example.com/unknown, hostPort`,
+	}, {
+		desc:      "create with unknown checks when disabled",
+		namespace: namespace(api.LevelBaseline, "hostPort", false),
+		disabled:  true,
+	}, {
+		desc:         "update adding unknown checks",
+		namespace:    namespace(api.LevelBaseline, "hostPort", false),
+		oldNamespace: namespace(api.LevelBaseline, "", false),
+		expectError:  `unknown checks: hostPort`,
+	}, {
+		desc:         "update keeping unknown checks",
+		namespace:    namespace(api.LevelRestricted, "hostPort", false),
+		oldNamespace: namespace(api.LevelBaseline, "hostPort", false),
+	}, {
+		desc:         "update adding exemptions without required confirmation",
+		namespace:    namespace(api.LevelBaseline, "privileged", false),
+		oldNamespace: namespace(api.LevelBaseline, "", false),
+	}, {
+		desc:         "update adding exemptions without confirmation",
+		namespace:    namespace(api.LevelBaseline, "privileged,hostPorts", false),
+		oldNamespace: namespace(api.LevelBaseline, "hostPorts", false),
+		confirmation: admissionapi.PodSecurityPrivilegedConfirmation{Required: true},
+		expectError:  `PodSecurity checks privileged exempted without confirmation, set the pod-security.kubernetes.io/confirm-privileged annotation to "true" to confirm`,
+	}, {
+		desc:         "update relaxing labels and adding exemptions without confirmation",
+		namespace:    namespace(api.LevelBaseline, "privileged", false),
+		oldNamespace: namespace(api.LevelRestricted, "", false),
+		confirmation: admissionapi.PodSecurityPrivilegedConfirmation{Required: true},
+		expectError:  `PodSecurity checks privileged exempted without confirmation`,
+	}, {
+		desc:         "update adding exemptions with confirmation",
+		namespace:    namespace(api.LevelBaseline, "privileged", true),
+		oldNamespace: namespace(api.LevelBaseline, "", false),
+		confirmation: admissionapi.PodSecurityPrivilegedConfirmation{Required: true},
+	}, {
+		desc:         "update adding exemptions of allowed namespace",
+		namespace:    namespace(api.LevelBaseline, "privileged", false),
+		oldNamespace: namespace(api.LevelBaseline, "", false),
+		confirmation: admissionapi.PodSecurityPrivilegedConfirmation{Required: true, AllowedNamespaces: []string{"ns"}},
+	}, {
+		desc:         "update removing exemptions",
+		namespace:    namespace(api.LevelBaseline, "hostPorts", false),
+		oldNamespace: namespace(api.LevelBaseline, "privileged,hostPorts", false),
+		confirmation: admissionapi.PodSecurityPrivilegedConfirmation{Required: true},
+	}, {
+		desc:         "update adding exemptions when disabled",
+		namespace:    namespace(api.LevelBaseline, "privileged", false),
+		oldNamespace: namespace(api.LevelBaseline, "", false),
+		disabled:     true,
+		confirmation: admissionapi.PodSecurityPrivilegedConfirmation{Required: true},
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			config, err := load.LoadFromData(nil)
+			require.NoError(t, err)
+			config.PrivilegedConfirmation = tc.confirmation
+			a := &Admission{
+				PodLister:                &testPodLister{},
+				Evaluator:                evaluator,
+				Configuration:            config,
+				Metrics:                  &FakeRecorder{},
+				NamespaceGetter:          testNamespaceGetter{},
+				NamespaceCheckExemptions: !tc.disabled,
+			}
+			require.NoError(t, a.CompleteConfiguration())
+			require.NoError(t, a.ValidateConfiguration())
+
+			attrs := &api.AttributesRecord{
+				Name:      "ns",
+				Namespace: "ns",
+				Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Namespace"},
+				Resource:  schema.GroupVersionResource{Version: "v1", Resource: "namespaces"},
+				Operation: admissionv1.Create,
+				Object:    tc.namespace,
+			}
+			if tc.oldNamespace != nil {
+				attrs.Operation = admissionv1.Update
+				attrs.OldObject = tc.oldNamespace
+			}
+			response := a.ValidateNamespace(ctx, attrs)
+			if tc.expectError == "" {
+				assert.True(t, response.Allowed, response.Result)
+				return
+			}
+			require.False(t, response.Allowed)
+			assert.Contains(t, response.Result.Message, tc.expectError)
+		})
+	}
+}
+
+func TestParseCheckOptOutPublicKeys(t *testing.T) {
+	publicKey, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+	data := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	keys, err := ParseCheckOptOutPublicKeys(data)
+	require.NoError(t, err)
+	assert.Equal(t, []ed25519.PublicKey{publicKey}, keys)
+
+	_, err = ParseCheckOptOutPublicKeys([]byte("not a key"))
+	assert.Error(t, err)
+}
+
+func TestEnforceFloor(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	config.Floor = admissionapi.PodSecurityFloor{Enforce: "baseline", EnforceVersion: "v1.25"}
+	config.Exemptions.Namespaces = []string{"exempt"}
+	privileged := map[string]string{api.EnforceLevelLabel: "privileged"}
+	nsGetter := testNamespaceGetter{
+		"ns":     {ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: privileged}},
+		"exempt": {ObjectMeta: metav1.ObjectMeta{Name: "exempt", Labels: privileged}},
+	}
+	a := &Admission{
+		PodLister:       &testPodLister{},
+		Evaluator:       &testEvaluator{},
+		Configuration:   config,
+		Metrics:         &FakeRecorder{},
+		NamespaceGetter: nsGetter,
+	}
+	require.NoError(t, a.CompleteConfiguration())
+	require.NoError(t, a.ValidateConfiguration())
+
+	tests := []struct {
+		labels   map[string]string
+		expected string
+	}{
+		{labels: nil, expected: "baseline:v1.25"},
+		{labels: privileged, expected: "baseline:v1.25"},
+		{labels: map[string]string{api.EnforceLevelLabel: "baseline", api.EnforceVersionLabel: "v1.23"}, expected: "baseline:v1.25"},
+		{labels: map[string]string{api.EnforceLevelLabel: "baseline", api.EnforceVersionLabel: "latest"}, expected: "baseline:latest"},
+		{labels: map[string]string{api.EnforceLevelLabel: "restricted", api.EnforceVersionLabel: "v1.23"}, expected: "restricted:v1.23"},
+	}
+	for _, tc := range tests {
+		p, errs := a.PolicyToEvaluate(tc.labels)
+		assert.Empty(t, errs)
+		assert.Equal(t, tc.expected, p.Enforce.String(), "labels: %v", tc.labels)
+	}
+
+	podAttrs := func(namespace string) *api.AttributesRecord {
+		return &api.AttributesRecord{
+			Name:      "test-pod",
+			Namespace: namespace,
+			Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+			Operation: admissionv1.Create,
+			Object:    &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: namespace, Annotations: map[string]string{"error": "privileged"}}},
+		}
+	}
+	response := a.Validate(ctx, podAttrs("ns"))
+	assert.False(t, response.Allowed)
+	if assert.NotNil(t, response.Result) {
+		assert.Contains(t, response.Result.Message, `violates PodSecurity "baseline:v1.25"`)
+	}
+
+	// the floor does not apply to exempt namespaces
+	response = a.Validate(ctx, podAttrs("exempt"))
+	assert.True(t, response.Allowed)
+	nsAttrs := &api.AttributesRecord{
+		Name:      "exempt",
+		Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Namespace"},
+		Resource:  schema.GroupVersionResource{Version: "v1", Resource: "namespaces"},
+		Operation: admissionv1.Create,
+		Object:    &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "exempt", Labels: privileged}},
+	}
+	response = a.Validate(ctx, nsAttrs)
+	assert.True(t, response.Allowed)
+	assert.Empty(t, response.Warnings)
+}
+
+func TestEnforcePolicySource(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	config.Floor = admissionapi.PodSecurityFloor{Enforce: "baseline", EnforceVersion: "v1.25"}
+	a := &Admission{
+		PodLister:       &testPodLister{},
+		Evaluator:       &testEvaluator{},
+		Configuration:   config,
+		Metrics:         &FakeRecorder{},
+		NamespaceGetter: testNamespaceGetter{},
+	}
+	require.NoError(t, a.CompleteConfiguration())
+	require.NoError(t, a.ValidateConfiguration())
+
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		lenient  bool
+		expected string
+	}{{
+		name:     "floor",
+		expected: `cluster floor enforce="baseline", cluster floor enforce-version="v1.25"`,
+	}, {
+		name:     "labels",
+		labels:   map[string]string{api.EnforceLevelLabel: "restricted", api.EnforceVersionLabel: "v1.26"},
+		expected: `namespace label pod-security.kubernetes.io/enforce="restricted", namespace label pod-security.kubernetes.io/enforce-version="v1.26"`,
+	}, {
+		name:     "default version",
+		labels:   map[string]string{api.EnforceLevelLabel: "restricted"},
+		expected: `namespace label pod-security.kubernetes.io/enforce="restricted", default enforce-version="latest"`,
+	}, {
+		name:     "version below floor",
+		labels:   map[string]string{api.EnforceLevelLabel: "baseline", api.EnforceVersionLabel: "v1.23"},
+		expected: `namespace label pod-security.kubernetes.io/enforce="baseline", cluster floor enforce-version="v1.25"`,
+	}, {
+		name:     "invalid",
+		labels:   map[string]string{api.EnforceLevelLabel: "Restricted"},
+		expected: `invalid namespace label pod-security.kubernetes.io/enforce="Restricted", default enforce-version="latest"`,
+	}, {
+		name:     "lenient",
+		labels:   map[string]string{api.EnforceLevelLabel: "Restricted"},
+		lenient:  true,
+		expected: `namespace label pod-security.kubernetes.io/enforce="Restricted", default enforce-version="latest"`,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			a.LenientLabelParsing = tc.lenient
+			p, _ := a.PolicyToEvaluate(tc.labels)
+			assert.Equal(t, tc.expected, describeEnforceStatus(a.enforceStatus(tc.labels, p.Enforce)))
+		})
+	}
+
+	a.LenientLabelParsing = false
+	a.NamespaceGetter = testNamespaceGetter{"ns": {ObjectMeta: metav1.ObjectMeta{Name: "ns"}}}
+	attrs := &api.AttributesRecord{
+		Name:      "test-pod",
+		Namespace: "ns",
+		Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+		Operation: admissionv1.Create,
+		Object:    &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "ns", Annotations: map[string]string{"error": "host ports"}}},
+	}
+	response := a.Validate(ctx, attrs)
+	assert.False(t, response.Allowed)
+	if assert.NotNil(t, response.Result) {
+		assert.Equal(t, `pods "test-pod" is forbidden: violates PodSecurity "baseline:v1.25" (from cluster floor enforce="baseline", cluster floor enforce-version="v1.25"): host ports`, response.Result.Message)
+	}
+	assert.Equal(t, `cluster floor enforce="baseline", cluster floor enforce-version="v1.25"`, response.AuditAnnotations[api.EnforcedPolicySourceAnnotationKey])
+}
+
+func TestPolicyStatus(t *testing.T) {
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	config.Floor = admissionapi.PodSecurityFloor{Enforce: "baseline", EnforceVersion: "v1.25"}
+	a := &Admission{
+		PodLister:       &testPodLister{},
+		Evaluator:       &testEvaluator{},
+		Configuration:   config,
+		Metrics:         &FakeRecorder{},
+		NamespaceGetter: testNamespaceGetter{},
+	}
+	require.NoError(t, a.CompleteConfiguration())
+	require.NoError(t, a.ValidateConfiguration())
+
+	labels := map[string]string{api.AuditLevelLabel: "Restricted", api.WarnLevelLabel: "baseline", api.WarnVersionLabel: "v1.20"}
+	status := a.PolicyStatus(labels)
+	assert.Equal(t, api.LabelSourceFloor, status.Enforce.Level.Source)
+	assert.Equal(t, "baseline", status.Enforce.Level.Effective)
+	assert.Equal(t, api.LabelSourceFallback, status.Audit.Level.Source)
+	assert.Equal(t, "privileged", status.Audit.Level.Effective)
+	assert.Equal(t, api.LabelSourceLabel, status.Warn.Version.Source)
+	assert.Equal(t, "v1.20", status.Warn.Version.Effective)
+
+	a.LenientLabelParsing = true
+	status = a.PolicyStatus(labels)
+	assert.Equal(t, api.LabelSourceLabel, status.Audit.Level.Source)
+	assert.Equal(t, "restricted", status.Audit.Level.Effective)
+	assert.Empty(t, status.Audit.Level.Error)
+}
+
+func TestWindowsPodModeWarnings(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks(), policy.WithWindowsPodMode(policy.WindowsPodModeWarn))
+	require.NoError(t, err)
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	restricted := map[string]string{api.EnforceLevelLabel: "restricted"}
+	a := &Admission{
+		PodLister:       &testPodLister{},
+		Evaluator:       evaluator,
+		Configuration:   config,
+		Metrics:         &FakeRecorder{},
+		NamespaceGetter: testNamespaceGetter{"ns": {ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: restricted}}},
+	}
+	require.NoError(t, a.CompleteConfiguration())
+	require.NoError(t, a.ValidateConfiguration())
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "ns"},
+		Spec: corev1.PodSpec{
+			OS:              &corev1.PodOS{Name: corev1.Windows},
+			SecurityContext: &corev1.PodSecurityContext{RunAsNonRoot: pointer.Bool(true)},
+			Containers:      []corev1.Container{{Name: "a"}},
+		},
+	}
+	attrs := &api.AttributesRecord{
+		Name:      "test-pod",
+		Namespace: "ns",
+		Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+		Operation: admissionv1.Create,
+		Object:    pod,
+	}
+	response := a.Validate(ctx, attrs)
+	assert.True(t, response.Allowed)
+	assert.ElementsMatch(t, []string{
+		`PodSecurity "restricted:latest": allowPrivilegeEscalation != false (container "a" must set securityContext.allowPrivilegeEscalation=false) is not enforced for Windows pods`,
+		`PodSecurity "restricted:latest": unrestricted capabilities (container "a" must set securityContext.capabilities.drop=["ALL"]) is not enforced for Windows pods`,
+		`PodSecurity "restricted:latest": seccompProfile (pod or container "a" must set securityContext.seccompProfile.type to "RuntimeDefault" or "Localhost") is not enforced for Windows pods`,
+	}, response.Warnings)
+}
+
+type versionSkewRecorder struct {
+	FakeRecorder
+	skews []string
+}
+
+func (r *versionSkewRecorder) RecordVersionSkew(evalMode metrics.Mode, skew api.VersionSkew, attrs api.Attributes) {
+	r.skews = append(r.skews, fmt.Sprintf("%s=%s", evalMode, skew))
+}
+
+func TestVersionSkew(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+	require.NoError(t, err)
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	skewed := map[string]string{
+		api.EnforceLevelLabel:   "baseline",
+		api.EnforceVersionLabel: "v1.25",
+		api.AuditLevelLabel:     "baseline",
+		api.AuditVersionLabel:   "v1.24",
+		api.WarnLevelLabel:      "restricted",
+		api.WarnVersionLabel:    "latest",
+	}
+	recorder := &versionSkewRecorder{}
+	a := &Admission{
+		PodLister:       &testPodLister{},
+		Evaluator:       evaluator,
+		Configuration:   config,
+		Metrics:         recorder,
+		NamespaceGetter: testNamespaceGetter{"ns": {ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: skewed}}},
+		WarnVersionSkew: true,
+	}
+	require.NoError(t, a.CompleteConfiguration())
+	require.NoError(t, a.ValidateConfiguration())
+	expectedWarnings := []string{
+		`PodSecurity audit version "v1.24" is older than enforce version "v1.25", which is likely a misconfiguration`,
+		`PodSecurity warn version "latest" is newer than enforce version "v1.25"`,
+	}
+
+	t.Run("pod", func(t *testing.T) {
+		recorder.skews = nil
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "ns"},
+			Spec: corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{
+					RunAsNonRoot:   pointer.Bool(true),
+					SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+				},
+				Containers: []corev1.Container{{Name: "a", SecurityContext: &corev1.SecurityContext{
+					AllowPrivilegeEscalation: pointer.Bool(false),
+					Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+				}}},
+			},
+		}
+		response := a.Validate(ctx, &api.AttributesRecord{
+			Name:      "test-pod",
+			Namespace: "ns",
+			Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+			Operation: admissionv1.Create,
+			Object:    pod,
+		})
+		assert.True(t, response.Allowed)
+		assert.Equal(t, expectedWarnings, response.Warnings)
+		assert.Equal(t, []string{"audit=older", "warn=newer"}, recorder.skews)
+	})
+
+	namespaceAttrs := func(op admissionv1.Operation, labels, oldLabels map[string]string) api.Attributes {
+		attrs := &api.AttributesRecord{
+			Name:      "other",
+			Namespace: "other",
+			Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Namespace"},
+			Resource:  schema.GroupVersionResource{Version: "v1", Resource: "namespaces"},
+			Operation: op,
+			Object:    &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other", Labels: labels}},
+		}
+		if oldLabels != nil {
+			attrs.OldObject = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other", Labels: oldLabels}}
+		}
+		return attrs
+	}
+
+	t.Run("namespace create", func(t *testing.T) {
+		response := a.Validate(ctx, namespaceAttrs(admissionv1.Create, skewed, nil))
+		assert.True(t, response.Allowed)
+		assert.Equal(t, expectedWarnings, response.Warnings)
+	})
+
+	t.Run("namespace update with changed skew", func(t *testing.T) {
+		response := a.Validate(ctx, namespaceAttrs(admissionv1.Update, skewed, map[string]string{api.EnforceLevelLabel: "baseline"}))
+		assert.True(t, response.Allowed)
+		assert.Equal(t, expectedWarnings, response.Warnings)
+	})
+
+	t.Run("namespace update with unchanged skew", func(t *testing.T) {
+		labels := map[string]string{}
+		for k, v := range skewed {
+			labels[k] = v
+		}
+		labels["other"] = "label"
+		response := a.Validate(ctx, namespaceAttrs(admissionv1.Update, labels, skewed))
+		assert.True(t, response.Allowed)
+		assert.Empty(t, response.Warnings)
+	})
+
+	t.Run("namespace without skew", func(t *testing.T) {
+		response := a.Validate(ctx, namespaceAttrs(admissionv1.Create, map[string]string{api.EnforceLevelLabel: "baseline"}, nil))
+		assert.True(t, response.Allowed)
+		assert.Empty(t, response.Warnings)
+	})
+}
+
+func TestValidatePodControllerUpdateOfUnevaluatedFields(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+	require.NoError(t, err)
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	recorder := &FakeRecorder{}
+	a := &Admission{
+		PodLister:        &testPodLister{},
+		Evaluator:        evaluator,
+		Configuration:    config,
+		Metrics:          recorder,
+		PodSpecExtractor: DefaultPodSpecExtractor{},
+		NamespaceGetter:  testNamespaceGetter{"ns": {ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: map[string]string{api.WarnLevelLabel: "baseline"}}}},
+	}
+	require.NoError(t, a.CompleteConfiguration())
+	require.NoError(t, a.ValidateConfiguration())
+
+	deployment := func(replicas int32, image string) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-deployment", Namespace: "ns"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: pointer.Int32(replicas),
+				Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+					HostNetwork: true,
+					Containers:  []corev1.Container{{Name: "a", Image: image}},
+				}},
+			},
+		}
+	}
+	update := func(oldDeployment, deployment *appsv1.Deployment) api.Attributes {
+		return &api.AttributesRecord{
+			Name:      "test-deployment",
+			Namespace: "ns",
+			Kind:      schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+			Resource:  schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			Operation: admissionv1.Update,
+			Object:    deployment,
+			OldObject: oldDeployment,
+		}
+	}
+
+	t.Run("scaled", func(t *testing.T) {
+		recorder.evaluations = nil
+		response := a.Validate(ctx, update(deployment(1, "app:1"), deployment(3, "app:1")))
+		assert.True(t, response.Allowed)
+		assert.Empty(t, response.Warnings)
+		assert.Empty(t, recorder.evaluations)
+	})
+
+	t.Run("image updated", func(t *testing.T) {
+		recorder.evaluations = nil
+		response := a.Validate(ctx, update(deployment(1, "app:1"), deployment(1, "app:2")))
+		assert.True(t, response.Allowed)
+		assert.Equal(t, []string{`would violate PodSecurity "baseline:latest": host namespaces (hostNetwork=true)`}, response.Warnings)
+		assert.NotEmpty(t, recorder.evaluations)
+	})
+}
+
+type testPodControllerGetter map[string]runtime.Object
+
+func (t testPodControllerGetter) GetPodController(ctx context.Context, resource schema.GroupResource, namespace, name string) (runtime.Object, error) {
+	if controller, ok := t[resource.Resource+"/"+name]; ok {
+		return controller, nil
+	}
+	return nil, fmt.Errorf("%s %q not found", resource, name)
+}
+
+func TestSubresourceWarnings(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+	require.NoError(t, err)
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+
+	template := corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+		HostNetwork: true,
+		Containers:  []corev1.Container{{Name: "a", Image: "app:1"}},
+	}}
+	getter := testPodControllerGetter{
+		"replicasets/web-1": &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "ns", OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Controller: pointer.Bool(true)},
+			}},
+			Spec: appsv1.ReplicaSetSpec{Template: template},
+		},
+		"statefulsets/db": &appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "ns"},
+			Spec:       appsv1.StatefulSetSpec{Template: template},
+		},
+	}
+	scale := func(resource, name string, oldReplicas, replicas int32) api.Attributes {
+		return &api.AttributesRecord{
+			Name:        name,
+			Namespace:   "ns",
+			Kind:        schema.GroupVersionKind{Group: "autoscaling", Version: "v1", Kind: "Scale"},
+			Resource:    schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: resource},
+			Subresource: "scale",
+			Operation:   admissionv1.Update,
+			Object:      &autoscalingv1.Scale{Spec: autoscalingv1.ScaleSpec{Replicas: replicas}},
+			OldObject:   &autoscalingv1.Scale{Spec: autoscalingv1.ScaleSpec{Replicas: oldReplicas}},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		warnings       SubresourceWarnings
+		attrs          api.Attributes
+		expectWarnings []string
+	}{
+		{
+			name:     "none",
+			warnings: SubresourceWarningsNone,
+			attrs:    scale("replicasets", "web-1", 0, 3),
+		},
+		{
+			name:           "detailed, attributed to deployment",
+			warnings:       SubresourceWarningsDetailed,
+			attrs:          scale("replicasets", "web-1", 0, 3),
+			expectWarnings: []string{`deployment "web" (replicaset "web-1"): would violate PodSecurity "baseline:latest": host namespaces (hostNetwork=true)`},
+		},
+		{
+			name:           "detailed",
+			warnings:       SubresourceWarningsDetailed,
+			attrs:          scale("statefulsets", "db", 1, 2),
+			expectWarnings: []string{`statefulset "db": would violate PodSecurity "baseline:latest": host namespaces (hostNetwork=true)`},
+		},
+		{
+			name:           "summary",
+			warnings:       SubresourceWarningsSummary,
+			attrs:          scale("replicasets", "web-1", 0, 3),
+			expectWarnings: []string{`scaling deployment "web" (replicaset "web-1") creates pods that would violate PodSecurity "baseline:latest": hostNamespaces`},
+		},
+		{
+			name:     "scaled down",
+			warnings: SubresourceWarningsDetailed,
+			attrs:    scale("replicasets", "web-1", 3, 0),
+		},
+		{
+			name:     "controller not found",
+			warnings: SubresourceWarningsDetailed,
+			attrs:    scale("replicasets", "web-2", 0, 3),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			a := &Admission{
+				PodLister:           &testPodLister{},
+				Evaluator:           evaluator,
+				Configuration:       config,
+				Metrics:             &FakeRecorder{},
+				PodSpecExtractor:    DefaultPodSpecExtractor{},
+				NamespaceGetter:     testNamespaceGetter{"ns": {ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: map[string]string{api.WarnLevelLabel: "baseline"}}}},
+				PodControllerGetter: getter,
+				SubresourceWarnings: tc.warnings,
+			}
+			require.NoError(t, a.CompleteConfiguration())
+			require.NoError(t, a.ValidateConfiguration())
+
+			response := a.Validate(ctx, tc.attrs)
+			assert.True(t, response.Allowed)
+			assert.Equal(t, tc.expectWarnings, response.Warnings)
+		})
+	}
+
+	t.Run("getter required", func(t *testing.T) {
+		a := &Admission{
+			PodLister:           &testPodLister{},
+			Evaluator:           evaluator,
+			Configuration:       config,
+			Metrics:             &FakeRecorder{},
+			NamespaceGetter:     testNamespaceGetter{},
+			SubresourceWarnings: SubresourceWarningsSummary,
+		}
+		require.NoError(t, a.CompleteConfiguration())
+		assert.Error(t, a.ValidateConfiguration())
+	})
+}
+
+func TestEphemeralContainerUpdates(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks(), policy.WithFieldErrors())
+	require.NoError(t, err)
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	a := &Admission{
+		PodLister:     &testPodLister{},
+		Evaluator:     evaluator,
+		Configuration: config,
+		Metrics:       &FakeRecorder{},
+		NamespaceGetter: testNamespaceGetter{
+			"baseline": {ObjectMeta: metav1.ObjectMeta{Name: "baseline", Labels: map[string]string{
+				api.EnforceLevelLabel: string(api.LevelBaseline),
+				api.AuditLevelLabel:   string(api.LevelBaseline),
+				api.WarnLevelLabel:    string(api.LevelBaseline),
+			}}},
+		},
+		AuditViolationsDetail: true,
+	}
+	require.NoError(t, a.CompleteConfiguration())
+	require.NoError(t, a.ValidateConfiguration())
+
+	debugContainer := func(name string, privileged bool) corev1.EphemeralContainer {
+		return corev1.EphemeralContainer{EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+			Name:            name,
+			Image:           "busybox",
+			SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(privileged)},
+		}}
+	}
+	// the pod predates the baseline enforce level of its namespace, and an earlier debug container
+	oldPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "baseline"},
+		Spec: corev1.PodSpec{
+			HostNetwork:         true,
+			Containers:          []corev1.Container{{Name: "app", Image: "app"}},
+			EphemeralContainers: []corev1.EphemeralContainer{debugContainer("debugger-old", true)},
+		},
+	}
+	updateAttrs := func(subresource string, debugger corev1.EphemeralContainer) *api.AttributesRecord {
+		pod := oldPod.DeepCopy()
+		pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, debugger)
+		return &api.AttributesRecord{
+			Name:        "test-pod",
+			Namespace:   "baseline",
+			Kind:        schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Resource:    schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+			Subresource: subresource,
+			Operation:   admissionv1.Update,
+			Object:      pod,
+			OldObject:   oldPod,
+		}
+	}
+
+	t.Run("compliant container", func(t *testing.T) {
+		response := a.Validate(ctx, updateAttrs("ephemeralcontainers", debugContainer("debugger", false)))
+		assert.True(t, response.Allowed)
+		assert.Empty(t, response.Warnings)
+		assert.NotContains(t, response.AuditAnnotations, api.AuditViolationsAnnotationKey)
+	})
+
+	t.Run("violating container", func(t *testing.T) {
+		response := a.Validate(ctx, updateAttrs("ephemeralcontainers", debugContainer("debugger", true)))
+		require.False(t, response.Allowed)
+		assert.True(t, strings.HasSuffix(response.Result.Message, `: privileged (container "debugger" must not set securityContext.privileged=true)`), response.Result.Message)
+		detail, err := api.ParseAuditViolationsDetail(response.AuditAnnotations[api.AuditViolationsDetailAnnotationKey])
+		require.NoError(t, err)
+		assert.Equal(t, []api.AuditViolation{{
+			Check:      "privileged",
+			Reason:     "privileged",
+			Severity:   string(policy.SeverityCritical),
+			Containers: []string{"debugger"},
+			Fields:     []string{"spec.ephemeralContainers[1].securityContext.privileged"},
+		}}, detail.Violations)
+	})
+
+	t.Run("pod update", func(t *testing.T) {
+		// updates of the pod itself are evaluated in full
+		response := a.Validate(ctx, updateAttrs("", debugContainer("debugger", false)))
+		require.False(t, response.Allowed)
+		assert.Contains(t, response.Result.Message, "host namespaces")
+		assert.Contains(t, response.Result.Message, `container "debugger-old" must not set securityContext.privileged=true`)
+	})
+}
+
+func TestResizeUpdates(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	evaluator, err := policy.NewEvaluator(append(policy.DefaultChecks(), policy.CheckResourceLimits()))
+	require.NoError(t, err)
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	a := &Admission{
+		PodLister:     &testPodLister{},
+		Evaluator:     evaluator,
+		Configuration: config,
+		Metrics:       &FakeRecorder{},
+		NamespaceGetter: testNamespaceGetter{
+			"baseline": {ObjectMeta: metav1.ObjectMeta{Name: "baseline", Labels: map[string]string{
+				api.EnforceLevelLabel: string(api.LevelBaseline),
+			}}},
+		},
+	}
+	require.NoError(t, a.CompleteConfiguration())
+	require.NoError(t, a.ValidateConfiguration())
+
+	// the pod predates the baseline enforce level of its namespace
+	oldPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "baseline"},
+		Spec: corev1.PodSpec{
+			HostNetwork: true,
+			Containers: []corev1.Container{{
+				Name:  "app",
+				Image: "app",
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("1"),
+					corev1.ResourceMemory: resource.MustParse("1Gi"),
+				}},
+			}},
+		},
+	}
+	// the sidecar predates the resourceLimits requirement
+	unlimitedSidecarPod := oldPod.DeepCopy()
+	unlimitedSidecarPod.Spec.Containers = append(unlimitedSidecarPod.Spec.Containers, corev1.Container{Name: "sidecar", Image: "sidecar"})
+	resizePodAttrs := func(oldPod *corev1.Pod, subresource string, resize func(*corev1.ResourceList)) *api.AttributesRecord {
+		pod := oldPod.DeepCopy()
+		resize(&pod.Spec.Containers[0].Resources.Limits)
+		return &api.AttributesRecord{
+			Name:        "test-pod",
+			Namespace:   "baseline",
+			Kind:        schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Resource:    schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+			Subresource: subresource,
+			Operation:   admissionv1.Update,
+			Object:      pod,
+			OldObject:   oldPod,
+		}
+	}
+	resizeAttrs := func(subresource string, resize func(*corev1.ResourceList)) *api.AttributesRecord {
+		return resizePodAttrs(oldPod, subresource, resize)
+	}
+
+	for _, subresource := range []string{"resize", ""} {
+		t.Run("resized limits "+subresource, func(t *testing.T) {
+			response := a.Validate(ctx, resizeAttrs(subresource, func(limits *corev1.ResourceList) {
+				(*limits)[corev1.ResourceCPU] = resource.MustParse("2")
+			}))
+			assert.True(t, response.Allowed, "the violations of the other checks cannot deny resizes")
+			assert.Empty(t, response.Warnings)
+		})
+
+		t.Run("removed limits "+subresource, func(t *testing.T) {
+			response := a.Validate(ctx, resizeAttrs(subresource, func(limits *corev1.ResourceList) {
+				delete(*limits, corev1.ResourceMemory)
+			}))
+			require.False(t, response.Allowed)
+			assert.True(t, strings.HasSuffix(response.Result.Message, `: resource limits (container "app" must set cpu, memory limits)`), response.Result.Message)
+		})
+
+		t.Run("resized limits with preexisting violation "+subresource, func(t *testing.T) {
+			response := a.Validate(ctx, resizePodAttrs(unlimitedSidecarPod, subresource, func(limits *corev1.ResourceList) {
+				(*limits)[corev1.ResourceCPU] = resource.MustParse("2")
+			}))
+			assert.True(t, response.Allowed, "the violations the pod already had cannot deny resizes")
+			assert.Empty(t, response.Warnings)
+		})
+
+		t.Run("removed limits with preexisting violation "+subresource, func(t *testing.T) {
+			response := a.Validate(ctx, resizePodAttrs(unlimitedSidecarPod, subresource, func(limits *corev1.ResourceList) {
+				delete(*limits, corev1.ResourceMemory)
+			}))
+			require.False(t, response.Allowed, "the violations introduced by resizes are denied")
+			assert.True(t, strings.HasSuffix(response.Result.Message, `: resource limits (containers "app", "sidecar" must set cpu, memory limits)`), response.Result.Message)
+		})
+	}
+}
+
+func TestDenialSnippets(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+	require.NoError(t, err)
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	a := &Admission{
+		PodLister:     &testPodLister{},
+		Evaluator:     evaluator,
+		Configuration: config,
+		Metrics:       &FakeRecorder{},
+		NamespaceGetter: testNamespaceGetter{
+			"restricted": {ObjectMeta: metav1.ObjectMeta{Name: "restricted", Labels: map[string]string{
+				api.EnforceLevelLabel: string(api.LevelRestricted),
+			}}},
+		},
+		DenialSnippets: []policy.CheckID{"runAsNonRoot", "allowPrivilegeEscalation"},
+	}
+	require.NoError(t, a.CompleteConfiguration())
+	require.NoError(t, a.ValidateConfiguration())
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "restricted"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app", Image: "app"}},
+		},
+	}
+	response := a.Validate(ctx, &api.AttributesRecord{
+		Name:      "test-pod",
+		Namespace: "restricted",
+		Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+		Operation: admissionv1.Create,
+		Object:    pod,
+	})
+	require.False(t, response.Allowed)
+	message := response.Result.Message
+	assert.Contains(t, message, "\nto satisfy allowPrivilegeEscalation, set in each offending container:\nsecurityContext:\n  allowPrivilegeEscalation: false")
+	assert.Contains(t, message, "\nto satisfy runAsNonRoot, set in the pod spec:\nsecurityContext:\n  runAsNonRoot: true")
+	assert.NotContains(t, message, "to satisfy capabilities_restricted")
+	assert.NotContains(t, message, "to satisfy seccompProfile_restricted")
+	// snippets follow the order of the violations in the denial
+	assert.Less(t, strings.Index(message, "to satisfy allowPrivilegeEscalation"), strings.Index(message, "to satisfy runAsNonRoot"))
+
+	a.DenialSnippets = []policy.CheckID{"sysctls"}
+	assert.Error(t, a.ValidateConfiguration())
+}
+
+func TestParseSubresourceWarnings(t *testing.T) {
+	for _, warnings := range []string{"", "None", "Summary", "Detailed"} {
+		_, err := ParseSubresourceWarnings(warnings)
+		assert.NoError(t, err, warnings)
+	}
+	_, err := ParseSubresourceWarnings("detailed")
+	assert.Error(t, err)
+}
+
+type deprecatedFieldRecorder struct {
+	FakeRecorder
+	fields []string
+}
+
+func (r *deprecatedFieldRecorder) RecordDeprecatedField(field string, attrs api.Attributes) {
+	r.fields = append(r.fields, field)
+}
+
+func TestDeprecatedFields(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+	require.NoError(t, err)
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	recorder := &deprecatedFieldRecorder{}
+	a := &Admission{
+		PodLister:       &testPodLister{},
+		Evaluator:       evaluator,
+		Configuration:   config,
+		Metrics:         recorder,
+		NamespaceGetter: testNamespaceGetter{"ns": {ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: map[string]string{api.EnforceLevelLabel: "baseline"}}}},
+	}
+	require.NoError(t, a.CompleteConfiguration())
+	require.NoError(t, a.ValidateConfiguration())
+
+	podAttrs := func(apparmor string) api.Attributes {
+		return &api.AttributesRecord{
+			Name:      "test-pod",
+			Namespace: "ns",
+			Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+			Operation: admissionv1.Create,
+			Object: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "ns", Annotations: map[string]string{
+					"container.apparmor.security.beta.kubernetes.io/a": apparmor,
+					"container.apparmor.security.beta.kubernetes.io/b": apparmor,
+				}},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "a"}, {Name: "b"}}},
+			},
+		}
+	}
+
+	testCases := []struct {
+		desc           string
+		warn           bool
+		apparmor       string
+		expectAllowed  bool
+		expectWarnings []string
+	}{{
+		desc:          "recorded",
+		apparmor:      "runtime/default",
+		expectAllowed: true,
+	}, {
+		desc:          "warned",
+		warn:          true,
+		apparmor:      "runtime/default",
+		expectAllowed: true,
+		expectWarnings: []string{
+			"metadata.annotations[container.apparmor.security.beta.kubernetes.io/a] is deprecated, use spec.containers[0].securityContext.appArmorProfile instead",
+			"metadata.annotations[container.apparmor.security.beta.kubernetes.io/b] is deprecated, use spec.containers[1].securityContext.appArmorProfile instead",
+		},
+	}, {
+		desc:          "not warned for denied pods",
+		warn:          true,
+		apparmor:      "unconfined",
+		expectAllowed: false,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			recorder.fields = nil
+			a := *a
+			a.WarnDeprecatedFields = tc.warn
+			response := a.Validate(ctx, podAttrs(tc.apparmor))
+			assert.Equal(t, tc.expectAllowed, response.Allowed)
+			assert.Equal(t, tc.expectWarnings, response.Warnings)
+			assert.Equal(t, []string{"container.apparmor.security.beta.kubernetes.io/"}, recorder.fields)
+		})
+	}
+}
+
+func TestIdentityExtractor(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	nsGetter := testNamespaceGetter{
+		"restricted": {ObjectMeta: metav1.ObjectMeta{Name: "restricted", Labels: map[string]string{api.EnforceLevelLabel: string(api.LevelRestricted)}}},
+	}
+	config, err := load.LoadFromData([]byte(`
+apiVersion: pod-security.admission.config.k8s.io/v1
+kind: PodSecurityConfiguration
+exemptions:
+  usernames: ["exempt-user", "spiffe://cluster.local/ns/ci/sa/builder"]
+`))
+	require.NoError(t, err)
+	const spiffeKey = "example.com/spiffe-id"
+	podAttrs := func(username string, extra map[string]authenticationv1.ExtraValue) *api.AttributesRecord {
+		return &api.AttributesRecord{
+			Name:      "test-pod",
+			Namespace: "restricted",
+			Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+			Operation: admissionv1.Create,
+			Username:  username,
+			UserExtra: extra,
+			Object:    &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "restricted", Annotations: map[string]string{"error": "host ports"}}},
+		}
+	}
+	spiffeID := map[string]authenticationv1.ExtraValue{spiffeKey: {"spiffe://cluster.local/ns/ci/sa/builder"}}
+
+	testCases := []struct {
+		desc          string
+		extractor     IdentityExtractor
+		attrs         *api.AttributesRecord
+		expectAllowed bool
+	}{{
+		desc:          "default username",
+		attrs:         podAttrs("exempt-user", nil),
+		expectAllowed: true,
+	}, {
+		desc:          "default ignores extra",
+		attrs:         podAttrs("other-user", spiffeID),
+		expectAllowed: false,
+	}, {
+		desc:          "extra",
+		extractor:     UserExtraIdentity(spiffeKey),
+		attrs:         podAttrs("other-user", spiffeID),
+		expectAllowed: true,
+	}, {
+		desc:          "extra ignores username",
+		extractor:     UserExtraIdentity(spiffeKey),
+		attrs:         podAttrs("exempt-user", nil),
+		expectAllowed: false,
+	}, {
+		desc:          "other extra",
+		extractor:     UserExtraIdentity(spiffeKey),
+		attrs:         podAttrs("other-user", map[string]authenticationv1.ExtraValue{spiffeKey: {"spiffe://cluster.local/ns/ci/sa/other"}}),
+		expectAllowed: false,
+	}, {
+		desc:          "multiple",
+		extractor:     MultiIdentityExtractor(UsernameIdentity, UserExtraIdentity(spiffeKey)),
+		attrs:         podAttrs("exempt-user", nil),
+		expectAllowed: true,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			a := &Admission{
+				PodLister:         &testPodLister{},
+				Evaluator:         &testEvaluator{},
+				Configuration:     config,
+				Metrics:           &FakeRecorder{},
+				NamespaceGetter:   nsGetter,
+				IdentityExtractor: tc.extractor,
+			}
+			require.NoError(t, a.CompleteConfiguration())
+			require.NoError(t, a.ValidateConfiguration())
+			response := a.Validate(ctx, tc.attrs)
+			assert.Equal(t, tc.expectAllowed, response.Allowed)
+			if tc.expectAllowed {
+				assert.Equal(t, "user", response.AuditAnnotations[api.ExemptionReasonAnnotationKey])
+			}
+		})
+	}
+}
+
+// concurrentTestEvaluator is a testEvaluator safe for concurrent use, tracking the maximum concurrent evaluations.
+type concurrentTestEvaluator struct {
+	delay time.Duration
+
+	lock        sync.Mutex
+	running     int
+	maxRunning  int
+	evaluations int
+}
+
+func (t *concurrentTestEvaluator) EvaluatePod(lv api.LevelVersion, meta *metav1.ObjectMeta, spec *corev1.PodSpec) []policy.CheckResult {
+	t.lock.Lock()
+	t.running++
+	t.evaluations++
+	if t.running > t.maxRunning {
+		t.maxRunning = t.running
+	}
+	t.lock.Unlock()
+	defer func() {
+		t.lock.Lock()
+		t.running--
+		t.lock.Unlock()
+	}()
+
+	time.Sleep(t.delay)
+	if meta.Annotations["error"] != "" {
+		return []policy.CheckResult{{Allowed: false, ForbiddenReason: meta.Annotations["error"]}}
+	}
+	return []policy.CheckResult{{Allowed: true}}
+}
+
+func TestAuditNamespace(t *testing.T) {
+	var pods []*corev1.Pod
+	for i := 0; i < 20; i++ {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("pod%02d", i)}}
+		switch i % 4 {
+		case 1:
+			pod.Annotations = map[string]string{"error": "host ports"}
+		case 2:
+			pod.Annotations = map[string]string{"error": "privileged"}
+		}
+		pods = append(pods, pod)
+	}
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+	lv := api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()}
+
+	for _, parallelism := range []int{0, 1, 4} {
+		t.Run(fmt.Sprintf("parallelism %d", parallelism), func(t *testing.T) {
+			evaluator := &concurrentTestEvaluator{delay: 10 * time.Millisecond}
+			a := &Admission{
+				PodLister:           &testPodLister{pods: pods},
+				Evaluator:           evaluator,
+				Configuration:       &admissionapi.PodSecurityConfiguration{},
+				Metrics:             &FakeRecorder{},
+				NamespaceEvaluation: NamespaceEvaluationOptions{Parallelism: parallelism},
+			}
+			audit, err := a.AuditNamespace(context.Background(), namespace, lv)
+			require.NoError(t, err)
+			assert.Equal(t, &NamespaceAudit{
+				TotalPods:   20,
+				CheckedPods: 20,
+				Violations: []NamespaceViolation{
+					{Reason: "host ports", PodName: "pod01", PodNames: []string{"pod01", "pod05", "pod09", "pod13", "pod17"}, PodCount: 5},
+					{Reason: "privileged", PodName: "pod02", PodNames: []string{"pod02", "pod06", "pod10", "pod14", "pod18"}, PodCount: 5},
+				},
+			}, audit)
+			assert.Equal(t, 20, evaluator.evaluations)
+			if parallelism > 1 {
+				assert.Greater(t, evaluator.maxRunning, 1)
+				assert.LessOrEqual(t, evaluator.maxRunning, parallelism)
+			} else {
+				assert.Equal(t, 1, evaluator.maxRunning)
+			}
+		})
+	}
+
+	t.Run("cancelled", func(t *testing.T) {
+		evaluator := &concurrentTestEvaluator{delay: 10 * time.Millisecond}
+		a := &Admission{
+			PodLister:           &testPodLister{pods: pods},
+			Evaluator:           evaluator,
+			Configuration:       &admissionapi.PodSecurityConfiguration{},
+			Metrics:             &FakeRecorder{},
+			NamespaceEvaluation: NamespaceEvaluationOptions{Parallelism: 4},
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 25*time.Millisecond)
+		defer cancel()
+		audit, err := a.auditNamespacePods(ctx, namespace.Name, lv, nil, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 20, audit.TotalPods)
+		assert.Less(t, audit.CheckedPods, 20)
+		assert.Equal(t, evaluator.evaluations, audit.CheckedPods)
+	})
+}
+
+type checkViolationRecorder struct {
+	FakeRecorder
+	violations []string
+}
+
+func (r *checkViolationRecorder) RecordCheckViolations(checks []policy.CheckID, policy api.LevelVersion, evalMode metrics.Mode) {
+	ids := make([]string, len(checks))
+	for i, id := range checks {
+		ids[i] = string(id)
+	}
+	r.violations = append(r.violations, fmt.Sprintf("%s %s %s", evalMode, policy.String(), strings.Join(ids, ",")))
+}
+
+func TestRecordCheckViolations(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+	require.NoError(t, err)
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	recorder := &checkViolationRecorder{}
+	a := &Admission{
+		PodLister:     &testPodLister{},
+		Evaluator:     evaluator,
+		Configuration: config,
+		Metrics:       recorder,
+		NamespaceGetter: testNamespaceGetter{"ns": {ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: map[string]string{
+			api.EnforceLevelLabel: "baseline",
+			api.AuditLevelLabel:   "restricted",
+			api.WarnLevelLabel:    "restricted",
+		}}}},
+	}
+	require.NoError(t, a.CompleteConfiguration())
+	require.NoError(t, a.ValidateConfiguration())
+	validate := func(pod *corev1.Pod) *admissionv1.AdmissionResponse {
+		return a.Validate(ctx, &api.AttributesRecord{
+			Name:      pod.Name,
+			Namespace: "ns",
+			Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+			Operation: admissionv1.Create,
+			Object:    pod,
+		})
+	}
+
+	t.Run("allowed", func(t *testing.T) {
+		recorder.violations = nil
+		response := validate(&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "allowed"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "a"}}},
+		})
+		assert.True(t, response.Allowed)
+		assert.Equal(t, []string{
+			"audit restricted:latest allowPrivilegeEscalation,capabilities_restricted,runAsNonRoot,seccompProfile_restricted",
+			"warn restricted:latest allowPrivilegeEscalation,capabilities_restricted,runAsNonRoot,seccompProfile_restricted",
+		}, recorder.violations)
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		recorder.violations = nil
+		response := validate(&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "denied"},
+			Spec: corev1.PodSpec{
+				HostNetwork: true,
+				Containers:  []corev1.Container{{Name: "a", SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(true)}}},
+			},
+		})
+		assert.False(t, response.Allowed)
+		assert.Equal(t, []string{
+			"enforce baseline:latest hostNamespaces,privileged",
+			"audit restricted:latest hostNamespaces,privileged,allowPrivilegeEscalation,capabilities_restricted,runAsNonRoot,seccompProfile_restricted",
+		}, recorder.violations)
+	})
+}
+
+func TestExemptServiceAccount(t *testing.T) {
+	a := &Admission{Configuration: &admissionapi.PodSecurityConfiguration{
+		Exemptions: admissionapi.PodSecurityExemptions{
+			ServiceAccountNames: []string{"kube-system/*", "*/istio-?", "ns/controller"},
+		},
+	}}
+	testCases := []struct {
+		namespace      string
+		serviceAccount string
+		expect         bool
+	}{
+		{namespace: "kube-system", serviceAccount: "anything", expect: true},
+		{namespace: "kube-system", expect: true},
+		{namespace: "ns", serviceAccount: "istio-a", expect: true},
+		{namespace: "ns", serviceAccount: "istio-ab", expect: false},
+		{namespace: "ns", serviceAccount: "controller", expect: true},
+		{namespace: "other", serviceAccount: "controller", expect: false},
+		{namespace: "ns", expect: false},
+		{namespace: "", serviceAccount: "controller", expect: false},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expect, a.exemptServiceAccount(tc.namespace, tc.serviceAccount), "%s/%s", tc.namespace, tc.serviceAccount)
+	}
+}
+
+func TestExemptionPatterns(t *testing.T) {
+	config, err := load.LoadFromData(nil) // Start with the default config.
+	require.NoError(t, err, "loading default config")
+	config.Exemptions = admissionapi.PodSecurityExemptions{
+		Namespaces:     []string{"kube-system", "team-*"},
+		Usernames:      []string{"admin", "system:serviceaccount:kube-*:*"},
+		RuntimeClasses: []string{"kata-?", "gvisor"},
+	}
+	for _, completed := range []bool{false, true} {
+		t.Run(fmt.Sprintf("completed=%v", completed), func(t *testing.T) {
+			a := &Admission{Configuration: config}
+			if completed {
+				require.NoError(t, a.CompleteConfiguration())
+				require.NotNil(t, a.exemptions)
+			}
+
+			assert.True(t, a.exemptNamespace("kube-system"))
+			assert.True(t, a.exemptNamespace("team-a"))
+			assert.False(t, a.exemptNamespace("team"))
+			assert.False(t, a.exemptNamespace("kube-public"))
+
+			user := func(username string) api.Attributes {
+				return &api.AttributesRecord{Username: username}
+			}
+			assert.True(t, a.exemptUser(user("admin")))
+			assert.True(t, a.exemptUser(user("system:serviceaccount:kube-system:replicaset-controller")))
+			assert.False(t, a.exemptUser(user("system:serviceaccount:default:default")))
+			assert.False(t, a.exemptUser(user("administrator")))
+
+			assert.True(t, a.exemptRuntimeClass(pointer.String("kata-a")))
+			assert.True(t, a.exemptRuntimeClass(pointer.String("gvisor")))
+			assert.False(t, a.exemptRuntimeClass(pointer.String("kata-ab")))
+			assert.False(t, a.exemptRuntimeClass(pointer.String("runc")))
+		})
+	}
+}
+
+func TestNamespaceWarnings(t *testing.T) {
+	violations := []NamespaceViolation{
+		{Reason: "host ports", PodName: "a", PodNames: []string{"a", "d"}, PodCount: 2},
+		{Reason: "privileged", PodName: "b", PodNames: []string{"b", "c", "e"}, PodCount: 5},
+		{Reason: "hostPath volumes", PodName: "f", PodNames: []string{"f"}, PodCount: 1},
+	}
+	testCases := []struct {
+		name           string
+		opts           NamespaceWarningOptions
+		expectWarnings []string
+	}{
+		{
+			name: "default",
+			expectWarnings: []string{
+				"a (and 1 other pod): host ports",
+				"b (and 4 other pods): privileged",
+				"f: hostPath volumes",
+			},
+		},
+		{
+			name: "pod names",
+			opts: NamespaceWarningOptions{MaxPodNames: 2},
+			expectWarnings: []string{
+				"a, d: host ports",
+				"b, c (and 3 other pods): privileged",
+				"f: hostPath volumes",
+			},
+		},
+		{
+			name: "pod count order",
+			opts: NamespaceWarningOptions{Order: NamespaceWarningOrderPodCount},
+			expectWarnings: []string{
+				"b (and 4 other pods): privileged",
+				"a (and 1 other pod): host ports",
+				"f: hostPath volumes",
+			},
+		},
+		{
+			name: "max violations",
+			opts: NamespaceWarningOptions{MaxViolations: 2, Order: NamespaceWarningOrderPodCount},
+			expectWarnings: []string{
+				"b (and 4 other pods): privileged",
+				"2 more distinct violations omitted, affecting 3 pods",
+			},
+		},
+		{
+			name: "within max violations",
+			opts: NamespaceWarningOptions{MaxViolations: 3},
+			expectWarnings: []string{
+				"a (and 1 other pod): host ports",
+				"b (and 4 other pods): privileged",
+				"f: hostPath volumes",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, tc.opts.Validate())
+			assert.Equal(t, tc.expectWarnings, tc.opts.violationWarnings(violations))
+		})
+	}
+
+	assert.Error(t, NamespaceWarningOptions{MaxViolations: -1}.Validate())
+	assert.Error(t, NamespaceWarningOptions{MaxViolations: 1}.Validate())
+	assert.Error(t, NamespaceWarningOptions{MaxPodNames: -1}.Validate())
+	assert.Error(t, NamespaceWarningOptions{Order: "Reason"}.Validate())
+}
+
+type nondeterministicDecisionRecorder struct {
+	FakeRecorder
+	decisions []string
+}
+
+func (r *nondeterministicDecisionRecorder) RecordNondeterministicDecision(policy api.LevelVersion) {
+	r.decisions = append(r.decisions, policy.String())
+}
+
+// globalStateEvaluator allows pods while allowed is set, like a check depending on global state.
+type globalStateEvaluator struct {
+	allowed *bool
+}
+
+func (e globalStateEvaluator) EvaluatePod(lv api.LevelVersion, meta *metav1.ObjectMeta, spec *corev1.PodSpec) []policy.CheckResult {
+	if *e.allowed {
+		return []policy.CheckResult{{ID: "global", Allowed: true}}
+	}
+	return []policy.CheckResult{{ID: "global", Allowed: false, ForbiddenReason: "global state"}}
+}
+
+func TestDeterminismGuard(t *testing.T) {
+	_, ctx := ktesting.NewTestContext(t)
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	allowed := true
+	newAdmission := func(sampleRate float64, newEvaluator func() (policy.Evaluator, error)) (*Admission, *nondeterministicDecisionRecorder) {
+		recorder := &nondeterministicDecisionRecorder{}
+		a := &Admission{
+			PodLister:        &testPodLister{},
+			Evaluator:        globalStateEvaluator{allowed: &allowed},
+			Configuration:    config,
+			Metrics:          recorder,
+			DeterminismGuard: NewDeterminismGuard(sampleRate, newEvaluator),
+			NamespaceGetter: testNamespaceGetter{"ns": {ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: map[string]string{
+				api.EnforceLevelLabel: "restricted",
+			}}}},
+		}
+		require.NoError(t, a.CompleteConfiguration())
+		require.NoError(t, a.ValidateConfiguration())
+		return a, recorder
+	}
+	validate := func(a *Admission) *admissionv1.AdmissionResponse {
+		return a.Validate(ctx, &api.AttributesRecord{
+			Name:      "pod",
+			Namespace: "ns",
+			Kind:      schema.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+			Operation: admissionv1.Create,
+			Object:    &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "ns"}},
+		})
+	}
+
+	t.Run("deterministic", func(t *testing.T) {
+		a, recorder := newAdmission(1, func() (policy.Evaluator, error) {
+			return globalStateEvaluator{allowed: &allowed}, nil
+		})
+		assert.True(t, validate(a).Allowed)
+		assert.Empty(t, recorder.decisions)
+	})
+
+	t.Run("nondeterministic", func(t *testing.T) {
+		a, recorder := newAdmission(1, func() (policy.Evaluator, error) {
+			allowed = !allowed
+			return globalStateEvaluator{allowed: &allowed}, nil
+		})
+		validate(a)
+		assert.Contains(t, recorder.decisions, "restricted:latest")
+	})
+
+	t.Run("not sampled", func(t *testing.T) {
+		a, recorder := newAdmission(0, func() (policy.Evaluator, error) {
+			allowed = !allowed
+			return globalStateEvaluator{allowed: &allowed}, nil
+		})
+		validate(a)
+		assert.Empty(t, recorder.decisions)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		assert.Error(t, NewDeterminismGuard(1.5, func() (policy.Evaluator, error) { return nil, nil }).Validate())
+		assert.Error(t, NewDeterminismGuard(0.5, nil).Validate())
+	})
+}
+
+func TestDecisionDiff(t *testing.T) {
+	results := []policy.CheckResult{
+		{ID: "a", Allowed: true},
+		{ID: "b", Allowed: false, ForbiddenReason: "b", ForbiddenDetail: `containers "x", "y"`},
+	}
+	assert.Empty(t, decisionDiff(results, results))
+	assert.Equal(t, "evaluated 2 checks, then 1 checks", decisionDiff(results, results[:1]))
+	assert.Equal(t, `check "b" detail "containers \"x\", \"y\"", then "containers \"y\", \"x\""`, decisionDiff(results, []policy.CheckResult{
+		{ID: "a", Allowed: true},
+		{ID: "b", Allowed: false, ForbiddenReason: "b", ForbiddenDetail: `containers "y", "x"`},
+	}))
+	assert.Equal(t, `evaluated check "a", then "b"`, decisionDiff(results, []policy.CheckResult{results[1], results[0]}))
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// EnforcementAction determines how pods violating the enforce policy of their namespace are handled.
+type EnforcementAction string
+
+const (
+	// EnforcementActionDeny rejects violating pods.
+	EnforcementActionDeny EnforcementAction = ""
+	// EnforcementActionAnnotate admits violating pods with a warning. Paired with a mutating webhook
+	// calling MutatePod, violating pods are annotated with api.ViolationsAnnotation.
+	// This is an intermediate step between warn and enforce during migrations.
+	EnforcementActionAnnotate EnforcementAction = "Annotate"
+)
+
+// ParseEnforcementAction returns the EnforcementAction for the given string.
+// action must be "", "Deny", or "Annotate".
+func ParseEnforcementAction(action string) (EnforcementAction, error) {
+	switch EnforcementAction(action) {
+	case EnforcementActionDeny, "Deny":
+		return EnforcementActionDeny, nil
+	case EnforcementActionAnnotate:
+		return EnforcementActionAnnotate, nil
+	default:
+		return EnforcementActionDeny, fmt.Errorf(`must be one of Deny, Annotate`)
+	}
+}
+
+// MutatePod annotates pods violating the enforce policy of their namespace with api.ViolationsAnnotation
+// when the EnforcementAction is Annotate, and removes the annotation from pods without violations.
+// Only pod creations are mutated.
+// The returned response may be shared and must not be mutated.
+func (a *Admission) MutatePod(ctx context.Context, attrs api.Attributes) *admissionv1.AdmissionResponse {
+	if a.EnforcementAction != EnforcementActionAnnotate ||
+		attrs.GetResource().GroupResource() != podsResource ||
+		attrs.GetSubresource() != "" ||
+		attrs.GetOperation() != admissionv1.Create {
+		return sharedAllowedResponse
+	}
+	if a.exemptNamespace(attrs.GetNamespace()) || a.exemptUser(attrs) {
+		return sharedAllowedResponse
+	}
+
+	namespace, err := a.lookupNamespace(ctx, attrs)
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "failed to fetch pod namespace", "namespace", attrs.GetNamespace())
+		return errorResponse(err, &apierrors.NewInternalError(fmt.Errorf("failed to lookup namespace %q", attrs.GetNamespace())).ErrStatus)
+	}
+	nsPolicy, _ := a.PolicyToEvaluate(namespace.Labels)
+
+	obj, err := attrs.GetObject()
+	if err != nil {
+		klog.FromContext(ctx).Error(err, "failed to decode object")
+		return errorResponse(err, &apierrors.NewBadRequest("failed to decode object").ErrStatus)
+	}
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		klog.FromContext(ctx).Info("failed to assert pod type", "type", reflect.TypeOf(obj))
+		return errorResponse(nil, &apierrors.NewBadRequest("failed to decode pod").ErrStatus)
+	}
+	if a.exemptRuntimeClass(pod.Spec.RuntimeClassName) {
+		return sharedAllowedResponse
+	}
+
+	var summary string
+	if nsPolicy.Enforce.Level != api.LevelPrivileged {
+		exemptChecks, _ := a.namespaceExemptChecks(namespace)
+		result := policy.AggregateCheckResults(a.evaluatePodIgnoringInvalidOptOut(nsPolicy.Enforce, attrs.GetNamespace(), exemptChecks, &pod.ObjectMeta, &pod.Spec))
+		if violated(result) {
+			summary = fmt.Sprintf("%s: %s", nsPolicy.Enforce.String(), result.ForbiddenReason())
+		}
+	}
+	patch, err := violationsAnnotationPatch(pod.Annotations, summary)
+	if err != nil {
+		return errorResponse(err, &apierrors.NewInternalError(fmt.Errorf("failed to create patch")).ErrStatus)
+	}
+	if patch == nil {
+		return sharedAllowedResponse
+	}
+	response := allowedResponse()
+	patchType := admissionv1.PatchTypeJSONPatch
+	response.Patch = patch
+	response.PatchType = &patchType
+	return response
+}
+
+// violationsAnnotationPatch returns the JSON patch setting the violations annotation to summary,
+// or removing it if summary is empty. It returns nil if no change is needed.
+func violationsAnnotationPatch(annotations map[string]string, summary string) ([]byte, error) {
+	current, exists := annotations[api.ViolationsAnnotation]
+	var op map[string]interface{}
+	switch {
+	case summary == "" && !exists:
+		return nil, nil
+	case summary == "":
+		op = map[string]interface{}{"op": "remove", "path": violationsAnnotationPath}
+	case exists && current == summary:
+		return nil, nil
+	case annotations == nil:
+		op = map[string]interface{}{"op": "add", "path": "/metadata/annotations", "value": map[string]string{api.ViolationsAnnotation: summary}}
+	default:
+		op = map[string]interface{}{"op": "add", "path": violationsAnnotationPath, "value": summary}
+	}
+	return json.Marshal([]interface{}{op})
+}
+
+// violationsAnnotationPath is the JSON pointer to the violations annotation.
+var violationsAnnotationPath = "/metadata/annotations/" + strings.ReplaceAll(strings.ReplaceAll(api.ViolationsAnnotation, "~", "~0"), "/", "~1")
@@ -18,6 +18,7 @@ package api
 
 import (
 	"fmt"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/util/errors"
 	policyapi "k8s.io/pod-security-admission/api"
@@ -78,6 +79,38 @@ func ToPolicy(defaults PodSecurityDefaults) (policyapi.Policy, error) {
 	return p, errors.NewAggregate(errs)
 }
 
+// ToFloor returns the minimum enforce level & version of the floor, or nil if the floor is disabled.
+func ToFloor(floor PodSecurityFloor) (*policyapi.LevelVersion, error) {
+	if len(floor.Enforce) == 0 {
+		if len(floor.EnforceVersion) > 0 {
+			return nil, fmt.Errorf("enforce-version: requires enforce")
+		}
+		return nil, nil
+	}
+	var (
+		err  error
+		errs []error
+		lv   policyapi.LevelVersion
+	)
+	lv.Level, err = policyapi.ParseLevel(floor.Enforce)
+	errs = appendErr(errs, err, "enforce")
+	lv.Version = policyapi.LatestVersion()
+	if len(floor.EnforceVersion) > 0 {
+		lv.Version, err = policyapi.ParseVersion(floor.EnforceVersion)
+		errs = appendErr(errs, err, "enforce-version")
+	}
+	if len(errs) > 0 {
+		return nil, errors.NewAggregate(errs)
+	}
+	return &lv, nil
+}
+
+// IsExemptionPattern returns true if the exemption is a pattern (see path.Match) rather than an exact value,
+// e.g. team-* or system:serviceaccount:kube-*:*.
+func IsExemptionPattern(exemption string) bool {
+	return strings.ContainsAny(exemption, `*?[\`)
+}
+
 // appendErr is a helper function to collect field-specific errors.
 func appendErr(errs []error, err error, field string) []error {
 	if err != nil {
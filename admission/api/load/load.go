@@ -76,3 +76,30 @@ func LoadFromData(data []byte) (*api.PodSecurityConfiguration, error) {
 	}
 	return configuration, nil
 }
+
+// LoadOverlayFromFile loads the configuration of an overlay merged by api.MergeConfigurations, like LoadOverlayFromData.
+func LoadOverlayFromFile(file string) (*api.PodSecurityConfiguration, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return LoadOverlayFromData(data)
+}
+
+// LoadOverlayFromData loads the configuration of an overlay merged by api.MergeConfigurations.
+// Unlike LoadFromData, unset fields are not defaulted, so they do not override the previous layers.
+func LoadOverlayFromData(data []byte) (*api.PodSecurityConfiguration, error) {
+	internalConfig := &api.PodSecurityConfiguration{}
+	if len(data) == 0 {
+		return internalConfig, nil
+	}
+
+	decodedObj, _, err := scheme.Codecs.UniversalDeserializer().Decode(data, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := scheme.Scheme.Convert(decodedObj, internalConfig, nil); err != nil {
+		return nil, fmt.Errorf("expected PodSecurityConfiguration, got %T: %w", decodedObj, err)
+	}
+	return internalConfig, nil
+}
@@ -339,6 +339,27 @@ exemptions:
 				},
 			},
 		},
+		{
+			name: "v1alpha1 - service account exemptions",
+			data: []byte(`
+apiVersion: pod-security.admission.config.k8s.io/v1alpha1
+kind: PodSecurityConfiguration
+defaults:
+  enforce: baseline
+exemptions:
+  serviceAccountNames: ["kube-system/*", "ci/builder"]
+`),
+			expectConfig: &api.PodSecurityConfiguration{
+				Defaults: api.PodSecurityDefaults{
+					Enforce: "baseline", EnforceVersion: "latest",
+					Warn: "privileged", WarnVersion: "latest",
+					Audit: "privileged", AuditVersion: "latest",
+				},
+				Exemptions: api.PodSecurityExemptions{
+					ServiceAccountNames: []string{"kube-system/*", "ci/builder"},
+				},
+			},
+		},
 		{
 			name: "v1beta1 - json",
 			data: []byte(`{
@@ -379,6 +400,27 @@ exemptions:
 				},
 			},
 		},
+		{
+			name: "v1beta1 - service account exemptions",
+			data: []byte(`
+apiVersion: pod-security.admission.config.k8s.io/v1beta1
+kind: PodSecurityConfiguration
+defaults:
+  enforce: baseline
+exemptions:
+  serviceAccountNames: ["kube-system/*", "ci/builder"]
+`),
+			expectConfig: &api.PodSecurityConfiguration{
+				Defaults: api.PodSecurityDefaults{
+					Enforce: "baseline", EnforceVersion: "latest",
+					Warn: "privileged", WarnVersion: "latest",
+					Audit: "privileged", AuditVersion: "latest",
+				},
+				Exemptions: api.PodSecurityExemptions{
+					ServiceAccountNames: []string{"kube-system/*", "ci/builder"},
+				},
+			},
+		},
 		{
 			name: "v1 - json",
 			data: []byte(`{
@@ -419,6 +461,71 @@ exemptions:
 				},
 			},
 		},
+		{
+			name: "v1 - service account exemptions",
+			data: []byte(`
+apiVersion: pod-security.admission.config.k8s.io/v1
+kind: PodSecurityConfiguration
+defaults:
+  enforce: baseline
+exemptions:
+  serviceAccountNames: ["kube-system/*", "ci/builder"]
+`),
+			expectConfig: &api.PodSecurityConfiguration{
+				Defaults: api.PodSecurityDefaults{
+					Enforce: "baseline", EnforceVersion: "latest",
+					Warn: "privileged", WarnVersion: "latest",
+					Audit: "privileged", AuditVersion: "latest",
+				},
+				Exemptions: api.PodSecurityExemptions{
+					ServiceAccountNames: []string{"kube-system/*", "ci/builder"},
+				},
+			},
+		},
+		{
+			name: "v1 - privileged confirmation",
+			data: []byte(`
+apiVersion: pod-security.admission.config.k8s.io/v1
+kind: PodSecurityConfiguration
+defaults:
+  enforce: restricted
+privilegedConfirmation:
+  required: true
+  allowedNamespaces: ["kube-system"]
+`),
+			expectConfig: &api.PodSecurityConfiguration{
+				Defaults: api.PodSecurityDefaults{
+					Enforce: "restricted", EnforceVersion: "latest",
+					Warn: "privileged", WarnVersion: "latest",
+					Audit: "privileged", AuditVersion: "latest",
+				},
+				PrivilegedConfirmation: api.PodSecurityPrivilegedConfirmation{
+					Required:          true,
+					AllowedNamespaces: []string{"kube-system"},
+				},
+			},
+		},
+		{
+			name: "v1 - floor",
+			data: []byte(`
+apiVersion: pod-security.admission.config.k8s.io/v1
+kind: PodSecurityConfiguration
+floor:
+  enforce: baseline
+  enforce-version: v1.25
+`),
+			expectConfig: &api.PodSecurityConfiguration{
+				Defaults: api.PodSecurityDefaults{
+					Enforce: "privileged", EnforceVersion: "latest",
+					Warn: "privileged", WarnVersion: "latest",
+					Audit: "privileged", AuditVersion: "latest",
+				},
+				Floor: api.PodSecurityFloor{
+					Enforce:        "baseline",
+					EnforceVersion: "v1.25",
+				},
+			},
+		},
 		{
 			name:      "missing apiVersion",
 			data:      []byte(`{"kind":"PodSecurityConfiguration"}`),
@@ -476,3 +583,75 @@ exemptions:
 		})
 	}
 }
+
+func TestLoadOverlayFromData(t *testing.T) {
+	testcases := []struct {
+		name         string
+		data         []byte
+		expectErr    string
+		expectConfig *api.PodSecurityConfiguration
+	}{
+		{
+			name:         "empty",
+			data:         nil,
+			expectConfig: &api.PodSecurityConfiguration{},
+		},
+		{
+			name: "v1 - yaml",
+			data: []byte(`
+apiVersion: pod-security.admission.config.k8s.io/v1
+kind: PodSecurityConfiguration
+defaults:
+  enforce: baseline
+exemptions:
+  namespaces: ["team-system"]
+`),
+			expectConfig: &api.PodSecurityConfiguration{
+				Defaults: api.PodSecurityDefaults{
+					Enforce: "baseline",
+				},
+				Exemptions: api.PodSecurityExemptions{
+					Namespaces: []string{"team-system"},
+				},
+			},
+		},
+		{
+			name: "v1beta1 - json",
+			data: []byte(`{
+"apiVersion":"pod-security.admission.config.k8s.io/v1beta1",
+"kind":"PodSecurityConfiguration",
+"floor":{"enforce":"baseline"}}`),
+			expectConfig: &api.PodSecurityConfiguration{
+				Floor: api.PodSecurityFloor{Enforce: "baseline"},
+			},
+		},
+		{
+			name: "wrong kind",
+			data: []byte(`{
+"apiVersion":"pod-security.admission.config.k8s.io/v1",
+"kind":"Foo"}`),
+			expectErr: `no kind "Foo" is registered`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			config, err := LoadOverlayFromData(tc.data)
+			if err != nil {
+				if len(tc.expectErr) == 0 {
+					t.Fatalf("unexpected err: %v", err)
+				}
+				if !strings.Contains(err.Error(), tc.expectErr) {
+					t.Fatalf("expected err containing %q, got %v", tc.expectErr, err)
+				}
+				return
+			}
+			if len(tc.expectErr) > 0 {
+				t.Fatalf("expected err containing %q, got none", tc.expectErr)
+			}
+			if !reflect.DeepEqual(config, tc.expectConfig) {
+				t.Fatalf("unexpected config:\n%s", cmp.Diff(tc.expectConfig, config))
+			}
+		})
+	}
+}
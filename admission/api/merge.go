@@ -0,0 +1,202 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	policyapi "k8s.io/pod-security-admission/api"
+)
+
+// ConfigurationLayer is a named PodSecurityConfiguration merged by MergeConfigurations,
+// e.g. the cluster-wide configuration or the overlay of a team.
+type ConfigurationLayer struct {
+	// Name identifies the layer in the sources of the merged configuration, e.g. the path of its file.
+	Name string
+	// Configuration is the configuration of the layer. The configurations of overlays must not be defaulted,
+	// so their unset defaults do not override the previous layers (see load.LoadOverlayFromData).
+	Configuration *PodSecurityConfiguration
+}
+
+// MergedConfiguration is the PodSecurityConfiguration merged from layers by MergeConfigurations.
+type MergedConfiguration struct {
+	Configuration *PodSecurityConfiguration
+	// Sources are the names of the layers the settings are taken from, by the path of the setting,
+	// e.g. defaults.enforce, or exemptions.namespaces[kube-system] for the entries of lists.
+	Sources map[string]string
+}
+
+var (
+	defaultsPath               = field.NewPath("defaults")
+	exemptionsPath             = field.NewPath("exemptions")
+	privilegedConfirmationPath = field.NewPath("privilegedConfirmation")
+	floorPath                  = field.NewPath("floor")
+)
+
+// MergeConfigurations merges the layers in order, with the following precedence:
+//
+//  1. Each default level & version set by a layer overrides the value of the previous layers.
+//  2. The exemptions and the namespaces allowed to enforce the privileged level without confirmation are combined,
+//     sorted and deduplicated. Entries are attributed to the first layer setting them.
+//  3. The privileged confirmation is required if any layer requires it, so later layers cannot disable it.
+//  4. The floor is the strictest floor of the layers, so later layers cannot lower it.
+//     Invalid floors override the previous layers, so the merged configuration fails validation.
+//
+// The merged configuration must be validated, e.g. with validation.ValidatePodSecurityConfiguration.
+func MergeConfigurations(layers ...ConfigurationLayer) *MergedConfiguration {
+	merged := &MergedConfiguration{
+		Configuration: &PodSecurityConfiguration{},
+		Sources:       map[string]string{},
+	}
+	c := merged.Configuration
+	for i, layer := range layers {
+		l := layer.Configuration
+		if l == nil {
+			continue
+		}
+		if i == 0 {
+			c.TypeMeta = l.TypeMeta
+		}
+
+		merged.override(defaultsPath.Child("enforce"), &c.Defaults.Enforce, l.Defaults.Enforce, layer.Name)
+		merged.override(defaultsPath.Child("enforce-version"), &c.Defaults.EnforceVersion, l.Defaults.EnforceVersion, layer.Name)
+		merged.override(defaultsPath.Child("audit"), &c.Defaults.Audit, l.Defaults.Audit, layer.Name)
+		merged.override(defaultsPath.Child("audit-version"), &c.Defaults.AuditVersion, l.Defaults.AuditVersion, layer.Name)
+		merged.override(defaultsPath.Child("warn"), &c.Defaults.Warn, l.Defaults.Warn, layer.Name)
+		merged.override(defaultsPath.Child("warn-version"), &c.Defaults.WarnVersion, l.Defaults.WarnVersion, layer.Name)
+
+		merged.combine(exemptionsPath.Child("usernames"), &c.Exemptions.Usernames, l.Exemptions.Usernames, layer.Name)
+		merged.combine(exemptionsPath.Child("namespaces"), &c.Exemptions.Namespaces, l.Exemptions.Namespaces, layer.Name)
+		merged.combine(exemptionsPath.Child("runtimeClasses"), &c.Exemptions.RuntimeClasses, l.Exemptions.RuntimeClasses, layer.Name)
+		merged.combine(exemptionsPath.Child("serviceAccountNames"), &c.Exemptions.ServiceAccountNames, l.Exemptions.ServiceAccountNames, layer.Name)
+
+		if l.PrivilegedConfirmation.Required && !c.PrivilegedConfirmation.Required {
+			c.PrivilegedConfirmation.Required = true
+			merged.Sources[privilegedConfirmationPath.Child("required").String()] = layer.Name
+		}
+		merged.combine(privilegedConfirmationPath.Child("allowedNamespaces"), &c.PrivilegedConfirmation.AllowedNamespaces, l.PrivilegedConfirmation.AllowedNamespaces, layer.Name)
+
+		if stricterFloor(l.Floor, c.Floor) {
+			c.Floor = l.Floor
+			delete(merged.Sources, floorPath.Child("enforce").String())
+			delete(merged.Sources, floorPath.Child("enforce-version").String())
+			merged.override(floorPath.Child("enforce"), &c.Floor.Enforce, l.Floor.Enforce, layer.Name)
+			merged.override(floorPath.Child("enforce-version"), &c.Floor.EnforceVersion, l.Floor.EnforceVersion, layer.Name)
+		}
+	}
+	return merged
+}
+
+// override sets the merged value to the value of the layer, if set.
+func (m *MergedConfiguration) override(path *field.Path, merged *string, value, layer string) {
+	if len(value) == 0 {
+		return
+	}
+	*merged = value
+	m.Sources[path.String()] = layer
+}
+
+// combine adds the values of the layer to the merged values, keeping them sorted and deduplicated.
+func (m *MergedConfiguration) combine(path *field.Path, merged *[]string, values []string, layer string) {
+	for _, value := range values {
+		key := path.Key(value).String()
+		if _, seen := m.Sources[key]; seen {
+			continue
+		}
+		m.Sources[key] = layer
+		*merged = append(*merged, value)
+	}
+	sort.Strings(*merged)
+}
+
+// stricterFloor returns true if the floor is stricter than the current floor, or invalid.
+func stricterFloor(floor, current PodSecurityFloor) bool {
+	lv, err := ToFloor(floor)
+	if err != nil {
+		return true
+	}
+	if lv == nil {
+		return false
+	}
+	currentLV, err := ToFloor(current)
+	if err != nil {
+		// keep the invalid floor, so the merged configuration fails validation
+		return false
+	}
+	if currentLV == nil {
+		return true
+	}
+	if c := policyapi.CompareLevels(lv.Level, currentLV.Level); c != 0 {
+		return c > 0
+	}
+	return currentLV.Version.Older(lv.Version)
+}
+
+// Render writes the merged settings, one per line with the layer they are taken from, e.g.
+// `defaults.enforce: "baseline" (from team.yaml)`. Lists are written one entry per line, and unset settings are omitted.
+func (m *MergedConfiguration) Render(w io.Writer) error {
+	c := m.Configuration
+	settings := []struct {
+		path  *field.Path
+		value string
+	}{
+		{defaultsPath.Child("enforce"), c.Defaults.Enforce},
+		{defaultsPath.Child("enforce-version"), c.Defaults.EnforceVersion},
+		{defaultsPath.Child("audit"), c.Defaults.Audit},
+		{defaultsPath.Child("audit-version"), c.Defaults.AuditVersion},
+		{defaultsPath.Child("warn"), c.Defaults.Warn},
+		{defaultsPath.Child("warn-version"), c.Defaults.WarnVersion},
+		{floorPath.Child("enforce"), c.Floor.Enforce},
+		{floorPath.Child("enforce-version"), c.Floor.EnforceVersion},
+	}
+	for _, s := range settings {
+		if len(s.value) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s: %q (from %s)\n", s.path, s.value, m.Sources[s.path.String()]); err != nil {
+			return err
+		}
+	}
+	if c.PrivilegedConfirmation.Required {
+		path := privilegedConfirmationPath.Child("required")
+		if _, err := fmt.Fprintf(w, "%s: true (from %s)\n", path, m.Sources[path.String()]); err != nil {
+			return err
+		}
+	}
+
+	lists := []struct {
+		path   *field.Path
+		values []string
+	}{
+		{exemptionsPath.Child("usernames"), c.Exemptions.Usernames},
+		{exemptionsPath.Child("namespaces"), c.Exemptions.Namespaces},
+		{exemptionsPath.Child("runtimeClasses"), c.Exemptions.RuntimeClasses},
+		{exemptionsPath.Child("serviceAccountNames"), c.Exemptions.ServiceAccountNames},
+		{privilegedConfirmationPath.Child("allowedNamespaces"), c.PrivilegedConfirmation.AllowedNamespaces},
+	}
+	for _, l := range lists {
+		for _, value := range l.values {
+			if _, err := fmt.Fprintf(w, "%s: %q (from %s)\n", l.path, value, m.Sources[l.path.Key(value).String()]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeConfigurations(t *testing.T) {
+	cluster := &PodSecurityConfiguration{
+		Defaults: PodSecurityDefaults{
+			Enforce: "baseline", EnforceVersion: "latest",
+			Audit: "restricted", AuditVersion: "latest",
+			Warn: "restricted", WarnVersion: "latest",
+		},
+		Exemptions: PodSecurityExemptions{
+			Namespaces: []string{"kube-system"},
+			Usernames:  []string{"system:serviceaccount:kube-system:replicaset-controller"},
+		},
+		PrivilegedConfirmation: PodSecurityPrivilegedConfirmation{Required: true},
+		Floor:                  PodSecurityFloor{Enforce: "baseline"},
+	}
+	team := &PodSecurityConfiguration{
+		Defaults: PodSecurityDefaults{
+			Enforce: "restricted", EnforceVersion: "v1.29",
+		},
+		Exemptions: PodSecurityExemptions{
+			Namespaces: []string{"team-system", "kube-system"},
+		},
+		PrivilegedConfirmation: PodSecurityPrivilegedConfirmation{AllowedNamespaces: []string{"team-sandbox"}},
+		Floor:                  PodSecurityFloor{Enforce: "privileged"},
+	}
+	stricter := &PodSecurityConfiguration{
+		Floor: PodSecurityFloor{Enforce: "restricted", EnforceVersion: "v1.29"},
+	}
+
+	merged := MergeConfigurations(
+		ConfigurationLayer{Name: "cluster.yaml", Configuration: cluster},
+		ConfigurationLayer{Name: "team.yaml", Configuration: team},
+		ConfigurationLayer{Name: "empty.yaml"},
+	)
+	assert.Equal(t, &PodSecurityConfiguration{
+		Defaults: PodSecurityDefaults{
+			Enforce: "restricted", EnforceVersion: "v1.29",
+			Audit: "restricted", AuditVersion: "latest",
+			Warn: "restricted", WarnVersion: "latest",
+		},
+		Exemptions: PodSecurityExemptions{
+			Namespaces: []string{"kube-system", "team-system"},
+			Usernames:  []string{"system:serviceaccount:kube-system:replicaset-controller"},
+		},
+		PrivilegedConfirmation: PodSecurityPrivilegedConfirmation{Required: true, AllowedNamespaces: []string{"team-sandbox"}},
+		Floor:                  PodSecurityFloor{Enforce: "baseline"},
+	}, merged.Configuration)
+	assert.Equal(t, map[string]string{
+		"defaults.enforce":                   "team.yaml",
+		"defaults.enforce-version":           "team.yaml",
+		"defaults.audit":                     "cluster.yaml",
+		"defaults.audit-version":             "cluster.yaml",
+		"defaults.warn":                      "cluster.yaml",
+		"defaults.warn-version":              "cluster.yaml",
+		"exemptions.namespaces[kube-system]": "cluster.yaml",
+		"exemptions.namespaces[team-system]": "team.yaml",
+		"exemptions.usernames[system:serviceaccount:kube-system:replicaset-controller]": "cluster.yaml",
+		"privilegedConfirmation.required":                                               "cluster.yaml",
+		"privilegedConfirmation.allowedNamespaces[team-sandbox]":                        "team.yaml",
+		"floor.enforce": "cluster.yaml",
+	}, merged.Sources)
+
+	var rendered bytes.Buffer
+	assert.NoError(t, merged.Render(&rendered))
+	assert.Equal(t, `defaults.enforce: "restricted" (from team.yaml)
+defaults.enforce-version: "v1.29" (from team.yaml)
+defaults.audit: "restricted" (from cluster.yaml)
+defaults.audit-version: "latest" (from cluster.yaml)
+defaults.warn: "restricted" (from cluster.yaml)
+defaults.warn-version: "latest" (from cluster.yaml)
+floor.enforce: "baseline" (from cluster.yaml)
+privilegedConfirmation.required: true (from cluster.yaml)
+exemptions.usernames: "system:serviceaccount:kube-system:replicaset-controller" (from cluster.yaml)
+exemptions.namespaces: "kube-system" (from cluster.yaml)
+exemptions.namespaces: "team-system" (from team.yaml)
+privilegedConfirmation.allowedNamespaces: "team-sandbox" (from team.yaml)
+`, rendered.String())
+
+	// a stricter floor overrides the floor of the previous layers
+	merged = MergeConfigurations(
+		ConfigurationLayer{Name: "cluster.yaml", Configuration: cluster},
+		ConfigurationLayer{Name: "stricter.yaml", Configuration: stricter},
+	)
+	assert.Equal(t, PodSecurityFloor{Enforce: "restricted", EnforceVersion: "v1.29"}, merged.Configuration.Floor)
+	assert.Equal(t, "stricter.yaml", merged.Sources["floor.enforce"])
+	assert.Equal(t, "stricter.yaml", merged.Sources["floor.enforce-version"])
+
+	// a newer version of the same floor level is stricter
+	merged = MergeConfigurations(
+		ConfigurationLayer{Name: "stricter.yaml", Configuration: stricter},
+		ConfigurationLayer{Name: "latest.yaml", Configuration: &PodSecurityConfiguration{Floor: PodSecurityFloor{Enforce: "restricted"}}},
+	)
+	assert.Equal(t, PodSecurityFloor{Enforce: "restricted"}, merged.Configuration.Floor)
+	assert.Equal(t, "latest.yaml", merged.Sources["floor.enforce"])
+	assert.NotContains(t, merged.Sources, "floor.enforce-version")
+
+	// an invalid floor overrides the previous layers, failing validation
+	merged = MergeConfigurations(
+		ConfigurationLayer{Name: "stricter.yaml", Configuration: stricter},
+		ConfigurationLayer{Name: "invalid.yaml", Configuration: &PodSecurityConfiguration{Floor: PodSecurityFloor{Enforce: "unknown"}}},
+		ConfigurationLayer{Name: "cluster.yaml", Configuration: cluster},
+	)
+	assert.Equal(t, PodSecurityFloor{Enforce: "unknown"}, merged.Configuration.Floor)
+}
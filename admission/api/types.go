@@ -24,8 +24,10 @@ import (
 
 type PodSecurityConfiguration struct {
 	metav1.TypeMeta
-	Defaults   PodSecurityDefaults
-	Exemptions PodSecurityExemptions
+	Defaults               PodSecurityDefaults
+	Exemptions             PodSecurityExemptions
+	PrivilegedConfirmation PodSecurityPrivilegedConfirmation
+	Floor                  PodSecurityFloor
 }
 
 type PodSecurityDefaults struct {
@@ -41,4 +43,29 @@ type PodSecurityExemptions struct {
 	Usernames      []string
 	Namespaces     []string
 	RuntimeClasses []string
+	// ServiceAccountNames exempt the pods running as the service accounts, as <namespace>/<name>.
+	// The namespace and name may be patterns, like kube-system/* (see path.Match).
+	ServiceAccountNames []string
+}
+
+// PodSecurityPrivilegedConfirmation configures the confirmation required to enforce
+// the privileged level on a namespace that previously enforced the baseline or restricted level.
+type PodSecurityPrivilegedConfirmation struct {
+	// Required rejects namespace updates enforcing the privileged level on a namespace
+	// previously enforcing the baseline or restricted level, unless the namespace sets
+	// the pod-security.kubernetes.io/confirm-privileged annotation to "true".
+	// The same confirmation is required to exempt the pods of such namespaces from additional checks
+	// with the pod-security.kubernetes.io/exempt-checks annotation.
+	Required bool
+	// AllowedNamespaces may enforce the privileged level without confirmation.
+	AllowedNamespaces []string
+}
+
+// PodSecurityFloor configures the minimum policy enforced on every namespace that is not exempt,
+// regardless of its labels. Namespaces may only enforce a stricter policy.
+type PodSecurityFloor struct {
+	// Enforce is the minimum level enforced on every namespace. Leave empty to disable the floor.
+	Enforce string
+	// EnforceVersion is the version of the Enforce level, "latest" if empty.
+	EnforceVersion string
 }
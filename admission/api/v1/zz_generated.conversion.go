@@ -66,6 +66,26 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*PodSecurityFloor)(nil), (*api.PodSecurityFloor)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1_PodSecurityFloor_To_api_PodSecurityFloor(a.(*PodSecurityFloor), b.(*api.PodSecurityFloor), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*api.PodSecurityFloor)(nil), (*PodSecurityFloor)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_api_PodSecurityFloor_To_v1_PodSecurityFloor(a.(*api.PodSecurityFloor), b.(*PodSecurityFloor), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*PodSecurityPrivilegedConfirmation)(nil), (*api.PodSecurityPrivilegedConfirmation)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1_PodSecurityPrivilegedConfirmation_To_api_PodSecurityPrivilegedConfirmation(a.(*PodSecurityPrivilegedConfirmation), b.(*api.PodSecurityPrivilegedConfirmation), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*api.PodSecurityPrivilegedConfirmation)(nil), (*PodSecurityPrivilegedConfirmation)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_api_PodSecurityPrivilegedConfirmation_To_v1_PodSecurityPrivilegedConfirmation(a.(*api.PodSecurityPrivilegedConfirmation), b.(*PodSecurityPrivilegedConfirmation), scope)
+	}); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -76,6 +96,12 @@ func autoConvert_v1_PodSecurityConfiguration_To_api_PodSecurityConfiguration(in
 	if err := Convert_v1_PodSecurityExemptions_To_api_PodSecurityExemptions(&in.Exemptions, &out.Exemptions, s); err != nil {
 		return err
 	}
+	if err := Convert_v1_PodSecurityPrivilegedConfirmation_To_api_PodSecurityPrivilegedConfirmation(&in.PrivilegedConfirmation, &out.PrivilegedConfirmation, s); err != nil {
+		return err
+	}
+	if err := Convert_v1_PodSecurityFloor_To_api_PodSecurityFloor(&in.Floor, &out.Floor, s); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -91,6 +117,12 @@ func autoConvert_api_PodSecurityConfiguration_To_v1_PodSecurityConfiguration(in
 	if err := Convert_api_PodSecurityExemptions_To_v1_PodSecurityExemptions(&in.Exemptions, &out.Exemptions, s); err != nil {
 		return err
 	}
+	if err := Convert_api_PodSecurityPrivilegedConfirmation_To_v1_PodSecurityPrivilegedConfirmation(&in.PrivilegedConfirmation, &out.PrivilegedConfirmation, s); err != nil {
+		return err
+	}
+	if err := Convert_api_PodSecurityFloor_To_v1_PodSecurityFloor(&in.Floor, &out.Floor, s); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -133,6 +165,7 @@ func autoConvert_v1_PodSecurityExemptions_To_api_PodSecurityExemptions(in *PodSe
 	out.Usernames = *(*[]string)(unsafe.Pointer(&in.Usernames))
 	out.Namespaces = *(*[]string)(unsafe.Pointer(&in.Namespaces))
 	out.RuntimeClasses = *(*[]string)(unsafe.Pointer(&in.RuntimeClasses))
+	out.ServiceAccountNames = *(*[]string)(unsafe.Pointer(&in.ServiceAccountNames))
 	return nil
 }
 
@@ -145,6 +178,7 @@ func autoConvert_api_PodSecurityExemptions_To_v1_PodSecurityExemptions(in *api.P
 	out.Usernames = *(*[]string)(unsafe.Pointer(&in.Usernames))
 	out.Namespaces = *(*[]string)(unsafe.Pointer(&in.Namespaces))
 	out.RuntimeClasses = *(*[]string)(unsafe.Pointer(&in.RuntimeClasses))
+	out.ServiceAccountNames = *(*[]string)(unsafe.Pointer(&in.ServiceAccountNames))
 	return nil
 }
 
@@ -152,3 +186,47 @@ func autoConvert_api_PodSecurityExemptions_To_v1_PodSecurityExemptions(in *api.P
 func Convert_api_PodSecurityExemptions_To_v1_PodSecurityExemptions(in *api.PodSecurityExemptions, out *PodSecurityExemptions, s conversion.Scope) error {
 	return autoConvert_api_PodSecurityExemptions_To_v1_PodSecurityExemptions(in, out, s)
 }
+
+func autoConvert_v1_PodSecurityFloor_To_api_PodSecurityFloor(in *PodSecurityFloor, out *api.PodSecurityFloor, s conversion.Scope) error {
+	out.Enforce = in.Enforce
+	out.EnforceVersion = in.EnforceVersion
+	return nil
+}
+
+// Convert_v1_PodSecurityFloor_To_api_PodSecurityFloor is an autogenerated conversion function.
+func Convert_v1_PodSecurityFloor_To_api_PodSecurityFloor(in *PodSecurityFloor, out *api.PodSecurityFloor, s conversion.Scope) error {
+	return autoConvert_v1_PodSecurityFloor_To_api_PodSecurityFloor(in, out, s)
+}
+
+func autoConvert_api_PodSecurityFloor_To_v1_PodSecurityFloor(in *api.PodSecurityFloor, out *PodSecurityFloor, s conversion.Scope) error {
+	out.Enforce = in.Enforce
+	out.EnforceVersion = in.EnforceVersion
+	return nil
+}
+
+// Convert_api_PodSecurityFloor_To_v1_PodSecurityFloor is an autogenerated conversion function.
+func Convert_api_PodSecurityFloor_To_v1_PodSecurityFloor(in *api.PodSecurityFloor, out *PodSecurityFloor, s conversion.Scope) error {
+	return autoConvert_api_PodSecurityFloor_To_v1_PodSecurityFloor(in, out, s)
+}
+
+func autoConvert_v1_PodSecurityPrivilegedConfirmation_To_api_PodSecurityPrivilegedConfirmation(in *PodSecurityPrivilegedConfirmation, out *api.PodSecurityPrivilegedConfirmation, s conversion.Scope) error {
+	out.Required = in.Required
+	out.AllowedNamespaces = *(*[]string)(unsafe.Pointer(&in.AllowedNamespaces))
+	return nil
+}
+
+// Convert_v1_PodSecurityPrivilegedConfirmation_To_api_PodSecurityPrivilegedConfirmation is an autogenerated conversion function.
+func Convert_v1_PodSecurityPrivilegedConfirmation_To_api_PodSecurityPrivilegedConfirmation(in *PodSecurityPrivilegedConfirmation, out *api.PodSecurityPrivilegedConfirmation, s conversion.Scope) error {
+	return autoConvert_v1_PodSecurityPrivilegedConfirmation_To_api_PodSecurityPrivilegedConfirmation(in, out, s)
+}
+
+func autoConvert_api_PodSecurityPrivilegedConfirmation_To_v1_PodSecurityPrivilegedConfirmation(in *api.PodSecurityPrivilegedConfirmation, out *PodSecurityPrivilegedConfirmation, s conversion.Scope) error {
+	out.Required = in.Required
+	out.AllowedNamespaces = *(*[]string)(unsafe.Pointer(&in.AllowedNamespaces))
+	return nil
+}
+
+// Convert_api_PodSecurityPrivilegedConfirmation_To_v1_PodSecurityPrivilegedConfirmation is an autogenerated conversion function.
+func Convert_api_PodSecurityPrivilegedConfirmation_To_v1_PodSecurityPrivilegedConfirmation(in *api.PodSecurityPrivilegedConfirmation, out *PodSecurityPrivilegedConfirmation, s conversion.Scope) error {
+	return autoConvert_api_PodSecurityPrivilegedConfirmation_To_v1_PodSecurityPrivilegedConfirmation(in, out, s)
+}
@@ -31,6 +31,8 @@ func (in *PodSecurityConfiguration) DeepCopyInto(out *PodSecurityConfiguration)
 	out.TypeMeta = in.TypeMeta
 	out.Defaults = in.Defaults
 	in.Exemptions.DeepCopyInto(&out.Exemptions)
+	in.PrivilegedConfirmation.DeepCopyInto(&out.PrivilegedConfirmation)
+	out.Floor = in.Floor
 	return
 }
 
@@ -86,6 +88,11 @@ func (in *PodSecurityExemptions) DeepCopyInto(out *PodSecurityExemptions) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ServiceAccountNames != nil {
+		in, out := &in.ServiceAccountNames, &out.ServiceAccountNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -98,3 +105,40 @@ func (in *PodSecurityExemptions) DeepCopy() *PodSecurityExemptions {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodSecurityFloor) DeepCopyInto(out *PodSecurityFloor) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodSecurityFloor.
+func (in *PodSecurityFloor) DeepCopy() *PodSecurityFloor {
+	if in == nil {
+		return nil
+	}
+	out := new(PodSecurityFloor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodSecurityPrivilegedConfirmation) DeepCopyInto(out *PodSecurityPrivilegedConfirmation) {
+	*out = *in
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodSecurityPrivilegedConfirmation.
+func (in *PodSecurityPrivilegedConfirmation) DeepCopy() *PodSecurityPrivilegedConfirmation {
+	if in == nil {
+		return nil
+	}
+	out := new(PodSecurityPrivilegedConfirmation)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package validation
 
 import (
+	"path"
 	"strings"
 
 	machinery "k8s.io/apimachinery/pkg/api/validation"
@@ -42,6 +43,13 @@ func ValidatePodSecurityConfiguration(configuration *admissionapi.PodSecurityCon
 	allErrs = append(allErrs, validateNamespaces(configuration)...)
 	allErrs = append(allErrs, validateRuntimeClasses(configuration)...)
 	allErrs = append(allErrs, validateUsernames(configuration)...)
+	allErrs = append(allErrs, validateServiceAccountNames(configuration)...)
+
+	// validate privileged confirmation
+	allErrs = append(allErrs, validatePrivilegedConfirmationNamespaces(configuration)...)
+
+	// validate floor
+	allErrs = append(allErrs, validateFloor(configuration)...)
 
 	return allErrs
 }
@@ -70,7 +78,7 @@ func validateNamespaces(configuration *admissionapi.PodSecurityConfiguration) fi
 	errs := field.ErrorList{}
 	validSet := sets.NewString()
 	for i, ns := range configuration.Exemptions.Namespaces {
-		err := machinery.ValidateNamespaceName(ns, false)
+		err := validateNamePattern(ns, machinery.ValidateNamespaceName)
 		if len(err) > 0 {
 			path := field.NewPath("exemptions", "namespaces").Index(i)
 			errs = append(errs, field.Invalid(path, ns, strings.Join(err, ", ")))
@@ -90,7 +98,7 @@ func validateRuntimeClasses(configuration *admissionapi.PodSecurityConfiguration
 	errs := field.ErrorList{}
 	validSet := sets.NewString()
 	for i, rc := range configuration.Exemptions.RuntimeClasses {
-		err := machinery.NameIsDNSSubdomain(rc, false)
+		err := validateNamePattern(rc, machinery.NameIsDNSSubdomain)
 		if len(err) > 0 {
 			path := field.NewPath("exemptions", "runtimeClasses").Index(i)
 			errs = append(errs, field.Invalid(path, rc, strings.Join(err, ", ")))
@@ -115,6 +123,11 @@ func validateUsernames(configuration *admissionapi.PodSecurityConfiguration) fie
 			errs = append(errs, field.Invalid(path, uname, "username must not be empty"))
 			continue
 		}
+		if err := validatePattern(uname); len(err) > 0 {
+			path := field.NewPath("exemptions", "usernames").Index(i)
+			errs = append(errs, field.Invalid(path, uname, strings.Join(err, ", ")))
+			continue
+		}
 		if validSet.Has(uname) {
 			path := field.NewPath("exemptions", "usernames").Index(i)
 			errs = append(errs, field.Duplicate(path, uname))
@@ -125,3 +138,85 @@ func validateUsernames(configuration *admissionapi.PodSecurityConfiguration) fie
 
 	return errs
 }
+
+func validateServiceAccountNames(configuration *admissionapi.PodSecurityConfiguration) field.ErrorList {
+	errs := field.ErrorList{}
+	validSet := sets.NewString()
+	for i, sa := range configuration.Exemptions.ServiceAccountNames {
+		p := field.NewPath("exemptions", "serviceAccountNames").Index(i)
+		namespace, name, ok := strings.Cut(sa, "/")
+		if !ok || strings.Contains(name, "/") {
+			errs = append(errs, field.Invalid(p, sa, "must be <namespace>/<name>"))
+			continue
+		}
+		var segmentErrs []string
+		segmentErrs = append(segmentErrs, validateNamePattern(namespace, machinery.ValidateNamespaceName)...)
+		segmentErrs = append(segmentErrs, validateNamePattern(name, machinery.NameIsDNSSubdomain)...)
+		if len(segmentErrs) > 0 {
+			errs = append(errs, field.Invalid(p, sa, strings.Join(segmentErrs, ", ")))
+			continue
+		}
+		if validSet.Has(sa) {
+			errs = append(errs, field.Duplicate(p, sa))
+			continue
+		}
+		validSet.Insert(sa)
+	}
+	return errs
+}
+
+// validateNamePattern validates a name with validateName, unless it is a pattern (see path.Match),
+// in which case only its syntax is validated.
+func validateNamePattern(name string, validateName machinery.ValidateNameFunc) []string {
+	if !admissionapi.IsExemptionPattern(name) {
+		return validateName(name, false)
+	}
+	return validatePattern(name)
+}
+
+// validatePattern validates the syntax of a value if it is a pattern (see path.Match).
+func validatePattern(value string) []string {
+	if !admissionapi.IsExemptionPattern(value) {
+		return nil
+	}
+	if _, err := path.Match(value, ""); err != nil {
+		return []string{"invalid pattern " + value}
+	}
+	return nil
+}
+
+func validatePrivilegedConfirmationNamespaces(configuration *admissionapi.PodSecurityConfiguration) field.ErrorList {
+	errs := field.ErrorList{}
+	validSet := sets.NewString()
+	for i, ns := range configuration.PrivilegedConfirmation.AllowedNamespaces {
+		err := machinery.ValidateNamespaceName(ns, false)
+		if len(err) > 0 {
+			path := field.NewPath("privilegedConfirmation", "allowedNamespaces").Index(i)
+			errs = append(errs, field.Invalid(path, ns, strings.Join(err, ", ")))
+			continue
+		}
+		if validSet.Has(ns) {
+			path := field.NewPath("privilegedConfirmation", "allowedNamespaces").Index(i)
+			errs = append(errs, field.Duplicate(path, ns))
+			continue
+		}
+		validSet.Insert(ns)
+	}
+	return errs
+}
+
+func validateFloor(configuration *admissionapi.PodSecurityConfiguration) field.ErrorList {
+	errs := field.ErrorList{}
+	floor := configuration.Floor
+	if len(floor.Enforce) == 0 {
+		if len(floor.EnforceVersion) > 0 {
+			errs = append(errs, field.Required(field.NewPath("floor", "enforce"), "required when floor.enforce-version is set"))
+		}
+		return errs
+	}
+	errs = append(errs, validateLevel(field.NewPath("floor", "enforce"), floor.Enforce)...)
+	if len(floor.EnforceVersion) > 0 {
+		errs = append(errs, validateVersion(field.NewPath("floor", "enforce-version"), floor.EnforceVersion)...)
+	}
+	return errs
+}
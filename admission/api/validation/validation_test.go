@@ -109,6 +109,134 @@ func TestValidatePodSecurityConfiguration(t *testing.T) {
 				Exemptions: api.PodSecurityExemptions{},
 			},
 		},
+		{
+			expectedErrList: field.ErrorList{
+				field.Invalid(exemptionsPath("serviceAccountNames", 0), invalidValueEmpty, "..."),
+				field.Invalid(exemptionsPath("serviceAccountNames", 1), validValue, "..."),
+				field.Invalid(exemptionsPath("serviceAccountNames", 2), "a/b/c", "..."),
+				field.Invalid(exemptionsPath("serviceAccountNames", 3), "TEST/testing", "..."),
+				field.Invalid(exemptionsPath("serviceAccountNames", 4), "testing/[a", "..."),
+				field.Duplicate(exemptionsPath("serviceAccountNames", 8), "testing/testing"),
+			},
+			configuration: api.PodSecurityConfiguration{
+				Defaults: api.PodSecurityDefaults{
+					Enforce:        "privileged",
+					EnforceVersion: "latest",
+					Audit:          "privileged",
+					AuditVersion:   "latest",
+					Warn:           "privileged",
+					WarnVersion:    "latest",
+				},
+				Exemptions: api.PodSecurityExemptions{
+					ServiceAccountNames: []string{
+						invalidValueEmpty,
+						validValue,
+						"a/b/c",
+						"TEST/testing",
+						"testing/[a",
+						"testing/testing",
+						"kube-system/*",
+						"*/istio-?",
+						"testing/testing",
+					},
+				},
+			},
+		},
+		{
+			expectedErrList: field.ErrorList{
+				field.Invalid(exemptionsPath("namespaces", 1), "team-[a", "..."),
+				field.Duplicate(exemptionsPath("namespaces", 2), "team-*"),
+				field.Invalid(exemptionsPath("runtimeClasses", 1), "kata-[", "..."),
+				field.Invalid(exemptionsPath("usernames", 1), "system:serviceaccount:[kube", "..."),
+			},
+			configuration: api.PodSecurityConfiguration{
+				Defaults: api.PodSecurityDefaults{
+					Enforce:        "privileged",
+					EnforceVersion: "latest",
+					Audit:          "privileged",
+					AuditVersion:   "latest",
+					Warn:           "privileged",
+					WarnVersion:    "latest",
+				},
+				Exemptions: api.PodSecurityExemptions{
+					Namespaces: []string{
+						"team-*",
+						"team-[a",
+						"team-*",
+					},
+					RuntimeClasses: []string{
+						"kata-*",
+						"kata-[",
+					},
+					Usernames: []string{
+						"system:serviceaccount:kube-*:*",
+						"system:serviceaccount:[kube",
+					},
+				},
+			},
+		},
+		{
+			expectedErrList: field.ErrorList{
+				field.Invalid(privilegedConfirmationPath("allowedNamespaces", 0), invalidValueChars, "..."),
+				field.Duplicate(privilegedConfirmationPath("allowedNamespaces", 2), validValue),
+			},
+			configuration: api.PodSecurityConfiguration{
+				Defaults: api.PodSecurityDefaults{
+					Enforce:        "privileged",
+					EnforceVersion: "latest",
+					Audit:          "privileged",
+					AuditVersion:   "latest",
+					Warn:           "privileged",
+					WarnVersion:    "latest",
+				},
+				PrivilegedConfirmation: api.PodSecurityPrivilegedConfirmation{
+					Required: true,
+					AllowedNamespaces: []string{
+						invalidValueChars,
+						validValue,
+						validValue,
+					},
+				},
+			},
+		},
+		{
+			expectedErrList: field.ErrorList{
+				field.Invalid(floorPath("enforce"), "baslein", "..."),
+				field.Invalid(floorPath("enforce-version"), "v.122", "..."),
+			},
+			configuration: api.PodSecurityConfiguration{
+				Defaults: api.PodSecurityDefaults{
+					Enforce:        "privileged",
+					EnforceVersion: "latest",
+					Audit:          "privileged",
+					AuditVersion:   "latest",
+					Warn:           "privileged",
+					WarnVersion:    "latest",
+				},
+				Floor: api.PodSecurityFloor{
+					Enforce:        "baslein",
+					EnforceVersion: "v.122",
+				},
+			},
+		},
+		{
+			expectedErrList: field.ErrorList{
+				field.Required(floorPath("enforce"), "..."),
+			},
+			configuration: api.PodSecurityConfiguration{
+				Defaults: api.PodSecurityDefaults{
+					Enforce:        "privileged",
+					EnforceVersion: "latest",
+					Audit:          "privileged",
+					AuditVersion:   "latest",
+					Warn:           "privileged",
+					WarnVersion:    "latest",
+				},
+				Floor: api.PodSecurityFloor{
+					EnforceVersion: "v1.25",
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -141,3 +269,13 @@ func defaultsPath(child string) *field.Path {
 func exemptionsPath(child string, i int) *field.Path {
 	return field.NewPath("exemptions", child).Index(i)
 }
+
+// floorPath returns the appropriate floor path
+func floorPath(child string) *field.Path {
+	return field.NewPath("floor", child)
+}
+
+// privilegedConfirmationPath returns the appropriate privilegedConfirmation path
+func privilegedConfirmationPath(child string, i int) *field.Path {
+	return field.NewPath("privilegedConfirmation", child).Index(i)
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/metrics"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// deprecatedFields returns the deprecated fields set in the pod if WarnDeprecatedFields is set,
+// and records them once per pod if the Metrics implement metrics.DeprecatedFieldRecorder.
+func (a *Admission) deprecatedFields(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, attrs api.Attributes) []policy.DeprecatedField {
+	recorder, record := a.metrics().(metrics.DeprecatedFieldRecorder)
+	if !record && !a.WarnDeprecatedFields {
+		return nil
+	}
+	fields := policy.DeprecatedFields(podMetadata, podSpec)
+	if record {
+		recorded := sets.New[string]()
+		for _, f := range fields {
+			if !recorded.Has(f.Field) {
+				recorded.Insert(f.Field)
+				recorder.RecordDeprecatedField(f.Field, attrs)
+			}
+		}
+	}
+	if !a.WarnDeprecatedFields {
+		return nil
+	}
+	return fields
+}
+
+// deprecatedFieldWarnings returns a warning for each deprecated field, naming its replacement.
+func deprecatedFieldWarnings(fields []policy.DeprecatedField) []string {
+	var warnings []string
+	for _, f := range fields {
+		if f.Replacement == nil {
+			warnings = append(warnings, fmt.Sprintf("%s is deprecated", f.Path))
+		} else {
+			warnings = append(warnings, fmt.Sprintf("%s is deprecated, use %s instead", f.Path, f.Replacement))
+		}
+	}
+	return warnings
+}
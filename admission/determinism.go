@@ -0,0 +1,115 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/metrics"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// DeterminismGuard re-evaluates a sample of the evaluated pods with a freshly constructed evaluator,
+// and reports the evaluations with a different decision, to catch nondeterministic evaluation in production,
+// e.g. from map ordering or global state.
+type DeterminismGuard struct {
+	sampleRate   float64
+	newEvaluator func() (policy.Evaluator, error)
+
+	lock sync.Mutex
+	rand *rand.Rand
+}
+
+// NewDeterminismGuard returns a guard re-evaluating the given fraction of evaluated pods, between 0 and 1,
+// with an evaluator constructed by newEvaluator for each re-evaluation.
+// Re-evaluations are synchronous, so they add to the latency of the sampled requests.
+func NewDeterminismGuard(sampleRate float64, newEvaluator func() (policy.Evaluator, error)) *DeterminismGuard {
+	return &DeterminismGuard{
+		sampleRate:   sampleRate,
+		newEvaluator: newEvaluator,
+		rand:         rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Validate checks the guard is valid.
+func (g *DeterminismGuard) Validate() error {
+	if g.sampleRate < 0 || g.sampleRate > 1 {
+		return fmt.Errorf("determinism guard sample rate must be between 0 and 1, got %v", g.sampleRate)
+	}
+	if g.newEvaluator == nil {
+		return fmt.Errorf("determinism guard evaluator constructor required")
+	}
+	return nil
+}
+
+func (g *DeterminismGuard) sample() bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.rand.Float64() < g.sampleRate
+}
+
+// check re-evaluates a sample of the pods evaluated with the given results, and reports the pods
+// with a different decision to the recorder, if it implements metrics.NondeterministicDecisionRecorder.
+func (g *DeterminismGuard) check(recorder metrics.Recorder, lv api.LevelVersion, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, results []policy.CheckResult) {
+	if !g.sample() {
+		return
+	}
+	evaluator, err := g.newEvaluator()
+	if err != nil {
+		klog.Background().Error(err, "failed to construct evaluator to re-evaluate pod")
+		return
+	}
+	diff := decisionDiff(results, evaluator.EvaluatePod(lv, podMetadata, podSpec))
+	if diff == "" {
+		return
+	}
+	klog.Background().Error(nil, "nondeterministic pod security decision", "policy", lv.String(), "namespace", podMetadata.Namespace, "name", podMetadata.Name, "diff", diff)
+	if r, ok := recorder.(metrics.NondeterministicDecisionRecorder); ok {
+		r.RecordNondeterministicDecision(lv)
+	}
+}
+
+// decisionDiff describes the first difference between the decisions of two evaluations of a pod,
+// or returns an empty string if the results agree on the evaluated checks and their decisions, reasons and details.
+func decisionDiff(results, reevaluated []policy.CheckResult) string {
+	if len(results) != len(reevaluated) {
+		return fmt.Sprintf("evaluated %d checks, then %d checks", len(results), len(reevaluated))
+	}
+	for i, r := range results {
+		o := reevaluated[i]
+		switch {
+		case r.ID != o.ID:
+			return fmt.Sprintf("evaluated check %q, then %q", r.ID, o.ID)
+		case r.Allowed != o.Allowed:
+			return fmt.Sprintf("check %q allowed=%v, then allowed=%v", r.ID, r.Allowed, o.Allowed)
+		case r.ForbiddenReason != o.ForbiddenReason:
+			return fmt.Sprintf("check %q reason %q, then %q", r.ID, r.ForbiddenReason, o.ForbiddenReason)
+		case r.ForbiddenDetail != o.ForbiddenDetail:
+			return fmt.Sprintf("check %q detail %q, then %q", r.ID, r.ForbiddenDetail, o.ForbiddenDetail)
+		case r.Warning != o.Warning:
+			return fmt.Sprintf("check %q warning %q, then %q", r.ID, r.Warning, o.Warning)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ephemeralContainersSubresource is the pod subresource adding ephemeral containers, e.g. by kubectl debug.
+const ephemeralContainersSubresource = "ephemeralcontainers"
+
+// updatedEphemeralContainers returns the indexes of the ephemeral containers of the pod that are added by the update,
+// or whose update should trigger a policy evaluation (see isSignificantContainerUpdate).
+func updatedEphemeralContainers(pod, oldPod *corev1.Pod) []int {
+	var indexes []int
+	for i := range pod.Spec.EphemeralContainers {
+		c := &pod.Spec.EphemeralContainers[i]
+		var oldC *corev1.Container
+		for j, oc := range oldPod.Spec.EphemeralContainers {
+			if oc.Name == c.Name {
+				oldC = (*corev1.Container)(&oldPod.Spec.EphemeralContainers[j].EphemeralContainerCommon)
+				break
+			}
+		}
+		if oldC == nil || isSignificantContainerUpdate((*corev1.Container)(&c.EphemeralContainerCommon), oldC) {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// scopeToEphemeralContainers returns a copy of the Admission evaluating only the ephemeral containers of pods
+// at the indexes, so the ephemeral container updates of pods are admitted regardless of the violations of their
+// other containers and of their pod-level fields, which the updates cannot change (see policy.EvaluateEphemeralContainers).
+// The warnings and denials name the evaluated containers, and the paths of their field errors are their paths in the pod.
+func (a *Admission) scopeToEphemeralContainers(indexes []int) *Admission {
+	scoped := *a
+	scoped.ephemeralContainers = indexes
+	return &scoped
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// namespaceExemptChecks returns the checks the pods of the namespace are exempt from by its api.ExemptChecksAnnotation,
+// if NamespaceCheckExemptions is set. Checks evaluated at the level of the enforce floor are not exempt, and returned as ignored,
+// so namespace annotations cannot relax the floor (see policy.FilterExemptChecks).
+func (a *Admission) namespaceExemptChecks(namespace *corev1.Namespace) (exempt, ignored []policy.CheckID) {
+	if !a.NamespaceCheckExemptions {
+		return nil, nil
+	}
+	return policy.FilterExemptChecks(a.Evaluator, a.enforceFloor, api.ExemptChecks(namespace.Annotations))
+}
+
+// exemptChecksErrors returns the errors of the check IDs listed in the api.ExemptChecksAnnotation of the namespace
+// that are not registered in the Evaluator, if NamespaceCheckExemptions is set, so misspelled checks are not silently ignored.
+func (a *Admission) exemptChecksErrors(namespace *corev1.Namespace) field.ErrorList {
+	if !a.NamespaceCheckExemptions {
+		return nil
+	}
+	unknown := policy.UnknownExemptChecks(a.Evaluator, api.ExemptChecks(namespace.Annotations))
+	if len(unknown) == 0 {
+		return nil
+	}
+	return field.ErrorList{field.Invalid(
+		field.NewPath("metadata", "annotations").Key(api.ExemptChecksAnnotation),
+		namespace.Annotations[api.ExemptChecksAnnotation],
+		fmt.Sprintf("unknown checks: %s", strings.Join(unknown, ", ")),
+	)}
+}
+
+// exemptChecksAuditAnnotation describes the exempt and ignored checks of the namespace in the audit annotations.
+func exemptChecksAuditAnnotation(exempt, ignored []policy.CheckID) string {
+	var parts []string
+	if len(exempt) > 0 {
+		parts = append(parts, joinCheckIDs(exempt))
+	}
+	if len(ignored) > 0 {
+		parts = append(parts, fmt.Sprintf("ignored %s evaluated by the enforce floor", joinCheckIDs(ignored)))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func joinCheckIDs(ids []policy.CheckID) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = string(id)
+	}
+	return strings.Join(s, ",")
+}
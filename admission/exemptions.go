@@ -0,0 +1,166 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/client-go/kubernetes"
+	admissionapi "k8s.io/pod-security-admission/admission/api"
+)
+
+var exemptedUsernamesPath = field.NewPath("exemptions", "usernames")
+
+// ExemptionMatcher matches values against a list of exemptions, which are exact values,
+// or patterns such as team-* or system:serviceaccount:kube-*:* (see path.Match).
+// Exact values are looked up in a set, so large lists of exemptions are matched in constant time.
+type ExemptionMatcher struct {
+	values   sets.Set[string]
+	patterns []string
+}
+
+// NewExemptionMatcher returns a matcher of the exemptions, which are expected to be validated.
+// Invalid patterns never match.
+func NewExemptionMatcher(exemptions []string) *ExemptionMatcher {
+	m := &ExemptionMatcher{values: sets.New[string]()}
+	for _, exemption := range exemptions {
+		if admissionapi.IsExemptionPattern(exemption) {
+			m.patterns = append(m.patterns, exemption)
+		} else {
+			m.values.Insert(exemption)
+		}
+	}
+	return m
+}
+
+// Matches returns true if the value is one of the exemptions, or matches one of their patterns.
+func (m *ExemptionMatcher) Matches(value string) bool {
+	if m.values.Has(value) {
+		return true
+	}
+	for _, pattern := range m.patterns {
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// exemptionMatchers are the matchers of the exemptions of a configuration.
+type exemptionMatchers struct {
+	namespaces          *ExemptionMatcher
+	usernames           *ExemptionMatcher
+	runtimeClasses      *ExemptionMatcher
+	serviceAccountNames *ExemptionMatcher
+}
+
+func newExemptionMatchers(exemptions admissionapi.PodSecurityExemptions) *exemptionMatchers {
+	return &exemptionMatchers{
+		namespaces:          NewExemptionMatcher(exemptions.Namespaces),
+		usernames:           NewExemptionMatcher(exemptions.Usernames),
+		runtimeClasses:      NewExemptionMatcher(exemptions.RuntimeClasses),
+		serviceAccountNames: NewExemptionMatcher(exemptions.ServiceAccountNames),
+	}
+}
+
+// ValidateExemptedUsernames cross-checks the exempted usernames against the identities of the cluster,
+// to flag exemptions that never match, e.g. because of typos:
+//   - service account usernames must be well-formed, and the service accounts must exist,
+//   - other usernames must be the subject of a RoleBinding or ClusterRoleBinding.
+//
+// Users authorized through their groups only are not subjects of any binding, so errors for usernames
+// other than service accounts should be reviewed rather than rejected.
+// An error is returned if the identities cannot be listed.
+func ValidateExemptedUsernames(ctx context.Context, exemptions admissionapi.PodSecurityExemptions, client kubernetes.Interface) (field.ErrorList, error) {
+	var (
+		errs     field.ErrorList
+		subjects sets.Set[string]
+	)
+	for i, username := range exemptions.Usernames {
+		if admissionapi.IsExemptionPattern(username) {
+			// patterns may match identities that don't exist yet
+			continue
+		}
+		path := exemptedUsernamesPath.Index(i)
+		if strings.HasPrefix(username, serviceaccount.ServiceAccountUsernamePrefix) {
+			namespace, name, err := serviceaccount.SplitUsername(username)
+			if err != nil {
+				errs = append(errs, field.Invalid(path, username, "must be system:serviceaccount:<namespace>:<name>"))
+				continue
+			}
+			_, err = client.CoreV1().ServiceAccounts(namespace).Get(ctx, name, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				errs = append(errs, notFound(path, username, "service account does not exist"))
+			} else if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if subjects == nil {
+			var err error
+			if subjects, err = rbacUserSubjects(ctx, client); err != nil {
+				return nil, err
+			}
+		}
+		if !subjects.Has(username) {
+			errs = append(errs, notFound(path, username, "not the subject of any RoleBinding or ClusterRoleBinding"))
+		}
+	}
+	return errs, nil
+}
+
+// rbacUserSubjects returns the names of the users bound by RoleBindings and ClusterRoleBindings.
+func rbacUserSubjects(ctx context.Context, client kubernetes.Interface) (sets.Set[string], error) {
+	users := sets.New[string]()
+	addUsers := func(subjects []rbacv1.Subject) {
+		for _, subject := range subjects {
+			if subject.Kind == rbacv1.UserKind {
+				users.Insert(subject.Name)
+			}
+		}
+	}
+	clusterRoleBindings, err := client.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, binding := range clusterRoleBindings.Items {
+		addUsers(binding.Subjects)
+	}
+	roleBindings, err := client.RbacV1().RoleBindings(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, binding := range roleBindings.Items {
+		addUsers(binding.Subjects)
+	}
+	return users, nil
+}
+
+func notFound(path *field.Path, value interface{}, detail string) *field.Error {
+	err := field.NotFound(path, value)
+	err.Detail = detail
+	return err
+}
@@ -0,0 +1,244 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/metrics"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// FailurePolicy determines how requests are handled when they cannot be evaluated.
+type FailurePolicy string
+
+const (
+	// FailurePolicyFail rejects requests that cannot be evaluated.
+	FailurePolicyFail FailurePolicy = "Fail"
+	// FailurePolicyIgnore admits requests that cannot be evaluated with a warning.
+	// Pod controller requests are admitted without a warning if FailurePolicies.Audit is unset.
+	FailurePolicyIgnore FailurePolicy = "Ignore"
+)
+
+// ParseFailurePolicy returns the FailurePolicy for the given string.
+// policy must be "Fail" or "Ignore".
+func ParseFailurePolicy(policy string) (FailurePolicy, error) {
+	switch FailurePolicy(policy) {
+	case FailurePolicyFail, FailurePolicyIgnore:
+		return FailurePolicy(policy), nil
+	default:
+		return "", fmt.Errorf(`must be one of Fail, Ignore`)
+	}
+}
+
+// FailurePolicies configures the handling of requests that cannot be evaluated,
+// because of internal errors like failed namespace lookups or because the evaluation exceeded Timeout.
+type FailurePolicies struct {
+	// Enforce applies to pod requests, evaluated against the enforce policy. Defaults to Fail.
+	Enforce FailurePolicy
+	// Audit applies to pod controller requests, only evaluated against the audit and warn policies.
+	// Defaults to admitting the request, only recording the error in the audit annotations.
+	Audit FailurePolicy
+	// Timeout bounds the evaluation of pod and pod controller requests, if non-zero.
+	Timeout time.Duration
+}
+
+// CheckErrorPolicies configures the handling of pods that some checks failed to evaluate, e.g. because they panicked
+// or exceeded their deadline (see policy.CheckResult.Error), which are reported separately from the violations
+// of the other checks.
+type CheckErrorPolicies struct {
+	// Enforce applies to the enforce policy. Fail rejects the pod with an internal error, and Ignore only enforces
+	// the other checks, admitting the pod with a warning if they allow it. Defaults to FailurePolicies.Enforce.
+	Enforce FailurePolicy
+	// Audit applies to the audit policy. Fail records the errors in the audit annotations, and Ignore omits them.
+	// Defaults to Fail.
+	Audit FailurePolicy
+	// Warn applies to the warn policy. Fail returns the errors as warnings, and Ignore omits them. Defaults to Fail.
+	Warn FailurePolicy
+}
+
+// checkErrorPolicy returns the check error policy for the given mode.
+func (a *Admission) checkErrorPolicy(mode metrics.Mode) FailurePolicy {
+	var policy FailurePolicy
+	switch mode {
+	case metrics.ModeEnforce:
+		policy = a.CheckErrorPolicies.Enforce
+		if policy == "" {
+			return a.failurePolicy(true)
+		}
+	case metrics.ModeAudit:
+		policy = a.CheckErrorPolicies.Audit
+	case metrics.ModeWarn:
+		policy = a.CheckErrorPolicies.Warn
+	}
+	if policy == "" {
+		return FailurePolicyFail
+	}
+	return policy
+}
+
+// failurePolicy returns the failure policy for the mode of the request.
+func (a *Admission) failurePolicy(enforce bool) FailurePolicy {
+	if enforce {
+		if a.FailurePolicies.Enforce == "" {
+			return FailurePolicyFail
+		}
+		return a.FailurePolicies.Enforce
+	}
+	if a.FailurePolicies.Audit == "" {
+		return FailurePolicyIgnore
+	}
+	return a.FailurePolicies.Audit
+}
+
+// failureResponse is the response used when a request cannot be evaluated.
+// The request is rejected with the given status, or admitted depending on the failure policy, with a warning
+// if the Ignore policy is set explicitly. Either way, the error is recorded in the audit annotations and in the error metric,
+// as fatal if the request is rejected.
+func (a *Admission) failureResponse(attrs api.Attributes, enforce bool, err error, status *metav1.Status) *admissionv1.AdmissionResponse {
+	failurePolicy := a.failurePolicy(enforce)
+	a.metrics().RecordError(failurePolicy == FailurePolicyFail, attrs)
+	if failurePolicy == FailurePolicyFail {
+		return errorResponse(err, status)
+	}
+	response := errorResponse(err, status)
+	response.Allowed = true
+	response.Result = nil
+	explicitPolicy := a.FailurePolicies.Audit
+	if enforce {
+		explicitPolicy = a.FailurePolicies.Enforce
+	}
+	if explicitPolicy == FailurePolicyIgnore {
+		response.Warnings = []string{fmt.Sprintf("PodSecurity evaluation skipped: %s", response.AuditAnnotations["error"])}
+	}
+	return response
+}
+
+// validateWithTimeout calls validate, and returns a failure response if it does not return within FailurePolicies.Timeout.
+// The abandoned evaluation of a timed out request is canceled: the namespace lookup is passed the expired context,
+// and the evaluation returns before evaluating the next policy level. It records no metrics, violations or decisions,
+// so the request is only recorded by its failure response. Checks running when the request timed out are only
+// stopped by their deadlines (see policy.WithCheckDeadline).
+func (a *Admission) validateWithTimeout(ctx context.Context, attrs api.Attributes, enforce bool, validate func(*Admission, context.Context, api.Attributes) *admissionv1.AdmissionResponse) *admissionv1.AdmissionResponse {
+	if a.FailurePolicies.Timeout <= 0 {
+		return validate(a, ctx, attrs)
+	}
+	ctx, cancel := context.WithTimeout(ctx, a.FailurePolicies.Timeout)
+	defer cancel()
+
+	bounded := *a
+	bounded.abandon = ctx.Done()
+	responses := make(chan *admissionv1.AdmissionResponse, 1)
+	go func() {
+		responses <- validate(&bounded, ctx, attrs)
+	}()
+	select {
+	case response := <-responses:
+		return response
+	case <-ctx.Done():
+		klog.FromContext(ctx).Info("PodSecurity evaluation timed out", "timeout", a.FailurePolicies.Timeout, "resource", attrs.GetResource(), "namespace", attrs.GetNamespace(), "name", attrs.GetName())
+		return a.failureResponse(attrs, enforce, ctx.Err(), &apierrors.NewTimeoutError("PodSecurity evaluation timed out", 0).ErrStatus)
+	}
+}
+
+// abandoned returns true if the request evaluated by the Admission timed out (see validateWithTimeout).
+func (a *Admission) abandoned() bool {
+	select {
+	case <-a.abandon:
+		return true
+	default:
+		return false
+	}
+}
+
+// abandonedResponse is the response returned by abandoned evaluations, discarded by validateWithTimeout.
+func abandonedResponse() *admissionv1.AdmissionResponse {
+	return errorResponse(context.DeadlineExceeded, &apierrors.NewTimeoutError("PodSecurity evaluation timed out", 0).ErrStatus)
+}
+
+// metrics returns the Metrics recording the evaluation of requests, or a recorder discarding the records
+// of abandoned evaluations, implementing none of the optional recorder interfaces.
+func (a *Admission) metrics() metrics.Recorder {
+	if a.abandoned() {
+		return discardRecorder{}
+	}
+	return a.Metrics
+}
+
+// discardRecorder is a metrics.Recorder recording nothing.
+type discardRecorder struct{}
+
+func (discardRecorder) RecordEvaluation(metrics.Decision, api.LevelVersion, metrics.Mode, api.Attributes) {
+}
+func (discardRecorder) RecordExemption(api.Attributes)   {}
+func (discardRecorder) RecordError(bool, api.Attributes) {}
+
+// enforceErrorResponse is the response rejecting pods whose enforce evaluation failed because of the errors of checks,
+// e.g. because they exceeded their deadline (see policy.WithCheckDeadline), with the Fail check error policy.
+func (a *Admission) enforceErrorResponse(attrs api.Attributes, lv api.LevelVersion, result policy.AggregateCheckResult) *admissionv1.AdmissionResponse {
+	a.metrics().RecordError(true, attrs)
+	errs := make([]error, len(result.Errors))
+	for i, err := range result.Errors {
+		errs[i] = err
+	}
+	return errorResponse(errors.Join(errs...), &apierrors.NewInternalError(fmt.Errorf("failed to evaluate PodSecurity %q", lv.String())).ErrStatus)
+}
+
+// checkErrorDetail describes the errors of the checks that failed to evaluate a pod against the given level & version.
+func checkErrorDetail(lv api.LevelVersion, result policy.AggregateCheckResult) string {
+	return fmt.Sprintf("failed to evaluate PodSecurity %q: %s", lv.String(), result.ErrorDetail())
+}
+
+// checkErrorWarning is the warning returned for the errors of the checks that failed to evaluate a pod.
+func checkErrorWarning(result policy.AggregateCheckResult) string {
+	return fmt.Sprintf("checks could not be evaluated: %s", result.ErrorDetail())
+}
+
+// appendAuditError appends detail to the error audit annotation.
+func appendAuditError(auditAnnotations map[string]string, detail string) {
+	if existing := auditAnnotations["error"]; existing != "" {
+		detail = existing + "; " + detail
+	}
+	auditAnnotations["error"] = detail
+}
+
+// violated returns true if the checks of the result that evaluated the pod disallowed it,
+// ignoring the checks that failed to evaluate it.
+func violated(result policy.AggregateCheckResult) bool {
+	return len(result.ForbiddenReasons) > 0
+}
+
+// recordCheckErrors records the checks that failed to evaluate a pod, if the Metrics implement metrics.CheckErrorRecorder.
+func (a *Admission) recordCheckErrors(results []policy.CheckResult) {
+	recorder, ok := a.metrics().(metrics.CheckErrorRecorder)
+	if !ok {
+		return
+	}
+	for _, result := range results {
+		if result.Error != nil {
+			recorder.RecordCheckError(result.ID, result.Error)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"k8s.io/pod-security-admission/api"
+)
+
+// applyFloor raises the enforce level & version of the policy to the floor, if the floor is stricter.
+// Levels are compared first, so a namespace enforcing a stricter level at an older version is not changed.
+func applyFloor(policy api.Policy, floor *api.LevelVersion) api.Policy {
+	if floor == nil {
+		return policy
+	}
+	switch api.CompareLevels(floor.Level, policy.Enforce.Level) {
+	case 1:
+		policy.Enforce = *floor
+	case 0:
+		if floor.Level != api.LevelPrivileged && policy.Enforce.Version.Older(floor.Version) {
+			policy.Enforce.Version = floor.Version
+		}
+	}
+	return policy
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"k8s.io/pod-security-admission/api"
+)
+
+// IdentityExtractor derives the identities of the requesting user that are matched against the exempt usernames
+// of the configuration, so clusters with external identity systems can exempt requests by workload identity.
+type IdentityExtractor interface {
+	// Identities returns the identities of the requesting user. The request is exempt if any identity is exempt.
+	Identities(attrs api.Attributes) []string
+}
+
+// IdentityExtractorFunc is an IdentityExtractor implemented by a function.
+type IdentityExtractorFunc func(attrs api.Attributes) []string
+
+func (f IdentityExtractorFunc) Identities(attrs api.Attributes) []string {
+	return f(attrs)
+}
+
+// UsernameIdentity extracts the authenticated username of the requesting user.
+// It is used by Admission if no IdentityExtractor is set.
+var UsernameIdentity IdentityExtractor = IdentityExtractorFunc(func(attrs api.Attributes) []string {
+	if username := attrs.GetUserName(); username != "" {
+		return []string{username}
+	}
+	return nil
+})
+
+// UserExtraIdentity extracts the values of the given extra field of the requesting user, like the SPIFFE IDs
+// set by an authenticating proxy or webhook. The field is only read from Attributes implementing api.UserInfoAttributes.
+//
+// Users allowed to impersonate the extra field (the impersonate verb on userextras/<key>) can set any value,
+// so only use keys that are set by trusted authenticators.
+func UserExtraIdentity(key string) IdentityExtractor {
+	return IdentityExtractorFunc(func(attrs api.Attributes) []string {
+		userInfoAttrs, ok := attrs.(api.UserInfoAttributes)
+		if !ok {
+			return nil
+		}
+		return userInfoAttrs.GetUserInfo().Extra[key]
+	})
+}
+
+// MultiIdentityExtractor returns the identities extracted by all the extractors.
+func MultiIdentityExtractor(extractors ...IdentityExtractor) IdentityExtractor {
+	return IdentityExtractorFunc(func(attrs api.Attributes) []string {
+		var identities []string
+		for _, extractor := range extractors {
+			identities = append(identities, extractor.Identities(attrs)...)
+		}
+		return identities
+	})
+}
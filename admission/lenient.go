@@ -0,0 +1,58 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"fmt"
+	"sort"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/pod-security-admission/api"
+)
+
+// withNormalizedLabelsWarnings returns a copy of the response with a warning for each PodSecurity label
+// of the namespace in the request that was normalized by lenient label parsing.
+// The warnings are appended after the existing warnings, which are more relevant to the enforce policy.
+func withNormalizedLabelsWarnings(response *admissionv1.AdmissionResponse, attrs api.Attributes) *admissionv1.AdmissionResponse {
+	obj, err := attrs.GetObject()
+	if err != nil {
+		return response
+	}
+	namespace, ok := obj.(*corev1.Namespace)
+	if !ok {
+		return response
+	}
+	_, normalized, _ := api.PolicyToEvaluateLenient(namespace.Labels, api.Policy{})
+	if len(normalized) == 0 {
+		return response
+	}
+
+	labels := make([]string, 0, len(normalized))
+	for label := range normalized {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	warnings := make([]string, 0, len(response.Warnings)+len(labels))
+	warnings = append(warnings, response.Warnings...)
+	for _, label := range labels {
+		warnings = append(warnings, fmt.Sprintf("label %s=%q is interpreted as %q", label, namespace.Labels[label], normalized[label]))
+	}
+	withWarnings := *response
+	withWarnings.Warnings = warnings
+	return &withWarnings
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// NamespaceAudit is the aggregate result of evaluating the existing pods of a namespace against a level & version.
+type NamespaceAudit struct {
+	// TotalPods is the number of pods to evaluate, excluding the pods skipped by NamespaceEvaluationOptions,
+	// pods with an exempt runtime class or service account, and additional replicas with WeightByReplicas.
+	TotalPods int
+	// CheckedPods is the number of evaluated pods. It is less than TotalPods if the evaluation was cut short.
+	CheckedPods int
+	// Violations are the distinct violations of the evaluated pods, sorted by Reason.
+	Violations []NamespaceViolation
+}
+
+// NamespaceViolation is a violation shared by pods of a namespace.
+type NamespaceViolation struct {
+	// Reason is the forbidden reason of the pods, e.g. "host ports, privileged".
+	Reason string
+	// PodName is the lexically first name of the violating pods.
+	PodName string
+	// PodNames are the names of the evaluated violating pods, sorted.
+	PodNames []string
+	// PodCount is the number of violating pods, counting all the replicas of evaluated pods with WeightByReplicas.
+	PodCount int
+}
+
+// AuditNamespace evaluates the existing pods of the namespace against the level & version, exempting them from
+// the checks of the namespace exempt-checks annotation if NamespaceCheckExemptions is set.
+// Pods are evaluated concurrently according to NamespaceEvaluation.Parallelism until ctx is done,
+// in which case the audit only covers the pods evaluated so far.
+func (a *Admission) AuditNamespace(ctx context.Context, namespace *corev1.Namespace, lv api.LevelVersion) (*NamespaceAudit, error) {
+	exemptChecks, _ := a.namespaceExemptChecks(namespace)
+	return a.auditNamespacePods(ctx, namespace.Name, lv, exemptChecks, 0)
+}
+
+// auditNamespacePods evaluates the pods of the namespace like AuditNamespace, exempting them from exemptChecks.
+// At most maxPods pods are evaluated if maxPods is positive, prioritizing unique pods.
+func (a *Admission) auditNamespacePods(ctx context.Context, namespace string, lv api.LevelVersion, exemptChecks []policy.CheckID, maxPods int) (*NamespaceAudit, error) {
+	pods, err := a.PodLister.ListPods(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	pods = a.filterNamespacePods(pods, time.Now())
+	var replicas map[types.UID]int
+	if a.NamespaceEvaluation.WeightByReplicas {
+		replicas = countReplicas(pods)
+	}
+	prioritizedPods := a.prioritizePods(pods)
+	if replicas != nil {
+		// only the first pod of each controller is evaluated
+		prioritizedPods = prioritizedPods[:len(prioritizedPods)-countDuplicateReplicas(prioritizedPods)]
+	}
+
+	audit := &NamespaceAudit{TotalPods: len(prioritizedPods)}
+	if maxPods > 0 && len(prioritizedPods) > maxPods {
+		prioritizedPods = prioritizedPods[0:maxPods]
+	}
+
+	reasons, evaluated := evaluatePodsConcurrently(ctx, prioritizedPods, a.NamespaceEvaluation.Parallelism, func(pod *corev1.Pod) string {
+		r := policy.AggregateCheckResults(a.evaluatePodIgnoringInvalidOptOut(lv, namespace, exemptChecks, &pod.ObjectMeta, &pod.Spec))
+		if !violated(r) {
+			return ""
+		}
+		return r.ForbiddenReason()
+	})
+
+	violations := make(map[string]*NamespaceViolation)
+	for i, pod := range prioritizedPods {
+		if !evaluated[i] {
+			continue
+		}
+		audit.CheckedPods++
+		if reasons[i] == "" {
+			continue
+		}
+		v, seen := violations[reasons[i]]
+		if !seen {
+			v = &NamespaceViolation{Reason: reasons[i], PodName: pod.Name}
+			violations[reasons[i]] = v
+		} else if pod.Name < v.PodName {
+			v.PodName = pod.Name
+		}
+		v.PodNames = append(v.PodNames, pod.Name)
+		v.PodCount += podWeight(pod, replicas)
+	}
+	for _, v := range violations {
+		sort.Strings(v.PodNames)
+		audit.Violations = append(audit.Violations, *v)
+	}
+	sort.Slice(audit.Violations, func(i, j int) bool { return audit.Violations[i].Reason < audit.Violations[j].Reason })
+
+	return audit, nil
+}
+
+// evaluatePodsConcurrently evaluates the pods in order with up to parallelism concurrent evaluations, until ctx is done.
+// It returns the forbidden reason of each pod, empty if allowed, and whether each pod was evaluated.
+func evaluatePodsConcurrently(ctx context.Context, pods []*corev1.Pod, parallelism int, evaluate func(pod *corev1.Pod) string) (reasons []string, evaluated []bool) {
+	reasons = make([]string, len(pods))
+	evaluated = make([]bool, len(pods))
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	if parallelism > len(pods) {
+		parallelism = len(pods)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if ctx.Err() != nil { // deadline exceeded or context was cancelled
+					continue
+				}
+				reasons[i] = evaluate(pods[i])
+				evaluated[i] = true
+			}
+		}()
+	}
+dispatch:
+	for i := range pods {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	return reasons, evaluated
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/metrics"
+)
+
+// NamespaceLookupFailureAction determines how requests are handled when their namespace cannot be fetched,
+// e.g. because of informer lag, or a race with the creation of the namespace.
+type NamespaceLookupFailureAction string
+
+const (
+	// NamespaceLookupFailureActionFailurePolicy handles the request according to the FailurePolicies.
+	NamespaceLookupFailureActionFailurePolicy NamespaceLookupFailureAction = ""
+	// NamespaceLookupFailureActionDefaults evaluates the request against the cluster defaults,
+	// as if the namespace had no labels or annotations.
+	NamespaceLookupFailureActionDefaults NamespaceLookupFailureAction = "Defaults"
+	// NamespaceLookupFailureActionDeny rejects the request, regardless of the FailurePolicies.
+	NamespaceLookupFailureActionDeny NamespaceLookupFailureAction = "Deny"
+)
+
+// ParseNamespaceLookupFailureAction returns the NamespaceLookupFailureAction for the given string.
+// action must be "", "FailurePolicy", "Defaults", or "Deny".
+func ParseNamespaceLookupFailureAction(action string) (NamespaceLookupFailureAction, error) {
+	switch NamespaceLookupFailureAction(action) {
+	case NamespaceLookupFailureActionFailurePolicy, "FailurePolicy":
+		return NamespaceLookupFailureActionFailurePolicy, nil
+	case NamespaceLookupFailureActionDefaults, NamespaceLookupFailureActionDeny:
+		return NamespaceLookupFailureAction(action), nil
+	default:
+		return "", fmt.Errorf("must be one of FailurePolicy, Defaults, Deny")
+	}
+}
+
+// defaultNamespaceLookupRetryInterval is the delay before the first retry of a failed namespace lookup, if unset.
+const defaultNamespaceLookupRetryInterval = 100 * time.Millisecond
+
+// NamespaceLookupOptions configures the handling of requests whose namespace cannot be fetched.
+type NamespaceLookupOptions struct {
+	// Retries is the number of times a failed lookup is retried before applying OnFailure.
+	// Retries stop early when the deadline of the request, e.g. set by FailurePolicies.Timeout, would be exceeded.
+	Retries int
+	// RetryInterval is the delay before the first retry, doubled for each subsequent retry. Defaults to 100ms.
+	RetryInterval time.Duration
+	// OnFailure determines how requests are handled when the lookup still fails.
+	OnFailure NamespaceLookupFailureAction
+}
+
+// Validate checks the options are valid.
+func (o NamespaceLookupOptions) Validate() error {
+	if o.Retries < 0 {
+		return fmt.Errorf("namespace lookup retries must not be negative")
+	}
+	if o.RetryInterval < 0 {
+		return fmt.Errorf("namespace lookup retry interval must not be negative")
+	}
+	if _, err := ParseNamespaceLookupFailureAction(string(o.OnFailure)); err != nil {
+		return fmt.Errorf("namespace lookup failure action: %w", err)
+	}
+	return nil
+}
+
+// lookupNamespace fetches the namespace of the request, retrying failed lookups as configured by NamespaceLookup.
+// If the lookup still fails and the OnFailure action is Defaults, it returns an empty namespace evaluated
+// against the cluster defaults. Failed lookups are recorded to the metrics recorder,
+// if it implements metrics.NamespaceLookupFailureRecorder.
+func (a *Admission) lookupNamespace(ctx context.Context, attrs api.Attributes) (*corev1.Namespace, error) {
+	name := attrs.GetNamespace()
+	namespace, err := a.NamespaceGetter.GetNamespace(ctx, name)
+	if err == nil {
+		return namespace, nil
+	}
+
+	logger := klog.FromContext(ctx)
+	interval := a.NamespaceLookup.RetryInterval
+	if interval == 0 {
+		interval = defaultNamespaceLookupRetryInterval
+	}
+	for retry := 0; retry < a.NamespaceLookup.Retries; retry++ {
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < interval {
+			break
+		}
+		logger.V(2).Info("retrying namespace lookup", "namespace", name, "retry", retry+1, "interval", interval, "err", err)
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			a.recordNamespaceLookupFailure(false)
+			return a.namespaceLookupFailure(name, err)
+		case <-timer.C:
+		}
+		if namespace, err = a.NamespaceGetter.GetNamespace(ctx, name); err == nil {
+			a.recordNamespaceLookupFailure(true)
+			return namespace, nil
+		}
+		interval *= 2
+	}
+	a.recordNamespaceLookupFailure(false)
+	return a.namespaceLookupFailure(name, err)
+}
+
+// namespaceLookupFailure returns the namespace and error returned by lookupNamespace for a failed lookup.
+func (a *Admission) namespaceLookupFailure(name string, err error) (*corev1.Namespace, error) {
+	if a.NamespaceLookup.OnFailure == NamespaceLookupFailureActionDefaults {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}, nil
+	}
+	return nil, err
+}
+
+// namespaceLookupFailureResponse is the response used when the namespace of a request cannot be fetched.
+// The request is rejected if the OnFailure action is Deny, and handled according to the FailurePolicies otherwise.
+func (a *Admission) namespaceLookupFailureResponse(ctx context.Context, attrs api.Attributes, enforce bool, err error) *admissionv1.AdmissionResponse {
+	klog.FromContext(ctx).Error(err, "failed to fetch pod namespace", "namespace", attrs.GetNamespace())
+	status := &apierrors.NewInternalError(fmt.Errorf("failed to lookup namespace %q", attrs.GetNamespace())).ErrStatus
+	if a.NamespaceLookup.OnFailure == NamespaceLookupFailureActionDeny {
+		a.metrics().RecordError(true, attrs)
+		return errorResponse(err, status)
+	}
+	return a.failureResponse(attrs, enforce, err, status)
+}
+
+// recordNamespaceLookupFailure records a failed namespace lookup, resolved by a retry if retried is true,
+// and by the OnFailure action otherwise.
+func (a *Admission) recordNamespaceLookupFailure(retried bool) {
+	r, ok := a.metrics().(metrics.NamespaceLookupFailureRecorder)
+	if !ok {
+		return
+	}
+	resolution := metrics.NamespaceLookupResolutionFailurePolicy
+	switch {
+	case retried:
+		resolution = metrics.NamespaceLookupResolutionRetried
+	case a.NamespaceLookup.OnFailure == NamespaceLookupFailureActionDefaults:
+		resolution = metrics.NamespaceLookupResolutionDefaults
+	case a.NamespaceLookup.OnFailure == NamespaceLookupFailureActionDeny:
+		resolution = metrics.NamespaceLookupResolutionDeny
+	}
+	r.RecordNamespaceLookupFailure(resolution)
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NamespaceWarningOrder is the order of the warnings about the existing pods violating a new namespace enforce level.
+type NamespaceWarningOrder string
+
+const (
+	// NamespaceWarningOrderPodName orders the warnings by the names of their first violating pod.
+	NamespaceWarningOrderPodName NamespaceWarningOrder = ""
+	// NamespaceWarningOrderPodCount orders the warnings by decreasing number of violating pods,
+	// then by the names of their first violating pod, so the most widespread violations are kept first.
+	NamespaceWarningOrderPodCount NamespaceWarningOrder = "PodCount"
+)
+
+// ParseNamespaceWarningOrder returns the NamespaceWarningOrder for the given string.
+// order must be "", "PodName", or "PodCount".
+func ParseNamespaceWarningOrder(order string) (NamespaceWarningOrder, error) {
+	switch NamespaceWarningOrder(order) {
+	case NamespaceWarningOrderPodName, "PodName":
+		return NamespaceWarningOrderPodName, nil
+	case NamespaceWarningOrderPodCount:
+		return NamespaceWarningOrderPodCount, nil
+	default:
+		return NamespaceWarningOrderPodName, fmt.Errorf("must be one of PodName, PodCount")
+	}
+}
+
+// NamespaceWarningOptions configures the warnings about the existing pods violating a new namespace enforce level.
+// Pods with identical violations are grouped in a single warning, counting the pods.
+type NamespaceWarningOptions struct {
+	// MaxViolations is the maximum number of distinct violations warned about, if non-zero.
+	// Violations beyond the limit are replaced with a closing warning, counted in the limit.
+	MaxViolations int
+	// MaxPodNames is the maximum number of pod names listed in the warning of a violation.
+	// A single pod name is listed if unset.
+	MaxPodNames int
+	// Order is the order of the warnings, NamespaceWarningOrderPodName if unset.
+	Order NamespaceWarningOrder
+}
+
+// Validate checks the namespace warning options are valid.
+func (o NamespaceWarningOptions) Validate() error {
+	if o.MaxViolations < 0 {
+		return fmt.Errorf("maximum number of violations must not be negative, got %d", o.MaxViolations)
+	}
+	if o.MaxViolations == 1 {
+		return fmt.Errorf("maximum number of violations must leave room for the closing warning, got %d", o.MaxViolations)
+	}
+	if o.MaxPodNames < 0 {
+		return fmt.Errorf("maximum number of pod names must not be negative, got %d", o.MaxPodNames)
+	}
+	if _, err := ParseNamespaceWarningOrder(string(o.Order)); err != nil {
+		return fmt.Errorf("invalid namespace warning order %q: %w", o.Order, err)
+	}
+	return nil
+}
+
+// violationWarnings returns the warnings of the violations, prefixed with the names of the violating pods,
+// in order and limited to MaxViolations.
+func (o NamespaceWarningOptions) violationWarnings(violations []NamespaceViolation) []string {
+	maxPodNames := o.MaxPodNames
+	if maxPodNames == 0 {
+		maxPodNames = 1
+	}
+	type podWarning struct {
+		warning  string
+		podCount int
+	}
+	podWarnings := make([]podWarning, 0, len(violations))
+	for _, v := range violations {
+		podWarnings = append(podWarnings, podWarning{warning: decoratePodWarning(v, maxPodNames), podCount: v.PodCount})
+	}
+	// put warnings in a deterministic order
+	sort.SliceStable(podWarnings, func(i, j int) bool {
+		if o.Order == NamespaceWarningOrderPodCount && podWarnings[i].podCount != podWarnings[j].podCount {
+			return podWarnings[i].podCount > podWarnings[j].podCount
+		}
+		return podWarnings[i].warning < podWarnings[j].warning
+	})
+
+	kept := len(podWarnings)
+	if o.MaxViolations > 0 && len(podWarnings) > o.MaxViolations {
+		kept = o.MaxViolations - 1
+	}
+	warnings := make([]string, 0, kept+1)
+	for _, w := range podWarnings[:kept] {
+		warnings = append(warnings, w.warning)
+	}
+	if omitted := podWarnings[kept:]; len(omitted) > 0 {
+		omittedPods := 0
+		for _, w := range omitted {
+			omittedPods += w.podCount
+		}
+		warnings = append(warnings, fmt.Sprintf("%d more distinct violations omitted, affecting %d pods", len(omitted), omittedPods))
+	}
+	return warnings
+}
+
+// decoratePodWarning prefixes the reason of the violation with up to maxPodNames names of the violating pods,
+// and the number of other violating pods.
+func decoratePodWarning(v NamespaceViolation, maxPodNames int) string {
+	podNames := v.PodNames
+	if len(podNames) == 0 && v.PodName != "" {
+		podNames = []string{v.PodName}
+	}
+	if len(podNames) > maxPodNames {
+		podNames = podNames[:maxPodNames]
+	}
+	if v.PodCount == 0 || len(podNames) == 0 {
+		// unexpected, just leave the warning alone
+		return v.Reason
+	}
+	prefix := strings.Join(podNames, ", ")
+	switch others := v.PodCount - len(podNames); {
+	case others <= 0:
+		return fmt.Sprintf("%s: %s", prefix, v.Reason)
+	case others == 1:
+		return fmt.Sprintf("%s (and 1 other pod): %s", prefix, v.Reason)
+	default:
+		return fmt.Sprintf("%s (and %d other pods): %s", prefix, others, v.Reason)
+	}
+}
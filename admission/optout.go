@@ -0,0 +1,246 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+	"k8s.io/utils/clock"
+)
+
+// CheckOptOut is an approved request to exclude the pods of a namespace from specific checks,
+// carried by the api.CheckOptOutAnnotation of the pods as a token signed with SignCheckOptOut.
+// Any pod of the namespace may carry the token until it expires, so opt-outs should be short-lived.
+type CheckOptOut struct {
+	// Namespace is the namespace of the pods the opt-out applies to.
+	Namespace string `json:"namespace"`
+	// Checks are the IDs of the checks the pods are excluded from.
+	Checks []policy.CheckID `json:"checks"`
+	// Expires is the time the opt-out expires at.
+	Expires time.Time `json:"expires"`
+	// Approver identifies who approved the opt-out, and is recorded in the audit annotations.
+	Approver string `json:"approver,omitempty"`
+}
+
+// CheckOptOutVerifier verifies the tokens of the api.CheckOptOutAnnotation of pods.
+type CheckOptOutVerifier interface {
+	// VerifyCheckOptOut returns the opt-out of the token, or an error if the token is not valid
+	// for pods in the given namespace.
+	VerifyCheckOptOut(namespace, token string) (*CheckOptOut, error)
+}
+
+// SignCheckOptOut returns the token of the opt-out signed with the private key, to set as the value
+// of the api.CheckOptOutAnnotation of pods. The token is the base64url-encoded JSON opt-out and its
+// base64url-encoded Ed25519 signature, separated by a dot.
+func SignCheckOptOut(key ed25519.PrivateKey, optOut CheckOptOut) (string, error) {
+	payload, err := json.Marshal(optOut)
+	if err != nil {
+		return "", err
+	}
+	signature := ed25519.Sign(key, payload)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// NewCheckOptOutVerifier returns a CheckOptOutVerifier accepting tokens signed by any of the public keys,
+// that have not expired.
+func NewCheckOptOutVerifier(keys []ed25519.PublicKey) CheckOptOutVerifier {
+	return &checkOptOutVerifier{keys: keys, clock: clock.RealClock{}}
+}
+
+type checkOptOutVerifier struct {
+	keys  []ed25519.PublicKey
+	clock clock.PassiveClock
+}
+
+func (v *checkOptOutVerifier) VerifyCheckOptOut(namespace, token string) (*CheckOptOut, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, errors.New("malformed token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, errors.New("malformed token")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return nil, errors.New("malformed token")
+	}
+	verified := false
+	for _, key := range v.keys {
+		if ed25519.Verify(key, payload, signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, errors.New("invalid signature")
+	}
+
+	optOut := &CheckOptOut{}
+	if err := json.Unmarshal(payload, optOut); err != nil {
+		return nil, errors.New("malformed token")
+	}
+	if optOut.Namespace != namespace {
+		return nil, fmt.Errorf("approved for namespace %q", optOut.Namespace)
+	}
+	if !v.clock.Now().Before(optOut.Expires) {
+		return nil, fmt.Errorf("expired at %s", optOut.Expires.UTC().Format(time.RFC3339))
+	}
+	return optOut, nil
+}
+
+// ParseCheckOptOutPublicKeys parses the PEM-encoded PKIX Ed25519 public keys verifying check opt-outs.
+func ParseCheckOptOutPublicKeys(data []byte) ([]ed25519.PublicKey, error) {
+	var keys []ed25519.PublicKey
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "PUBLIC KEY" {
+			continue
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		ed25519Key, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("expected an Ed25519 public key, got %T", key)
+		}
+		keys = append(keys, ed25519Key)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no public keys found")
+	}
+	return keys, nil
+}
+
+// checkOptOut returns the checks the pod is opted out of by its api.CheckOptOutAnnotation, if any.
+// An error is returned if the annotation is set but cannot be verified, or if no verifier is configured.
+func (a *Admission) checkOptOut(namespace string, podMetadata *metav1.ObjectMeta) (*CheckOptOut, error) {
+	token, ok := podMetadata.Annotations[api.CheckOptOutAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	if a.CheckOptOutVerifier == nil {
+		return nil, errors.New("check opt-outs are not enabled")
+	}
+	return a.CheckOptOutVerifier.VerifyCheckOptOut(namespace, token)
+}
+
+// evaluatePod evaluates the pod against the policy, omitting the results of the checks the pod is opted out of,
+// and of the checks the namespace of the pod is exempt from.
+func (a *Admission) evaluatePod(lv api.LevelVersion, optOut *CheckOptOut, exemptChecks []policy.CheckID, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec) []policy.CheckResult {
+	var results []policy.CheckResult
+	if a.ephemeralContainers != nil {
+		results = policy.EvaluateEphemeralContainers(a.Evaluator, lv, podMetadata, podSpec, a.ephemeralContainers)
+	} else {
+		results = a.Evaluator.EvaluatePod(lv, podMetadata, podSpec)
+	}
+	a.recordCheckErrors(results)
+	// the guard re-evaluates whole pods, so the results scoped to ephemeral containers are not compared
+	if a.DeterminismGuard != nil && a.ephemeralContainers == nil {
+		a.DeterminismGuard.check(a.metrics(), lv, podMetadata, podSpec, results)
+	}
+	if a.resizedFrom != nil {
+		results = a.withoutPreexistingViolations(lv, results)
+	}
+	excluded := excludedChecks(optOut, exemptChecks)
+	if excluded.Len() == 0 && a.resourceChecks == nil {
+		return results
+	}
+	filtered := make([]policy.CheckResult, 0, len(results))
+	for _, result := range results {
+		if !excluded.Has(result.ID) && (a.resourceChecks == nil || a.resourceChecks.Has(result.ID)) {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
+}
+
+// evaluatePodUntilDenied evaluates the pod like evaluatePod, stopping at the first check disallowing the pod
+// if the Evaluator implements policy.ShortCircuitEvaluator and the evaluation is not scoped to ephemeral containers
+// or to the checks reading resources.
+// It returns false if the evaluation stopped, in which case the results only include the first violated check.
+func (a *Admission) evaluatePodUntilDenied(lv api.LevelVersion, optOut *CheckOptOut, exemptChecks []policy.CheckID, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec) ([]policy.CheckResult, bool) {
+	evaluator, ok := a.Evaluator.(policy.ShortCircuitEvaluator)
+	if !ok || a.ephemeralContainers != nil || a.resourceChecks != nil {
+		return a.evaluatePod(lv, optOut, exemptChecks, podMetadata, podSpec), true
+	}
+	var skip func(policy.CheckID) bool
+	excluded := excludedChecks(optOut, exemptChecks)
+	if excluded.Len() > 0 {
+		skip = excluded.Has
+	}
+	results := evaluator.EvaluatePodUntilDenied(lv, podMetadata, podSpec, skip)
+	a.recordCheckErrors(results)
+	if len(results) > 0 && !results[len(results)-1].Allowed {
+		return results, false
+	}
+	// the results of all the checks are compared with the re-evaluation of the guard
+	if a.DeterminismGuard != nil && skip == nil {
+		a.DeterminismGuard.check(a.metrics(), lv, podMetadata, podSpec, results)
+	}
+	return results, true
+}
+
+// excludedChecks returns the checks the pod is excluded from by its opt-out and the exempt checks of its namespace.
+func excludedChecks(optOut *CheckOptOut, exemptChecks []policy.CheckID) sets.Set[policy.CheckID] {
+	excluded := sets.New(exemptChecks...)
+	if optOut != nil {
+		excluded.Insert(optOut.Checks...)
+	}
+	return excluded
+}
+
+// evaluatePodIgnoringInvalidOptOut evaluates the pod like evaluatePod, honoring the opt-out of the pod
+// only if it can be verified.
+func (a *Admission) evaluatePodIgnoringInvalidOptOut(lv api.LevelVersion, namespace string, exemptChecks []policy.CheckID, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec) []policy.CheckResult {
+	optOut, err := a.checkOptOut(namespace, podMetadata)
+	if err != nil {
+		optOut = nil
+	}
+	return a.evaluatePod(lv, optOut, exemptChecks, podMetadata, podSpec)
+}
+
+// checkOptOutAuditAnnotation describes the honored opt-out in the audit annotations.
+func checkOptOutAuditAnnotation(optOut *CheckOptOut) string {
+	checks := make([]string, len(optOut.Checks))
+	for i, id := range optOut.Checks {
+		checks[i] = string(id)
+	}
+	value := fmt.Sprintf("%s until %s", strings.Join(checks, ","), optOut.Expires.UTC().Format(time.RFC3339))
+	if optOut.Approver != "" {
+		value += " approved by " + optOut.Approver
+	}
+	return value
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// resizeSubresource is the pod subresource resizing the resources of containers in place.
+const resizeSubresource = "resize"
+
+// resizedContainers returns true if the update changes the resources or resizePolicy of containers or init containers,
+// like in-place resizes of clusters updating them through the pod itself rather than the resize subresource.
+func resizedContainers(pod, oldPod *corev1.Pod) bool {
+	resized := func(containers, oldContainers []corev1.Container) bool {
+		for i := range containers {
+			if i >= len(oldContainers) {
+				return true
+			}
+			if !equality.Semantic.DeepEqual(containers[i].Resources, oldContainers[i].Resources) ||
+				!equality.Semantic.DeepEqual(containers[i].ResizePolicy, oldContainers[i].ResizePolicy) {
+				return true
+			}
+		}
+		return false
+	}
+	return resized(pod.Spec.Containers, oldPod.Spec.Containers) || resized(pod.Spec.InitContainers, oldPod.Spec.InitContainers)
+}
+
+// scopeToResourceChecks returns a copy of the Admission evaluating only the checks reading the resources of containers
+// (see policy.ResourceChecks), like resourceLimits. In-place resizes cannot change the other fields of pods, so they are
+// admitted regardless of the violations of the other checks, while resizes introducing violations of the resource checks
+// are denied. The violations the oldPod already had, e.g. of a container that was not resized, do not deny the resize.
+func (a *Admission) scopeToResourceChecks(oldPod *corev1.Pod) *Admission {
+	scoped := *a
+	scoped.resourceChecks = sets.New(policy.ResourceChecks()...)
+	scoped.resizedFrom = oldPod
+	return &scoped
+}
+
+// withoutPreexistingViolations returns the results of the resized pod, allowing the checks disallowing it
+// with the same details as the pod it was resized from. The results are copied before being modified,
+// since they may be shared by the Evaluator.
+func (a *Admission) withoutPreexistingViolations(lv api.LevelVersion, results []policy.CheckResult) []policy.CheckResult {
+	var oldResults map[policy.CheckID]policy.CheckResult
+	copied := false
+	for i, result := range results {
+		if result.Allowed || result.Error != nil || !a.resourceChecks.Has(result.ID) {
+			continue
+		}
+		if oldResults == nil {
+			oldResults = map[policy.CheckID]policy.CheckResult{}
+			for _, oldResult := range a.Evaluator.EvaluatePod(lv, &a.resizedFrom.ObjectMeta, &a.resizedFrom.Spec) {
+				oldResults[oldResult.ID] = oldResult
+			}
+		}
+		oldResult, ok := oldResults[result.ID]
+		if !ok || oldResult.Allowed || oldResult.Error != nil ||
+			oldResult.ForbiddenReason != result.ForbiddenReason || oldResult.ForbiddenDetail != result.ForbiddenDetail {
+			continue
+		}
+		if !copied {
+			results = append([]policy.CheckResult(nil), results...)
+			copied = true
+		}
+		results[i] = policy.CheckResult{ID: result.ID, Allowed: true, Version: result.Version, Source: result.Source, Severity: result.Severity}
+	}
+	return results
+}
@@ -27,11 +27,12 @@ import (
 )
 
 var (
-	sharedAllowedResponse                        = allowedResponse()
-	sharedAllowedPrivilegedResponse              = allowedResponse()
-	sharedAllowedByUserExemptionResponse         = allowedResponse()
-	sharedAllowedByNamespaceExemptionResponse    = allowedResponse()
-	sharedAllowedByRuntimeClassExemptionResponse = allowedResponse()
+	sharedAllowedResponse                          = allowedResponse()
+	sharedAllowedPrivilegedResponse                = allowedResponse()
+	sharedAllowedByUserExemptionResponse           = allowedResponse()
+	sharedAllowedByNamespaceExemptionResponse      = allowedResponse()
+	sharedAllowedByRuntimeClassExemptionResponse   = allowedResponse()
+	sharedAllowedByServiceAccountExemptionResponse = allowedResponse()
 )
 
 func init() {
@@ -41,6 +42,7 @@ func init() {
 	sharedAllowedByUserExemptionResponse.AuditAnnotations = map[string]string{api.ExemptionReasonAnnotationKey: "user"}
 	sharedAllowedByNamespaceExemptionResponse.AuditAnnotations = map[string]string{api.ExemptionReasonAnnotationKey: "namespace"}
 	sharedAllowedByRuntimeClassExemptionResponse.AuditAnnotations = map[string]string{api.ExemptionReasonAnnotationKey: "runtimeClass"}
+	sharedAllowedByServiceAccountExemptionResponse.AuditAnnotations = map[string]string{api.ExemptionReasonAnnotationKey: "serviceAccount"}
 }
 
 // allowedResponse is the response used when the admission decision is allow.
@@ -0,0 +1,97 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"context"
+	"fmt"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/pod-security-admission/api"
+)
+
+// Decision is the admission decision of a Result.
+type Decision string
+
+const (
+	// DecisionAllow indicates the request is admitted.
+	DecisionAllow Decision = "Allow"
+	// DecisionDeny indicates the request is rejected.
+	DecisionDeny Decision = "Deny"
+)
+
+// Result is the typed outcome of admitting a request, for callers that do not
+// operate on AdmissionReviews, like CLIs and controllers.
+// Unlike the responses returned by Validate, a Result is never shared and may be mutated.
+type Result struct {
+	// Decision is the admission decision.
+	Decision Decision
+	// Warnings are the warnings to return to the requester.
+	Warnings []string
+	// AuditAnnotations are the annotations to record in the audit event of the request.
+	AuditAnnotations map[string]string
+	// Errors explains a Deny decision. Invalid requests have one error per invalid field,
+	// other denials have a single error.
+	Errors []error
+	// Status is the API status of a Deny decision.
+	Status *metav1.Status
+}
+
+// Allowed returns true if the decision is Allow.
+func (r *Result) Allowed() bool {
+	return r.Decision == DecisionAllow
+}
+
+// ValidateResult admits an API request like Validate, and returns the typed result.
+func (a *Admission) ValidateResult(ctx context.Context, attrs api.Attributes) *Result {
+	return ResultFromResponse(a.Validate(ctx, attrs))
+}
+
+// ResultFromResponse converts an admission response to a Result.
+// The response is not retained and may be shared.
+func ResultFromResponse(response *admissionv1.AdmissionResponse) *Result {
+	result := &Result{Decision: DecisionAllow}
+	if len(response.Warnings) > 0 {
+		result.Warnings = append([]string(nil), response.Warnings...)
+	}
+	if len(response.AuditAnnotations) > 0 {
+		result.AuditAnnotations = make(map[string]string, len(response.AuditAnnotations))
+		for k, v := range response.AuditAnnotations {
+			result.AuditAnnotations[k] = v
+		}
+	}
+	if response.Allowed {
+		return result
+	}
+
+	result.Decision = DecisionDeny
+	if response.Result == nil {
+		result.Errors = []error{fmt.Errorf("request denied")}
+		return result
+	}
+	result.Status = response.Result.DeepCopy()
+	if result.Status.Reason == metav1.StatusReasonInvalid && result.Status.Details != nil && len(result.Status.Details.Causes) > 0 {
+		for _, cause := range result.Status.Details.Causes {
+			result.Errors = append(result.Errors, fmt.Errorf("%s: %s", cause.Field, cause.Message))
+		}
+	} else {
+		result.Errors = []error{&apierrors.StatusError{ErrStatus: *result.Status}}
+	}
+	return result
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/pod-security-admission/api"
+)
+
+// NamespaceRolloutGuard determines how namespace label updates that tighten the enforce level
+// without following a safe rollout are handled.
+//
+// An update is considered unsafe if it raises the enforce level by more than one level at a time
+// (privileged to restricted), or raises the enforce level above the audit level previously set on the namespace.
+type NamespaceRolloutGuard string
+
+const (
+	// NamespaceRolloutGuardNone allows namespace label updates regardless of rollout.
+	NamespaceRolloutGuardNone NamespaceRolloutGuard = ""
+	// NamespaceRolloutGuardWarn allows unsafe namespace label updates with a warning.
+	NamespaceRolloutGuardWarn NamespaceRolloutGuard = "Warn"
+	// NamespaceRolloutGuardDeny rejects unsafe namespace label updates.
+	NamespaceRolloutGuardDeny NamespaceRolloutGuard = "Deny"
+)
+
+// ParseNamespaceRolloutGuard returns the NamespaceRolloutGuard for the given string.
+// guard must be "", "None", "Warn", or "Deny".
+func ParseNamespaceRolloutGuard(guard string) (NamespaceRolloutGuard, error) {
+	switch NamespaceRolloutGuard(guard) {
+	case NamespaceRolloutGuardNone, "None":
+		return NamespaceRolloutGuardNone, nil
+	case NamespaceRolloutGuardWarn, NamespaceRolloutGuardDeny:
+		return NamespaceRolloutGuard(guard), nil
+	default:
+		return NamespaceRolloutGuardNone, fmt.Errorf(`must be one of None, Warn, Deny`)
+	}
+}
+
+// levelRank orders levels by strictness.
+var levelRank = map[api.Level]int{
+	api.LevelPrivileged: 0,
+	api.LevelBaseline:   1,
+	api.LevelRestricted: 2,
+}
+
+// unsafeRollout returns the reasons the update from oldPolicy to newPolicy does not follow a safe rollout.
+func unsafeRollout(oldPolicy, newPolicy api.Policy) []string {
+	if api.CompareLevels(newPolicy.Enforce.Level, oldPolicy.Enforce.Level) < 1 {
+		// The enforce level was not tightened.
+		return nil
+	}
+	var reasons []string
+	if levelRank[newPolicy.Enforce.Level]-levelRank[oldPolicy.Enforce.Level] > 1 {
+		reasons = append(reasons, fmt.Sprintf(
+			"PodSecurity enforce level raised from %q to %q, enforce level should be raised one level at a time",
+			oldPolicy.Enforce.Level, newPolicy.Enforce.Level,
+		))
+	}
+	if api.CompareLevels(oldPolicy.Audit.Level, newPolicy.Enforce.Level) < 0 {
+		reasons = append(reasons, fmt.Sprintf(
+			"PodSecurity enforce level raised to %q without auditing at %q first, set the %s label before the %s label",
+			newPolicy.Enforce.Level, newPolicy.Enforce.Level, api.AuditLevelLabel, api.EnforceLevelLabel,
+		))
+	}
+	return reasons
+}
+
+// unconfirmedPrivilegedDowngrade returns an error if the configuration requires a confirmation to enforce
+// the privileged level on a namespace previously enforcing the baseline or restricted level,
+// and the namespace is not allowed to skip it and does not set the api.ConfirmPrivilegedAnnotation to "true".
+func (a *Admission) unconfirmedPrivilegedDowngrade(namespace *corev1.Namespace, oldPolicy, newPolicy api.Policy) error {
+	confirmation := a.Configuration.PrivilegedConfirmation
+	if !confirmation.Required ||
+		newPolicy.Enforce.Level != api.LevelPrivileged ||
+		oldPolicy.Enforce.Level == api.LevelPrivileged {
+		return nil
+	}
+	if containsString(namespace.Name, confirmation.AllowedNamespaces) {
+		return nil
+	}
+	if namespace.Annotations[api.ConfirmPrivilegedAnnotation] == "true" {
+		return nil
+	}
+	return fmt.Errorf(
+		"PodSecurity enforce level lowered from %q to %q without confirmation, set the %s annotation to \"true\" to confirm",
+		oldPolicy.Enforce.Level, newPolicy.Enforce.Level, api.ConfirmPrivilegedAnnotation,
+	)
+}
+
+// unconfirmedCheckExemptions returns an error if the configuration requires a confirmation to enforce the privileged level
+// (see unconfirmedPrivilegedDowngrade), and the namespace enforcing the baseline or restricted level newly exempts its pods
+// from checks without the same confirmation, since exempting checks also relaxes its enforce policy.
+func (a *Admission) unconfirmedCheckExemptions(namespace, oldNamespace *corev1.Namespace, newPolicy api.Policy) error {
+	confirmation := a.Configuration.PrivilegedConfirmation
+	if !confirmation.Required || newPolicy.Enforce.Level == api.LevelPrivileged {
+		return nil
+	}
+	exempt, _ := a.namespaceExemptChecks(namespace)
+	oldExempt, _ := a.namespaceExemptChecks(oldNamespace)
+	added := sets.List(sets.New(exempt...).Difference(sets.New(oldExempt...)))
+	if len(added) == 0 {
+		return nil
+	}
+	if containsString(namespace.Name, confirmation.AllowedNamespaces) {
+		return nil
+	}
+	if namespace.Annotations[api.ConfirmPrivilegedAnnotation] == "true" {
+		return nil
+	}
+	return fmt.Errorf(
+		"PodSecurity checks %s exempted without confirmation, set the %s annotation to \"true\" to confirm",
+		joinCheckIDs(added), api.ConfirmPrivilegedAnnotation,
+	)
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// violationSeveritiesAuditAnnotation describes the violated checks of the result by severity, from the most to the
+// least severe, e.g. "critical=privileged,hostPathVolumes; medium=runAsNonRoot", so audit-mode violations can be triaged.
+// Violations without a check ID or severity are omitted.
+func violationSeveritiesAuditAnnotation(result policy.AggregateCheckResult) string {
+	bySeverity := map[policy.Severity][]policy.CheckID{}
+	for _, violation := range result.Violations {
+		if violation.Check != "" && violation.Severity != "" {
+			bySeverity[violation.Severity] = append(bySeverity[violation.Severity], violation.Check)
+		}
+	}
+	var parts []string
+	for _, severity := range policy.Severities() {
+		if checks := bySeverity[severity]; len(checks) > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%s", severity, joinCheckIDs(checks)))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// auditViolationsDetailAuditAnnotation encodes the violations of the result of the audit evaluation against lv
+// as an api.AuditViolationsDetail.
+func auditViolationsDetailAuditAnnotation(lv api.LevelVersion, result policy.AggregateCheckResult) (string, error) {
+	detail := api.AuditViolationsDetail{
+		Policy:     lv.String(),
+		Violations: make([]api.AuditViolation, 0, len(result.Violations)),
+	}
+	for _, violation := range result.Violations {
+		v := api.AuditViolation{
+			Check:      string(violation.Check),
+			Reason:     violation.Reason,
+			Severity:   string(violation.Severity),
+			Containers: violation.Containers,
+		}
+		for _, f := range violation.Fields {
+			if f.Path != "" {
+				v.Fields = append(v.Fields, f.Path)
+			}
+		}
+		detail.Violations = append(detail.Violations, v)
+	}
+	return api.EncodeAuditViolationsDetail(detail)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"fmt"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/metrics"
+)
+
+// recordVersionSkew records the audit and warn versions of the policy skewed from the enforce version,
+// if the Metrics implement metrics.VersionSkewRecorder.
+func (a *Admission) recordVersionSkew(nsPolicy api.Policy, attrs api.Attributes) {
+	recorder, ok := a.metrics().(metrics.VersionSkewRecorder)
+	if !ok {
+		return
+	}
+	if skew := nsPolicy.AuditVersionSkew(); skew != api.VersionSkewNone {
+		recorder.RecordVersionSkew(metrics.ModeAudit, skew, attrs)
+	}
+	if skew := nsPolicy.WarnVersionSkew(); skew != api.VersionSkewNone {
+		recorder.RecordVersionSkew(metrics.ModeWarn, skew, attrs)
+	}
+}
+
+// versionSkewWarnings returns a warning for the audit and warn versions of the policy skewed from the enforce version.
+func versionSkewWarnings(nsPolicy api.Policy) []string {
+	var warnings []string
+	for _, mode := range []struct {
+		name string
+		lv   api.LevelVersion
+		skew api.VersionSkew
+	}{
+		{name: "audit", lv: nsPolicy.Audit, skew: nsPolicy.AuditVersionSkew()},
+		{name: "warn", lv: nsPolicy.Warn, skew: nsPolicy.WarnVersionSkew()},
+	} {
+		switch mode.skew {
+		case api.VersionSkewNewer:
+			warnings = append(warnings, fmt.Sprintf("PodSecurity %s version %q is newer than enforce version %q",
+				mode.name, mode.lv.Version.String(), nsPolicy.Enforce.Version.String()))
+		case api.VersionSkewOlder:
+			warnings = append(warnings, fmt.Sprintf("PodSecurity %s version %q is older than enforce version %q, which is likely a misconfiguration",
+				mode.name, mode.lv.Version.String(), nsPolicy.Enforce.Version.String()))
+		}
+	}
+	return warnings
+}
+
+// withVersionSkewWarnings returns a copy of the response with the versionSkewWarnings of the namespace in the request,
+// if it is created with skewed versions, or updated to a different skew.
+func (a *Admission) withVersionSkewWarnings(response *admissionv1.AdmissionResponse, attrs api.Attributes) *admissionv1.AdmissionResponse {
+	if attrs.GetSubresource() != "" || a.exemptNamespace(attrs.GetNamespace()) {
+		return response
+	}
+	namespace, ok := namespaceObject(attrs.GetObject())
+	if !ok {
+		return response
+	}
+	newPolicy, errs := a.PolicyToEvaluate(namespace.Labels)
+	if len(errs) > 0 {
+		return response
+	}
+	switch attrs.GetOperation() {
+	case admissionv1.Create:
+	case admissionv1.Update:
+		oldNamespace, ok := namespaceObject(attrs.GetOldObject())
+		if !ok {
+			return response
+		}
+		oldPolicy, _ := a.PolicyToEvaluate(oldNamespace.Labels)
+		if oldPolicy.AuditVersionSkew() == newPolicy.AuditVersionSkew() && oldPolicy.WarnVersionSkew() == newPolicy.WarnVersionSkew() {
+			return response
+		}
+	default:
+		return response
+	}
+
+	skewWarnings := versionSkewWarnings(newPolicy)
+	if len(skewWarnings) == 0 {
+		return response
+	}
+	warnings := make([]string, 0, len(response.Warnings)+len(skewWarnings))
+	warnings = append(warnings, response.Warnings...)
+	warnings = append(warnings, skewWarnings...)
+	withWarnings := *response
+	withWarnings.Warnings = warnings
+	return &withWarnings
+}
+
+func namespaceObject(obj interface{}, err error) (*corev1.Namespace, bool) {
+	if err != nil {
+		return nil, false
+	}
+	namespace, ok := obj.(*corev1.Namespace)
+	return namespace, ok
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// denialSnippets returns the snippets of the DenialSnippets checks violated by the pod, appended to the message
+// of its enforce denial, or an empty string if there are none.
+func (a *Admission) denialSnippets(result policy.AggregateCheckResult) string {
+	if len(a.DenialSnippets) == 0 {
+		return ""
+	}
+	selected := sets.New(a.DenialSnippets...)
+	var b strings.Builder
+	for _, violation := range result.Violations {
+		if !selected.Has(violation.Check) {
+			continue
+		}
+		if snippet, ok := policy.CheckSnippetFor(violation.Check); ok {
+			fmt.Fprintf(&b, "\nto satisfy %s, set %s", violation.Check, snippet)
+		}
+	}
+	return b.String()
+}
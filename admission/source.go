@@ -0,0 +1,88 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"fmt"
+
+	"k8s.io/pod-security-admission/api"
+)
+
+// enforceStatus describes where the level & version of the enforce policy evaluated for a namespace
+// with the given labels come from: the namespace labels, the configured defaults, or the floor.
+func (a *Admission) enforceStatus(labels map[string]string, enforce api.LevelVersion) api.ModeStatus {
+	status := a.lenientModeStatus(api.PolicyStatusFor(labels, a.defaultPolicy).Enforce)
+
+	labelPolicy, _ := a.labelPolicy(labels)
+	if labelPolicy.Enforce.Level != enforce.Level {
+		status.Level.Source = api.LabelSourceFloor
+	}
+	if labelPolicy.Enforce.Version != enforce.Version {
+		status.Version.Source = api.LabelSourceFloor
+	}
+	status.Level.Effective = string(enforce.Level)
+	status.Version.Effective = enforce.Version.String()
+	return status
+}
+
+// PolicyStatus describes how the policy evaluated for a namespace with the given labels is resolved
+// from the labels, the configured defaults and the floor, like PolicyToEvaluate.
+func (a *Admission) PolicyStatus(labels map[string]string) api.PolicyStatus {
+	nsPolicy, _ := a.PolicyToEvaluate(labels)
+	status := api.PolicyStatusFor(labels, a.defaultPolicy)
+	status.Policy = nsPolicy
+	status.Enforce = a.enforceStatus(labels, nsPolicy.Enforce)
+	status.Audit = a.lenientModeStatus(status.Audit)
+	status.Audit.Level.Effective, status.Audit.Version.Effective = string(nsPolicy.Audit.Level), nsPolicy.Audit.Version.String()
+	status.Warn = a.lenientModeStatus(status.Warn)
+	status.Warn.Level.Effective, status.Warn.Version.Effective = string(nsPolicy.Warn.Level), nsPolicy.Warn.Version.String()
+	return status
+}
+
+// lenientModeStatus marks the labels normalized by LenientLabelParsing as valid.
+func (a *Admission) lenientModeStatus(status api.ModeStatus) api.ModeStatus {
+	if !a.LenientLabelParsing {
+		return status
+	}
+	if _, _, err := api.ParseLevelLenient(status.Level.Value); err == nil && status.Level.Set {
+		status.Level.Source, status.Level.Error = api.LabelSourceLabel, ""
+	}
+	if _, _, err := api.ParseVersionLenient(status.Version.Value); err == nil && status.Version.Set {
+		status.Version.Source, status.Version.Error = api.LabelSourceLabel, ""
+	}
+	return status
+}
+
+// describeEnforceStatus returns a short description of the sources of the enforce level & version, e.g.
+// `namespace label pod-security.kubernetes.io/enforce="baseline", default enforce-version="latest"`.
+func describeEnforceStatus(status api.ModeStatus) string {
+	return describeLabelStatus(status.Level, "enforce") + ", " + describeLabelStatus(status.Version, "enforce-version")
+}
+
+// describeLabelStatus describes the source of a single label, with the key of the equivalent configuration field.
+func describeLabelStatus(status api.LabelStatus, key string) string {
+	switch status.Source {
+	case api.LabelSourceLabel:
+		return fmt.Sprintf("namespace label %s=%q", status.Label, status.Value)
+	case api.LabelSourceFallback:
+		return fmt.Sprintf("invalid namespace label %s=%q", status.Label, status.Value)
+	case api.LabelSourceFloor:
+		return fmt.Sprintf("cluster floor %s=%q", key, status.Effective)
+	default:
+		return fmt.Sprintf("default %s=%q", key, status.Effective)
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+	"k8s.io/pod-security-admission/api"
+)
+
+// SubresourceWarnings determines the warnings returned for scale subresource requests of pod controllers.
+// Scaling up materializes pods from a pod template that is not updated by the request, and may have been admitted
+// under an older namespace policy, e.g. when rolling back by scaling up an old ReplicaSet of a Deployment.
+type SubresourceWarnings string
+
+const (
+	// SubresourceWarningsNone allows subresource requests without evaluation.
+	SubresourceWarningsNone SubresourceWarnings = ""
+	// SubresourceWarningsSummary adds a single warning naming the controller and the checks violated by its pod template.
+	SubresourceWarningsSummary SubresourceWarnings = "Summary"
+	// SubresourceWarningsDetailed adds the warnings of an update of the pod template, attributed to the controller.
+	SubresourceWarningsDetailed SubresourceWarnings = "Detailed"
+)
+
+// ParseSubresourceWarnings returns the SubresourceWarnings for the given string.
+// warnings must be "", "None", "Summary", or "Detailed".
+func ParseSubresourceWarnings(warnings string) (SubresourceWarnings, error) {
+	switch SubresourceWarnings(warnings) {
+	case SubresourceWarningsNone, "None":
+		return SubresourceWarningsNone, nil
+	case SubresourceWarningsSummary, SubresourceWarningsDetailed:
+		return SubresourceWarnings(warnings), nil
+	default:
+		return SubresourceWarningsNone, fmt.Errorf(`must be one of None, Summary, Detailed`)
+	}
+}
+
+// PodControllerGetter gets the pod controllers targeted by subresource requests.
+type PodControllerGetter interface {
+	// GetPodController returns the pod controller of the given resource in the namespace.
+	GetPodController(ctx context.Context, resource schema.GroupResource, namespace, name string) (runtime.Object, error)
+}
+
+// PodControllerGetterFromClient returns a PodControllerGetter that does live gets of
+// deployments, replicasets, statefulsets and replicationcontrollers using the provided client.
+func PodControllerGetterFromClient(client kubernetes.Interface) PodControllerGetter {
+	return &clientPodControllerGetter{client}
+}
+
+type clientPodControllerGetter struct {
+	client kubernetes.Interface
+}
+
+func (g *clientPodControllerGetter) GetPodController(ctx context.Context, resource schema.GroupResource, namespace, name string) (runtime.Object, error) {
+	switch resource {
+	case deploymentsResource:
+		return g.client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	case replicaSetsResource:
+		return g.client.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	case statefulSetsResource:
+		return g.client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	case replicationControllersResource:
+		return g.client.CoreV1().ReplicationControllers(namespace).Get(ctx, name, metav1.GetOptions{})
+	default:
+		return nil, fmt.Errorf("unsupported pod controller resource %s", resource)
+	}
+}
+
+var (
+	deploymentsResource            = schema.GroupResource{Group: "apps", Resource: "deployments"}
+	replicaSetsResource            = schema.GroupResource{Group: "apps", Resource: "replicasets"}
+	statefulSetsResource           = schema.GroupResource{Group: "apps", Resource: "statefulsets"}
+	replicationControllersResource = corev1.Resource("replicationcontrollers")
+)
+
+// validateScale evaluates the pod template of the pod controller scaled up by a scale subresource request.
+// The request is always allowed, with warnings according to SubresourceWarnings.
+func (a *Admission) validateScale(ctx context.Context, attrs api.Attributes) *admissionv1.AdmissionResponse {
+	if a.exemptNamespace(attrs.GetNamespace()) || a.exemptUser(attrs) {
+		a.metrics().RecordExemption(attrs)
+		return sharedAllowedResponse
+	}
+	if !scaledUp(attrs) {
+		return sharedAllowedResponse
+	}
+
+	logger := klog.FromContext(ctx)
+	namespace, err := a.lookupNamespace(ctx, attrs)
+	if err != nil {
+		logger.Error(err, "failed to fetch pod namespace", "namespace", attrs.GetNamespace())
+		return sharedAllowedResponse
+	}
+	nsPolicy, nsPolicyErrs := a.PolicyToEvaluate(namespace.Labels)
+	if len(nsPolicyErrs) == 0 && nsPolicy.Warn.Level == api.LevelPrivileged && nsPolicy.Audit.Level == api.LevelPrivileged {
+		return sharedAllowedResponse
+	}
+
+	controller, err := a.PodControllerGetter.GetPodController(ctx, attrs.GetResource().GroupResource(), attrs.GetNamespace(), attrs.GetName())
+	if err != nil {
+		logger.Error(err, "failed to fetch scaled pod controller", "resource", attrs.GetResource(), "namespace", attrs.GetNamespace(), "name", attrs.GetName())
+		return sharedAllowedResponse
+	}
+	podMetadata, podSpec, err := a.PodSpecExtractor.ExtractPodSpec(controller)
+	if err != nil {
+		logger.Error(err, "failed to extract pod spec")
+		return sharedAllowedResponse
+	}
+	if podMetadata == nil && podSpec == nil {
+		return sharedAllowedResponse
+	}
+
+	exemptChecks, ignoredExemptChecks := a.namespaceExemptChecks(namespace)
+	response := a.evaluatePodPolicy(ctx, nsPolicy, nsPolicyErrs.ToAggregate(), "", exemptChecks, ignoredExemptChecks, podMetadata, podSpec, attrs, false)
+	if len(response.Warnings) == 0 {
+		return response
+	}
+	controllerName := describePodController(attrs.GetResource().GroupResource(), controller)
+	switch a.SubresourceWarnings {
+	case SubresourceWarningsSummary:
+		optOut, _ := a.checkOptOut(attrs.GetNamespace(), podMetadata)
+		checks := violatedChecks(a.evaluatePod(nsPolicy.Warn, optOut, exemptChecks, podMetadata, podSpec))
+		if len(checks) == 0 {
+			response.Warnings = nil
+			break
+		}
+		names := make([]string, len(checks))
+		for i, check := range checks {
+			names[i] = string(check)
+		}
+		response.Warnings = []string{fmt.Sprintf(
+			"scaling %s creates pods that would violate PodSecurity %q: %s",
+			controllerName, nsPolicy.Warn.String(), strings.Join(names, ", "),
+		)}
+	default:
+		for i, warning := range response.Warnings {
+			response.Warnings[i] = fmt.Sprintf("%s: %s", controllerName, warning)
+		}
+	}
+	return response
+}
+
+// scaledUp returns false if the scale subresource request does not increase the number of replicas.
+// Requests with objects that are not autoscaling/v1 Scale objects are assumed to scale up.
+func scaledUp(attrs api.Attributes) bool {
+	if attrs.GetOperation() != admissionv1.Update {
+		return true
+	}
+	obj, err := attrs.GetObject()
+	if err != nil {
+		return true
+	}
+	oldObj, err := attrs.GetOldObject()
+	if err != nil {
+		return true
+	}
+	scale, ok := obj.(*autoscalingv1.Scale)
+	if !ok {
+		return true
+	}
+	oldScale, ok := oldObj.(*autoscalingv1.Scale)
+	if !ok {
+		return true
+	}
+	return scale.Spec.Replicas > oldScale.Spec.Replicas
+}
+
+// describePodController returns the kind and name of the pod controller,
+// attributed to the Deployment controlling it if it is a ReplicaSet, e.g. deployment "web" (replicaset "web-7d4b9c").
+func describePodController(resource schema.GroupResource, controller runtime.Object) string {
+	kind := strings.TrimSuffix(resource.Resource, "s")
+	accessor, err := meta.Accessor(controller)
+	if err != nil {
+		return kind
+	}
+	description := fmt.Sprintf("%s %q", kind, accessor.GetName())
+	if owner := metav1.GetControllerOfNoCopy(accessor); owner != nil && resource == replicaSetsResource && owner.Kind == "Deployment" {
+		description = fmt.Sprintf("deployment %q (%s)", owner.Name, description)
+	}
+	return description
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"fmt"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/pod-security-admission/api"
+)
+
+// UnknownLabelsAction determines how namespaces with unknown labels under the pod-security.kubernetes.io/ prefix
+// are handled, e.g. typos like pod-security.kubernetes.io/enforces, which are otherwise ignored and leave the namespace
+// evaluated against the defaults (see api.UnknownLabels).
+type UnknownLabelsAction string
+
+const (
+	// UnknownLabelsIgnore allows namespaces with unknown labels.
+	UnknownLabelsIgnore UnknownLabelsAction = ""
+	// UnknownLabelsWarn allows namespaces with unknown labels with a warning for each unknown label.
+	UnknownLabelsWarn UnknownLabelsAction = "Warn"
+	// UnknownLabelsDeny rejects namespaces with unknown labels, like labels with invalid values:
+	// on creation, and on updates changing the unknown labels.
+	UnknownLabelsDeny UnknownLabelsAction = "Deny"
+)
+
+// ParseUnknownLabelsAction returns the UnknownLabelsAction for the given string.
+// action must be "", "Ignore", "Warn", or "Deny".
+func ParseUnknownLabelsAction(action string) (UnknownLabelsAction, error) {
+	switch UnknownLabelsAction(action) {
+	case UnknownLabelsIgnore, "Ignore":
+		return UnknownLabelsIgnore, nil
+	case UnknownLabelsWarn, UnknownLabelsDeny:
+		return UnknownLabelsAction(action), nil
+	default:
+		return UnknownLabelsIgnore, fmt.Errorf(`must be one of Ignore, Warn, Deny`)
+	}
+}
+
+// withUnknownLabelsWarnings returns a copy of the response with a warning for each unknown PodSecurity label
+// of the namespace in the request. The warnings are appended after the existing warnings.
+func withUnknownLabelsWarnings(response *admissionv1.AdmissionResponse, attrs api.Attributes) *admissionv1.AdmissionResponse {
+	obj, err := attrs.GetObject()
+	if err != nil {
+		return response
+	}
+	namespace, ok := obj.(*corev1.Namespace)
+	if !ok {
+		return response
+	}
+	errs := api.UnknownLabels(namespace.Labels)
+	if len(errs) == 0 {
+		return response
+	}
+	warnings := make([]string, 0, len(response.Warnings)+len(errs))
+	warnings = append(warnings, response.Warnings...)
+	for _, err := range errs {
+		warnings = append(warnings, fmt.Sprintf("unknown label %s is ignored, %s", err.BadValue, err.Detail))
+	}
+	withWarnings := *response
+	withWarnings.Warnings = warnings
+	return &withWarnings
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/metrics"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// unrelaxedUserNamespaceChecks returns the sorted IDs of the checks violated by a pod setting hostUsers=false
+// that would allow it if policies were relaxed for pods in user namespaces, if the Evaluator implements
+// policy.UserNamespaceRelaxationChecker. The pod is recorded if the Metrics implement metrics.UnrelaxedUserNamespacePodRecorder.
+func (a *Admission) unrelaxedUserNamespaceChecks(podSpec *corev1.PodSpec, attrs api.Attributes, results ...policy.AggregateCheckResult) []string {
+	checker, ok := a.Evaluator.(policy.UserNamespaceRelaxationChecker)
+	if !ok {
+		return nil
+	}
+	ids := sets.New[string]()
+	for _, result := range results {
+		for _, id := range checker.UnrelaxedUserNamespaceChecks(podSpec, result.Violations) {
+			ids.Insert(string(id))
+		}
+	}
+	if ids.Len() == 0 {
+		return nil
+	}
+	if recorder, ok := a.metrics().(metrics.UnrelaxedUserNamespacePodRecorder); ok {
+		recorder.RecordUnrelaxedUserNamespacePod(attrs)
+	}
+	return sets.List(ids)
+}
+
+// unrelaxedUserNamespaceWarning explains that the checks violated by a pod setting hostUsers=false are not relaxed for it.
+func unrelaxedUserNamespaceWarning(checks []string) string {
+	subject := fmt.Sprintf("checks %s are", strings.Join(checks, ", "))
+	if len(checks) == 1 {
+		subject = fmt.Sprintf("check %s is", checks[0])
+	}
+	return fmt.Sprintf("pod sets hostUsers=false, but PodSecurity %s not relaxed for pods in user namespaces, since the %s feature is disabled",
+		subject, policy.UserNamespacesPodSecurityStandards)
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"fmt"
+
+	admissionv1 "k8s.io/api/admission/v1"
+)
+
+// WarningLimits caps the number of warnings returned per request, since some clients truncate
+// or fail on large lists of warnings.
+type WarningLimits struct {
+	// MaxWarnings is the maximum number of warnings returned per request, if non-zero.
+	// Warnings beyond the limit are replaced with a closing warning, counted in the limit.
+	// Warnings are truncated in the order they are returned, and warnings about the enforce policy
+	// are returned before the warnings about the warn policy, so they are kept first.
+	MaxWarnings int
+	// ReportURL is optional, and linked from the closing warning as the location of the full report
+	// of the omitted warnings.
+	ReportURL string
+}
+
+// Validate checks the warning limits are valid.
+func (l WarningLimits) Validate() error {
+	if l.MaxWarnings < 0 {
+		return fmt.Errorf("maximum number of warnings must not be negative, got %d", l.MaxWarnings)
+	}
+	if l.MaxWarnings == 1 {
+		return fmt.Errorf("maximum number of warnings must leave room for the closing warning, got %d", l.MaxWarnings)
+	}
+	return nil
+}
+
+// limit returns a copy of the response with the warnings exceeding MaxWarnings replaced with a closing warning,
+// or the response itself if it is within the limit.
+func (l WarningLimits) limit(response *admissionv1.AdmissionResponse) *admissionv1.AdmissionResponse {
+	if l.MaxWarnings == 0 || len(response.Warnings) <= l.MaxWarnings {
+		return response
+	}
+	kept := l.MaxWarnings - 1
+	closing := fmt.Sprintf("%d more PodSecurity warnings omitted", len(response.Warnings)-kept)
+	if l.ReportURL != "" {
+		closing += ", see " + l.ReportURL + " for the full report"
+	}
+	limited := *response
+	limited.Warnings = append(response.Warnings[:kept:kept], closing)
+	return &limited
+}
@@ -18,6 +18,7 @@ package api
 
 import (
 	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
@@ -49,7 +50,14 @@ type Attributes interface {
 	GetUserName() string
 }
 
-// AttributesRecord is a simple struct implementing the Attributes interface.
+// UserInfoAttributes is optionally implemented by Attributes to expose the requesting user's groups and extra fields,
+// e.g. to exempt requests by workload identity (see admission.IdentityExtractor).
+type UserInfoAttributes interface {
+	// GetUserInfo returns the requesting user's authenticated information.
+	GetUserInfo() authenticationv1.UserInfo
+}
+
+// AttributesRecord is a simple struct implementing the Attributes and UserInfoAttributes interfaces.
 type AttributesRecord struct {
 	Name        string
 	Namespace   string
@@ -60,6 +68,8 @@ type AttributesRecord struct {
 	Object      runtime.Object
 	OldObject   runtime.Object
 	Username    string
+	UserGroups  []string
+	UserExtra   map[string]authenticationv1.ExtraValue
 }
 
 func (a *AttributesRecord) GetName() string {
@@ -83,6 +93,9 @@ func (a *AttributesRecord) GetOperation() admissionv1.Operation {
 func (a *AttributesRecord) GetUserName() string {
 	return a.Username
 }
+func (a *AttributesRecord) GetUserInfo() authenticationv1.UserInfo {
+	return authenticationv1.UserInfo{Username: a.Username, Groups: a.UserGroups, Extra: a.UserExtra}
+}
 func (a *AttributesRecord) GetObject() (runtime.Object, error) {
 	return a.Object, nil
 }
@@ -91,6 +104,7 @@ func (a *AttributesRecord) GetOldObject() (runtime.Object, error) {
 }
 
 var _ Attributes = &AttributesRecord{}
+var _ UserInfoAttributes = &AttributesRecord{}
 
 // RequestAttributes adapts an admission.Request to the Attributes interface.
 func RequestAttributes(request *admissionv1.AdmissionRequest, decoder runtime.Decoder) Attributes {
@@ -128,6 +142,9 @@ func (a *attributes) GetOperation() admissionv1.Operation {
 func (a *attributes) GetUserName() string {
 	return a.r.UserInfo.Username
 }
+func (a *attributes) GetUserInfo() authenticationv1.UserInfo {
+	return a.r.UserInfo
+}
 func (a *attributes) GetObject() (runtime.Object, error) {
 	return a.decode(a.r.Object)
 }
@@ -144,3 +161,4 @@ func (a *attributes) decode(in runtime.RawExtension) (runtime.Object, error) {
 }
 
 var _ Attributes = &attributes{}
+var _ UserInfoAttributes = &attributes{}
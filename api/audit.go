@@ -0,0 +1,76 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// AuditViolationsDetailSchemaVersion is the schema version of the AuditViolationsDetail encoded in the
+// AuditViolationsDetailAnnotationKey audit annotation. It changes on incompatible changes of the schema only,
+// new optional fields may be added within a schema version.
+const AuditViolationsDetailSchemaVersion = "v1"
+
+// AuditViolationsDetail is the structured form of the AuditViolationsAnnotationKey audit annotation,
+// recorded as JSON in the AuditViolationsDetailAnnotationKey audit annotation for log pipelines.
+type AuditViolationsDetail struct {
+	// SchemaVersion is the AuditViolationsDetailSchemaVersion the detail was encoded with.
+	SchemaVersion string `json:"schemaVersion"`
+	// Policy is the audit level & version the pod was evaluated against, e.g. baseline:v1.29.
+	Policy string `json:"policy"`
+	// Violations are the violations of the checks of the audit policy, in the order of the checks.
+	Violations []AuditViolation `json:"violations"`
+}
+
+// AuditViolation is a violation of a single check of an AuditViolationsDetail.
+type AuditViolation struct {
+	// Check is the ID of the violated check, e.g. privileged.
+	Check string `json:"check"`
+	// Reason is the forbidden reason of the check, e.g. privileged.
+	Reason string `json:"reason"`
+	// Severity is the severity of the check, if known.
+	Severity string `json:"severity,omitempty"`
+	// Containers are the names of the offending containers, if known.
+	Containers []string `json:"containers,omitempty"`
+	// Fields are the paths of the offending fields, e.g. spec.containers[0].securityContext.privileged, if known.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// EncodeAuditViolationsDetail encodes the detail as the value of the AuditViolationsDetailAnnotationKey audit annotation,
+// setting its SchemaVersion.
+func EncodeAuditViolationsDetail(detail AuditViolationsDetail) (string, error) {
+	detail.SchemaVersion = AuditViolationsDetailSchemaVersion
+	data, err := json.Marshal(detail)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ParseAuditViolationsDetail parses the value of the AuditViolationsDetailAnnotationKey audit annotation.
+// An error is returned if the value is not valid JSON, or if its schema version is not supported.
+func ParseAuditViolationsDetail(value string) (AuditViolationsDetail, error) {
+	var detail AuditViolationsDetail
+	if err := json.Unmarshal([]byte(value), &detail); err != nil {
+		return AuditViolationsDetail{}, fmt.Errorf("invalid audit violations detail: %w", err)
+	}
+	if detail.SchemaVersion != AuditViolationsDetailSchemaVersion {
+		return AuditViolationsDetail{}, fmt.Errorf("unsupported audit violations detail schema version %q, must be %q", detail.SchemaVersion, AuditViolationsDetailSchemaVersion)
+	}
+	return detail, nil
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditViolationsDetail(t *testing.T) {
+	detail := AuditViolationsDetail{
+		Policy: "baseline:v1.29",
+		Violations: []AuditViolation{{
+			Check:      "privileged",
+			Reason:     "privileged",
+			Severity:   "critical",
+			Containers: []string{"a"},
+			Fields:     []string{"spec.containers[0].securityContext.privileged"},
+		}},
+	}
+	value, err := EncodeAuditViolationsDetail(detail)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"schemaVersion": "v1",
+		"policy": "baseline:v1.29",
+		"violations": [{
+			"check": "privileged",
+			"reason": "privileged",
+			"severity": "critical",
+			"containers": ["a"],
+			"fields": ["spec.containers[0].securityContext.privileged"]
+		}]
+	}`, value)
+
+	parsed, err := ParseAuditViolationsDetail(value)
+	require.NoError(t, err)
+	detail.SchemaVersion = AuditViolationsDetailSchemaVersion
+	assert.Equal(t, detail, parsed)
+
+	// unknown fields are ignored within a schema version
+	_, err = ParseAuditViolationsDetail(`{"schemaVersion": "v1", "policy": "restricted:latest", "violations": [], "new": true}`)
+	assert.NoError(t, err)
+
+	_, err = ParseAuditViolationsDetail(`{"schemaVersion": "v2", "violations": []}`)
+	assert.EqualError(t, err, `unsupported audit violations detail schema version "v2", must be "v1"`)
+	_, err = ParseAuditViolationsDetail(`{"violations": []}`)
+	assert.EqualError(t, err, `unsupported audit violations detail schema version "", must be "v1"`)
+	_, err = ParseAuditViolationsDetail(`would violate PodSecurity "baseline:latest": privileged`)
+	assert.ErrorContains(t, err, "invalid audit violations detail")
+}
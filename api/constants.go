@@ -44,7 +44,39 @@ const (
 	WarnLevelLabel      = labelPrefix + "warn"
 	WarnVersionLabel    = labelPrefix + "warn-version"
 
+	// ExemptChecksAnnotation is the namespace annotation listing the IDs of the checks
+	// the namespace is exempt from, as a comma-separated list.
+	ExemptChecksAnnotation = labelPrefix + "exempt-checks"
+
+	// ConfirmPrivilegedAnnotation is the namespace annotation confirming a downgrade of the enforce level
+	// to privileged when the admission configuration requires it. The only accepted value is "true".
+	ConfirmPrivilegedAnnotation = labelPrefix + "confirm-privileged"
+
+	// CheckOptOutAnnotation is the pod annotation carrying a signed token excluding the pod from specific checks,
+	// honored when the token is verified by the configured keys.
+	CheckOptOutAnnotation = labelPrefix + "check-opt-out"
+
+	// ViolationsAnnotation is the pod annotation summarizing the violations of the enforce policy
+	// of pods admitted with the Annotate enforcement action.
+	ViolationsAnnotation = labelPrefix + "violations"
+
 	ExemptionReasonAnnotationKey = "exempt"
 	AuditViolationsAnnotationKey = "audit-violations"
 	EnforcedPolicyAnnotationKey  = "enforce-policy"
+	// AuditViolationSeveritiesAnnotationKey is the audit annotation grouping the checks of the AuditViolationsAnnotationKey
+	// by severity, from the most to the least severe, e.g. "critical=privileged; medium=runAsNonRoot".
+	AuditViolationSeveritiesAnnotationKey = "audit-violation-severities"
+	// AuditViolationsDetailAnnotationKey is the audit annotation recording the violations of the AuditViolationsAnnotationKey
+	// as a versioned JSON AuditViolationsDetail, parsed by ParseAuditViolationsDetail.
+	AuditViolationsDetailAnnotationKey = "audit-violations-detail"
+	// EnforcedPolicySourceAnnotationKey is the audit annotation describing the namespace labels, defaults or floor
+	// the enforce level & version of the EnforcedPolicyAnnotationKey come from.
+	EnforcedPolicySourceAnnotationKey = "enforce-policy-source"
+	// ChecksSchemaVersionAnnotationKey is the audit annotation recording the schema version of the evaluated checks.
+	ChecksSchemaVersionAnnotationKey = "checks-schema-version"
+	// CheckOptOutAnnotationKey is the audit annotation recording the checks a pod is excluded from by its CheckOptOutAnnotation.
+	CheckOptOutAnnotationKey = "check-opt-out"
+	// ExemptChecksAnnotationKey is the audit annotation recording the checks a pod is exempt from by the
+	// ExemptChecksAnnotation of its namespace.
+	ExemptChecksAnnotationKey = "exempt-checks"
 )
@@ -23,6 +23,7 @@ import (
 	"strings"
 	"unicode"
 
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/component-base/version"
 )
@@ -105,6 +106,14 @@ func ParseLevel(level string) (Level, error) {
 	}
 }
 
+// ParseLevelLenient returns the level like ParseLevel, ignoring surrounding whitespace and case,
+// e.g. " Restricted" is parsed as "restricted".
+// normalized is true if level differs from the returned level.
+func ParseLevelLenient(level string) (l Level, normalized bool, err error) {
+	l, err = ParseLevel(strings.ToLower(strings.TrimSpace(level)))
+	return l, err == nil && string(l) != level, err
+}
+
 // Valid checks whether the level l is a valid level.
 func (l *Level) Valid() bool {
 	switch *l {
@@ -135,6 +144,27 @@ func ParseVersion(version string) (Version, error) {
 	return Version{major: 1, minor: versionNumber}, nil
 }
 
+// ParseVersionLenient returns the version like ParseVersion, ignoring surrounding whitespace and case,
+// e.g. "V1.31 " is parsed as "v1.31".
+// normalized is true if version differs from the returned version.
+func ParseVersionLenient(version string) (v Version, normalized bool, err error) {
+	v, err = ParseVersion(strings.ToLower(strings.TrimSpace(version)))
+	return v, err == nil && v.String() != version, err
+}
+
+// ResolveVersion returns the version evaluated for the requested version by a cluster at clusterVersion.
+// latest, and versions newer than the cluster, resolve to the cluster version.
+// The requested version is returned unchanged if clusterVersion is latest or unknown (v0.0, see GetAPIVersion).
+func ResolveVersion(requested, clusterVersion Version) Version {
+	if clusterVersion.latest || clusterVersion.major == 0 {
+		return requested
+	}
+	if clusterVersion.Older(requested) {
+		return clusterVersion
+	}
+	return requested
+}
+
 type LevelVersion struct {
 	Level
 	Version
@@ -179,41 +209,80 @@ func (p *Policy) FullyPrivileged() bool {
 // returned, even when an error is returned. If labels cannot be parsed correctly, the values of
 // "restricted" and "latest" are used for level and version respectively.
 func PolicyToEvaluate(labels map[string]string, defaults Policy) (Policy, field.ErrorList) {
+	p, _, errs := policyToEvaluate(labels, defaults, false)
+	return p, errs
+}
+
+// PolicyToEvaluateLenient resolves the PodSecurity namespace labels like PolicyToEvaluate,
+// parsing levels and versions with ParseLevelLenient and ParseVersionLenient.
+// The normalized values are returned by label, for the labels that differ from them.
+func PolicyToEvaluateLenient(labels map[string]string, defaults Policy) (Policy, map[string]string, field.ErrorList) {
+	return policyToEvaluate(labels, defaults, true)
+}
+
+func policyToEvaluate(labels map[string]string, defaults Policy, lenient bool) (Policy, map[string]string, field.ErrorList) {
 	var (
 		err  error
 		errs field.ErrorList
 
-		p = defaults
+		p          = defaults
+		normalized map[string]string
 
 		hasEnforceLevel              bool
 		hasWarnLevel, hasWarnVersion bool
 	)
 	if len(labels) == 0 {
-		return p, nil
+		return p, nil, nil
+	}
+	parseLevel := func(label, level string) (Level, error) {
+		if !lenient {
+			return ParseLevel(level)
+		}
+		l, wasNormalized, err := ParseLevelLenient(level)
+		if wasNormalized {
+			if normalized == nil {
+				normalized = map[string]string{}
+			}
+			normalized[label] = string(l)
+		}
+		return l, err
+	}
+	parseVersion := func(label, version string) (Version, error) {
+		if !lenient {
+			return ParseVersion(version)
+		}
+		v, wasNormalized, err := ParseVersionLenient(version)
+		if wasNormalized {
+			if normalized == nil {
+				normalized = map[string]string{}
+			}
+			normalized[label] = v.String()
+		}
+		return v, err
 	}
 	if level, ok := labels[EnforceLevelLabel]; ok {
-		p.Enforce.Level, err = ParseLevel(level)
+		p.Enforce.Level, err = parseLevel(EnforceLevelLabel, level)
 		hasEnforceLevel = (err == nil) // Don't default warn in case of error
 		errs = appendErr(errs, err, EnforceLevelLabel, level)
 	}
 	if version, ok := labels[EnforceVersionLabel]; ok {
-		p.Enforce.Version, err = ParseVersion(version)
+		p.Enforce.Version, err = parseVersion(EnforceVersionLabel, version)
 		errs = appendErr(errs, err, EnforceVersionLabel, version)
 	}
 	if level, ok := labels[AuditLevelLabel]; ok {
-		p.Audit.Level, err = ParseLevel(level)
+		p.Audit.Level, err = parseLevel(AuditLevelLabel, level)
 		errs = appendErr(errs, err, AuditLevelLabel, level)
 		if err != nil {
 			p.Audit.Level = LevelPrivileged // Fail open for audit.
 		}
 	}
 	if version, ok := labels[AuditVersionLabel]; ok {
-		p.Audit.Version, err = ParseVersion(version)
+		p.Audit.Version, err = parseVersion(AuditVersionLabel, version)
 		errs = appendErr(errs, err, AuditVersionLabel, version)
 	}
 	if level, ok := labels[WarnLevelLabel]; ok {
 		hasWarnLevel = true
-		p.Warn.Level, err = ParseLevel(level)
+		p.Warn.Level, err = parseLevel(WarnLevelLabel, level)
 		errs = appendErr(errs, err, WarnLevelLabel, level)
 		if err != nil {
 			p.Warn.Level = LevelPrivileged // Fail open for warn.
@@ -221,7 +290,7 @@ func PolicyToEvaluate(labels map[string]string, defaults Policy) (Policy, field.
 	}
 	if version, ok := labels[WarnVersionLabel]; ok {
 		hasWarnVersion = true
-		p.Warn.Version, err = ParseVersion(version)
+		p.Warn.Version, err = parseVersion(WarnVersionLabel, version)
 		errs = appendErr(errs, err, WarnVersionLabel, version)
 	}
 
@@ -233,7 +302,23 @@ func PolicyToEvaluate(labels map[string]string, defaults Policy) (Policy, field.
 		}
 	}
 
-	return p, errs
+	return p, normalized, errs
+}
+
+// ExemptChecks returns the sorted, de-duplicated check IDs listed in the ExemptChecksAnnotation
+// of the given namespace annotations. Empty entries are ignored.
+func ExemptChecks(annotations map[string]string) []string {
+	value, ok := annotations[ExemptChecksAnnotation]
+	if !ok {
+		return nil
+	}
+	ids := sets.New[string]()
+	for _, id := range strings.Split(value, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids.Insert(id)
+		}
+	}
+	return sets.List(ids)
 }
 
 // CompareLevels returns an integer comparing two levels by strictness. The result will be 0 if
@@ -260,6 +345,30 @@ func CompareLevels(a, b Level) int {
 
 var labelsPath = field.NewPath("metadata", "labels")
 
+// policyLabels are the PodSecurity namespace labels.
+var policyLabels = []string{
+	EnforceLevelLabel, EnforceVersionLabel,
+	AuditLevelLabel, AuditVersionLabel,
+	WarnLevelLabel, WarnVersionLabel,
+}
+
+// UnknownLabels returns an error for each label with the PodSecurity label prefix that is not a PodSecurity label,
+// e.g. a typo like pod-security.kubernetes.io/enforces, which PolicyToEvaluate ignores. The errors are sorted by label.
+func UnknownLabels(labels map[string]string) field.ErrorList {
+	known := sets.New(policyLabels...)
+	unknown := sets.New[string]()
+	for label := range labels {
+		if strings.HasPrefix(label, labelPrefix) && !known.Has(label) {
+			unknown.Insert(label)
+		}
+	}
+	var errs field.ErrorList
+	for _, label := range sets.List(unknown) {
+		errs = append(errs, field.NotSupported(labelsPath, label, policyLabels))
+	}
+	return errs
+}
+
 // appendErr is a helper function to collect label-specific errors.
 func appendErr(errs field.ErrorList, err error, label, value string) field.ErrorList {
 	if err != nil {
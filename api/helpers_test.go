@@ -54,6 +54,26 @@ func TestParseVersion(t *testing.T) {
 	}
 }
 
+func TestResolveVersion(t *testing.T) {
+	cluster := MajorMinorVersion(1, 29)
+	testCases := []struct {
+		requested, cluster, expected Version
+	}{
+		{requested: MajorMinorVersion(1, 25), cluster: cluster, expected: MajorMinorVersion(1, 25)},
+		{requested: MajorMinorVersion(1, 29), cluster: cluster, expected: cluster},
+		{requested: MajorMinorVersion(1, 30), cluster: cluster, expected: cluster},
+		{requested: LatestVersion(), cluster: cluster, expected: cluster},
+		{requested: LatestVersion(), cluster: LatestVersion(), expected: LatestVersion()},
+		{requested: MajorMinorVersion(1, 30), cluster: LatestVersion(), expected: MajorMinorVersion(1, 30)},
+		{requested: LatestVersion(), cluster: Version{}, expected: LatestVersion()},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.requested.String()+"@"+tc.cluster.String(), func(t *testing.T) {
+			assert.Equal(t, tc.expected, ResolveVersion(tc.requested, tc.cluster))
+		})
+	}
+}
+
 func TestLevelVersionEquals(t *testing.T) {
 	t.Run("a LevelVersion should be equal to itself", func(t *testing.T) {
 		for _, l := range []Level{LevelPrivileged, LevelBaseline, LevelRestricted} {
@@ -265,3 +285,105 @@ func makeLabels(kvs ...string) map[string]string {
 	}
 	return labels
 }
+
+func TestExemptChecks(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expected    []string
+	}{
+		{name: "unset", annotations: map[string]string{"foo": "bar"}},
+		{name: "empty", annotations: map[string]string{ExemptChecksAnnotation: ""}, expected: []string{}},
+		{name: "single", annotations: map[string]string{ExemptChecksAnnotation: "hostPorts"}, expected: []string{"hostPorts"}},
+		{name: "multiple", annotations: map[string]string{ExemptChecksAnnotation: " sysctls, hostPorts,,sysctls "}, expected: []string{"hostPorts", "sysctls"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ExemptChecks(tc.annotations))
+		})
+	}
+}
+
+func TestUnknownLabels(t *testing.T) {
+	assert.Empty(t, UnknownLabels(nil))
+	assert.Empty(t, UnknownLabels(makeLabels("enforce", "restricted", "warn-version", "latest")))
+	assert.Empty(t, UnknownLabels(map[string]string{"example.com/enforces": "restricted"}))
+
+	errs := UnknownLabels(map[string]string{
+		"pod-security.kubernetes.io/warns":    "baseline",
+		"pod-security.kubernetes.io/enforces": "restricted",
+		"pod-security.kubernetes.io/enforce":  "restricted",
+	})
+	require.Len(t, errs, 2)
+	assert.Equal(t, "pod-security.kubernetes.io/enforces", errs[0].BadValue)
+	assert.Equal(t, "pod-security.kubernetes.io/warns", errs[1].BadValue)
+	assert.Equal(t, "metadata.labels", errs[0].Field)
+}
+
+func TestParseLenient(t *testing.T) {
+	levelCases := []struct {
+		level            string
+		expectLevel      Level
+		expectNormalized bool
+		expectErr        bool
+	}{
+		{level: "restricted", expectLevel: LevelRestricted},
+		{level: "Restricted", expectLevel: LevelRestricted, expectNormalized: true},
+		{level: " baseline\t", expectLevel: LevelBaseline, expectNormalized: true},
+		{level: "PRIVILEGED", expectLevel: LevelPrivileged, expectNormalized: true},
+		{level: "restrict", expectLevel: LevelRestricted, expectErr: true},
+	}
+	for _, tc := range levelCases {
+		level, normalized, err := ParseLevelLenient(tc.level)
+		assert.Equal(t, tc.expectLevel, level, tc.level)
+		assert.Equal(t, tc.expectNormalized, normalized, tc.level)
+		assert.Equal(t, tc.expectErr, err != nil, tc.level)
+	}
+
+	versionCases := []struct {
+		version          string
+		expectVersion    Version
+		expectNormalized bool
+		expectErr        bool
+	}{
+		{version: "v1.31", expectVersion: MajorMinorVersion(1, 31)},
+		{version: "V1.31", expectVersion: MajorMinorVersion(1, 31), expectNormalized: true},
+		{version: " latest ", expectVersion: LatestVersion(), expectNormalized: true},
+		{version: "Latest", expectVersion: LatestVersion(), expectNormalized: true},
+		{version: "1.31", expectVersion: LatestVersion(), expectErr: true},
+	}
+	for _, tc := range versionCases {
+		version, normalized, err := ParseVersionLenient(tc.version)
+		assert.Equal(t, tc.expectVersion, version, tc.version)
+		assert.Equal(t, tc.expectNormalized, normalized, tc.version)
+		assert.Equal(t, tc.expectErr, err != nil, tc.version)
+	}
+}
+
+func TestPolicyToEvaluateLenient(t *testing.T) {
+	labels := map[string]string{
+		EnforceLevelLabel:   " Restricted",
+		EnforceVersionLabel: "V1.31",
+		WarnLevelLabel:      "baseline",
+		AuditLevelLabel:     "Strict",
+	}
+	defaults := Policy{
+		Enforce: LevelVersion{LevelPrivileged, LatestVersion()},
+		Audit:   LevelVersion{LevelPrivileged, LatestVersion()},
+		Warn:    LevelVersion{LevelPrivileged, LatestVersion()},
+	}
+
+	policy, normalized, errs := PolicyToEvaluateLenient(labels, defaults)
+	assert.Equal(t, Policy{
+		Enforce: LevelVersion{LevelRestricted, MajorMinorVersion(1, 31)},
+		Audit:   LevelVersion{LevelPrivileged, LatestVersion()},
+		Warn:    LevelVersion{LevelBaseline, LatestVersion()},
+	}, policy)
+	assert.Equal(t, map[string]string{EnforceLevelLabel: "restricted", EnforceVersionLabel: "v1.31"}, normalized)
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, "metadata.labels[pod-security.kubernetes.io/audit]", errs[0].Field)
+	}
+
+	_, errs = PolicyToEvaluate(labels, defaults)
+	assert.Len(t, errs, 3, "strict parsing should reject the labels accepted by lenient parsing")
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+// VersionSkew is the skew of the audit or warn version of a policy from its enforce version.
+type VersionSkew string
+
+const (
+	// VersionSkewNone is reported when the versions match, or either level is privileged and evaluates no version.
+	VersionSkewNone VersionSkew = ""
+	// VersionSkewNewer is reported for versions newer than the enforce version, e.g. to stage an enforce version update.
+	VersionSkewNewer VersionSkew = "newer"
+	// VersionSkewOlder is reported for versions older than the enforce version, which is likely a misconfiguration.
+	VersionSkewOlder VersionSkew = "older"
+)
+
+// AuditVersionSkew returns the skew of the audit version from the enforce version.
+func (p *Policy) AuditVersionSkew() VersionSkew {
+	return versionSkew(p.Audit, p.Enforce)
+}
+
+// WarnVersionSkew returns the skew of the warn version from the enforce version.
+func (p *Policy) WarnVersionSkew() VersionSkew {
+	return versionSkew(p.Warn, p.Enforce)
+}
+
+func versionSkew(lv, enforce LevelVersion) VersionSkew {
+	if lv.Level == LevelPrivileged || enforce.Level == LevelPrivileged {
+		return VersionSkewNone
+	}
+	switch {
+	case lv.Version.Older(enforce.Version):
+		return VersionSkewOlder
+	case enforce.Version.Older(lv.Version):
+		return VersionSkewNewer
+	default:
+		return VersionSkewNone
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionSkew(t *testing.T) {
+	v127 := LevelVersion{Level: LevelBaseline, Version: MajorMinorVersion(1, 27)}
+	v128 := LevelVersion{Level: LevelRestricted, Version: MajorMinorVersion(1, 28)}
+	latest := LevelVersion{Level: LevelRestricted, Version: LatestVersion()}
+	privileged := LevelVersion{Level: LevelPrivileged, Version: MajorMinorVersion(1, 20)}
+
+	tests := []struct {
+		name          string
+		policy        Policy
+		expectedAudit VersionSkew
+		expectedWarn  VersionSkew
+	}{{
+		name:   "same versions",
+		policy: Policy{Enforce: v128, Audit: v128, Warn: v128},
+	}, {
+		name:          "newer audit and warn",
+		policy:        Policy{Enforce: v127, Audit: v128, Warn: latest},
+		expectedAudit: VersionSkewNewer,
+		expectedWarn:  VersionSkewNewer,
+	}, {
+		name:          "older audit",
+		policy:        Policy{Enforce: latest, Audit: v127, Warn: latest},
+		expectedAudit: VersionSkewOlder,
+	}, {
+		name:         "older warn",
+		policy:       Policy{Enforce: v128, Audit: v128, Warn: v127},
+		expectedWarn: VersionSkewOlder,
+	}, {
+		name:   "privileged enforce",
+		policy: Policy{Enforce: privileged, Audit: v128, Warn: v127},
+	}, {
+		name:         "privileged audit",
+		policy:       Policy{Enforce: v128, Audit: privileged, Warn: latest},
+		expectedWarn: VersionSkewNewer,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedAudit, tc.policy.AuditVersionSkew())
+			assert.Equal(t, tc.expectedWarn, tc.policy.WarnVersionSkew())
+		})
+	}
+}
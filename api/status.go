@@ -0,0 +1,163 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import "fmt"
+
+// LabelSource describes where the value applied for a PodSecurity namespace label comes from.
+type LabelSource string
+
+const (
+	// LabelSourceLabel is a valid value set by the label.
+	LabelSourceLabel LabelSource = "Label"
+	// LabelSourceDefault is the configured default, applied when the label is not set.
+	LabelSourceDefault LabelSource = "Default"
+	// LabelSourceFallback is the fallback applied when the label is set to an invalid value.
+	LabelSourceFallback LabelSource = "Fallback"
+	// LabelSourceEnforce is the enforce level or version, applied to the warn mode when the
+	// warn level is not set and the enforce level is more restrictive.
+	LabelSourceEnforce LabelSource = "Enforce"
+	// LabelSourceFloor is the cluster-wide floor of the admission configuration, applied to the enforce mode
+	// when it is stricter than the label or the default.
+	LabelSourceFloor LabelSource = "Floor"
+)
+
+// LabelStatus describes how a single PodSecurity namespace label is resolved.
+type LabelStatus struct {
+	// Label is the label key, e.g. pod-security.kubernetes.io/warn-version.
+	Label string
+	// Value is the value of the label, if Set.
+	Value string
+	// Set is true if the namespace has the label.
+	Set bool
+	// Effective is the level or version applied.
+	Effective string
+	// Source describes where the Effective value comes from.
+	Source LabelSource
+	// Error is the reason the value of the label is invalid, if Source is LabelSourceFallback.
+	Error string
+}
+
+// String describes the label status in a human readable form, e.g.
+// `pod-security.kubernetes.io/warn-version label "v2" is invalid: must be "latest" or "v1.x", defaulting to "latest"`.
+func (s LabelStatus) String() string {
+	switch s.Source {
+	case LabelSourceFallback:
+		return fmt.Sprintf("%s label %q is invalid: %s, defaulting to %q", s.Label, s.Value, s.Error, s.Effective)
+	case LabelSourceDefault:
+		return fmt.Sprintf("%s label is not set, defaulting to %q", s.Label, s.Effective)
+	case LabelSourceEnforce:
+		return fmt.Sprintf("%s label is not set, defaulting to the enforce %q", s.Label, s.Effective)
+	case LabelSourceFloor:
+		if s.Set {
+			return fmt.Sprintf("%s label %q is below the cluster floor %q", s.Label, s.Value, s.Effective)
+		}
+		return fmt.Sprintf("%s label is not set, raised to the cluster floor %q", s.Label, s.Effective)
+	default:
+		return fmt.Sprintf("%s label is %q", s.Label, s.Effective)
+	}
+}
+
+// ModeStatus describes how the level and version of a single mode are resolved.
+type ModeStatus struct {
+	Level   LabelStatus
+	Version LabelStatus
+}
+
+// PolicyStatus is a structured representation of the policy resolved from PodSecurity namespace labels,
+// with the validity of each label and the fallback applied to invalid labels.
+type PolicyStatus struct {
+	// Policy is the policy evaluated for the namespace, as returned by PolicyToEvaluate.
+	Policy Policy
+
+	Enforce ModeStatus
+	Audit   ModeStatus
+	Warn    ModeStatus
+}
+
+// Valid returns true if none of the labels are invalid.
+func (s *PolicyStatus) Valid() bool {
+	for _, l := range s.Labels() {
+		if l.Source == LabelSourceFallback {
+			return false
+		}
+	}
+	return true
+}
+
+// Labels returns the status of the level and version labels of every mode,
+// in enforce, audit, warn order.
+func (s *PolicyStatus) Labels() []LabelStatus {
+	return []LabelStatus{
+		s.Enforce.Level, s.Enforce.Version,
+		s.Audit.Level, s.Audit.Version,
+		s.Warn.Level, s.Warn.Version,
+	}
+}
+
+// PolicyStatusFor resolves the PodSecurity namespace labels like PolicyToEvaluate, and describes
+// how each label is resolved instead of silently falling back on invalid labels.
+func PolicyStatusFor(labels map[string]string, defaults Policy) PolicyStatus {
+	p, _ := PolicyToEvaluate(labels, defaults)
+	s := PolicyStatus{Policy: p}
+
+	_, hasWarnLevel := labels[WarnLevelLabel]
+	_, hasWarnVersion := labels[WarnVersionLabel]
+	warnFromEnforce := !hasWarnLevel && p.Warn.Level != defaults.Warn.Level
+
+	s.Enforce.Level = levelStatus(labels, EnforceLevelLabel, p.Enforce.Level, false)
+	s.Enforce.Version = versionStatus(labels, EnforceVersionLabel, p.Enforce.Version, false)
+	s.Audit.Level = levelStatus(labels, AuditLevelLabel, p.Audit.Level, false)
+	s.Audit.Version = versionStatus(labels, AuditVersionLabel, p.Audit.Version, false)
+	s.Warn.Level = levelStatus(labels, WarnLevelLabel, p.Warn.Level, warnFromEnforce)
+	s.Warn.Version = versionStatus(labels, WarnVersionLabel, p.Warn.Version, warnFromEnforce && !hasWarnVersion)
+	return s
+}
+
+func levelStatus(labels map[string]string, label string, effective Level, fromEnforce bool) LabelStatus {
+	value, set := labels[label]
+	var err error
+	if set {
+		_, err = ParseLevel(value)
+	}
+	return labelStatus(label, value, set, string(effective), err, fromEnforce)
+}
+
+func versionStatus(labels map[string]string, label string, effective Version, fromEnforce bool) LabelStatus {
+	value, set := labels[label]
+	var err error
+	if set {
+		_, err = ParseVersion(value)
+	}
+	return labelStatus(label, value, set, effective.String(), err, fromEnforce)
+}
+
+func labelStatus(label, value string, set bool, effective string, err error, fromEnforce bool) LabelStatus {
+	s := LabelStatus{Label: label, Value: value, Set: set, Effective: effective}
+	switch {
+	case err != nil:
+		s.Source = LabelSourceFallback
+		s.Error = err.Error()
+	case set:
+		s.Source = LabelSourceLabel
+	case fromEnforce:
+		s.Source = LabelSourceEnforce
+	default:
+		s.Source = LabelSourceDefault
+	}
+	return s
+}
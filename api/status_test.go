@@ -0,0 +1,95 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyStatusFor(t *testing.T) {
+	defaults := Policy{
+		Enforce: LevelVersion{LevelPrivileged, LatestVersion()},
+		Audit:   LevelVersion{LevelPrivileged, LatestVersion()},
+		Warn:    LevelVersion{LevelPrivileged, LatestVersion()},
+	}
+
+	t.Run("no labels", func(t *testing.T) {
+		s := PolicyStatusFor(nil, defaults)
+		assert.True(t, s.Valid())
+		assert.Equal(t, defaults, s.Policy)
+		for _, l := range s.Labels() {
+			assert.Equal(t, LabelSourceDefault, l.Source, l.Label)
+			assert.False(t, l.Set, l.Label)
+		}
+		assert.Equal(t, `pod-security.kubernetes.io/warn-version label is not set, defaulting to "latest"`, s.Warn.Version.String())
+	})
+
+	t.Run("valid labels", func(t *testing.T) {
+		s := PolicyStatusFor(makeLabels("enforce", "baseline", "enforce-version", "v1.22", "audit", "restricted"), defaults)
+		assert.True(t, s.Valid())
+		assert.Equal(t, LabelStatus{
+			Label: EnforceLevelLabel, Value: "baseline", Set: true, Effective: "baseline", Source: LabelSourceLabel,
+		}, s.Enforce.Level)
+		assert.Equal(t, LabelStatus{
+			Label: EnforceVersionLabel, Value: "v1.22", Set: true, Effective: "v1.22", Source: LabelSourceLabel,
+		}, s.Enforce.Version)
+		assert.Equal(t, LabelSourceLabel, s.Audit.Level.Source)
+		assert.Equal(t, LabelSourceDefault, s.Audit.Version.Source)
+		assert.Equal(t, LabelStatus{
+			Label: WarnLevelLabel, Effective: "baseline", Source: LabelSourceEnforce,
+		}, s.Warn.Level)
+		assert.Equal(t, LabelStatus{
+			Label: WarnVersionLabel, Effective: "v1.22", Source: LabelSourceEnforce,
+		}, s.Warn.Version)
+		assert.Equal(t, `pod-security.kubernetes.io/warn label is not set, defaulting to the enforce "baseline"`, s.Warn.Level.String())
+	})
+
+	t.Run("warn version set", func(t *testing.T) {
+		s := PolicyStatusFor(makeLabels("enforce", "baseline", "warn-version", "v1.23"), defaults)
+		assert.Equal(t, LabelSourceEnforce, s.Warn.Level.Source)
+		assert.Equal(t, LabelSourceLabel, s.Warn.Version.Source)
+		assert.Equal(t, "v1.23", s.Warn.Version.Effective)
+	})
+
+	t.Run("invalid labels", func(t *testing.T) {
+		s := PolicyStatusFor(makeLabels("enforce", "foo", "audit", "bar", "warn-version", "v2"), defaults)
+		assert.False(t, s.Valid())
+		assert.Equal(t, LabelStatus{
+			Label: EnforceLevelLabel, Value: "foo", Set: true, Effective: "restricted", Source: LabelSourceFallback,
+			Error: "must be one of privileged, baseline, restricted",
+		}, s.Enforce.Level)
+		assert.Equal(t, LabelStatus{
+			Label: AuditLevelLabel, Value: "bar", Set: true, Effective: "privileged", Source: LabelSourceFallback,
+			Error: "must be one of privileged, baseline, restricted",
+		}, s.Audit.Level)
+		assert.Equal(t, LabelSourceDefault, s.Warn.Level.Source, "warn should not default to an invalid enforce level")
+		assert.Equal(t,
+			`pod-security.kubernetes.io/warn-version label "v2" is invalid: must be "latest" or "v1.x", defaulting to "latest"`,
+			s.Warn.Version.String())
+
+		p, _ := PolicyToEvaluate(makeLabels("enforce", "foo", "audit", "bar", "warn-version", "v2"), defaults)
+		assert.Equal(t, p, s.Policy)
+	})
+	t.Run("floor", func(t *testing.T) {
+		set := LabelStatus{Label: EnforceLevelLabel, Value: "privileged", Set: true, Effective: "baseline", Source: LabelSourceFloor}
+		assert.Equal(t, `pod-security.kubernetes.io/enforce label "privileged" is below the cluster floor "baseline"`, set.String())
+		unset := LabelStatus{Label: EnforceLevelLabel, Effective: "baseline", Source: LabelSourceFloor}
+		assert.Equal(t, `pod-security.kubernetes.io/enforce label is not set, raised to the cluster floor "baseline"`, unset.String())
+	})
+}
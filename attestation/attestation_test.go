@@ -0,0 +1,125 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attestation
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+)
+
+const rendered = `---
+# Source: app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app
+---
+# Source: app/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+`
+
+func TestGenerate(t *testing.T) {
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+	require.NoError(t, err)
+	baseline := api.LevelVersion{Level: api.LevelBaseline, Version: api.MajorMinorVersion(1, 25)}
+	now := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("passed", func(t *testing.T) {
+		statement, err := Generate(evaluator, baseline, "app", []byte(rendered), now)
+		require.NoError(t, err)
+		assert.Equal(t, StatementType, statement.Type)
+		assert.Equal(t, PredicateType, statement.PredicateType)
+		assert.Equal(t, []Subject{{Name: "app", Digest: Digest([]byte(rendered))}}, statement.Subject)
+		assert.Equal(t, api.LevelBaseline, statement.Predicate.Level)
+		assert.Equal(t, "v1.25", statement.Predicate.Version)
+		assert.Equal(t, policy.EvaluatorSchemaVersion(evaluator), statement.Predicate.ChecksSchemaVersion)
+		assert.Contains(t, statement.Predicate.Checks, policy.CheckID("privileged"))
+		assert.NotContains(t, statement.Predicate.Checks, policy.CheckID("runAsNonRoot"))
+		assert.Equal(t, 1, statement.Predicate.PodTemplates)
+		assert.Equal(t, now, statement.Predicate.EvaluatedAt)
+		assert.True(t, statement.Covers([]byte(rendered)))
+		assert.False(t, statement.Covers([]byte(rendered+"\n")))
+	})
+
+	t.Run("violations", func(t *testing.T) {
+		restricted := api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()}
+		_, err := Generate(evaluator, restricted, "app", []byte(rendered), now)
+		assert.ErrorContains(t, err, `app violates PodSecurity "restricted:latest"`)
+		assert.ErrorContains(t, err, "app/templates/deployment.yaml:")
+	})
+}
+
+func TestSignVerify(t *testing.T) {
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+	require.NoError(t, err)
+	statement, err := Generate(evaluator, api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}, "app", []byte(rendered), time.Now())
+	require.NoError(t, err)
+
+	public, private, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	otherPublic, _, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	envelope, err := Sign(private, statement)
+	require.NoError(t, err)
+	assert.Equal(t, PayloadType, envelope.PayloadType)
+	require.Len(t, envelope.Signatures, 1)
+	assert.Equal(t, KeyID(public), envelope.Signatures[0].KeyID)
+
+	t.Run("verified", func(t *testing.T) {
+		verified, err := Verify(envelope, []ed25519.PublicKey{otherPublic, public})
+		require.NoError(t, err)
+		assert.Equal(t, statement.Subject, verified.Subject)
+		assert.Equal(t, statement.Predicate.Checks, verified.Predicate.Checks)
+		assert.True(t, verified.Predicate.EvaluatedAt.Equal(statement.Predicate.EvaluatedAt))
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		_, err := Verify(envelope, []ed25519.PublicKey{otherPublic})
+		assert.ErrorContains(t, err, "signature not verified")
+	})
+
+	t.Run("tampered payload", func(t *testing.T) {
+		tampered := *envelope
+		tampered.Payload = base64.StdEncoding.EncodeToString([]byte(`{"_type":"` + StatementType + `"}`))
+		_, err := Verify(&tampered, []ed25519.PublicKey{public})
+		assert.ErrorContains(t, err, "signature not verified")
+	})
+
+	t.Run("tampered payload type", func(t *testing.T) {
+		tampered := *envelope
+		tampered.PayloadType = "application/json"
+		_, err := Verify(&tampered, []ed25519.PublicKey{public})
+		assert.ErrorContains(t, err, "unsupported payload type")
+	})
+}
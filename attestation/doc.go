@@ -0,0 +1,20 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package attestation generates and verifies signed in-toto attestations that rendered manifests
+// passed a Pod Security Standards level & version, e.g. for supply-chain pipelines that must prove
+// the policy was evaluated before deploying the manifests.
+package attestation // import "k8s.io/pod-security-admission/attestation"
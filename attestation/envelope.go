@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attestation
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// PayloadType is the payload type of envelopes of in-toto statements.
+const PayloadType = "application/vnd.in-toto+json"
+
+// Envelope is a DSSE envelope of a signed statement.
+type Envelope struct {
+	PayloadType string `json:"payloadType"`
+	// Payload is the base64-encoded JSON statement.
+	Payload    string      `json:"payload"`
+	Signatures []Signature `json:"signatures"`
+}
+
+// Signature is an Ed25519 signature of the payload of an envelope.
+type Signature struct {
+	// KeyID identifies the public key verifying the signature, see KeyID.
+	KeyID string `json:"keyid,omitempty"`
+	// Sig is the base64-encoded signature of the pre-authentication encoding of the payload.
+	Sig string `json:"sig"`
+}
+
+// KeyID returns the ID of the public key, the hex-encoded SHA-256 digest of the key.
+func KeyID(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:])
+}
+
+// Sign returns a DSSE envelope of the statement signed with the private key.
+func Sign(key ed25519.PrivateKey, statement *Statement) (*Envelope, error) {
+	payload, err := json.Marshal(statement)
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{
+		PayloadType: PayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures: []Signature{{
+			KeyID: KeyID(key.Public().(ed25519.PublicKey)),
+			Sig:   base64.StdEncoding.EncodeToString(ed25519.Sign(key, pae(PayloadType, payload))),
+		}},
+	}, nil
+}
+
+// Verify returns the statement of the envelope, or an error if the envelope is not signed by any of the public keys,
+// or does not contain a statement generated by Generate.
+// Callers must check that the statement Covers the deployed manifests, and that its level & version are sufficient.
+func Verify(envelope *Envelope, keys []ed25519.PublicKey) (*Statement, error) {
+	if envelope.PayloadType != PayloadType {
+		return nil, fmt.Errorf("unsupported payload type %q", envelope.PayloadType)
+	}
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload: %w", err)
+	}
+	if !verified(envelope.Signatures, keys, pae(envelope.PayloadType, payload)) {
+		return nil, errors.New("signature not verified by any key")
+	}
+	statement := &Statement{}
+	if err := json.Unmarshal(payload, statement); err != nil {
+		return nil, fmt.Errorf("invalid statement: %w", err)
+	}
+	if statement.Type != StatementType || statement.PredicateType != PredicateType {
+		return nil, fmt.Errorf("unsupported statement type %q with predicate type %q", statement.Type, statement.PredicateType)
+	}
+	return statement, nil
+}
+
+// verified returns true if any of the signatures of the message is verified by any of the keys.
+func verified(signatures []Signature, keys []ed25519.PublicKey, message []byte) bool {
+	for _, s := range signatures {
+		sig, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err != nil {
+			continue
+		}
+		for _, key := range keys {
+			if s.KeyID != "" && s.KeyID != KeyID(key) {
+				continue
+			}
+			if ed25519.Verify(key, message, sig) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pae returns the DSSE pre-authentication encoding of the payload.
+func pae(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
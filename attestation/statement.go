@@ -0,0 +1,127 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package attestation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/helm"
+	"k8s.io/pod-security-admission/policy"
+)
+
+const (
+	// StatementType is the type of in-toto v1 statements.
+	StatementType = "https://in-toto.io/Statement/v1"
+	// PredicateType is the type of the predicate of statements generated by Generate.
+	PredicateType = "https://pod-security.kubernetes.io/attestation/v1"
+)
+
+// Statement is an in-toto statement that the subject manifests passed a Pod Security Standards level & version.
+type Statement struct {
+	Type          string    `json:"_type"`
+	Subject       []Subject `json:"subject"`
+	PredicateType string    `json:"predicateType"`
+	Predicate     Predicate `json:"predicate"`
+}
+
+// Subject identifies the attested manifests by name and digest.
+type Subject struct {
+	Name string `json:"name"`
+	// Digest maps digest algorithms to the hex-encoded digest of the manifests.
+	Digest map[string]string `json:"digest"`
+}
+
+// Predicate records the policy the subject manifests passed.
+type Predicate struct {
+	Level   api.Level `json:"level"`
+	Version string    `json:"version"`
+	// ChecksSchemaVersion is the schema version of the checks of the evaluator, if known.
+	// See policy.EvaluatorSchemaVersion.
+	ChecksSchemaVersion string `json:"checksSchemaVersion,omitempty"`
+	// Checks are the IDs of the checks the pod templates of the manifests passed.
+	Checks []policy.CheckID `json:"checks"`
+	// PodTemplates is the number of evaluated pods and pod templates.
+	PodTemplates int `json:"podTemplates"`
+	// EvaluatedAt is the time the manifests were evaluated at.
+	EvaluatedAt time.Time `json:"evaluatedAt"`
+}
+
+// Digest returns the digests of the manifests, in the format of Subject.Digest.
+func Digest(manifests []byte) map[string]string {
+	sum := sha256.Sum256(manifests)
+	return map[string]string{"sha256": hex.EncodeToString(sum[:])}
+}
+
+// Covers returns true if the statement attests manifests with the same digest as the given manifests.
+func (s *Statement) Covers(manifests []byte) bool {
+	digest := Digest(manifests)
+	for _, subject := range s.Subject {
+		if subject.Digest["sha256"] != "" && subject.Digest["sha256"] == digest["sha256"] {
+			return true
+		}
+	}
+	return false
+}
+
+// Generate evaluates the pod templates of the rendered manifests, in the format parsed by helm.ParseManifests,
+// against the policy for the given level & version, and returns a statement that the manifests passed it at the given time.
+// An error is returned if the manifests cannot be evaluated, or violate the policy.
+func Generate(evaluator policy.Evaluator, lv api.LevelVersion, name string, rendered []byte, now time.Time) (*Statement, error) {
+	manifests, err := helm.ParseManifests(rendered)
+	if err != nil {
+		return nil, err
+	}
+	violations, err := helm.Evaluate(evaluator, lv, manifests)
+	if err != nil {
+		return nil, err
+	}
+	if len(violations) > 0 {
+		lines := make([]string, 0, len(violations))
+		for _, v := range violations {
+			lines = append(lines, v.String())
+		}
+		return nil, fmt.Errorf("%s violates PodSecurity %q:\n%s", name, lv.String(), strings.Join(lines, "\n"))
+	}
+
+	// Every check evaluated for the level & version returns a result for every pod,
+	// so the checks passed by the manifests are the checks evaluated for an empty pod.
+	var checks []policy.CheckID
+	for _, result := range evaluator.EvaluatePod(lv, &metav1.ObjectMeta{}, &corev1.PodSpec{}) {
+		checks = append(checks, result.ID)
+	}
+
+	return &Statement{
+		Type:          StatementType,
+		Subject:       []Subject{{Name: name, Digest: Digest(rendered)}},
+		PredicateType: PredicateType,
+		Predicate: Predicate{
+			Level:               lv.Level,
+			Version:             lv.Version.String(),
+			ChecksSchemaVersion: policy.EvaluatorSchemaVersion(evaluator),
+			Checks:              checks,
+			PodTemplates:        len(helm.PodTemplates(manifests)),
+			EvaluatedAt:         now.UTC(),
+		},
+	}, nil
+}
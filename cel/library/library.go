@@ -0,0 +1,190 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package library
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// PodSecurity returns a CEL library evaluating pods against the Pod Security Standards with the given evaluator.
+//
+// The pod argument may be a pod or a pod template (any object with metadata and spec),
+// so the same expressions can be used for pods and the templates of workload resources.
+// Versions are formatted as "v1.x" or "latest".
+//
+// psa.isBaselineCompliant
+//
+// Returns true if the pod is allowed by the baseline policy of the given version.
+//
+//	psa.isBaselineCompliant(<dyn>, <string>) <bool>
+//
+// Examples:
+//
+//	psa.isBaselineCompliant(object, 'latest')
+//
+// psa.isRestrictedCompliant
+//
+// Returns true if the pod is allowed by the restricted policy of the given version.
+//
+//	psa.isRestrictedCompliant(<dyn>, <string>) <bool>
+//
+// Examples:
+//
+//	psa.isRestrictedCompliant(object, 'v1.31')
+//	psa.isRestrictedCompliant(object.spec.template, 'latest')
+//
+// psa.violations
+//
+// Returns the forbidden reasons of the checks disallowing the pod at the given level and version.
+//
+//	psa.violations(<dyn>, <string>, <string>) <list<string>>
+//
+// Examples:
+//
+//	psa.violations(object, 'restricted', 'latest') == [] // returns true for a compliant pod
+func PodSecurity(evaluator policy.Evaluator) cel.EnvOption {
+	return cel.Lib(&podSecurityLib{evaluator: evaluator})
+}
+
+type podSecurityLib struct {
+	evaluator policy.Evaluator
+}
+
+func (l *podSecurityLib) CompileOptions() []cel.EnvOption {
+	return []cel.EnvOption{
+		cel.Function("psa.isBaselineCompliant",
+			cel.Overload("psa_is_baseline_compliant_dyn_string", []*cel.Type{cel.DynType, cel.StringType}, cel.BoolType,
+				cel.BinaryBinding(func(pod, version ref.Val) ref.Val {
+					return l.isCompliant(pod, api.LevelBaseline, version)
+				}))),
+		cel.Function("psa.isRestrictedCompliant",
+			cel.Overload("psa_is_restricted_compliant_dyn_string", []*cel.Type{cel.DynType, cel.StringType}, cel.BoolType,
+				cel.BinaryBinding(func(pod, version ref.Val) ref.Val {
+					return l.isCompliant(pod, api.LevelRestricted, version)
+				}))),
+		cel.Function("psa.violations",
+			cel.Overload("psa_violations_dyn_string_string", []*cel.Type{cel.DynType, cel.StringType, cel.StringType}, cel.ListType(cel.StringType),
+				cel.FunctionBinding(func(args ...ref.Val) ref.Val {
+					level, ok := args[1].(types.String)
+					if !ok {
+						return types.MaybeNoSuchOverloadErr(args[1])
+					}
+					parsed, err := api.ParseLevel(string(level))
+					if err != nil {
+						return types.NewErr("psa.violations: %v", err)
+					}
+					result, errVal := l.evaluate(args[0], parsed, args[2])
+					if errVal != nil {
+						return errVal
+					}
+					return types.NewStringList(types.DefaultTypeAdapter, result.ForbiddenReasons)
+				}))),
+	}
+}
+
+func (l *podSecurityLib) ProgramOptions() []cel.ProgramOption {
+	return []cel.ProgramOption{}
+}
+
+func (l *podSecurityLib) isCompliant(pod ref.Val, level api.Level, version ref.Val) ref.Val {
+	result, errVal := l.evaluate(pod, level, version)
+	if errVal != nil {
+		return errVal
+	}
+	return types.Bool(result.Allowed)
+}
+
+func (l *podSecurityLib) evaluate(pod ref.Val, level api.Level, version ref.Val) (policy.AggregateCheckResult, ref.Val) {
+	v, ok := version.(types.String)
+	if !ok {
+		return policy.AggregateCheckResult{}, types.MaybeNoSuchOverloadErr(version)
+	}
+	parsed, err := api.ParseVersion(string(v))
+	if err != nil {
+		return policy.AggregateCheckResult{}, types.NewErr("psa: %v", err)
+	}
+	template, err := toPodTemplateSpec(pod)
+	if err != nil {
+		return policy.AggregateCheckResult{}, types.NewErr("psa: %v", err)
+	}
+	lv := api.LevelVersion{Level: level, Version: parsed}
+	return policy.AggregateCheckResults(l.evaluator.EvaluatePod(lv, &template.ObjectMeta, &template.Spec)), nil
+}
+
+// toPodTemplateSpec converts a CEL object with metadata and spec fields to a pod template.
+func toPodTemplateSpec(val ref.Val) (*corev1.PodTemplateSpec, error) {
+	native, err := toNative(val)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := native.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an object, got %s", val.Type().TypeName())
+	}
+	template := &corev1.PodTemplateSpec{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj, template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// toNative converts a CEL value to its unstructured representation.
+func toNative(val ref.Val) (interface{}, error) {
+	switch v := val.(type) {
+	case types.Null:
+		return nil, nil
+	case traits.Mapper:
+		obj := map[string]interface{}{}
+		it := v.Iterator()
+		for it.HasNext() == types.True {
+			key := it.Next()
+			k, ok := key.(types.String)
+			if !ok {
+				return nil, fmt.Errorf("unsupported key type %s", key.Type().TypeName())
+			}
+			field, err := toNative(v.Get(key))
+			if err != nil {
+				return nil, err
+			}
+			obj[string(k)] = field
+		}
+		return obj, nil
+	case traits.Lister:
+		var list []interface{}
+		it := v.Iterator()
+		for it.HasNext() == types.True {
+			item, err := toNative(it.Next())
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, nil
+	default:
+		return val.Value(), nil
+	}
+}
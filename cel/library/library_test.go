@@ -0,0 +1,159 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package library
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/pod-security-admission/policy"
+	"k8s.io/utils/pointer"
+)
+
+func TestPodSecurity(t *testing.T) {
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+	require.NoError(t, err)
+	env, err := cel.NewEnv(cel.Variable("object", cel.DynType), PodSecurity(evaluator))
+	require.NoError(t, err)
+
+	baselinePod := &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "a"}}}}
+	restrictedPod := &corev1.Pod{Spec: corev1.PodSpec{
+		SecurityContext: &corev1.PodSecurityContext{
+			RunAsNonRoot:   pointer.Bool(true),
+			SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+		},
+		Containers: []corev1.Container{{
+			Name: "a",
+			SecurityContext: &corev1.SecurityContext{
+				AllowPrivilegeEscalation: pointer.Bool(false),
+				Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+			},
+		}},
+	}}
+	privilegedPod := &corev1.Pod{Spec: corev1.PodSpec{HostNetwork: true, Containers: []corev1.Container{{Name: "a"}}}}
+	deployment := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": toUnstructured(t, privilegedPod),
+		},
+	}
+
+	tests := []struct {
+		name        string
+		expr        string
+		object      interface{}
+		expect      interface{}
+		expectError string
+	}{
+		{
+			name:   "baseline pod is baseline compliant",
+			expr:   "psa.isBaselineCompliant(object, 'latest')",
+			object: toUnstructured(t, baselinePod),
+			expect: true,
+		},
+		{
+			name:   "baseline pod is not restricted compliant",
+			expr:   "psa.isRestrictedCompliant(object, 'v1.31')",
+			object: toUnstructured(t, baselinePod),
+			expect: false,
+		},
+		{
+			name:   "restricted pod is restricted compliant",
+			expr:   "psa.isRestrictedCompliant(object, 'latest')",
+			object: toUnstructured(t, restrictedPod),
+			expect: true,
+		},
+		{
+			name:   "pod template",
+			expr:   "psa.isBaselineCompliant(object.spec.template, 'latest')",
+			object: deployment,
+			expect: false,
+		},
+		{
+			name:   "combined with custom conditions",
+			expr:   "psa.isBaselineCompliant(object, 'latest') && object.spec.containers.all(c, c.name == 'a')",
+			object: toUnstructured(t, baselinePod),
+			expect: true,
+		},
+		{
+			name:   "violations",
+			expr:   "psa.violations(object, 'baseline', 'latest')",
+			object: toUnstructured(t, privilegedPod),
+			expect: []string{"host namespaces"},
+		},
+		{
+			name:   "no violations",
+			expr:   "psa.violations(object, 'restricted', 'latest') == []",
+			object: toUnstructured(t, restrictedPod),
+			expect: true,
+		},
+		{
+			name:        "invalid version",
+			expr:        "psa.isRestrictedCompliant(object, '1.31')",
+			object:      toUnstructured(t, restrictedPod),
+			expectError: "psa: ",
+		},
+		{
+			name:        "invalid level",
+			expr:        "psa.violations(object, 'strict', 'latest')",
+			object:      toUnstructured(t, restrictedPod),
+			expectError: "psa.violations: ",
+		},
+		{
+			name:        "invalid object",
+			expr:        "psa.isBaselineCompliant(object, 'latest')",
+			object:      "pod",
+			expectError: "expected an object",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ast, issues := env.Compile(tc.expr)
+			require.NoError(t, issues.Err())
+			prg, err := env.Program(ast)
+			require.NoError(t, err)
+
+			out, _, err := prg.Eval(map[string]interface{}{"object": tc.object})
+			if tc.expectError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectError)
+				return
+			}
+			require.NoError(t, err)
+			if expectList, ok := tc.expect.([]string); ok {
+				actual, err := out.ConvertToNative(reflectStringSlice)
+				require.NoError(t, err)
+				assert.Equal(t, expectList, actual)
+			} else {
+				assert.Equal(t, tc.expect, out.Value())
+			}
+		})
+	}
+}
+
+var reflectStringSlice = reflect.TypeOf([]string{})
+
+func toUnstructured(t *testing.T, pod *corev1.Pod) map[string]interface{} {
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+	require.NoError(t, err)
+	return obj
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/pod-security-admission/admission"
+	admissionapi "k8s.io/pod-security-admission/admission/api"
+	podsecurityconfigloader "k8s.io/pod-security-admission/admission/api/load"
+	"k8s.io/pod-security-admission/admission/api/validation"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/cmd/webhook/server/options"
+)
+
+// newConfigCommand creates the config subcommand, rendering the effective configuration merged from overlays.
+func newConfigCommand() *cobra.Command {
+	opts := options.NewConfigOptions()
+
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Render the effective PodSecurity configuration merged from overlays",
+		Long: `Merge PodSecurity configuration overlays, e.g. of teams, over the cluster-wide
+configuration and render the effective settings with the file each is taken from.
+Defaults are overridden by later overlays, exemptions are combined, and the floor
+and privileged confirmation can only be tightened. With --namespace-file, also
+render the policy the namespace is evaluated against under the merged configuration.
+Nothing is sent to the API server.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runConfig(opts, cmd.OutOrStdout())
+		},
+		Args: cobra.NoArgs,
+	}
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func runConfig(opts *options.ConfigOptions, out io.Writer) error {
+	if errs := opts.Validate(); len(errs) > 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+
+	base, err := podsecurityconfigloader.LoadFromFile(opts.Config)
+	if err != nil {
+		return err
+	}
+	baseName := opts.Config
+	if baseName == "" {
+		baseName = "default configuration"
+	}
+	layers := []admissionapi.ConfigurationLayer{{Name: baseName, Configuration: base}}
+	for _, overlay := range opts.Overlays {
+		config, err := podsecurityconfigloader.LoadOverlayFromFile(overlay)
+		if err != nil {
+			return fmt.Errorf("failed to load %s: %w", overlay, err)
+		}
+		layers = append(layers, admissionapi.ConfigurationLayer{Name: overlay, Configuration: config})
+	}
+
+	merged := admissionapi.MergeConfigurations(layers...)
+	if errs := validation.ValidatePodSecurityConfiguration(merged.Configuration); len(errs) > 0 {
+		return fmt.Errorf("invalid merged configuration: %w", errs.ToAggregate())
+	}
+	if err := merged.Render(out); err != nil {
+		return err
+	}
+	if opts.NamespaceFile == "" {
+		return nil
+	}
+
+	namespace, err := readNamespace(opts.NamespaceFile)
+	if err != nil {
+		return err
+	}
+	a := &admission.Admission{
+		Configuration:       merged.Configuration,
+		LenientLabelParsing: opts.LenientLabelParsing,
+	}
+	if err := a.CompleteConfiguration(); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\nnamespace %s:\n", namespace.Name)
+	for _, namespaceExemption := range merged.Configuration.Exemptions.Namespaces {
+		if admission.NewExemptionMatcher([]string{namespaceExemption}).Matches(namespace.Name) {
+			fmt.Fprintf(&b, "  exempt (from %s)\n", merged.Sources[field.NewPath("exemptions", "namespaces").Key(namespaceExemption).String()])
+		}
+	}
+	status := a.PolicyStatus(namespace.Labels)
+	for _, label := range status.Labels() {
+		fmt.Fprintf(&b, "  %s\n", label)
+	}
+	if checks := api.ExemptChecks(namespace.Annotations); opts.NamespaceCheckExemptions && len(checks) > 0 {
+		fmt.Fprintf(&b, "  %s annotation exempts checks: %s\n", api.ExemptChecksAnnotation, strings.Join(checks, ", "))
+	}
+	_, err = io.WriteString(out, b.String())
+	return err
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/pod-security-admission/admission"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+	"sigs.k8s.io/yaml"
+)
+
+// NewCorpusRecorder returns an admission.ViolationRecorder persisting the given fraction of violating pods,
+// sanitized with policy.SanitizePod, to dir. Pods are written to <level>/<version>/fail/<hash>.yaml,
+// the layout of the test/testdata fixtures, so the corpus can be replayed against later policy versions.
+// Identical sanitized pods are only written once.
+func NewCorpusRecorder(dir string, sampleRate float64) admission.ViolationRecorder {
+	return &corpusRecorder{
+		dir:        dir,
+		sampleRate: sampleRate,
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+type corpusRecorder struct {
+	dir        string
+	sampleRate float64
+
+	lock sync.Mutex
+	rand *rand.Rand
+}
+
+func (r *corpusRecorder) RecordViolation(ctx context.Context, lv api.LevelVersion, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, attrs api.Attributes) {
+	if !r.sample() {
+		return
+	}
+	if err := r.write(lv, podMetadata, podSpec); err != nil {
+		klog.FromContext(ctx).Error(err, "failed to record violating pod", "namespace", attrs.GetNamespace(), "name", attrs.GetName())
+	}
+}
+
+func (r *corpusRecorder) sample() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.rand.Float64() < r.sampleRate
+}
+
+func (r *corpusRecorder) write(lv api.LevelVersion, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec) error {
+	sanitizedMetadata, sanitizedSpec := policy.SanitizePod(podMetadata, podSpec)
+	pod := &corev1.Pod{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: *sanitizedMetadata,
+	}
+	// Recorded pods are deduplicated across namespaces, like the test fixtures.
+	pod.Namespace = ""
+	if sanitizedSpec != nil {
+		pod.Spec = *sanitizedSpec
+	}
+	sum, err := policy.PodHash(&pod.ObjectMeta, &pod.Spec)
+	if err != nil {
+		return err
+	}
+	pod.Name = hex.EncodeToString(sum[:8])
+	data, err := yaml.Marshal(pod)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(r.dir, string(lv.Level), lv.Version.String(), "fail")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	file := filepath.Join(dir, pod.Name+".yaml")
+	if _, err := os.Stat(file); err == nil {
+		return nil // already recorded
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	return nil
+}
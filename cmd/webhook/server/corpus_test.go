@@ -0,0 +1,134 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+	utilpointer "k8s.io/utils/pointer"
+	"sigs.k8s.io/yaml"
+)
+
+// privilegedPod returns a pod violating the baseline level, with data the corpus must not record.
+func privilegedPod(name string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      map[string]string{"app": name},
+			Annotations: map[string]string{"owner": "team-a"},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:            "app",
+			Image:           "app",
+			Env:             []corev1.EnvVar{{Name: "PASSWORD", Value: name}},
+			SecurityContext: &corev1.SecurityContext{Privileged: utilpointer.Bool(true)},
+		}}},
+	}
+}
+
+// corpusFiles returns the paths of the pods recorded to dir, relative to it.
+func corpusFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, "*", "*", "fail", "*.yaml"))
+	require.NoError(t, err)
+	var files []string
+	for _, match := range matches {
+		file, err := filepath.Rel(dir, match)
+		require.NoError(t, err)
+		files = append(files, file)
+	}
+	return files
+}
+
+func TestCorpusRecorder(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		sampleRate float64
+		expectPods int
+	}{
+		{name: "disabled", sampleRate: 0, expectPods: 0},
+		{name: "all", sampleRate: 1, expectPods: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			c, _ := newTestHandlerConfig(t)
+			c.ViolationRecorder = NewCorpusRecorder(dir, tc.sampleRate)
+			h, err := newHandler(c)
+			require.NoError(t, err)
+
+			// Pods only differing by unchecked fields are recorded once.
+			for _, name := range []string{"pod-a", "pod-b"} {
+				assert.False(t, serveReview(t, h, podCreateRequest(t, privilegedPod(name))).Allowed)
+			}
+			// Allowed pods are not recorded.
+			assert.True(t, serveReview(t, h, podCreateRequest(t, &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "allowed"},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app"}}},
+			})).Allowed)
+
+			files := corpusFiles(t, dir)
+			require.Len(t, files, tc.expectPods)
+			for _, file := range files {
+				assert.Equal(t, filepath.Join("baseline", "latest", "fail"), filepath.Dir(file))
+			}
+		})
+	}
+}
+
+func TestCorpusRecorderReplay(t *testing.T) {
+	dir := t.TempDir()
+	c, _ := newTestHandlerConfig(t)
+	c.ViolationRecorder = NewCorpusRecorder(dir, 1)
+	h, err := newHandler(c)
+	require.NoError(t, err)
+	original := privilegedPod("pod-a")
+	assert.False(t, serveReview(t, h, podCreateRequest(t, original)).Allowed)
+
+	files := corpusFiles(t, dir)
+	require.Len(t, files, 1)
+	data, err := os.ReadFile(filepath.Join(dir, files[0]))
+	require.NoError(t, err)
+	recorded := &corev1.Pod{}
+	require.NoError(t, yaml.UnmarshalStrict(data, recorded))
+
+	// The recorded pod is sanitized, and named by its hash.
+	assert.Equal(t, "Pod", recorded.Kind)
+	assert.Equal(t, recorded.Name+".yaml", filepath.Base(files[0]))
+	assert.Empty(t, recorded.Namespace)
+	assert.Empty(t, recorded.Labels)
+	assert.Empty(t, recorded.Annotations)
+	require.Len(t, recorded.Spec.Containers, 1)
+	assert.Empty(t, recorded.Spec.Containers[0].Env)
+
+	// Replaying the recorded pod returns the results of the original pod.
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+	require.NoError(t, err)
+	lv := api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}
+	expected := policy.AggregateCheckResults(evaluator.EvaluatePod(lv, &original.ObjectMeta, &original.Spec))
+	replayed := policy.AggregateCheckResults(evaluator.EvaluatePod(lv, &recorded.ObjectMeta, &recorded.Spec))
+	assert.False(t, replayed.Allowed)
+	assert.Equal(t, expected, replayed)
+}
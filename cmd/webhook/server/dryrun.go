@@ -0,0 +1,268 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/pod-security-admission/admission"
+	admissionapi "k8s.io/pod-security-admission/admission/api"
+	podsecurityconfigloader "k8s.io/pod-security-admission/admission/api/load"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/cmd/webhook/server/options"
+	"k8s.io/pod-security-admission/metrics"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// newDryRunCommand creates the dryrun subcommand, reporting the namespaces whose pods violate tightened enforce levels.
+func newDryRunCommand() *cobra.Command {
+	opts := options.NewDryRunOptions()
+
+	cmd := &cobra.Command{
+		Use:   "dryrun",
+		Short: "Report the namespaces that would break if their enforce levels were tightened",
+		Long: `Evaluate the existing pods of all namespaces of a cluster against the given
+enforce levels, and report the namespaces whose pods violate the levels that are
+stricter than their current enforce level, as text, JSON or SARIF. Useful to plan
+migrations, e.g. from baseline to restricted.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runDryRun(cmd.Context(), opts, cmd.OutOrStdout())
+		},
+		Args: cobra.NoArgs,
+	}
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// dryRunReport is the report of the dryrun subcommand, with an entry for each evaluated level.
+type dryRunReport struct {
+	Levels []dryRunLevelReport `json:"levels"`
+}
+
+// dryRunLevelReport is the report of the namespaces evaluated against a level & version.
+type dryRunLevelReport struct {
+	// Level is the evaluated level & version, e.g. restricted:latest.
+	Level string `json:"level"`
+	// EvaluatedNamespaces is the number of namespaces whose enforce level is less strict than Level.
+	EvaluatedNamespaces int `json:"evaluatedNamespaces"`
+	// EnforcedNamespaces is the number of namespaces already enforcing Level or a stricter level.
+	EnforcedNamespaces int `json:"enforcedNamespaces"`
+	// ExemptNamespaces is the number of namespaces exempt by the PodSecurity configuration.
+	ExemptNamespaces int `json:"exemptNamespaces"`
+	// BreakingNamespaces are the evaluated namespaces with pods violating Level, sorted by name.
+	BreakingNamespaces []dryRunNamespaceReport `json:"breakingNamespaces"`
+}
+
+// dryRunNamespaceReport is the report of a namespace with pods violating an evaluated level.
+type dryRunNamespaceReport struct {
+	Namespace string `json:"namespace"`
+	// Enforce is the current enforce level & version of the namespace.
+	Enforce     string                  `json:"enforce"`
+	TotalPods   int                     `json:"totalPods"`
+	CheckedPods int                     `json:"checkedPods"`
+	Violations  []dryRunViolationReport `json:"violations"`
+}
+
+// dryRunViolationReport is a violation shared by pods of a namespace, like admission.NamespaceViolation.
+type dryRunViolationReport struct {
+	Reason   string `json:"reason"`
+	PodName  string `json:"podName"`
+	PodCount int    `json:"podCount"`
+}
+
+func runDryRun(ctx context.Context, opts *options.DryRunOptions, out io.Writer) error {
+	if errs := opts.Validate(); len(errs) > 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+
+	podSecurityConfig, err := podsecurityconfigloader.LoadFromFile(opts.Config)
+	if err != nil {
+		return err
+	}
+	kubeConfig, err := clientcmd.BuildConfigFromFlags("", opts.Kubeconfig)
+	if err != nil {
+		return err
+	}
+	client, err := clientset.NewForConfig(kubeConfig)
+	if err != nil {
+		return err
+	}
+	report, err := dryRun(ctx, opts, podSecurityConfig, client)
+	if err != nil {
+		return err
+	}
+
+	switch opts.Format {
+	case options.DryRunFormatJSON:
+		return writeJSON(out, report)
+	case options.DryRunFormatSARIF:
+		return writeJSON(out, newSARIFLog(report))
+	default:
+		return writeDryRunReport(out, report)
+	}
+}
+
+// dryRun evaluates the pods of the namespaces selected by the options against each level,
+// if it is stricter than the enforce level of the namespace.
+func dryRun(ctx context.Context, opts *options.DryRunOptions, podSecurityConfig *admissionapi.PodSecurityConfiguration, client clientset.Interface) (*dryRunReport, error) {
+	windowsPodMode, _ := policy.ParseWindowsPodMode(opts.WindowsPodMode) // validated above
+	h, err := newHandler(handlerConfig{
+		Admission: admission.Admission{
+			Configuration: podSecurityConfig,
+			Metrics:       metrics.NewPrometheusRecorder(api.GetAPIVersion()),
+			NamespaceEvaluation: admission.NamespaceEvaluationOptions{
+				Parallelism: opts.Parallelism,
+			},
+
+			NamespaceCheckExemptions: opts.NamespaceCheckExemptions,
+		},
+		WindowsPodMode: windowsPodMode,
+		Client:         client,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces, err := client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: opts.NamespaceSelector})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(namespaces.Items, func(i, j int) bool { return namespaces.Items[i].Name < namespaces.Items[j].Name })
+	exemptNamespaces := admission.NewExemptionMatcher(podSecurityConfig.Exemptions.Namespaces)
+
+	report := &dryRunReport{}
+	for _, level := range opts.Levels {
+		lv, _ := options.ParseLevelVersion(level) // validated above
+		levelReport := dryRunLevelReport{Level: lv.String()}
+		for i := range namespaces.Items {
+			namespace := &namespaces.Items[i]
+			if exemptNamespaces.Matches(namespace.Name) {
+				levelReport.ExemptNamespaces++
+				continue
+			}
+			// Invalid labels evaluate to the restricted level, like in admission.
+			nsPolicy, _ := h.PolicyToEvaluate(namespace.Labels)
+			if !tightens(lv, nsPolicy.Enforce) {
+				levelReport.EnforcedNamespaces++
+				continue
+			}
+			levelReport.EvaluatedNamespaces++
+
+			audit, err := h.AuditNamespace(ctx, namespace, lv)
+			if err != nil {
+				return nil, fmt.Errorf("failed to evaluate the pods of namespace %s: %w", namespace.Name, err)
+			}
+			if len(audit.Violations) == 0 {
+				continue
+			}
+			levelReport.BreakingNamespaces = append(levelReport.BreakingNamespaces, newDryRunNamespaceReport(namespace, nsPolicy.Enforce, audit))
+		}
+		report.Levels = append(report.Levels, levelReport)
+	}
+	return report, nil
+}
+
+// tightens returns true if enforcing the level & version is stricter than enforcing the current level & version.
+func tightens(lv, current api.LevelVersion) bool {
+	if c := api.CompareLevels(lv.Level, current.Level); c != 0 {
+		return c > 0
+	}
+	return current.Version.Older(lv.Version)
+}
+
+func newDryRunNamespaceReport(namespace *corev1.Namespace, enforce api.LevelVersion, audit *admission.NamespaceAudit) dryRunNamespaceReport {
+	report := dryRunNamespaceReport{
+		Namespace:   namespace.Name,
+		Enforce:     enforce.String(),
+		TotalPods:   audit.TotalPods,
+		CheckedPods: audit.CheckedPods,
+	}
+	for _, v := range audit.Violations {
+		report.Violations = append(report.Violations, dryRunViolationReport{Reason: v.Reason, PodName: v.PodName, PodCount: v.PodCount})
+	}
+	return report
+}
+
+func writeJSON(out io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func writeDryRunReport(out io.Writer, report *dryRunReport) error {
+	for _, level := range report.Levels {
+		fmt.Fprintf(out, "%s: %d of %d namespaces would break (%d already enforced, %d exempt)\n",
+			level.Level, len(level.BreakingNamespaces), level.EvaluatedNamespaces, level.EnforcedNamespaces, level.ExemptNamespaces)
+		for _, namespace := range level.BreakingNamespaces {
+			fmt.Fprintf(out, "  %s (enforce=%s): %d of %d pods evaluated\n", namespace.Namespace, namespace.Enforce, namespace.CheckedPods, namespace.TotalPods)
+			for _, v := range namespace.Violations {
+				fmt.Fprintf(out, "    %s: %d %s (e.g. %s)\n", v.Reason, v.PodCount, pluralizePods(v.PodCount), v.PodName)
+			}
+		}
+	}
+	return nil
+}
+
+func pluralizePods(count int) string {
+	if count == 1 {
+		return "pod"
+	}
+	return "pods"
+}
+
+// newSARIFLog returns the report as a SARIF log, with a rule for each evaluated level
+// and a result for each violation of a breaking namespace.
+func newSARIFLog(report *dryRunReport) *policy.SARIFLog {
+	run := policy.SARIFRun{
+		Tool:    policy.SARIFTool{Driver: policy.SARIFDriver{Name: "podsecurity-webhook"}},
+		Results: []policy.SARIFResult{},
+	}
+	for _, level := range report.Levels {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, policy.SARIFRule{
+			ID:               level.Level,
+			ShortDescription: policy.SARIFMessage{Text: fmt.Sprintf("Pods must satisfy the %s policy", level.Level)},
+		})
+		for _, namespace := range level.BreakingNamespaces {
+			for _, v := range namespace.Violations {
+				run.Results = append(run.Results, policy.SARIFResult{
+					RuleID: level.Level,
+					Level:  "error",
+					Message: policy.SARIFMessage{Text: fmt.Sprintf("%d %s in namespace %s (enforce=%s) would violate %s: %s (e.g. %s)",
+						v.PodCount, pluralizePods(v.PodCount), namespace.Namespace, namespace.Enforce, level.Level, v.Reason, v.PodName)},
+					Locations: []policy.SARIFLocation{{LogicalLocations: []policy.SARIFLogicalLocation{{Name: namespace.Namespace, Kind: "namespace"}}}},
+				})
+			}
+		}
+	}
+	return &policy.SARIFLog{
+		Schema:  policy.SARIFSchema,
+		Version: policy.SARIFVersion,
+		Runs:    []policy.SARIFRun{run},
+	}
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/pod-security-admission/admission/api/load"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/cmd/webhook/server/options"
+	"k8s.io/utils/ptr"
+)
+
+func TestDryRun(t *testing.T) {
+	config, err := load.LoadFromData([]byte(`
+apiVersion: pod-security.admission.config.k8s.io/v1
+kind: PodSecurityConfiguration
+exemptions:
+  namespaces:
+  - kube-system
+`))
+	require.NoError(t, err)
+
+	namespace := func(name string, level api.Level) *corev1.Namespace {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if level != "" {
+			ns.Labels = map[string]string{api.EnforceLevelLabel: string(level)}
+		}
+		return ns
+	}
+	pod := func(namespace, name string, securityContext *corev1.SecurityContext) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name:            "app",
+				Image:           "app",
+				SecurityContext: securityContext,
+			}}},
+		}
+	}
+	restrictedSecurityContext := &corev1.SecurityContext{
+		AllowPrivilegeEscalation: ptr.To(false),
+		Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+		RunAsNonRoot:             ptr.To(true),
+		SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	}
+	privilegedSecurityContext := &corev1.SecurityContext{Privileged: ptr.To(true)}
+	client := fake.NewSimpleClientset([]runtime.Object{
+		// breaks both levels
+		namespace("legacy", ""),
+		pod("legacy", "privileged", privilegedSecurityContext),
+		// breaks restricted
+		namespace("team-a", api.LevelBaseline),
+		pod("team-a", "web-1", nil),
+		pod("team-a", "web-2", nil),
+		// compliant with restricted
+		namespace("team-b", api.LevelBaseline),
+		pod("team-b", "web", restrictedSecurityContext),
+		namespace("secure", api.LevelRestricted),
+		pod("secure", "web", restrictedSecurityContext),
+		namespace("kube-system", ""),
+		pod("kube-system", "privileged", privilegedSecurityContext),
+	}...)
+
+	opts := options.NewDryRunOptions()
+	opts.Levels = []string{"restricted", "baseline:v1.30"}
+	report, err := dryRun(context.Background(), opts, config, client)
+	require.NoError(t, err)
+
+	expected := &dryRunReport{Levels: []dryRunLevelReport{{
+		Level:               "restricted:latest",
+		EvaluatedNamespaces: 3,
+		EnforcedNamespaces:  1,
+		ExemptNamespaces:    1,
+		BreakingNamespaces: []dryRunNamespaceReport{{
+			Namespace:   "legacy",
+			Enforce:     "privileged:latest",
+			TotalPods:   1,
+			CheckedPods: 1,
+			Violations: []dryRunViolationReport{{
+				Reason:   "privileged, allowPrivilegeEscalation != false, unrestricted capabilities, runAsNonRoot != true, seccompProfile",
+				PodName:  "privileged",
+				PodCount: 1,
+			}},
+		}, {
+			Namespace:   "team-a",
+			Enforce:     "baseline:latest",
+			TotalPods:   2,
+			CheckedPods: 2,
+			Violations: []dryRunViolationReport{{
+				Reason:   "allowPrivilegeEscalation != false, unrestricted capabilities, runAsNonRoot != true, seccompProfile",
+				PodName:  "web-1",
+				PodCount: 2,
+			}},
+		}},
+	}, {
+		Level:               "baseline:v1.30",
+		EvaluatedNamespaces: 1,
+		EnforcedNamespaces:  3,
+		ExemptNamespaces:    1,
+		BreakingNamespaces: []dryRunNamespaceReport{{
+			Namespace:   "legacy",
+			Enforce:     "privileged:latest",
+			TotalPods:   1,
+			CheckedPods: 1,
+			Violations: []dryRunViolationReport{{
+				Reason:   "privileged",
+				PodName:  "privileged",
+				PodCount: 1,
+			}},
+		}},
+	}}}
+	assert.Equal(t, expected, report)
+
+	var out bytes.Buffer
+	require.NoError(t, writeDryRunReport(&out, report))
+	assert.Contains(t, out.String(), "restricted:latest: 2 of 3 namespaces would break (1 already enforced, 1 exempt)\n")
+	assert.Contains(t, out.String(), "  team-a (enforce=baseline:latest): 2 of 2 pods evaluated\n")
+	assert.Contains(t, out.String(), "baseline:v1.30: 1 of 1 namespaces would break (3 already enforced, 1 exempt)\n")
+
+	sarif := newSARIFLog(report)
+	require.Len(t, sarif.Runs, 1)
+	assert.Len(t, sarif.Runs[0].Tool.Driver.Rules, 2)
+	assert.Len(t, sarif.Runs[0].Results, 3)
+}
+
+func TestDryRunNamespaceSelector(t *testing.T) {
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	client := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "selected", Labels: map[string]string{"team": "a"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}},
+	)
+	opts := options.NewDryRunOptions()
+	opts.NamespaceSelector = "team=a"
+	report, err := dryRun(context.Background(), opts, config, client)
+	require.NoError(t, err)
+	require.Len(t, report.Levels, 1)
+	assert.Equal(t, 1, report.Levels[0].EvaluatedNamespaces)
+	assert.Empty(t, report.Levels[0].BreakingNamespaces)
+}
+
+func TestTightens(t *testing.T) {
+	lv := func(level api.Level, minor int) api.LevelVersion {
+		return api.LevelVersion{Level: level, Version: api.MajorMinorVersion(1, minor)}
+	}
+	latest := func(level api.Level) api.LevelVersion {
+		return api.LevelVersion{Level: level, Version: api.LatestVersion()}
+	}
+	assert.True(t, tightens(latest(api.LevelRestricted), latest(api.LevelBaseline)))
+	assert.False(t, tightens(latest(api.LevelBaseline), latest(api.LevelRestricted)))
+	assert.True(t, tightens(lv(api.LevelBaseline, 30), lv(api.LevelBaseline, 25)))
+	assert.False(t, tightens(lv(api.LevelBaseline, 25), lv(api.LevelBaseline, 30)))
+	assert.False(t, tightens(latest(api.LevelBaseline), latest(api.LevelBaseline)))
+	assert.True(t, tightens(latest(api.LevelBaseline), lv(api.LevelBaseline, 30)))
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/klog/v2"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+	"k8s.io/pod-security-admission/webhook"
+)
+
+// evaluateResponse is the response of the /evaluate endpoint.
+type evaluateResponse struct {
+	// Kind and Name identify the evaluated object.
+	Kind string `json:"kind"`
+	Name string `json:"name,omitempty"`
+	// Level and Version are the policy the object is evaluated against.
+	Level   api.Level `json:"level"`
+	Version string    `json:"version"`
+	// ChecksSchemaVersion is the schema version of the evaluated checks (see HandleChecksSchemaVersion).
+	ChecksSchemaVersion string `json:"checksSchemaVersion"`
+	// Result is the evaluation of the pod template of the object.
+	Result policy.AggregateCheckResult `json:"result"`
+}
+
+// HandleEvaluate evaluates the Pod or workload in the request body, as JSON or YAML, against the level and version
+// query parameters, and serves the result as JSON, so tooling like CI systems can use the webhook as an evaluation
+// service. The version defaults to latest. Exemptions and namespace labels are not applied.
+func (s *Server) HandleEvaluate(w http.ResponseWriter, r *http.Request) {
+	defer utilruntime.HandleCrash(func(_ interface{}) {
+		// Assume the crash happened before the response was written.
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	})
+	logger := klog.FromContext(r.Context())
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	level, err := api.ParseLevel(r.URL.Query().Get("level"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid level: %v", err), http.StatusBadRequest)
+		return
+	}
+	version := api.LatestVersion()
+	if v := r.URL.Query().Get("version"); v != "" {
+		if version, err = api.ParseVersion(v); err != nil {
+			http.Error(w, fmt.Sprintf("invalid version: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	defer r.Body.Close()
+	limitedReader := &io.LimitedReader{R: r.Body, N: webhook.MaxRequestSize}
+	body, err := io.ReadAll(limitedReader)
+	if err != nil {
+		logger.Error(err, "unable to read the body from the incoming request")
+		http.Error(w, "unable to read the body from the incoming request", http.StatusBadRequest)
+		return
+	}
+	if limitedReader.N <= 0 {
+		http.Error(w, fmt.Sprintf("request entity is too large; limit is %d bytes", webhook.MaxRequestSize), http.StatusRequestEntityTooLarge)
+		return
+	}
+	obj, gvk, err := codecs.UniversalDeserializer().Decode(body, nil, nil)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to decode the object: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	delegate := s.handler.Load().Admission
+	lv := api.LevelVersion{Level: level, Version: version}
+	template, results, err := policy.EvaluateWorkload(delegate.Evaluator, lv, obj)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to evaluate the object: %v", err), http.StatusBadRequest)
+		return
+	}
+	if template.Spec == nil {
+		http.Error(w, fmt.Sprintf("%s has no pod template", gvk.Kind), http.StatusBadRequest)
+		return
+	}
+	response := evaluateResponse{
+		Kind:                gvk.Kind,
+		Level:               level,
+		Version:             version.String(),
+		ChecksSchemaVersion: delegate.ChecksSchemaVersion,
+		Result:              policy.AggregateCheckResults(results),
+	}
+	if accessor, err := meta.Accessor(obj); err == nil {
+		response.Name = accessor.GetName()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error(err, "Failed to encode evaluation")
+	}
+}
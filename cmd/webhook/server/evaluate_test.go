@@ -0,0 +1,200 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/pod-security-admission/api"
+)
+
+// newTestServer returns a Server serving a handler of the handlerConfig.
+func newTestServer(t *testing.T, c handlerConfig) *Server {
+	t.Helper()
+	h, err := newHandler(c)
+	require.NoError(t, err)
+	s := &Server{}
+	s.handler.Store(h)
+	return s
+}
+
+func TestHandleEvaluate(t *testing.T) {
+	c, _ := newTestHandlerConfig(t)
+	s := newTestServer(t, c)
+
+	privilegedPod := `{
+  "apiVersion": "v1",
+  "kind": "Pod",
+  "metadata": {"name": "test-pod"},
+  "spec": {"containers": [{"name": "app", "image": "app", "securityContext": {"privileged": true}}]}
+}`
+	deployment := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test-deployment
+spec:
+  selector:
+    matchLabels:
+      app: test
+  template:
+    metadata:
+      labels:
+        app: test
+    spec:
+      containers:
+      - name: app
+        image: app
+`
+	configMap := `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "test-config"}}`
+
+	testCases := []struct {
+		name         string
+		method       string
+		query        string
+		body         string
+		expectStatus int
+		// expectResponse is the expected JSON response, if the request is evaluated
+		expectResponse string
+		// expectError is a substring of the error served otherwise
+		expectError string
+	}{{
+		name:         "violating pod",
+		query:        "level=baseline&version=v1.30",
+		body:         privilegedPod,
+		expectStatus: http.StatusOK,
+		expectResponse: `{
+  "kind": "Pod",
+  "name": "test-pod",
+  "level": "baseline",
+  "version": "v1.30",
+  "checksSchemaVersion": "` + s.handler.Load().ChecksSchemaVersion + `",
+  "result": {
+    "allowed": false,
+    "violations": [{
+      "check": "privileged",
+      "version": "v1.30",
+      "source": "k8s.io/pod-security-admission",
+      "severity": "critical",
+      "reason": "privileged",
+      "detail": "container \"app\" must not set securityContext.privileged=true"
+    }]
+  }
+}`,
+	}, {
+		name:         "compliant deployment as YAML",
+		query:        "level=baseline",
+		body:         deployment,
+		expectStatus: http.StatusOK,
+		expectResponse: `{
+  "kind": "Deployment",
+  "name": "test-deployment",
+  "level": "baseline",
+  "version": "latest",
+  "checksSchemaVersion": "` + s.handler.Load().ChecksSchemaVersion + `",
+  "result": {"allowed": true}
+}`,
+	}, {
+		name:         "malformed body",
+		query:        "level=baseline",
+		body:         `{"apiVersion": "v1", "kind": "Pod"`,
+		expectStatus: http.StatusBadRequest,
+		expectError:  "unable to decode the object",
+	}, {
+		name:         "unsupported kind",
+		query:        "level=baseline",
+		body:         configMap,
+		expectStatus: http.StatusBadRequest,
+		expectError:  "unable to evaluate the object",
+	}, {
+		name:         "invalid level",
+		query:        "level=strict",
+		body:         privilegedPod,
+		expectStatus: http.StatusBadRequest,
+		expectError:  "invalid level",
+	}, {
+		name:         "invalid version",
+		query:        "level=baseline&version=1.30",
+		body:         privilegedPod,
+		expectStatus: http.StatusBadRequest,
+		expectError:  "invalid version",
+	}, {
+		name:         "GET",
+		method:       http.MethodGet,
+		query:        "level=baseline",
+		expectStatus: http.StatusMethodNotAllowed,
+		expectError:  "only POST is supported",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, "/evaluate?"+tc.query, strings.NewReader(tc.body))
+			w := httptest.NewRecorder()
+			s.HandleEvaluate(w, req)
+
+			assert.Equal(t, tc.expectStatus, w.Code, w.Body.String())
+			if tc.expectResponse != "" {
+				assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+				assert.JSONEq(t, tc.expectResponse, w.Body.String())
+			} else {
+				assert.Contains(t, w.Body.String(), tc.expectError)
+			}
+		})
+	}
+}
+
+func TestHandleEvaluateResult(t *testing.T) {
+	c, _ := newTestHandlerConfig(t)
+	s := newTestServer(t, c)
+	req := httptest.NewRequest(http.MethodPost, "/evaluate?level=restricted", strings.NewReader(`{
+  "apiVersion": "v1",
+  "kind": "Pod",
+  "metadata": {"name": "test-pod"},
+  "spec": {"containers": [{"name": "app", "image": "app"}]}
+}`))
+	w := httptest.NewRecorder()
+	s.HandleEvaluate(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var response struct {
+		Level  api.Level `json:"level"`
+		Result struct {
+			Allowed    bool `json:"allowed"`
+			Violations []struct {
+				Check string `json:"check"`
+			} `json:"violations"`
+		} `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, api.LevelRestricted, response.Level)
+	assert.False(t, response.Result.Allowed)
+	var checks []string
+	for _, violation := range response.Result.Violations {
+		checks = append(checks, violation.Check)
+	}
+	assert.ElementsMatch(t, []string{"allowPrivilegeEscalation", "capabilities_restricted", "runAsNonRoot", "seccompProfile_restricted"}, checks)
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"time"
+
+	clientset "k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/pod-security-admission/admission"
+	"k8s.io/pod-security-admission/metrics"
+	"k8s.io/pod-security-admission/policy"
+	"k8s.io/pod-security-admission/webhook"
+)
+
+// handlerConfig holds the inputs of the webhook.Handler of the server and of its subcommands.
+type handlerConfig struct {
+	// Admission is copied to the Admission of each handler, e.g. of each reloaded configuration.
+	// Its Evaluator defaults to an evaluator of policy.DefaultChecks() configured by the options below.
+	admission.Admission
+
+	// WindowsPodMode configures the evaluation of Windows pods by the default Evaluator (see policy.WithWindowsPodMode).
+	WindowsPodMode policy.WindowsPodMode
+	// ResultCacheSize is the number of evaluated pods whose results are cached by the default Evaluator, if non-zero
+	// (see policy.WithResultCache). Cache lookups are recorded if the Metrics implement metrics.ResultCacheRecorder.
+	ResultCacheSize int
+	// CheckDeadline bounds the execution of each check of the default Evaluator, if non-zero
+	// (see policy.WithCheckDeadline).
+	CheckDeadline time.Duration
+
+	// Client is used to get namespaces, list the pods of namespaces and get pod controllers. Required.
+	Client clientset.Interface
+	// NamespaceLister is optional, and used to get namespaces before falling back to the Client.
+	NamespaceLister corev1listers.NamespaceLister
+}
+
+// newHandler returns a webhook.Handler of a copy of the Admission of the config.
+func newHandler(c handlerConfig) (*webhook.Handler, error) {
+	delegate := c.Admission
+	if delegate.Evaluator == nil {
+		evaluator, err := c.newEvaluator(policy.DefaultChecks())
+		if err != nil {
+			return nil, fmt.Errorf("could not create PodSecurityRegistry: %w", err)
+		}
+		delegate.Evaluator = evaluator
+	}
+	return webhook.NewHandlerForClient(&delegate, c.Client, c.NamespaceLister)
+}
+
+// evaluatorOptions returns the options of the default Evaluator, except its result cache.
+func (c handlerConfig) evaluatorOptions() []policy.Option {
+	opts := []policy.Option{
+		policy.WithWindowsPodMode(c.WindowsPodMode),
+		policy.WithCheckDeadline(c.CheckDeadline),
+	}
+	if c.AuditViolationsDetail {
+		opts = append(opts, policy.WithFieldErrors())
+	}
+	return opts
+}
+
+// newEvaluator returns the default Evaluator of the checks.
+func (c handlerConfig) newEvaluator(checks []policy.Check) (policy.Evaluator, error) {
+	var recordLookup func(hit bool)
+	if r, ok := c.Metrics.(metrics.ResultCacheRecorder); ok {
+		recordLookup = r.RecordResultCacheLookup
+	}
+	opts := append(c.evaluatorOptions(), policy.WithResultCache(c.ResultCacheSize, recordLookup))
+	return policy.NewEvaluator(checks, opts...)
+}
+
+// newDeterminismGuard returns a DeterminismGuard re-evaluating a sample of the pods with evaluators of the checks
+// configured like the default Evaluator, without its result cache so pods are actually re-evaluated.
+func newDeterminismGuard(sampleRate float64, c handlerConfig, checks []policy.Check) *admission.DeterminismGuard {
+	opts := c.evaluatorOptions()
+	return admission.NewDeterminismGuard(sampleRate, func() (policy.Evaluator, error) {
+		return policy.NewEvaluator(checks, opts...)
+	})
+}
@@ -0,0 +1,265 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/pod-security-admission/admission"
+	"k8s.io/pod-security-admission/admission/api/load"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/metrics"
+	"k8s.io/pod-security-admission/policy"
+	"k8s.io/pod-security-admission/webhook"
+	utilpointer "k8s.io/utils/pointer"
+)
+
+// testRecorder records the decisions, nondeterministic decisions and result cache lookups of a handler.
+type testRecorder struct {
+	lock             sync.Mutex
+	decisions        []metrics.Decision
+	nondeterministic int
+	cacheLookups     []bool
+}
+
+func (r *testRecorder) RecordEvaluation(decision metrics.Decision, _ api.LevelVersion, _ metrics.Mode, _ api.Attributes) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.decisions = append(r.decisions, decision)
+}
+
+func (r *testRecorder) RecordExemption(api.Attributes)   {}
+func (r *testRecorder) RecordError(bool, api.Attributes) {}
+
+func (r *testRecorder) RecordNondeterministicDecision(api.LevelVersion) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.nondeterministic++
+}
+
+func (r *testRecorder) RecordResultCacheLookup(hit bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.cacheLookups = append(r.cacheLookups, hit)
+}
+
+// newTestHandlerConfig returns the handlerConfig of a handler with the default configuration,
+// whose client serves a namespace enforcing the baseline policy, and the objects.
+func newTestHandlerConfig(t *testing.T, objects ...runtime.Object) (handlerConfig, *testRecorder) {
+	t.Helper()
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	objects = append(objects, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "test-ns",
+		Labels: map[string]string{api.EnforceLevelLabel: string(api.LevelBaseline)},
+	}})
+	recorder := &testRecorder{}
+	return handlerConfig{
+		Admission: admission.Admission{
+			Configuration: config,
+			Metrics:       recorder,
+		},
+		Client: fake.NewSimpleClientset(objects...),
+	}, recorder
+}
+
+// podCreateRequest returns the AdmissionRequest creating the pod in the test-ns namespace.
+func podCreateRequest(t *testing.T, pod *corev1.Pod) *admissionv1.AdmissionRequest {
+	t.Helper()
+	pod.Namespace = "test-ns"
+	raw, err := json.Marshal(pod)
+	require.NoError(t, err)
+	return &admissionv1.AdmissionRequest{
+		UID:       "test-uid",
+		Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		Resource:  metav1.GroupVersionResource{Version: "v1", Resource: "pods"},
+		Namespace: "test-ns",
+		Name:      pod.Name,
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: raw},
+	}
+}
+
+// serveReview sends an AdmissionReview of the request to the handler, and returns its response.
+func serveReview(t *testing.T, h http.Handler, request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	t.Helper()
+	body, err := json.Marshal(&admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: admissionv1.SchemeGroupVersion.String(), Kind: "AdmissionReview"},
+		Request:  request,
+	})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	review := &admissionv1.AdmissionReview{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), review))
+	require.NotNil(t, review.Response)
+	assert.Equal(t, request.UID, review.Response.UID)
+	return review.Response
+}
+
+func TestDeterminismGuardEvaluatorOptions(t *testing.T) {
+	unblocked := make(chan struct{})
+	defer close(unblocked)
+	slowCheck := policy.Check{
+		ID:    "example.com/slow",
+		Level: api.LevelBaseline,
+		Versions: []policy.VersionedCheck{{
+			MinimumVersion: api.MajorMinorVersion(1, 0),
+			CheckPod: func(_ *metav1.ObjectMeta, _ *corev1.PodSpec, _ ...policy.Option) policy.CheckResult {
+				select {
+				case <-unblocked:
+				case <-time.After(time.Second):
+				}
+				return policy.CheckResult{Allowed: true}
+			},
+		}},
+	}
+	checks := append(policy.DefaultChecks(), slowCheck)
+
+	c, recorder := newTestHandlerConfig(t)
+	c.CheckDeadline = 10 * time.Millisecond
+	c.ResultCacheSize = 10
+	evaluator, err := c.newEvaluator(checks)
+	require.NoError(t, err)
+	c.Evaluator = evaluator
+	c.DeterminismGuard = newDeterminismGuard(1, c, checks)
+	h, err := newHandler(c)
+	require.NoError(t, err)
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pod"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app"}}},
+	}
+	for i := 0; i < 2; i++ {
+		response := serveReview(t, h, podCreateRequest(t, pod))
+		assert.False(t, response.Allowed, "the slow check should exceed the deadline")
+		assert.Equal(t, int32(http.StatusInternalServerError), response.Result.Code)
+	}
+	assert.Equal(t, 0, recorder.nondeterministic, "the guard should evaluate pods with the deadline of the evaluator")
+	assert.Len(t, recorder.cacheLookups, 2, "the guard should bypass the result cache")
+}
+
+func TestNewHandlerEvaluatorOptions(t *testing.T) {
+	privilegedPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pod"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "app", Image: "app", SecurityContext: &corev1.SecurityContext{Privileged: utilpointer.Bool(true)},
+		}}},
+	}
+	windowsPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pod"},
+		Spec: corev1.PodSpec{
+			OS:         &corev1.PodOS{Name: corev1.Windows},
+			Containers: []corev1.Container{{Name: "app", Image: "app"}},
+		},
+	}
+	evaluate := func(h *webhook.Handler, level api.Level, pod *corev1.Pod) []policy.CheckResult {
+		return h.Evaluator.EvaluatePod(api.LevelVersion{Level: level, Version: api.LatestVersion()}, &pod.ObjectMeta, &pod.Spec)
+	}
+	privilegedErrors := func(h *webhook.Handler) *field.ErrorList {
+		for _, result := range evaluate(h, api.LevelBaseline, privilegedPod) {
+			if result.ID == "privileged" {
+				return result.ErrList
+			}
+		}
+		t.Fatal("privileged check not evaluated")
+		return nil
+	}
+
+	testCases := []struct {
+		name        string
+		config      func(*handlerConfig)
+		expectError string
+		check       func(t *testing.T, h *webhook.Handler, recorder *testRecorder)
+	}{{
+		name: "no result cache",
+		check: func(t *testing.T, h *webhook.Handler, recorder *testRecorder) {
+			serveReview(t, h, podCreateRequest(t, privilegedPod.DeepCopy()))
+			assert.Empty(t, recorder.cacheLookups)
+		},
+	}, {
+		name:   "result cache",
+		config: func(c *handlerConfig) { c.ResultCacheSize = 10 },
+		check: func(t *testing.T, h *webhook.Handler, recorder *testRecorder) {
+			for i := 0; i < 2; i++ {
+				assert.False(t, serveReview(t, h, podCreateRequest(t, privilegedPod.DeepCopy())).Allowed)
+			}
+			assert.Equal(t, []bool{false, true}, recorder.cacheLookups)
+		},
+	}, {
+		name:   "negative check deadline",
+		config: func(c *handlerConfig) { c.CheckDeadline = -time.Second },
+		// the deadline is validated by the evaluator
+		expectError: "check deadline must not be negative",
+	}, {
+		name: "no field errors",
+		check: func(t *testing.T, h *webhook.Handler, _ *testRecorder) {
+			assert.Nil(t, privilegedErrors(h))
+		},
+	}, {
+		name:   "field errors",
+		config: func(c *handlerConfig) { c.AuditViolationsDetail = true },
+		check: func(t *testing.T, h *webhook.Handler, _ *testRecorder) {
+			assert.NotNil(t, privilegedErrors(h))
+		},
+	}, {
+		name: "default windows pod mode",
+		check: func(t *testing.T, h *webhook.Handler, _ *testRecorder) {
+			assert.True(t, policy.AggregateCheckResults(evaluate(h, api.LevelRestricted, windowsPod)).Allowed)
+		},
+	}, {
+		name:   "enforced windows pod mode",
+		config: func(c *handlerConfig) { c.WindowsPodMode = policy.WindowsPodModeEnforce },
+		check: func(t *testing.T, h *webhook.Handler, _ *testRecorder) {
+			assert.False(t, policy.AggregateCheckResults(evaluate(h, api.LevelRestricted, windowsPod)).Allowed)
+		},
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, recorder := newTestHandlerConfig(t)
+			if tc.config != nil {
+				tc.config(&c)
+			}
+			h, err := newHandler(c)
+			if tc.expectError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectError)
+				return
+			}
+			require.NoError(t, err)
+			tc.check(t, h, recorder)
+		})
+	}
+}
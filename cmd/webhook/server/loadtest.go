@@ -0,0 +1,302 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/pod-security-admission/cmd/webhook/server/options"
+	"k8s.io/utils/pointer"
+)
+
+// newLoadTestCommand creates the loadtest subcommand, sending synthetic AdmissionReview traffic to a running webhook.
+func newLoadTestCommand() *cobra.Command {
+	opts := options.NewLoadTestOptions()
+
+	cmd := &cobra.Command{
+		Use:   "loadtest",
+		Short: "Send synthetic AdmissionReview traffic to a running webhook",
+		Long: `Send synthetic pod creation AdmissionReviews to a running webhook, with
+configurable pod sizes, violation ratio and concurrency, and print the latency
+percentiles and error rate of the responses. Useful to size the webhook replicas
+before enforcing policies cluster-wide.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runLoadTest(cmd.Context(), opts, cmd.OutOrStdout())
+		},
+		Args: cobra.NoArgs,
+	}
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+// loadTestResult is the outcome of a single AdmissionReview sent by the loadtest subcommand.
+type loadTestResult struct {
+	latency time.Duration
+	allowed bool
+	err     error
+}
+
+func runLoadTest(ctx context.Context, opts *options.LoadTestOptions, out io.Writer) error {
+	if errs := opts.Validate(); len(errs) > 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+	client, err := loadTestClient(opts)
+	if err != nil {
+		return err
+	}
+
+	// Encode the reviews upfront, so the measured latency only covers the requests.
+	reviews := make([][]byte, opts.Requests)
+	for i := range reviews {
+		if reviews[i], err = syntheticReview(opts, i, violatingRequest(i, opts.ViolationRatio)); err != nil {
+			return err
+		}
+	}
+
+	var (
+		indexes = make(chan int)
+		results = make([]loadTestResult, opts.Requests)
+		wg      sync.WaitGroup
+	)
+	start := time.Now()
+	for w := 0; w < opts.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = sendReview(ctx, client, opts, reviews[i])
+			}
+		}()
+	}
+	sent := 0
+dispatch:
+	for ; sent < opts.Requests; sent++ {
+		select {
+		case indexes <- sent:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	writeLoadTestReport(out, results[:sent], time.Since(start))
+	return ctx.Err()
+}
+
+// violatingRequest returns true if the i-th request should violate the policies,
+// spreading the violating requests evenly according to the ratio.
+func violatingRequest(i int, ratio float64) bool {
+	return math.Floor(float64(i+1)*ratio) > math.Floor(float64(i)*ratio)
+}
+
+// loadTestClient returns an HTTP client verifying the webhook serving certificate as configured by the options.
+func loadTestClient(opts *options.LoadTestOptions) (*http.Client, error) {
+	tlsConfig, err := webhookTLSConfig(opts.CAFile, opts.InsecureSkipTLSVerify)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	transport.MaxIdleConnsPerHost = opts.Concurrency
+	return &http.Client{Transport: transport, Timeout: opts.Timeout}, nil
+}
+
+// webhookTLSConfig returns the TLS configuration verifying the webhook serving certificate with the CA bundle
+// at caFile, or the system roots if empty.
+func webhookTLSConfig(caFile string, insecureSkipVerify bool) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: insecureSkipVerify}
+	if caFile != "" {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+	}
+	return tlsConfig, nil
+}
+
+// sendReview sends the encoded AdmissionReview to the webhook, and decodes whether the response allowed it.
+func sendReview(ctx context.Context, client *http.Client, opts *options.LoadTestOptions, review []byte) loadTestResult {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, opts.URL, bytes.NewReader(review))
+	if err != nil {
+		return loadTestResult{err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return loadTestResult{latency: time.Since(start), err: err}
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	result := loadTestResult{latency: time.Since(start)}
+	if err != nil {
+		result.err = err
+		return result
+	}
+	if resp.StatusCode != http.StatusOK {
+		result.err = fmt.Errorf("unexpected status %d", resp.StatusCode)
+		return result
+	}
+	var response admissionv1.AdmissionReview
+	if err := json.Unmarshal(body, &response); err != nil {
+		result.err = err
+		return result
+	}
+	if response.Response == nil {
+		result.err = fmt.Errorf("missing response")
+		return result
+	}
+	result.allowed = response.Response.Allowed
+	return result
+}
+
+// syntheticReview returns the encoded AdmissionReview creating the i-th synthetic pod.
+func syntheticReview(opts *options.LoadTestOptions, i int, violating bool) ([]byte, error) {
+	pod := syntheticPod(opts, i, violating)
+	raw, err := json.Marshal(pod)
+	if err != nil {
+		return nil, err
+	}
+	uid := types.UID(fmt.Sprintf("podsecurity-loadtest-%d", i))
+	return json.Marshal(&admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: admissionv1.SchemeGroupVersion.String(), Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       uid,
+			Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+			Resource:  metav1.GroupVersionResource{Version: "v1", Resource: "pods"},
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+			Operation: admissionv1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	})
+}
+
+// syntheticPod returns a pod of the configured size, satisfying the restricted policy unless violating,
+// in which case its containers are privileged.
+func syntheticPod(opts *options.LoadTestOptions, i int, violating bool) *corev1.Pod {
+	pod := &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("podsecurity-loadtest-%d", i),
+			Namespace: opts.Namespace,
+		},
+		Spec: corev1.PodSpec{
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot:   pointer.Bool(true),
+				SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+			},
+		},
+	}
+	for c := 0; c < opts.Containers; c++ {
+		container := corev1.Container{
+			Name:  fmt.Sprintf("container-%d", c),
+			Image: "registry.k8s.io/pause",
+			SecurityContext: &corev1.SecurityContext{
+				AllowPrivilegeEscalation: pointer.Bool(false),
+				Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+			},
+		}
+		for e := 0; e < opts.EnvVars; e++ {
+			container.Env = append(container.Env, corev1.EnvVar{Name: fmt.Sprintf("VAR_%d", e), Value: fmt.Sprintf("value-%d", e)})
+		}
+		if violating {
+			container.SecurityContext.Privileged = pointer.Bool(true)
+		}
+		pod.Spec.Containers = append(pod.Spec.Containers, container)
+	}
+	return pod
+}
+
+// writeLoadTestReport writes the throughput, outcomes, error rate and latency percentiles of the results.
+func writeLoadTestReport(out io.Writer, results []loadTestResult, elapsed time.Duration) {
+	var (
+		allowed, denied, errored int
+		latencies                []time.Duration
+		firstErr                 error
+	)
+	for _, r := range results {
+		switch {
+		case r.err != nil:
+			errored++
+			if firstErr == nil {
+				firstErr = r.err
+			}
+		case r.allowed:
+			allowed++
+		default:
+			denied++
+		}
+		if r.latency > 0 {
+			latencies = append(latencies, r.latency)
+		}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	fmt.Fprintf(out, "Requests:   %d in %s (%.1f/s)\n", len(results), elapsed.Round(time.Millisecond), float64(len(results))/elapsed.Seconds())
+	fmt.Fprintf(out, "Allowed:    %d\n", allowed)
+	fmt.Fprintf(out, "Denied:     %d\n", denied)
+	errorRate := 0.0
+	if len(results) > 0 {
+		errorRate = 100 * float64(errored) / float64(len(results))
+	}
+	fmt.Fprintf(out, "Errors:     %d (%.2f%%)\n", errored, errorRate)
+	if firstErr != nil {
+		fmt.Fprintf(out, "First error: %v\n", firstErr)
+	}
+	if len(latencies) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "Latency:    p50 %s, p90 %s, p99 %s, max %s\n",
+		percentile(latencies, 0.50), percentile(latencies, 0.90), percentile(latencies, 0.99), percentile(latencies, 1))
+}
+
+// percentile returns the nearest-rank percentile p of the sorted, non-empty latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i].Round(time.Microsecond)
+}
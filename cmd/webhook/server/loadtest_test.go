@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/pod-security-admission/cmd/webhook/server/options"
+)
+
+func TestRunLoadTest(t *testing.T) {
+	c, _ := newTestHandlerConfig(t)
+	h, err := newHandler(c)
+	require.NoError(t, err)
+	var requests atomic.Int32
+	webhook := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		h.ServeHTTP(w, r)
+	}))
+	defer webhook.Close()
+
+	opts := options.NewLoadTestOptions()
+	opts.URL = webhook.URL
+	opts.InsecureSkipTLSVerify = true
+	opts.Namespace = "test-ns"
+	opts.Requests = 20
+	opts.Concurrency = 4
+	opts.Containers = 2
+	opts.EnvVars = 3
+	opts.ViolationRatio = 0.25
+	var out bytes.Buffer
+	require.NoError(t, runLoadTest(context.Background(), opts, &out))
+
+	assert.Equal(t, int32(20), requests.Load())
+	assert.Contains(t, out.String(), "Requests:   20 in ")
+	assert.Contains(t, out.String(), "Allowed:    15\nDenied:     5\nErrors:     0 (0.00%)\nLatency:    p50 ")
+}
+
+func TestWriteLoadTestReport(t *testing.T) {
+	var results []loadTestResult
+	for i := 1; i <= 10; i++ {
+		results = append(results, loadTestResult{latency: time.Duration(i) * time.Millisecond, allowed: i%2 == 0})
+	}
+	results = append(results,
+		loadTestResult{latency: 20 * time.Millisecond, err: errors.New("unexpected status 500")},
+		loadTestResult{err: errors.New("connection refused")},
+	)
+
+	var out bytes.Buffer
+	writeLoadTestReport(&out, results, 2*time.Second)
+	assert.Equal(t, `Requests:   12 in 2s (6.0/s)
+Allowed:    5
+Denied:     5
+Errors:     2 (16.67%)
+First error: unexpected status 500
+Latency:    p50 6ms, p90 10ms, p99 20ms, max 20ms
+`, out.String())
+
+	out.Reset()
+	writeLoadTestReport(&out, nil, time.Second)
+	assert.Equal(t, "Requests:   0 in 1s (0.0/s)\nAllowed:    0\nDenied:     0\nErrors:     0 (0.00%)\n", out.String())
+}
+
+func TestViolatingRequest(t *testing.T) {
+	for _, ratio := range []float64{0, 0.1, 0.25, 1} {
+		violating := 0
+		for i := 0; i < 100; i++ {
+			if violatingRequest(i, ratio) {
+				violating++
+			}
+		}
+		assert.Equal(t, int(ratio*100), violating, "ratio %v", ratio)
+	}
+}
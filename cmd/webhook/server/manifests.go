@@ -0,0 +1,488 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	admissionapi "k8s.io/pod-security-admission/admission/api"
+	podsecurityconfigloader "k8s.io/pod-security-admission/admission/api/load"
+	"k8s.io/pod-security-admission/admission/api/validation"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/cmd/webhook/server/options"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+)
+
+// newManifestsCommand creates the manifests subcommand, generating the manifests deploying the webhook.
+func newManifestsCommand() *cobra.Command {
+	opts := options.NewManifestsOptions()
+
+	cmd := &cobra.Command{
+		Use:   "manifests",
+		Short: "Generate the manifests deploying the webhook with a PodSecurity configuration",
+		Long: `Generate the manifests deploying the webhook with the given PodSecurity
+configuration, as a kustomize directory or a Helm chart: the namespace, the
+ConfigMap of the configuration, RBAC, the Deployment and Service of the webhook
+server, and the ValidatingWebhookConfiguration. Unless --match-conditions=false,
+the webhooks are not called for pod and pod controller requests exempt by the exact
+usernames and namespaces of the configuration.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runManifests(opts, cmd.OutOrStdout())
+		},
+		Args: cobra.NoArgs,
+	}
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+const (
+	webhookName         = "pod-security-webhook"
+	webhookServiceName  = "webhook"
+	webhookConfigName   = "pod-security-webhook.kubernetes.io"
+	advisoryWebhookName = "advisory.pod-security-webhook.kubernetes.io"
+	webhookConfigFile   = "podsecurityconfiguration.yaml"
+	webhookPort         = 10250
+)
+
+// manifestFile is a file of the generated manifests, relative to the output directory.
+type manifestFile struct {
+	name string
+	data []byte
+}
+
+// manifestParams parameterize the generated manifests. The helm format sets them to template expressions.
+type manifestParams struct {
+	namespace string
+	image     string
+	// caBundle is the CA bundle of the webhooks, base64 encoded, left unset if empty.
+	caBundle string
+}
+
+func runManifests(opts *options.ManifestsOptions, out io.Writer) error {
+	if errs := opts.Validate(); len(errs) > 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+
+	configData, err := os.ReadFile(opts.Config)
+	if err != nil {
+		return err
+	}
+	config, err := podsecurityconfigloader.LoadFromData(configData)
+	if err != nil {
+		return err
+	}
+	if errs := validation.ValidatePodSecurityConfiguration(config); len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errs.ToAggregate())
+	}
+	var matchConditions []admissionregistrationv1.MatchCondition
+	if opts.MatchConditions {
+		matchConditions = exemptionMatchConditions(config.Exemptions)
+	}
+
+	var files []manifestFile
+	switch opts.Format {
+	case options.ManifestsFormatHelm:
+		files, err = helmChart(string(configData), opts.Image, matchConditions)
+	default:
+		files, err = kustomizeManifests(string(configData), opts.Namespace, opts.Image, matchConditions)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		file := filepath.Join(opts.OutputDir, f.name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, f.data, 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "wrote %s\n", file)
+	}
+	return nil
+}
+
+// exemptionMatchConditions returns the CEL match conditions skipping the webhooks for pod and pod controller requests
+// exempt by the exact usernames and namespaces of the exemptions. Exemption patterns are left to the webhook server.
+// Namespace requests are always sent to the webhook, since exemptions do not apply to the validation of their labels.
+func exemptionMatchConditions(exemptions admissionapi.PodSecurityExemptions) []admissionregistrationv1.MatchCondition {
+	var conditions []admissionregistrationv1.MatchCondition
+	for _, exemption := range []struct {
+		name       string
+		attribute  string
+		exemptions []string
+	}{
+		{"exempt-namespaces", "request.namespace", exemptions.Namespaces},
+		{"exempt-usernames", "request.userInfo.username", exemptions.Usernames},
+	} {
+		var values []string
+		for _, value := range exemption.exemptions {
+			if !admissionapi.IsExemptionPattern(value) {
+				values = append(values, strconv.Quote(value))
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		conditions = append(conditions, admissionregistrationv1.MatchCondition{
+			Name:       exemption.name,
+			Expression: fmt.Sprintf("request.resource.resource == 'namespaces' || !(%s in [%s])", exemption.attribute, strings.Join(values, ", ")),
+		})
+	}
+	return conditions
+}
+
+// kustomizeManifests returns a kustomize directory mirroring the webhook directory of this repository:
+// the manifests are in the manifests base, and the top-level kustomization generates the serving certificate
+// Secret from the pki directory and injects its CA into the webhooks.
+func kustomizeManifests(configData, namespace, image string, matchConditions []admissionregistrationv1.MatchCondition) ([]manifestFile, error) {
+	params := manifestParams{namespace: namespace, image: image}
+	objects := append([]runtime.Object{webhookNamespace(params)}, webhookObjects(params, configData, matchConditions)...)
+
+	var files []manifestFile
+	var resources []string
+	for i, obj := range objects {
+		data, err := marshalManifest(obj, params)
+		if err != nil {
+			return nil, err
+		}
+		name := manifestFileName(i, obj)
+		files = append(files, manifestFile{name: filepath.Join("manifests", name), data: data})
+		resources = append(resources, name)
+	}
+
+	base, err := yaml.Marshal(map[string]interface{}{"resources": resources})
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, manifestFile{name: filepath.Join("manifests", "kustomization.yaml"), data: base})
+
+	kustomization, err := yaml.Marshal(map[string]interface{}{
+		"resources": []string{"./manifests"},
+		// generated from the serving certificate, which can be created with `make certs` in the webhook directory
+		"secretGenerator": []interface{}{map[string]interface{}{
+			"name":      webhookName,
+			"namespace": namespace,
+			"type":      string(corev1.SecretTypeTLS),
+			"options":   map[string]interface{}{"disableNameSuffixHash": true},
+			"files":     []string{"pki/ca.crt", "pki/tls.crt", "pki/tls.key"},
+		}},
+		"replacements": []interface{}{map[string]interface{}{
+			"source": map[string]interface{}{
+				"kind":      "Secret",
+				"name":      webhookName,
+				"namespace": namespace,
+				"fieldPath": `data.ca\.crt`,
+			},
+			"targets": []interface{}{map[string]interface{}{
+				"select": map[string]interface{}{
+					"kind": "ValidatingWebhookConfiguration",
+					"name": webhookConfigName,
+				},
+				"fieldPaths": []string{"webhooks.0.clientConfig.caBundle", "webhooks.1.clientConfig.caBundle"},
+				"options":    map[string]interface{}{"create": true},
+			}},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(files, manifestFile{name: "kustomization.yaml", data: kustomization}), nil
+}
+
+// helmChart returns a Helm chart deploying the webhook to the release namespace, which is expected to exist.
+// The serving certificate Secret is expected to be created separately, e.g. by cert-manager, and its CA set in
+// the caBundle value.
+func helmChart(configData, image string, matchConditions []admissionregistrationv1.MatchCondition) ([]manifestFile, error) {
+	if strings.Contains(configData, "{{") {
+		return nil, fmt.Errorf("the configuration must not contain template actions ({{) to be included in a Helm chart")
+	}
+	params := manifestParams{
+		namespace: "{{ .Release.Namespace }}",
+		image:     "{{ .Values.image }}",
+		caBundle:  "{{ .Values.caBundle }}",
+	}
+
+	appVersion := api.GetAPIVersion().String()
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		appVersion = image[i+1:]
+	}
+	chart, err := yaml.Marshal(map[string]interface{}{
+		"apiVersion":  "v2",
+		"name":        webhookName,
+		"description": "The PodSecurity admission webhook",
+		"type":        "application",
+		"version":     "0.1.0",
+		"appVersion":  appVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+	values := fmt.Sprintf(`# Image of the webhook server.
+image: %s
+# Base64-encoded CA bundle of the serving certificate of the webhook, stored in the
+# %s Secret of the release namespace as tls.crt and tls.key.
+caBundle: ""
+`, strconv.Quote(image), webhookName)
+	files := []manifestFile{
+		{name: "Chart.yaml", data: chart},
+		{name: "values.yaml", data: []byte(values)},
+	}
+
+	for i, obj := range webhookObjects(params, configData, matchConditions) {
+		data, err := marshalManifest(obj, params)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, manifestFile{name: filepath.Join("templates", manifestFileName(i+1, obj)), data: data})
+	}
+	return files, nil
+}
+
+// manifestFileName returns the file name of the i-th manifest, prefixed to keep the order of the objects,
+// as in the webhook directory of this repository.
+func manifestFileName(i int, obj runtime.Object) string {
+	return fmt.Sprintf("%02d-%s.yaml", (i+1)*10, strings.ToLower(obj.GetObjectKind().GroupVersionKind().Kind))
+}
+
+// marshalManifest marshals the object to YAML, omitting unset metadata and status.
+func marshalManifest(obj runtime.Object, params manifestParams) ([]byte, error) {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	unstructured.RemoveNestedField(u, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(u, "spec", "template", "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(u, "status")
+	if webhooks, ok := u["webhooks"].([]interface{}); ok && params.caBundle != "" {
+		for _, webhook := range webhooks {
+			if err := unstructured.SetNestedField(webhook.(map[string]interface{}), params.caBundle, "clientConfig", "caBundle"); err != nil {
+				return nil, err
+			}
+		}
+	}
+	data, err := yaml.Marshal(u)
+	if err != nil {
+		return nil, err
+	}
+	return append(bytes.TrimSpace(data), '\n'), nil
+}
+
+func webhookNamespace(params manifestParams) *corev1.Namespace {
+	return &corev1.Namespace{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: params.namespace,
+			// the webhook excludes its own namespace to avoid a circular dependency,
+			// but the pod spec of its deployment is compatible with the restricted level
+			Labels: map[string]string{api.EnforceLevelLabel: string(api.LevelRestricted)},
+		},
+	}
+}
+
+// webhookObjects returns the objects deploying the webhook server to the namespace, in the order they are applied.
+func webhookObjects(params manifestParams, configData string, matchConditions []admissionregistrationv1.MatchCondition) []runtime.Object {
+	meta := metav1.ObjectMeta{Name: webhookName, Namespace: params.namespace}
+	labels := map[string]string{"app": webhookName}
+
+	configMap := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: meta,
+		Data:       map[string]string{webhookConfigFile: configData},
+	}
+	serviceAccount := &corev1.ServiceAccount{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+		ObjectMeta: meta,
+	}
+	resourceQuota := &corev1.ResourceQuota{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ResourceQuota"},
+		ObjectMeta: meta,
+		Spec: corev1.ResourceQuotaSpec{
+			Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("3")},
+			ScopeSelector: &corev1.ScopeSelector{MatchExpressions: []corev1.ScopedResourceSelectorRequirement{{
+				ScopeName: corev1.ResourceQuotaScopePriorityClass,
+				Operator:  corev1.ScopeSelectorOpIn,
+				Values:    []string{"system-cluster-critical"},
+			}}},
+		},
+	}
+	clusterRole := &rbacv1.ClusterRole{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+		ObjectMeta: metav1.ObjectMeta{Name: webhookName},
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups: []string{""},
+			Resources: []string{"pods", "namespaces"},
+			Verbs:     []string{"get", "watch", "list"},
+		}},
+	}
+	clusterRoleBinding := &rbacv1.ClusterRoleBinding{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding"},
+		ObjectMeta: metav1.ObjectMeta{Name: webhookName},
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: webhookName, Namespace: params.namespace}},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: webhookName},
+	}
+	deployment := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: webhookName, Namespace: params.namespace, Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       webhookPodSpec(params),
+			},
+		},
+	}
+	service := &corev1.Service{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: metav1.ObjectMeta{Name: webhookServiceName, Namespace: params.namespace, Labels: labels},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{
+				Name:       "https",
+				Port:       443,
+				TargetPort: intstr.FromString("webhook"),
+				Protocol:   corev1.ProtocolTCP,
+			}},
+			Selector: labels,
+		},
+	}
+
+	return []runtime.Object{
+		configMap,
+		serviceAccount,
+		resourceQuota,
+		clusterRole,
+		clusterRoleBinding,
+		deployment,
+		service,
+		webhookConfiguration(params, matchConditions),
+	}
+}
+
+func webhookPodSpec(params manifestParams) corev1.PodSpec {
+	return corev1.PodSpec{
+		ServiceAccountName: webhookName,
+		PriorityClassName:  "system-cluster-critical",
+		NodeSelector:       map[string]string{corev1.LabelOSStable: "linux"},
+		Volumes: []corev1.Volume{
+			{Name: "config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: webhookName},
+			}}},
+			{Name: "pki", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: webhookName}}},
+		},
+		Containers: []corev1.Container{{
+			Name:                     webhookName,
+			Image:                    params.image,
+			TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+			// a port > 1024 avoids needing low port bind privileges
+			Ports: []corev1.ContainerPort{{Name: "webhook", ContainerPort: webhookPort}},
+			Args: []string{
+				"--config", "/etc/config/" + webhookConfigFile,
+				"--tls-cert-file", "/etc/pki/tls.crt",
+				"--tls-private-key-file", "/etc/pki/tls.key",
+				"--secure-port", strconv.Itoa(webhookPort),
+			},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+				Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+			},
+			SecurityContext: &corev1.SecurityContext{
+				AllowPrivilegeEscalation: ptr.To(false),
+				Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+				RunAsNonRoot:             ptr.To(true),
+				RunAsUser:                ptr.To[int64](1000),
+				SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+			},
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: "config", MountPath: "/etc/config", ReadOnly: true},
+				{Name: "pki", MountPath: "/etc/pki", ReadOnly: true},
+			},
+		}},
+	}
+}
+
+// webhookConfiguration returns the configuration of the enforcing webhook of pods and namespaces,
+// and of the advisory webhook of pod controllers, which fails open.
+func webhookConfiguration(params manifestParams, matchConditions []admissionregistrationv1.MatchCondition) *admissionregistrationv1.ValidatingWebhookConfiguration {
+	webhook := func(name string, failurePolicy admissionregistrationv1.FailurePolicyType, rules ...admissionregistrationv1.RuleWithOperations) admissionregistrationv1.ValidatingWebhook {
+		return admissionregistrationv1.ValidatingWebhook{
+			Name:          name,
+			FailurePolicy: ptr.To(failurePolicy),
+			NamespaceSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+				// exempt the webhook itself to avoid a circular dependency
+				Key:      corev1.LabelMetadataName,
+				Operator: metav1.LabelSelectorOpNotIn,
+				Values:   []string{params.namespace},
+			}}},
+			Rules:           rules,
+			MatchConditions: matchConditions,
+			ClientConfig: admissionregistrationv1.WebhookClientConfig{
+				Service: &admissionregistrationv1.ServiceReference{Namespace: params.namespace, Name: webhookServiceName},
+			},
+			AdmissionReviewVersions: []string{"v1"},
+			SideEffects:             ptr.To(admissionregistrationv1.SideEffectClassNone),
+			TimeoutSeconds:          ptr.To[int32](5),
+		}
+	}
+	rule := func(group string, resources ...string) admissionregistrationv1.RuleWithOperations {
+		return admissionregistrationv1.RuleWithOperations{
+			Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update},
+			Rule: admissionregistrationv1.Rule{
+				APIGroups:   []string{group},
+				APIVersions: []string{"v1"},
+				Resources:   resources,
+			},
+		}
+	}
+
+	return &admissionregistrationv1.ValidatingWebhookConfiguration{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "admissionregistration.k8s.io/v1", Kind: "ValidatingWebhookConfiguration"},
+		ObjectMeta: metav1.ObjectMeta{Name: webhookConfigName},
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{
+			// fail-closed admission webhooks can present operational challenges,
+			// a failure policy of Ignore should be weighed against the security tradeoffs
+			webhook(webhookConfigName, admissionregistrationv1.Fail,
+				rule("", "namespaces", "pods", "pods/ephemeralcontainers"),
+			),
+			webhook(advisoryWebhookName, admissionregistrationv1.Ignore,
+				rule("", "podtemplates", "replicationcontrollers"),
+				rule("apps", "daemonsets", "deployments", "replicasets", "statefulsets"),
+				rule("batch", "cronjobs", "jobs"),
+			),
+		},
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/pod-security-admission/cmd/webhook/server/options"
+)
+
+const updateManifestsEnvVar = "UPDATE_WEBHOOK_MANIFESTS_DATA"
+
+// TestManifests ensures the manifests generated for the configuration in testdata/manifests
+// match the expected kustomize directory and Helm chart. They can be updated by running:
+//
+//	UPDATE_WEBHOOK_MANIFESTS_DATA=true go test k8s.io/pod-security-admission/cmd/webhook/server -run TestManifests
+func TestManifests(t *testing.T) {
+	for _, format := range []string{options.ManifestsFormatKustomize, options.ManifestsFormatHelm} {
+		t.Run(format, func(t *testing.T) {
+			opts := options.NewManifestsOptions()
+			opts.Config = filepath.Join("testdata", "manifests", "podsecurityconfiguration.yaml")
+			opts.OutputDir = t.TempDir()
+			opts.Format = format
+			opts.Image = "registry.example.com/pod-security-webhook:v1.0.0"
+			var out bytes.Buffer
+			require.NoError(t, runManifests(opts, &out))
+
+			expectedDir := filepath.Join("testdata", "manifests", format)
+			generated := manifestFiles(t, opts.OutputDir)
+			expected := manifestFiles(t, expectedDir)
+			for _, name := range sets.List(sets.KeySet(generated)) {
+				require.Contains(t, out.String(), filepath.Join(opts.OutputDir, name), "the written files should be reported")
+				testManifestFile(t, filepath.Join(expectedDir, name), generated[name], expected[name])
+			}
+			for _, name := range sets.List(sets.KeySet(expected).Difference(sets.KeySet(generated))) {
+				t.Errorf("unexpected extra manifest %s", filepath.Join(expectedDir, name))
+				if os.Getenv(updateManifestsEnvVar) == "true" {
+					os.Remove(filepath.Join(expectedDir, name))
+					t.Logf("Removed extra manifest %s", filepath.Join(expectedDir, name))
+				}
+			}
+		})
+	}
+}
+
+// manifestFiles returns the content of the files in the directory, by path relative to the directory.
+func manifestFiles(t *testing.T, dir string) map[string]string {
+	files := map[string]string{}
+	err := filepath.Walk(dir, func(path string, f os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if f.IsDir() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[name] = string(data)
+		return nil
+	})
+	require.NoError(t, err)
+	return files
+}
+
+func testManifestFile(t *testing.T, filename, generated, expected string) {
+	if generated == expected {
+		return
+	}
+	t.Errorf("generated manifest does not match the expected manifest in %s", filename)
+	if os.Getenv(updateManifestsEnvVar) == "true" {
+		if err := os.MkdirAll(filepath.Dir(filename), os.FileMode(0755)); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(generated), os.FileMode(0644)); err == nil {
+			t.Logf("Updated data in %s", filename)
+			t.Logf("Verify the diff, commit changes, and rerun the tests")
+		} else {
+			t.Logf("Could not update data in %s: %v", filename, err)
+		}
+	} else {
+		t.Logf("Diff between generated and expected manifest in %s:\n-------------\n%s", filename, cmp.Diff(generated, expected))
+		t.Logf("If the change is expected, re-run with %s=true to update the manifests", updateManifestsEnvVar)
+	}
+}
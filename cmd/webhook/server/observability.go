@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/cmd/webhook/server/options"
+	"k8s.io/pod-security-admission/metrics"
+)
+
+// newObservabilityCommand creates the observability subcommand, writing a Grafana dashboard and Prometheus alerts.
+func newObservabilityCommand() *cobra.Command {
+	opts := options.NewObservabilityOptions()
+
+	cmd := &cobra.Command{
+		Use:   "observability",
+		Short: "Write a Grafana dashboard and Prometheus alerts for the webhook metrics",
+		Long: `Write a Grafana dashboard (dashboard.json) and Prometheus alerting rules (alerts.yaml)
+generated from the metrics exposed by the webhook server, so that their names and
+labels always match the running version.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runObservability(opts, cmd.OutOrStdout())
+		},
+		Args: cobra.NoArgs,
+	}
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func runObservability(opts *options.ObservabilityOptions, out io.Writer) error {
+	if errs := opts.Validate(); len(errs) > 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+
+	recorder := metrics.NewPrometheusRecorder(api.GetAPIVersion())
+	if opts.MetricsCheckViolations {
+		recorder.EnableCheckViolations()
+	}
+	descriptors := recorder.Descriptors()
+	dashboard, err := metrics.GrafanaDashboard(descriptors)
+	if err != nil {
+		return err
+	}
+	alerts, err := metrics.AlertRules(descriptors)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		return err
+	}
+	for _, f := range []struct {
+		name string
+		data []byte
+	}{
+		{"dashboard.json", append(dashboard, '\n')},
+		{"alerts.yaml", alerts},
+	} {
+		file := filepath.Join(opts.OutputDir, f.name)
+		if err := os.WriteFile(file, f.data, 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "wrote %s\n", file)
+	}
+	return nil
+}
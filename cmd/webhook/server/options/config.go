@@ -0,0 +1,62 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+// ConfigOptions has the params needed to render the effective PodSecurity configuration merged from overlays.
+type ConfigOptions struct {
+	// Config is the file path to the cluster-wide PodSecurity configuration file, the first merged layer.
+	Config string
+	// Overlays are the file paths to the PodSecurity configuration files merged over Config, in order.
+	Overlays []string
+	// NamespaceFile is the file path to a Namespace whose effective policy is rendered, if set.
+	NamespaceFile string
+	// LenientLabelParsing mirrors the corresponding Option of the webhook server.
+	LenientLabelParsing bool
+	// NamespaceCheckExemptions mirrors the corresponding Option of the webhook server.
+	NamespaceCheckExemptions bool
+}
+
+func NewConfigOptions() *ConfigOptions {
+	return &ConfigOptions{}
+}
+
+func (o *ConfigOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Config, "config", o.Config, "The path to the cluster-wide PodSecurity configuration file. Leave empty to use the default configuration.")
+	fs.StringArrayVar(&o.Overlays, "overlay", o.Overlays, "The path to a PodSecurity configuration file merged over the configuration, e.g. of a team. May be repeated; later overlays take precedence.")
+	fs.StringVar(&o.NamespaceFile, "namespace-file", o.NamespaceFile, "Path to a Namespace, as JSON or YAML, whose effective policy is rendered from its labels and the merged configuration.")
+	fs.BoolVar(&o.LenientLabelParsing, "lenient-label-parsing", o.LenientLabelParsing, "Accept namespace level and version labels differing only in case or surrounding whitespace from a valid value.")
+	fs.BoolVar(&o.NamespaceCheckExemptions, "namespace-check-exemptions", o.NamespaceCheckExemptions, "Exempt pods from the checks listed in the pod-security.kubernetes.io/exempt-checks annotation of their namespace.")
+}
+
+// Validate validates all the required options.
+func (o *ConfigOptions) Validate() []error {
+	var errs []error
+
+	for _, overlay := range o.Overlays {
+		if overlay == "" {
+			errs = append(errs, fmt.Errorf("--overlay must not be empty"))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+)
+
+const (
+	DryRunFormatText  = "text"
+	DryRunFormatJSON  = "json"
+	DryRunFormatSARIF = "sarif"
+
+	DefaultDryRunParallelism = 4
+)
+
+// DryRunOptions has the params needed to report the namespaces whose pods violate tightened enforce levels.
+type DryRunOptions struct {
+	// Config is the file path to the PodSecurity configuration file, whose defaults and exemptions are applied.
+	Config string
+	// Kubeconfig is the file path to the KubeConfig file of the evaluated cluster.
+	Kubeconfig string
+
+	// Levels are the level & versions the namespaces are evaluated against, as level or level:version.
+	Levels []string
+	// NamespaceSelector is the label selector of the evaluated namespaces.
+	NamespaceSelector string
+	// Format is the format of the report, one of text, json or sarif.
+	Format string
+	// Parallelism is the number of pods of a namespace evaluated concurrently.
+	Parallelism int
+
+	// The options below mirror the corresponding Options of the webhook server.
+	WindowsPodMode           string
+	NamespaceCheckExemptions bool
+}
+
+func NewDryRunOptions() *DryRunOptions {
+	return &DryRunOptions{
+		Levels:      []string{string(api.LevelRestricted)},
+		Format:      DryRunFormatText,
+		Parallelism: DefaultDryRunParallelism,
+	}
+}
+
+func (o *DryRunOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Config, "config", o.Config, "The path to the PodSecurity configuration file. Leave empty to use the default configuration.")
+	fs.StringVar(&o.Kubeconfig, "kubeconfig", o.Kubeconfig, "The path to the KubeConfig file of the evaluated cluster. Leave empty to use the in-cluster configuration.")
+	fs.StringSliceVar(&o.Levels, "levels", o.Levels, "Comma-separated enforce levels to evaluate the namespaces against, as level or level:version, e.g. baseline,restricted:v1.29. The version defaults to latest.")
+	fs.StringVar(&o.NamespaceSelector, "namespace-selector", o.NamespaceSelector, "Label selector of the evaluated namespaces. Leave empty to evaluate all namespaces.")
+	fs.StringVarP(&o.Format, "output", "o", o.Format, "Format of the report. One of text, json, sarif.")
+	fs.IntVar(&o.Parallelism, "parallelism", o.Parallelism, "Number of pods of a namespace evaluated concurrently.")
+	fs.StringVar(&o.WindowsPodMode, "windows-pod-mode", o.WindowsPodMode, "Evaluation of pods with spec.os.name=windows by the restricted checks of Linux-only fields. One of Default, Skip, Warn, Enforce.")
+	fs.BoolVar(&o.NamespaceCheckExemptions, "namespace-check-exemptions", o.NamespaceCheckExemptions, "Exempt pods from the checks listed in the pod-security.kubernetes.io/exempt-checks annotation of their namespace.")
+}
+
+// Validate validates all the required options.
+func (o *DryRunOptions) Validate() []error {
+	var errs []error
+
+	if len(o.Levels) == 0 {
+		errs = append(errs, fmt.Errorf("--levels is required"))
+	}
+	for _, level := range o.Levels {
+		if _, err := ParseLevelVersion(level); err != nil {
+			errs = append(errs, fmt.Errorf("--levels: %w", err))
+		}
+	}
+	if _, err := labels.Parse(o.NamespaceSelector); err != nil {
+		errs = append(errs, fmt.Errorf("--namespace-selector: %w", err))
+	}
+	switch o.Format {
+	case DryRunFormatText, DryRunFormatJSON, DryRunFormatSARIF:
+	default:
+		errs = append(errs, fmt.Errorf("--output must be one of %s, %s, %s", DryRunFormatText, DryRunFormatJSON, DryRunFormatSARIF))
+	}
+	if o.Parallelism < 1 {
+		errs = append(errs, fmt.Errorf("--parallelism must be positive"))
+	}
+	if _, err := policy.ParseWindowsPodMode(o.WindowsPodMode); err != nil {
+		errs = append(errs, fmt.Errorf("--windows-pod-mode: %w", err))
+	}
+
+	return errs
+}
+
+// ParseLevelVersion parses a level & version formatted as level or level:version, e.g. restricted:v1.29.
+// The version defaults to latest. The privileged level is not allowed, since it enforces nothing.
+func ParseLevelVersion(value string) (api.LevelVersion, error) {
+	levelValue, versionValue, found := strings.Cut(value, ":")
+	level, err := api.ParseLevel(levelValue)
+	if err != nil {
+		return api.LevelVersion{}, fmt.Errorf("%q: %w", value, err)
+	}
+	if level == api.LevelPrivileged {
+		return api.LevelVersion{}, fmt.Errorf("%q: level must be baseline or restricted", value)
+	}
+	version := api.LatestVersion()
+	if found {
+		if version, err = api.ParseVersion(versionValue); err != nil {
+			return api.LevelVersion{}, fmt.Errorf("%q: %w", value, err)
+		}
+	}
+	return api.LevelVersion{Level: level, Version: version}, nil
+}
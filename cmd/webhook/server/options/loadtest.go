@@ -0,0 +1,112 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	DefaultLoadTestRequests    = 1000
+	DefaultLoadTestConcurrency = 10
+	DefaultLoadTestContainers  = 1
+	DefaultLoadTestTimeout     = 10 * time.Second
+)
+
+// LoadTestOptions has the params needed to send synthetic AdmissionReview traffic to a running webhook.
+type LoadTestOptions struct {
+	// URL is the URL of the webhook endpoint the AdmissionReviews are sent to.
+	URL string
+	// CAFile is the file path to the CA bundle verifying the serving certificate of the webhook, if set.
+	CAFile string
+	// InsecureSkipTLSVerify disables the verification of the serving certificate of the webhook.
+	InsecureSkipTLSVerify bool
+
+	// Namespace is the namespace of the synthetic pods, whose policy the webhook evaluates them against.
+	Namespace string
+	// Requests is the total number of AdmissionReviews sent.
+	Requests int
+	// Concurrency is the number of AdmissionReviews sent in parallel.
+	Concurrency int
+	// Timeout bounds each request.
+	Timeout time.Duration
+
+	// Containers is the number of containers of each synthetic pod.
+	Containers int
+	// EnvVars is the number of environment variables of each container, to inflate the size of the pods.
+	EnvVars int
+	// ViolationRatio is the fraction of synthetic pods violating the baseline and restricted policies.
+	ViolationRatio float64
+}
+
+func NewLoadTestOptions() *LoadTestOptions {
+	return &LoadTestOptions{
+		Namespace:   "default",
+		Requests:    DefaultLoadTestRequests,
+		Concurrency: DefaultLoadTestConcurrency,
+		Timeout:     DefaultLoadTestTimeout,
+		Containers:  DefaultLoadTestContainers,
+	}
+}
+
+func (o *LoadTestOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.URL, "url", o.URL, "URL of the webhook endpoint, e.g. https://podsecurity-webhook.podsecurity-webhook.svc/.")
+	fs.StringVar(&o.CAFile, "ca-file", o.CAFile, "Path to the CA bundle verifying the serving certificate of the webhook. Leave empty to use the system roots.")
+	fs.BoolVar(&o.InsecureSkipTLSVerify, "insecure-skip-tls-verify", o.InsecureSkipTLSVerify, "Skip the verification of the serving certificate of the webhook.")
+	fs.StringVar(&o.Namespace, "namespace", o.Namespace, "Namespace of the synthetic pods, whose policy labels the webhook evaluates them against.")
+	fs.IntVar(&o.Requests, "requests", o.Requests, "Total number of AdmissionReviews to send.")
+	fs.IntVar(&o.Concurrency, "concurrency", o.Concurrency, "Number of AdmissionReviews to send in parallel.")
+	fs.DurationVar(&o.Timeout, "timeout", o.Timeout, "Timeout of each request, counted as an error when exceeded.")
+	fs.IntVar(&o.Containers, "containers", o.Containers, "Number of containers of each synthetic pod.")
+	fs.IntVar(&o.EnvVars, "env-vars", o.EnvVars, "Number of environment variables of each container, to inflate the size of the synthetic pods.")
+	fs.Float64Var(&o.ViolationRatio, "violation-ratio", o.ViolationRatio, "Fraction of synthetic pods violating the baseline and restricted policies, between 0 and 1. The other pods satisfy the restricted policy.")
+}
+
+// Validate validates all the required options.
+func (o *LoadTestOptions) Validate() []error {
+	var errs []error
+
+	if o.URL == "" {
+		errs = append(errs, fmt.Errorf("--url is required"))
+	}
+	if o.Namespace == "" {
+		errs = append(errs, fmt.Errorf("--namespace is required"))
+	}
+	if o.Requests < 1 {
+		errs = append(errs, fmt.Errorf("--requests must be positive"))
+	}
+	if o.Concurrency < 1 {
+		errs = append(errs, fmt.Errorf("--concurrency must be positive"))
+	}
+	if o.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("--timeout must be positive"))
+	}
+	if o.Containers < 1 {
+		errs = append(errs, fmt.Errorf("--containers must be positive"))
+	}
+	if o.EnvVars < 0 {
+		errs = append(errs, fmt.Errorf("--env-vars must not be negative"))
+	}
+	if o.ViolationRatio < 0 || o.ViolationRatio > 1 {
+		errs = append(errs, fmt.Errorf("--violation-ratio must be between 0 and 1"))
+	}
+
+	return errs
+}
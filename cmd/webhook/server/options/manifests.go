@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	ManifestsFormatKustomize = "kustomize"
+	ManifestsFormatHelm      = "helm"
+
+	DefaultManifestsNamespace = "pod-security-webhook"
+	DefaultManifestsImage     = "registry.k8s.io/sig-auth/pod-security-webhook:v1.25.0"
+)
+
+// ManifestsOptions has the params needed to generate the manifests deploying the webhook with a PodSecurity configuration.
+type ManifestsOptions struct {
+	// Config is the file path to the PodSecurity configuration file deployed with the webhook.
+	Config string
+	// OutputDir is the directory the manifests are written to.
+	OutputDir string
+	// Format is the format of the manifests, one of kustomize or helm.
+	Format string
+	// Namespace is the namespace the webhook is deployed to. Helm charts are deployed to the release namespace instead.
+	Namespace string
+	// Image is the image of the webhook server.
+	Image string
+	// MatchConditions skips calling the webhook for requests exempt by the exact usernames and namespaces
+	// of the configuration, using CEL match conditions of the webhook configuration.
+	MatchConditions bool
+}
+
+func NewManifestsOptions() *ManifestsOptions {
+	return &ManifestsOptions{
+		Format:          ManifestsFormatKustomize,
+		Namespace:       DefaultManifestsNamespace,
+		Image:           DefaultManifestsImage,
+		MatchConditions: true,
+	}
+}
+
+func (o *ManifestsOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Config, "config", o.Config, "The path to the PodSecurity configuration file deployed with the webhook.")
+	fs.StringVar(&o.OutputDir, "output-dir", o.OutputDir, "Directory the manifests are written to.")
+	fs.StringVar(&o.Format, "format", o.Format, "Format of the manifests. One of kustomize, helm.")
+	fs.StringVar(&o.Namespace, "namespace", o.Namespace, "Namespace the webhook is deployed to. Ignored by the helm format, which deploys to the release namespace.")
+	fs.StringVar(&o.Image, "image", o.Image, "Image of the webhook server.")
+	fs.BoolVar(&o.MatchConditions, "match-conditions", o.MatchConditions, "Skip calling the webhook for pod and pod controller requests exempt by the exact usernames and namespaces of the configuration, using match conditions of the webhook configuration. Requires Kubernetes v1.28 or later.")
+}
+
+// Validate validates all the required options.
+func (o *ManifestsOptions) Validate() []error {
+	var errs []error
+
+	if o.Config == "" {
+		errs = append(errs, fmt.Errorf("--config is required"))
+	}
+	if o.OutputDir == "" {
+		errs = append(errs, fmt.Errorf("--output-dir is required"))
+	}
+	switch o.Format {
+	case ManifestsFormatKustomize, ManifestsFormatHelm:
+	default:
+		errs = append(errs, fmt.Errorf("--format must be one of %s, %s", ManifestsFormatKustomize, ManifestsFormatHelm))
+	}
+	if msgs := validation.IsDNS1123Label(o.Namespace); len(msgs) > 0 {
+		errs = append(errs, fmt.Errorf("--namespace: %s", strings.Join(msgs, ", ")))
+	}
+	if o.Image == "" {
+		errs = append(errs, fmt.Errorf("--image is required"))
+	}
+
+	return errs
+}
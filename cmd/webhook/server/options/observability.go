@@ -0,0 +1,51 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+// ObservabilityOptions has the params needed to generate the Grafana dashboard and Prometheus alerts of the metrics.
+type ObservabilityOptions struct {
+	// OutputDir is the directory the dashboard and alerts are written to.
+	OutputDir string
+	// MetricsCheckViolations mirrors the corresponding Option of the webhook server.
+	MetricsCheckViolations bool
+}
+
+func NewObservabilityOptions() *ObservabilityOptions {
+	return &ObservabilityOptions{}
+}
+
+func (o *ObservabilityOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.OutputDir, "output-dir", o.OutputDir, "Directory the dashboard.json and alerts.yaml files are written to.")
+	fs.BoolVar(&o.MetricsCheckViolations, "metrics-check-violations", o.MetricsCheckViolations, "Include the pod_security_check_violations_total metric, if exposed by the webhook server.")
+}
+
+// Validate validates all the required options.
+func (o *ObservabilityOptions) Validate() []error {
+	var errs []error
+
+	if o.OutputDir == "" {
+		errs = append(errs, fmt.Errorf("--output-dir is required"))
+	}
+
+	return errs
+}
@@ -17,9 +17,15 @@ limitations under the License.
 package options
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/spf13/pflag"
 
 	apiserveroptions "k8s.io/apiserver/pkg/server/options"
+	"k8s.io/pod-security-admission/admission"
+	"k8s.io/pod-security-admission/policy"
 )
 
 const (
@@ -27,6 +33,8 @@ const (
 	DefaultInsecurePort   = 8080
 	DefaultClientQPSLimit = 20
 	DefaultClientQPSBurst = 50
+
+	DefaultReplayCorpusSampleRate = 0.01
 )
 
 // Options has all the params needed to run a PodSecurity webhook.
@@ -36,10 +44,112 @@ type Options struct {
 
 	// Config is the file path to the PodSecurity configuration file.
 	Config string
+	// ConfigReloadInterval is the interval the Config file is checked for changes at, if non-zero.
+	ConfigReloadInterval time.Duration
 
 	ClientQPSLimit float32
 	ClientQPSBurst int
 
+	// WarnUnevaluatedFields enables warnings for securityContext fields not evaluated by the policy checks.
+	WarnUnevaluatedFields bool
+
+	// WarnVersionSkew enables warnings for audit and warn versions newer or older than the enforce version.
+	WarnVersionSkew bool
+
+	// WarnDeprecatedFields enables warnings for deprecated fields set in admitted pods.
+	WarnDeprecatedFields bool
+
+	// NamespaceRolloutGuard is the handling of namespace label updates that tighten the enforce level unsafely.
+	NamespaceRolloutGuard string
+
+	// NamespaceEvaluation configures the existing pods checked when a namespace enforce level is tightened.
+	NamespaceEvaluation admission.NamespaceEvaluationOptions
+	// NamespaceWarnings configures the warnings about existing pods violating a tightened namespace enforce level.
+	// Its Order is set from NamespaceWarningOrder.
+	NamespaceWarnings admission.NamespaceWarningOptions
+	// NamespaceWarningOrder is the order of the warnings about existing pods violating a tightened namespace enforce level.
+	NamespaceWarningOrder string
+
+	// ReplayCorpusDir is the directory sanitized violating pods are recorded to, if set.
+	ReplayCorpusDir string
+	// ReplayCorpusSampleRate is the fraction of violating pods recorded to ReplayCorpusDir.
+	ReplayCorpusSampleRate float64
+
+	// DeterminismGuardSampleRate is the fraction of pod evaluations re-evaluated to detect nondeterministic decisions.
+	DeterminismGuardSampleRate float64
+
+	// AuditViolationsDetail records the audit violations of pods as versioned JSON, with their offending containers and fields.
+	AuditViolationsDetail bool
+
+	// EnforcementAction is the handling of pods violating the enforce policy of their namespace.
+	EnforcementAction string
+	// ShortCircuitEnforce stops the enforce evaluation of pods at the first violated check.
+	ShortCircuitEnforce bool
+	// DenialSnippets are the IDs of the checks whose enforce denials append a minimal compliant snippet.
+	DenialSnippets []string
+
+	// EnforceFailurePolicy is the handling of pod requests that cannot be evaluated.
+	EnforceFailurePolicy string
+	// AuditFailurePolicy is the handling of pod controller requests that cannot be evaluated.
+	// If empty, the requests are admitted without a warning.
+	AuditFailurePolicy string
+	// EvaluationTimeout bounds the evaluation of pod and pod controller requests, if non-zero.
+	EvaluationTimeout time.Duration
+
+	// EnforceCheckErrorPolicy is the handling of pods that some checks failed to evaluate against the enforce policy.
+	// Defaults to EnforceFailurePolicy if empty.
+	EnforceCheckErrorPolicy string
+	// AuditCheckErrorPolicy is the handling of pods that some checks failed to evaluate against the audit policy.
+	AuditCheckErrorPolicy string
+	// WarnCheckErrorPolicy is the handling of pods that some checks failed to evaluate against the warn policy.
+	WarnCheckErrorPolicy string
+
+	// NamespaceLookupRetries is the number of times a failed namespace lookup is retried.
+	NamespaceLookupRetries int
+	// NamespaceLookupRetryInterval is the delay before the first retry of a failed namespace lookup.
+	NamespaceLookupRetryInterval time.Duration
+	// NamespaceLookupFailureAction is the handling of requests whose namespace cannot be fetched.
+	NamespaceLookupFailureAction string
+
+	// LenientLabelParsing accepts namespace level and version labels differing in case or surrounding whitespace.
+	LenientLabelParsing bool
+	// UnknownLabels is the handling of namespaces with unknown labels under the pod-security.kubernetes.io/ prefix.
+	UnknownLabels string
+
+	// NamespaceCheckExemptions exempts pods from the checks listed in the exempt-checks annotation of their namespace.
+	NamespaceCheckExemptions bool
+
+	// ExemptionUserExtraKeys are the user extra keys whose values are matched against exempt usernames, in addition to the username.
+	ExemptionUserExtraKeys []string
+
+	// WarningLimits caps the number of warnings returned per request.
+	WarningLimits admission.WarningLimits
+
+	// DecisionLedgerFile is the file enforce decisions are recorded to. Leave empty to disable recording.
+	DecisionLedgerFile string
+	// DecisionLedgerDeniedOnly only records Deny decisions to DecisionLedgerFile.
+	DecisionLedgerDeniedOnly bool
+
+	// EvaluateEndpoint serves the /evaluate endpoint, evaluating pods and workloads without an AdmissionReview.
+	EvaluateEndpoint bool
+
+	// MetricsCheckViolations enables the pod_security_check_violations_total metric.
+	MetricsCheckViolations bool
+
+	// CheckOptOutPublicKeysFile is the file of the public keys verifying check opt-out annotations.
+	// Leave empty to ignore check opt-out annotations.
+	CheckOptOutPublicKeysFile string
+
+	// WindowsPodMode is the evaluation of Windows pods by the restricted checks of Linux-only fields.
+	WindowsPodMode string
+	// ResultCacheSize is the number of evaluated pods whose results are cached, or 0 to disable the cache.
+	ResultCacheSize int
+	// CheckDeadline bounds the execution of each check, or 0 to not bound it.
+	CheckDeadline time.Duration
+
+	// SubresourceWarnings is the verbosity of the warnings of scale requests of pod controllers.
+	SubresourceWarnings string
+
 	SecureServing apiserveroptions.SecureServingOptions
 }
 
@@ -50,6 +160,13 @@ func NewOptions() *Options {
 		SecureServing:  *secureServing,
 		ClientQPSLimit: DefaultClientQPSLimit,
 		ClientQPSBurst: DefaultClientQPSBurst,
+
+		ReplayCorpusSampleRate: DefaultReplayCorpusSampleRate,
+
+		EnforceFailurePolicy: string(admission.FailurePolicyFail),
+
+		AuditCheckErrorPolicy: string(admission.FailurePolicyFail),
+		WarnCheckErrorPolicy:  string(admission.FailurePolicyFail),
 	}
 	o.SecureServing.BindPort = DefaultPort
 	return o
@@ -58,8 +175,51 @@ func NewOptions() *Options {
 func (o *Options) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&o.Kubeconfig, "kubeconfig", o.Kubeconfig, "Path to the kubeconfig file specifying how to connect to the API server. Leave empty to use an in-cluster config.")
 	fs.StringVar(&o.Config, "config", o.Config, "The path to the PodSecurity configuration file.")
+	fs.DurationVar(&o.ConfigReloadInterval, "config-reload-interval", o.ConfigReloadInterval, "Interval at which --config is checked for changes, e.g. of a mounted ConfigMap, reloading the changed defaults and exemptions without restarting the webhook. An invalid configuration is not reloaded. 0 disables reloading.")
 	fs.Float32Var(&o.ClientQPSLimit, "client-qps-limit", o.ClientQPSLimit, "Client QPS limit for throttling requests to the API server.")
 	fs.IntVar(&o.ClientQPSBurst, "client-qps-burst", o.ClientQPSBurst, "Client QPS burst limit for throttling requests to the API server.")
+	fs.BoolVar(&o.WarnUnevaluatedFields, "warn-unevaluated-fields", o.WarnUnevaluatedFields, "Warn about securityContext fields set in pods that are not evaluated by any policy version.")
+	fs.BoolVar(&o.WarnVersionSkew, "warn-version-skew", o.WarnVersionSkew, "Warn about namespace audit and warn versions newer than the enforce version, e.g. to stage a version update, or older, which is likely a misconfiguration, on pod and namespace requests.")
+	fs.BoolVar(&o.WarnDeprecatedFields, "warn-deprecated-fields", o.WarnDeprecatedFields, "Warn about deprecated fields set in admitted pods, like the seccomp alpha and AppArmor beta annotations, naming the securityContext fields replacing them.")
+	fs.StringVar(&o.NamespaceRolloutGuard, "namespace-rollout-guard", o.NamespaceRolloutGuard, "Handling of namespace label updates that raise the enforce level by more than one level, or above the previous audit level. One of None, Warn, Deny.")
+	fs.BoolVar(&o.NamespaceEvaluation.SkipCompletedPods, "namespace-evaluation-skip-completed-pods", o.NamespaceEvaluation.SkipCompletedPods, "Skip Succeeded and Failed pods when checking existing pods against a new namespace enforce level.")
+	fs.DurationVar(&o.NamespaceEvaluation.MaxPodAge, "namespace-evaluation-max-pod-age", o.NamespaceEvaluation.MaxPodAge, "Skip pods older than this when checking existing pods against a new namespace enforce level. 0 checks pods of any age.")
+	fs.BoolVar(&o.NamespaceEvaluation.WeightByReplicas, "namespace-evaluation-weight-by-replicas", o.NamespaceEvaluation.WeightByReplicas, "Check a single pod per controller and count violations by the controller's pods when checking existing pods against a new namespace enforce level.")
+	fs.IntVar(&o.NamespaceEvaluation.Parallelism, "namespace-evaluation-parallelism", o.NamespaceEvaluation.Parallelism, "Number of existing pods evaluated concurrently when checking them against a new namespace enforce level. Pods are evaluated serially if 0.")
+	fs.IntVar(&o.NamespaceWarnings.MaxViolations, "namespace-warnings-max-violations", o.NamespaceWarnings.MaxViolations, "Maximum number of distinct violations warned about when checking existing pods against a new namespace enforce level, including a closing warning summarizing the omitted violations. 0 warns about all violations.")
+	fs.IntVar(&o.NamespaceWarnings.MaxPodNames, "namespace-warnings-max-pod-names", o.NamespaceWarnings.MaxPodNames, "Maximum number of pod names listed in the warning of a violation when checking existing pods against a new namespace enforce level. A single pod name is listed if 0.")
+	fs.StringVar(&o.NamespaceWarningOrder, "namespace-warnings-order", o.NamespaceWarningOrder, "Order of the warnings about violations when checking existing pods against a new namespace enforce level. One of PodName, PodCount. PodCount keeps the violations of the most pods first.")
+	fs.StringVar(&o.ReplayCorpusDir, "replay-corpus-dir", o.ReplayCorpusDir, "Directory to record a sample of sanitized violating pods to, laid out as <level>/<version>/fail/<name>.yaml for replay. Leave empty to disable recording.")
+	fs.Float64Var(&o.ReplayCorpusSampleRate, "replay-corpus-sample-rate", o.ReplayCorpusSampleRate, "Fraction of violating pods recorded to --replay-corpus-dir, between 0 and 1.")
+	fs.Float64Var(&o.DeterminismGuardSampleRate, "determinism-guard-sample-rate", o.DeterminismGuardSampleRate, "Fraction of pod evaluations re-evaluated with a freshly constructed evaluator, between 0 and 1, logging and counting the evaluations with a different decision. 0 disables re-evaluation.")
+	fs.StringVar(&o.EnforcementAction, "enforcement-action", o.EnforcementAction, "Handling of pods violating the enforce policy of their namespace. One of Deny, Annotate. Annotate admits violating pods, and annotates them with pod-security.kubernetes.io/violations when the /mutate endpoint is registered as a mutating webhook.")
+	fs.BoolVar(&o.AuditViolationsDetail, "audit-violations-detail", o.AuditViolationsDetail, "Record the audit violations of pods as versioned JSON in the audit-violations-detail audit annotation, with the IDs of the violated checks and their offending containers and fields. Collecting the offending fields adds to the cost of evaluating violating pods.")
+	fs.BoolVar(&o.ShortCircuitEnforce, "short-circuit-enforce", o.ShortCircuitEnforce, "Stop the enforce evaluation of pods at the first violated check, reducing the latency of denied requests. Denied pods are reported with the first violated check only, while audit and warn policies are still evaluated against all the checks. Ignored with --enforcement-action=Annotate.")
+	fs.StringSliceVar(&o.DenialSnippets, "denial-snippets", o.DenialSnippets, "Comma-separated IDs of the checks whose violations append a minimal example of the compliant fields, e.g. the securityContext of the offending containers, to the message of enforce denials. Supported checks: "+strings.Join(snippetCheckIDs(), ", ")+".")
+	fs.StringVar(&o.EnforceFailurePolicy, "enforce-failure-policy", o.EnforceFailurePolicy, "Handling of pod requests that cannot be evaluated because of internal errors or --evaluation-timeout. One of Fail, Ignore. Ignore admits the pod with a warning.")
+	fs.StringVar(&o.EnforceCheckErrorPolicy, "enforce-check-error-policy", o.EnforceCheckErrorPolicy, "Handling of pods that some checks failed to evaluate against the enforce policy, e.g. because they exceeded --check-deadline. One of Fail, Ignore. Fail rejects the pod, and Ignore enforces the other checks and warns about the errors. Defaults to --enforce-failure-policy.")
+	fs.StringVar(&o.AuditCheckErrorPolicy, "audit-check-error-policy", o.AuditCheckErrorPolicy, "Handling of pods that some checks failed to evaluate against the audit policy. One of Fail, Ignore. Fail records the errors in the error audit annotation.")
+	fs.StringVar(&o.WarnCheckErrorPolicy, "warn-check-error-policy", o.WarnCheckErrorPolicy, "Handling of pods that some checks failed to evaluate against the warn policy. One of Fail, Ignore. Fail returns the errors as warnings.")
+	fs.StringVar(&o.AuditFailurePolicy, "audit-failure-policy", o.AuditFailurePolicy, "Handling of pod controller requests, only evaluated against the audit and warn policies, that cannot be evaluated because of internal errors or --evaluation-timeout. One of Fail, Ignore. Ignore admits the request with a warning. If unset, the request is admitted without a warning, only recording the error in the audit annotations.")
+	fs.DurationVar(&o.EvaluationTimeout, "evaluation-timeout", o.EvaluationTimeout, "Maximum duration of the evaluation of pod and pod controller requests, handled by --enforce-failure-policy and --audit-failure-policy when exceeded. 0 disables the timeout.")
+	fs.IntVar(&o.NamespaceLookupRetries, "namespace-lookup-retries", o.NamespaceLookupRetries, "Number of times a failed namespace lookup is retried before applying --namespace-lookup-failure-action. Retries stop early when --evaluation-timeout would be exceeded.")
+	fs.DurationVar(&o.NamespaceLookupRetryInterval, "namespace-lookup-retry-interval", o.NamespaceLookupRetryInterval, "Delay before the first retry of a failed namespace lookup, doubled for each subsequent retry. 0 uses the default of 100ms.")
+	fs.StringVar(&o.NamespaceLookupFailureAction, "namespace-lookup-failure-action", o.NamespaceLookupFailureAction, "Handling of requests whose namespace cannot be fetched. One of FailurePolicy, Defaults, Deny. FailurePolicy applies --enforce-failure-policy and --audit-failure-policy, Defaults evaluates the request against the default policy levels, and Deny rejects the request.")
+	fs.IntVar(&o.WarningLimits.MaxWarnings, "max-warnings", o.WarningLimits.MaxWarnings, "Maximum number of warnings returned per request, including a closing warning replacing the omitted warnings. Warnings about the enforce policy are kept first. 0 returns all warnings.")
+	fs.StringVar(&o.WarningLimits.ReportURL, "warnings-report-url", o.WarningLimits.ReportURL, "URL of the full report of PodSecurity violations, linked from the closing warning when warnings are omitted because of --max-warnings.")
+	fs.StringVar(&o.DecisionLedgerFile, "decision-ledger-file", o.DecisionLedgerFile, "File to record the enforce decisions of evaluated pods to, one JSON record per line, queried from the /debug/decisions endpoint. Leave empty to disable recording.")
+	fs.BoolVar(&o.DecisionLedgerDeniedOnly, "decision-ledger-denied-only", o.DecisionLedgerDeniedOnly, "Only record denied pods to --decision-ledger-file.")
+	fs.BoolVar(&o.EvaluateEndpoint, "evaluate-endpoint", o.EvaluateEndpoint, "Serve the /evaluate endpoint, returning the evaluation of a posted Pod or workload against the level and version query parameters as JSON, e.g. for CI systems. Exemptions and namespace labels are not applied.")
+	fs.BoolVar(&o.MetricsCheckViolations, "metrics-check-violations", o.MetricsCheckViolations, "Expose the pod_security_check_violations_total metric, counting the evaluations violating each check by policy level, version and mode.")
+	fs.StringVar(&o.CheckOptOutPublicKeysFile, "check-opt-out-public-keys-file", o.CheckOptOutPublicKeysFile, "File of PEM-encoded Ed25519 public keys verifying the signed tokens of pod-security.kubernetes.io/check-opt-out pod annotations, which exclude pods from specific checks. Leave empty to ignore the annotation.")
+	fs.StringVar(&o.WindowsPodMode, "windows-pod-mode", o.WindowsPodMode, "Evaluation of pods with spec.os.name=windows by the restricted checks of Linux-only fields (allowPrivilegeEscalation, capabilities, runAsNonRoot, seccompProfile). One of Default, Skip, Warn, Enforce. Default skips the checks starting v1.25, and runAsNonRoot starting v1.30. Warn admits the pod with a warning for each violation.")
+	fs.DurationVar(&o.CheckDeadline, "check-deadline", o.CheckDeadline, "Maximum duration of the evaluation of a pod by each check. Checks exceeding it fail the evaluation, which is handled by --enforce-check-error-policy and recorded in the pod_security_check_errors_total metric. 0 does not bound the checks.")
+	fs.IntVar(&o.ResultCacheSize, "result-cache-size", o.ResultCacheSize, "Number of distinct pods whose evaluation results are cached, so identical pods, like the pods of large ReplicaSets, are evaluated once per policy level and version. 0 disables the cache.")
+	fs.BoolVar(&o.LenientLabelParsing, "lenient-label-parsing", o.LenientLabelParsing, "Accept namespace level and version labels differing only in case or surrounding whitespace from a valid value, like \"Restricted\" or \" v1.30\", and warn about them instead of rejecting them.")
+	fs.StringVar(&o.UnknownLabels, "unknown-labels", o.UnknownLabels, "Handling of namespaces with unknown labels under the pod-security.kubernetes.io/ prefix, like typos of the level and version labels, which are otherwise ignored. One of Ignore, Warn, Deny. Deny rejects namespaces adding unknown labels.")
+	fs.BoolVar(&o.NamespaceCheckExemptions, "namespace-check-exemptions", o.NamespaceCheckExemptions, "Exempt pods from the checks listed in the pod-security.kubernetes.io/exempt-checks annotation of their namespace, as a comma-separated list of check IDs. Checks evaluated at the level of the enforce floor cannot be exempt.")
+	fs.StringVar(&o.SubresourceWarnings, "subresource-warnings", o.SubresourceWarnings, "Warnings of scale requests of deployments, replicasets, statefulsets and replicationcontrollers increasing the replicas, evaluating the pod template of the scaled controller. One of None, Summary, Detailed. Summary returns a single warning naming the violated checks, and Detailed the warnings of a pod template update. Scaled replicasets are attributed to the deployment controlling them.")
+	fs.StringSliceVar(&o.ExemptionUserExtraKeys, "exemption-user-extra-keys", o.ExemptionUserExtraKeys, "Comma-separated user extra keys, like a SPIFFE ID set by the authenticator, whose values are matched against the exempt usernames of the PodSecurity configuration in addition to the username. Only list keys set by a trusted authenticator, since users allowed to impersonate user extras can set any value.")
 
 	o.SecureServing.AddFlags(fs)
 }
@@ -69,6 +229,104 @@ func (o *Options) Validate() []error {
 	var errs []error
 
 	errs = append(errs, o.SecureServing.Validate()...)
+	if o.NamespaceEvaluation.MaxPodAge < 0 {
+		errs = append(errs, fmt.Errorf("--namespace-evaluation-max-pod-age must not be negative"))
+	}
+	if o.NamespaceEvaluation.Parallelism < 0 {
+		errs = append(errs, fmt.Errorf("--namespace-evaluation-parallelism must not be negative"))
+	}
+	if _, err := admission.ParseNamespaceRolloutGuard(o.NamespaceRolloutGuard); err != nil {
+		errs = append(errs, fmt.Errorf("--namespace-rollout-guard: %w", err))
+	}
+	if _, err := admission.ParseUnknownLabelsAction(o.UnknownLabels); err != nil {
+		errs = append(errs, fmt.Errorf("--unknown-labels: %w", err))
+	}
+	if _, err := admission.ParseEnforcementAction(o.EnforcementAction); err != nil {
+		errs = append(errs, fmt.Errorf("--enforcement-action: %w", err))
+	}
+	if _, err := admission.ParseFailurePolicy(o.EnforceFailurePolicy); err != nil {
+		errs = append(errs, fmt.Errorf("--enforce-failure-policy: %w", err))
+	}
+	if o.AuditFailurePolicy != "" {
+		if _, err := admission.ParseFailurePolicy(o.AuditFailurePolicy); err != nil {
+			errs = append(errs, fmt.Errorf("--audit-failure-policy: %w", err))
+		}
+	}
+	if o.EnforceCheckErrorPolicy != "" {
+		if _, err := admission.ParseFailurePolicy(o.EnforceCheckErrorPolicy); err != nil {
+			errs = append(errs, fmt.Errorf("--enforce-check-error-policy: %w", err))
+		}
+	}
+	if _, err := admission.ParseFailurePolicy(o.AuditCheckErrorPolicy); err != nil {
+		errs = append(errs, fmt.Errorf("--audit-check-error-policy: %w", err))
+	}
+	if _, err := admission.ParseFailurePolicy(o.WarnCheckErrorPolicy); err != nil {
+		errs = append(errs, fmt.Errorf("--warn-check-error-policy: %w", err))
+	}
+	if o.ConfigReloadInterval < 0 {
+		errs = append(errs, fmt.Errorf("--config-reload-interval must not be negative"))
+	} else if o.ConfigReloadInterval > 0 && o.Config == "" {
+		errs = append(errs, fmt.Errorf("--config-reload-interval requires --config"))
+	}
+	if o.ResultCacheSize < 0 {
+		errs = append(errs, fmt.Errorf("--result-cache-size must not be negative"))
+	}
+	if o.CheckDeadline < 0 {
+		errs = append(errs, fmt.Errorf("--check-deadline must not be negative"))
+	}
+	if _, err := policy.ParseWindowsPodMode(o.WindowsPodMode); err != nil {
+		errs = append(errs, fmt.Errorf("--windows-pod-mode: %w", err))
+	}
+	if _, err := admission.ParseSubresourceWarnings(o.SubresourceWarnings); err != nil {
+		errs = append(errs, fmt.Errorf("--subresource-warnings: %w", err))
+	}
+	for _, id := range o.DenialSnippets {
+		if _, ok := policy.CheckSnippetFor(policy.CheckID(id)); !ok {
+			errs = append(errs, fmt.Errorf("--denial-snippets: check %q has no snippet", id))
+		}
+	}
+	if o.EvaluationTimeout < 0 {
+		errs = append(errs, fmt.Errorf("--evaluation-timeout must not be negative"))
+	}
+	if o.NamespaceLookupRetries < 0 {
+		errs = append(errs, fmt.Errorf("--namespace-lookup-retries must not be negative"))
+	}
+	if o.NamespaceLookupRetryInterval < 0 {
+		errs = append(errs, fmt.Errorf("--namespace-lookup-retry-interval must not be negative"))
+	}
+	if _, err := admission.ParseNamespaceLookupFailureAction(o.NamespaceLookupFailureAction); err != nil {
+		errs = append(errs, fmt.Errorf("--namespace-lookup-failure-action: %w", err))
+	}
+	if err := o.WarningLimits.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("--max-warnings: %w", err))
+	}
+	if err := o.NamespaceWarnings.Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("--namespace-warnings-max-violations, --namespace-warnings-max-pod-names: %w", err))
+	}
+	if _, err := admission.ParseNamespaceWarningOrder(o.NamespaceWarningOrder); err != nil {
+		errs = append(errs, fmt.Errorf("--namespace-warnings-order: %w", err))
+	}
+	for _, key := range o.ExemptionUserExtraKeys {
+		if key == "" {
+			errs = append(errs, fmt.Errorf("--exemption-user-extra-keys must not contain empty keys"))
+			break
+		}
+	}
+	if o.ReplayCorpusSampleRate < 0 || o.ReplayCorpusSampleRate > 1 {
+		errs = append(errs, fmt.Errorf("--replay-corpus-sample-rate must be between 0 and 1"))
+	}
+	if o.DeterminismGuardSampleRate < 0 || o.DeterminismGuardSampleRate > 1 {
+		errs = append(errs, fmt.Errorf("--determinism-guard-sample-rate must be between 0 and 1"))
+	}
 
 	return errs
 }
+
+// snippetCheckIDs returns the IDs of the checks supported by --denial-snippets.
+func snippetCheckIDs() []string {
+	var ids []string
+	for _, id := range policy.SnippetCheckIDs() {
+		ids = append(ids, string(id))
+	}
+	return ids
+}
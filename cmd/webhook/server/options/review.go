@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"k8s.io/pod-security-admission/admission"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// ReviewOptions has the params needed to replay an AdmissionReview through the webhook handler.
+type ReviewOptions struct {
+	// Filename is the file path to the AdmissionReview to replay, or "-" for stdin.
+	Filename string
+
+	// Config is the file path to the PodSecurity configuration file.
+	Config string
+
+	// NamespaceFile is the file path to the Namespace of the request, if set.
+	// Otherwise the namespace is read from the API server configured by Kubeconfig.
+	NamespaceFile string
+	// Kubeconfig is the file path to the KubeConfig file to use when NamespaceFile is not set.
+	Kubeconfig string
+
+	// The options below mirror the corresponding Options of the webhook server.
+	WarnUnevaluatedFields bool
+	WarnVersionSkew       bool
+	WarnDeprecatedFields  bool
+	EnforcementAction     string
+	LenientLabelParsing   bool
+	WindowsPodMode        string
+	SubresourceWarnings   string
+
+	NamespaceCheckExemptions bool
+	ExemptionUserExtraKeys   []string
+}
+
+func NewReviewOptions() *ReviewOptions {
+	return &ReviewOptions{Filename: "-"}
+}
+
+func (o *ReviewOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVarP(&o.Filename, "filename", "f", o.Filename, "Path to the AdmissionReview JSON or YAML to replay, or - to read it from stdin.")
+	fs.StringVar(&o.Config, "config", o.Config, "The path to the PodSecurity configuration file.")
+	fs.StringVar(&o.NamespaceFile, "namespace-file", o.NamespaceFile, "Path to the Namespace of the request, as JSON or YAML, to replay the request offline. Leave empty to get the namespace from the API server configured by --kubeconfig.")
+	fs.StringVar(&o.Kubeconfig, "kubeconfig", o.Kubeconfig, "Path to the kubeconfig file specifying how to connect to the API server when --namespace-file is not set. Leave empty to use an in-cluster config.")
+	fs.BoolVar(&o.WarnUnevaluatedFields, "warn-unevaluated-fields", o.WarnUnevaluatedFields, "Warn about securityContext fields set in pods that are not evaluated by any policy version.")
+	fs.BoolVar(&o.WarnVersionSkew, "warn-version-skew", o.WarnVersionSkew, "Warn about namespace audit and warn versions newer or older than the enforce version.")
+	fs.BoolVar(&o.WarnDeprecatedFields, "warn-deprecated-fields", o.WarnDeprecatedFields, "Warn about deprecated fields set in admitted pods.")
+	fs.StringVar(&o.EnforcementAction, "enforcement-action", o.EnforcementAction, "Handling of pods violating the enforce policy of their namespace. One of Deny, Annotate.")
+	fs.BoolVar(&o.LenientLabelParsing, "lenient-label-parsing", o.LenientLabelParsing, "Accept namespace level and version labels differing only in case or surrounding whitespace from a valid value.")
+	fs.BoolVar(&o.NamespaceCheckExemptions, "namespace-check-exemptions", o.NamespaceCheckExemptions, "Exempt pods from the checks listed in the pod-security.kubernetes.io/exempt-checks annotation of their namespace.")
+	fs.StringSliceVar(&o.ExemptionUserExtraKeys, "exemption-user-extra-keys", o.ExemptionUserExtraKeys, "Comma-separated user extra keys whose values are matched against the exempt usernames of the PodSecurity configuration in addition to the username.")
+	fs.StringVar(&o.WindowsPodMode, "windows-pod-mode", o.WindowsPodMode, "Evaluation of pods with spec.os.name=windows by the restricted checks of Linux-only fields. One of Default, Skip, Warn, Enforce.")
+	fs.StringVar(&o.SubresourceWarnings, "subresource-warnings", o.SubresourceWarnings, "Warnings of scale requests of pod controllers. One of None, Summary, Detailed. The scaled controller is fetched from the API server, so scale requests are not evaluated with --namespace-file.")
+}
+
+// Validate validates all the required options.
+func (o *ReviewOptions) Validate() []error {
+	var errs []error
+
+	if o.Filename == "" {
+		errs = append(errs, fmt.Errorf("--filename is required"))
+	}
+	if o.Config == "" {
+		errs = append(errs, fmt.Errorf("--config is required"))
+	}
+	if _, err := admission.ParseEnforcementAction(o.EnforcementAction); err != nil {
+		errs = append(errs, fmt.Errorf("--enforcement-action: %w", err))
+	}
+	if _, err := policy.ParseWindowsPodMode(o.WindowsPodMode); err != nil {
+		errs = append(errs, fmt.Errorf("--windows-pod-mode: %w", err))
+	}
+	if _, err := admission.ParseSubresourceWarnings(o.SubresourceWarnings); err != nil {
+		errs = append(errs, fmt.Errorf("--subresource-warnings: %w", err))
+	}
+
+	return errs
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// SchemasOptions has the params needed to generate the JSON Schemas of a policy.
+type SchemasOptions struct {
+	// Level is the policy level the schemas are generated for.
+	Level string
+	// Version is the policy version the schemas are generated for.
+	Version string
+	// OutputDir is the directory the schemas are written to.
+	OutputDir string
+	// WindowsPodMode mirrors the corresponding Option of the webhook server.
+	WindowsPodMode string
+}
+
+func NewSchemasOptions() *SchemasOptions {
+	return &SchemasOptions{
+		Level:   string(api.LevelRestricted),
+		Version: "latest",
+	}
+}
+
+func (o *SchemasOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.Level, "level", o.Level, "Policy level of the schemas. One of baseline, restricted.")
+	fs.StringVar(&o.Version, "version", o.Version, "Policy version of the schemas, e.g. v1.29 or latest.")
+	fs.StringVar(&o.OutputDir, "output-dir", o.OutputDir, "Directory the schemas are written to, named like the standalone schemas of kubeconform, e.g. deployment-apps-v1.json.")
+	fs.StringVar(&o.WindowsPodMode, "windows-pod-mode", o.WindowsPodMode, "Evaluation of pods with spec.os.name=windows by the restricted checks of Linux-only fields. One of Default, Skip, Warn, Enforce.")
+}
+
+// Validate validates all the required options.
+func (o *SchemasOptions) Validate() []error {
+	var errs []error
+
+	if level, err := api.ParseLevel(o.Level); err != nil {
+		errs = append(errs, fmt.Errorf("--level: %w", err))
+	} else if level == api.LevelPrivileged {
+		errs = append(errs, fmt.Errorf("--level must be baseline or restricted"))
+	}
+	if _, err := api.ParseVersion(o.Version); err != nil {
+		errs = append(errs, fmt.Errorf("--version: %w", err))
+	}
+	if o.OutputDir == "" {
+		errs = append(errs, fmt.Errorf("--output-dir is required"))
+	}
+	if _, err := policy.ParseWindowsPodMode(o.WindowsPodMode); err != nil {
+		errs = append(errs, fmt.Errorf("--windows-pod-mode: %w", err))
+	}
+
+	return errs
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	WatchColorAuto   = "auto"
+	WatchColorAlways = "always"
+	WatchColorNever  = "never"
+)
+
+// WatchOptions has the params needed to stream the live enforce decisions of a running webhook.
+type WatchOptions struct {
+	// URL is the base URL of the webhook, serving the decisions from /debug/decisions/watch.
+	URL string
+	// CAFile is the file path to the CA bundle verifying the serving certificate of the webhook, if set.
+	CAFile string
+	// InsecureSkipTLSVerify disables the verification of the serving certificate of the webhook.
+	InsecureSkipTLSVerify bool
+
+	// Namespace, Check and Decision select the streamed decisions, if set.
+	Namespace string
+	Check     string
+	Decision  string
+	// Color is one of auto, always or never. Auto colorizes the decisions when writing to a terminal.
+	Color string
+}
+
+func NewWatchOptions() *WatchOptions {
+	return &WatchOptions{Color: WatchColorAuto}
+}
+
+func (o *WatchOptions) AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&o.URL, "url", o.URL, "Base URL of the webhook recording decisions with --decision-ledger-file, e.g. https://podsecurity-webhook.podsecurity-webhook.svc.")
+	fs.StringVar(&o.CAFile, "ca-file", o.CAFile, "Path to the CA bundle verifying the serving certificate of the webhook. Leave empty to use the system roots.")
+	fs.BoolVar(&o.InsecureSkipTLSVerify, "insecure-skip-tls-verify", o.InsecureSkipTLSVerify, "Skip the verification of the serving certificate of the webhook.")
+	fs.StringVar(&o.Namespace, "namespace", o.Namespace, "Only print the decisions of pods in this namespace.")
+	fs.StringVar(&o.Check, "check", o.Check, "Only print the decisions of pods violating the check with this ID.")
+	fs.StringVar(&o.Decision, "decision", o.Decision, "Only print the decisions of the enforce mode with this outcome, Allow or Deny.")
+	fs.StringVar(&o.Color, "color", o.Color, "Colorize the decisions. One of auto, always, never. Auto colorizes them when writing to a terminal.")
+}
+
+// Validate validates all the required options.
+func (o *WatchOptions) Validate() []error {
+	var errs []error
+
+	if o.URL == "" {
+		errs = append(errs, fmt.Errorf("--url is required"))
+	}
+	switch o.Decision {
+	case "", "Allow", "Deny":
+	default:
+		errs = append(errs, fmt.Errorf("--decision must be one of Allow, Deny"))
+	}
+	switch o.Color {
+	case WatchColorAuto, WatchColorAlways, WatchColorNever:
+	default:
+		errs = append(errs, fmt.Errorf("--color must be one of %s, %s, %s", WatchColorAuto, WatchColorAlways, WatchColorNever))
+	}
+
+	return errs
+}
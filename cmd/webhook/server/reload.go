@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+	admissionapi "k8s.io/pod-security-admission/admission/api"
+	podsecurityconfigloader "k8s.io/pod-security-admission/admission/api/load"
+	"k8s.io/pod-security-admission/metrics"
+	"k8s.io/pod-security-admission/webhook"
+	"k8s.io/utils/clock"
+)
+
+// configReloader polls the PodSecurity configuration file, and replaces the handler of the server with a handler of the
+// reloaded configuration when the content of the file changes, e.g. when a mounted ConfigMap is updated.
+// Configurations that cannot be loaded are logged and recorded, and the previous configuration stays in effect.
+type configReloader struct {
+	path     string
+	interval time.Duration
+	// newHandler returns a handler of the configuration, failing if it is invalid.
+	newHandler func(*admissionapi.PodSecurityConfiguration) (*webhook.Handler, error)
+	// swap replaces the handler of the server.
+	swap func(*webhook.Handler)
+	// recorder is optional.
+	recorder metrics.ConfigReloadRecorder
+	clock    clock.Clock
+
+	// hash is the hash of the content of the file last attempted to be loaded.
+	hash       [sha256.Size]byte
+	generation int64
+}
+
+// newConfigReloader returns a configReloader of the file whose content, with the given hash, was loaded at startup.
+func newConfigReloader(path string, hash [sha256.Size]byte, interval time.Duration, newHandler func(*admissionapi.PodSecurityConfiguration) (*webhook.Handler, error), swap func(*webhook.Handler), recorder metrics.Recorder) *configReloader {
+	r := &configReloader{
+		path:       path,
+		interval:   interval,
+		newHandler: newHandler,
+		swap:       swap,
+		hash:       hash,
+		generation: 1,
+		clock:      clock.RealClock{},
+	}
+	if recorder, ok := recorder.(metrics.ConfigReloadRecorder); ok {
+		r.recorder = recorder
+		recorder.RecordConfigGeneration(r.generation)
+	}
+	return r
+}
+
+// run checks the file for changes every interval until the context is done.
+func (r *configReloader) run(ctx context.Context) {
+	wait.BackoffUntil(func() { r.reload(ctx) }, wait.NewJitteredBackoffManager(r.interval, 0, r.clock), true, ctx.Done())
+}
+
+// reload loads the configuration file if its content changed since the last attempt.
+func (r *configReloader) reload(ctx context.Context) {
+	logger := klog.FromContext(ctx)
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		// the file may be missing while a ConfigMap volume is updated, so it is read again on the next poll
+		logger.V(2).Info("Failed to read PodSecurity configuration", "path", r.path, "err", err)
+		return
+	}
+	hash := sha256.Sum256(data)
+	if hash == r.hash {
+		return
+	}
+	r.hash = hash
+
+	h, err := r.loadHandler(data)
+	if err != nil {
+		logger.Error(err, "Failed to reload PodSecurity configuration, keeping the previous configuration", "path", r.path, "generation", r.generation)
+		r.recordReload(false)
+		return
+	}
+	r.swap(h)
+	r.generation++
+	logger.Info("Reloaded PodSecurity configuration", "path", r.path, "generation", r.generation)
+	r.recordReload(true)
+}
+
+func (r *configReloader) loadHandler(data []byte) (*webhook.Handler, error) {
+	config, err := podsecurityconfigloader.LoadFromData(data)
+	if err != nil {
+		return nil, err
+	}
+	return r.newHandler(config)
+}
+
+func (r *configReloader) recordReload(success bool) {
+	if r.recorder == nil {
+		return
+	}
+	r.recorder.RecordConfigReload(success)
+	r.recorder.RecordConfigGeneration(r.generation)
+}
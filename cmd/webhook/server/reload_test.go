@@ -0,0 +1,153 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionapi "k8s.io/pod-security-admission/admission/api"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/metrics"
+	"k8s.io/pod-security-admission/webhook"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+// configReloadRecorder records the reloads and generations of the configuration.
+type configReloadRecorder struct {
+	testRecorder
+	reloads     []bool
+	generations []int64
+}
+
+func (r *configReloadRecorder) RecordConfigReload(success bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.reloads = append(r.reloads, success)
+}
+
+func (r *configReloadRecorder) RecordConfigGeneration(generation int64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.generations = append(r.generations, generation)
+}
+
+var _ metrics.ConfigReloadRecorder = &configReloadRecorder{}
+
+func testConfig(level api.Level) string {
+	return `
+apiVersion: pod-security.admission.config.k8s.io/v1
+kind: PodSecurityConfiguration
+defaults:
+  enforce: ` + string(level) + `
+`
+}
+
+func TestConfigReloader(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	initial := []byte(testConfig(api.LevelBaseline))
+	require.NoError(t, os.WriteFile(path, initial, 0600))
+
+	var lock sync.Mutex
+	var loaded []*admissionapi.PodSecurityConfiguration
+	var swapped []*webhook.Handler
+	newHandler := func(config *admissionapi.PodSecurityConfiguration) (*webhook.Handler, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		loaded = append(loaded, config)
+		return &webhook.Handler{}, nil
+	}
+	swap := func(h *webhook.Handler) {
+		lock.Lock()
+		defer lock.Unlock()
+		swapped = append(swapped, h)
+	}
+	recorder := &configReloadRecorder{}
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	r := newConfigReloader(path, sha256.Sum256(initial), time.Minute, newHandler, swap, recorder)
+	r.clock = fakeClock
+	go r.run(ctx)
+
+	// poll steps the clock to the next poll, and waits for the reloader to wait for the following one
+	poll := func() {
+		t.Helper()
+		require.Eventually(t, fakeClock.HasWaiters, time.Second, time.Millisecond, "the reloader should wait for the next poll")
+		fakeClock.Step(time.Minute)
+		require.Eventually(t, fakeClock.HasWaiters, time.Second, time.Millisecond, "the reloader should poll the file")
+	}
+	state := func() ([]bool, []int64, int, int) {
+		lock.Lock()
+		defer lock.Unlock()
+		recorder.lock.Lock()
+		defer recorder.lock.Unlock()
+		return append([]bool(nil), recorder.reloads...), append([]int64(nil), recorder.generations...), len(loaded), len(swapped)
+	}
+
+	t.Run("unchanged", func(t *testing.T) {
+		poll()
+		reloads, generations, loads, swaps := state()
+		assert.Empty(t, reloads)
+		assert.Equal(t, []int64{1}, generations, "the generation of the initial configuration should be recorded")
+		assert.Zero(t, loads, "an unchanged file should not be loaded")
+		assert.Zero(t, swaps)
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte(testConfig(api.LevelRestricted)), 0600))
+		poll()
+		reloads, generations, loads, swaps := state()
+		assert.Equal(t, []bool{true}, reloads)
+		assert.Equal(t, []int64{1, 2}, generations)
+		assert.Equal(t, 1, loads)
+		assert.Equal(t, 1, swaps)
+		assert.Equal(t, api.LevelRestricted, api.Level(loaded[0].Defaults.Enforce))
+		assert.Equal(t, int64(2), r.generation)
+
+		poll()
+		_, _, loads, swaps = state()
+		assert.Equal(t, 1, loads, "the reloaded file should not be loaded again")
+		assert.Equal(t, 1, swaps)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("{"), 0600))
+		poll()
+		reloads, generations, loads, swaps := state()
+		assert.Equal(t, []bool{true, false}, reloads, "the failed reload should be recorded")
+		assert.Equal(t, []int64{1, 2, 2}, generations, "the previous configuration should stay in effect")
+		assert.Equal(t, 1, loads)
+		assert.Equal(t, 1, swaps, "the handler should not be swapped")
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		require.NoError(t, os.Remove(path))
+		poll()
+		reloads, _, _, swaps := state()
+		assert.Equal(t, []bool{true, false}, reloads, "a missing file should be read again on the next poll")
+		assert.Equal(t, 1, swaps)
+	})
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	clientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/pod-security-admission/admission"
+	podsecurityconfigloader "k8s.io/pod-security-admission/admission/api/load"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/cmd/webhook/server/options"
+	"k8s.io/pod-security-admission/metrics"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// newReviewCommand creates the review subcommand, replaying an AdmissionReview through the webhook handler.
+func newReviewCommand() *cobra.Command {
+	opts := options.NewReviewOptions()
+
+	cmd := &cobra.Command{
+		Use:   "review",
+		Short: "Replay an AdmissionReview through the webhook handler",
+		Long: `Replay an AdmissionReview, e.g. captured from API server audit or webhook logs,
+through the webhook handler with the given PodSecurity configuration, and print
+the AdmissionReview response. Useful to debug denials offline.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runReview(cmd.Context(), opts, cmd.InOrStdin(), cmd.OutOrStdout())
+		},
+		Args: cobra.NoArgs,
+	}
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func runReview(ctx context.Context, opts *options.ReviewOptions, stdin io.Reader, out io.Writer) error {
+	if errs := opts.Validate(); len(errs) > 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+
+	var (
+		review []byte
+		err    error
+	)
+	if opts.Filename == "-" {
+		review, err = io.ReadAll(stdin)
+	} else {
+		review, err = os.ReadFile(opts.Filename)
+	}
+	if err != nil {
+		return err
+	}
+
+	podSecurityConfig, err := podsecurityconfigloader.LoadFromFile(opts.Config)
+	if err != nil {
+		return err
+	}
+	client, err := reviewClient(opts)
+	if err != nil {
+		return err
+	}
+
+	enforcementAction, _ := admission.ParseEnforcementAction(opts.EnforcementAction)       // validated above
+	windowsPodMode, _ := policy.ParseWindowsPodMode(opts.WindowsPodMode)                   // validated above
+	subresourceWarnings, _ := admission.ParseSubresourceWarnings(opts.SubresourceWarnings) // validated above
+	h, err := newHandler(handlerConfig{
+		Admission: admission.Admission{
+			Configuration:         podSecurityConfig,
+			Metrics:               metrics.NewPrometheusRecorder(api.GetAPIVersion()),
+			WarnUnevaluatedFields: opts.WarnUnevaluatedFields,
+			WarnVersionSkew:       opts.WarnVersionSkew,
+			WarnDeprecatedFields:  opts.WarnDeprecatedFields,
+			EnforcementAction:     enforcementAction,
+			LenientLabelParsing:   opts.LenientLabelParsing,
+			SubresourceWarnings:   subresourceWarnings,
+			IdentityExtractor:     exemptionIdentityExtractor(opts.ExemptionUserExtraKeys),
+
+			NamespaceCheckExemptions: opts.NamespaceCheckExemptions,
+		},
+		WindowsPodMode: windowsPodMode,
+		Client:         client,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Serve the review like the webhook server does, so the request is decoded and evaluated identically.
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(review)).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		return fmt.Errorf("failed to review the request (%d): %s", recorder.Code, strings.TrimSpace(recorder.Body.String()))
+	}
+
+	var response bytes.Buffer
+	if err := json.Indent(&response, recorder.Body.Bytes(), "", "  "); err != nil {
+		return err
+	}
+	_, err = out.Write(response.Bytes())
+	return err
+}
+
+// reviewClient returns a fake client serving the namespace of the NamespaceFile if set,
+// or a client of the API server configured by the Kubeconfig.
+func reviewClient(opts *options.ReviewOptions) (clientset.Interface, error) {
+	if opts.NamespaceFile == "" {
+		kubeConfig, err := clientcmd.BuildConfigFromFlags("", opts.Kubeconfig)
+		if err != nil {
+			return nil, err
+		}
+		return clientset.NewForConfig(kubeConfig)
+	}
+
+	namespace, err := readNamespace(opts.NamespaceFile)
+	if err != nil {
+		return nil, err
+	}
+	return fake.NewSimpleClientset(namespace), nil
+}
+
+// readNamespace reads a Namespace from a JSON or YAML file.
+func readNamespace(file string) (*corev1.Namespace, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	obj, _, err := codecs.UniversalDeserializer().Decode(data, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", file, err)
+	}
+	namespace, ok := obj.(*corev1.Namespace)
+	if !ok {
+		return nil, fmt.Errorf("%s: expected a Namespace, got %T", file, obj)
+	}
+	return namespace, nil
+}
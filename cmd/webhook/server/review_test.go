@@ -0,0 +1,175 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/pod-security-admission/cmd/webhook/server/options"
+	"k8s.io/utils/ptr"
+)
+
+// writeTestFile writes the data to the file in the directory, and returns its path.
+func writeTestFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, data, 0600))
+	return path
+}
+
+func TestRunReview(t *testing.T) {
+	dir := t.TempDir()
+	config := writeTestFile(t, dir, "config.yaml", []byte(testConfig("privileged")))
+	namespaceFile := func(labels string) string {
+		return writeTestFile(t, t.TempDir(), "namespace.yaml", []byte(`
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: test-ns
+  labels: {`+labels+`}
+`))
+	}
+	reviewOf := func(pod *corev1.Pod) []byte {
+		data, err := json.Marshal(&admissionv1.AdmissionReview{
+			TypeMeta: metav1.TypeMeta{APIVersion: admissionv1.SchemeGroupVersion.String(), Kind: "AdmissionReview"},
+			Request:  podCreateRequest(t, pod),
+		})
+		require.NoError(t, err)
+		return data
+	}
+	compliantPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pod"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app"}}},
+	}
+	privilegedPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pod"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:            "app",
+			Image:           "app",
+			SecurityContext: &corev1.SecurityContext{Privileged: ptr.To(true)},
+		}}},
+	}
+
+	testCases := []struct {
+		name string
+		// review is read from the file, or from stdin if stdin is set
+		review            []byte
+		stdin             bool
+		namespaceLabels   string
+		enforcementAction string
+
+		expectErr      string
+		expectAllowed  bool
+		expectMessage  string
+		expectWarnings []string
+	}{{
+		name:            "allowed",
+		review:          reviewOf(compliantPod),
+		namespaceLabels: `pod-security.kubernetes.io/enforce: baseline`,
+		expectAllowed:   true,
+	}, {
+		name:            "denied",
+		review:          reviewOf(privilegedPod),
+		namespaceLabels: `pod-security.kubernetes.io/enforce: baseline`,
+		expectMessage:   `pods "test-pod" is forbidden: violates PodSecurity "baseline:latest" (from namespace label pod-security.kubernetes.io/enforce="baseline", default enforce-version="latest"): privileged (container "app" must not set securityContext.privileged=true)`,
+	}, {
+		name:            "warned",
+		review:          reviewOf(privilegedPod),
+		namespaceLabels: `pod-security.kubernetes.io/warn: baseline`,
+		expectAllowed:   true,
+		expectWarnings:  []string{`would violate PodSecurity "baseline:latest": privileged (container "app" must not set securityContext.privileged=true)`},
+	}, {
+		name:              "annotated",
+		review:            reviewOf(privilegedPod),
+		namespaceLabels:   `pod-security.kubernetes.io/enforce: baseline`,
+		enforcementAction: "Annotate",
+		expectAllowed:     true,
+		expectWarnings:    []string{`admitted with violations of PodSecurity "baseline:latest": privileged (container "app" must not set securityContext.privileged=true)`},
+	}, {
+		name:            "stdin",
+		review:          reviewOf(privilegedPod),
+		stdin:           true,
+		namespaceLabels: `pod-security.kubernetes.io/enforce: baseline`,
+		expectMessage:   `pods "test-pod" is forbidden: violates PodSecurity "baseline:latest" (from namespace label pod-security.kubernetes.io/enforce="baseline", default enforce-version="latest"): privileged (container "app" must not set securityContext.privileged=true)`,
+	}, {
+		name:            "malformed review",
+		review:          []byte(`{"apiVersion": "admission.k8s.io/v1", "kind": "AdmissionReview"`),
+		namespaceLabels: `pod-security.kubernetes.io/enforce: baseline`,
+		expectErr:       "failed to review the request (400): unable to decode the request",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := options.NewReviewOptions()
+			opts.Config = config
+			opts.NamespaceFile = namespaceFile(tc.namespaceLabels)
+			opts.EnforcementAction = tc.enforcementAction
+			var stdin bytes.Buffer
+			if tc.stdin {
+				stdin.Write(tc.review)
+			} else {
+				opts.Filename = writeTestFile(t, t.TempDir(), "review.json", tc.review)
+			}
+
+			var out bytes.Buffer
+			err := runReview(context.Background(), opts, &stdin, &out)
+			if tc.expectErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+
+			review := &admissionv1.AdmissionReview{}
+			require.NoError(t, json.Unmarshal(out.Bytes(), review))
+			require.NotNil(t, review.Response)
+			assert.Equal(t, review.Request.UID, review.Response.UID)
+			assert.Equal(t, tc.expectAllowed, review.Response.Allowed)
+			if tc.expectMessage != "" && assert.NotNil(t, review.Response.Result) {
+				assert.Equal(t, tc.expectMessage, review.Response.Result.Message)
+			}
+			assert.Equal(t, tc.expectWarnings, review.Response.Warnings)
+			assert.True(t, strings.HasPrefix(out.String(), "{\n  "), "the response should be indented")
+		})
+	}
+}
+
+func TestRunReviewInvalidNamespaceFile(t *testing.T) {
+	dir := t.TempDir()
+	opts := options.NewReviewOptions()
+	opts.Config = writeTestFile(t, dir, "config.yaml", []byte(testConfig("privileged")))
+	opts.NamespaceFile = writeTestFile(t, dir, "namespace.yaml", []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test-ns\n"))
+
+	err := runReview(context.Background(), opts, strings.NewReader("{}"), &bytes.Buffer{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected a Namespace")
+
+	opts = options.NewReviewOptions()
+	err = runReview(context.Background(), opts, strings.NewReader("{}"), &bytes.Buffer{})
+	assert.EqualError(t, err, "--config is required")
+}
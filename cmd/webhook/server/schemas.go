@@ -0,0 +1,115 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/cmd/webhook/server/options"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// workloadKindGroupVersions are the API group versions of the kinds supported by policy.WorkloadJSONSchema,
+// naming their schema files.
+var workloadKindGroupVersions = map[string]string{
+	"Pod":                   "v1",
+	"PodTemplate":           "v1",
+	"ReplicationController": "v1",
+	"ReplicaSet":            "apps/v1",
+	"Deployment":            "apps/v1",
+	"DaemonSet":             "apps/v1",
+	"StatefulSet":           "apps/v1",
+	"Job":                   "batch/v1",
+	"CronJob":               "batch/v1",
+}
+
+// newSchemasCommand creates the schemas subcommand, writing the JSON Schemas of a policy.
+func newSchemasCommand() *cobra.Command {
+	opts := options.NewSchemasOptions()
+
+	cmd := &cobra.Command{
+		Use:   "schemas",
+		Short: "Write the JSON Schemas of the pods and workloads satisfying a policy",
+		Long: `Write JSON Schemas of the pods and workloads satisfying a policy level & version,
+generated from the registered checks. Useful to flag violations while authoring
+manifests, in IDEs or with kubeconform-style validators.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runSchemas(opts, cmd.OutOrStdout())
+		},
+		Args: cobra.NoArgs,
+	}
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func runSchemas(opts *options.SchemasOptions, out io.Writer) error {
+	if errs := opts.Validate(); len(errs) > 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+	level, _ := api.ParseLevel(opts.Level)                               // validated above
+	version, _ := api.ParseVersion(opts.Version)                         // validated above
+	windowsPodMode, _ := policy.ParseWindowsPodMode(opts.WindowsPodMode) // validated above
+
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks(), policy.WithWindowsPodMode(windowsPodMode))
+	if err != nil {
+		return err
+	}
+	pod, unsupported := evaluator.(policy.SchemaGenerator).PodJSONSchema(api.LevelVersion{Level: level, Version: version})
+	for _, id := range unsupported {
+		fmt.Fprintf(out, "check %s is not enforced by the schemas\n", id)
+	}
+
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		return err
+	}
+	for _, kind := range policy.WorkloadKinds() {
+		schema, err := policy.WorkloadJSONSchema(kind, pod)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			return err
+		}
+		file := filepath.Join(opts.OutputDir, schemaFilename(kind, workloadKindGroupVersions[kind]))
+		if err := os.WriteFile(file, append(data, '\n'), 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "wrote %s\n", file)
+	}
+	return nil
+}
+
+// schemaFilename returns the file name of the schema of the kind, like the standalone schemas of kubeconform,
+// e.g. deployment-apps-v1.json, or pod-v1.json for the core group.
+func schemaFilename(kind, groupVersion string) string {
+	group, version, found := strings.Cut(groupVersion, "/")
+	if !found {
+		return fmt.Sprintf("%s-%s.json", strings.ToLower(kind), groupVersion)
+	}
+	return fmt.Sprintf("%s-%s-%s.json", strings.ToLower(kind), group, version)
+}
@@ -19,21 +19,21 @@ package server
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
 
-	admissionv1 "k8s.io/api/admission/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
-	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	apiserver "k8s.io/apiserver/pkg/server"
 	"k8s.io/apiserver/pkg/server/healthz"
 	kubeinformers "k8s.io/client-go/informers"
@@ -48,12 +48,12 @@ import (
 	podsecurityconfigloader "k8s.io/pod-security-admission/admission/api/load"
 	"k8s.io/pod-security-admission/api"
 	"k8s.io/pod-security-admission/cmd/webhook/server/options"
+	"k8s.io/pod-security-admission/ledger"
 	"k8s.io/pod-security-admission/metrics"
 	"k8s.io/pod-security-admission/policy"
+	"k8s.io/pod-security-admission/webhook"
 )
 
-const maxRequestSize = int64(3 * 1024 * 1024)
-
 // NewSchedulerCommand creates a *cobra.Command object with default parameters and registryOptions
 func NewServerCommand() *cobra.Command {
 	opts := options.NewOptions()
@@ -74,6 +74,14 @@ Security Standards.`,
 	}
 	opts.AddFlags(cmd.Flags())
 	verflag.AddFlags(cmd.Flags())
+	cmd.AddCommand(newReviewCommand())
+	cmd.AddCommand(newLoadTestCommand())
+	cmd.AddCommand(newSchemasCommand())
+	cmd.AddCommand(newObservabilityCommand())
+	cmd.AddCommand(newDryRunCommand())
+	cmd.AddCommand(newConfigCommand())
+	cmd.AddCommand(newWatchCommand())
+	cmd.AddCommand(newManifestsCommand())
 
 	return cmd
 }
@@ -105,7 +113,15 @@ type Server struct {
 
 	informerFactory kubeinformers.SharedInformerFactory
 
-	delegate *admission.Admission
+	// handler is replaced when the PodSecurity configuration is reloaded.
+	handler atomic.Pointer[webhook.Handler]
+	// reloader is nil unless the PodSecurity configuration is reloaded.
+	reloader *configReloader
+
+	// decisionLedger is nil unless enforce decisions are recorded.
+	decisionLedger *ledger.Broadcaster
+	// evaluateEndpoint serves the /evaluate endpoint.
+	evaluateEndpoint bool
 
 	metricsRegistry compbasemetrics.KubeRegistry
 }
@@ -113,6 +129,9 @@ type Server struct {
 func (s *Server) Start(ctx context.Context) error {
 	s.informerFactory.Start(ctx.Done())
 	logger := klog.FromContext(ctx)
+	if s.reloader != nil {
+		go s.reloader.run(ctx)
+	}
 
 	mux := http.NewServeMux()
 	healthz.InstallHandler(mux, healthz.PingHealthz)
@@ -120,10 +139,20 @@ func (s *Server) Start(ctx context.Context) error {
 	// The webhook is stateless, so it's safe to expose everything on the insecure port for
 	// debugging or proxy purposes. The API server will not connect to an http webhook.
 	mux.HandleFunc("/", s.HandleValidate)
+	mux.HandleFunc("/debug/checks-schema-version", s.HandleChecksSchemaVersion)
+	mux.HandleFunc("/mutate", s.HandleMutate)
+	if s.decisionLedger != nil {
+		mux.Handle("/debug/decisions", ledger.NewHandler(s.decisionLedger))
+		mux.Handle("/debug/decisions/watch", ledger.NewWatchHandler(s.decisionLedger))
+	}
+	if s.evaluateEndpoint {
+		mux.HandleFunc("/evaluate", s.HandleEvaluate)
+	}
 
-	// Serve the metrics.
+	// Serve the metrics, in the OpenMetrics format on request to include the trace exemplars of the denial counters
+	// and of the evaluation latency histogram.
 	mux.Handle("/metrics",
-		compbasemetrics.HandlerFor(s.metricsRegistry, compbasemetrics.HandlerOpts{ErrorHandling: compbasemetrics.ContinueOnError}))
+		promhttp.HandlerFor(s.metricsRegistry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError, EnableOpenMetrics: true}))
 
 	if s.insecureServing != nil {
 		if err := s.insecureServing.Serve(mux, 0, ctx.Done()); err != nil {
@@ -152,79 +181,26 @@ func (s *Server) Start(ctx context.Context) error {
 }
 
 func (s *Server) HandleValidate(w http.ResponseWriter, r *http.Request) {
-	defer utilruntime.HandleCrash(func(_ interface{}) {
-		// Assume the crash happened before the response was written.
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-	})
-
-	var (
-		body   []byte
-		err    error
-		ctx    = r.Context()
-		logger = klog.FromContext(ctx)
-	)
-
-	if timeout, ok, err := parseTimeout(r); err != nil {
-		// Ignore an invalid timeout.
-		logger.V(2).Info("Invalid timeout", "error", err)
-	} else if ok {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, timeout)
-		defer cancel()
-	}
-
-	if r.Body == nil || r.Body == http.NoBody {
-		err = errors.New("request body is empty")
-		logger.Error(err, "bad request")
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	defer r.Body.Close()
-	limitedReader := &io.LimitedReader{R: r.Body, N: maxRequestSize}
-	if body, err = ioutil.ReadAll(limitedReader); err != nil {
-		logger.Error(err, "unable to read the body from the incoming request")
-		http.Error(w, "unable to read the body from the incoming request", http.StatusBadRequest)
-		return
-	}
-	if limitedReader.N <= 0 {
-		logger.Error(err, "unable to read the body from the incoming request; limit reached")
-		http.Error(w, fmt.Sprintf("request entity is too large; limit is %d bytes", maxRequestSize), http.StatusRequestEntityTooLarge)
-		return
-	}
+	s.handler.Load().ServeHTTP(w, r)
+}
 
-	// verify the content type is accurate
-	if contentType := r.Header.Get("Content-Type"); contentType != "application/json" {
-		err = fmt.Errorf("contentType=%s, expected application/json", contentType)
-		logger.Error(err, "unable to process a request with an unknown content type", "type", contentType)
-		http.Error(w, "unable to process a request with a non-json content type", http.StatusBadRequest)
-		return
-	}
+// HandleMutate serves the requests of the mutating webhook paired with the Annotate enforcement action.
+func (s *Server) HandleMutate(w http.ResponseWriter, r *http.Request) {
+	s.handler.Load().Mutating().ServeHTTP(w, r)
+}
 
-	v1AdmissionReviewKind := admissionv1.SchemeGroupVersion.WithKind("AdmissionReview")
-	reviewObject, gvk, err := codecs.UniversalDeserializer().Decode(body, &v1AdmissionReviewKind, nil)
-	if err != nil {
-		logger.Error(err, "unable to decode the request")
-		http.Error(w, "unable to decode the request", http.StatusBadRequest)
-		return
+// HandleChecksSchemaVersion serves the schema version of the evaluated policy checks and their IDs, levels and origins,
+// so operators can verify all replicas enforce identical logic.
+func (s *Server) HandleChecksSchemaVersion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	delegate := s.handler.Load().Admission
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"apiVersion":          api.GetAPIVersion().String(),
+		"checksSchemaVersion": delegate.ChecksSchemaVersion,
+		"checks":              policy.EvaluatorChecks(delegate.Evaluator),
+	}); err != nil {
+		klog.ErrorS(err, "Failed to encode checks schema version")
 	}
-	if *gvk != v1AdmissionReviewKind {
-		logger.Info("Unexpected AdmissionReview kind", "kind", gvk.String())
-		http.Error(w, fmt.Sprintf("unexpected AdmissionReview kind: %s", gvk.String()), http.StatusBadRequest)
-		return
-	}
-	review, ok := reviewObject.(*admissionv1.AdmissionReview)
-	if !ok {
-		logger.Info("Failed admissionv1.AdmissionReview type assertion")
-		http.Error(w, "unexpected AdmissionReview type", http.StatusBadRequest)
-	}
-	logger.V(1).Info("received request", "UID", review.Request.UID, "kind", review.Request.Kind, "resource", review.Request.Resource)
-
-	attributes := api.RequestAttributes(review.Request, codecs.UniversalDeserializer())
-	response := s.delegate.Validate(ctx, attributes)
-	response.UID = review.Request.UID // Response UID must match request UID
-	review.Response = response
-	writeResponse(w, review)
 }
 
 // Config holds the loaded options.Options used to set up the webhook server.
@@ -233,6 +209,55 @@ type Config struct {
 	InsecureServing   *apiserver.DeprecatedInsecureServingInfo
 	KubeConfig        *restclient.Config
 	PodSecurityConfig *admissionapi.PodSecurityConfiguration
+	// PodSecurityConfigFile is the file the PodSecurityConfig is loaded from, if any,
+	// and PodSecurityConfigHash the SHA-256 hash of its content.
+	PodSecurityConfigFile string
+	PodSecurityConfigHash [sha256.Size]byte
+	// ConfigReloadInterval is the interval the PodSecurityConfigFile is checked for changes at, if non-zero.
+	ConfigReloadInterval time.Duration
+
+	WarnUnevaluatedFields bool
+	WarnVersionSkew       bool
+	WarnDeprecatedFields  bool
+	NamespaceRolloutGuard admission.NamespaceRolloutGuard
+	NamespaceEvaluation   admission.NamespaceEvaluationOptions
+	NamespaceWarnings     admission.NamespaceWarningOptions
+
+	ReplayCorpusDir        string
+	ReplayCorpusSampleRate float64
+
+	DeterminismGuardSampleRate float64
+
+	AuditViolationsDetail bool
+
+	EnforcementAction   admission.EnforcementAction
+	ShortCircuitEnforce bool
+	DenialSnippets      []policy.CheckID
+	FailurePolicies     admission.FailurePolicies
+	CheckErrorPolicies  admission.CheckErrorPolicies
+	NamespaceLookup     admission.NamespaceLookupOptions
+
+	LenientLabelParsing      bool
+	UnknownLabels            admission.UnknownLabelsAction
+	NamespaceCheckExemptions bool
+	WarningLimits            admission.WarningLimits
+
+	ExemptionUserExtraKeys []string
+
+	DecisionLedgerFile       string
+	DecisionLedgerDeniedOnly bool
+
+	EvaluateEndpoint bool
+
+	MetricsCheckViolations bool
+
+	CheckOptOutPublicKeys []ed25519.PublicKey
+
+	WindowsPodMode  policy.WindowsPodMode
+	ResultCacheSize int
+	CheckDeadline   time.Duration
+
+	SubresourceWarnings admission.SubresourceWarnings
 }
 
 // LoadConfig loads the Config from the Options.
@@ -253,11 +278,70 @@ func LoadConfig(opts *options.Options) (*Config, error) {
 	kubeConfig.Burst = opts.ClientQPSBurst
 	c.KubeConfig = restclient.AddUserAgent(kubeConfig, "podsecurity-webhook")
 
+	c.WarnUnevaluatedFields = opts.WarnUnevaluatedFields
+	c.WarnVersionSkew = opts.WarnVersionSkew
+	c.WarnDeprecatedFields = opts.WarnDeprecatedFields
+	c.NamespaceRolloutGuard, _ = admission.ParseNamespaceRolloutGuard(opts.NamespaceRolloutGuard) // validated above
+	c.NamespaceEvaluation = opts.NamespaceEvaluation
+	c.NamespaceWarnings = opts.NamespaceWarnings
+	c.NamespaceWarnings.Order, _ = admission.ParseNamespaceWarningOrder(opts.NamespaceWarningOrder) // validated above
+	c.ReplayCorpusDir = opts.ReplayCorpusDir
+	c.ReplayCorpusSampleRate = opts.ReplayCorpusSampleRate
+	c.DeterminismGuardSampleRate = opts.DeterminismGuardSampleRate
+	c.EnforcementAction, _ = admission.ParseEnforcementAction(opts.EnforcementAction) // validated above
+	c.ShortCircuitEnforce = opts.ShortCircuitEnforce
+	for _, id := range opts.DenialSnippets {
+		c.DenialSnippets = append(c.DenialSnippets, policy.CheckID(id))
+	}
+	c.AuditViolationsDetail = opts.AuditViolationsDetail
+
+	c.FailurePolicies.Enforce, _ = admission.ParseFailurePolicy(opts.EnforceFailurePolicy) // validated above
+	c.FailurePolicies.Audit = admission.FailurePolicy(opts.AuditFailurePolicy)             // validated above
+	c.FailurePolicies.Timeout = opts.EvaluationTimeout
+	c.CheckErrorPolicies.Enforce = admission.FailurePolicy(opts.EnforceCheckErrorPolicy) // validated above
+	c.CheckErrorPolicies.Audit = admission.FailurePolicy(opts.AuditCheckErrorPolicy)     // validated above
+	c.CheckErrorPolicies.Warn = admission.FailurePolicy(opts.WarnCheckErrorPolicy)       // validated above
+	c.NamespaceLookup.Retries = opts.NamespaceLookupRetries
+	c.NamespaceLookup.RetryInterval = opts.NamespaceLookupRetryInterval
+	c.NamespaceLookup.OnFailure, _ = admission.ParseNamespaceLookupFailureAction(opts.NamespaceLookupFailureAction) // validated above
+	c.LenientLabelParsing = opts.LenientLabelParsing
+	c.UnknownLabels, _ = admission.ParseUnknownLabelsAction(opts.UnknownLabels) // validated above
+	c.NamespaceCheckExemptions = opts.NamespaceCheckExemptions
+	c.WarningLimits = opts.WarningLimits
+	c.ExemptionUserExtraKeys = opts.ExemptionUserExtraKeys
+	c.DecisionLedgerFile = opts.DecisionLedgerFile
+	c.DecisionLedgerDeniedOnly = opts.DecisionLedgerDeniedOnly
+	c.EvaluateEndpoint = opts.EvaluateEndpoint
+	c.MetricsCheckViolations = opts.MetricsCheckViolations
+	c.WindowsPodMode, _ = policy.ParseWindowsPodMode(opts.WindowsPodMode)                   // validated above
+	c.SubresourceWarnings, _ = admission.ParseSubresourceWarnings(opts.SubresourceWarnings) // validated above
+	c.ResultCacheSize = opts.ResultCacheSize
+	c.CheckDeadline = opts.CheckDeadline
+
 	// Load PodSecurity config
-	c.PodSecurityConfig, err = podsecurityconfigloader.LoadFromFile(opts.Config)
+	var configData []byte
+	if opts.Config != "" {
+		if configData, err = os.ReadFile(opts.Config); err != nil {
+			return nil, err
+		}
+	}
+	c.PodSecurityConfig, err = podsecurityconfigloader.LoadFromData(configData)
 	if err != nil {
 		return nil, err
 	}
+	c.PodSecurityConfigFile = opts.Config
+	c.PodSecurityConfigHash = sha256.Sum256(configData)
+	c.ConfigReloadInterval = opts.ConfigReloadInterval
+
+	if opts.CheckOptOutPublicKeysFile != "" {
+		data, err := os.ReadFile(opts.CheckOptOutPublicKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		if c.CheckOptOutPublicKeys, err = admission.ParseCheckOptOutPublicKeys(data); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", opts.CheckOptOutPublicKeysFile, err)
+		}
+	}
 
 	return &c, nil
 }
@@ -265,8 +349,9 @@ func LoadConfig(opts *options.Options) (*Config, error) {
 // Setup creates an Admission object to handle the admission logic.
 func Setup(c *Config) (*Server, error) {
 	s := &Server{
-		secureServing:   c.SecureServing,
-		insecureServing: c.InsecureServing,
+		secureServing:    c.SecureServing,
+		insecureServing:  c.InsecureServing,
+		evaluateEndpoint: c.EvaluateEndpoint,
 	}
 
 	if s.secureServing == nil && s.insecureServing == nil {
@@ -281,57 +366,95 @@ func Setup(c *Config) (*Server, error) {
 	namespaceInformer := s.informerFactory.Core().V1().Namespaces()
 	namespaceLister := namespaceInformer.Lister()
 
-	evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
-	if err != nil {
-		return nil, fmt.Errorf("could not create PodSecurityRegistry: %w", err)
-	}
 	metrics := metrics.NewPrometheusRecorder(api.GetAPIVersion())
+	if c.MetricsCheckViolations {
+		metrics.EnableCheckViolations()
+	}
 	s.metricsRegistry = compbasemetrics.NewKubeRegistry()
 	metrics.MustRegister(s.metricsRegistry.MustRegister)
 
-	s.delegate = &admission.Admission{
-		Configuration:    c.PodSecurityConfig,
-		Evaluator:        evaluator,
-		Metrics:          metrics,
-		PodSpecExtractor: admission.DefaultPodSpecExtractor{},
-		PodLister:        admission.PodListerFromClient(client),
-		NamespaceGetter:  admission.NamespaceGetterFromListerAndClient(namespaceLister, client),
+	var violationRecorder admission.ViolationRecorder
+	if c.ReplayCorpusDir != "" {
+		violationRecorder = NewCorpusRecorder(c.ReplayCorpusDir, c.ReplayCorpusSampleRate)
+	}
+	var checkOptOutVerifier admission.CheckOptOutVerifier
+	if len(c.CheckOptOutPublicKeys) > 0 {
+		checkOptOutVerifier = admission.NewCheckOptOutVerifier(c.CheckOptOutPublicKeys)
+	}
+	var decisionRecorder admission.DecisionRecorder
+	if c.DecisionLedgerFile != "" {
+		s.decisionLedger = ledger.NewBroadcaster(ledger.NewFileStore(c.DecisionLedgerFile))
+		decisionRecorder = ledger.NewRecorder(s.decisionLedger, c.DecisionLedgerDeniedOnly)
 	}
 
-	if err := s.delegate.CompleteConfiguration(); err != nil {
-		return nil, fmt.Errorf("configuration error: %w", err)
+	handlerConfig := handlerConfig{
+		Admission: admission.Admission{
+			Configuration:         c.PodSecurityConfig,
+			Metrics:               metrics,
+			WarnUnevaluatedFields: c.WarnUnevaluatedFields,
+			WarnVersionSkew:       c.WarnVersionSkew,
+			WarnDeprecatedFields:  c.WarnDeprecatedFields,
+			NamespaceRolloutGuard: c.NamespaceRolloutGuard,
+			NamespaceEvaluation:   c.NamespaceEvaluation,
+			NamespaceWarnings:     c.NamespaceWarnings,
+			ViolationRecorder:     violationRecorder,
+			EnforcementAction:     c.EnforcementAction,
+			ShortCircuitEnforce:   c.ShortCircuitEnforce,
+			DenialSnippets:        c.DenialSnippets,
+			AuditViolationsDetail: c.AuditViolationsDetail,
+			FailurePolicies:       c.FailurePolicies,
+			CheckErrorPolicies:    c.CheckErrorPolicies,
+			NamespaceLookup:       c.NamespaceLookup,
+			LenientLabelParsing:   c.LenientLabelParsing,
+			UnknownLabels:         c.UnknownLabels,
+			WarningLimits:         c.WarningLimits,
+			DecisionRecorder:      decisionRecorder,
+			IdentityExtractor:     exemptionIdentityExtractor(c.ExemptionUserExtraKeys),
+			CheckOptOutVerifier:   checkOptOutVerifier,
+			SubresourceWarnings:   c.SubresourceWarnings,
+
+			NamespaceCheckExemptions: c.NamespaceCheckExemptions,
+		},
+		WindowsPodMode:  c.WindowsPodMode,
+		ResultCacheSize: c.ResultCacheSize,
+		CheckDeadline:   c.CheckDeadline,
+		Client:          client,
+		NamespaceLister: namespaceLister,
+	}
+	if c.DeterminismGuardSampleRate > 0 {
+		handlerConfig.DeterminismGuard = newDeterminismGuard(c.DeterminismGuardSampleRate, handlerConfig, policy.DefaultChecks())
+	}
+	h, err := newHandler(handlerConfig)
+	if err != nil {
+		return nil, err
 	}
-	if err := s.delegate.ValidateConfiguration(); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
+	s.handler.Store(h)
+	metrics.RecordChecksSchemaVersion(h.ChecksSchemaVersion)
+	metrics.RecordChecks(policy.EvaluatorChecks(h.Evaluator))
+
+	if c.ConfigReloadInterval > 0 && c.PodSecurityConfigFile != "" {
+		// reloaded handlers share the evaluator, whose checks and result cache do not depend on the configuration
+		handlerConfig.Evaluator = h.Evaluator
+		reloadHandler := func(config *admissionapi.PodSecurityConfiguration) (*webhook.Handler, error) {
+			reloadConfig := handlerConfig
+			reloadConfig.Configuration = config
+			return newHandler(reloadConfig)
+		}
+		s.reloader = newConfigReloader(c.PodSecurityConfigFile, c.PodSecurityConfigHash, c.ConfigReloadInterval, reloadHandler, s.handler.Store, metrics)
 	}
 
 	return s, nil
 }
 
-func writeResponse(w http.ResponseWriter, review *admissionv1.AdmissionReview) {
-	// Webhooks should always respond with a 200 HTTP status code when an AdmissionResponse can be sent.
-	// In an error case, the true status code is captured in the response.result.code
-	if err := json.NewEncoder(w).Encode(review); err != nil {
-		klog.ErrorS(err, "Failed to encode response")
-		// Unable to send an AdmissionResponse, fall back to an HTTP error.
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+// exemptionIdentityExtractor matches the values of the given user extra keys against exempt usernames,
+// in addition to the username. It returns nil, matching the username only, if no keys are given.
+func exemptionIdentityExtractor(userExtraKeys []string) admission.IdentityExtractor {
+	if len(userExtraKeys) == 0 {
+		return nil
 	}
-}
-
-// parseTimeout parses the given HTTP request URL and extracts the timeout query parameter
-// value if specified by the user.
-// If a timeout is not specified the function returns false and err is set to nil
-// If the value specified is malformed then the function returns false and err is set
-func parseTimeout(req *http.Request) (time.Duration, bool, error) {
-	value := req.URL.Query().Get("timeout")
-	if value == "" {
-		return 0, false, nil
+	extractors := []admission.IdentityExtractor{admission.UsernameIdentity}
+	for _, key := range userExtraKeys {
+		extractors = append(extractors, admission.UserExtraIdentity(key))
 	}
-
-	timeout, err := time.ParseDuration(value)
-	if err != nil {
-		return 0, false, fmt.Errorf("invalid timeout query: %w", err)
-	}
-
-	return timeout, true, nil
+	return admission.MultiIdentityExtractor(extractors...)
 }
@@ -0,0 +1,170 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/pod-security-admission/admission"
+	"k8s.io/pod-security-admission/cmd/webhook/server/options"
+	"k8s.io/pod-security-admission/ledger"
+)
+
+const (
+	colorReset  = "\x1b[0m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+)
+
+// newWatchCommand creates the watch subcommand, streaming the live enforce decisions of a running webhook.
+func newWatchCommand() *cobra.Command {
+	opts := options.NewWatchOptions()
+
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Stream the live enforce decisions of a running webhook",
+		Long: `Stream the enforce decisions of a running webhook recording them with
+--decision-ledger-file, and print them as they are made, optionally filtered by
+namespace, violated check and decision. Useful to follow the pods denied during
+an enforcement rollout.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return runWatch(cmd.Context(), opts, cmd.OutOrStdout())
+		},
+		Args: cobra.NoArgs,
+	}
+	opts.AddFlags(cmd.Flags())
+
+	return cmd
+}
+
+func runWatch(ctx context.Context, opts *options.WatchOptions, out io.Writer) error {
+	if errs := opts.Validate(); len(errs) > 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+	watchURL, err := decisionsWatchURL(opts)
+	if err != nil {
+		return err
+	}
+	tlsConfig, err := webhookTLSConfig(opts.CAFile, opts.InsecureSkipTLSVerify)
+	if err != nil {
+		return err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	// The stream is open until interrupted, so the client has no timeout.
+	client := &http.Client{Transport: transport}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, watchURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	color := useColor(opts.Color, out)
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var record ledger.Record
+		if err := decoder.Decode(&record); err != nil {
+			if errors.Is(err, io.EOF) || ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to decode decision: %w", err)
+		}
+		writeDecision(out, &record, color)
+	}
+}
+
+// decisionsWatchURL returns the URL of the decisions stream of the webhook, with the filters of the options.
+func decisionsWatchURL(opts *options.WatchOptions) (string, error) {
+	u, err := url.Parse(opts.URL)
+	if err != nil {
+		return "", fmt.Errorf("invalid --url: %w", err)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/debug/decisions/watch"
+	query := url.Values{}
+	for key, value := range map[string]string{"namespace": opts.Namespace, "check": opts.Check, "decision": opts.Decision} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// useColor returns true if the decisions written to out should be colorized.
+func useColor(mode string, out io.Writer) bool {
+	switch mode {
+	case options.WatchColorAlways:
+		return true
+	case options.WatchColorNever:
+		return false
+	}
+	f, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// writeDecision writes a line with the time, decision, pod, policy and violated checks of the record,
+// with denied decisions in red, allowed ones in green, and checks in yellow if colorized.
+func writeDecision(out io.Writer, r *ledger.Record, color bool) {
+	paint := func(s, c string) string {
+		if !color {
+			return s
+		}
+		return c + s + colorReset
+	}
+	decisionColor := colorGreen
+	if r.Decision == admission.DecisionDeny {
+		decisionColor = colorRed
+	}
+	pod := r.Namespace + "/" + r.Name
+	if r.Name == "" {
+		pod = r.Namespace + "/<generated>"
+	}
+	line := fmt.Sprintf("%s %s %s %s workload=%s", r.Time.Format("15:04:05"), paint(fmt.Sprintf("%-5s", r.Decision), decisionColor), pod, r.Policy, r.Workload)
+	if len(r.Checks) > 0 {
+		checks := make([]string, len(r.Checks))
+		for i, id := range r.Checks {
+			checks[i] = string(id)
+		}
+		line += " checks=" + paint(strings.Join(checks, ","), colorYellow)
+	}
+	fmt.Fprintln(out, line)
+}
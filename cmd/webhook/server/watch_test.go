@@ -0,0 +1,173 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubeinformers "k8s.io/client-go/informers"
+	"k8s.io/pod-security-admission/admission"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/cmd/webhook/server/options"
+	"k8s.io/pod-security-admission/ledger"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buf.String()
+}
+
+// flushNotifier closes flushed on the first Flush, once the watch handler subscribed to the broadcaster.
+type flushNotifier struct {
+	http.ResponseWriter
+	once    *sync.Once
+	flushed chan struct{}
+}
+
+func (w flushNotifier) Flush() {
+	w.ResponseWriter.(http.Flusher).Flush()
+	w.once.Do(func() { close(w.flushed) })
+}
+
+func TestRunWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c, _ := newTestHandlerConfig(t, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "other-ns",
+		Labels: map[string]string{api.EnforceLevelLabel: string(api.LevelRestricted)},
+	}})
+	informerFactory := kubeinformers.NewSharedInformerFactory(c.Client, 0)
+	namespaceLister := informerFactory.Core().V1().Namespaces().Lister()
+	informerFactory.Start(ctx.Done())
+	informerFactory.WaitForCacheSync(ctx.Done())
+	c.NamespaceLister = namespaceLister
+	decisions := ledger.NewBroadcaster(ledger.NewMemoryStore(10))
+	c.DecisionRecorder = ledger.NewRecorder(decisions, false)
+	h, err := newHandler(c)
+	require.NoError(t, err)
+
+	subscribed := make(chan struct{})
+	var once sync.Once
+	watchHandler := ledger.NewWatchHandler(decisions)
+	mux := http.NewServeMux()
+	mux.Handle("/", h)
+	mux.Handle("/debug/decisions/watch", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		watchHandler.ServeHTTP(flushNotifier{ResponseWriter: w, once: &once, flushed: subscribed}, r)
+	}))
+	webhook := httptest.NewTLSServer(mux)
+	defer webhook.Close()
+
+	opts := options.NewWatchOptions()
+	opts.URL = webhook.URL
+	opts.InsecureSkipTLSVerify = true
+	opts.Namespace = "test-ns"
+	opts.Color = options.WatchColorNever
+	out := &syncBuffer{}
+	done := make(chan error)
+	go func() { done <- runWatch(ctx, opts, out) }()
+	select {
+	case <-subscribed:
+	case err := <-done:
+		t.Fatalf("watch stopped before streaming: %v", err)
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pod"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app"}}},
+	}
+	assert.True(t, serveReview(t, h, podCreateRequest(t, pod)).Allowed)
+
+	// Pods of other namespaces are filtered out.
+	otherRequest := podCreateRequest(t, pod.DeepCopy())
+	otherRequest.Namespace = "other-ns"
+	assert.False(t, serveReview(t, h, otherRequest).Allowed)
+
+	// Tightening the namespace re-evaluates the same pod against the new level once the informer observed it.
+	namespace, err := c.Client.CoreV1().Namespaces().Get(ctx, "test-ns", metav1.GetOptions{})
+	require.NoError(t, err)
+	namespace.Labels[api.EnforceLevelLabel] = string(api.LevelRestricted)
+	_, err = c.Client.CoreV1().Namespaces().Update(ctx, namespace, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		namespace, err := namespaceLister.Get("test-ns")
+		return err == nil && namespace.Labels[api.EnforceLevelLabel] == string(api.LevelRestricted)
+	}, wait.ForeverTestTimeout, 10*time.Millisecond)
+	assert.False(t, serveReview(t, h, podCreateRequest(t, pod)).Allowed)
+
+	require.Eventually(t, func() bool { return strings.Count(out.String(), "\n") == 2 }, wait.ForeverTestTimeout, 10*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	for i, line := range lines {
+		// Trim the time of the decision.
+		lines[i] = line[len("15:04:05 "):]
+	}
+	assert.Equal(t, []string{
+		"Allow test-ns/test-pod baseline:latest workload=" + ledger.WorkloadHash(&pod.ObjectMeta, &pod.Spec),
+		"Deny  test-ns/test-pod restricted:latest workload=" + ledger.WorkloadHash(&pod.ObjectMeta, &pod.Spec) +
+			" checks=allowPrivilegeEscalation,capabilities_restricted,runAsNonRoot,seccompProfile_restricted",
+	}, lines)
+}
+
+func TestWriteDecision(t *testing.T) {
+	record := &ledger.Record{
+		Time:      time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC),
+		Namespace: "test-ns",
+		Decision:  admission.DecisionDeny,
+		Policy:    "restricted:v1.30",
+		Workload:  "abc",
+		Checks:    []policy.CheckID{"runAsNonRoot", "seccompProfile_restricted"},
+	}
+
+	var out bytes.Buffer
+	writeDecision(&out, record, false)
+	assert.Equal(t, "12:30:00 Deny  test-ns/<generated> restricted:v1.30 workload=abc checks=runAsNonRoot,seccompProfile_restricted\n", out.String())
+
+	out.Reset()
+	writeDecision(&out, record, true)
+	assert.Equal(t, "12:30:00 "+colorRed+"Deny "+colorReset+" test-ns/<generated> restricted:v1.30 workload=abc checks="+
+		colorYellow+"runAsNonRoot,seccompProfile_restricted"+colorReset+"\n", out.String())
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// ExemptNamespacesMatchConditionName is the name of the match condition returned by ExemptNamespacesMatchCondition.
+const ExemptNamespacesMatchConditionName = "pod-security.kubernetes.io/exempt-namespaces"
+
+// ExemptionOptions mirror the webhook configuration deciding which checks are exempted
+// by the pod-security.kubernetes.io/exempt-checks annotation of namespaces.
+type ExemptionOptions struct {
+	// NamespaceCheckExemptions mirrors the corresponding field of the webhook admission.
+	// The exempt-checks annotations are ignored if unset, like the webhook does.
+	NamespaceCheckExemptions bool
+	// Evaluator is the evaluator of the webhook, resolving the levels of the exempted checks.
+	Evaluator policy.Evaluator
+	// EnforceFloor is the enforce floor of the webhook configuration, if any (see admissionapi.ToFloor).
+	// Checks evaluated at the level of the floor are not exempt.
+	EnforceFloor *api.LevelVersion
+}
+
+// ExemptNamespacesByCheck returns the names of the namespaces exempting each check
+// with the pod-security.kubernetes.io/exempt-checks annotation, sorted by name.
+// Only the exemptions honored by a webhook configured with the options are returned (see policy.FilterExemptChecks).
+// The result can be used to generate policy exceptions for policy engines without match conditions.
+func ExemptNamespacesByCheck(namespaces []*corev1.Namespace, opts ExemptionOptions) map[policy.CheckID][]string {
+	exempt := map[policy.CheckID][]string{}
+	if !opts.NamespaceCheckExemptions {
+		return exempt
+	}
+	for _, ns := range namespaces {
+		ids, _ := policy.FilterExemptChecks(opts.Evaluator, opts.EnforceFloor, api.ExemptChecks(ns.Annotations))
+		for _, id := range ids {
+			exempt[id] = append(exempt[id], ns.Name)
+		}
+	}
+	for _, names := range exempt {
+		sort.Strings(names)
+	}
+	return exempt
+}
+
+// ExemptNamespacesMatchCondition returns a ValidatingAdmissionPolicy match condition excluding requests
+// in the given namespaces, so an exported policy enforcing a single check skips the namespaces exempting
+// that check like the webhook does. It returns false if there are no namespaces to exclude.
+func ExemptNamespacesMatchCondition(namespaces []string) (admissionregistrationv1.MatchCondition, bool) {
+	if len(namespaces) == 0 {
+		return admissionregistrationv1.MatchCondition{}, false
+	}
+	quoted := make([]string, len(namespaces))
+	for i, ns := range namespaces {
+		quoted[i] = strconv.Quote(ns)
+	}
+	return admissionregistrationv1.MatchCondition{
+		Name:       ExemptNamespacesMatchConditionName,
+		Expression: "!(request.namespace in [" + strings.Join(quoted, ", ") + "])",
+	}, true
+}
+
+// CheckMatchConditions returns the match conditions to add to the exported policy of each check
+// exempted by at least one of the given namespaces, as honored by a webhook configured with the options.
+func CheckMatchConditions(namespaces []*corev1.Namespace, opts ExemptionOptions) map[policy.CheckID][]admissionregistrationv1.MatchCondition {
+	conditions := map[policy.CheckID][]admissionregistrationv1.MatchCondition{}
+	for id, names := range ExemptNamespacesByCheck(namespaces, opts) {
+		if condition, ok := ExemptNamespacesMatchCondition(names); ok {
+			conditions[id] = append(conditions[id], condition)
+		}
+	}
+	return conditions
+}
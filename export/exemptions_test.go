@@ -0,0 +1,74 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package export
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+)
+
+func TestCheckMatchConditions(t *testing.T) {
+	namespace := func(name, exemptChecks string) *corev1.Namespace {
+		ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if exemptChecks != "" {
+			ns.Annotations = map[string]string{api.ExemptChecksAnnotation: exemptChecks}
+		}
+		return ns
+	}
+	namespaces := []*corev1.Namespace{
+		namespace("b", "hostPorts,sysctls"),
+		namespace("a", "hostPorts"),
+		namespace("c", ""),
+		namespace("d", "runAsNonRoot"),
+	}
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+	require.NoError(t, err)
+	opts := ExemptionOptions{NamespaceCheckExemptions: true, Evaluator: evaluator}
+
+	assert.Equal(t, map[policy.CheckID][]string{
+		"hostPorts":    {"a", "b"},
+		"sysctls":      {"b"},
+		"runAsNonRoot": {"d"},
+	}, ExemptNamespacesByCheck(namespaces, opts))
+
+	// the webhook ignores the annotations unless namespace check exemptions are enabled
+	assert.Empty(t, ExemptNamespacesByCheck(namespaces, ExemptionOptions{Evaluator: evaluator}))
+
+	// the webhook never exempts the checks evaluated at the level of the enforce floor
+	floorOpts := opts
+	floorOpts.EnforceFloor = &api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}
+	assert.Equal(t, map[policy.CheckID][]string{
+		"runAsNonRoot": {"d"},
+	}, ExemptNamespacesByCheck(namespaces, floorOpts))
+
+	assert.Equal(t, map[policy.CheckID][]admissionregistrationv1.MatchCondition{
+		"hostPorts":    {{Name: ExemptNamespacesMatchConditionName, Expression: `!(request.namespace in ["a", "b"])`}},
+		"sysctls":      {{Name: ExemptNamespacesMatchConditionName, Expression: `!(request.namespace in ["b"])`}},
+		"runAsNonRoot": {{Name: ExemptNamespacesMatchConditionName, Expression: `!(request.namespace in ["d"])`}},
+	}, CheckMatchConditions(namespaces, opts))
+
+	_, ok := ExemptNamespacesMatchCondition(nil)
+	assert.False(t, ok)
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+	goyaml "sigs.k8s.io/yaml/goyaml.v3"
+)
+
+// podTemplatePaths are the paths of the pod templates of the resources evaluated by PodSecurity admission.
+// Pods are evaluated as a whole.
+var podTemplatePaths = map[schema.GroupKind]*field.Path{
+	{Kind: "Pod"}:                        nil,
+	{Kind: "PodTemplate"}:                field.NewPath("template"),
+	{Kind: "ReplicationController"}:      field.NewPath("spec", "template"),
+	{Group: "apps", Kind: "ReplicaSet"}:  field.NewPath("spec", "template"),
+	{Group: "apps", Kind: "Deployment"}:  field.NewPath("spec", "template"),
+	{Group: "apps", Kind: "StatefulSet"}: field.NewPath("spec", "template"),
+	{Group: "apps", Kind: "DaemonSet"}:   field.NewPath("spec", "template"),
+	{Group: "batch", Kind: "Job"}:        field.NewPath("spec", "template"),
+	{Group: "batch", Kind: "CronJob"}:    field.NewPath("spec", "jobTemplate", "spec", "template"),
+}
+
+// PodTemplate is a pod, or the pod template of a workload resource.
+type PodTemplate struct {
+	Manifest *Manifest
+	// Path is the path of the pod template in the resource, or nil for pods.
+	Path *field.Path
+}
+
+// PodTemplates returns the pods and pod templates of the manifests, including hooks.
+// Resources without a pod template are skipped.
+func PodTemplates(manifests []*Manifest) []PodTemplate {
+	var templates []PodTemplate
+	for _, m := range manifests {
+		path, ok := podTemplatePaths[m.Object.GroupVersionKind().GroupKind()]
+		if !ok {
+			continue
+		}
+		templates = append(templates, PodTemplate{Manifest: m, Path: path})
+	}
+	return templates
+}
+
+// object returns the unstructured pod or pod template, or nil if it is not set.
+func (t PodTemplate) object() (map[string]interface{}, error) {
+	obj := t.Manifest.Object.Object
+	if t.Path == nil {
+		return obj, nil
+	}
+	for _, f := range strings.Split(t.Path.String(), ".") {
+		v, ok := obj[f]
+		if !ok || v == nil {
+			return nil, nil
+		}
+		if obj, ok = v.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("%s: expected an object, got %T", t.Path, v)
+		}
+	}
+	return obj, nil
+}
+
+// Violation is a violation of a check by a pod template of the rendered manifests.
+type Violation struct {
+	// Template is the chart template the violating resource is rendered from, if known.
+	Template string
+	// Line is the line of the rendered manifests of the violating field,
+	// or of the violating resource if the field is unknown.
+	Line int
+	// Kind and Name identify the violating resource.
+	Kind string
+	Name string
+	// Hook is the HookAnnotation of the violating resource, if it is a hook.
+	Hook string
+
+	// Check is the ID of the violated check.
+	Check           policy.CheckID
+	ForbiddenReason string
+	ForbiddenDetail string
+	// Field is the path of the violating field in the resource, if known.
+	Field string
+}
+
+// String formats the violation as "<template>:<line>: <kind> <name>: <reason> (<detail>)", followed by the field if known.
+func (v Violation) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s:%d: %s %s: %s", v.Template, v.Line, v.Kind, v.Name, v.ForbiddenReason)
+	if v.ForbiddenDetail != "" {
+		fmt.Fprintf(&b, " (%s)", v.ForbiddenDetail)
+	}
+	if v.Field != "" {
+		fmt.Fprintf(&b, " at %s", v.Field)
+	}
+	return b.String()
+}
+
+// Evaluate evaluates the pod templates of the manifests, including hooks, against the policy for the given level & version.
+// Violations are located at the violating fields when the evaluator is constructed with policy.WithFieldErrors,
+// and at the violating resources otherwise.
+// An error is returned if a pod template cannot be converted for evaluation.
+func Evaluate(evaluator policy.Evaluator, lv api.LevelVersion, manifests []*Manifest) ([]Violation, error) {
+	var violations []Violation
+	for _, t := range PodTemplates(manifests) {
+		m := t.Manifest
+		obj, err := t.object()
+		if err != nil {
+			return nil, fmt.Errorf("%s %s in %s: %w", m.Object.GetKind(), m.Object.GetName(), m.Template, err)
+		}
+		if obj == nil {
+			continue
+		}
+		pod, err := policy.NewUnstructuredPodAccessor(obj)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s in %s: %w", m.Object.GetKind(), m.Object.GetName(), m.Template, err)
+		}
+		for _, result := range policy.EvaluatePodAccessor(evaluator, lv, pod) {
+			if result.Allowed {
+				continue
+			}
+			violation := Violation{
+				Template:        m.Template,
+				Line:            m.Line,
+				Kind:            m.Object.GetKind(),
+				Name:            m.Object.GetName(),
+				Hook:            m.Hook(),
+				Check:           result.ID,
+				ForbiddenReason: result.ForbiddenReason,
+				ForbiddenDetail: result.ForbiddenDetail,
+			}
+			if result.ErrList == nil || len(*result.ErrList) == 0 {
+				violations = append(violations, violation)
+				continue
+			}
+			for _, err := range *result.ErrList {
+				violation.Field = err.Field
+				if t.Path != nil {
+					violation.Field = t.Path.String() + "." + err.Field
+				}
+				violation.Line = m.lineOf(violation.Field)
+				violations = append(violations, violation)
+			}
+		}
+	}
+	return violations, nil
+}
+
+// lineOf returns the line of the rendered manifests of the field at the given path in the resource,
+// or of its closest parent set in the resource.
+func (m *Manifest) lineOf(path string) int {
+	node := m.node
+	for _, segment := range splitPath(path) {
+		next := child(node, segment)
+		if next == nil {
+			break
+		}
+		node = next
+	}
+	return m.offset + node.Line
+}
+
+// child returns the mapping value for the key, or the sequence item at the index, or nil if it is not set.
+func child(node *goyaml.Node, segment string) *goyaml.Node {
+	switch node.Kind {
+	case goyaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == segment {
+				return node.Content[i+1]
+			}
+		}
+	case goyaml.SequenceNode:
+		if i, err := strconv.Atoi(segment); err == nil && i >= 0 && i < len(node.Content) {
+			return node.Content[i]
+		}
+	}
+	return nil
+}
+
+// splitPath splits a field path like metadata.annotations[key.with.dots] or spec.containers[0].name
+// into the keys and indexes of its segments.
+func splitPath(path string) []string {
+	var segments []string
+	for len(path) > 0 {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return append(segments, path[1:])
+			}
+			segments = append(segments, path[1:end])
+			path = path[end+1:]
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				return append(segments, path)
+			}
+			segments = append(segments, path[:end])
+			path = path[end:]
+		}
+	}
+	return segments
+}
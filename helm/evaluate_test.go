@@ -0,0 +1,81 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+)
+
+func TestEvaluate(t *testing.T) {
+	manifests, err := ParseManifests([]byte(rendered))
+	require.NoError(t, err)
+	baseline := api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}
+
+	t.Run("field errors", func(t *testing.T) {
+		evaluator, err := policy.NewEvaluator(policy.DefaultChecks(), policy.WithFieldErrors())
+		require.NoError(t, err)
+		violations, err := Evaluate(evaluator, baseline, manifests)
+		require.NoError(t, err)
+
+		var lines []string
+		for _, v := range violations {
+			lines = append(lines, v.String())
+		}
+		assert.Equal(t, []string{
+			`app/templates/deployment.yaml:18: Deployment app: forbidden AppArmor profile (annotation must not set AppArmor profile type to "container.apparmor.security.beta.kubernetes.io/app="unconfined"") at spec.template.metadata.annotations[container.apparmor.security.beta.kubernetes.io/app]`,
+			`app/templates/deployment.yaml:24: Deployment app: privileged (container "app" must not set securityContext.privileged=true) at spec.template.spec.containers[0].securityContext.privileged`,
+			`app/templates/tests/test-connection.yaml:39: Pod app-test-connection: host namespaces (hostNetwork=true) at spec.hostNetwork`,
+		}, lines)
+		assert.Equal(t, "test", violations[2].Hook)
+		assert.Equal(t, policy.CheckID("hostNamespaces"), violations[2].Check)
+	})
+
+	t.Run("without field errors", func(t *testing.T) {
+		evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+		require.NoError(t, err)
+		violations, err := Evaluate(evaluator, baseline, manifests)
+		require.NoError(t, err)
+		require.Len(t, violations, 3)
+		for _, v := range violations {
+			assert.Empty(t, v.Field)
+		}
+		assert.Equal(t, 9, violations[0].Line)
+		assert.Equal(t, 32, violations[2].Line)
+	})
+
+	t.Run("privileged", func(t *testing.T) {
+		evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+		require.NoError(t, err)
+		violations, err := Evaluate(evaluator, api.LevelVersion{Level: api.LevelPrivileged, Version: api.LatestVersion()}, manifests)
+		require.NoError(t, err)
+		assert.Empty(t, violations)
+	})
+
+	t.Run("invalid pod template", func(t *testing.T) {
+		manifests, err := ParseManifests([]byte("# Source: app/templates/job.yaml\napiVersion: batch/v1\nkind: Job\nmetadata:\n  name: job\nspec:\n  template:\n    spec:\n      hostNetwork: yes-please\n"))
+		require.NoError(t, err)
+		evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+		require.NoError(t, err)
+		_, err = Evaluate(evaluator, baseline, manifests)
+		assert.ErrorContains(t, err, "Job job in app/templates/job.yaml")
+	})
+}
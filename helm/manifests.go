@@ -0,0 +1,142 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"sigs.k8s.io/yaml"
+	goyaml "sigs.k8s.io/yaml/goyaml.v3"
+)
+
+// HookAnnotation is the annotation marking chart resources as hooks, e.g. "pre-install".
+const HookAnnotation = "helm.sh/hook"
+
+var (
+	documentSeparator = regexp.MustCompile(`^---\s*(#.*)?$`)
+	sourceComment     = regexp.MustCompile(`^#\s*Source:\s*(\S+)\s*$`)
+)
+
+// Manifest is a single resource of rendered chart manifests.
+type Manifest struct {
+	// Template is the chart template the resource is rendered from, as recorded by the
+	// "# Source:" comment of the rendered document, if any.
+	Template string
+	// Line is the line of the rendered manifests the resource starts at.
+	Line int
+	// Object is the resource.
+	Object *unstructured.Unstructured
+
+	// node is the parsed document, to locate fields of the resource.
+	node *goyaml.Node
+	// offset is the number of lines of the rendered manifests preceding the document.
+	offset int
+}
+
+// Hook returns the hooks the resource is run for, or an empty string if the resource is not a hook.
+func (m *Manifest) Hook() string {
+	return m.Object.GetAnnotations()[HookAnnotation]
+}
+
+// ParseManifests parses rendered chart manifests, as output by "helm template", into resources.
+// Hooks are included like any other resource, so the output of "helm get manifest" and "helm get hooks"
+// can be concatenated. Empty documents are skipped.
+func ParseManifests(rendered []byte) ([]*Manifest, error) {
+	var (
+		manifests []*Manifest
+		document  bytes.Buffer
+		template  string
+		start     = 1
+		line      = 0
+	)
+	flush := func() error {
+		defer func() {
+			document.Reset()
+			template = ""
+			start = line + 1
+		}()
+		m, err := parseManifest(document.Bytes(), template, start)
+		if err != nil {
+			return fmt.Errorf("document at line %d: %w", start, err)
+		}
+		if m != nil {
+			manifests = append(manifests, m)
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(rendered))
+	scanner.Buffer(nil, len(rendered)+1)
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if documentSeparator.MatchString(text) {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if match := sourceComment.FindStringSubmatch(text); match != nil && template == "" {
+			template = match[1]
+		}
+		document.WriteString(text)
+		document.WriteString("\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return manifests, nil
+}
+
+// parseManifest parses a single document starting at the given line of the rendered manifests, or returns nil if the document is empty.
+func parseManifest(document []byte, template string, start int) (*Manifest, error) {
+	if len(strings.TrimSpace(string(document))) == 0 {
+		return nil, nil
+	}
+	var node goyaml.Node
+	if err := goyaml.Unmarshal(document, &node); err != nil {
+		return nil, err
+	}
+	if len(node.Content) == 0 {
+		// only comments
+		return nil, nil
+	}
+	data, err := yaml.YAMLToJSON(document)
+	if err != nil {
+		return nil, err
+	}
+	obj := map[string]interface{}{}
+	if err := utiljson.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	return &Manifest{
+		Template: template,
+		Line:     start + node.Content[0].Line - 1,
+		Object:   &unstructured.Unstructured{Object: obj},
+		node:     node.Content[0],
+		offset:   start - 1,
+	}, nil
+}
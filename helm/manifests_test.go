@@ -0,0 +1,101 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rendered = `---
+# Source: app/templates/serviceaccount.yaml
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: app
+---
+# Source: app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  replicas: 2
+  template:
+    metadata:
+      annotations:
+        container.apparmor.security.beta.kubernetes.io/app: unconfined
+    spec:
+      containers:
+      - name: app
+        image: app
+        securityContext:
+          privileged: true
+      - name: sidecar
+        image: sidecar
+---
+# Source: app/templates/empty.yaml
+# only comments
+---
+# Source: app/templates/tests/test-connection.yaml
+apiVersion: v1
+kind: Pod
+metadata:
+  name: app-test-connection
+  annotations:
+    "helm.sh/hook": test
+spec:
+  hostNetwork: true
+  containers:
+  - name: wget
+    image: busybox
+`
+
+func TestParseManifests(t *testing.T) {
+	manifests, err := ParseManifests([]byte(rendered))
+	require.NoError(t, err)
+	require.Len(t, manifests, 3)
+
+	assert.Equal(t, "app/templates/serviceaccount.yaml", manifests[0].Template)
+	assert.Equal(t, 3, manifests[0].Line)
+	assert.Equal(t, "ServiceAccount", manifests[0].Object.GetKind())
+	assert.Empty(t, manifests[0].Hook())
+
+	assert.Equal(t, "app/templates/deployment.yaml", manifests[1].Template)
+	assert.Equal(t, 9, manifests[1].Line)
+	assert.Equal(t, "Deployment", manifests[1].Object.GetKind())
+	assert.Equal(t, 24, manifests[1].lineOf("spec.template.spec.containers[0].securityContext.privileged"))
+	assert.Equal(t, 18, manifests[1].lineOf("spec.template.metadata.annotations[container.apparmor.security.beta.kubernetes.io/app]"))
+	assert.Equal(t, 25, manifests[1].lineOf("spec.template.spec.containers[1].securityContext"), "missing fields are located at their parent")
+
+	assert.Equal(t, "app/templates/tests/test-connection.yaml", manifests[2].Template)
+	assert.Equal(t, 32, manifests[2].Line)
+	assert.Equal(t, "test", manifests[2].Hook())
+}
+
+func TestParseManifestsWithoutSource(t *testing.T) {
+	manifests, err := ParseManifests([]byte("apiVersion: v1\nkind: Pod\nmetadata:\n  name: foo\n"))
+	require.NoError(t, err)
+	require.Len(t, manifests, 1)
+	assert.Empty(t, manifests[0].Template)
+	assert.Equal(t, 1, manifests[0].Line)
+
+	_, err = ParseManifests([]byte("---\napiVersion: v1\nkind: [\n"))
+	assert.ErrorContains(t, err, "document at line 2")
+}
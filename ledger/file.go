@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+)
+
+// NewFileStore returns a Store appending records to the file at path, one JSON object per line.
+// Queries scan the whole file, so the file should be rotated or truncated by the operator
+// to bound its size; records of rotated files are no longer queried.
+func NewFileStore(path string) Store {
+	return &fileStore{path: path}
+}
+
+type fileStore struct {
+	path string
+	lock sync.Mutex
+}
+
+func (s *fileStore) Append(_ context.Context, r Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (s *fileStore) Query(ctx context.Context, q Query) ([]Record, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	f, err := os.Open(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", s.path, line, err)
+		}
+		if q.Matches(&r) {
+			records = append(records, r)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return limit(records, q.Limit), nil
+}
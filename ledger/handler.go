@@ -0,0 +1,99 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"k8s.io/klog/v2"
+	"k8s.io/pod-security-admission/admission"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// ParseQuery parses a query from URL parameters:
+//   - namespace, decision (Allow or Deny) and check select records by value,
+//   - since and until bound the time of the records, as RFC 3339 timestamps, or as durations before now like 168h,
+//   - limit is the maximum number of records.
+func ParseQuery(values url.Values, now time.Time) (Query, error) {
+	q := Query{
+		Namespace: values.Get("namespace"),
+		Decision:  admission.Decision(values.Get("decision")),
+		Check:     policy.CheckID(values.Get("check")),
+	}
+	switch q.Decision {
+	case "", admission.DecisionAllow, admission.DecisionDeny:
+	default:
+		return q, fmt.Errorf("decision must be one of %s, %s", admission.DecisionAllow, admission.DecisionDeny)
+	}
+	var err error
+	if q.Since, err = parseTime(values.Get("since"), now); err != nil {
+		return q, fmt.Errorf("since: %w", err)
+	}
+	if q.Until, err = parseTime(values.Get("until"), now); err != nil {
+		return q, fmt.Errorf("until: %w", err)
+	}
+	if v := values.Get("limit"); v != "" {
+		if q.Limit, err = strconv.Atoi(v); err != nil || q.Limit < 0 {
+			return q, fmt.Errorf("limit must be a non-negative integer")
+		}
+	}
+	return q, nil
+}
+
+func parseTime(v string, now time.Time) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("must be an RFC 3339 timestamp or a duration")
+	}
+	return t, nil
+}
+
+// NewHandler returns an http.Handler serving the records of the store selected by the query parameters
+// (see ParseQuery) as a JSON list.
+func NewHandler(store Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q, err := ParseQuery(r.URL.Query(), time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		records, err := store.Query(r.Context(), q)
+		if err != nil {
+			klog.FromContext(r.Context()).Error(err, "failed to query PodSecurity decisions")
+			http.Error(w, "failed to query decisions", http.StatusInternalServerError)
+			return
+		}
+		if records == nil {
+			records = []Record{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(records); err != nil {
+			klog.FromContext(r.Context()).Error(err, "failed to encode PodSecurity decisions")
+		}
+	})
+}
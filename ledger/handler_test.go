@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/pod-security-admission/admission"
+)
+
+func TestParseQuery(t *testing.T) {
+	q, err := ParseQuery(url.Values{
+		"namespace": {"a"},
+		"decision":  {"Deny"},
+		"check":     {"privileged"},
+		"since":     {"168h"},
+		"until":     {"2024-06-10T11:00:00Z"},
+		"limit":     {"5"},
+	}, now)
+	require.NoError(t, err)
+	assert.Equal(t, Query{
+		Namespace: "a",
+		Decision:  admission.DecisionDeny,
+		Check:     "privileged",
+		Since:     now.Add(-7 * 24 * time.Hour),
+		Until:     now.Add(-time.Hour),
+		Limit:     5,
+	}, q)
+
+	for _, values := range []url.Values{
+		{"decision": {"deny"}},
+		{"since": {"last week"}},
+		{"until": {"yesterday"}},
+		{"limit": {"-1"}},
+	} {
+		_, err := ParseQuery(values, now)
+		assert.Error(t, err, "%v", values)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	store := NewMemoryStore(10)
+	for _, r := range testRecords() {
+		require.NoError(t, store.Append(context.Background(), r))
+	}
+	handler := NewHandler(store)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/decisions?namespace=b&decision=Deny", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var records []Record
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &records))
+	assert.Equal(t, []string{"b/denied"}, names(records))
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/decisions?namespace=c", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, "[]", w.Body.String())
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/decisions?limit=all", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
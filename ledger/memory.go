@@ -0,0 +1,64 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"context"
+	"sync"
+)
+
+// NewMemoryStore returns a Store keeping the most recent records in memory, up to capacity.
+// Records are lost on restart, so it is suited for a single replica or for tests.
+func NewMemoryStore(capacity int) Store {
+	return &memoryStore{records: make([]Record, 0, capacity), capacity: capacity}
+}
+
+type memoryStore struct {
+	lock     sync.RWMutex
+	records  []Record
+	capacity int
+	// next is the index of the oldest record once the store is full.
+	next int
+}
+
+func (s *memoryStore) Append(_ context.Context, r Record) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.capacity <= 0 {
+		return nil
+	}
+	if len(s.records) < s.capacity {
+		s.records = append(s.records, r)
+		return nil
+	}
+	s.records[s.next] = r
+	s.next = (s.next + 1) % s.capacity
+	return nil
+}
+
+func (s *memoryStore) Query(_ context.Context, q Query) ([]Record, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	var records []Record
+	for i := range s.records {
+		r := &s.records[(s.next+i)%len(s.records)]
+		if q.Matches(r) {
+			records = append(records, *r)
+		}
+	}
+	return limit(records, q.Limit), nil
+}
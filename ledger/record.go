@@ -0,0 +1,112 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"context"
+	"encoding/hex"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/pod-security-admission/admission"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// Record is a compact record of the enforce decision of a pod.
+type Record struct {
+	// Time is the time of the decision.
+	Time time.Time `json:"time"`
+	// Namespace and Name identify the pod. Name may be empty for pods with generated names.
+	Namespace string `json:"namespace"`
+	Name      string `json:"name,omitempty"`
+	// Workload is the WorkloadHash of the pod, identical for the pods of a controller.
+	Workload string `json:"workload"`
+	// Decision is the enforce decision.
+	Decision admission.Decision `json:"decision"`
+	// Policy is the enforced level & version.
+	Policy string `json:"policy"`
+	// Checks are the IDs of the checks violated by the pod.
+	Checks []policy.CheckID `json:"checks,omitempty"`
+}
+
+// WorkloadHash returns a short policy.PodHash of the pod sanitized with policy.SanitizePod,
+// which is identical for pods with the same fields evaluated by the checks, e.g. the pods of a controller.
+func WorkloadHash(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec) string {
+	sanitizedMetadata, sanitizedSpec := policy.SanitizePod(podMetadata, podSpec)
+	// Workloads are identified across namespaces.
+	sanitizedMetadata.Namespace = ""
+	sum, err := policy.PodHash(sanitizedMetadata, sanitizedSpec)
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(sum[:8])
+}
+
+// Query selects records. Zero fields match all records.
+type Query struct {
+	Namespace string
+	Decision  admission.Decision
+	// Check matches records of pods violating the check.
+	Check policy.CheckID
+	// Since and Until bound the time of the records, inclusively.
+	Since time.Time
+	Until time.Time
+	// Limit is the maximum number of records returned, keeping the most recent ones, if non-zero.
+	Limit int
+}
+
+// Matches returns true if the record is selected by the query, regardless of the Limit.
+func (q *Query) Matches(r *Record) bool {
+	if q.Namespace != "" && r.Namespace != q.Namespace {
+		return false
+	}
+	if q.Decision != "" && r.Decision != q.Decision {
+		return false
+	}
+	if !q.Since.IsZero() && r.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && r.Time.After(q.Until) {
+		return false
+	}
+	if q.Check != "" {
+		for _, id := range r.Checks {
+			if id == q.Check {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Store persists records.
+type Store interface {
+	// Append persists the record.
+	Append(ctx context.Context, r Record) error
+	// Query returns the records selected by the query, ordered by time.
+	Query(ctx context.Context, q Query) ([]Record, error)
+}
+
+// limit returns the last limit records, if limit is non-zero.
+func limit(records []Record, limit int) []Record {
+	if limit > 0 && len(records) > limit {
+		return records[len(records)-limit:]
+	}
+	return records
+}
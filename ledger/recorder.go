@@ -0,0 +1,61 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/pod-security-admission/admission"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+	"k8s.io/utils/clock"
+)
+
+// NewRecorder returns an admission.DecisionRecorder appending a Record of each decision to the store.
+// If deniedOnly is true, only Deny decisions are recorded.
+// Records are appended synchronously, on the admission path of the request, and failures are logged.
+func NewRecorder(store Store, deniedOnly bool) admission.DecisionRecorder {
+	return &recorder{store: store, deniedOnly: deniedOnly, clock: clock.RealClock{}}
+}
+
+type recorder struct {
+	store      Store
+	deniedOnly bool
+	clock      clock.PassiveClock
+}
+
+func (r *recorder) RecordDecision(ctx context.Context, decision admission.Decision, lv api.LevelVersion, checks []policy.CheckID, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, attrs api.Attributes) {
+	if r.deniedOnly && decision != admission.DecisionDeny {
+		return
+	}
+	record := Record{
+		Time:      r.clock.Now().UTC().Truncate(time.Second),
+		Namespace: attrs.GetNamespace(),
+		Name:      attrs.GetName(),
+		Workload:  WorkloadHash(podMetadata, podSpec),
+		Decision:  decision,
+		Policy:    lv.String(),
+		Checks:    checks,
+	}
+	if err := r.store.Append(ctx, record); err != nil {
+		klog.FromContext(ctx).Error(err, "failed to record PodSecurity decision", "namespace", attrs.GetNamespace(), "name", attrs.GetName())
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/pod-security-admission/admission"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(10)
+	r := &recorder{store: store, deniedOnly: true, clock: clocktesting.NewFakePassiveClock(now)}
+	lv := api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}
+	attrs := func(name string) api.Attributes {
+		return &api.AttributesRecord{
+			Name:      name,
+			Namespace: "ns",
+			Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+		}
+	}
+	privileged := true
+	podSpec := &corev1.PodSpec{Containers: []corev1.Container{{
+		Name:            "app",
+		SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+	}}}
+
+	r.RecordDecision(ctx, admission.DecisionAllow, lv, nil, &metav1.ObjectMeta{}, &corev1.PodSpec{}, attrs("allowed"))
+	r.RecordDecision(ctx, admission.DecisionDeny, lv, []policy.CheckID{"privileged"}, &metav1.ObjectMeta{Name: "denied"}, podSpec, attrs("denied"))
+
+	records, err := store.Query(ctx, Query{})
+	require.NoError(t, err)
+	require.Len(t, records, 1, "allowed pods should not be recorded")
+	assert.Equal(t, Record{
+		Time:      now,
+		Namespace: "ns",
+		Name:      "denied",
+		Workload:  WorkloadHash(&metav1.ObjectMeta{}, podSpec),
+		Decision:  admission.DecisionDeny,
+		Policy:    "baseline:latest",
+		Checks:    []policy.CheckID{"privileged"},
+	}, records[0])
+}
+
+func TestWorkloadHash(t *testing.T) {
+	podSpec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:v1"}}}
+	hash := WorkloadHash(&metav1.ObjectMeta{Name: "app-1", Namespace: "a"}, podSpec)
+	assert.Len(t, hash, 16)
+	assert.Equal(t, hash, WorkloadHash(&metav1.ObjectMeta{Name: "app-2", Namespace: "b", Labels: map[string]string{"pod-template-hash": "1"}}, podSpec),
+		"fields not evaluated by the checks should not change the hash")
+	assert.NotEqual(t, hash, WorkloadHash(&metav1.ObjectMeta{}, &corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:v2"}}}))
+}
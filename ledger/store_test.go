@@ -0,0 +1,126 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/pod-security-admission/admission"
+	"k8s.io/pod-security-admission/policy"
+)
+
+var now = time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+
+func testRecords() []Record {
+	return []Record{
+		{Time: now.Add(-10 * 24 * time.Hour), Namespace: "a", Name: "old", Decision: admission.DecisionDeny, Checks: []policy.CheckID{"privileged"}},
+		{Time: now.Add(-2 * time.Hour), Namespace: "a", Name: "denied", Decision: admission.DecisionDeny, Checks: []policy.CheckID{"hostNamespaces", "privileged"}},
+		{Time: now.Add(-time.Hour), Namespace: "a", Name: "allowed", Decision: admission.DecisionAllow},
+		{Time: now, Namespace: "b", Name: "denied", Decision: admission.DecisionDeny, Checks: []policy.CheckID{"hostPorts"}},
+	}
+}
+
+func names(records []Record) []string {
+	var names []string
+	for _, r := range records {
+		names = append(names, r.Namespace+"/"+r.Name)
+	}
+	return names
+}
+
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+	for _, r := range testRecords() {
+		require.NoError(t, store.Append(ctx, r))
+	}
+	testCases := []struct {
+		desc   string
+		query  Query
+		expect []string
+	}{{
+		desc:   "all",
+		expect: []string{"a/old", "a/denied", "a/allowed", "b/denied"},
+	}, {
+		desc:   "denied in namespace this week",
+		query:  Query{Namespace: "a", Decision: admission.DecisionDeny, Since: now.Add(-7 * 24 * time.Hour)},
+		expect: []string{"a/denied"},
+	}, {
+		desc:   "check",
+		query:  Query{Check: "privileged"},
+		expect: []string{"a/old", "a/denied"},
+	}, {
+		desc:   "until",
+		query:  Query{Until: now.Add(-time.Hour)},
+		expect: []string{"a/old", "a/denied", "a/allowed"},
+	}, {
+		desc:   "limit keeps the most recent records",
+		query:  Query{Decision: admission.DecisionDeny, Limit: 2},
+		expect: []string{"a/denied", "b/denied"},
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			records, err := store.Query(ctx, tc.query)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expect, names(records))
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore(10))
+
+	t.Run("capacity", func(t *testing.T) {
+		ctx := context.Background()
+		store := NewMemoryStore(2)
+		for _, r := range testRecords() {
+			require.NoError(t, store.Append(ctx, r))
+		}
+		records, err := store.Query(ctx, Query{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a/allowed", "b/denied"}, names(records))
+	})
+}
+
+func TestFileStore(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "decisions.jsonl")
+
+	records, err := NewFileStore(path).Query(context.Background(), Query{})
+	require.NoError(t, err)
+	assert.Empty(t, records, "missing files should have no records")
+
+	testStore(t, NewFileStore(path))
+
+	t.Run("records are persisted", func(t *testing.T) {
+		records, err := NewFileStore(path).Query(context.Background(), Query{})
+		require.NoError(t, err)
+		assert.Equal(t, testRecords(), records)
+	})
+
+	t.Run("corrupt", func(t *testing.T) {
+		path := filepath.Join(dir, "corrupt.jsonl")
+		require.NoError(t, os.WriteFile(path, []byte("{}\nnot json\n"), 0644))
+		_, err := NewFileStore(path).Query(context.Background(), Query{})
+		assert.ErrorContains(t, err, "corrupt.jsonl:2")
+	})
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// watchBufferSize is the number of records buffered for each watcher.
+// Records are dropped for watchers falling further behind, rather than blocking the admission path.
+const watchBufferSize = 100
+
+// Broadcaster is a Store appending records to a wrapped store, and sending them to the watchers subscribed by Watch.
+type Broadcaster struct {
+	store Store
+
+	lock     sync.Mutex
+	watchers map[*watcher]struct{}
+}
+
+type watcher struct {
+	query   Query
+	records chan Record
+}
+
+// NewBroadcaster returns a Broadcaster wrapping the store.
+func NewBroadcaster(store Store) *Broadcaster {
+	return &Broadcaster{store: store, watchers: map[*watcher]struct{}{}}
+}
+
+// Append appends the record to the wrapped store, and sends it to the matching watchers even if the store failed.
+func (b *Broadcaster) Append(ctx context.Context, r Record) error {
+	err := b.store.Append(ctx, r)
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for w := range b.watchers {
+		if !w.query.Matches(&r) {
+			continue
+		}
+		select {
+		case w.records <- r:
+		default:
+		}
+	}
+	return err
+}
+
+// Query returns the records of the wrapped store selected by the query.
+func (b *Broadcaster) Query(ctx context.Context, q Query) ([]Record, error) {
+	return b.store.Query(ctx, q)
+}
+
+// Watch returns a channel receiving the records appended from now on that match the query, regardless of its Limit,
+// and a function unsubscribing the watcher and closing the channel.
+func (b *Broadcaster) Watch(q Query) (<-chan Record, func()) {
+	w := &watcher{query: q, records: make(chan Record, watchBufferSize)}
+	b.lock.Lock()
+	b.watchers[w] = struct{}{}
+	b.lock.Unlock()
+	var once sync.Once
+	return w.records, func() {
+		once.Do(func() {
+			b.lock.Lock()
+			defer b.lock.Unlock()
+			delete(b.watchers, w)
+			close(w.records)
+		})
+	}
+}
+
+// NewWatchHandler returns an http.Handler streaming the records appended to the broadcaster,
+// selected by the namespace, decision and check query parameters (see ParseQuery), one JSON object per line,
+// until the client disconnects.
+func NewWatchHandler(b *Broadcaster) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q, err := ParseQuery(r.URL.Query(), time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+			return
+		}
+		// Only new records are streamed, so the time bounds and the limit do not apply.
+		q = Query{Namespace: q.Namespace, Decision: q.Decision, Check: q.Check}
+		records, stop := b.Watch(q)
+		defer stop()
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+		encoder := json.NewEncoder(w)
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case record := <-records:
+				if err := encoder.Encode(record); err != nil {
+					klog.FromContext(r.Context()).V(2).Info("stopped streaming PodSecurity decisions", "err", err)
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	})
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ledger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/pod-security-admission/admission"
+)
+
+func TestBroadcaster(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(10)
+	b := NewBroadcaster(store)
+
+	denied, stopDenied := b.Watch(Query{Decision: admission.DecisionDeny, Limit: 1})
+	all, stopAll := b.Watch(Query{})
+	for _, r := range testRecords() {
+		require.NoError(t, b.Append(ctx, r))
+	}
+	stopDenied()
+	stopAll()
+	// Stopping twice is a no-op.
+	stopAll()
+
+	var deniedRecords, allRecords []Record
+	for r := range denied {
+		deniedRecords = append(deniedRecords, r)
+	}
+	for r := range all {
+		allRecords = append(allRecords, r)
+	}
+	assert.Equal(t, []string{"a/old", "a/denied", "b/denied"}, names(deniedRecords))
+	assert.Equal(t, []string{"a/old", "a/denied", "a/allowed", "b/denied"}, names(allRecords))
+
+	// Records are still appended to the wrapped store.
+	records, err := b.Query(ctx, Query{})
+	require.NoError(t, err)
+	assert.Len(t, records, 4)
+
+	// Records appended once stopped are not sent.
+	require.NoError(t, b.Append(ctx, testRecords()[0]))
+	assert.Empty(t, b.watchers)
+}
+
+func TestBroadcasterSlowWatcher(t *testing.T) {
+	b := NewBroadcaster(NewMemoryStore(0))
+	records, stop := b.Watch(Query{})
+	for i := 0; i < watchBufferSize+10; i++ {
+		require.NoError(t, b.Append(context.Background(), Record{Namespace: "a"}))
+	}
+	stop()
+	n := 0
+	for range records {
+		n++
+	}
+	assert.Equal(t, watchBufferSize, n)
+}
+
+func TestWatchHandler(t *testing.T) {
+	b := NewBroadcaster(NewMemoryStore(10))
+	server := httptest.NewServer(NewWatchHandler(b))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?decision=Invalid")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?namespace=a&decision=Deny&since=1h", nil)
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+
+	// The watcher is subscribed once the response headers are received.
+	for _, r := range testRecords() {
+		require.NoError(t, b.Append(context.Background(), r))
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	var records []Record
+	for len(records) < 2 && scanner.Scan() {
+		var r Record
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &r))
+		records = append(records, r)
+	}
+	// The time bounds of the query do not apply to streamed records.
+	assert.Equal(t, []string{"a/old", "a/denied"}, names(records))
+}
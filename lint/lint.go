@@ -0,0 +1,241 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+	"sigs.k8s.io/yaml"
+)
+
+var scheme = runtime.NewScheme()
+var codecs = serializer.NewCodecFactory(scheme)
+
+func init() {
+	utilruntime.Must(corev1.AddToScheme(scheme))
+	utilruntime.Must(appsv1.AddToScheme(scheme))
+	utilruntime.Must(batchv1.AddToScheme(scheme))
+}
+
+// documentSeparator separates the documents of a YAML stream.
+const documentSeparator = "---"
+
+// Result is the evaluation of an object of a manifest.
+type Result struct {
+	// File is the name of the evaluated file or stream.
+	File string
+	// Line is the line of the document of the object in the file, starting at 1.
+	Line int
+	// Document is the index of the document of the object among the documents of the file with content, starting at 0.
+	Document int
+	// Item is the index of the object in the List of the document, or -1 if the document is not a List.
+	Item int
+	// GroupVersionKind is the kind of the object, if known.
+	GroupVersionKind schema.GroupVersionKind
+	// Namespace and Name identify the object, if decoded.
+	Namespace string
+	Name      string
+	// Evaluated is true if the object has a pod template supported by policy.ExtractPodTemplate.
+	// Other objects, e.g. Services or custom resources, are not evaluated.
+	Evaluated bool
+	// Results are the results of the checks evaluating the pod template of the object.
+	// Their field errors, set if the evaluator is created WithFieldErrors, are rooted at the object (see policy.EvaluateWorkload).
+	Results []policy.CheckResult
+	// Err is the error decoding the object, if any.
+	Err error
+}
+
+// Allowed returns true if the object was evaluated without error and allowed by all the checks.
+func (r *Result) Allowed() bool {
+	return r.Err == nil && policy.AggregateCheckResults(r.Results).Allowed
+}
+
+// Location returns the location of the object, e.g. deploy.yaml:12 (Deployment ns/name).
+func (r *Result) Location() string {
+	location := fmt.Sprintf("%s:%d", r.File, r.Line)
+	if r.Item >= 0 {
+		location += fmt.Sprintf("[%d]", r.Item)
+	}
+	if len(r.GroupVersionKind.Kind) == 0 {
+		return location
+	}
+	name := r.Name
+	if len(r.Namespace) > 0 {
+		name = r.Namespace + "/" + r.Name
+	}
+	return fmt.Sprintf("%s (%s %s)", location, r.GroupVersionKind.Kind, name)
+}
+
+// LintFile evaluates the objects of the manifest file against the level & version, like LintData.
+func LintFile(evaluator policy.Evaluator, lv api.LevelVersion, file string) ([]Result, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return LintData(evaluator, lv, file, data), nil
+}
+
+// LintReader evaluates the objects of the named manifest stream against the level & version, like LintData.
+func LintReader(evaluator policy.Evaluator, lv api.LevelVersion, name string, reader io.Reader) ([]Result, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return LintData(evaluator, lv, name, data), nil
+}
+
+// LintData evaluates the objects of the named manifest against the level & version.
+// The manifest is a JSON document or a stream of YAML documents separated by ---,
+// each holding a single object or a List of objects.
+// A result is returned for each object, in order, including the objects not evaluated and those failing to decode.
+// Documents without content, e.g. only comments, are ignored.
+func LintData(evaluator policy.Evaluator, lv api.LevelVersion, name string, data []byte) []Result {
+	var results []Result
+	for i, doc := range splitDocuments(data) {
+		result := Result{File: name, Line: doc.line, Document: i, Item: -1}
+		obj, err := decode(&result, doc.data)
+		if err != nil {
+			result.Err = err
+			results = append(results, result)
+			continue
+		}
+		if obj == nil {
+			results = append(results, result)
+			continue
+		}
+		if !meta.IsListType(obj) {
+			results = append(results, evaluate(evaluator, lv, result, obj))
+			continue
+		}
+
+		items, err := meta.ExtractList(obj)
+		if err != nil {
+			result.Err = err
+			results = append(results, result)
+			continue
+		}
+		for j, item := range items {
+			itemResult := result
+			itemResult.Item = j
+			itemResult.GroupVersionKind = schema.GroupVersionKind{}
+			if unknown, ok := item.(*runtime.Unknown); ok {
+				// items of v1 Lists are decoded as raw data
+				item, itemResult.Err = decode(&itemResult, unknown.Raw)
+				if item == nil {
+					results = append(results, itemResult)
+					continue
+				}
+			}
+			results = append(results, evaluate(evaluator, lv, itemResult, item))
+		}
+	}
+	return results
+}
+
+// decode decodes the object, setting its kind on the result. Objects of kinds not registered, e.g. custom resources,
+// are not decoded and return no error, setting their name on the result.
+func decode(result *Result, data []byte) (runtime.Object, error) {
+	obj, gvk, err := codecs.UniversalDeserializer().Decode(data, nil, nil)
+	if gvk != nil {
+		result.GroupVersionKind = *gvk
+	}
+	if err == nil {
+		return obj, nil
+	}
+	if !runtime.IsNotRegisteredError(err) {
+		return nil, err
+	}
+	partial := &metav1.PartialObjectMetadata{}
+	if err := yaml.Unmarshal(data, partial); err == nil {
+		result.GroupVersionKind = partial.GroupVersionKind()
+		result.Namespace, result.Name = partial.Namespace, partial.Name
+	}
+	return nil, nil
+}
+
+// evaluate evaluates the pod template of the object, if any.
+func evaluate(evaluator policy.Evaluator, lv api.LevelVersion, result Result, obj runtime.Object) Result {
+	if kind := obj.GetObjectKind().GroupVersionKind(); !kind.Empty() {
+		result.GroupVersionKind = kind
+	} else if kinds, _, err := scheme.ObjectKinds(obj); err == nil {
+		// the items of typed Lists have no kind set
+		result.GroupVersionKind = kinds[0]
+	}
+	if accessor, err := meta.Accessor(obj); err == nil {
+		result.Namespace, result.Name = accessor.GetNamespace(), accessor.GetName()
+	}
+	_, checkResults, err := policy.EvaluateWorkload(evaluator, lv, obj)
+	if err != nil {
+		// not a pod or workload
+		return result
+	}
+	result.Evaluated = true
+	result.Results = checkResults
+	return result
+}
+
+// document is a document of a manifest, with the line it starts at.
+type document struct {
+	data []byte
+	line int
+}
+
+// splitDocuments splits the manifest into its documents, separated by --- lines like by yaml.NewYAMLReader,
+// skipping the documents without content. Documents start at their first line with content.
+func splitDocuments(data []byte) []document {
+	var docs []document
+	var current document
+	flush := func() {
+		if current.line > 0 {
+			docs = append(docs, current)
+		}
+		current = document{}
+	}
+	for i, line := range bytes.SplitAfter(data, []byte("\n")) {
+		if rest, ok := bytes.CutPrefix(line, []byte(documentSeparator)); ok {
+			if trimmed := strings.TrimSpace(string(rest)); len(trimmed) == 0 || trimmed[0] == '#' {
+				flush()
+				continue
+			}
+		}
+		if current.line == 0 {
+			trimmed := strings.TrimSpace(string(line))
+			if len(trimmed) == 0 || trimmed[0] == '#' {
+				continue
+			}
+			current.line = i + 1
+		}
+		current.data = append(current.data, line...)
+	}
+	flush()
+	return docs
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+)
+
+const manifests = `# leading comment
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: team-a
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+        securityContext:
+          privileged: true
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+# only comments
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: w
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: a
+  spec:
+    containers:
+    - name: a
+      image: busybox
+- apiVersion: batch/v1
+  kind: CronJob
+  metadata:
+    name: b
+  spec:
+    schedule: "* * * * *"
+    jobTemplate:
+      spec:
+        template:
+          spec:
+            hostNetwork: true
+            containers:
+            - name: b
+              image: busybox
+---
+apiVersion: v1
+kind: PodList
+items:
+- metadata:
+    name: c
+  spec:
+    containers:
+    - name: c
+      image: busybox
+---
+kind: [
+`
+
+func TestLintData(t *testing.T) {
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks(), policy.WithFieldErrors())
+	require.NoError(t, err)
+	lv := api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}
+
+	results := LintData(evaluator, lv, "manifests.yaml", []byte(manifests))
+	require.Len(t, results, 7)
+
+	type summary struct {
+		location  string
+		document  int
+		evaluated bool
+		allowed   bool
+		err       bool
+	}
+	var summaries []summary
+	for _, r := range results {
+		summaries = append(summaries, summary{location: r.Location(), document: r.Document, evaluated: r.Evaluated, allowed: r.Allowed(), err: r.Err != nil})
+	}
+	assert.Equal(t, []summary{
+		{location: "manifests.yaml:3 (Deployment team-a/web)", document: 0, evaluated: true},
+		{location: "manifests.yaml:17 (Service web)", document: 1, allowed: true},
+		{location: "manifests.yaml:24 (Widget w)", document: 2, allowed: true},
+		{location: "manifests.yaml:29[0] (Pod a)", document: 3, evaluated: true, allowed: true},
+		{location: "manifests.yaml:29[1] (CronJob b)", document: 3, evaluated: true},
+		{location: "manifests.yaml:55[0] (Pod c)", document: 4, evaluated: true, allowed: true},
+		{location: "manifests.yaml:65", document: 5, err: true},
+	}, summaries)
+	assert.Equal(t, schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}, results[2].GroupVersionKind)
+
+	// field errors are rooted at the objects
+	deployment := policy.AggregateCheckResults(results[0].Results)
+	assert.Equal(t, []string{"privileged"}, deployment.ForbiddenReasons)
+	require.Len(t, deployment.Violations, 1)
+	assert.Equal(t, "spec.template.spec.containers[0].securityContext.privileged", deployment.Violations[0].Fields[0].Path)
+	cronJob := policy.AggregateCheckResults(results[4].Results)
+	assert.Equal(t, []string{"host namespaces"}, cronJob.ForbiddenReasons)
+	assert.Equal(t, "spec.jobTemplate.spec.template.spec.hostNetwork", cronJob.Violations[0].Fields[0].Path)
+}
+
+func TestLintJSON(t *testing.T) {
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+	require.NoError(t, err)
+	lv := api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()}
+
+	results, err := LintReader(evaluator, lv, "pod.json", strings.NewReader(`
+
+{
+  "apiVersion": "v1",
+  "kind": "Pod",
+  "metadata": {"name": "a", "namespace": "ns"},
+  "spec": {"containers": [{"name": "a", "image": "busybox"}]}
+}`))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "pod.json:3 (Pod ns/a)", results[0].Location())
+	assert.True(t, results[0].Evaluated)
+	assert.False(t, results[0].Allowed())
+}
+
+func TestLintFile(t *testing.T) {
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+	require.NoError(t, err)
+	lv := api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}
+
+	file := filepath.Join(t.TempDir(), "manifests.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(manifests), 0o600))
+	results, err := LintFile(evaluator, lv, file)
+	require.NoError(t, err)
+	assert.Len(t, results, 7)
+	assert.Equal(t, file, results[0].File)
+
+	_, err = LintFile(evaluator, lv, filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
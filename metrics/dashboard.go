@@ -0,0 +1,219 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// requestLabels are the labels describing the admission request of a metric,
+// aggregated away by the dashboard panels to keep them readable.
+var requestLabels = map[string]bool{
+	"request_operation": true,
+	"resource":          true,
+	"subresource":       true,
+	"policy_version":    true,
+}
+
+// dashboardPanel is a panel of a Grafana dashboard.
+type dashboardPanel struct {
+	ID          int               `json:"id"`
+	Type        string            `json:"type"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Datasource  map[string]string `json:"datasource"`
+	GridPos     map[string]int    `json:"gridPos"`
+	Targets     []map[string]any  `json:"targets"`
+}
+
+// GrafanaDashboard returns the JSON model of a Grafana dashboard with a panel for each of the metrics:
+// the per-second rate of counters by their labels, excluding the labels of the admission request and policy version,
+// the 99th percentile of histograms by the same labels, with their exemplars, and a table of the series of gauges. Import it into Grafana, selecting the Prometheus datasource scraping the webhook.
+func GrafanaDashboard(descriptors []MetricDescriptor) ([]byte, error) {
+	datasource := map[string]string{"type": "prometheus", "uid": "${datasource}"}
+	panels := make([]dashboardPanel, 0, len(descriptors))
+	for i, d := range descriptors {
+		panel := dashboardPanel{
+			ID:          i + 1,
+			Title:       d.Name,
+			Description: d.Help,
+			Datasource:  datasource,
+			GridPos:     map[string]int{"h": 8, "w": 12, "x": 12 * (i % 2), "y": 8 * (i / 2)},
+		}
+		var by, legend []string
+		for _, label := range d.Labels {
+			if !requestLabels[label] {
+				by = append(by, label)
+				legend = append(legend, "{{"+label+"}}")
+			}
+		}
+		switch d.Type {
+		case MetricTypeCounter:
+			expr := fmt.Sprintf("sum(rate(%s[$__rate_interval]))", d.Name)
+			if len(by) > 0 {
+				expr = fmt.Sprintf("sum by (%s) (rate(%s[$__rate_interval]))", strings.Join(by, ", "), d.Name)
+			}
+			panel.Type = "timeseries"
+			panel.Targets = []map[string]any{{"refId": "A", "datasource": datasource, "expr": expr, "legendFormat": strings.Join(legend, " ")}}
+		case MetricTypeHistogram:
+			// the exemplars of the buckets link the latency to the traces of the requests
+			expr := fmt.Sprintf("histogram_quantile(0.99, sum by (%s) (rate(%s_bucket[$__rate_interval])))", strings.Join(append(by, "le"), ", "), d.Name)
+			panel.Type = "timeseries"
+			panel.Targets = []map[string]any{{"refId": "A", "datasource": datasource, "expr": expr, "legendFormat": strings.Join(legend, " "), "exemplar": true}}
+		case MetricTypeGauge:
+			panel.Type = "table"
+			panel.Targets = []map[string]any{{"refId": "A", "datasource": datasource, "expr": d.Name, "format": "table", "instant": true}}
+		default:
+			return nil, fmt.Errorf("metric %s: unsupported type %q", d.Name, d.Type)
+		}
+		panels = append(panels, panel)
+	}
+
+	dashboard := map[string]any{
+		"title":         "Pod Security Admission",
+		"uid":           "pod-security-admission",
+		"tags":          []string{"pod-security"},
+		"schemaVersion": 39,
+		"time":          map[string]string{"from": "now-6h", "to": "now"},
+		"refresh":       "1m",
+		"templating": map[string]any{"list": []map[string]any{{
+			"name":  "datasource",
+			"label": "Datasource",
+			"type":  "datasource",
+			"query": "prometheus",
+		}}},
+		"panels": panels,
+	}
+	return json.MarshalIndent(dashboard, "", "  ")
+}
+
+// alertRule is a Prometheus alerting rule on a metric.
+type alertRule struct {
+	Alert       string            `json:"alert"`
+	Expr        string            `json:"expr"`
+	For         string            `json:"for,omitempty"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+
+	// metric and labels are the metric and labels read by Expr, which must be described.
+	metric string
+	labels []string
+}
+
+// alertRules are the alerting rules generated by AlertRules.
+var alertRules = []alertRule{
+	{
+		Alert:       "PodSecurityFatalErrors",
+		Expr:        `sum(rate(pod_security_errors_total{fatal="true"}[5m])) > 0`,
+		For:         "10m",
+		Labels:      map[string]string{"severity": "warning"},
+		Annotations: map[string]string{"summary": "PodSecurity admission is rejecting requests it cannot evaluate, e.g. because namespace lookups fail or evaluations time out."},
+		metric:      "pod_security_errors_total",
+		labels:      []string{"fatal"},
+	},
+	{
+		Alert:       "PodSecurityCheckDeadlinesExceeded",
+		Expr:        `sum by (check_id) (rate(pod_security_check_errors_total{reason="deadline_exceeded"}[5m])) > 0`,
+		For:         "10m",
+		Labels:      map[string]string{"severity": "warning"},
+		Annotations: map[string]string{"summary": "PodSecurity check {{ $labels.check_id }} is exceeding its deadline, failing the evaluation of pods."},
+		metric:      "pod_security_check_errors_total",
+		labels:      []string{"check_id", "reason"},
+	},
+	{
+		Alert:       "PodSecurityNamespaceLookupDenials",
+		Expr:        `sum(rate(pod_security_namespace_lookup_failures_total{resolution="deny"}[5m])) > 0`,
+		For:         "10m",
+		Labels:      map[string]string{"severity": "warning"},
+		Annotations: map[string]string{"summary": "PodSecurity admission is rejecting requests whose namespace cannot be fetched."},
+		metric:      "pod_security_namespace_lookup_failures_total",
+		labels:      []string{"resolution"},
+	},
+	{
+		Alert:       "PodSecurityConfigReloadFailures",
+		Expr:        `sum(increase(pod_security_config_reloads_total{result="failure"}[15m])) > 0`,
+		Labels:      map[string]string{"severity": "warning"},
+		Annotations: map[string]string{"summary": "The changed PodSecurity admission configuration failed to load, the previous configuration is still in effect."},
+		metric:      "pod_security_config_reloads_total",
+		labels:      []string{"result"},
+	},
+	{
+		Alert:       "PodSecurityNondeterministicDecisions",
+		Expr:        `sum(increase(pod_security_nondeterministic_decisions_total[1h])) > 0`,
+		Labels:      map[string]string{"severity": "warning"},
+		Annotations: map[string]string{"summary": "Re-evaluations of sampled pods returned different decisions, the checks depend on state they should not."},
+		metric:      "pod_security_nondeterministic_decisions_total",
+	},
+	{
+		Alert:       "PodSecurityChecksSchemaMismatch",
+		Expr:        `count(count by (schema_version) (pod_security_checks_schema_info)) > 1`,
+		For:         "30m",
+		Labels:      map[string]string{"severity": "warning"},
+		Annotations: map[string]string{"summary": "PodSecurity admission replicas enforce different checks, e.g. after an incomplete rollout."},
+		metric:      "pod_security_checks_schema_info",
+		labels:      []string{"schema_version"},
+	},
+	{
+		Alert:       "PodSecurityOlderAuditOrWarnVersion",
+		Expr:        `sum(increase(pod_security_version_skew_total{skew="older"}[1h])) > 0`,
+		Labels:      map[string]string{"severity": "info"},
+		Annotations: map[string]string{"summary": "Namespaces set audit or warn versions older than their enforce version, which is likely a misconfiguration."},
+		metric:      "pod_security_version_skew_total",
+		labels:      []string{"skew"},
+	},
+}
+
+// AlertRules returns a Prometheus rule file with alerting rules on the metrics, e.g. for fatal evaluation errors.
+// Rules on metrics that are not described are omitted, and an error is returned if a rule reads an undescribed label.
+func AlertRules(descriptors []MetricDescriptor) ([]byte, error) {
+	described := map[string]MetricDescriptor{}
+	for _, d := range descriptors {
+		described[d.Name] = d
+	}
+	var rules []alertRule
+	for _, rule := range alertRules {
+		d, ok := described[rule.metric]
+		if !ok {
+			continue
+		}
+		for _, label := range rule.labels {
+			if !hasLabel(d, label) {
+				return nil, fmt.Errorf("alert %s: metric %s has no label %s", rule.Alert, rule.metric, label)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return yaml.Marshal(map[string]any{
+		"groups": []map[string]any{{
+			"name":  "pod-security-admission",
+			"rules": rules,
+		}},
+	})
+}
+
+func hasLabel(d MetricDescriptor, label string) bool {
+	for _, l := range d.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
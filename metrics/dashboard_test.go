@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func TestGrafanaDashboard(t *testing.T) {
+	recorder := NewPrometheusRecorder(testVersion)
+	recorder.EnableCheckViolations()
+	descriptors := recorder.Descriptors()
+
+	data, err := GrafanaDashboard(descriptors)
+	require.NoError(t, err)
+	var dashboard struct {
+		UID    string `json:"uid"`
+		Panels []struct {
+			Type    string `json:"type"`
+			Title   string `json:"title"`
+			Targets []struct {
+				Expr string `json:"expr"`
+			} `json:"targets"`
+		} `json:"panels"`
+	}
+	require.NoError(t, json.Unmarshal(data, &dashboard))
+	assert.Equal(t, "pod-security-admission", dashboard.UID)
+	require.Len(t, dashboard.Panels, len(descriptors))
+
+	exprs := map[string]string{}
+	for i, panel := range dashboard.Panels {
+		assert.Equal(t, descriptors[i].Name, panel.Title)
+		require.Len(t, panel.Targets, 1)
+		exprs[panel.Title] = panel.Targets[0].Expr
+	}
+	assert.Equal(t, "sum by (decision, policy_level, mode) (rate(pod_security_evaluations_total[$__rate_interval]))", exprs["pod_security_evaluations_total"])
+	assert.Equal(t, "sum by (check_id, policy_level, mode) (rate(pod_security_check_violations_total[$__rate_interval]))", exprs["pod_security_check_violations_total"])
+	assert.Equal(t, "pod_security_checks_schema_info", exprs["pod_security_checks_schema_info"])
+	assert.Equal(t, "histogram_quantile(0.99, sum by (decision, le) (rate(pod_security_evaluation_duration_seconds_bucket[$__rate_interval])))", exprs["pod_security_evaluation_duration_seconds"])
+
+	_, err = GrafanaDashboard([]MetricDescriptor{{Name: "unknown", Type: "summary"}})
+	assert.Error(t, err)
+}
+
+func TestAlertRules(t *testing.T) {
+	data, err := AlertRules(NewPrometheusRecorder(testVersion).Descriptors())
+	require.NoError(t, err)
+	var file struct {
+		Groups []struct {
+			Name  string `json:"name"`
+			Rules []struct {
+				Alert string `json:"alert"`
+				Expr  string `json:"expr"`
+			} `json:"rules"`
+		} `json:"groups"`
+	}
+	require.NoError(t, yaml.Unmarshal(data, &file))
+	require.Len(t, file.Groups, 1)
+	require.Len(t, file.Groups[0].Rules, len(alertRules))
+	for i, rule := range file.Groups[0].Rules {
+		assert.Equal(t, alertRules[i].Alert, rule.Alert)
+		assert.Equal(t, alertRules[i].Expr, rule.Expr)
+	}
+
+	// Rules on undescribed metrics are omitted.
+	data, err = AlertRules([]MetricDescriptor{{Name: "pod_security_errors_total", Type: MetricTypeCounter, Labels: []string{"fatal"}}})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "PodSecurityFatalErrors")
+	assert.Equal(t, 1, strings.Count(string(data), "alert:"))
+
+	// Rules reading undescribed labels are rejected.
+	_, err = AlertRules([]MetricDescriptor{{Name: "pod_security_errors_total", Type: MetricTypeCounter}})
+	assert.ErrorContains(t, err, "has no label fatal")
+}
@@ -17,16 +17,22 @@ limitations under the License.
 package metrics
 
 import (
+	"context"
+	"errors"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/blang/semver/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/component-base/metrics"
 	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
 )
 
 const (
@@ -46,18 +52,171 @@ type Recorder interface {
 	RecordError(fatal bool, attrs api.Attributes)
 }
 
+// VersionSkewRecorder is optionally implemented by a Recorder to record the evaluations of policies
+// with audit or warn versions skewed from the enforce version (see api.Policy.AuditVersionSkew).
+type VersionSkewRecorder interface {
+	RecordVersionSkew(Mode, api.VersionSkew, api.Attributes)
+}
+
+// DeprecatedFieldRecorder is optionally implemented by a Recorder to record the deprecated fields
+// set in evaluated pods (see policy.DeprecatedFields).
+type DeprecatedFieldRecorder interface {
+	RecordDeprecatedField(field string, attrs api.Attributes)
+}
+
+// UnrelaxedUserNamespacePodRecorder is optionally implemented by a Recorder to record the evaluated pods
+// setting hostUsers=false that violate checks not relaxed for them (see policy.UserNamespaceRelaxationChecker).
+type UnrelaxedUserNamespacePodRecorder interface {
+	RecordUnrelaxedUserNamespacePod(attrs api.Attributes)
+}
+
+// CheckViolationRecorder is optionally implemented by a Recorder to record the checks violated by evaluated pods,
+// for each evaluation with a deny decision, including the enforce evaluations of pods admitted with the Annotate
+// enforcement action.
+type CheckViolationRecorder interface {
+	RecordCheckViolations(checks []policy.CheckID, policy api.LevelVersion, evalMode Mode)
+}
+
+// ExemplarRecorder is optionally implemented by a Recorder to record evaluations with the context of their request,
+// attaching the ID of its sampled trace as an exemplar to the counters of deny decisions and violated checks.
+type ExemplarRecorder interface {
+	RecordEvaluationWithContext(ctx context.Context, decision Decision, policy api.LevelVersion, evalMode Mode, attrs api.Attributes)
+	RecordCheckViolationsWithContext(ctx context.Context, checks []policy.CheckID, policy api.LevelVersion, evalMode Mode)
+}
+
+// LatencyRecorder is optionally implemented by a Recorder to record the latency of the evaluation of admission requests,
+// attaching the ID of the sampled trace of the request context as an exemplar.
+type LatencyRecorder interface {
+	RecordEvaluationLatency(ctx context.Context, decision Decision, latency time.Duration, attrs api.Attributes)
+}
+
+// NondeterministicDecisionRecorder is optionally implemented by a Recorder to record the evaluations of pods
+// re-evaluated with a different decision by an admission.DeterminismGuard.
+type NondeterministicDecisionRecorder interface {
+	RecordNondeterministicDecision(policy api.LevelVersion)
+}
+
+// NamespaceLookupFailureRecorder is optionally implemented by a Recorder to record the requests
+// whose namespace could not be fetched on the first attempt, by how they were resolved.
+type NamespaceLookupFailureRecorder interface {
+	RecordNamespaceLookupFailure(resolution NamespaceLookupResolution)
+}
+
+// NamespaceLookupResolution describes how a request whose namespace lookup failed was handled.
+type NamespaceLookupResolution string
+
+const (
+	// NamespaceLookupResolutionRetried is used for lookups succeeding when retried.
+	NamespaceLookupResolutionRetried NamespaceLookupResolution = "retried"
+	// NamespaceLookupResolutionFailurePolicy is used for requests handled according to the failure policies.
+	NamespaceLookupResolutionFailurePolicy NamespaceLookupResolution = "failure_policy"
+	// NamespaceLookupResolutionDefaults is used for requests evaluated against the cluster defaults.
+	NamespaceLookupResolutionDefaults NamespaceLookupResolution = "defaults"
+	// NamespaceLookupResolutionDeny is used for rejected requests.
+	NamespaceLookupResolutionDeny NamespaceLookupResolution = "deny"
+)
+
+// ResultCacheRecorder is optionally implemented by a Recorder to record the lookups of the result cache
+// of an Evaluator (see policy.WithResultCache).
+type ResultCacheRecorder interface {
+	RecordResultCacheLookup(hit bool)
+}
+
+// CheckErrorRecorder is optionally implemented by a Recorder to record the checks that failed to evaluate pods,
+// e.g. because they exceeded their deadline (see policy.WithCheckDeadline).
+type CheckErrorRecorder interface {
+	RecordCheckError(check policy.CheckID, err error)
+}
+
+// ConfigReloadRecorder is optionally implemented by a Recorder to record the reloads of the admission configuration.
+type ConfigReloadRecorder interface {
+	// RecordConfigReload records an attempt to reload a changed configuration.
+	RecordConfigReload(success bool)
+	// RecordConfigGeneration records the generation of the configuration in effect,
+	// 1 for the configuration loaded at startup and incremented by each successful reload.
+	RecordConfigGeneration(generation int64)
+}
+
+// MetricType is the type of a metric.
+type MetricType string
+
+const (
+	MetricTypeCounter   MetricType = "counter"
+	MetricTypeGauge     MetricType = "gauge"
+	MetricTypeHistogram MetricType = "histogram"
+)
+
+// MetricDescriptor describes a metric registered by a PrometheusRecorder.
+type MetricDescriptor struct {
+	Name   string
+	Help   string
+	Type   MetricType
+	Labels []string
+}
+
+// newCounterVec returns a new CounterVec, appending its descriptor to descriptors.
+func newCounterVec(descriptors *[]MetricDescriptor, opts *metrics.CounterOpts, labels []string) *metrics.CounterVec {
+	*descriptors = append(*descriptors, MetricDescriptor{Name: opts.Name, Help: opts.Help, Type: MetricTypeCounter, Labels: labels})
+	return metrics.NewCounterVec(opts, labels)
+}
+
+// newGaugeVec returns a new GaugeVec, appending its descriptor to descriptors.
+func newGaugeVec(descriptors *[]MetricDescriptor, opts *metrics.GaugeOpts, labels []string) *metrics.GaugeVec {
+	*descriptors = append(*descriptors, MetricDescriptor{Name: opts.Name, Help: opts.Help, Type: MetricTypeGauge, Labels: labels})
+	return metrics.NewGaugeVec(opts, labels)
+}
+
+// newHistogramVec returns a new HistogramVec, appending its descriptor to descriptors.
+func newHistogramVec(descriptors *[]MetricDescriptor, opts *metrics.HistogramOpts, labels []string) *metrics.HistogramVec {
+	*descriptors = append(*descriptors, MetricDescriptor{Name: opts.Name, Help: opts.Help, Type: MetricTypeHistogram, Labels: labels})
+	return metrics.NewHistogramVec(opts, labels)
+}
+
 type PrometheusRecorder struct {
 	apiVersion api.Version
 
 	evaluationsCounter *evaluationsCounter
 	exemptionsCounter  *exemptionsCounter
 	errorsCounter      *metrics.CounterVec
+	checksSchemaInfo   *metrics.GaugeVec
+	checkInfo          *metrics.GaugeVec
+	versionSkewCounter *metrics.CounterVec
+
+	deprecatedFieldsCounter           *metrics.CounterVec
+	unrelaxedUserNamespacePodsCounter *metrics.CounterVec
+	nondeterministicDecisionsCounter  *metrics.CounterVec
+	namespaceLookupFailuresCounter    *metrics.CounterVec
+	resultCacheLookupsCounter         *metrics.CounterVec
+	checkErrorsCounter                *metrics.CounterVec
+	configReloadsCounter              *metrics.CounterVec
+	configGeneration                  *metrics.GaugeVec
+	evaluationDuration                *metrics.HistogramVec
+	// checkViolationsCounter is nil unless enabled by EnableCheckViolations.
+	checkViolationsCounter *metrics.CounterVec
+
+	// descriptors describe the metrics of the recorder, in registration order.
+	descriptors []MetricDescriptor
 }
 
 var _ Recorder = &PrometheusRecorder{}
+var _ VersionSkewRecorder = &PrometheusRecorder{}
+var _ DeprecatedFieldRecorder = &PrometheusRecorder{}
+var _ UnrelaxedUserNamespacePodRecorder = &PrometheusRecorder{}
+var _ CheckViolationRecorder = &PrometheusRecorder{}
+var _ ExemplarRecorder = &PrometheusRecorder{}
+var _ LatencyRecorder = &PrometheusRecorder{}
+var _ NondeterministicDecisionRecorder = &PrometheusRecorder{}
+var _ NamespaceLookupFailureRecorder = &PrometheusRecorder{}
+var _ ResultCacheRecorder = &PrometheusRecorder{}
+var _ CheckErrorRecorder = &PrometheusRecorder{}
+var _ ConfigReloadRecorder = &PrometheusRecorder{}
 
 func NewPrometheusRecorder(version api.Version) *PrometheusRecorder {
-	errorsCounter := metrics.NewCounterVec(
+	var descriptors []MetricDescriptor
+	evaluationsCounter := newEvaluationsCounter(&descriptors)
+	exemptionsCounter := newExemptionsCounter(&descriptors)
+
+	errorsCounter := newCounterVec(&descriptors,
 		&metrics.CounterOpts{
 			Name:           "pod_security_errors_total",
 			Help:           "Number of errors preventing normal evaluation. Non-fatal errors may result in the latest restricted profile being used for evaluation.",
@@ -66,47 +225,236 @@ func NewPrometheusRecorder(version api.Version) *PrometheusRecorder {
 		[]string{"fatal", "request_operation", "resource", "subresource"},
 	)
 
+	checksSchemaInfo := newGaugeVec(&descriptors,
+		&metrics.GaugeOpts{
+			Name:           "pod_security_checks_schema_info",
+			Help:           "Schema version of the policy checks evaluated by PodSecurity admission, with a value of 1. Replicas enforcing identical checks report the same schema version.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"schema_version"},
+	)
+
+	checkInfo := newGaugeVec(&descriptors,
+		&metrics.GaugeOpts{
+			Name:           "pod_security_check_info",
+			Help:           "Policy checks evaluated by PodSecurity admission, with a value of 1, by ID, level and origin (builtin or custom).",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"check", "level", "origin"},
+	)
+
+	versionSkewCounter := newCounterVec(&descriptors,
+		&metrics.CounterOpts{
+			Name:           "pod_security_version_skew_total",
+			Help:           "Number of evaluations of namespace policies with an audit or warn version newer or older than the enforce version. Older versions are likely misconfigured.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"mode", "skew", "request_operation", "resource", "subresource"},
+	)
+
+	deprecatedFieldsCounter := newCounterVec(&descriptors,
+		&metrics.CounterOpts{
+			Name:           "pod_security_deprecated_fields_total",
+			Help:           "Number of evaluated pods setting deprecated fields, like the seccomp alpha and AppArmor beta annotations, by field.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"field", "request_operation", "resource", "subresource"},
+	)
+
+	unrelaxedUserNamespacePodsCounter := newCounterVec(&descriptors,
+		&metrics.CounterOpts{
+			Name:           "pod_security_unrelaxed_user_namespace_pods_total",
+			Help:           "Number of evaluated pods setting hostUsers=false that violate checks only relaxed for them with the UserNamespacesPodSecurityStandards feature, which is disabled.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"request_operation", "resource", "subresource"},
+	)
+
+	nondeterministicDecisionsCounter := newCounterVec(&descriptors,
+		&metrics.CounterOpts{
+			Name:           "pod_security_nondeterministic_decisions_total",
+			Help:           "Number of sampled pod evaluations whose re-evaluation with a freshly constructed evaluator returned a different decision.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"policy_level", "policy_version"},
+	)
+
+	namespaceLookupFailuresCounter := newCounterVec(&descriptors,
+		&metrics.CounterOpts{
+			Name:           "pod_security_namespace_lookup_failures_total",
+			Help:           "Number of requests whose namespace could not be fetched on the first attempt, by resolution: retried, failure_policy, defaults or deny.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"resolution"},
+	)
+
+	resultCacheLookupsCounter := newCounterVec(&descriptors,
+		&metrics.CounterOpts{
+			Name:           "pod_security_result_cache_lookups_total",
+			Help:           "Number of lookups of the evaluation result cache, by result: hit or miss.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"result"},
+	)
+
+	checkErrorsCounter := newCounterVec(&descriptors,
+		&metrics.CounterOpts{
+			Name:           "pod_security_check_errors_total",
+			Help:           "Number of check evaluations that failed to evaluate a pod, by check and reason: deadline_exceeded or error.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"check_id", "reason"},
+	)
+
+	configReloadsCounter := newCounterVec(&descriptors,
+		&metrics.CounterOpts{
+			Name:           "pod_security_config_reloads_total",
+			Help:           "Number of attempts to reload the changed PodSecurity admission configuration, by result: success or failure.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"result"},
+	)
+
+	configGeneration := newGaugeVec(&descriptors,
+		&metrics.GaugeOpts{
+			Name:           "pod_security_config_generation",
+			Help:           "Generation of the PodSecurity admission configuration in effect, 1 for the configuration loaded at startup and incremented by each successful reload.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{},
+	)
+
+	evaluationDuration := newHistogramVec(&descriptors,
+		&metrics.HistogramOpts{
+			Name:           "pod_security_evaluation_duration_seconds",
+			Help:           "Latency of the evaluation of admission requests by PodSecurity admission, by decision. Observations of sampled traces have the trace ID as an exemplar.",
+			Buckets:        []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"decision", "request_operation", "resource", "subresource"},
+	)
+
 	return &PrometheusRecorder{
 		apiVersion:         version,
-		evaluationsCounter: newEvaluationsCounter(),
-		exemptionsCounter:  newExemptionsCounter(),
+		evaluationsCounter: evaluationsCounter,
+		exemptionsCounter:  exemptionsCounter,
 		errorsCounter:      errorsCounter,
+		checksSchemaInfo:   checksSchemaInfo,
+		checkInfo:          checkInfo,
+		versionSkewCounter: versionSkewCounter,
+
+		deprecatedFieldsCounter:           deprecatedFieldsCounter,
+		unrelaxedUserNamespacePodsCounter: unrelaxedUserNamespacePodsCounter,
+		nondeterministicDecisionsCounter:  nondeterministicDecisionsCounter,
+		namespaceLookupFailuresCounter:    namespaceLookupFailuresCounter,
+		resultCacheLookupsCounter:         resultCacheLookupsCounter,
+		checkErrorsCounter:                checkErrorsCounter,
+		configReloadsCounter:              configReloadsCounter,
+		configGeneration:                  configGeneration,
+		evaluationDuration:                evaluationDuration,
+
+		descriptors: descriptors,
 	}
 }
 
+// EnableCheckViolations enables the pod_security_check_violations_total counter, which is opt-in since it has a series
+// for each check violated at each policy level, version and mode. It must be called before MustRegister.
+func (r *PrometheusRecorder) EnableCheckViolations() {
+	r.checkViolationsCounter = newCounterVec(&r.descriptors,
+		&metrics.CounterOpts{
+			Name:           "pod_security_check_violations_total",
+			Help:           "Number of policy evaluations with a deny decision by violated check, counting each check violated by the evaluated pod.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"check_id", "policy_level", "policy_version", "mode"},
+	)
+}
+
+// Descriptors returns the descriptors of the metrics registered by MustRegister, e.g. to generate dashboards and alerts.
+// The pod_security_check_violations_total counter is only included if enabled by EnableCheckViolations.
+func (r *PrometheusRecorder) Descriptors() []MetricDescriptor {
+	return append([]MetricDescriptor(nil), r.descriptors...)
+}
+
 func (r *PrometheusRecorder) MustRegister(registerFunc func(...metrics.Registerable)) {
 	registerFunc(r.evaluationsCounter)
 	registerFunc(r.exemptionsCounter)
 	registerFunc(r.errorsCounter)
+	registerFunc(r.checksSchemaInfo)
+	registerFunc(r.checkInfo)
+	registerFunc(r.versionSkewCounter)
+	registerFunc(r.deprecatedFieldsCounter)
+	registerFunc(r.unrelaxedUserNamespacePodsCounter)
+	registerFunc(r.nondeterministicDecisionsCounter)
+	registerFunc(r.namespaceLookupFailuresCounter)
+	registerFunc(r.resultCacheLookupsCounter)
+	registerFunc(r.checkErrorsCounter)
+	registerFunc(r.configReloadsCounter)
+	registerFunc(r.configGeneration)
+	registerFunc(r.evaluationDuration)
+	if r.checkViolationsCounter != nil {
+		registerFunc(r.checkViolationsCounter)
+	}
 }
 
 func (r *PrometheusRecorder) Reset() {
 	r.evaluationsCounter.Reset()
 	r.exemptionsCounter.Reset()
 	r.errorsCounter.Reset()
+	r.checksSchemaInfo.Reset()
+	r.checkInfo.Reset()
+	r.versionSkewCounter.Reset()
+	r.deprecatedFieldsCounter.Reset()
+	r.unrelaxedUserNamespacePodsCounter.Reset()
+	r.nondeterministicDecisionsCounter.Reset()
+	r.namespaceLookupFailuresCounter.Reset()
+	r.resultCacheLookupsCounter.Reset()
+	r.checkErrorsCounter.Reset()
+	r.configReloadsCounter.Reset()
+	r.configGeneration.Reset()
+	r.evaluationDuration.Reset()
+	if r.checkViolationsCounter != nil {
+		r.checkViolationsCounter.Reset()
+	}
 }
 
 func (r *PrometheusRecorder) RecordEvaluation(decision Decision, policy api.LevelVersion, evalMode Mode, attrs api.Attributes) {
-	var version string
-	if policy.Version.Latest() || policy.Level == api.LevelPrivileged { // Privileged is always effectively latest.
-		version = "latest"
-	} else {
-		if !r.apiVersion.Older(policy.Version) {
-			version = policy.Version.String()
-		} else {
-			version = "future"
-		}
+	r.evaluationsCounter.CachedInc(r.evaluationsLabels(decision, policy, evalMode, attrs))
+}
+
+// RecordEvaluationWithContext records an evaluation like RecordEvaluation,
+// attaching the sampled trace of ctx as an exemplar to the counter of deny decisions.
+func (r *PrometheusRecorder) RecordEvaluationWithContext(ctx context.Context, decision Decision, policy api.LevelVersion, evalMode Mode, attrs api.Attributes) {
+	exemplar := traceExemplar(ctx)
+	if decision != DecisionDeny || exemplar == nil {
+		r.RecordEvaluation(decision, policy, evalMode, attrs)
+		return
 	}
+	labels := r.evaluationsLabels(decision, policy, evalMode, attrs)
+	incWithExemplar(r.evaluationsCounter.WithLabelValues(labels.labels()...), exemplar)
+}
 
-	r.evaluationsCounter.CachedInc(evaluationsLabels{
+func (r *PrometheusRecorder) evaluationsLabels(decision Decision, policy api.LevelVersion, evalMode Mode, attrs api.Attributes) evaluationsLabels {
+	return evaluationsLabels{
 		decision:    string(decision),
 		level:       string(policy.Level),
-		version:     version,
+		version:     r.versionLabel(policy),
 		mode:        string(evalMode),
 		operation:   operationLabel(attrs.GetOperation()),
 		resource:    resourceLabel(attrs.GetResource()),
 		subresource: attrs.GetSubresource(),
-	})
+	}
+}
+
+// versionLabel returns the policy_version label of the policy, bounding the cardinality of future versions.
+func (r *PrometheusRecorder) versionLabel(policy api.LevelVersion) string {
+	if policy.Version.Latest() || policy.Level == api.LevelPrivileged { // Privileged is always effectively latest.
+		return "latest"
+	}
+	if !r.apiVersion.Older(policy.Version) {
+		return policy.Version.String()
+	}
+	return "future"
 }
 
 func (r *PrometheusRecorder) RecordExemption(attrs api.Attributes) {
@@ -126,6 +474,155 @@ func (r *PrometheusRecorder) RecordError(fatal bool, attrs api.Attributes) {
 	).Inc()
 }
 
+// RecordChecksSchemaVersion records the schema version of the evaluated policy checks (see policy.SchemaVersion).
+func (r *PrometheusRecorder) RecordChecksSchemaVersion(schemaVersion string) {
+	r.checksSchemaInfo.Reset()
+	r.checksSchemaInfo.WithLabelValues(schemaVersion).Set(1)
+}
+
+// RecordChecks records the evaluated policy checks (see policy.EvaluatorChecks).
+func (r *PrometheusRecorder) RecordChecks(checks []policy.CheckInfo) {
+	r.checkInfo.Reset()
+	for _, c := range checks {
+		r.checkInfo.WithLabelValues(string(c.ID), string(c.Level), string(c.Origin)).Set(1)
+	}
+}
+
+// RecordVersionSkew records the evaluation of a policy with the audit or warn version skewed from the enforce version.
+func (r *PrometheusRecorder) RecordVersionSkew(evalMode Mode, skew api.VersionSkew, attrs api.Attributes) {
+	r.versionSkewCounter.WithLabelValues(
+		string(evalMode),
+		string(skew),
+		operationLabel(attrs.GetOperation()),
+		resourceLabel(attrs.GetResource()),
+		attrs.GetSubresource(),
+	).Inc()
+}
+
+// RecordDeprecatedField records a deprecated field set in an evaluated pod.
+func (r *PrometheusRecorder) RecordDeprecatedField(field string, attrs api.Attributes) {
+	r.deprecatedFieldsCounter.WithLabelValues(
+		field,
+		operationLabel(attrs.GetOperation()),
+		resourceLabel(attrs.GetResource()),
+		attrs.GetSubresource(),
+	).Inc()
+}
+
+// RecordUnrelaxedUserNamespacePod records an evaluated pod setting hostUsers=false that violates checks not relaxed for it.
+func (r *PrometheusRecorder) RecordUnrelaxedUserNamespacePod(attrs api.Attributes) {
+	r.unrelaxedUserNamespacePodsCounter.WithLabelValues(
+		operationLabel(attrs.GetOperation()),
+		resourceLabel(attrs.GetResource()),
+		attrs.GetSubresource(),
+	).Inc()
+}
+
+// RecordNondeterministicDecision records a pod evaluation whose re-evaluation returned a different decision.
+func (r *PrometheusRecorder) RecordNondeterministicDecision(policy api.LevelVersion) {
+	r.nondeterministicDecisionsCounter.WithLabelValues(string(policy.Level), r.versionLabel(policy)).Inc()
+}
+
+// RecordNamespaceLookupFailure records a request whose namespace could not be fetched on the first attempt.
+func (r *PrometheusRecorder) RecordNamespaceLookupFailure(resolution NamespaceLookupResolution) {
+	r.namespaceLookupFailuresCounter.WithLabelValues(string(resolution)).Inc()
+}
+
+// RecordResultCacheLookup records a lookup of the result cache of an Evaluator.
+func (r *PrometheusRecorder) RecordResultCacheLookup(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	r.resultCacheLookupsCounter.WithLabelValues(result).Inc()
+}
+
+// RecordCheckError records a check that failed to evaluate a pod.
+func (r *PrometheusRecorder) RecordCheckError(check policy.CheckID, err error) {
+	reason := "error"
+	if errors.Is(err, context.DeadlineExceeded) {
+		reason = "deadline_exceeded"
+	}
+	r.checkErrorsCounter.WithLabelValues(string(check), reason).Inc()
+}
+
+// RecordConfigReload records an attempt to reload a changed configuration.
+func (r *PrometheusRecorder) RecordConfigReload(success bool) {
+	result := "success"
+	if !success {
+		result = "failure"
+	}
+	r.configReloadsCounter.WithLabelValues(result).Inc()
+}
+
+// RecordConfigGeneration records the generation of the configuration in effect.
+func (r *PrometheusRecorder) RecordConfigGeneration(generation int64) {
+	r.configGeneration.WithLabelValues().Set(float64(generation))
+}
+
+// RecordCheckViolations records the checks violated by a pod evaluated with a deny decision,
+// if enabled by EnableCheckViolations.
+func (r *PrometheusRecorder) RecordCheckViolations(checks []policy.CheckID, policy api.LevelVersion, evalMode Mode) {
+	if r.checkViolationsCounter == nil {
+		return
+	}
+	version := r.versionLabel(policy)
+	for _, check := range checks {
+		r.checkViolationsCounter.WithLabelValues(string(check), string(policy.Level), version, string(evalMode)).Inc()
+	}
+}
+
+// RecordCheckViolationsWithContext records violated checks like RecordCheckViolations,
+// attaching the sampled trace of ctx as an exemplar to their counters.
+func (r *PrometheusRecorder) RecordCheckViolationsWithContext(ctx context.Context, checks []policy.CheckID, policy api.LevelVersion, evalMode Mode) {
+	exemplar := traceExemplar(ctx)
+	if r.checkViolationsCounter == nil || exemplar == nil {
+		r.RecordCheckViolations(checks, policy, evalMode)
+		return
+	}
+	version := r.versionLabel(policy)
+	for _, check := range checks {
+		incWithExemplar(r.checkViolationsCounter.WithLabelValues(string(check), string(policy.Level), version, string(evalMode)), exemplar)
+	}
+}
+
+// RecordEvaluationLatency records the latency of the evaluation of an admission request with the given decision,
+// attaching the sampled trace of ctx as an exemplar.
+func (r *PrometheusRecorder) RecordEvaluationLatency(ctx context.Context, decision Decision, latency time.Duration, attrs api.Attributes) {
+	observer := r.evaluationDuration.WithLabelValues(string(decision), operationLabel(attrs.GetOperation()), resourceLabel(attrs.GetResource()), attrs.GetSubresource())
+	observeWithExemplar(observer, latency.Seconds(), traceExemplar(ctx))
+}
+
+// traceExemplar returns the exemplar labels identifying the sampled trace of ctx, or nil if it has none.
+func traceExemplar(ctx context.Context) prometheus.Labels {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() || !spanContext.IsSampled() {
+		return nil
+	}
+	return prometheus.Labels{
+		"trace_id": spanContext.TraceID().String(),
+		"span_id":  spanContext.SpanID().String(),
+	}
+}
+
+// incWithExemplar increments the counter, attaching the exemplar if supported by the counter.
+func incWithExemplar(counter metrics.CounterMetric, exemplar prometheus.Labels) {
+	if adder, ok := counter.(prometheus.ExemplarAdder); ok {
+		adder.AddWithExemplar(1, exemplar)
+		return
+	}
+	counter.Inc()
+}
+
+// observeWithExemplar observes the value, attaching the exemplar if set and supported by the observer.
+func observeWithExemplar(observer metrics.ObserverMetric, value float64, exemplar prometheus.Labels) {
+	if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok && exemplar != nil {
+		exemplarObserver.ObserveWithExemplar(value, exemplar)
+		return
+	}
+	observer.Observe(value)
+}
+
 var (
 	podResource       = corev1.Resource("pods")
 	namespaceResource = corev1.Resource("namespaces")
@@ -186,9 +683,9 @@ type evaluationsCounter struct {
 	cacheLock sync.RWMutex
 }
 
-func newEvaluationsCounter() *evaluationsCounter {
+func newEvaluationsCounter(descriptors *[]MetricDescriptor) *evaluationsCounter {
 	return &evaluationsCounter{
-		CounterVec: metrics.NewCounterVec(
+		CounterVec: newCounterVec(descriptors,
 			&metrics.CounterOpts{
 				Name:           "pod_security_evaluations_total",
 				Help:           "Number of policy evaluations that occurred, not counting ignored or exempt requests.",
@@ -246,9 +743,9 @@ type exemptionsCounter struct {
 	cacheLock sync.RWMutex
 }
 
-func newExemptionsCounter() *exemptionsCounter {
+func newExemptionsCounter(descriptors *[]MetricDescriptor) *exemptionsCounter {
 	return &exemptionsCounter{
-		CounterVec: metrics.NewCounterVec(
+		CounterVec: newCounterVec(descriptors,
 			&metrics.CounterOpts{
 				Name:           "pod_security_exemptions_total",
 				Help:           "Number of exempt requests, not counting ignored or out of scope requests.",
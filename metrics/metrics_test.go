@@ -18,10 +18,12 @@ package metrics
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"sort"
 	"strings"
 	"testing"
+	"time"
 
 	admissionv1 "k8s.io/api/admission/v1"
 	appsv1 "k8s.io/api/apps/v1"
@@ -30,8 +32,11 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/component-base/metrics/testutil"
 	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
@@ -150,6 +155,352 @@ func TestRecordError(t *testing.T) {
 	}
 }
 
+func TestRecordChecksSchemaVersion(t *testing.T) {
+	recorder := NewPrometheusRecorder(testVersion)
+	registry := testutil.NewFakeKubeRegistry("1.23.0")
+	recorder.MustRegister(registry.MustRegister)
+
+	recorder.RecordChecksSchemaVersion("old")
+	recorder.RecordChecksSchemaVersion("0123456789abcdef")
+
+	expected := bytes.NewBufferString(`
+	# HELP pod_security_checks_schema_info [ALPHA] Schema version of the policy checks evaluated by PodSecurity admission, with a value of 1. Replicas enforcing identical checks report the same schema version.
+	# TYPE pod_security_checks_schema_info gauge
+	pod_security_checks_schema_info{schema_version="0123456789abcdef"} 1
+	`)
+	assert.NoError(t, testutil.GatherAndCompare(registry, expected, "pod_security_checks_schema_info"))
+}
+
+func TestRecordChecks(t *testing.T) {
+	recorder := NewPrometheusRecorder(testVersion)
+	registry := testutil.NewFakeKubeRegistry("1.23.0")
+	recorder.MustRegister(registry.MustRegister)
+
+	recorder.RecordChecks([]policy.CheckInfo{{ID: "old", Level: api.LevelBaseline, Origin: policy.CheckOriginBuiltin}})
+	recorder.RecordChecks([]policy.CheckInfo{
+		{ID: "example.com/no-root-fs", Level: api.LevelRestricted, Origin: policy.CheckOriginCustom},
+		{ID: "privileged", Level: api.LevelBaseline, Origin: policy.CheckOriginBuiltin},
+	})
+
+	expected := bytes.NewBufferString(`
+	# HELP pod_security_check_info [ALPHA] Policy checks evaluated by PodSecurity admission, with a value of 1, by ID, level and origin (builtin or custom).
+	# TYPE pod_security_check_info gauge
+	pod_security_check_info{check="example.com/no-root-fs",level="restricted",origin="custom"} 1
+	pod_security_check_info{check="privileged",level="baseline",origin="builtin"} 1
+	`)
+	assert.NoError(t, testutil.GatherAndCompare(registry, expected, "pod_security_check_info"))
+}
+
+func TestRecordVersionSkew(t *testing.T) {
+	recorder := NewPrometheusRecorder(testVersion)
+	registry := testutil.NewFakeKubeRegistry("1.23.0")
+	recorder.MustRegister(registry.MustRegister)
+
+	attrs := &api.AttributesRecord{
+		Resource:  corev1.SchemeGroupVersion.WithResource("pods"),
+		Operation: admissionv1.Create,
+	}
+	recorder.RecordVersionSkew(ModeAudit, api.VersionSkewOlder, attrs)
+	recorder.RecordVersionSkew(ModeWarn, api.VersionSkewNewer, attrs)
+	recorder.RecordVersionSkew(ModeWarn, api.VersionSkewNewer, attrs)
+
+	expected := bytes.NewBufferString(`
+	# HELP pod_security_version_skew_total [ALPHA] Number of evaluations of namespace policies with an audit or warn version newer or older than the enforce version. Older versions are likely misconfigured.
+	# TYPE pod_security_version_skew_total counter
+	pod_security_version_skew_total{mode="audit",request_operation="create",resource="pod",skew="older",subresource=""} 1
+	pod_security_version_skew_total{mode="warn",request_operation="create",resource="pod",skew="newer",subresource=""} 2
+	`)
+	assert.NoError(t, testutil.GatherAndCompare(registry, expected, "pod_security_version_skew_total"))
+}
+
+func TestRecordDeprecatedField(t *testing.T) {
+	recorder := NewPrometheusRecorder(testVersion)
+	registry := testutil.NewFakeKubeRegistry("1.23.0")
+	recorder.MustRegister(registry.MustRegister)
+
+	attrs := &api.AttributesRecord{
+		Resource:  corev1.SchemeGroupVersion.WithResource("pods"),
+		Operation: admissionv1.Create,
+	}
+	recorder.RecordDeprecatedField("seccomp.security.alpha.kubernetes.io/pod", attrs)
+	recorder.RecordDeprecatedField("container.apparmor.security.beta.kubernetes.io/", attrs)
+	recorder.RecordDeprecatedField("container.apparmor.security.beta.kubernetes.io/", attrs)
+
+	expected := bytes.NewBufferString(`
+	# HELP pod_security_deprecated_fields_total [ALPHA] Number of evaluated pods setting deprecated fields, like the seccomp alpha and AppArmor beta annotations, by field.
+	# TYPE pod_security_deprecated_fields_total counter
+	pod_security_deprecated_fields_total{field="container.apparmor.security.beta.kubernetes.io/",request_operation="create",resource="pod",subresource=""} 2
+	pod_security_deprecated_fields_total{field="seccomp.security.alpha.kubernetes.io/pod",request_operation="create",resource="pod",subresource=""} 1
+	`)
+	assert.NoError(t, testutil.GatherAndCompare(registry, expected, "pod_security_deprecated_fields_total"))
+}
+
+func TestRecordUnrelaxedUserNamespacePod(t *testing.T) {
+	recorder := NewPrometheusRecorder(testVersion)
+	registry := testutil.NewFakeKubeRegistry("1.23.0")
+	recorder.MustRegister(registry.MustRegister)
+
+	attrs := &api.AttributesRecord{
+		Resource:  corev1.SchemeGroupVersion.WithResource("pods"),
+		Operation: admissionv1.Create,
+	}
+	recorder.RecordUnrelaxedUserNamespacePod(attrs)
+	recorder.RecordUnrelaxedUserNamespacePod(attrs)
+
+	expected := bytes.NewBufferString(`
+	# HELP pod_security_unrelaxed_user_namespace_pods_total [ALPHA] Number of evaluated pods setting hostUsers=false that violate checks only relaxed for them with the UserNamespacesPodSecurityStandards feature, which is disabled.
+	# TYPE pod_security_unrelaxed_user_namespace_pods_total counter
+	pod_security_unrelaxed_user_namespace_pods_total{request_operation="create",resource="pod",subresource=""} 2
+	`)
+	assert.NoError(t, testutil.GatherAndCompare(registry, expected, "pod_security_unrelaxed_user_namespace_pods_total"))
+}
+
+func TestRecordNondeterministicDecision(t *testing.T) {
+	recorder := NewPrometheusRecorder(testVersion)
+	registry := testutil.NewFakeKubeRegistry("1.23.0")
+	recorder.MustRegister(registry.MustRegister)
+
+	recorder.RecordNondeterministicDecision(levelVersion(api.LevelRestricted, "v1.22"))
+	recorder.RecordNondeterministicDecision(levelVersion(api.LevelRestricted, "v1.22"))
+	recorder.RecordNondeterministicDecision(levelVersion(api.LevelBaseline, "latest"))
+
+	expected := bytes.NewBufferString(`
+	# HELP pod_security_nondeterministic_decisions_total [ALPHA] Number of sampled pod evaluations whose re-evaluation with a freshly constructed evaluator returned a different decision.
+	# TYPE pod_security_nondeterministic_decisions_total counter
+	pod_security_nondeterministic_decisions_total{policy_level="baseline",policy_version="latest"} 1
+	pod_security_nondeterministic_decisions_total{policy_level="restricted",policy_version="v1.22"} 2
+	`)
+	assert.NoError(t, testutil.GatherAndCompare(registry, expected, "pod_security_nondeterministic_decisions_total"))
+}
+
+func TestRecordNamespaceLookupFailure(t *testing.T) {
+	recorder := NewPrometheusRecorder(testVersion)
+	registry := testutil.NewFakeKubeRegistry("1.23.0")
+	recorder.MustRegister(registry.MustRegister)
+
+	recorder.RecordNamespaceLookupFailure(NamespaceLookupResolutionRetried)
+	recorder.RecordNamespaceLookupFailure(NamespaceLookupResolutionRetried)
+	recorder.RecordNamespaceLookupFailure(NamespaceLookupResolutionDefaults)
+
+	expected := bytes.NewBufferString(`
+	# HELP pod_security_namespace_lookup_failures_total [ALPHA] Number of requests whose namespace could not be fetched on the first attempt, by resolution: retried, failure_policy, defaults or deny.
+	# TYPE pod_security_namespace_lookup_failures_total counter
+	pod_security_namespace_lookup_failures_total{resolution="defaults"} 1
+	pod_security_namespace_lookup_failures_total{resolution="retried"} 2
+	`)
+	assert.NoError(t, testutil.GatherAndCompare(registry, expected, "pod_security_namespace_lookup_failures_total"))
+}
+
+func TestRecordResultCacheLookup(t *testing.T) {
+	recorder := NewPrometheusRecorder(testVersion)
+	registry := testutil.NewFakeKubeRegistry("1.23.0")
+	recorder.MustRegister(registry.MustRegister)
+
+	recorder.RecordResultCacheLookup(false)
+	recorder.RecordResultCacheLookup(true)
+	recorder.RecordResultCacheLookup(true)
+
+	expected := bytes.NewBufferString(`
+	# HELP pod_security_result_cache_lookups_total [ALPHA] Number of lookups of the evaluation result cache, by result: hit or miss.
+	# TYPE pod_security_result_cache_lookups_total counter
+	pod_security_result_cache_lookups_total{result="hit"} 2
+	pod_security_result_cache_lookups_total{result="miss"} 1
+	`)
+	assert.NoError(t, testutil.GatherAndCompare(registry, expected, "pod_security_result_cache_lookups_total"))
+}
+
+func TestRecordCheckError(t *testing.T) {
+	recorder := NewPrometheusRecorder(testVersion)
+	registry := testutil.NewFakeKubeRegistry("1.23.0")
+	recorder.MustRegister(registry.MustRegister)
+
+	recorder.RecordCheckError("example.com/slow", &policy.CheckDeadlineExceededError{ID: "example.com/slow", Deadline: time.Second})
+	recorder.RecordCheckError("example.com/slow", &policy.CheckDeadlineExceededError{ID: "example.com/slow", Deadline: time.Second})
+	recorder.RecordCheckError("example.com/panicking", fmt.Errorf("check example.com/panicking panicked"))
+
+	expected := bytes.NewBufferString(`
+	# HELP pod_security_check_errors_total [ALPHA] Number of check evaluations that failed to evaluate a pod, by check and reason: deadline_exceeded or error.
+	# TYPE pod_security_check_errors_total counter
+	pod_security_check_errors_total{check_id="example.com/panicking",reason="error"} 1
+	pod_security_check_errors_total{check_id="example.com/slow",reason="deadline_exceeded"} 2
+	`)
+	assert.NoError(t, testutil.GatherAndCompare(registry, expected, "pod_security_check_errors_total"))
+}
+
+func TestRecordConfigReload(t *testing.T) {
+	recorder := NewPrometheusRecorder(testVersion)
+	registry := testutil.NewFakeKubeRegistry("1.23.0")
+	recorder.MustRegister(registry.MustRegister)
+
+	recorder.RecordConfigGeneration(1)
+	recorder.RecordConfigReload(false)
+	recorder.RecordConfigReload(true)
+	recorder.RecordConfigGeneration(2)
+
+	expected := bytes.NewBufferString(`
+	# HELP pod_security_config_generation [ALPHA] Generation of the PodSecurity admission configuration in effect, 1 for the configuration loaded at startup and incremented by each successful reload.
+	# TYPE pod_security_config_generation gauge
+	pod_security_config_generation 2
+	# HELP pod_security_config_reloads_total [ALPHA] Number of attempts to reload the changed PodSecurity admission configuration, by result: success or failure.
+	# TYPE pod_security_config_reloads_total counter
+	pod_security_config_reloads_total{result="failure"} 1
+	pod_security_config_reloads_total{result="success"} 1
+	`)
+	assert.NoError(t, testutil.GatherAndCompare(registry, expected, "pod_security_config_generation", "pod_security_config_reloads_total"))
+}
+
+func TestRecordCheckViolations(t *testing.T) {
+	recorder := NewPrometheusRecorder(testVersion)
+	registry := testutil.NewFakeKubeRegistry("1.23.0")
+	recorder.MustRegister(registry.MustRegister)
+
+	// The counter is not registered unless enabled.
+	recorder.RecordCheckViolations([]policy.CheckID{"privileged"}, levelVersion(api.LevelBaseline, "v1.22"), ModeEnforce)
+	assert.NoError(t, testutil.GatherAndCompare(registry, bytes.NewBufferString(""), "pod_security_check_violations_total"))
+
+	recorder = NewPrometheusRecorder(testVersion)
+	recorder.EnableCheckViolations()
+	registry = testutil.NewFakeKubeRegistry("1.23.0")
+	recorder.MustRegister(registry.MustRegister)
+
+	recorder.RecordCheckViolations([]policy.CheckID{"privileged", "hostPorts"}, levelVersion(api.LevelBaseline, "v1.22"), ModeEnforce)
+	recorder.RecordCheckViolations([]policy.CheckID{"privileged"}, levelVersion(api.LevelBaseline, "v1.22"), ModeEnforce)
+	recorder.RecordCheckViolations([]policy.CheckID{"runAsNonRoot"}, levelVersion(api.LevelRestricted, "latest"), ModeWarn)
+	recorder.RecordCheckViolations([]policy.CheckID{"runAsNonRoot"}, levelVersion(api.LevelRestricted, "v1.999"), ModeAudit)
+
+	expected := bytes.NewBufferString(`
+	# HELP pod_security_check_violations_total [ALPHA] Number of policy evaluations with a deny decision by violated check, counting each check violated by the evaluated pod.
+	# TYPE pod_security_check_violations_total counter
+	pod_security_check_violations_total{check_id="hostPorts",mode="enforce",policy_level="baseline",policy_version="v1.22"} 1
+	pod_security_check_violations_total{check_id="privileged",mode="enforce",policy_level="baseline",policy_version="v1.22"} 2
+	pod_security_check_violations_total{check_id="runAsNonRoot",mode="audit",policy_level="restricted",policy_version="future"} 1
+	pod_security_check_violations_total{check_id="runAsNonRoot",mode="warn",policy_level="restricted",policy_version="latest"} 1
+	`)
+	assert.NoError(t, testutil.GatherAndCompare(registry, expected, "pod_security_check_violations_total"))
+
+	recorder.Reset()
+	assert.NoError(t, testutil.GatherAndCompare(registry, bytes.NewBufferString(""), "pod_security_check_violations_total"))
+}
+
+func TestRecordWithContextExemplars(t *testing.T) {
+	recorder := NewPrometheusRecorder(testVersion)
+	recorder.EnableCheckViolations()
+	registry := testutil.NewFakeKubeRegistry("1.23.0")
+	recorder.MustRegister(registry.MustRegister)
+
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+	unsampledCtx := trace.ContextWithSpanContext(context.Background(), spanContext.WithTraceFlags(0))
+	attrs := &api.AttributesRecord{
+		Resource:  corev1.SchemeGroupVersion.WithResource("pods"),
+		Operation: admissionv1.Create,
+	}
+	lv := levelVersion(api.LevelBaseline, "latest")
+
+	recorder.RecordEvaluationWithContext(ctx, DecisionDeny, lv, ModeEnforce, attrs)
+	recorder.RecordEvaluationWithContext(ctx, DecisionAllow, lv, ModeAudit, attrs)
+	recorder.RecordEvaluationWithContext(unsampledCtx, DecisionDeny, lv, ModeWarn, attrs)
+	recorder.RecordCheckViolationsWithContext(ctx, []policy.CheckID{"privileged"}, lv, ModeEnforce)
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	exemplars := map[string]map[string]string{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			exemplar := metric.GetCounter().GetExemplar()
+			if exemplar == nil {
+				continue
+			}
+			assert.Equal(t, float64(1), exemplar.GetValue())
+			var key []string
+			for _, label := range metric.GetLabel() {
+				key = append(key, label.GetValue())
+			}
+			labels := map[string]string{}
+			for _, label := range exemplar.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			exemplars[family.GetName()+"/"+strings.Join(key, ",")] = labels
+		}
+	}
+	expectedLabels := map[string]string{
+		"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":  "00f067aa0ba902b7",
+	}
+	// only the deny decisions and violated checks of sampled traces have exemplars
+	assert.Equal(t, map[string]map[string]string{
+		"pod_security_evaluations_total/deny,enforce,baseline,latest,create,pod,": expectedLabels,
+		"pod_security_check_violations_total/privileged,enforce,baseline,latest":  expectedLabels,
+	}, exemplars)
+
+	expected := bytes.NewBufferString(`
+	# HELP pod_security_evaluations_total [ALPHA] Number of policy evaluations that occurred, not counting ignored or exempt requests.
+	# TYPE pod_security_evaluations_total counter
+	pod_security_evaluations_total{decision="allow",mode="audit",policy_level="baseline",policy_version="latest",request_operation="create",resource="pod",subresource=""} 1
+	pod_security_evaluations_total{decision="allow",mode="enforce",policy_level="privileged",policy_version="latest",request_operation="create",resource="pod",subresource=""} 0
+	pod_security_evaluations_total{decision="allow",mode="enforce",policy_level="privileged",policy_version="latest",request_operation="update",resource="pod",subresource=""} 0
+	pod_security_evaluations_total{decision="deny",mode="enforce",policy_level="baseline",policy_version="latest",request_operation="create",resource="pod",subresource=""} 1
+	pod_security_evaluations_total{decision="deny",mode="warn",policy_level="baseline",policy_version="latest",request_operation="create",resource="pod",subresource=""} 1
+	`)
+	assert.NoError(t, testutil.GatherAndCompare(registry, expected, "pod_security_evaluations_total"))
+}
+
+func TestRecordEvaluationLatencyExemplars(t *testing.T) {
+	recorder := NewPrometheusRecorder(testVersion)
+	registry := testutil.NewFakeKubeRegistry("1.23.0")
+	recorder.MustRegister(registry.MustRegister)
+
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+	unsampledCtx := trace.ContextWithSpanContext(context.Background(), spanContext.WithTraceFlags(0))
+	attrs := &api.AttributesRecord{
+		Resource:  corev1.SchemeGroupVersion.WithResource("pods"),
+		Operation: admissionv1.Create,
+	}
+
+	recorder.RecordEvaluationLatency(ctx, DecisionDeny, 3*time.Millisecond, attrs)
+	recorder.RecordEvaluationLatency(unsampledCtx, DecisionAllow, 3*time.Millisecond, attrs)
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	exemplars := map[string]map[string]string{}
+	for _, family := range families {
+		if family.GetName() != "pod_security_evaluation_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			assert.Equal(t, uint64(1), metric.GetHistogram().GetSampleCount())
+			for _, bucket := range metric.GetHistogram().GetBucket() {
+				exemplar := bucket.GetExemplar()
+				if exemplar == nil {
+					continue
+				}
+				assert.Equal(t, 0.003, exemplar.GetValue())
+				labels := map[string]string{}
+				for _, label := range exemplar.GetLabel() {
+					labels[label.GetName()] = label.GetValue()
+				}
+				exemplars[metric.GetLabel()[0].GetValue()] = labels
+			}
+		}
+	}
+	// only the observations of sampled traces have exemplars
+	assert.Equal(t, map[string]map[string]string{
+		"deny": {
+			"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+			"span_id":  "00f067aa0ba902b7",
+		},
+	}, exemplars)
+}
+
 func levelVersion(level api.Level, version string) api.LevelVersion {
 	lv := api.LevelVersion{Level: level}
 	var err error
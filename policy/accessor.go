@@ -0,0 +1,221 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/pod-security-admission/api"
+)
+
+// PodAccessor provides the parts of a pod that are read by the checks,
+// so pods held in representations other than corev1 can be evaluated without a full conversion.
+type PodAccessor interface {
+	// Annotations returns metadata.annotations.
+	Annotations() map[string]string
+	// HostNamespaces returns spec.hostNetwork, spec.hostPID and spec.hostIPC.
+	HostNamespaces() (network, pid, ipc bool)
+	// HostUsers returns spec.hostUsers.
+	HostUsers() *bool
+	// OS returns spec.os.
+	OS() *corev1.PodOS
+	// RuntimeClassName returns spec.runtimeClassName.
+	RuntimeClassName() *string
+	// SecurityContext returns spec.securityContext.
+	SecurityContext() *corev1.PodSecurityContext
+	// Containers returns spec.containers.
+	Containers() []corev1.Container
+	// InitContainers returns spec.initContainers.
+	InitContainers() []corev1.Container
+	// EphemeralContainers returns spec.ephemeralContainers.
+	EphemeralContainers() []corev1.EphemeralContainer
+	// Volumes returns spec.volumes.
+	Volumes() []corev1.Volume
+	// ResourceClaims returns spec.resourceClaims.
+	ResourceClaims() []corev1.PodResourceClaim
+}
+
+// EvaluatePodAccessor evaluates the pod provided by the accessor against the policy for the given level & version.
+func EvaluatePodAccessor(evaluator Evaluator, lv api.LevelVersion, pod PodAccessor) []CheckResult {
+	if p, ok := pod.(*corePodAccessor); ok {
+		return evaluator.EvaluatePod(lv, p.podMetadata, p.podSpec)
+	}
+	hostNetwork, hostPID, hostIPC := pod.HostNamespaces()
+	podMetadata := &metav1.ObjectMeta{Annotations: pod.Annotations()}
+	podSpec := &corev1.PodSpec{
+		HostNetwork:         hostNetwork,
+		HostPID:             hostPID,
+		HostIPC:             hostIPC,
+		HostUsers:           pod.HostUsers(),
+		OS:                  pod.OS(),
+		RuntimeClassName:    pod.RuntimeClassName(),
+		SecurityContext:     pod.SecurityContext(),
+		Containers:          pod.Containers(),
+		InitContainers:      pod.InitContainers(),
+		EphemeralContainers: pod.EphemeralContainers(),
+		Volumes:             pod.Volumes(),
+		ResourceClaims:      pod.ResourceClaims(),
+	}
+	return evaluator.EvaluatePod(lv, podMetadata, podSpec)
+}
+
+// NewCorePodAccessor returns a PodAccessor for the given corev1 pod metadata and spec.
+func NewCorePodAccessor(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec) PodAccessor {
+	if podMetadata == nil {
+		podMetadata = &metav1.ObjectMeta{}
+	}
+	return &corePodAccessor{podMetadata: podMetadata, podSpec: podSpec}
+}
+
+type corePodAccessor struct {
+	podMetadata *metav1.ObjectMeta
+	podSpec     *corev1.PodSpec
+}
+
+func (p *corePodAccessor) Annotations() map[string]string {
+	return p.podMetadata.Annotations
+}
+
+func (p *corePodAccessor) HostNamespaces() (network, pid, ipc bool) {
+	return p.podSpec.HostNetwork, p.podSpec.HostPID, p.podSpec.HostIPC
+}
+
+func (p *corePodAccessor) HostUsers() *bool {
+	return p.podSpec.HostUsers
+}
+
+func (p *corePodAccessor) OS() *corev1.PodOS {
+	return p.podSpec.OS
+}
+
+func (p *corePodAccessor) RuntimeClassName() *string {
+	return p.podSpec.RuntimeClassName
+}
+
+func (p *corePodAccessor) SecurityContext() *corev1.PodSecurityContext {
+	return p.podSpec.SecurityContext
+}
+
+func (p *corePodAccessor) Containers() []corev1.Container {
+	return p.podSpec.Containers
+}
+
+func (p *corePodAccessor) InitContainers() []corev1.Container {
+	return p.podSpec.InitContainers
+}
+
+func (p *corePodAccessor) EphemeralContainers() []corev1.EphemeralContainer {
+	return p.podSpec.EphemeralContainers
+}
+
+func (p *corePodAccessor) Volumes() []corev1.Volume {
+	return p.podSpec.Volumes
+}
+
+func (p *corePodAccessor) ResourceClaims() []corev1.PodResourceClaim {
+	return p.podSpec.ResourceClaims
+}
+
+// unstructuredSpecFields are the pod spec fields read by the checks, which are the fields kept by SanitizePod.
+// Other pod spec fields are not converted by NewUnstructuredPodAccessor.
+var unstructuredSpecFields = []string{
+	"hostNetwork", "hostPID", "hostIPC", "hostUsers", "os", "runtimeClassName", "securityContext", "volumes", "resourceClaims",
+}
+
+// unstructuredContainerFields are the container fields read by the checks, which are the fields kept by SanitizePod.
+// Other container fields are not converted by NewUnstructuredPodAccessor.
+var unstructuredContainerFields = []string{
+	"name", "image", "command", "args", "restartPolicy", "ports", "volumeMounts", "resources", "securityContext",
+	"livenessProbe", "readinessProbe", "startupProbe", "lifecycle",
+}
+
+// NewUnstructuredPodAccessor returns a PodAccessor for an unstructured pod or pod template,
+// holding "metadata" and "spec" fields. For workload resources, pass the pod template,
+// e.g. the "spec.template" field of a Deployment.
+// Only the fields read by the checks are converted, and invalid values of those fields are returned as an error.
+func NewUnstructuredPodAccessor(obj map[string]interface{}) (PodAccessor, error) {
+	p := &corePodAccessor{podMetadata: &metav1.ObjectMeta{}, podSpec: &corev1.PodSpec{}}
+
+	if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+		partial := map[string]interface{}{}
+		for _, f := range []string{"namespace", "annotations"} {
+			if v, ok := metadata[f]; ok {
+				partial[f] = v
+			}
+		}
+		if err := fromUnstructured(partial, p.podMetadata); err != nil {
+			return nil, fmt.Errorf("metadata: %w", err)
+		}
+	}
+
+	spec, ok := obj["spec"].(map[string]interface{})
+	if !ok {
+		return p, nil
+	}
+	partial := map[string]interface{}{}
+	for _, f := range unstructuredSpecFields {
+		if v, ok := spec[f]; ok {
+			partial[f] = v
+		}
+	}
+	for _, f := range []string{"containers", "initContainers", "ephemeralContainers"} {
+		if v, ok := spec[f]; ok {
+			containers, err := pruneContainers(v)
+			if err != nil {
+				return nil, fmt.Errorf("spec.%s: %w", f, err)
+			}
+			partial[f] = containers
+		}
+	}
+	if err := fromUnstructured(partial, p.podSpec); err != nil {
+		return nil, fmt.Errorf("spec: %w", err)
+	}
+	return p, nil
+}
+
+// pruneContainers returns the unstructured containers limited to the unstructuredContainerFields.
+func pruneContainers(v interface{}) ([]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	containers, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a list, got %T", v)
+	}
+	pruned := make([]interface{}, 0, len(containers))
+	for i, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("[%d]: expected an object, got %T", i, c)
+		}
+		prunedContainer := make(map[string]interface{}, len(unstructuredContainerFields))
+		for _, f := range unstructuredContainerFields {
+			if v, ok := container[f]; ok {
+				prunedContainer[f] = v
+			}
+		}
+		pruned = append(pruned, prunedContainer)
+	}
+	return pruned, nil
+}
+
+func fromUnstructured(u map[string]interface{}, obj interface{}) error {
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(u, obj)
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/utils/pointer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluatePodAccessor(t *testing.T) {
+	evaluator, err := NewEvaluator(DefaultChecks())
+	require.NoError(t, err)
+	lv := api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			Annotations: map[string]string{corev1.SeccompPodAnnotationKey: corev1.SeccompProfileNameUnconfined},
+		},
+		Spec: corev1.PodSpec{
+			HostNetwork: true,
+			Containers: []corev1.Container{{
+				Name:            "a",
+				Env:             []corev1.EnvVar{{Name: "FOO", Value: "bar"}},
+				Ports:           []corev1.ContainerPort{{ContainerPort: 80, HostPort: 80}},
+				SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(true)},
+			}},
+			InitContainers: []corev1.Container{{Name: "b", SecurityContext: &corev1.SecurityContext{RunAsUser: pointer.Int64(0)}}},
+			Volumes:        []corev1.Volume{{Name: "host", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/"}}}},
+			ResourceClaims: []corev1.PodResourceClaim{{Name: "gpu", Source: corev1.ClaimSource{ResourceClaimName: pointer.String("gpu")}}},
+		},
+	}
+	expected := evaluator.EvaluatePod(lv, &pod.ObjectMeta, &pod.Spec)
+
+	assert.Equal(t, expected, EvaluatePodAccessor(evaluator, lv, NewCorePodAccessor(&pod.ObjectMeta, &pod.Spec)))
+
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+	require.NoError(t, err)
+	accessor, err := NewUnstructuredPodAccessor(u)
+	require.NoError(t, err)
+	assert.Equal(t, expected, EvaluatePodAccessor(evaluator, lv, accessor))
+	assert.Empty(t, accessor.Containers()[0].Env, "unread container fields should not be converted")
+	assert.Equal(t, pod.Spec.ResourceClaims, accessor.ResourceClaims())
+
+	_, err = NewUnstructuredPodAccessor(map[string]interface{}{"spec": map[string]interface{}{"containers": "invalid"}})
+	assert.ErrorContains(t, err, "spec.containers")
+}
+
+// TestEvaluatePodAccessorMatchesEvaluatePod ensures every registered check returns the same results
+// for unstructured pods as for the corev1 pods they were converted from.
+func TestEvaluatePodAccessorMatchesEvaluatePod(t *testing.T) {
+	restartPolicyAlways := corev1.ContainerRestartPolicyAlways
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			Annotations: map[string]string{corev1.DeprecatedAppArmorBetaContainerAnnotationKeyPrefix + "a": "unconfined"},
+		},
+		Spec: corev1.PodSpec{
+			HostPID:          true,
+			RuntimeClassName: pointer.String("gvisor"),
+			SecurityContext:  &corev1.PodSecurityContext{RunAsNonRoot: pointer.Bool(true), SupplementalGroups: []int64{0}},
+			Containers: []corev1.Container{{
+				Name:         "a",
+				Image:        "registry.example.com/app:latest",
+				Command:      []string{"nsenter"},
+				Args:         []string{"--target", "1", "--mount", "--", "sh"},
+				VolumeMounts: []corev1.VolumeMount{{Name: "host", MountPath: "/host", ReadOnly: true}},
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("1"),
+					corev1.ResourceMemory: resource.MustParse("1Gi"),
+				}},
+				LivenessProbe: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{Exec: &corev1.ExecAction{Command: []string{"chroot", "/host"}}}},
+				Lifecycle: &corev1.Lifecycle{
+					PostStart: &corev1.LifecycleHandler{Exec: &corev1.ExecAction{Command: []string{"mount", "-t", "tmpfs"}}},
+				},
+				SecurityContext: &corev1.SecurityContext{RunAsUser: pointer.Int64(1000)},
+			}},
+			InitContainers: []corev1.Container{{
+				Name:          "a",
+				Image:         "registry.example.com/init",
+				RestartPolicy: &restartPolicyAlways,
+				Resources:     corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")}},
+			}},
+			Volumes: []corev1.Volume{{Name: "host", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/"}}}},
+		},
+	}
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+	require.NoError(t, err)
+	accessor, err := NewUnstructuredPodAccessor(u)
+	require.NoError(t, err)
+
+	checks := append(DefaultChecks(), OptionalChecks()...)
+	for _, check := range ExperimentalChecks() {
+		assigned, err := AssignExperimentalCheck(check, api.MajorMinorVersion(1, 0))
+		require.NoError(t, err)
+		checks = append(checks, assigned)
+	}
+	for _, check := range checks {
+		t.Run(string(check.ID), func(t *testing.T) {
+			evaluator, err := NewEvaluator([]Check{check}, WithFieldErrors())
+			require.NoError(t, err)
+			for _, level := range []api.Level{api.LevelBaseline, api.LevelRestricted} {
+				lv := api.LevelVersion{Level: level, Version: api.LatestVersion()}
+				expected := evaluator.EvaluatePod(lv, &pod.ObjectMeta, &pod.Spec)
+				assert.Equal(t, expected, EvaluatePodAccessor(evaluator, lv, accessor), level)
+			}
+		})
+	}
+
+	evaluator, err := NewEvaluator(OptionalChecks())
+	require.NoError(t, err)
+	results := EvaluatePodAccessor(evaluator, api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}, accessor)
+	allowed := map[CheckID]bool{}
+	for _, result := range results {
+		allowed[result.ID] = result.Allowed
+	}
+	assert.False(t, allowed[checkHostBreakoutCommandsID], "commands should be converted")
+	assert.True(t, allowed[checkResourceLimitsID], "resource limits should be converted")
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// WithoutBadValues clears the BadValue of the field errors of the checks with the given IDs, which must be registered,
+// for data-minimization requirements of clusters that must not record the user-controlled values read by some checks,
+// e.g. annotation values, SELinux users or host paths. It is applied to the results of the checks by the Evaluator
+// returned by NewEvaluator; the BadValue of the field errors of other checks is populated as usual.
+// The ForbiddenDetail of the results is unchanged.
+func WithoutBadValues(ids ...CheckID) Option {
+	return func(opt options) options {
+		opt.withoutBadValues = append(opt.withoutBadValues, ids...)
+		return opt
+	}
+}
+
+// withoutBadValuesSet returns the set of the check IDs whose BadValue is cleared by the options,
+// or an error if any of them is not one of the checks.
+func withoutBadValuesSet(checks []Check, opts options) (map[CheckID]bool, error) {
+	if len(opts.withoutBadValues) == 0 {
+		return nil, nil
+	}
+	ids := make(map[CheckID]bool, len(checks))
+	for _, c := range checks {
+		ids[c.ID] = true
+	}
+	set := make(map[CheckID]bool, len(opts.withoutBadValues))
+	for _, id := range opts.withoutBadValues {
+		if !ids[id] {
+			return nil, fmt.Errorf("cannot omit bad values of unknown check %s", id)
+		}
+		set[id] = true
+	}
+	return set, nil
+}
+
+// clearBadValues returns copies of the field errors without BadValue.
+func clearBadValues(errs field.ErrorList) field.ErrorList {
+	cleared := make(field.ErrorList, 0, len(errs))
+	for _, err := range errs {
+		if err == nil {
+			cleared = append(cleared, nil)
+			continue
+		}
+		clearedErr := *err
+		clearedErr.BadValue = nil
+		cleared = append(cleared, &clearedErr)
+	}
+	return cleared
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/pod-security-admission/api"
+)
+
+func TestWithoutBadValues(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"container.apparmor.security.beta.kubernetes.io/a": "unconfined"}},
+		Spec: corev1.PodSpec{
+			HostNetwork: true,
+			SecurityContext: &corev1.PodSecurityContext{
+				SELinuxOptions: &corev1.SELinuxOptions{User: "secret_u"},
+			},
+			Containers: []corev1.Container{{Name: "a"}},
+		},
+	}
+	lv := api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}
+	badValues := func(evaluator Evaluator) map[CheckID][]interface{} {
+		values := map[CheckID][]interface{}{}
+		for _, result := range evaluator.EvaluatePod(lv, &pod.ObjectMeta, &pod.Spec) {
+			if result.ErrList == nil {
+				continue
+			}
+			for _, err := range *result.ErrList {
+				values[result.ID] = append(values[result.ID], err.BadValue)
+			}
+		}
+		return values
+	}
+
+	evaluator, err := NewEvaluator(DefaultChecks(), WithFieldErrors())
+	require.NoError(t, err)
+	assert.Equal(t, map[CheckID][]interface{}{
+		"appArmorProfile": {"unconfined"},
+		"hostNamespaces":  {true},
+		"seLinuxOptions":  {"secret_u"},
+	}, badValues(evaluator))
+
+	evaluator, err = NewEvaluator(DefaultChecks(), WithFieldErrors(), WithoutBadValues("appArmorProfile", "seLinuxOptions"))
+	require.NoError(t, err)
+	assert.Equal(t, map[CheckID][]interface{}{
+		"appArmorProfile": {nil},
+		"hostNamespaces":  {true},
+		"seLinuxOptions":  {nil},
+	}, badValues(evaluator))
+
+	_, err = NewEvaluator(DefaultChecks(), WithoutBadValues("unknown"))
+	assert.ErrorContains(t, err, "unknown check unknown")
+}
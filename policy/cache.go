@@ -0,0 +1,142 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/utils/lru"
+)
+
+// WithResultCache caches the results of up to size pods in the Evaluator returned by NewEvaluator, evicting the least
+// recently evaluated pods, so the identical pods of large ReplicaSets or Jobs are only evaluated once per level & version.
+// Results are keyed by the evaluated level & version and by the PodHash of the pod, hashing the fields kept by SanitizePod:
+// its namespace, seccomp and AppArmor annotations, and the spec fields read by the checks of this package, so pods only
+// differing in other annotations or fields share an entry. If custom checks are registered (see CheckID.Origin),
+// which may read any field, results are keyed by the hash of the whole pod metadata and spec instead.
+// Since the registered checks and options cannot change, entries never go stale;
+// policy version changes evaluate other keys, and new checks are registered in a new Evaluator with an empty cache.
+//
+// recordLookup is optional, and called for each cache lookup, e.g. to export the hit rate.
+// A size of 0 disables the cache. The cache must not be combined with WithRuntimeClassDefaults,
+// WithLocalhostProfileCatalog or WithDeviceClassResolver, whose results depend on objects that may change.
+func WithResultCache(size int, recordLookup func(hit bool)) Option {
+	return func(opt options) options {
+		opt.resultCacheSize = size
+		opt.recordResultCacheLookup = recordLookup
+		return opt
+	}
+}
+
+// resultCache is an LRU cache of the results of evaluated pods.
+type resultCache struct {
+	cache        *lru.Cache
+	recordLookup func(hit bool)
+	// hash hashes the evaluated pods: PodHash, or unsanitizedPodHash if custom checks are registered.
+	hash func(*metav1.ObjectMeta, *corev1.PodSpec) ([sha256.Size]byte, error)
+}
+
+// newResultCache returns the result cache of the checks configured by the options, or nil if it is disabled.
+func newResultCache(checks []Check, opts options) (*resultCache, error) {
+	if opts.resultCacheSize <= 0 {
+		return nil, nil
+	}
+	if opts.runtimeClassDefaultsResolver != nil || opts.localhostProfileCatalog != nil || opts.deviceClassResolver != nil {
+		return nil, fmt.Errorf("result cache cannot be combined with resolvers of RuntimeClasses, Localhost profiles or device classes")
+	}
+	c := &resultCache{cache: lru.New(opts.resultCacheSize), recordLookup: opts.recordResultCacheLookup, hash: PodHash}
+	for _, check := range checks {
+		if check.ID.Origin() == CheckOriginCustom {
+			c.hash = unsanitizedPodHash
+			break
+		}
+	}
+	return c, nil
+}
+
+// unsanitizedPodHash returns the SHA-256 hash of the JSON encoding of the pod metadata and spec, including the fields
+// dropped by SanitizePod, which custom checks may read.
+func unsanitizedPodHash(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec) ([sha256.Size]byte, error) {
+	pod := struct {
+		Metadata *metav1.ObjectMeta `json:"metadata"`
+		Spec     *corev1.PodSpec    `json:"spec"`
+	}{podMetadata, podSpec}
+	data, err := json.Marshal(pod)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
+
+// resultCacheKey is the key of the results of a pod evaluated at a level & version.
+type resultCacheKey struct {
+	lv   api.LevelVersion
+	hash [sha256.Size]byte
+}
+
+// key returns the key of the results of the pod evaluated at the level & version,
+// and false if the pod cannot be hashed.
+func (c *resultCache) key(lv api.LevelVersion, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec) (resultCacheKey, bool) {
+	hash, err := c.hash(podMetadata, podSpec)
+	if err != nil {
+		return resultCacheKey{}, false
+	}
+	return resultCacheKey{lv: lv, hash: hash}, true
+}
+
+// get returns a copy of the cached results for the key.
+func (c *resultCache) get(key resultCacheKey) ([]CheckResult, bool) {
+	value, ok := c.cache.Get(key)
+	if c.recordLookup != nil {
+		c.recordLookup(ok)
+	}
+	if !ok {
+		return nil, false
+	}
+	return copyCheckResults(value.([]CheckResult)), true
+}
+
+// add caches a copy of the results for the key.
+func (c *resultCache) add(key resultCacheKey, results []CheckResult) {
+	c.cache.Add(key, copyCheckResults(results))
+}
+
+// copyCheckResults returns a copy of the results, so callers cannot mutate the cached results.
+func copyCheckResults(results []CheckResult) []CheckResult {
+	copied := make([]CheckResult, len(results))
+	for i, result := range results {
+		if result.ErrList != nil {
+			errs := make(field.ErrorList, len(*result.ErrList))
+			for j, err := range *result.ErrList {
+				e := *err
+				errs[j] = &e
+			}
+			result.ErrList = &errs
+		}
+		result.Containers = append([]string(nil), result.Containers...)
+		result.SidecarContainers = append([]string(nil), result.SidecarContainers...)
+		result.Resolutions = append([]ProfileResolution(nil), result.Resolutions...)
+		copied[i] = result
+	}
+	return copied
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/utils/pointer"
+)
+
+func TestResultCache(t *testing.T) {
+	var hits, misses int
+	recordLookup := func(hit bool) {
+		if hit {
+			hits++
+		} else {
+			misses++
+		}
+	}
+	uncached, err := NewEvaluator(DefaultChecks(), WithFieldErrors())
+	require.NoError(t, err)
+	cached, err := NewEvaluator(DefaultChecks(), WithFieldErrors(), WithResultCache(2, recordLookup))
+	require.NoError(t, err)
+
+	restricted := api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()}
+	baseline := api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}
+	metadata := &metav1.ObjectMeta{Name: "pod-1", Namespace: "ns"}
+	spec := &corev1.PodSpec{Containers: []corev1.Container{{
+		Name:            "app",
+		SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(true)},
+	}}}
+
+	expected := uncached.EvaluatePod(restricted, metadata, spec)
+	assert.Equal(t, expected, cached.EvaluatePod(restricted, metadata, spec))
+	assert.Equal(t, []int{0, 1}, []int{hits, misses})
+
+	// pods of the same ReplicaSet only differ in fields not read by the checks
+	results := cached.EvaluatePod(restricted, &metav1.ObjectMeta{Name: "pod-2", Namespace: "ns", Labels: map[string]string{"a": "b"}}, spec.DeepCopy())
+	assert.Equal(t, expected, results)
+	assert.Equal(t, []int{1, 1}, []int{hits, misses})
+
+	// annotations not read by the checks, like the ones set by deployment tooling, share the entry
+	rolledOut := &metav1.ObjectMeta{Name: "pod-3", Namespace: "ns", Annotations: map[string]string{"kubectl.kubernetes.io/restartedAt": "2024-01-01T00:00:00Z"}}
+	assert.Equal(t, expected, cached.EvaluatePod(restricted, rolledOut, spec))
+	assert.Equal(t, []int{2, 1}, []int{hits, misses})
+
+	// cached results cannot be mutated by callers
+	for i := range results {
+		if results[i].ErrList != nil {
+			(*results[i].ErrList)[0].Detail = "mutated"
+			results[i].Containers[0] = "mutated"
+		}
+		results[i].Allowed = !results[i].Allowed
+	}
+	assert.Equal(t, expected, cached.EvaluatePod(restricted, metadata, spec))
+	assert.Equal(t, []int{3, 1}, []int{hits, misses})
+
+	// the level & version, namespace, annotations and spec are part of the key
+	assert.Equal(t, uncached.EvaluatePod(baseline, metadata, spec), cached.EvaluatePod(baseline, metadata, spec))
+	annotated := &metav1.ObjectMeta{Namespace: "ns", Annotations: map[string]string{corev1.SeccompPodAnnotationKey: "unconfined"}}
+	assert.Equal(t, uncached.EvaluatePod(baseline, annotated, spec), cached.EvaluatePod(baseline, annotated, spec))
+	allowedSpec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}}
+	assert.Equal(t, uncached.EvaluatePod(baseline, metadata, allowedSpec), cached.EvaluatePod(baseline, metadata, allowedSpec))
+	assert.Equal(t, []int{3, 4}, []int{hits, misses})
+
+	// the least recently evaluated pods are evicted
+	cached.EvaluatePod(restricted, metadata, spec)
+	assert.Equal(t, []int{3, 5}, []int{hits, misses})
+
+	// in-place resizes only change the resources and resizePolicy of containers, which share a cache entry
+	resizable := &corev1.PodSpec{Containers: []corev1.Container{{
+		Name:         "app",
+		Resources:    corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+		ResizePolicy: []corev1.ContainerResizePolicy{{ResourceName: corev1.ResourceCPU, RestartPolicy: corev1.NotRequired}},
+	}}}
+	assert.Equal(t, uncached.EvaluatePod(baseline, metadata, resizable), cached.EvaluatePod(baseline, metadata, resizable))
+	assert.Equal(t, []int{3, 6}, []int{hits, misses})
+	resized := resizable.DeepCopy()
+	resized.Containers[0].Resources.Limits[corev1.ResourceCPU] = resource.MustParse("2")
+	resized.Containers[0].Resources.Requests = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}
+	resized.Containers[0].ResizePolicy[0].RestartPolicy = corev1.RestartContainer
+	assert.Equal(t, uncached.EvaluatePod(baseline, metadata, resized), cached.EvaluatePod(baseline, metadata, resized))
+	assert.Equal(t, []int{4, 6}, []int{hits, misses})
+
+	// privileged pods are not evaluated
+	assert.Empty(t, cached.EvaluatePod(api.LevelVersion{Level: api.LevelPrivileged, Version: api.LatestVersion()}, metadata, spec))
+	assert.Equal(t, []int{4, 6}, []int{hits, misses})
+}
+
+func TestResultCacheOptions(t *testing.T) {
+	evaluator, err := NewEvaluator(DefaultChecks(), WithResultCache(0, nil))
+	require.NoError(t, err)
+	assert.Nil(t, evaluator.(*checkRegistry).cache)
+
+	evaluator, err = NewEvaluator(DefaultChecks(), WithResultCache(10, nil))
+	require.NoError(t, err)
+	lv := api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()}
+	evaluator.EvaluatePod(lv, &metav1.ObjectMeta{}, &corev1.PodSpec{})
+	assert.Equal(t, 1, evaluator.(*checkRegistry).cache.cache.Len())
+
+	_, err = NewEvaluator(DefaultChecks(), WithResultCache(10, nil), WithRuntimeClassDefaults(testRuntimeClassDefaults{}))
+	assert.Error(t, err, "results of resolved profiles may change")
+}
+
+func TestResultCacheResourceLimits(t *testing.T) {
+	// the presence of limits read by the resourceLimits check is part of the key, unlike their quantities
+	checks := append(DefaultChecks(), CheckResourceLimits())
+	uncached, err := NewEvaluator(checks)
+	require.NoError(t, err)
+	cached, err := NewEvaluator(checks, WithResultCache(10, nil))
+	require.NoError(t, err)
+
+	baseline := api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}
+	limited := &corev1.PodSpec{Containers: []corev1.Container{{
+		Name: "app",
+		Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		}},
+	}}}
+	resized := limited.DeepCopy()
+	resized.Containers[0].Resources.Limits[corev1.ResourceMemory] = resource.MustParse("2Gi")
+	unlimited := limited.DeepCopy()
+	delete(unlimited.Containers[0].Resources.Limits, corev1.ResourceMemory)
+
+	for _, spec := range []*corev1.PodSpec{limited, resized, unlimited, limited} {
+		assert.Equal(t, uncached.EvaluatePod(baseline, &metav1.ObjectMeta{}, spec), cached.EvaluatePod(baseline, &metav1.ObjectMeta{}, spec))
+	}
+	assert.Equal(t, 2, cached.(*checkRegistry).cache.cache.Len())
+}
+
+func TestResultCacheCustomChecks(t *testing.T) {
+	// the custom check reads a label dropped by SanitizePod, so the pods are hashed as a whole
+	teamCheck := Check{
+		ID:    testCheckDomain + "team",
+		Level: api.LevelBaseline,
+		Versions: []VersionedCheck{{
+			MinimumVersion: api.MajorMinorVersion(1, 0),
+			CheckPod: func(podMetadata *metav1.ObjectMeta, _ *corev1.PodSpec, _ ...Option) CheckResult {
+				if podMetadata.Labels["team"] == "" {
+					return CheckResult{Allowed: false, ForbiddenReason: "team label", ForbiddenDetail: "pods must set the team label"}
+				}
+				return CheckResult{Allowed: true}
+			},
+		}},
+	}
+	checks := append(DefaultChecks(), teamCheck)
+	uncached, err := NewEvaluator(checks)
+	require.NoError(t, err)
+	cached, err := NewEvaluator(checks, WithResultCache(10, nil))
+	require.NoError(t, err)
+
+	baseline := api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}
+	spec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}}
+	labeled := &metav1.ObjectMeta{Name: "pod-1", Namespace: "ns", Labels: map[string]string{"team": "a"}}
+	unlabeled := &metav1.ObjectMeta{Name: "pod-1", Namespace: "ns"}
+	for _, metadata := range []*metav1.ObjectMeta{labeled, unlabeled, labeled} {
+		assert.Equal(t, uncached.EvaluatePod(baseline, metadata, spec), cached.EvaluatePod(baseline, metadata, spec))
+	}
+	assert.False(t, AggregateCheckResults(cached.EvaluatePod(baseline, unlabeled, spec)).Allowed)
+	assert.Equal(t, 2, cached.(*checkRegistry).cache.cache.Len())
+}
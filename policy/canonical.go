@@ -0,0 +1,87 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// prunableTypes are the types whose empty values are equivalent to nil for the checks.
+// Empty members of unions, like the sources of volumes, empty profiles, which are invalid, and empty security contexts,
+// reported differently by the field errors of the checks, are not prunable, since their presence is meaningful.
+var prunableTypes = map[reflect.Type]bool{
+	reflect.TypeOf(corev1.Capabilities{}):                  true,
+	reflect.TypeOf(corev1.SELinuxOptions{}):                true,
+	reflect.TypeOf(corev1.WindowsSecurityContextOptions{}): true,
+	reflect.TypeOf(corev1.PodOS{}):                         true,
+	reflect.TypeOf(corev1.Lifecycle{}):                     true,
+}
+
+// PodHash returns the SHA-256 hash of the JSON encoding of the pod metadata and spec sanitized with SanitizePod,
+// so pods evaluated identically by the checks hash identically. It is used by the caches of this package, like
+// WithResultCache, and by the decision ledger, and integrators can use it to key their own caches identically.
+// Maps are encoded with sorted keys by encoding/json.
+func PodHash(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec) ([sha256.Size]byte, error) {
+	sanitizedMetadata, sanitizedSpec := SanitizePod(podMetadata, podSpec)
+	pod := struct {
+		Namespace   string            `json:"namespace,omitempty"`
+		Annotations map[string]string `json:"annotations,omitempty"`
+		Spec        *corev1.PodSpec   `json:"spec"`
+	}{sanitizedMetadata.Namespace, sanitizedMetadata.Annotations, sanitizedSpec}
+	data, err := json.Marshal(pod)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
+
+// canonicalize canonicalizes the settable value in place, as described by SanitizePod.
+func canonicalize(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		canonicalize(v.Elem())
+		if prunableTypes[v.Type().Elem()] && v.Elem().IsZero() {
+			v.Set(reflect.Zero(v.Type()))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Field(i); f.CanSet() {
+				canonicalize(f)
+			}
+		}
+	case reflect.Slice:
+		if v.Len() == 0 {
+			v.Set(reflect.Zero(v.Type()))
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			canonicalize(v.Index(i))
+		}
+	case reflect.Map:
+		if v.Len() == 0 {
+			v.Set(reflect.Zero(v.Type()))
+		}
+	}
+}
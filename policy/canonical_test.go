@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/utils/pointer"
+)
+
+func TestSanitizePodCanonicalForm(t *testing.T) {
+	metadata := &metav1.ObjectMeta{Name: "a", Namespace: "ns", Labels: map[string]string{"app": "a"}, Annotations: map[string]string{}}
+	spec := &corev1.PodSpec{
+		SecurityContext: &corev1.PodSecurityContext{SupplementalGroups: []int64{}},
+		NodeSelector:    map[string]string{},
+		Containers: []corev1.Container{{
+			Name: "a",
+			SecurityContext: &corev1.SecurityContext{
+				Capabilities:   &corev1.Capabilities{Drop: []corev1.Capability{}},
+				SELinuxOptions: &corev1.SELinuxOptions{},
+			},
+			Lifecycle: &corev1.Lifecycle{PreStop: &corev1.LifecycleHandler{Sleep: &corev1.SleepAction{Seconds: 1}}},
+		}},
+		Volumes: []corev1.Volume{{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}},
+	}
+	canonicalMetadata, canonicalSpec := SanitizePod(metadata, spec)
+	assert.Equal(t, &metav1.ObjectMeta{Namespace: "ns"}, canonicalMetadata)
+	expected := &corev1.PodSpec{
+		SecurityContext: &corev1.PodSecurityContext{},
+		Containers:      []corev1.Container{{Name: "a", SecurityContext: &corev1.SecurityContext{}}},
+		Volumes:         []corev1.Volume{{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}},
+	}
+	assert.Equal(t, expected, canonicalSpec, "empty security contexts and volume sources are kept")
+	assert.NotNil(t, spec.Containers[0].SecurityContext.Capabilities, "the pod must not be mutated")
+
+	_, nilSpec := SanitizePod(nil, nil)
+	assert.Nil(t, nilSpec)
+
+	hash, err := PodHash(metadata, spec)
+	require.NoError(t, err)
+	canonicalHash, err := PodHash(&metav1.ObjectMeta{Name: "b", Namespace: "ns"}, expected)
+	require.NoError(t, err)
+	assert.Equal(t, hash, canonicalHash)
+
+	for _, other := range []*corev1.PodSpec{
+		{Containers: []corev1.Container{{Name: "a"}}},
+		{Containers: []corev1.Container{{Name: "a", SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(false)}}}},
+	} {
+		otherHash, err := PodHash(metadata, other)
+		require.NoError(t, err)
+		assert.NotEqual(t, hash, otherHash)
+	}
+	otherNamespaceHash, err := PodHash(&metav1.ObjectMeta{Namespace: "other"}, spec)
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, otherNamespaceHash)
+}
+
+func TestSanitizePodEvaluation(t *testing.T) {
+	evaluator, err := NewEvaluator(append(DefaultChecks(), OptionalChecks()...), WithFieldErrors())
+	require.NoError(t, err)
+	for _, spec := range []*corev1.PodSpec{
+		// fields only read by the optional checks
+		{
+			HostPID: true,
+			Containers: []corev1.Container{{
+				Name:            "a",
+				Command:         []string{"nsenter", "-t", "1", "-m", "sh"},
+				VolumeMounts:    []corev1.VolumeMount{{Name: "host", MountPath: "/host"}},
+				Resources:       corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+				SecurityContext: &corev1.SecurityContext{RunAsNonRoot: pointer.Bool(true)},
+				LivenessProbe:   &corev1.Probe{ProbeHandler: corev1.ProbeHandler{Exec: &corev1.ExecAction{Command: []string{"sudo", "true"}}}},
+				Lifecycle:       &corev1.Lifecycle{PreStop: &corev1.LifecycleHandler{Exec: &corev1.ExecAction{Command: []string{"su", "-c", "true"}}}},
+			}},
+			Volumes: []corev1.Volume{{Name: "host", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/"}}}},
+		},
+		{Containers: []corev1.Container{{Name: "a", SecurityContext: &corev1.SecurityContext{}}}},
+		{SecurityContext: &corev1.PodSecurityContext{}, Containers: []corev1.Container{{Name: "a"}}},
+		{
+			SecurityContext: &corev1.PodSecurityContext{
+				SeccompProfile: &corev1.SeccompProfile{},
+				SELinuxOptions: &corev1.SELinuxOptions{},
+				WindowsOptions: &corev1.WindowsSecurityContextOptions{},
+				Sysctls:        []corev1.Sysctl{},
+			},
+			Containers: []corev1.Container{{Name: "a", Ports: []corev1.ContainerPort{}, SecurityContext: &corev1.SecurityContext{
+				Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{}},
+				AllowPrivilegeEscalation: pointer.Bool(false),
+				SELinuxOptions:           &corev1.SELinuxOptions{},
+				WindowsOptions:           &corev1.WindowsSecurityContextOptions{},
+				AppArmorProfile:          &corev1.AppArmorProfile{},
+			}}},
+			Volumes: []corev1.Volume{{Name: "tmp", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}},
+			OS:      &corev1.PodOS{},
+		},
+	} {
+		metadata := &metav1.ObjectMeta{Annotations: map[string]string{}}
+		canonicalMetadata, canonicalSpec := SanitizePod(metadata, spec)
+		for _, level := range []api.Level{api.LevelBaseline, api.LevelRestricted} {
+			lv := api.LevelVersion{Level: level, Version: api.LatestVersion()}
+			assert.Equal(t, evaluator.EvaluatePod(lv, metadata, spec), evaluator.EvaluatePod(lv, canonicalMetadata, canonicalSpec))
+		}
+	}
+}
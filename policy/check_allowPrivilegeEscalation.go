@@ -44,14 +44,15 @@ func init() {
 // that requires allowPrivilegeEscalation=false in 1.8+
 func CheckAllowPrivilegeEscalation() Check {
 	return Check{
-		ID:    "allowPrivilegeEscalation",
-		Level: api.LevelRestricted,
+		ID:       "allowPrivilegeEscalation",
+		Level:    api.LevelRestricted,
+		SpecOnly: true,
 		Versions: []VersionedCheck{
 			{
 				// Field added in 1.8:
 				// https://github.com/kubernetes/kubernetes/blob/v1.8.0/staging/src/k8s.io/api/core/v1/types.go#L4797-L4804
 				MinimumVersion: api.MajorMinorVersion(1, 8),
-				CheckPod:       withOptions(allowPrivilegeEscalationV1Dot8),
+				CheckPod:       withOptions(linuxOnly(allowPrivilegeEscalationV1Dot8, false)),
 			},
 			{
 				// Starting 1.25, windows pods would be exempted from this check using pod.spec.os field when set to windows.
@@ -63,20 +64,20 @@ func CheckAllowPrivilegeEscalation() Check {
 }
 
 func allowPrivilegeEscalationV1Dot8(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
-	badContainers := NewViolations(opts.withFieldErrors)
+	badContainers := newViolations(opts)
 
-	visitContainers(podSpec, opts, func(container *corev1.Container, path *field.Path) {
+	visitContainers(podSpec, opts, func(container *corev1.Container, kind ContainerKind, path *field.Path) {
 		if opts.withFieldErrors {
 			path = path.Child("securityContext", "allowPrivilegeEscalation")
 			if container.SecurityContext == nil {
-				badContainers.Add(container.Name, required(path))
+				badContainers.AddContainer(container.Name, kind, required(path))
 			} else if container.SecurityContext.AllowPrivilegeEscalation == nil {
-				badContainers.Add(container.Name, withBadValue(forbidden(path), "nil"))
+				badContainers.AddContainer(container.Name, kind, withBadValue(forbidden(path), "nil"))
 			} else if *container.SecurityContext.AllowPrivilegeEscalation {
-				badContainers.Add(container.Name, withBadValue(forbidden(path), true))
+				badContainers.AddContainer(container.Name, kind, withBadValue(forbidden(path), true))
 			}
 		} else if container.SecurityContext == nil || container.SecurityContext.AllowPrivilegeEscalation == nil || *container.SecurityContext.AllowPrivilegeEscalation {
-			badContainers.Add(container.Name)
+			badContainers.AddContainer(container.Name, kind)
 		}
 	})
 
@@ -85,9 +86,8 @@ func allowPrivilegeEscalationV1Dot8(podMetadata *metav1.ObjectMeta, podSpec *cor
 			Allowed:         false,
 			ForbiddenReason: "allowPrivilegeEscalation != false",
 			ForbiddenDetail: fmt.Sprintf(
-				"%s %s must set securityContext.allowPrivilegeEscalation=false",
-				pluralize("container", "containers", badContainers.Len()),
-				joinQuote(badContainers.Data()),
+				"%s must set securityContext.allowPrivilegeEscalation=false",
+				badContainers.DescribeContainers(),
 			),
 			ErrList: badContainers.Errs(),
 		}
@@ -98,8 +98,5 @@ func allowPrivilegeEscalationV1Dot8(podMetadata *metav1.ObjectMeta, podSpec *cor
 func allowPrivilegeEscalationV1Dot25(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
 	// Pod API validation would have failed if podOS == Windows and if privilegeEscalation has been set.
 	// We can admit the Windows pod even if privilegeEscalation has not been set.
-	if podSpec.OS != nil && podSpec.OS.Name == corev1.Windows {
-		return CheckResult{Allowed: true}
-	}
-	return allowPrivilegeEscalationV1Dot8(podMetadata, podSpec, opts)
+	return linuxOnly(allowPrivilegeEscalationV1Dot8, true)(podMetadata, podSpec, opts)
 }
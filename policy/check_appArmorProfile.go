@@ -82,7 +82,7 @@ func allowedProfileType(profile corev1.AppArmorProfileType) bool {
 }
 
 func appArmorProfileV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
-	badSetters := NewViolations(opts.withFieldErrors) // things that explicitly set appArmorProfile.type to a bad value
+	badSetters := newViolations(opts) // things that explicitly set appArmorProfile.type to a bad value
 	badValues := sets.NewString()
 
 	if podSpec.SecurityContext != nil && podSpec.SecurityContext.AppArmorProfile != nil {
@@ -96,13 +96,13 @@ func appArmorProfileV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSp
 		}
 	}
 
-	badContainers := NewViolations(opts.withFieldErrors) // containers that set apparmorProfile.type to a bad value
+	badContainers := newViolations(opts) // containers that set apparmorProfile.type to a bad value
 	var errs field.ErrorList
 
-	visitContainers(podSpec, opts, func(c *corev1.Container, path *field.Path) {
+	visitContainers(podSpec, opts, func(c *corev1.Container, kind ContainerKind, path *field.Path) {
 		if c.SecurityContext != nil && c.SecurityContext.AppArmorProfile != nil {
 			if !allowedProfileType(c.SecurityContext.AppArmorProfile.Type) {
-				badContainers.Add(c.Name)
+				badContainers.AddContainer(c.Name, kind)
 				errs = append(errs, withBadValue(forbidden(path.Child("securityContext", "appArmorProfile", "type")), string(c.SecurityContext.AppArmorProfile.Type)))
 				badValues.Insert(string(c.SecurityContext.AppArmorProfile.Type))
 			}
@@ -111,16 +111,12 @@ func appArmorProfileV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSp
 
 	if !badContainers.Empty() {
 		badSetters.Add(
-			fmt.Sprintf(
-				"%s %s",
-				pluralize("container", "containers", badContainers.Len()),
-				joinQuote(badContainers.Data()),
-			),
+			badContainers.DescribeContainers(),
 			errs...,
 		)
 	}
 
-	forbiddenAnnotations := NewViolations(opts.withFieldErrors)
+	forbiddenAnnotations := newViolations(opts)
 	for k, v := range podMetadata.Annotations {
 		if strings.HasPrefix(k, corev1.DeprecatedAppArmorBetaContainerAnnotationKeyPrefix) && !allowedAnnotationValue(v) {
 			if opts.withFieldErrors {
@@ -32,6 +32,7 @@ Adding NET_RAW or capabilities beyond the default set must be disallowed.
 **Restricted Fields:**
 spec.containers[*].securityContext.capabilities.add
 spec.initContainers[*].securityContext.capabilities.add
+spec.securityContext.capabilities.add (when the PodLevelCapabilities feature is enabled)
 
 **Allowed Values:**
 undefined / empty
@@ -48,8 +49,9 @@ const checkCapabilitiesBaselineID CheckID = "capabilities_baseline"
 // that limits the capabilities that can be added in 1.0+
 func CheckCapabilitiesBaseline() Check {
 	return Check{
-		ID:    checkCapabilitiesBaselineID,
-		Level: api.LevelBaseline,
+		ID:       checkCapabilitiesBaselineID,
+		Level:    api.LevelBaseline,
+		SpecOnly: true,
 		Versions: []VersionedCheck{
 			{
 				MinimumVersion: api.MajorMinorVersion(1, 0),
@@ -78,14 +80,15 @@ var (
 )
 
 func capabilitiesBaselineV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
-	badContainers := NewViolations(opts.withFieldErrors)
+	badContainers := newViolations(opts)
 	nonDefaultCapabilities := sets.NewString()
-	visitContainers(podSpec, opts, func(container *corev1.Container, path *field.Path) {
-		if container.SecurityContext != nil && container.SecurityContext.Capabilities != nil {
+	visitContainers(podSpec, opts, func(container *corev1.Container, kind ContainerKind, path *field.Path) {
+		capabilities, capabilitiesPath := containerCapabilities(podSpec, container, path, opts)
+		if capabilities != nil {
 			valid := true
 			if opts.withFieldErrors {
 				forbiddenValue := sets.NewString()
-				for _, c := range container.SecurityContext.Capabilities.Add {
+				for _, c := range capabilities.Add {
 					if !capabilities_allowed_1_0.Has(string(c)) {
 						valid = false
 						nonDefaultCapabilities.Insert(string(c))
@@ -93,17 +96,17 @@ func capabilitiesBaselineV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.
 					}
 				}
 				if !valid {
-					badContainers.Add(container.Name, withBadValue(forbidden(path.Child("securityContext", "capabilities", "add")), forbiddenValue.List()))
+					badContainers.AddContainer(container.Name, kind, withBadValue(forbidden(capabilitiesPath.Child("add")), forbiddenValue.List()))
 				}
 			} else {
-				for _, c := range container.SecurityContext.Capabilities.Add {
+				for _, c := range capabilities.Add {
 					if !capabilities_allowed_1_0.Has(string(c)) {
 						valid = false
 						nonDefaultCapabilities.Insert(string(c))
 					}
 				}
 				if !valid {
-					badContainers.Add(container.Name)
+					badContainers.AddContainer(container.Name, kind)
 				}
 			}
 		}
@@ -114,9 +117,8 @@ func capabilitiesBaselineV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.
 			Allowed:         false,
 			ForbiddenReason: "non-default capabilities",
 			ForbiddenDetail: fmt.Sprintf(
-				"%s %s must not include %s in securityContext.capabilities.add",
-				pluralize("container", "containers", badContainers.Len()),
-				joinQuote(badContainers.Data()),
+				"%s must not include %s in securityContext.capabilities.add",
+				badContainers.DescribeContainers(),
 				joinQuote(nonDefaultCapabilities.List()),
 			),
 			ErrList: badContainers.Errs(),
@@ -38,6 +38,7 @@ Containers must drop ALL, and may only add NET_BIND_SERVICE.
 **Restricted Fields:**
 spec.containers[*].securityContext.capabilities.drop
 spec.initContainers[*].securityContext.capabilities.drop
+spec.securityContext.capabilities.drop (when the PodLevelCapabilities feature is enabled)
 
 **Allowed Values:**
 Must include "ALL"
@@ -45,6 +46,7 @@ Must include "ALL"
 **Restricted Fields:**
 spec.containers[*].securityContext.capabilities.add
 spec.initContainers[*].securityContext.capabilities.add
+spec.securityContext.capabilities.add (when the PodLevelCapabilities feature is enabled)
 
 **Allowed Values:**
 undefined / empty
@@ -59,12 +61,13 @@ func init() {
 // that ensures ALL capabilities are dropped in 1.22+
 func CheckCapabilitiesRestricted() Check {
 	return Check{
-		ID:    "capabilities_restricted",
-		Level: api.LevelRestricted,
+		ID:       "capabilities_restricted",
+		Level:    api.LevelRestricted,
+		SpecOnly: true,
 		Versions: []VersionedCheck{
 			{
 				MinimumVersion:   api.MajorMinorVersion(1, 22),
-				CheckPod:         withOptions(capabilitiesRestrictedV1Dot22),
+				CheckPod:         withOptions(linuxOnly(capabilitiesRestrictedV1Dot22, false)),
 				OverrideCheckIDs: []CheckID{checkCapabilitiesBaselineID},
 			},
 			// Starting 1.25, windows pods would be exempted from this check using pod.spec.os field when set to windows.
@@ -79,17 +82,18 @@ func CheckCapabilitiesRestricted() Check {
 
 func capabilitiesRestrictedV1Dot22(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
 	forbiddenCapabilities := sets.NewString()
-	containersMissingDropAll := NewViolations(opts.withFieldErrors)
-	containersAddingForbidden := NewViolations(opts.withFieldErrors)
+	containersMissingDropAll := newViolations(opts)
+	containersAddingForbidden := newViolations(opts)
 
-	visitContainers(podSpec, opts, func(container *corev1.Container, path *field.Path) {
-		if container.SecurityContext == nil || container.SecurityContext.Capabilities == nil {
-			containersMissingDropAll.Add(container.Name, required(path.Child("securityContext", "capabilities", "drop")))
+	visitContainers(podSpec, opts, func(container *corev1.Container, kind ContainerKind, path *field.Path) {
+		capabilities, capabilitiesPath := containerCapabilities(podSpec, container, path, opts)
+		if capabilities == nil {
+			containersMissingDropAll.AddContainer(container.Name, kind, required(capabilitiesPath.Child("drop")))
 			return
 		}
 
 		droppedAll := false
-		for _, c := range container.SecurityContext.Capabilities.Drop {
+		for _, c := range capabilities.Drop {
 			if c == capabilityAll {
 				droppedAll = true
 				break
@@ -97,26 +101,26 @@ func capabilitiesRestrictedV1Dot22(podMetadata *metav1.ObjectMeta, podSpec *core
 		}
 		if !droppedAll {
 			if opts.withFieldErrors {
-				length := len(container.SecurityContext.Capabilities.Drop)
+				length := len(capabilities.Drop)
 				if length > 0 {
-					strSlice := make([]string, len(container.SecurityContext.Capabilities.Drop))
-					for i, v := range container.SecurityContext.Capabilities.Drop {
+					strSlice := make([]string, len(capabilities.Drop))
+					for i, v := range capabilities.Drop {
 						strSlice[i] = string(v)
 					}
 					forbiddenValues := sets.NewString(strSlice...)
-					containersMissingDropAll.Add(container.Name, withBadValue(forbidden(path.Child("securityContext", "capabilities", "drop")), forbiddenValues.List()))
+					containersMissingDropAll.AddContainer(container.Name, kind, withBadValue(forbidden(capabilitiesPath.Child("drop")), forbiddenValues.List()))
 				} else if length == 0 {
-					containersMissingDropAll.Add(container.Name, required(path.Child("securityContext", "capabilities", "drop")))
+					containersMissingDropAll.AddContainer(container.Name, kind, required(capabilitiesPath.Child("drop")))
 				}
 			} else {
-				containersMissingDropAll.Add(container.Name)
+				containersMissingDropAll.AddContainer(container.Name, kind)
 			}
 		}
 
 		addedForbidden := false
 		if opts.withFieldErrors {
 			forbiddenValues := sets.NewString()
-			for _, c := range container.SecurityContext.Capabilities.Add {
+			for _, c := range capabilities.Add {
 				if c != capabilityNetBindService {
 					addedForbidden = true
 					forbiddenCapabilities.Insert(string(c))
@@ -124,17 +128,17 @@ func capabilitiesRestrictedV1Dot22(podMetadata *metav1.ObjectMeta, podSpec *core
 				}
 			}
 			if addedForbidden {
-				containersAddingForbidden.Add(container.Name, withBadValue(forbidden(path.Child("securityContext", "capabilities", "add")), forbiddenValues.List()))
+				containersAddingForbidden.AddContainer(container.Name, kind, withBadValue(forbidden(capabilitiesPath.Child("add")), forbiddenValues.List()))
 			}
 		} else {
-			for _, c := range container.SecurityContext.Capabilities.Add {
+			for _, c := range capabilities.Add {
 				if c != capabilityNetBindService {
 					addedForbidden = true
 					forbiddenCapabilities.Insert(string(c))
 				}
 			}
 			if addedForbidden {
-				containersAddingForbidden.Add(container.Name)
+				containersAddingForbidden.AddContainer(container.Name, kind)
 			}
 		}
 	})
@@ -147,15 +151,13 @@ func capabilitiesRestrictedV1Dot22(podMetadata *metav1.ObjectMeta, podSpec *core
 	}
 	if !containersMissingDropAll.Empty() {
 		forbiddenDetails = append(forbiddenDetails, fmt.Sprintf(
-			`%s %s must set securityContext.capabilities.drop=["ALL"]`,
-			pluralize("container", "containers", containersMissingDropAll.Len()),
-			joinQuote(containersMissingDropAll.Data())))
+			`%s must set securityContext.capabilities.drop=["ALL"]`,
+			containersMissingDropAll.DescribeContainers()))
 	}
 	if !containersAddingForbidden.Empty() {
 		forbiddenDetails = append(forbiddenDetails, fmt.Sprintf(
-			`%s %s must not include %s in securityContext.capabilities.add`,
-			pluralize("container", "containers", containersAddingForbidden.Len()),
-			joinQuote(containersAddingForbidden.Data()),
+			`%s must not include %s in securityContext.capabilities.add`,
+			containersAddingForbidden.DescribeContainers(),
 			joinQuote(forbiddenCapabilities.List())))
 	}
 	if len(forbiddenDetails) > 0 {
@@ -172,8 +174,5 @@ func capabilitiesRestrictedV1Dot22(podMetadata *metav1.ObjectMeta, podSpec *core
 func capabilitiesRestrictedV1Dot25(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
 	// Pod API validation would have failed if podOS == Windows and if capabilities have been set.
 	// We can admit the Windows pod even if capabilities has not been set.
-	if podSpec.OS != nil && podSpec.OS.Name == corev1.Windows {
-		return CheckResult{Allowed: true}
-	}
-	return capabilitiesRestrictedV1Dot22(podMetadata, podSpec, opts)
+	return linuxOnly(capabilitiesRestrictedV1Dot22, true)(podMetadata, podSpec, opts)
 }
@@ -0,0 +1,188 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/pod-security-admission/api"
+)
+
+/*
+Containers duplicating other containers of the pod can be used to confuse validators
+that only inspect the first container with a given name or image, and should be forbidden.
+This check is optional and not part of the Pod Security Standards.
+
+**Restricted Fields:**
+
+spec.initContainers[*].name
+spec.containers[*].name
+spec.ephemeralContainers[*].name
+
+**Allowed Values:** names not used by another container of the pod
+
+**Restricted Fields:**
+
+spec.initContainers[*].securityContext
+(for init containers with restartPolicy=Always and the image of a container in spec.containers)
+
+**Allowed Values:** a securityContext at least as strict as the securityContext of the containers with the same image,
+considering privileged, allowPrivilegeEscalation, runAsNonRoot, readOnlyRootFilesystem and capabilities.add
+*/
+
+func init() {
+	addOptionalCheck(CheckDuplicateContainers)
+}
+
+const checkDuplicateContainersID CheckID = "duplicateContainers"
+
+// CheckDuplicateContainers returns an optional baseline level check
+// that forbids duplicate container names and weakened sidecar mirrors of containers in 1.0+
+func CheckDuplicateContainers() Check {
+	return Check{
+		ID:       checkDuplicateContainersID,
+		Level:    api.LevelBaseline,
+		SpecOnly: true,
+		Versions: []VersionedCheck{
+			{
+				MinimumVersion: api.MajorMinorVersion(1, 0),
+				CheckPod:       withOptions(duplicateContainersV1Dot0),
+			},
+		},
+	}
+}
+
+func duplicateContainersV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
+	duplicateNames := newViolations(opts)
+	weakenedSidecars := newViolations(opts)
+
+	names := sets.New[string]()
+	visitContainers(podSpec, opts, func(container *corev1.Container, _ ContainerKind, path *field.Path) {
+		if names.Has(container.Name) {
+			var err *field.Error
+			if opts.withFieldErrors {
+				err = field.Duplicate(path.Child("name"), container.Name)
+			}
+			duplicateNames.Add(container.Name, err)
+		}
+		names.Insert(container.Name)
+	})
+
+	for i := range podSpec.InitContainers {
+		sidecar := &podSpec.InitContainers[i]
+		if sidecar.RestartPolicy == nil || *sidecar.RestartPolicy != corev1.ContainerRestartPolicyAlways {
+			continue
+		}
+		var weakened []string
+		for j := range podSpec.Containers {
+			container := &podSpec.Containers[j]
+			if container.Image == "" || container.Image != sidecar.Image {
+				continue
+			}
+			if fields := weakenedSecurityContextFields(container.SecurityContext, sidecar.SecurityContext); len(fields) > 0 {
+				weakened = append(weakened, fmt.Sprintf("%q (%s)", container.Name, strings.Join(fields, ", ")))
+			}
+		}
+		if len(weakened) == 0 {
+			continue
+		}
+		var err *field.Error
+		if opts.withFieldErrors {
+			err = forbidden(initContainersFldPath.Index(i).Child("securityContext"))
+			err.Detail = "weaker than containers " + strings.Join(weakened, ", ")
+		}
+		weakenedSidecars.Add(sidecar.Name, err)
+	}
+
+	if duplicateNames.Empty() && weakenedSidecars.Empty() {
+		return CheckResult{Allowed: true}
+	}
+
+	var forbiddenDetails []string
+	if !duplicateNames.Empty() {
+		forbiddenDetails = append(forbiddenDetails, fmt.Sprintf(
+			`%s %s must not reuse the name of another container`,
+			pluralize("container", "containers", duplicateNames.Len()),
+			joinQuote(duplicateNames.Data()),
+		))
+	}
+	if !weakenedSidecars.Empty() {
+		forbiddenDetails = append(forbiddenDetails, fmt.Sprintf(
+			`restartable init %s %s must not set a weaker securityContext than containers with the same image`,
+			pluralize("container", "containers", weakenedSidecars.Len()),
+			joinQuote(weakenedSidecars.Data()),
+		))
+	}
+
+	var errs *field.ErrorList
+	if opts.withFieldErrors {
+		errs = &field.ErrorList{}
+		*errs = append(*errs, *duplicateNames.Errs()...)
+		*errs = append(*errs, *weakenedSidecars.Errs()...)
+	}
+	return CheckResult{
+		Allowed:         false,
+		ForbiddenReason: "duplicate containers",
+		ForbiddenDetail: strings.Join(forbiddenDetails, "; "),
+		ErrList:         errs,
+	}
+}
+
+// weakenedSecurityContextFields returns the securityContext fields of the mirror that are weaker
+// than the securityContext of the original container.
+func weakenedSecurityContextFields(original, mirror *corev1.SecurityContext) []string {
+	if original == nil {
+		original = &corev1.SecurityContext{}
+	}
+	if mirror == nil {
+		mirror = &corev1.SecurityContext{}
+	}
+	isTrue := func(b *bool) bool { return b != nil && *b }
+	isFalse := func(b *bool) bool { return b != nil && !*b }
+
+	var fields []string
+	if isTrue(mirror.Privileged) && !isTrue(original.Privileged) {
+		fields = append(fields, "privileged")
+	}
+	if isFalse(original.AllowPrivilegeEscalation) && !isFalse(mirror.AllowPrivilegeEscalation) {
+		fields = append(fields, "allowPrivilegeEscalation")
+	}
+	if isTrue(original.RunAsNonRoot) && !isTrue(mirror.RunAsNonRoot) {
+		fields = append(fields, "runAsNonRoot")
+	}
+	if isTrue(original.ReadOnlyRootFilesystem) && !isTrue(mirror.ReadOnlyRootFilesystem) {
+		fields = append(fields, "readOnlyRootFilesystem")
+	}
+	originalAdded := sets.New[corev1.Capability]()
+	if original.Capabilities != nil {
+		originalAdded.Insert(original.Capabilities.Add...)
+	}
+	if mirror.Capabilities != nil {
+		for _, capability := range mirror.Capabilities.Add {
+			if !originalAdded.Has(capability) {
+				fields = append(fields, "capabilities.add")
+				break
+			}
+		}
+	}
+	return fields
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestDuplicateContainers(t *testing.T) {
+	always := corev1.ContainerRestartPolicyAlways
+
+	tests := []struct {
+		name          string
+		pod           *corev1.Pod
+		opts          options
+		allowed       bool
+		expectReason  string
+		expectDetail  string
+		expectErrList field.ErrorList
+	}{
+		{
+			name: "unique names",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				InitContainers:      []corev1.Container{{Name: "a"}},
+				Containers:          []corev1.Container{{Name: "b"}},
+				EphemeralContainers: []corev1.EphemeralContainer{{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "c"}}},
+			}},
+			allowed: true,
+		},
+		{
+			name: "ephemeral container duplicating a container name, enable field error list",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				Containers:          []corev1.Container{{Name: "a"}, {Name: "b"}},
+				EphemeralContainers: []corev1.EphemeralContainer{{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "b"}}},
+			}},
+			opts: options{
+				withFieldErrors: true,
+			},
+			expectReason: `duplicate containers`,
+			expectDetail: `container "b" must not reuse the name of another container`,
+			expectErrList: field.ErrorList{
+				{Type: field.ErrorTypeDuplicate, Field: "spec.ephemeralContainers[0].name", BadValue: "b"},
+			},
+		},
+		{
+			name: "restartable init container with the same image and securityContext",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "sidecar", Image: "app", RestartPolicy: &always, SecurityContext: &corev1.SecurityContext{
+					AllowPrivilegeEscalation: pointer.Bool(false),
+					RunAsNonRoot:             pointer.Bool(true),
+				}}},
+				Containers: []corev1.Container{{Name: "app", Image: "app", SecurityContext: &corev1.SecurityContext{
+					AllowPrivilegeEscalation: pointer.Bool(false),
+					RunAsNonRoot:             pointer.Bool(true),
+				}}},
+			}},
+			allowed: true,
+		},
+		{
+			name: "init container with the same image and weaker securityContext",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "setup", Image: "app"}},
+				Containers: []corev1.Container{{Name: "app", Image: "app", SecurityContext: &corev1.SecurityContext{
+					RunAsNonRoot: pointer.Bool(true),
+				}}},
+			}},
+			allowed: true,
+		},
+		{
+			name: "restartable init container with a different image",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "sidecar", Image: "proxy", RestartPolicy: &always}},
+				Containers: []corev1.Container{{Name: "app", Image: "app", SecurityContext: &corev1.SecurityContext{
+					RunAsNonRoot: pointer.Bool(true),
+				}}},
+			}},
+			allowed: true,
+		},
+		{
+			name: "restartable init container with the same image and weaker securityContext",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "sidecar", Image: "app", RestartPolicy: &always, SecurityContext: &corev1.SecurityContext{
+					Privileged:   pointer.Bool(true),
+					Capabilities: &corev1.Capabilities{Add: []corev1.Capability{"NET_RAW", "SYS_ADMIN"}},
+				}}},
+				Containers: []corev1.Container{{Name: "app", Image: "app", SecurityContext: &corev1.SecurityContext{
+					AllowPrivilegeEscalation: pointer.Bool(false),
+					ReadOnlyRootFilesystem:   pointer.Bool(true),
+					Capabilities:             &corev1.Capabilities{Add: []corev1.Capability{"NET_RAW"}},
+				}}},
+			}},
+			expectReason: `duplicate containers`,
+			expectDetail: `restartable init container "sidecar" must not set a weaker securityContext than containers with the same image`,
+		},
+		{
+			name: "duplicate names and weaker securityContext, enable field error list",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "app", Image: "app", RestartPolicy: &always}},
+				Containers: []corev1.Container{{Name: "app", Image: "app", SecurityContext: &corev1.SecurityContext{
+					RunAsNonRoot: pointer.Bool(true),
+				}}},
+			}},
+			opts: options{
+				withFieldErrors: true,
+			},
+			expectReason: `duplicate containers`,
+			expectDetail: `container "app" must not reuse the name of another container; restartable init container "app" must not set a weaker securityContext than containers with the same image`,
+			expectErrList: field.ErrorList{
+				{Type: field.ErrorTypeDuplicate, Field: "spec.containers[0].name", BadValue: "app"},
+				{Type: field.ErrorTypeForbidden, Field: "spec.initContainers[0].securityContext", BadValue: ""},
+			},
+		},
+	}
+
+	cmpOpts := []cmp.Option{cmpopts.IgnoreFields(field.Error{}, "Detail"), cmpopts.SortSlices(func(a, b *field.Error) bool { return a.Error() < b.Error() })}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := duplicateContainersV1Dot0(&tc.pod.ObjectMeta, &tc.pod.Spec, tc.opts)
+			if !tc.allowed {
+				if result.Allowed {
+					t.Fatal("expected disallowed")
+				}
+				if e, a := tc.expectReason, result.ForbiddenReason; e != a {
+					t.Errorf("expected\n%s\ngot\n%s", e, a)
+				}
+				if e, a := tc.expectDetail, result.ForbiddenDetail; e != a {
+					t.Errorf("expected\n%s\ngot\n%s", e, a)
+				}
+				if result.ErrList != nil {
+					if diff := cmp.Diff(tc.expectErrList, *result.ErrList, cmpOpts...); diff != "" {
+						t.Errorf("unexpected field errors (-want,+got):\n%s", diff)
+					}
+				}
+			} else if !result.Allowed {
+				t.Fatal("expected allowed")
+			}
+		})
+	}
+}
+
+func TestWeakenedSecurityContextFields(t *testing.T) {
+	original := &corev1.SecurityContext{
+		AllowPrivilegeEscalation: pointer.Bool(false),
+		RunAsNonRoot:             pointer.Bool(true),
+		ReadOnlyRootFilesystem:   pointer.Bool(true),
+		Capabilities:             &corev1.Capabilities{Add: []corev1.Capability{"NET_BIND_SERVICE"}},
+	}
+	mirror := &corev1.SecurityContext{
+		Privileged:   pointer.Bool(true),
+		Capabilities: &corev1.Capabilities{Add: []corev1.Capability{"NET_BIND_SERVICE", "SYS_ADMIN"}},
+	}
+	expected := []string{"privileged", "allowPrivilegeEscalation", "runAsNonRoot", "readOnlyRootFilesystem", "capabilities.add"}
+	if diff := cmp.Diff(expected, weakenedSecurityContextFields(original, mirror)); diff != "" {
+		t.Errorf("unexpected fields (-want,+got):\n%s", diff)
+	}
+	if fields := weakenedSecurityContextFields(original, original); len(fields) > 0 {
+		t.Errorf("expected no weakened fields, got %v", fields)
+	}
+	if fields := weakenedSecurityContextFields(nil, nil); len(fields) > 0 {
+		t.Errorf("expected no weakened fields, got %v", fields)
+	}
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/pod-security-admission/api"
+)
+
+/*
+Containers re-executing into the host namespaces with tools like nsenter, or changing their root
+to a mount of the host filesystem, are the classic container breakout when combined with hostPID or
+hostPath volumes, and are not caught by the checks of the individual fields when some of them are exempt.
+This check is experimental, optional and not part of the Pod Security Standards.
+
+**Restricted Fields:**
+
+spec.initContainers[*].command
+spec.initContainers[*].args
+spec.containers[*].command
+spec.containers[*].args
+spec.ephemeralContainers[*].command
+spec.ephemeralContainers[*].args
+(for pods with hostPID=true or hostPath volumes)
+
+**Allowed Values:**
+commands and args not matching the patterns configured with WithHostBreakoutCommandPatterns,
+or DefaultHostBreakoutCommandPatterns
+*/
+
+func init() {
+	addOptionalCheck(CheckHostBreakoutCommands)
+}
+
+const checkHostBreakoutCommandsID CheckID = "hostBreakoutCommands"
+
+// CheckHostBreakoutCommands returns an optional baseline level check
+// that forbids host breakout commands in pods with hostPID or hostPath volumes in 1.0+
+func CheckHostBreakoutCommands() Check {
+	return Check{
+		ID:       checkHostBreakoutCommandsID,
+		Level:    api.LevelBaseline,
+		SpecOnly: true,
+		Severity: SeverityCritical,
+		Versions: []VersionedCheck{
+			{
+				MinimumVersion: api.MajorMinorVersion(1, 0),
+				CheckPod:       withOptions(hostBreakoutCommandsV1Dot0),
+			},
+		},
+	}
+}
+
+// DefaultHostBreakoutCommandPatterns returns the patterns matched by the hostBreakoutCommands check
+// when WithHostBreakoutCommandPatterns is not set: nsenter, and chroot to /host or a path below it.
+func DefaultHostBreakoutCommandPatterns() []*regexp.Regexp {
+	return []*regexp.Regexp{
+		regexp.MustCompile(`\bnsenter\b`),
+		regexp.MustCompile(`\bchroot\s+/host\b`),
+	}
+}
+
+// WithHostBreakoutCommandPatterns configures the hostBreakoutCommands check to forbid commands matching any of the given patterns.
+// Patterns are matched against the command and args of each container, joined with spaces.
+func WithHostBreakoutCommandPatterns(patterns ...*regexp.Regexp) Option {
+	return func(opt options) options {
+		opt.hostBreakoutCommandPatterns = patterns
+		return opt
+	}
+}
+
+func hostBreakoutCommandsV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
+	var hostAccess []string
+	if podSpec.HostPID {
+		hostAccess = append(hostAccess, "hostPID=true")
+	}
+	for _, volume := range podSpec.Volumes {
+		if volume.HostPath != nil {
+			hostAccess = append(hostAccess, "hostPath volumes")
+			break
+		}
+	}
+	if len(hostAccess) == 0 {
+		return CheckResult{Allowed: true}
+	}
+
+	patterns := opts.hostBreakoutCommandPatterns
+	if patterns == nil {
+		patterns = DefaultHostBreakoutCommandPatterns()
+	}
+	badContainers := newViolations(opts)
+	visitContainers(podSpec, opts, func(container *corev1.Container, kind ContainerKind, path *field.Path) {
+		command := strings.Join(container.Command, " ")
+		args := strings.Join(container.Args, " ")
+		commandLine := strings.TrimSpace(command + " " + args)
+		for _, pattern := range patterns {
+			if !pattern.MatchString(commandLine) {
+				continue
+			}
+			var err *field.Error
+			if opts.withFieldErrors {
+				// point to args if the match is only in args
+				if !pattern.MatchString(command) && pattern.MatchString(args) {
+					err = withBadValue(forbidden(path.Child("args")), container.Args)
+				} else {
+					err = withBadValue(forbidden(path.Child("command")), container.Command)
+				}
+			}
+			badContainers.AddContainer(container.Name, kind, err)
+			return
+		}
+	})
+
+	if badContainers.Empty() {
+		return CheckResult{Allowed: true}
+	}
+	return CheckResult{
+		Allowed:         false,
+		ForbiddenReason: "host breakout commands",
+		ForbiddenDetail: fmt.Sprintf(
+			"%s must not run host breakout commands with %s",
+			badContainers.DescribeContainers(),
+			strings.Join(hostAccess, " and "),
+		),
+		ErrList: badContainers.Errs(),
+	}
+}
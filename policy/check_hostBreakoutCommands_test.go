@@ -0,0 +1,142 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"regexp"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestHostBreakoutCommands(t *testing.T) {
+	hostPathVolumes := []corev1.Volume{{Name: "host", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/"}}}}
+
+	tests := []struct {
+		name          string
+		pod           *corev1.Pod
+		opts          options
+		allowed       bool
+		expectReason  string
+		expectDetail  string
+		expectErrList field.ErrorList
+	}{
+		{
+			name: "nsenter without host access",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "a", Command: []string{"nsenter", "-t", "1", "-m", "sh"}}},
+			}},
+			allowed: true,
+		},
+		{
+			name: "host access without breakout commands",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				HostPID:    true,
+				Volumes:    hostPathVolumes,
+				Containers: []corev1.Container{{Name: "a", Command: []string{"sh", "-c"}, Args: []string{"cat /host/etc/os-release"}}},
+			}},
+			allowed: true,
+		},
+		{
+			name: "nsenter with hostPID",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				HostPID:    true,
+				Containers: []corev1.Container{{Name: "a", Command: []string{"/usr/bin/nsenter", "-t", "1", "-m", "-u", "-i", "-n", "sh"}}},
+			}},
+			expectReason: `host breakout commands`,
+			expectDetail: `container "a" must not run host breakout commands with hostPID=true`,
+		},
+		{
+			name: "chroot in a shell with hostPath volumes and hostPID, enable field error list",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				HostPID: true,
+				Volumes: hostPathVolumes,
+				InitContainers: []corev1.Container{
+					{Name: "a", Command: []string{"chroot", "/host"}},
+				},
+				Containers: []corev1.Container{
+					{Name: "b", Command: []string{"sh", "-c"}, Args: []string{"chroot /host/ sh"}},
+					{Name: "c", Command: []string{"chroot"}, Args: []string{"/hostname"}},
+				},
+			}},
+			opts: options{
+				withFieldErrors: true,
+			},
+			expectReason: `host breakout commands`,
+			expectDetail: `containers "a", "b" must not run host breakout commands with hostPID=true and hostPath volumes`,
+			expectErrList: field.ErrorList{
+				{Type: field.ErrorTypeForbidden, Field: "spec.initContainers[0].command", BadValue: []string{"chroot", "/host"}},
+				{Type: field.ErrorTypeForbidden, Field: "spec.containers[0].args", BadValue: []string{"chroot /host/ sh"}},
+			},
+		},
+		{
+			name: "configured patterns",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				Volumes: hostPathVolumes,
+				Containers: []corev1.Container{
+					{Name: "a", Command: []string{"nsenter"}},
+					{Name: "b", Command: []string{"unshare", "--mount"}},
+				},
+			}},
+			opts: options{
+				hostBreakoutCommandPatterns: []*regexp.Regexp{regexp.MustCompile(`\bunshare\b`)},
+			},
+			expectReason: `host breakout commands`,
+			expectDetail: `container "b" must not run host breakout commands with hostPath volumes`,
+		},
+		{
+			name: "no patterns",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				HostPID:    true,
+				Containers: []corev1.Container{{Name: "a", Command: []string{"nsenter"}}},
+			}},
+			opts: options{
+				hostBreakoutCommandPatterns: []*regexp.Regexp{},
+			},
+			allowed: true,
+		},
+	}
+
+	cmpOpts := []cmp.Option{cmpopts.IgnoreFields(field.Error{}, "Detail"), cmpopts.SortSlices(func(a, b *field.Error) bool { return a.Error() < b.Error() })}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := hostBreakoutCommandsV1Dot0(&tc.pod.ObjectMeta, &tc.pod.Spec, tc.opts)
+			if !tc.allowed {
+				if result.Allowed {
+					t.Fatal("expected disallowed")
+				}
+				if e, a := tc.expectReason, result.ForbiddenReason; e != a {
+					t.Errorf("expected\n%s\ngot\n%s", e, a)
+				}
+				if e, a := tc.expectDetail, result.ForbiddenDetail; e != a {
+					t.Errorf("expected\n%s\ngot\n%s", e, a)
+				}
+				if result.ErrList != nil {
+					if diff := cmp.Diff(tc.expectErrList, *result.ErrList, cmpOpts...); diff != "" {
+						t.Errorf("unexpected field errors (-want,+got):\n%s", diff)
+					}
+				}
+			} else if !result.Allowed {
+				t.Fatal("expected allowed")
+			}
+		})
+	}
+}
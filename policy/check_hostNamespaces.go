@@ -44,8 +44,10 @@ func init() {
 // that prohibits host namespaces in 1.0+
 func CheckHostNamespaces() Check {
 	return Check{
-		ID:    "hostNamespaces",
-		Level: api.LevelBaseline,
+		ID:       "hostNamespaces",
+		Level:    api.LevelBaseline,
+		SpecOnly: true,
+		Severity: SeverityCritical,
 		Versions: []VersionedCheck{
 			{
 				MinimumVersion: api.MajorMinorVersion(1, 0),
@@ -56,7 +58,7 @@ func CheckHostNamespaces() Check {
 }
 
 func hostNamespacesV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
-	hostNamespaces := NewViolations(opts.withFieldErrors)
+	hostNamespaces := newViolations(opts)
 
 	if podSpec.HostNetwork {
 		if opts.withFieldErrors {
@@ -44,8 +44,10 @@ const checkHostPathVolumesID CheckID = "hostPathVolumes"
 // that requires hostPath=undefined/null in 1.0+
 func CheckHostPathVolumes() Check {
 	return Check{
-		ID:    checkHostPathVolumesID,
-		Level: api.LevelBaseline,
+		ID:       checkHostPathVolumesID,
+		Level:    api.LevelBaseline,
+		SpecOnly: true,
+		Severity: SeverityCritical,
 		Versions: []VersionedCheck{
 			{
 				MinimumVersion: api.MajorMinorVersion(1, 0),
@@ -56,7 +58,7 @@ func CheckHostPathVolumes() Check {
 }
 
 func hostPathVolumesV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
-	hostVolumes := NewViolations(opts.withFieldErrors)
+	hostVolumes := newViolations(opts)
 
 	for i, volume := range podSpec.Volumes {
 		if volume.HostPath != nil {
@@ -47,8 +47,9 @@ func init() {
 // that forbids any host ports in 1.0+
 func CheckHostPorts() Check {
 	return Check{
-		ID:    "hostPorts",
-		Level: api.LevelBaseline,
+		ID:       "hostPorts",
+		Level:    api.LevelBaseline,
+		SpecOnly: true,
 		Versions: []VersionedCheck{
 			{
 				MinimumVersion: api.MajorMinorVersion(1, 0),
@@ -59,9 +60,9 @@ func CheckHostPorts() Check {
 }
 
 func hostPortsV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
-	badContainers := NewViolations(opts.withFieldErrors)
+	badContainers := newViolations(opts)
 	forbiddenHostPorts := sets.NewString()
-	visitContainers(podSpec, opts, func(container *corev1.Container, path *field.Path) {
+	visitContainers(podSpec, opts, func(container *corev1.Container, kind ContainerKind, path *field.Path) {
 		valid := true
 		var errs field.ErrorList
 		for i, c := range container.Ports {
@@ -75,9 +76,9 @@ func hostPortsV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, op
 		}
 		if !valid {
 			if opts.withFieldErrors {
-				badContainers.Add(container.Name, errs...)
+				badContainers.AddContainer(container.Name, kind, errs...)
 			} else {
-				badContainers.Add(container.Name)
+				badContainers.AddContainer(container.Name, kind)
 			}
 		}
 	})
@@ -87,9 +88,8 @@ func hostPortsV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, op
 			Allowed:         false,
 			ForbiddenReason: "hostPort",
 			ForbiddenDetail: fmt.Sprintf(
-				"%s %s %s %s %s",
-				pluralize("container", "containers", badContainers.Len()),
-				joinQuote(badContainers.Data()),
+				"%s %s %s %s",
+				badContainers.DescribeContainers(),
 				pluralize("uses", "use", badContainers.Len()),
 				pluralize("hostPort", "hostPorts", len(forbiddenHostPorts)),
 				strings.Join(forbiddenHostPorts.List(), ", "),
@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/pod-security-admission/api"
+)
+
+/*
+Images referenced by the mutable latest tag, or without a tag, can change between pulls,
+so the code running in a container cannot be traced back to a reviewed build.
+This check is optional and not part of the Pod Security Standards.
+
+**Restricted Fields:**
+
+spec.containers[*].image
+spec.initContainers[*].image
+spec.ephemeralContainers[*].image
+
+**Allowed Values:** images with a digest, or a tag other than latest (digests only, with WithRequiredImageDigests)
+*/
+
+func init() {
+	addOptionalCheck(CheckImagePolicy)
+}
+
+const checkImagePolicyID CheckID = "imagePolicy"
+
+// CheckImagePolicy returns an optional baseline level check
+// that forbids latest and untagged images in 1.0+
+func CheckImagePolicy() Check {
+	return Check{
+		ID:       checkImagePolicyID,
+		Level:    api.LevelBaseline,
+		SpecOnly: true,
+		Versions: []VersionedCheck{
+			{
+				MinimumVersion: api.MajorMinorVersion(1, 0),
+				CheckPod:       withOptions(imagePolicyV1Dot0),
+			},
+		},
+	}
+}
+
+// WithRequiredImageDigests configures the imagePolicy check to require images pinned to a digest,
+// forbidding images only referenced by a tag.
+func WithRequiredImageDigests() Option {
+	return func(opt options) options {
+		opt.requireImageDigests = true
+		return opt
+	}
+}
+
+func imagePolicyV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
+	badContainers := newViolations(opts)
+
+	visitContainers(podSpec, opts, func(container *corev1.Container, kind ContainerKind, path *field.Path) {
+		tag, digest := imageTagAndDigest(container.Image)
+		if digest != "" || (!opts.requireImageDigests && tag != "" && tag != "latest") {
+			return
+		}
+		if opts.withFieldErrors {
+			badContainers.AddContainer(container.Name, kind, withBadValue(forbidden(path.Child("image")), container.Image))
+		} else {
+			badContainers.AddContainer(container.Name, kind)
+		}
+	})
+
+	if !badContainers.Empty() {
+		requirement := "images pinned to a digest or a tag other than latest"
+		if opts.requireImageDigests {
+			requirement = "images pinned to a digest"
+		}
+		return CheckResult{
+			Allowed:         false,
+			ForbiddenReason: "image policy",
+			ForbiddenDetail: fmt.Sprintf("%s must use %s", badContainers.DescribeContainers(), requirement),
+			ErrList:         badContainers.Errs(),
+		}
+	}
+	return CheckResult{Allowed: true}
+}
+
+// imageTagAndDigest returns the tag and digest of the image reference, or empty strings if unset,
+// e.g. "1.0" and "" for registry.example.com:5000/app:1.0.
+func imageTagAndDigest(image string) (tag, digest string) {
+	image, digest, _ = strings.Cut(image, "@")
+	// The tag follows the last path component, so that the port of a registry is not mistaken for a tag.
+	name := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		tag = name[i+1:]
+	}
+	return tag, digest
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestImagePolicy(t *testing.T) {
+	const digest = "@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		name          string
+		pod           *corev1.Pod
+		opts          options
+		allowed       bool
+		expectReason  string
+		expectDetail  string
+		expectErrList field.ErrorList
+	}{
+		{
+			name: "tags and digests",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "init", Image: "registry.example.com:5000/init:1.0"}},
+				Containers: []corev1.Container{
+					{Name: "a", Image: "app:v2"},
+					{Name: "b", Image: "app" + digest},
+					{Name: "c", Image: "app:latest" + digest},
+				},
+			}},
+			allowed: true,
+		},
+		{
+			name: "latest and untagged images",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "init", Image: "registry.example.com:5000/init"}},
+				Containers: []corev1.Container{
+					{Name: "a", Image: "app:latest"},
+					{Name: "b", Image: "app:v2"},
+					{Name: "c", Image: "app"},
+				},
+			}},
+			expectReason: `image policy`,
+			expectDetail: `containers "init", "a", "c" must use images pinned to a digest or a tag other than latest`,
+		},
+		{
+			name: "latest and untagged images, enable field error list",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "a", Image: "app:latest"},
+					{Name: "b", Image: "app:v2"},
+				},
+				EphemeralContainers: []corev1.EphemeralContainer{
+					{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debug", Image: "busybox"}},
+				},
+			}},
+			opts: options{
+				withFieldErrors: true,
+			},
+			expectReason: `image policy`,
+			expectDetail: `containers "a", "debug" must use images pinned to a digest or a tag other than latest`,
+			expectErrList: field.ErrorList{
+				{Type: field.ErrorTypeForbidden, Field: "spec.containers[0].image", BadValue: "app:latest"},
+				{Type: field.ErrorTypeForbidden, Field: "spec.ephemeralContainers[0].image", BadValue: "busybox"},
+			},
+		},
+		{
+			name: "required digests",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "a", Image: "app:v2"},
+					{Name: "b", Image: "app:v2" + digest},
+				},
+			}},
+			opts: options{
+				requireImageDigests: true,
+			},
+			expectReason: `image policy`,
+			expectDetail: `container "a" must use images pinned to a digest`,
+		},
+	}
+
+	cmpOpts := []cmp.Option{cmpopts.IgnoreFields(field.Error{}, "Detail"), cmpopts.SortSlices(func(a, b *field.Error) bool { return a.Error() < b.Error() })}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := imagePolicyV1Dot0(&tc.pod.ObjectMeta, &tc.pod.Spec, tc.opts)
+			if result.Allowed != tc.allowed {
+				t.Fatalf("expected allowed=%v", tc.allowed)
+			}
+			if e, a := tc.expectReason, result.ForbiddenReason; e != a {
+				t.Errorf("expected\n%s\ngot\n%s", e, a)
+			}
+			if e, a := tc.expectDetail, result.ForbiddenDetail; e != a {
+				t.Errorf("expected\n%s\ngot\n%s", e, a)
+			}
+			if result.ErrList != nil {
+				if diff := cmp.Diff(tc.expectErrList, *result.ErrList, cmpOpts...); diff != "" {
+					t.Errorf("unexpected field errors (-want,+got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/pod-security-admission/api"
+)
+
+/*
+Localhost seccomp and AppArmor profiles are only effective when installed on the nodes.
+References to profiles missing from the profile catalog configured with WithLocalhostProfileCatalog,
+e.g. the profiles installed by security-profiles-operator, should be forbidden.
+This check is optional and not part of the Pod Security Standards.
+
+**Restricted Fields:**
+
+metadata.annotations['seccomp.security.alpha.kubernetes.io/pod']
+metadata.annotations['container.seccomp.security.alpha.kubernetes.io/*']
+metadata.annotations['container.apparmor.security.beta.kubernetes.io/*']
+spec.securityContext.seccompProfile.localhostProfile
+spec.containers[*].securityContext.seccompProfile.localhostProfile
+spec.initContainers[*].securityContext.seccompProfile.localhostProfile
+spec.ephemeralContainers[*].securityContext.seccompProfile.localhostProfile
+spec.securityContext.appArmorProfile.localhostProfile
+spec.containers[*].securityContext.appArmorProfile.localhostProfile
+spec.initContainers[*].securityContext.appArmorProfile.localhostProfile
+spec.ephemeralContainers[*].securityContext.appArmorProfile.localhostProfile
+
+**Allowed Values:** profiles that are ready in the catalog, and non-Localhost profiles
+*/
+
+func init() {
+	addOptionalCheck(CheckLocalhostProfiles)
+}
+
+const checkLocalhostProfilesID CheckID = "localhostProfiles"
+
+// CheckLocalhostProfiles returns an optional baseline level check
+// that requires Localhost seccomp and AppArmor profiles to be ready in the profile catalog in 1.0+
+func CheckLocalhostProfiles() Check {
+	return Check{
+		ID:    checkLocalhostProfilesID,
+		Level: api.LevelBaseline,
+		Versions: []VersionedCheck{
+			{
+				MinimumVersion: api.MajorMinorVersion(1, 0),
+				CheckPod:       withOptions(localhostProfilesV1Dot0),
+			},
+		},
+	}
+}
+
+// LocalhostProfileType is the type of a Localhost profile.
+type LocalhostProfileType string
+
+const (
+	LocalhostProfileTypeSeccomp  LocalhostProfileType = "seccomp"
+	LocalhostProfileTypeAppArmor LocalhostProfileType = "AppArmor"
+)
+
+// LocalhostProfileStatus is the status of a Localhost profile in a catalog.
+type LocalhostProfileStatus string
+
+const (
+	// LocalhostProfileReady is the status of profiles installed on the nodes.
+	LocalhostProfileReady LocalhostProfileStatus = "Ready"
+	// LocalhostProfileNotReady is the status of profiles in the catalog that are not installed yet, or failed to install.
+	LocalhostProfileNotReady LocalhostProfileStatus = "NotReady"
+	// LocalhostProfileNotFound is the status of profiles missing from the catalog.
+	LocalhostProfileNotFound LocalhostProfileStatus = "NotFound"
+)
+
+// LocalhostProfileCatalog looks up the Localhost profiles available to pods.
+// Implementations are called during evaluation, and are expected to read from a local cache.
+type LocalhostProfileCatalog interface {
+	// LocalhostProfileStatus returns the status of the profile referenced by a pod in the given namespace,
+	// as the localhostProfile field or the value of a "localhost/" annotation without the prefix.
+	LocalhostProfileStatus(namespace string, profileType LocalhostProfileType, localhostProfile string) LocalhostProfileStatus
+}
+
+// WithLocalhostProfileCatalog configures the catalog used by the localhostProfiles check to look up
+// the Localhost profiles referenced by pods. Without a catalog, all profiles are allowed.
+func WithLocalhostProfileCatalog(catalog LocalhostProfileCatalog) Option {
+	return func(opt options) options {
+		opt.localhostProfileCatalog = catalog
+		return opt
+	}
+}
+
+func localhostProfilesV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
+	if opts.localhostProfileCatalog == nil {
+		return CheckResult{Allowed: true}
+	}
+	badProfiles := newViolations(opts)
+
+	checkProfile := func(profileType LocalhostProfileType, localhostProfile string, path *field.Path) {
+		status := opts.localhostProfileCatalog.LocalhostProfileStatus(podMetadata.Namespace, profileType, localhostProfile)
+		if status == LocalhostProfileReady {
+			return
+		}
+		var err *field.Error
+		if opts.withFieldErrors {
+			err = withBadValue(forbidden(path), localhostProfile)
+			err.Detail = fmt.Sprintf("%s profile status is %s", profileType, status)
+		}
+		badProfiles.Add(fmt.Sprintf("%s profile %q (%s)", profileType, localhostProfile, status), err)
+	}
+
+	// annotations, in a stable order
+	keys := make([]string, 0, len(podMetadata.Annotations))
+	for k := range podMetadata.Annotations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		var profileType LocalhostProfileType
+		var prefix string
+		switch {
+		case k == annotationKeyPod || strings.HasPrefix(k, annotationKeyContainerPrefix):
+			profileType, prefix = LocalhostProfileTypeSeccomp, corev1.SeccompLocalhostProfileNamePrefix
+		case strings.HasPrefix(k, corev1.DeprecatedAppArmorBetaContainerAnnotationKeyPrefix):
+			profileType, prefix = LocalhostProfileTypeAppArmor, corev1.DeprecatedAppArmorBetaProfileNamePrefix
+		default:
+			continue
+		}
+		if localhostProfile, ok := strings.CutPrefix(podMetadata.Annotations[k], prefix); ok {
+			var path *field.Path
+			if opts.withFieldErrors {
+				path = annotationsPath.Key(k)
+			}
+			checkProfile(profileType, localhostProfile, path)
+		}
+	}
+
+	if sc := podSpec.SecurityContext; sc != nil {
+		if profile := sc.SeccompProfile; profile != nil && profile.Type == corev1.SeccompProfileTypeLocalhost && profile.LocalhostProfile != nil {
+			checkProfile(LocalhostProfileTypeSeccomp, *profile.LocalhostProfile, securityContextPath.Child("seccompProfile", "localhostProfile"))
+		}
+		if profile := sc.AppArmorProfile; profile != nil && profile.Type == corev1.AppArmorProfileTypeLocalhost && profile.LocalhostProfile != nil {
+			checkProfile(LocalhostProfileTypeAppArmor, *profile.LocalhostProfile, securityContextPath.Child("appArmorProfile", "localhostProfile"))
+		}
+	}
+	visitContainers(podSpec, opts, func(c *corev1.Container, _ ContainerKind, path *field.Path) {
+		sc := c.SecurityContext
+		if sc == nil {
+			return
+		}
+		if profile := sc.SeccompProfile; profile != nil && profile.Type == corev1.SeccompProfileTypeLocalhost && profile.LocalhostProfile != nil {
+			checkProfile(LocalhostProfileTypeSeccomp, *profile.LocalhostProfile, path.Child("securityContext", "seccompProfile", "localhostProfile"))
+		}
+		if profile := sc.AppArmorProfile; profile != nil && profile.Type == corev1.AppArmorProfileTypeLocalhost && profile.LocalhostProfile != nil {
+			checkProfile(LocalhostProfileTypeAppArmor, *profile.LocalhostProfile, path.Child("securityContext", "appArmorProfile", "localhostProfile"))
+		}
+	})
+
+	if !badProfiles.Empty() {
+		return CheckResult{
+			Allowed:         false,
+			ForbiddenReason: "localhost profiles",
+			ForbiddenDetail: fmt.Sprintf(
+				"pod must only reference ready Localhost profiles, but references %s",
+				strings.Join(badProfiles.Data(), ", "),
+			),
+			ErrList: badProfiles.Errs(),
+		}
+	}
+	return CheckResult{Allowed: true}
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// testProfileCatalog holds the status of profiles by type and name, and the namespaces of the lookups.
+type testProfileCatalog struct {
+	profiles   map[LocalhostProfileType]map[string]LocalhostProfileStatus
+	namespaces []string
+}
+
+func (c *testProfileCatalog) LocalhostProfileStatus(namespace string, profileType LocalhostProfileType, localhostProfile string) LocalhostProfileStatus {
+	c.namespaces = append(c.namespaces, namespace)
+	if status, ok := c.profiles[profileType][localhostProfile]; ok {
+		return status
+	}
+	return LocalhostProfileNotFound
+}
+
+func TestLocalhostProfiles(t *testing.T) {
+	newCatalog := func() *testProfileCatalog {
+		return &testProfileCatalog{profiles: map[LocalhostProfileType]map[string]LocalhostProfileStatus{
+			LocalhostProfileTypeSeccomp: {
+				"operator/ns/ready.json":   LocalhostProfileReady,
+				"operator/ns/pending.json": LocalhostProfileNotReady,
+			},
+			LocalhostProfileTypeAppArmor: {
+				"ready": LocalhostProfileReady,
+			},
+		}}
+	}
+	seccomp := func(profile string) *corev1.SeccompProfile {
+		return &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeLocalhost, LocalhostProfile: pointer.String(profile)}
+	}
+	appArmor := func(profile string) *corev1.AppArmorProfile {
+		return &corev1.AppArmorProfile{Type: corev1.AppArmorProfileTypeLocalhost, LocalhostProfile: pointer.String(profile)}
+	}
+
+	tests := []struct {
+		name          string
+		pod           *corev1.Pod
+		noCatalog     bool
+		opts          options
+		allowed       bool
+		expectReason  string
+		expectDetail  string
+		expectErrList field.ErrorList
+	}{
+		{
+			name: "no catalog",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{SeccompProfile: seccomp("operator/ns/missing.json")},
+			}},
+			noCatalog: true,
+			allowed:   true,
+		},
+		{
+			name: "ready and non-Localhost profiles",
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Annotations: map[string]string{
+					annotationKeyPod: "localhost/operator/ns/ready.json",
+					corev1.DeprecatedAppArmorBetaContainerAnnotationKeyPrefix + "a": "runtime/default",
+				}},
+				Spec: corev1.PodSpec{
+					SecurityContext: &corev1.PodSecurityContext{
+						SeccompProfile:  seccomp("operator/ns/ready.json"),
+						AppArmorProfile: &corev1.AppArmorProfile{Type: corev1.AppArmorProfileTypeRuntimeDefault},
+					},
+					Containers: []corev1.Container{{Name: "a", SecurityContext: &corev1.SecurityContext{AppArmorProfile: appArmor("ready")}}},
+				},
+			},
+			allowed: true,
+		},
+		{
+			name: "missing and pending profiles",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{SeccompProfile: seccomp("operator/ns/pending.json")},
+				Containers:      []corev1.Container{{Name: "a", SecurityContext: &corev1.SecurityContext{AppArmorProfile: appArmor("missing")}}},
+			}},
+			expectReason: `localhost profiles`,
+			expectDetail: `pod must only reference ready Localhost profiles, but references seccomp profile "operator/ns/pending.json" (NotReady), AppArmor profile "missing" (NotFound)`,
+		},
+		{
+			name: "missing profiles, enable field error list",
+			pod: &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "ns", Annotations: map[string]string{
+					annotationKeyContainerPrefix + "a":                              "localhost/operator/ns/missing.json",
+					corev1.DeprecatedAppArmorBetaContainerAnnotationKeyPrefix + "a": "localhost/missing",
+				}},
+				Spec: corev1.PodSpec{
+					InitContainers: []corev1.Container{{Name: "a", SecurityContext: &corev1.SecurityContext{SeccompProfile: seccomp("operator/ns/missing.json")}}},
+				},
+			},
+			opts: options{
+				withFieldErrors: true,
+			},
+			expectReason: `localhost profiles`,
+			expectDetail: `pod must only reference ready Localhost profiles, but references AppArmor profile "missing" (NotFound), seccomp profile "operator/ns/missing.json" (NotFound), seccomp profile "operator/ns/missing.json" (NotFound)`,
+			expectErrList: field.ErrorList{
+				{Type: field.ErrorTypeForbidden, Field: "metadata.annotations[container.apparmor.security.beta.kubernetes.io/a]", BadValue: "missing"},
+				{Type: field.ErrorTypeForbidden, Field: "metadata.annotations[container.seccomp.security.alpha.kubernetes.io/a]", BadValue: "operator/ns/missing.json"},
+				{Type: field.ErrorTypeForbidden, Field: "spec.initContainers[0].securityContext.seccompProfile.localhostProfile", BadValue: "operator/ns/missing.json"},
+			},
+		},
+	}
+
+	cmpOpts := []cmp.Option{cmpopts.IgnoreFields(field.Error{}, "Detail"), cmpopts.SortSlices(func(a, b *field.Error) bool { return a.Error() < b.Error() })}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			catalog := newCatalog()
+			if !tc.noCatalog {
+				tc.opts.localhostProfileCatalog = catalog
+			}
+			result := localhostProfilesV1Dot0(&tc.pod.ObjectMeta, &tc.pod.Spec, tc.opts)
+			for _, namespace := range catalog.namespaces {
+				if namespace != tc.pod.Namespace {
+					t.Errorf("expected lookups in namespace %q, got %q", tc.pod.Namespace, namespace)
+				}
+			}
+			if !tc.allowed {
+				if result.Allowed {
+					t.Fatal("expected disallowed")
+				}
+				if e, a := tc.expectReason, result.ForbiddenReason; e != a {
+					t.Errorf("expected\n%s\ngot\n%s", e, a)
+				}
+				if e, a := tc.expectDetail, result.ForbiddenDetail; e != a {
+					t.Errorf("expected\n%s\ngot\n%s", e, a)
+				}
+				if result.ErrList != nil {
+					if diff := cmp.Diff(tc.expectErrList, *result.ErrList, cmpOpts...); diff != "" {
+						t.Errorf("unexpected field errors (-want,+got):\n%s", diff)
+					}
+				}
+			} else if !result.Allowed {
+				t.Fatal("expected allowed")
+			}
+		})
+	}
+}
@@ -43,8 +43,10 @@ func init() {
 // that forbids privileged=true in 1.0+
 func CheckPrivileged() Check {
 	return Check{
-		ID:    "privileged",
-		Level: api.LevelBaseline,
+		ID:       "privileged",
+		Level:    api.LevelBaseline,
+		SpecOnly: true,
+		Severity: SeverityCritical,
 		Versions: []VersionedCheck{
 			{
 				MinimumVersion: api.MajorMinorVersion(1, 0),
@@ -55,14 +57,14 @@ func CheckPrivileged() Check {
 }
 
 func privilegedV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
-	badContainers := NewViolations(opts.withFieldErrors)
+	badContainers := newViolations(opts)
 
-	visitContainers(podSpec, opts, func(container *corev1.Container, path *field.Path) {
+	visitContainers(podSpec, opts, func(container *corev1.Container, kind ContainerKind, path *field.Path) {
 		if container.SecurityContext != nil && container.SecurityContext.Privileged != nil && *container.SecurityContext.Privileged {
 			if opts.withFieldErrors {
-				badContainers.Add(container.Name, withBadValue(forbidden(path.Child("securityContext", "privileged")), true))
+				badContainers.AddContainer(container.Name, kind, withBadValue(forbidden(path.Child("securityContext", "privileged")), true))
 			} else {
-				badContainers.Add(container.Name)
+				badContainers.AddContainer(container.Name, kind)
 			}
 		}
 	})
@@ -72,9 +74,8 @@ func privilegedV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, o
 			Allowed:         false,
 			ForbiddenReason: "privileged",
 			ForbiddenDetail: fmt.Sprintf(
-				`%s %s must not set securityContext.privileged=true`,
-				pluralize("container", "containers", badContainers.Len()),
-				joinQuote(badContainers.Data()),
+				`%s must not set securityContext.privileged=true`,
+				badContainers.DescribeContainers(),
 			),
 			ErrList: badContainers.Errs(),
 		}
@@ -28,6 +28,7 @@ import (
 )
 
 func TestPrivileged(t *testing.T) {
+	restartPolicyAlways := corev1.ContainerRestartPolicyAlways
 	tests := []struct {
 		name          string
 		pod           *corev1.Pod
@@ -71,6 +72,20 @@ func TestPrivileged(t *testing.T) {
 				{Type: field.ErrorTypeForbidden, Field: "spec.containers[4].securityContext.privileged", BadValue: true},
 			},
 		},
+		{
+			name: "privileged sidecar",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{
+					{Name: "init", SecurityContext: &corev1.SecurityContext{Privileged: utilpointer.Bool(true)}},
+					{Name: "istio-proxy", RestartPolicy: &restartPolicyAlways, SecurityContext: &corev1.SecurityContext{Privileged: utilpointer.Bool(true)}},
+				},
+				Containers: []corev1.Container{
+					{Name: "a", SecurityContext: &corev1.SecurityContext{Privileged: utilpointer.Bool(true)}},
+				},
+			}},
+			expectReason: `privileged`,
+			expectDetail: `containers "init", "a" and sidecar container "istio-proxy" must not set securityContext.privileged=true`,
+		},
 	}
 
 	cmpOpts := []cmp.Option{cmpopts.IgnoreFields(field.Error{}, "Detail"), cmpopts.SortSlices(func(a, b *field.Error) bool { return a.Error() < b.Error() })}
@@ -47,8 +47,9 @@ func init() {
 // in 1.0+
 func CheckProcMount() Check {
 	return Check{
-		ID:    "procMount",
-		Level: api.LevelBaseline,
+		ID:       "procMount",
+		Level:    api.LevelBaseline,
+		SpecOnly: true,
 		Versions: []VersionedCheck{
 			{
 				MinimumVersion: api.MajorMinorVersion(1, 0),
@@ -59,9 +60,9 @@ func CheckProcMount() Check {
 }
 
 func procMountV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
-	badContainers := NewViolations(opts.withFieldErrors)
+	badContainers := newViolations(opts)
 	forbiddenProcMountTypes := sets.NewString()
-	visitContainers(podSpec, opts, func(container *corev1.Container, path *field.Path) {
+	visitContainers(podSpec, opts, func(container *corev1.Container, kind ContainerKind, path *field.Path) {
 		// allow if the security context is nil.
 		if container.SecurityContext == nil {
 			return
@@ -73,9 +74,9 @@ func procMountV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, op
 		// check if the value of the proc mount type is valid.
 		if *container.SecurityContext.ProcMount != corev1.DefaultProcMount {
 			if opts.withFieldErrors {
-				badContainers.Add(container.Name, withBadValue(forbidden(path.Child("securityContext", "procMount")), string(*container.SecurityContext.ProcMount)))
+				badContainers.AddContainer(container.Name, kind, withBadValue(forbidden(path.Child("securityContext", "procMount")), string(*container.SecurityContext.ProcMount)))
 			} else {
-				badContainers.Add(container.Name)
+				badContainers.AddContainer(container.Name, kind)
 			}
 			forbiddenProcMountTypes.Insert(string(*container.SecurityContext.ProcMount))
 		}
@@ -85,9 +86,8 @@ func procMountV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, op
 			Allowed:         false,
 			ForbiddenReason: "procMount",
 			ForbiddenDetail: fmt.Sprintf(
-				"%s %s must not set securityContext.procMount to %s",
-				pluralize("container", "containers", badContainers.Len()),
-				joinQuote(badContainers.Data()),
+				"%s must not set securityContext.procMount to %s",
+				badContainers.DescribeContainers(),
 				joinQuote(forbiddenProcMountTypes.List()),
 			),
 			ErrList: badContainers.Errs(),
@@ -0,0 +1,168 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/pod-security-admission/api"
+)
+
+/*
+HostPath volumes may only be mounted read-only, and only for the host paths allowed with
+WithReadOnlyHostPaths, e.g. /var/log for log collectors. This check is optional and not part of
+the Pod Security Standards. It replaces the baseline hostPathVolumes check when enabled, and is
+skipped by the restricted restrictedVolumes check, which forbids all hostPath volumes.
+
+**Restricted Fields:**
+
+spec.volumes[*].hostPath.path
+spec.volumes[*].hostPath.type
+spec.containers[*].volumeMounts[*].readOnly
+spec.initContainers[*].volumeMounts[*].readOnly
+spec.ephemeralContainers[*].volumeMounts[*].readOnly
+
+**Allowed Values:**
+hostPath.path: paths configured with WithReadOnlyHostPaths, or paths beneath them
+hostPath.type: any type except DirectoryOrCreate and FileOrCreate
+volumeMounts[*].readOnly: true, for mounts of hostPath volumes
+*/
+
+func init() {
+	addOptionalCheck(CheckReadOnlyHostPathVolumes)
+}
+
+const checkReadOnlyHostPathVolumesID CheckID = "readOnlyHostPathVolumes"
+
+// CheckReadOnlyHostPathVolumes returns an optional baseline level check
+// that replaces the hostPathVolumes check to allow read-only mounts of allowed host paths in 1.0+
+func CheckReadOnlyHostPathVolumes() Check {
+	return Check{
+		ID:       checkReadOnlyHostPathVolumesID,
+		Level:    api.LevelBaseline,
+		SpecOnly: true,
+		Severity: SeverityCritical,
+		Versions: []VersionedCheck{
+			{
+				MinimumVersion:   api.MajorMinorVersion(1, 0),
+				CheckPod:         withOptions(readOnlyHostPathVolumesV1Dot0),
+				OverrideCheckIDs: []CheckID{checkHostPathVolumesID},
+			},
+		},
+	}
+}
+
+// WithReadOnlyHostPaths configures the readOnlyHostPathVolumes check to allow read-only mounts of the given
+// absolute host paths and the paths beneath them. Without allowed paths, all hostPath volumes are forbidden.
+func WithReadOnlyHostPaths(paths ...string) Option {
+	return func(opt options) options {
+		opt.readOnlyHostPaths = paths
+		return opt
+	}
+}
+
+func readOnlyHostPathVolumesV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
+	hostPathVolumes := sets.New[string]()
+	for _, volume := range podSpec.Volumes {
+		if volume.HostPath != nil {
+			hostPathVolumes.Insert(volume.Name)
+		}
+	}
+	if hostPathVolumes.Len() == 0 {
+		return CheckResult{Allowed: true}
+	}
+
+	// Collect the writable mounts of each hostPath volume.
+	writableMounts := map[string][]*field.Error{}
+	visitContainers(podSpec, opts, func(container *corev1.Container, kind ContainerKind, path *field.Path) {
+		for i, mount := range container.VolumeMounts {
+			if mount.ReadOnly || !hostPathVolumes.Has(mount.Name) {
+				continue
+			}
+			var err *field.Error
+			if opts.withFieldErrors {
+				err = withBadValue(forbidden(path.Child("volumeMounts").Index(i).Child("readOnly")), false)
+			}
+			writableMounts[mount.Name] = append(writableMounts[mount.Name], err)
+		}
+	})
+
+	badVolumes := newViolations(opts)
+	for i, volume := range podSpec.Volumes {
+		if volume.HostPath == nil {
+			continue
+		}
+		var errs []*field.Error
+		if !readOnlyHostPathAllowed(volume.HostPath.Path, opts.readOnlyHostPaths) {
+			var err *field.Error
+			if opts.withFieldErrors {
+				err = withBadValue(forbidden(volumesPath.Index(i).Child("hostPath", "path")), volume.HostPath.Path)
+			}
+			errs = append(errs, err)
+		}
+		if hostPathType := volume.HostPath.Type; hostPathType != nil && (*hostPathType == corev1.HostPathDirectoryOrCreate || *hostPathType == corev1.HostPathFileOrCreate) {
+			var err *field.Error
+			if opts.withFieldErrors {
+				err = withBadValue(forbidden(volumesPath.Index(i).Child("hostPath", "type")), string(*hostPathType))
+			}
+			errs = append(errs, err)
+		}
+		errs = append(errs, writableMounts[volume.Name]...)
+		if len(errs) > 0 {
+			badVolumes.Add(volume.Name, errs...)
+		}
+	}
+
+	if !badVolumes.Empty() {
+		return CheckResult{
+			Allowed:         false,
+			ForbiddenReason: "hostPath volumes",
+			ForbiddenDetail: fmt.Sprintf(
+				"%s %s must be read-only mounts of allowed host paths",
+				pluralize("volume", "volumes", badVolumes.Len()),
+				joinQuote(badVolumes.Data()),
+			),
+			ErrList: badVolumes.Errs(),
+		}
+	}
+	return CheckResult{Allowed: true}
+}
+
+// readOnlyHostPathAllowed returns true if the host path is one of the allowed paths, or beneath one of them.
+// Paths are cleaned before matching, so that relative elements cannot escape the allowed paths.
+func readOnlyHostPathAllowed(hostPath string, allowedPaths []string) bool {
+	if !path.IsAbs(hostPath) {
+		return false
+	}
+	hostPath = path.Clean(hostPath)
+	for _, allowed := range allowedPaths {
+		if !path.IsAbs(allowed) {
+			continue
+		}
+		allowed = path.Clean(allowed)
+		if hostPath == allowed || strings.HasPrefix(hostPath, strings.TrimSuffix(allowed, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
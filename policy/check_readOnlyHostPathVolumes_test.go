@@ -0,0 +1,155 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/pod-security-admission/api"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadOnlyHostPathVolumes(t *testing.T) {
+	hostPath := func(name, path string) corev1.Volume {
+		return corev1.Volume{Name: name, VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: path}}}
+	}
+	directoryOrCreate := corev1.HostPathDirectoryOrCreate
+
+	tests := []struct {
+		name          string
+		pod           *corev1.Pod
+		opts          options
+		allowed       bool
+		expectReason  string
+		expectDetail  string
+		expectErrList field.ErrorList
+	}{
+		{
+			name: "no host path volumes",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{{Name: "a", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}},
+			}},
+			allowed: true,
+		},
+		{
+			name: "read-only mounts of allowed host paths",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{hostPath("logs", "/var/log"), hostPath("pods", "/var/log/pods/")},
+				Containers: []corev1.Container{{
+					Name:         "a",
+					VolumeMounts: []corev1.VolumeMount{{Name: "logs", ReadOnly: true}, {Name: "pods", ReadOnly: true}},
+				}},
+			}},
+			opts:    options{readOnlyHostPaths: []string{"/var/log"}},
+			allowed: true,
+		},
+		{
+			name: "no allowed host paths",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{hostPath("logs", "/var/log")},
+			}},
+			expectReason: `hostPath volumes`,
+			expectDetail: `volume "logs" must be read-only mounts of allowed host paths`,
+		},
+		{
+			name: "forbidden host paths, types and writable mounts, enable field error list",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{
+					hostPath("logs", "/var/log"),
+					hostPath("escape", "/var/log/../../etc"),
+					hostPath("prefix", "/var/logs"),
+					{Name: "create", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/log/new", Type: &directoryOrCreate}}},
+				},
+				InitContainers: []corev1.Container{{
+					Name:         "init",
+					VolumeMounts: []corev1.VolumeMount{{Name: "logs", ReadOnly: true}},
+				}},
+				Containers: []corev1.Container{{
+					Name:         "a",
+					VolumeMounts: []corev1.VolumeMount{{Name: "create", ReadOnly: true}, {Name: "logs"}},
+				}},
+			}},
+			opts: options{
+				withFieldErrors:   true,
+				readOnlyHostPaths: []string{"/var/log/"},
+			},
+			expectReason: `hostPath volumes`,
+			expectDetail: `volumes "logs", "escape", "prefix", "create" must be read-only mounts of allowed host paths`,
+			expectErrList: field.ErrorList{
+				{Type: field.ErrorTypeForbidden, Field: "spec.containers[0].volumeMounts[1].readOnly", BadValue: false},
+				{Type: field.ErrorTypeForbidden, Field: "spec.volumes[1].hostPath.path", BadValue: "/var/log/../../etc"},
+				{Type: field.ErrorTypeForbidden, Field: "spec.volumes[2].hostPath.path", BadValue: "/var/logs"},
+				{Type: field.ErrorTypeForbidden, Field: "spec.volumes[3].hostPath.type", BadValue: "DirectoryOrCreate"},
+			},
+		},
+	}
+
+	cmpOpts := []cmp.Option{cmpopts.IgnoreFields(field.Error{}, "Detail"), cmpopts.SortSlices(func(a, b *field.Error) bool { return a.Error() < b.Error() })}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := readOnlyHostPathVolumesV1Dot0(&tc.pod.ObjectMeta, &tc.pod.Spec, tc.opts)
+			if !tc.allowed {
+				if result.Allowed {
+					t.Fatal("expected disallowed")
+				}
+				if e, a := tc.expectReason, result.ForbiddenReason; e != a {
+					t.Errorf("expected\n%s\ngot\n%s", e, a)
+				}
+				if e, a := tc.expectDetail, result.ForbiddenDetail; e != a {
+					t.Errorf("expected\n%s\ngot\n%s", e, a)
+				}
+				if result.ErrList != nil {
+					if diff := cmp.Diff(tc.expectErrList, *result.ErrList, cmpOpts...); diff != "" {
+						t.Errorf("unexpected field errors (-want,+got):\n%s", diff)
+					}
+				}
+			} else if !result.Allowed {
+				t.Fatal("expected allowed")
+			}
+		})
+	}
+}
+
+func TestReadOnlyHostPathVolumesEvaluator(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{
+		Volumes: []corev1.Volume{{Name: "logs", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/log"}}}},
+		Containers: []corev1.Container{{
+			Name:         "collector",
+			VolumeMounts: []corev1.VolumeMount{{Name: "logs", ReadOnly: true}},
+		}},
+	}}
+	checks := append(DefaultChecks(), CheckReadOnlyHostPathVolumes())
+
+	evaluator, err := NewEvaluator(checks, WithReadOnlyHostPaths("/var/log"))
+	require.NoError(t, err)
+	baseline := AggregateCheckResults(evaluator.EvaluatePod(api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}, &pod.ObjectMeta, &pod.Spec))
+	assert.True(t, baseline.Allowed, "expected read-only mounts of allowed host paths to be allowed at baseline")
+
+	restricted := AggregateCheckResults(evaluator.EvaluatePod(api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()}, &pod.ObjectMeta, &pod.Spec))
+	assert.False(t, restricted.Allowed)
+	assert.NotContains(t, restricted.ForbiddenReasons, "hostPath volumes", "expected only the restrictedVolumes check to forbid hostPath volumes")
+
+	pod.Spec.Containers[0].VolumeMounts[0].ReadOnly = false
+	baseline = AggregateCheckResults(evaluator.EvaluatePod(api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}, &pod.ObjectMeta, &pod.Spec))
+	assert.Equal(t, []string{"hostPath volumes"}, baseline.ForbiddenReasons)
+}
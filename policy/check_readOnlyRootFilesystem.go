@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/pod-security-admission/api"
+)
+
+/*
+Containers should run with a read-only root filesystem, so that a compromised process cannot
+modify the binaries and configuration of the container image. Writable paths are mounted as volumes.
+This check is optional and not part of the Pod Security Standards.
+
+**Restricted Fields:**
+
+spec.containers[*].securityContext.readOnlyRootFilesystem
+spec.initContainers[*].securityContext.readOnlyRootFilesystem
+spec.ephemeralContainers[*].securityContext.readOnlyRootFilesystem
+
+**Allowed Values:** true
+*/
+
+func init() {
+	addOptionalCheck(CheckReadOnlyRootFilesystem)
+}
+
+const checkReadOnlyRootFilesystemID CheckID = "readOnlyRootFilesystem"
+
+// CheckReadOnlyRootFilesystem returns an optional restricted level check
+// that requires readOnlyRootFilesystem=true in 1.0+
+func CheckReadOnlyRootFilesystem() Check {
+	return Check{
+		ID:       checkReadOnlyRootFilesystemID,
+		Level:    api.LevelRestricted,
+		SpecOnly: true,
+		Versions: []VersionedCheck{
+			{
+				// Pod API validation forbids readOnlyRootFilesystem for pods with spec.os.name=windows,
+				// so Windows pods are allowed by default.
+				MinimumVersion: api.MajorMinorVersion(1, 0),
+				CheckPod:       withOptions(linuxOnly(readOnlyRootFilesystemV1Dot0, true)),
+			},
+		},
+	}
+}
+
+func readOnlyRootFilesystemV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
+	badContainers := newViolations(opts)
+
+	visitContainers(podSpec, opts, func(container *corev1.Container, kind ContainerKind, path *field.Path) {
+		if opts.withFieldErrors {
+			path = path.Child("securityContext", "readOnlyRootFilesystem")
+			if container.SecurityContext == nil {
+				badContainers.AddContainer(container.Name, kind, required(path))
+			} else if container.SecurityContext.ReadOnlyRootFilesystem == nil {
+				badContainers.AddContainer(container.Name, kind, withBadValue(forbidden(path), "nil"))
+			} else if !*container.SecurityContext.ReadOnlyRootFilesystem {
+				badContainers.AddContainer(container.Name, kind, withBadValue(forbidden(path), false))
+			}
+		} else if container.SecurityContext == nil || container.SecurityContext.ReadOnlyRootFilesystem == nil || !*container.SecurityContext.ReadOnlyRootFilesystem {
+			badContainers.AddContainer(container.Name, kind)
+		}
+	})
+
+	if !badContainers.Empty() {
+		return CheckResult{
+			Allowed:         false,
+			ForbiddenReason: "readOnlyRootFilesystem != true",
+			ForbiddenDetail: fmt.Sprintf(
+				"%s must set securityContext.readOnlyRootFilesystem=true",
+				badContainers.DescribeContainers(),
+			),
+			ErrList: badContainers.Errs(),
+		}
+	}
+	return CheckResult{Allowed: true}
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestReadOnlyRootFilesystem(t *testing.T) {
+	restartPolicyAlways := corev1.ContainerRestartPolicyAlways
+	tests := []struct {
+		name          string
+		pod           *corev1.Pod
+		opts          options
+		expectReason  string
+		expectDetail  string
+		allowed       bool
+		expectErrList field.ErrorList
+	}{
+		{
+			name: "read-only root filesystems",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{
+					{Name: "init", SecurityContext: &corev1.SecurityContext{ReadOnlyRootFilesystem: pointer.Bool(true)}},
+				},
+				Containers: []corev1.Container{
+					{Name: "a", SecurityContext: &corev1.SecurityContext{ReadOnlyRootFilesystem: pointer.Bool(true)}},
+				}}},
+			allowed: true,
+		},
+		{
+			name: "multiple containers",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{
+					{Name: "proxy", RestartPolicy: &restartPolicyAlways},
+				},
+				Containers: []corev1.Container{
+					{Name: "a"},
+					{Name: "b", SecurityContext: &corev1.SecurityContext{ReadOnlyRootFilesystem: nil}},
+					{Name: "c", SecurityContext: &corev1.SecurityContext{ReadOnlyRootFilesystem: pointer.Bool(false)}},
+					{Name: "d", SecurityContext: &corev1.SecurityContext{ReadOnlyRootFilesystem: pointer.Bool(true)}},
+				}}},
+			expectReason: `readOnlyRootFilesystem != true`,
+			expectDetail: `containers "a", "b", "c" and sidecar container "proxy" must set securityContext.readOnlyRootFilesystem=true`,
+		},
+		{
+			name: "multiple containers, enable field error list",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "a"},
+					{Name: "b", SecurityContext: &corev1.SecurityContext{ReadOnlyRootFilesystem: nil}},
+					{Name: "c", SecurityContext: &corev1.SecurityContext{ReadOnlyRootFilesystem: pointer.Bool(false)}},
+					{Name: "d", SecurityContext: &corev1.SecurityContext{ReadOnlyRootFilesystem: pointer.Bool(true)}},
+				},
+				EphemeralContainers: []corev1.EphemeralContainer{
+					{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debug"}},
+				}}},
+			opts: options{
+				withFieldErrors: true,
+			},
+			expectReason: `readOnlyRootFilesystem != true`,
+			expectDetail: `containers "a", "b", "c", "debug" must set securityContext.readOnlyRootFilesystem=true`,
+			expectErrList: field.ErrorList{
+				{Type: field.ErrorTypeRequired, Field: "spec.containers[0].securityContext.readOnlyRootFilesystem", BadValue: ""},
+				{Type: field.ErrorTypeForbidden, Field: "spec.containers[1].securityContext.readOnlyRootFilesystem", BadValue: "nil"},
+				{Type: field.ErrorTypeForbidden, Field: "spec.containers[2].securityContext.readOnlyRootFilesystem", BadValue: false},
+				{Type: field.ErrorTypeRequired, Field: "spec.ephemeralContainers[0].securityContext.readOnlyRootFilesystem", BadValue: ""},
+			},
+		},
+		{
+			name: "windows pod, admit without checking readOnlyRootFilesystem",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				OS: &corev1.PodOS{Name: corev1.Windows},
+				Containers: []corev1.Container{
+					{Name: "a"},
+				}}},
+			allowed: true,
+		},
+		{
+			name: "windows pod, enforce readOnlyRootFilesystem",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				OS: &corev1.PodOS{Name: corev1.Windows},
+				Containers: []corev1.Container{
+					{Name: "a"},
+				}}},
+			opts: options{
+				windowsPodMode: WindowsPodModeEnforce,
+			},
+			expectReason: `readOnlyRootFilesystem != true`,
+			expectDetail: `container "a" must set securityContext.readOnlyRootFilesystem=true`,
+		},
+	}
+
+	cmpOpts := []cmp.Option{cmpopts.IgnoreFields(field.Error{}, "Detail"), cmpopts.SortSlices(func(a, b *field.Error) bool { return a.Error() < b.Error() })}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := linuxOnly(readOnlyRootFilesystemV1Dot0, true)(&tc.pod.ObjectMeta, &tc.pod.Spec, tc.opts)
+			if result.Allowed != tc.allowed {
+				t.Fatalf("expected allowed=%v", tc.allowed)
+			}
+			if e, a := tc.expectReason, result.ForbiddenReason; e != a {
+				t.Errorf("expected\n%s\ngot\n%s", e, a)
+			}
+			if e, a := tc.expectDetail, result.ForbiddenDetail; e != a {
+				t.Errorf("expected\n%s\ngot\n%s", e, a)
+			}
+			if result.ErrList != nil {
+				if diff := cmp.Diff(tc.expectErrList, *result.ErrList, cmpOpts...); diff != "" {
+					t.Errorf("unexpected field errors (-want,+got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/pod-security-admission/api"
+)
+
+/*
+Devices allocated through dynamic resource allocation (e.g. GPUs with peer-to-peer access, NICs)
+can grant host-adjacent capabilities, and should be restricted to approved device classes.
+This check is optional and not part of the Pod Security Standards.
+
+**Restricted Fields:**
+
+spec.resourceClaims[*]
+
+**Allowed Values:**
+claims whose device classes, as returned by the resolver configured with WithDeviceClassResolver,
+are all configured with WithAllowedDeviceClasses
+undefined/null
+*/
+
+func init() {
+	addOptionalCheck(CheckResourceClaims)
+}
+
+const checkResourceClaimsID CheckID = "resourceClaims"
+
+// CheckResourceClaims returns an optional baseline level check
+// that restricts resource claims to approved device classes in 1.0+
+func CheckResourceClaims() Check {
+	return Check{
+		ID:    checkResourceClaimsID,
+		Level: api.LevelBaseline,
+		Versions: []VersionedCheck{
+			{
+				MinimumVersion: api.MajorMinorVersion(1, 0),
+				CheckPod:       withOptions(resourceClaimsV1Dot0),
+			},
+		},
+	}
+}
+
+// DeviceClassResolver returns the device classes requested by the ResourceClaim or ResourceClaimTemplate
+// referenced by a resource claim of the pod, and false if the referenced object is unknown.
+// Resolvers are called during evaluation, and are expected to read from a local cache.
+type DeviceClassResolver func(podMetadata *metav1.ObjectMeta, claim corev1.PodResourceClaim) (deviceClasses []string, ok bool)
+
+// WithAllowedDeviceClasses configures the resourceClaims check to allow the given device classes.
+func WithAllowedDeviceClasses(deviceClasses ...string) Option {
+	return func(opt options) options {
+		opt.allowedDeviceClasses = deviceClasses
+		return opt
+	}
+}
+
+// WithDeviceClassResolver configures the resolver used by the resourceClaims check to look up
+// the device classes of resource claims. Without a resolver, all resource claims are forbidden.
+func WithDeviceClassResolver(resolver DeviceClassResolver) Option {
+	return func(opt options) options {
+		opt.deviceClassResolver = resolver
+		return opt
+	}
+}
+
+func resourceClaimsV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
+	badClaims := newViolations(opts)
+
+	allowed := sets.New[string](opts.allowedDeviceClasses...)
+	for i, claim := range podSpec.ResourceClaims {
+		var deviceClasses []string
+		ok := false
+		if opts.deviceClassResolver != nil {
+			deviceClasses, ok = opts.deviceClassResolver(podMetadata, claim)
+		}
+		if !ok {
+			var err *field.Error
+			if opts.withFieldErrors {
+				err = withBadValue(forbidden(resourceClaimsPath.Index(i)), claim.Name)
+				err.Detail = "unknown device classes"
+			}
+			badClaims.Add(claim.Name, err)
+			continue
+		}
+
+		var forbiddenClasses []string
+		for _, deviceClass := range deviceClasses {
+			if !allowed.Has(deviceClass) {
+				forbiddenClasses = append(forbiddenClasses, deviceClass)
+			}
+		}
+		if len(forbiddenClasses) > 0 {
+			var err *field.Error
+			if opts.withFieldErrors {
+				err = withBadValue(forbidden(resourceClaimsPath.Index(i)), strings.Join(forbiddenClasses, ", "))
+				err.Detail = "forbidden device classes"
+			}
+			badClaims.Add(claim.Name, err)
+		}
+	}
+
+	if !badClaims.Empty() {
+		return CheckResult{
+			Allowed:         false,
+			ForbiddenReason: "resource claims",
+			ForbiddenDetail: fmt.Sprintf(
+				"resource %s %s must only use allowed device classes",
+				pluralize("claim", "claims", badClaims.Len()),
+				joinQuote(badClaims.Data()),
+			),
+			ErrList: badClaims.Errs(),
+		}
+	}
+	return CheckResult{Allowed: true}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestResourceClaims(t *testing.T) {
+	deviceClasses := map[string][]string{
+		"gpu":      {"gpu.example.com"},
+		"gpu-p2p":  {"gpu.example.com", "gpu-p2p.example.com"},
+		"nic":      {"nic.example.com"},
+		"no-class": {},
+	}
+	resolver := func(podMetadata *metav1.ObjectMeta, claim corev1.PodResourceClaim) ([]string, bool) {
+		if podMetadata.Namespace != "ns" || claim.Source.ResourceClaimTemplateName == nil {
+			return nil, false
+		}
+		classes, ok := deviceClasses[*claim.Source.ResourceClaimTemplateName]
+		return classes, ok
+	}
+	claim := func(name, template string) corev1.PodResourceClaim {
+		return corev1.PodResourceClaim{Name: name, Source: corev1.ClaimSource{ResourceClaimTemplateName: pointer.String(template)}}
+	}
+
+	tests := []struct {
+		name          string
+		pod           *corev1.Pod
+		opts          options
+		allowed       bool
+		expectReason  string
+		expectDetail  string
+		expectErrList field.ErrorList
+	}{
+		{
+			name:    "no resource claims",
+			pod:     &corev1.Pod{Spec: corev1.PodSpec{}},
+			allowed: true,
+		},
+		{
+			name: "allowed device classes",
+			pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns"}, Spec: corev1.PodSpec{
+				ResourceClaims: []corev1.PodResourceClaim{claim("a", "gpu"), claim("b", "no-class")},
+			}},
+			opts: options{
+				allowedDeviceClasses: []string{"gpu.example.com"},
+				deviceClassResolver:  resolver,
+			},
+			allowed: true,
+		},
+		{
+			name: "no resolver",
+			pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns"}, Spec: corev1.PodSpec{
+				ResourceClaims: []corev1.PodResourceClaim{claim("a", "gpu")},
+			}},
+			opts: options{
+				allowedDeviceClasses: []string{"gpu.example.com"},
+			},
+			expectReason: `resource claims`,
+			expectDetail: `resource claim "a" must only use allowed device classes`,
+		},
+		{
+			name: "forbidden and unknown device classes, enable field error list",
+			pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns"}, Spec: corev1.PodSpec{
+				ResourceClaims: []corev1.PodResourceClaim{claim("a", "gpu"), claim("b", "gpu-p2p"), claim("c", "nic"), claim("d", "unknown")},
+			}},
+			opts: options{
+				withFieldErrors:      true,
+				allowedDeviceClasses: []string{"gpu.example.com"},
+				deviceClassResolver:  resolver,
+			},
+			expectReason: `resource claims`,
+			expectDetail: `resource claims "b", "c", "d" must only use allowed device classes`,
+			expectErrList: field.ErrorList{
+				{Type: field.ErrorTypeForbidden, Field: "spec.resourceClaims[1]", BadValue: "gpu-p2p.example.com"},
+				{Type: field.ErrorTypeForbidden, Field: "spec.resourceClaims[2]", BadValue: "nic.example.com"},
+				{Type: field.ErrorTypeForbidden, Field: "spec.resourceClaims[3]", BadValue: "d"},
+			},
+		},
+	}
+
+	cmpOpts := []cmp.Option{cmpopts.IgnoreFields(field.Error{}, "Detail"), cmpopts.SortSlices(func(a, b *field.Error) bool { return a.Error() < b.Error() })}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := resourceClaimsV1Dot0(&tc.pod.ObjectMeta, &tc.pod.Spec, tc.opts)
+			if !tc.allowed {
+				if result.Allowed {
+					t.Fatal("expected disallowed")
+				}
+				if e, a := tc.expectReason, result.ForbiddenReason; e != a {
+					t.Errorf("expected\n%s\ngot\n%s", e, a)
+				}
+				if e, a := tc.expectDetail, result.ForbiddenDetail; e != a {
+					t.Errorf("expected\n%s\ngot\n%s", e, a)
+				}
+				if result.ErrList != nil {
+					if diff := cmp.Diff(tc.expectErrList, *result.ErrList, cmpOpts...); diff != "" {
+						t.Errorf("unexpected field errors (-want,+got):\n%s", diff)
+					}
+				}
+			} else if !result.Allowed {
+				t.Fatal("expected allowed")
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/pod-security-admission/api"
+)
+
+/*
+Containers without resource limits can starve the other workloads of their node. Requiring limits
+lets clusters enforce LimitRange-style hygiene with the same levels, modes and exemptions as the
+Pod Security Standards. This check is optional and not part of the Pod Security Standards.
+Ephemeral containers are not evaluated, since they cannot set resources.
+
+**Restricted Fields:**
+
+spec.containers[*].resources.limits
+spec.initContainers[*].resources.limits
+
+**Allowed Values:** limits for cpu and memory, or the resources configured with WithRequiredResourceLimits
+*/
+
+func init() {
+	addOptionalCheck(CheckResourceLimits)
+}
+
+const checkResourceLimitsID CheckID = "resourceLimits"
+
+// defaultRequiredResourceLimits are the resources required by the resourceLimits check by default.
+var defaultRequiredResourceLimits = []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
+
+// CheckResourceLimits returns an optional baseline level check
+// that requires containers to set resource limits in 1.0+
+func CheckResourceLimits() Check {
+	return Check{
+		ID:       checkResourceLimitsID,
+		Level:    api.LevelBaseline,
+		SpecOnly: true,
+		Severity: SeverityMedium,
+		Versions: []VersionedCheck{
+			{
+				MinimumVersion: api.MajorMinorVersion(1, 0),
+				CheckPod:       withOptions(resourceLimitsV1Dot0),
+			},
+		},
+	}
+}
+
+// WithRequiredResourceLimits configures the resourceLimits check to require limits for the given resources,
+// e.g. ephemeral-storage, instead of cpu and memory.
+func WithRequiredResourceLimits(resources ...corev1.ResourceName) Option {
+	return func(opt options) options {
+		opt.requiredResourceLimits = resources
+		return opt
+	}
+}
+
+func resourceLimitsV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
+	required := opts.requiredResourceLimits
+	if len(required) == 0 {
+		required = defaultRequiredResourceLimits
+	}
+	badContainers := newViolations(opts)
+
+	visitContainers(podSpec, opts, func(container *corev1.Container, kind ContainerKind, path *field.Path) {
+		if kind == ContainerKindEphemeral {
+			return
+		}
+		var errs []*field.Error
+		for _, resource := range required {
+			if _, ok := container.Resources.Limits[resource]; ok {
+				continue
+			}
+			var err *field.Error
+			if opts.withFieldErrors {
+				err = field.Required(path.Child("resources", "limits").Key(string(resource)), "")
+			}
+			errs = append(errs, err)
+		}
+		if len(errs) > 0 {
+			badContainers.AddContainer(container.Name, kind, errs...)
+		}
+	})
+
+	if !badContainers.Empty() {
+		names := make([]string, len(required))
+		for i, resource := range required {
+			names[i] = string(resource)
+		}
+		return CheckResult{
+			Allowed:         false,
+			ForbiddenReason: "resource limits",
+			ForbiddenDetail: fmt.Sprintf(
+				"%s must set %s limits",
+				badContainers.DescribeContainers(),
+				strings.Join(names, ", "),
+			),
+			ErrList: badContainers.Errs(),
+		}
+	}
+	return CheckResult{Allowed: true}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestResourceLimits(t *testing.T) {
+	restartPolicyAlways := corev1.ContainerRestartPolicyAlways
+	limits := func(resources ...corev1.ResourceName) corev1.ResourceRequirements {
+		requirements := corev1.ResourceRequirements{Limits: corev1.ResourceList{}}
+		for _, name := range resources {
+			requirements.Limits[name] = resource.MustParse("1")
+		}
+		return requirements
+	}
+
+	tests := []struct {
+		name          string
+		pod           *corev1.Pod
+		opts          options
+		allowed       bool
+		expectReason  string
+		expectDetail  string
+		expectErrList field.ErrorList
+	}{
+		{
+			name: "cpu and memory limits",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "init", Resources: limits(corev1.ResourceCPU, corev1.ResourceMemory)}},
+				Containers:     []corev1.Container{{Name: "a", Resources: limits(corev1.ResourceCPU, corev1.ResourceMemory)}},
+				EphemeralContainers: []corev1.EphemeralContainer{
+					{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debug"}},
+				},
+			}},
+			allowed: true,
+		},
+		{
+			name: "missing limits",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "proxy", RestartPolicy: &restartPolicyAlways}},
+				Containers: []corev1.Container{
+					{Name: "a", Resources: limits(corev1.ResourceCPU)},
+					{Name: "b", Resources: limits(corev1.ResourceCPU, corev1.ResourceMemory)},
+				},
+			}},
+			expectReason: `resource limits`,
+			expectDetail: `container "a" and sidecar container "proxy" must set cpu, memory limits`,
+		},
+		{
+			name: "missing limits, enable field error list",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "init"}},
+				Containers: []corev1.Container{
+					{Name: "a", Resources: limits(corev1.ResourceMemory)},
+				},
+			}},
+			opts: options{
+				withFieldErrors: true,
+			},
+			expectReason: `resource limits`,
+			expectDetail: `containers "init", "a" must set cpu, memory limits`,
+			expectErrList: field.ErrorList{
+				{Type: field.ErrorTypeRequired, Field: "spec.initContainers[0].resources.limits[cpu]", BadValue: ""},
+				{Type: field.ErrorTypeRequired, Field: "spec.initContainers[0].resources.limits[memory]", BadValue: ""},
+				{Type: field.ErrorTypeRequired, Field: "spec.containers[0].resources.limits[cpu]", BadValue: ""},
+			},
+		},
+		{
+			name: "configured resources",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "a", Resources: limits(corev1.ResourceMemory)},
+					{Name: "b", Resources: limits(corev1.ResourceCPU)},
+				},
+			}},
+			opts: options{
+				requiredResourceLimits: []corev1.ResourceName{corev1.ResourceMemory},
+			},
+			expectReason: `resource limits`,
+			expectDetail: `container "b" must set memory limits`,
+		},
+	}
+
+	cmpOpts := []cmp.Option{cmpopts.IgnoreFields(field.Error{}, "Detail"), cmpopts.SortSlices(func(a, b *field.Error) bool { return a.Error() < b.Error() })}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := resourceLimitsV1Dot0(&tc.pod.ObjectMeta, &tc.pod.Spec, tc.opts)
+			if result.Allowed != tc.allowed {
+				t.Fatalf("expected allowed=%v", tc.allowed)
+			}
+			if e, a := tc.expectReason, result.ForbiddenReason; e != a {
+				t.Errorf("expected\n%s\ngot\n%s", e, a)
+			}
+			if e, a := tc.expectDetail, result.ForbiddenDetail; e != a {
+				t.Errorf("expected\n%s\ngot\n%s", e, a)
+			}
+			if result.ErrList != nil {
+				if diff := cmp.Diff(tc.expectErrList, *result.ErrList, cmpOpts...); diff != "" {
+					t.Errorf("unexpected field errors (-want,+got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
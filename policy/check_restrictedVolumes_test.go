@@ -158,6 +158,27 @@ func TestRestrictedVolumes(t *testing.T) {
 				{Type: field.ErrorTypeForbidden, Field: "spec.volumes[29].unknown", BadValue: ""},
 			},
 		},
+		{
+			name: "additional allowed volume types, enable field error list",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{
+					{Name: "a", VolumeSource: corev1.VolumeSource{NFS: &corev1.NFSVolumeSource{}}},
+					{Name: "b", VolumeSource: corev1.VolumeSource{CephFS: &corev1.CephFSVolumeSource{}}},
+					{Name: "c", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{}}},
+					{Name: "d", VolumeSource: corev1.VolumeSource{RBD: &corev1.RBDVolumeSource{}}},
+				},
+			}},
+			opts: options{
+				withFieldErrors:              true,
+				additionalAllowedVolumeTypes: []string{"nfs", "cephfs", "hostPath"},
+			},
+			expectReason: `restricted volume types`,
+			expectDetail: `volumes "c", "d" use restricted volume types "hostPath", "rbd"`,
+			expectErrList: field.ErrorList{
+				{Type: field.ErrorTypeForbidden, Field: "spec.volumes[2].hostPath", BadValue: ""},
+				{Type: field.ErrorTypeForbidden, Field: "spec.volumes[3].rbd", BadValue: ""},
+			},
+		},
 	}
 
 	cmpOpts := []cmp.Option{cmpopts.IgnoreFields(field.Error{}, "Detail"), cmpopts.SortSlices(func(a, b *field.Error) bool { return a.Error() < b.Error() })}
@@ -181,3 +202,25 @@ func TestRestrictedVolumes(t *testing.T) {
 		})
 	}
 }
+
+func TestAdditionalAllowedVolumeTypes(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{
+		Volumes: []corev1.Volume{
+			{Name: "a", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+			{Name: "b", VolumeSource: corev1.VolumeSource{NFS: &corev1.NFSVolumeSource{}}},
+		},
+	}}
+	check := CheckRestrictedVolumes().Versions[0].CheckPod
+	if result := check(&pod.ObjectMeta, &pod.Spec); result.Allowed {
+		t.Fatal("expected disallowed")
+	}
+	if result := check(&pod.ObjectMeta, &pod.Spec, WithAdditionalAllowedVolumeTypes("nfs")); !result.Allowed {
+		t.Fatalf("expected allowed, got %s", result.ForbiddenDetail)
+	}
+
+	for _, volumeType := range RestrictedVolumeTypes() {
+		if volumeType == "hostPath" {
+			t.Errorf("hostPath must not be allowed")
+		}
+	}
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/pod-security-admission/api"
+)
+
+/*
+Exec probes and lifecycle hooks whose commands require root, like sudo or package installs, fail at runtime
+in containers running as non-root, e.g. to satisfy the restricted policy, causing restarts or CrashLoopBackOffs
+that are hard to trace back to the security context.
+This check is experimental, optional and not part of the Pod Security Standards.
+
+**Restricted Fields:**
+
+spec.initContainers[*].livenessProbe.exec.command
+spec.initContainers[*].readinessProbe.exec.command
+spec.initContainers[*].startupProbe.exec.command
+spec.initContainers[*].lifecycle.postStart.exec.command
+spec.initContainers[*].lifecycle.preStop.exec.command
+spec.containers[*].livenessProbe.exec.command
+spec.containers[*].readinessProbe.exec.command
+spec.containers[*].startupProbe.exec.command
+spec.containers[*].lifecycle.postStart.exec.command
+spec.containers[*].lifecycle.preStop.exec.command
+(for containers with runAsNonRoot=true, set on the container or inherited from the pod)
+
+**Allowed Values:**
+commands not matching the patterns configured with WithRootExecCommandPatterns,
+or DefaultRootExecCommandPatterns
+*/
+
+func init() {
+	addOptionalCheck(CheckRootExecCommands)
+}
+
+const checkRootExecCommandsID CheckID = "rootExecCommands"
+
+// CheckRootExecCommands returns an optional restricted level check
+// that forbids exec probes and lifecycle hooks requiring root in containers running as non-root in 1.0+
+func CheckRootExecCommands() Check {
+	return Check{
+		ID:       checkRootExecCommandsID,
+		Level:    api.LevelRestricted,
+		SpecOnly: true,
+		Versions: []VersionedCheck{
+			{
+				MinimumVersion: api.MajorMinorVersion(1, 0),
+				CheckPod:       withOptions(rootExecCommandsV1Dot0),
+			},
+		},
+	}
+}
+
+// DefaultRootExecCommandPatterns returns the patterns matched by the rootExecCommands check
+// when WithRootExecCommandPatterns is not set: sudo, su, package installs, mount, iptables and sysctl -w.
+func DefaultRootExecCommandPatterns() []*regexp.Regexp {
+	return []*regexp.Regexp{
+		regexp.MustCompile(`\bsudo\b`),
+		regexp.MustCompile(`(^|[\s;&|])su(\s|$)`),
+		regexp.MustCompile(`\b(apt-get|apt|yum|dnf|apk)\s+(install|add|update|upgrade)\b`),
+		regexp.MustCompile(`\bu?mount\b`),
+		regexp.MustCompile(`\b(iptables|ip6tables)\b`),
+		regexp.MustCompile(`\bsysctl\s+-w\b`),
+	}
+}
+
+// WithRootExecCommandPatterns configures the rootExecCommands check to forbid commands matching any of the given patterns.
+// Patterns are matched against the command of each exec probe and lifecycle hook, joined with spaces.
+func WithRootExecCommandPatterns(patterns ...*regexp.Regexp) Option {
+	return func(opt options) options {
+		opt.rootExecCommandPatterns = patterns
+		return opt
+	}
+}
+
+func rootExecCommandsV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
+	podRunAsNonRoot := podSpec.SecurityContext != nil && podSpec.SecurityContext.RunAsNonRoot != nil && *podSpec.SecurityContext.RunAsNonRoot
+
+	patterns := opts.rootExecCommandPatterns
+	if patterns == nil {
+		patterns = DefaultRootExecCommandPatterns()
+	}
+	badContainers := newViolations(opts)
+	forbiddenHandlers := sets.NewString()
+	visitContainers(podSpec, opts, func(container *corev1.Container, kind ContainerKind, path *field.Path) {
+		runAsNonRoot := podRunAsNonRoot
+		if container.SecurityContext != nil && container.SecurityContext.RunAsNonRoot != nil {
+			runAsNonRoot = *container.SecurityContext.RunAsNonRoot
+		}
+		if !runAsNonRoot {
+			return
+		}
+
+		valid := true
+		var errs field.ErrorList
+		for _, h := range containerExecHandlers(container) {
+			if !matchesAnyPattern(patterns, strings.Join(h.command, " ")) {
+				continue
+			}
+			valid = false
+			forbiddenHandlers.Insert(strings.Join(h.fields, "."))
+			if opts.withFieldErrors {
+				errs = append(errs, withBadValue(forbidden(path.Child(h.fields[0], append(h.fields[1:], "exec", "command")...)), h.command))
+			}
+		}
+		if !valid {
+			if opts.withFieldErrors {
+				badContainers.AddContainer(container.Name, kind, errs...)
+			} else {
+				badContainers.AddContainer(container.Name, kind)
+			}
+		}
+	})
+
+	if badContainers.Empty() {
+		return CheckResult{Allowed: true}
+	}
+	return CheckResult{
+		Allowed:         false,
+		ForbiddenReason: "exec commands requiring root",
+		ForbiddenDetail: fmt.Sprintf(
+			"%s must not run commands requiring root in %s with runAsNonRoot=true",
+			badContainers.DescribeContainers(),
+			strings.Join(forbiddenHandlers.List(), ", "),
+		),
+		ErrList: badContainers.Errs(),
+	}
+}
+
+// execHandler is an exec probe or lifecycle hook of a container.
+type execHandler struct {
+	// fields is the path of the probe or hook in the container, e.g. livenessProbe or lifecycle.preStop.
+	fields  []string
+	command []string
+}
+
+// containerExecHandlers returns the exec probes and lifecycle hooks of the container.
+func containerExecHandlers(container *corev1.Container) []execHandler {
+	var handlers []execHandler
+	probes := []struct {
+		name  string
+		probe *corev1.Probe
+	}{
+		{"livenessProbe", container.LivenessProbe},
+		{"readinessProbe", container.ReadinessProbe},
+		{"startupProbe", container.StartupProbe},
+	}
+	for _, p := range probes {
+		if p.probe != nil && p.probe.Exec != nil {
+			handlers = append(handlers, execHandler{fields: []string{p.name}, command: p.probe.Exec.Command})
+		}
+	}
+	if container.Lifecycle != nil {
+		hooks := []struct {
+			name    string
+			handler *corev1.LifecycleHandler
+		}{
+			{"postStart", container.Lifecycle.PostStart},
+			{"preStop", container.Lifecycle.PreStop},
+		}
+		for _, h := range hooks {
+			if h.handler != nil && h.handler.Exec != nil {
+				handlers = append(handlers, execHandler{fields: []string{"lifecycle", h.name}, command: h.handler.Exec.Command})
+			}
+		}
+	}
+	return handlers
+}
+
+func matchesAnyPattern(patterns []*regexp.Regexp, s string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
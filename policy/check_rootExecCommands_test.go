@@ -0,0 +1,175 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"regexp"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/pointer"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestRootExecCommands(t *testing.T) {
+	execProbe := func(command ...string) *corev1.Probe {
+		return &corev1.Probe{ProbeHandler: corev1.ProbeHandler{Exec: &corev1.ExecAction{Command: command}}}
+	}
+	execHook := func(command ...string) *corev1.LifecycleHandler {
+		return &corev1.LifecycleHandler{Exec: &corev1.ExecAction{Command: command}}
+	}
+	runAsNonRoot := &corev1.PodSecurityContext{RunAsNonRoot: pointer.Bool(true)}
+
+	tests := []struct {
+		name          string
+		pod           *corev1.Pod
+		opts          options
+		allowed       bool
+		expectReason  string
+		expectDetail  string
+		expectErrList field.ErrorList
+	}{
+		{
+			name: "sudo without runAsNonRoot",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "a", LivenessProbe: execProbe("sudo", "cat", "/run/healthy")}},
+			}},
+			allowed: true,
+		},
+		{
+			name: "runAsNonRoot without root commands",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				SecurityContext: runAsNonRoot,
+				Containers: []corev1.Container{{
+					Name:           "a",
+					Command:        []string{"sudo", "true"},
+					LivenessProbe:  execProbe("cat", "/run/healthy"),
+					ReadinessProbe: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/sudo"}}},
+					Lifecycle:      &corev1.Lifecycle{PreStop: execHook("sh", "-c", "summary --upload")},
+				}},
+			}},
+			allowed: true,
+		},
+		{
+			name: "container overrides pod runAsNonRoot",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				SecurityContext: runAsNonRoot,
+				Containers: []corev1.Container{{
+					Name:            "a",
+					SecurityContext: &corev1.SecurityContext{RunAsNonRoot: pointer.Bool(false)},
+					LivenessProbe:   execProbe("sudo", "cat", "/run/healthy"),
+				}},
+			}},
+			allowed: true,
+		},
+		{
+			name: "sudo probe inheriting pod runAsNonRoot",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				SecurityContext: runAsNonRoot,
+				Containers:      []corev1.Container{{Name: "a", LivenessProbe: execProbe("sudo", "cat", "/run/healthy")}},
+			}},
+			expectReason: `exec commands requiring root`,
+			expectDetail: `container "a" must not run commands requiring root in livenessProbe with runAsNonRoot=true`,
+		},
+		{
+			name: "probes and hooks of containers with runAsNonRoot, enable field error list",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{
+					Name:            "a",
+					SecurityContext: &corev1.SecurityContext{RunAsNonRoot: pointer.Bool(true)},
+					StartupProbe:    execProbe("sh", "-c", "mount | grep /data"),
+				}},
+				Containers: []corev1.Container{{
+					Name:            "b",
+					SecurityContext: &corev1.SecurityContext{RunAsNonRoot: pointer.Bool(true)},
+					ReadinessProbe:  execProbe("cat", "/run/ready"),
+					Lifecycle: &corev1.Lifecycle{
+						PostStart: execHook("sh", "-c", "apt-get install -y curl"),
+						PreStop:   execHook("su", "-c", "drain"),
+					},
+				}, {
+					Name:          "c",
+					LivenessProbe: execProbe("sysctl", "-w", "net.core.somaxconn=1024"),
+				}},
+			}},
+			opts: options{
+				withFieldErrors: true,
+			},
+			expectReason: `exec commands requiring root`,
+			expectDetail: `containers "a", "b" must not run commands requiring root in lifecycle.postStart, lifecycle.preStop, startupProbe with runAsNonRoot=true`,
+			expectErrList: field.ErrorList{
+				{Type: field.ErrorTypeForbidden, Field: "spec.initContainers[0].startupProbe.exec.command", BadValue: []string{"sh", "-c", "mount | grep /data"}},
+				{Type: field.ErrorTypeForbidden, Field: "spec.containers[0].lifecycle.postStart.exec.command", BadValue: []string{"sh", "-c", "apt-get install -y curl"}},
+				{Type: field.ErrorTypeForbidden, Field: "spec.containers[0].lifecycle.preStop.exec.command", BadValue: []string{"su", "-c", "drain"}},
+			},
+		},
+		{
+			name: "configured patterns",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				SecurityContext: runAsNonRoot,
+				Containers: []corev1.Container{
+					{Name: "a", LivenessProbe: execProbe("sudo", "true")},
+					{Name: "b", LivenessProbe: execProbe("chown", "-R", "app", "/data")},
+				},
+			}},
+			opts: options{
+				rootExecCommandPatterns: []*regexp.Regexp{regexp.MustCompile(`\bchown\b`)},
+			},
+			expectReason: `exec commands requiring root`,
+			expectDetail: `container "b" must not run commands requiring root in livenessProbe with runAsNonRoot=true`,
+		},
+		{
+			name: "no patterns",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				SecurityContext: runAsNonRoot,
+				Containers:      []corev1.Container{{Name: "a", LivenessProbe: execProbe("sudo", "true")}},
+			}},
+			opts: options{
+				rootExecCommandPatterns: []*regexp.Regexp{},
+			},
+			allowed: true,
+		},
+	}
+
+	cmpOpts := []cmp.Option{cmpopts.IgnoreFields(field.Error{}, "Detail"), cmpopts.SortSlices(func(a, b *field.Error) bool { return a.Error() < b.Error() })}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := rootExecCommandsV1Dot0(&tc.pod.ObjectMeta, &tc.pod.Spec, tc.opts)
+			if !tc.allowed {
+				if result.Allowed {
+					t.Fatal("expected disallowed")
+				}
+				if e, a := tc.expectReason, result.ForbiddenReason; e != a {
+					t.Errorf("expected\n%s\ngot\n%s", e, a)
+				}
+				if e, a := tc.expectDetail, result.ForbiddenDetail; e != a {
+					t.Errorf("expected\n%s\ngot\n%s", e, a)
+				}
+				if result.ErrList != nil {
+					if diff := cmp.Diff(tc.expectErrList, *result.ErrList, cmpOpts...); diff != "" {
+						t.Errorf("unexpected field errors (-want,+got):\n%s", diff)
+					}
+				}
+			} else if !result.Allowed {
+				t.Fatal("expected allowed")
+			}
+		})
+	}
+}
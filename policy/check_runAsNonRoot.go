@@ -45,15 +45,22 @@ func init() {
 }
 
 // CheckRunAsNonRoot returns a restricted level check
-// that requires runAsNonRoot=true in 1.0+
+// that requires runAsNonRoot=true in 1.0+,
+// and allows Windows pods in 1.30+
 func CheckRunAsNonRoot() Check {
 	return Check{
-		ID:    "runAsNonRoot",
-		Level: api.LevelRestricted,
+		ID:       "runAsNonRoot",
+		Level:    api.LevelRestricted,
+		SpecOnly: true,
 		Versions: []VersionedCheck{
 			{
 				MinimumVersion: api.MajorMinorVersion(1, 0),
-				CheckPod:       withOptions(runAsNonRootV1Dot0),
+				CheckPod:       withOptions(linuxOnly(runAsNonRootV1Dot0, false)),
+			},
+			{
+				// Starting 1.30, windows pods would be exempted from this check using pod.spec.os field when set to windows.
+				MinimumVersion: api.MajorMinorVersion(1, 30),
+				CheckPod:       withOptions(runAsNonRootV1Dot30),
 			},
 		},
 	}
@@ -61,12 +68,12 @@ func CheckRunAsNonRoot() Check {
 
 func runAsNonRootV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
 	// See KEP-127: https://github.com/kubernetes/enhancements/blob/308ba8d/keps/sig-node/127-user-namespaces/README.md?plain=1#L411-L447
-	if relaxPolicyForUserNamespacePod(podSpec) {
+	if relaxPolicyForUserNamespacePod(podSpec, opts) {
 		return CheckResult{Allowed: true}
 	}
 
 	// things that explicitly set runAsNonRoot=false
-	badSetters := NewViolations(opts.withFieldErrors)
+	badSetters := newViolations(opts)
 
 	podRunAsNonRoot := false
 	if podSpec.SecurityContext != nil && podSpec.SecurityContext.RunAsNonRoot != nil {
@@ -82,16 +89,16 @@ func runAsNonRootV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec,
 	}
 
 	// containers that explicitly set runAsNonRoot=false
-	explicitlyBadContainers := NewViolations(opts.withFieldErrors)
+	explicitlyBadContainers := newViolations(opts)
 	// containers that didn't set runAsNonRoot and aren't caught by a pod-level runAsNonRoot=true
-	implicitlyBadContainers := NewViolations(opts.withFieldErrors)
+	implicitlyBadContainers := newViolations(opts)
 	var explicitlyErrs field.ErrorList
 
-	visitContainers(podSpec, opts, func(container *corev1.Container, path *field.Path) {
+	visitContainers(podSpec, opts, func(container *corev1.Container, kind ContainerKind, path *field.Path) {
 		if container.SecurityContext != nil && container.SecurityContext.RunAsNonRoot != nil {
 			// container explicitly set runAsNonRoot
 			if !*container.SecurityContext.RunAsNonRoot {
-				explicitlyBadContainers.Add(container.Name)
+				explicitlyBadContainers.AddContainer(container.Name, kind)
 				if opts.withFieldErrors {
 					explicitlyErrs = append(explicitlyErrs, withBadValue(forbidden(path.Child("securityContext", "runAsNonRoot")), false))
 				}
@@ -101,9 +108,9 @@ func runAsNonRootV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec,
 			if !podRunAsNonRoot {
 				// no pod-level runAsNonRoot=true, so this container implicitly has a bad value
 				if opts.withFieldErrors {
-					implicitlyBadContainers.Add(container.Name, required(path.Child("securityContext", "runAsNonRoot")))
+					implicitlyBadContainers.AddContainer(container.Name, kind, required(path.Child("securityContext", "runAsNonRoot")))
 				} else {
-					implicitlyBadContainers.Add(container.Name)
+					implicitlyBadContainers.AddContainer(container.Name, kind)
 				}
 			}
 		}
@@ -111,11 +118,7 @@ func runAsNonRootV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec,
 
 	if !explicitlyBadContainers.Empty() {
 		badSetters.Add(
-			fmt.Sprintf(
-				"%s %s",
-				pluralize("container", "containers", explicitlyBadContainers.Len()),
-				joinQuote(explicitlyBadContainers.Data()),
-			),
+			explicitlyBadContainers.DescribeContainers(),
 			explicitlyErrs...,
 		)
 	}
@@ -135,9 +138,8 @@ func runAsNonRootV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec,
 			Allowed:         false,
 			ForbiddenReason: "runAsNonRoot != true",
 			ForbiddenDetail: fmt.Sprintf(
-				"pod or %s %s must set securityContext.runAsNonRoot=true",
-				pluralize("container", "containers", implicitlyBadContainers.Len()),
-				joinQuote(implicitlyBadContainers.Data()),
+				"pod or %s must set securityContext.runAsNonRoot=true",
+				implicitlyBadContainers.DescribeContainers(),
 			),
 			ErrList: implicitlyBadContainers.Errs(),
 		}
@@ -145,3 +147,8 @@ func runAsNonRootV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec,
 
 	return CheckResult{Allowed: true}
 }
+
+// runAsNonRootV1Dot30 exempts pods with spec.os.name=windows (see KEP-2802: https://github.com/kubernetes/enhancements/tree/master/keps/sig-windows/2802-identify-windows-pods-apiserver-admission)
+func runAsNonRootV1Dot30(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
+	return linuxOnly(runAsNonRootV1Dot0, true)(podMetadata, podSpec, opts)
+}
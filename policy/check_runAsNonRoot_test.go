@@ -220,10 +220,11 @@ func TestRunAsNonRoot(t *testing.T) {
 	cmpOpts := []cmp.Option{cmpopts.IgnoreFields(field.Error{}, "Detail"), cmpopts.SortSlices(func(a, b *field.Error) bool { return a.Error() < b.Error() })}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
+			opts := tc.opts
 			if tc.enableUserNamespacesPodSecurityStandards {
-				RelaxPolicyForUserNamespacePods(true)
+				opts = WithRelaxedUserNamespacePods(true)(opts)
 			}
-			result := runAsNonRootV1Dot0(&tc.pod.ObjectMeta, &tc.pod.Spec, tc.opts)
+			result := runAsNonRootV1Dot0(&tc.pod.ObjectMeta, &tc.pod.Spec, opts)
 			if result.Allowed && !tc.allowed {
 				t.Fatal("expected disallowed")
 			}
@@ -241,3 +242,70 @@ func TestRunAsNonRoot(t *testing.T) {
 		})
 	}
 }
+
+func TestRunAsNonRoot_1_30(t *testing.T) {
+	tests := []struct {
+		name         string
+		pod          *corev1.Pod
+		opts         options
+		expectReason string
+		allowed      bool
+	}{
+		{
+			name: "windows pod, admit without checking runAsNonRoot",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				OS:         &corev1.PodOS{Name: corev1.Windows},
+				Containers: []corev1.Container{{Name: "a"}},
+			}},
+			allowed: true,
+		},
+		{
+			name: "windows pod, admit without checking runAsNonRoot, enable field error list",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				OS:         &corev1.PodOS{Name: corev1.Windows},
+				Containers: []corev1.Container{{Name: "a"}},
+			}},
+			opts:    options{withFieldErrors: true},
+			allowed: true,
+		},
+		{
+			name: "windows pod, enforce mode",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				OS:         &corev1.PodOS{Name: corev1.Windows},
+				Containers: []corev1.Container{{Name: "a"}},
+			}},
+			opts:         options{windowsPodMode: WindowsPodModeEnforce},
+			expectReason: `runAsNonRoot != true`,
+			allowed:      false,
+		},
+		{
+			name: "linux pod, reject if runAsNonRoot is not set",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				OS:         &corev1.PodOS{Name: corev1.Linux},
+				Containers: []corev1.Container{{Name: "a"}},
+			}},
+			expectReason: `runAsNonRoot != true`,
+			allowed:      false,
+		},
+		{
+			name: "pod without os, reject if runAsNonRoot is not set",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "a"}},
+			}},
+			expectReason: `runAsNonRoot != true`,
+			allowed:      false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := runAsNonRootV1Dot30(&tc.pod.ObjectMeta, &tc.pod.Spec, tc.opts)
+			if result.Allowed != tc.allowed {
+				t.Fatalf("expected allowed=%v, got %v", tc.allowed, result.Allowed)
+			}
+			if e, a := tc.expectReason, result.ForbiddenReason; e != a {
+				t.Errorf("expected\n%s\ngot\n%s", e, a)
+			}
+		})
+	}
+}
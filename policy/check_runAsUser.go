@@ -49,8 +49,9 @@ func init() {
 // that forbides runAsUser=0 in 1.23+
 func CheckRunAsUser() Check {
 	return Check{
-		ID:    "runAsUser",
-		Level: api.LevelRestricted,
+		ID:       "runAsUser",
+		Level:    api.LevelRestricted,
+		SpecOnly: true,
 		Versions: []VersionedCheck{
 			{
 				MinimumVersion: api.MajorMinorVersion(1, 23),
@@ -62,12 +63,12 @@ func CheckRunAsUser() Check {
 
 func runAsUserV1Dot23(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
 	// See KEP-127: https://github.com/kubernetes/enhancements/blob/308ba8d/keps/sig-node/127-user-namespaces/README.md?plain=1#L411-L447
-	if relaxPolicyForUserNamespacePod(podSpec) {
+	if relaxPolicyForUserNamespacePod(podSpec, opts) {
 		return CheckResult{Allowed: true}
 	}
 
 	// things that explicitly set runAsUser=0
-	badSetters := NewViolations(opts.withFieldErrors)
+	badSetters := newViolations(opts)
 
 	if podSpec.SecurityContext != nil && podSpec.SecurityContext.RunAsUser != nil && *podSpec.SecurityContext.RunAsUser == 0 {
 		if opts.withFieldErrors {
@@ -78,12 +79,12 @@ func runAsUserV1Dot23(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, o
 	}
 
 	// containers that explicitly set runAsUser=0
-	explicitlyBadContainers := NewViolations(opts.withFieldErrors)
+	explicitlyBadContainers := newViolations(opts)
 	var explicitlyErrs field.ErrorList
 
-	visitContainers(podSpec, opts, func(container *corev1.Container, path *field.Path) {
+	visitContainers(podSpec, opts, func(container *corev1.Container, kind ContainerKind, path *field.Path) {
 		if container.SecurityContext != nil && container.SecurityContext.RunAsUser != nil && *container.SecurityContext.RunAsUser == 0 {
-			explicitlyBadContainers.Add(container.Name)
+			explicitlyBadContainers.AddContainer(container.Name, kind)
 			if opts.withFieldErrors {
 				explicitlyErrs = append(explicitlyErrs, withBadValue(forbidden(path.Child("securityContext", "runAsUser")), 0))
 			}
@@ -92,11 +93,7 @@ func runAsUserV1Dot23(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, o
 
 	if !explicitlyBadContainers.Empty() {
 		badSetters.Add(
-			fmt.Sprintf(
-				"%s %s",
-				pluralize("container", "containers", explicitlyBadContainers.Len()),
-				joinQuote(explicitlyBadContainers.Data()),
-			),
+			explicitlyBadContainers.DescribeContainers(),
 			explicitlyErrs...,
 		)
 	}
@@ -234,10 +234,11 @@ func TestRunAsUser(t *testing.T) {
 	cmpOpts := []cmp.Option{cmpopts.IgnoreFields(field.Error{}, "Detail"), cmpopts.SortSlices(func(a, b *field.Error) bool { return a.Error() < b.Error() })}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
+			opts := tc.opts
 			if tc.enableUserNamespacesPodSecurityStandards {
-				RelaxPolicyForUserNamespacePods(true)
+				opts = WithRelaxedUserNamespacePods(true)(opts)
 			}
-			result := runAsUserV1Dot23(&tc.pod.ObjectMeta, &tc.pod.Spec, tc.opts)
+			result := runAsUserV1Dot23(&tc.pod.ObjectMeta, &tc.pod.Spec, opts)
 			if tc.expectAllow {
 				if !result.Allowed {
 					t.Fatalf("expected to be allowed, disallowed: %s, %s", result.ForbiddenReason, result.ForbiddenDetail)
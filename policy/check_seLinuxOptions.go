@@ -60,8 +60,9 @@ func init() {
 // that limits seLinuxOptions type, user, and role values in 1.0+
 func CheckSELinuxOptions() Check {
 	return Check{
-		ID:    "seLinuxOptions",
-		Level: api.LevelBaseline,
+		ID:       "seLinuxOptions",
+		Level:    api.LevelBaseline,
+		SpecOnly: true,
 		Versions: []VersionedCheck{
 			{
 				MinimumVersion: api.MajorMinorVersion(1, 0),
@@ -78,7 +79,7 @@ var (
 func seLinuxOptionsV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
 	var (
 		// sources that set bad seLinuxOptions
-		badSetters        = NewViolations(opts.withFieldErrors)
+		badSetters        = newViolations(opts)
 		badContainersErrs field.ErrorList
 		badPodErrs        field.ErrorList
 		// invalid type values set
@@ -127,24 +128,20 @@ func seLinuxOptionsV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpe
 		}
 	}
 
-	var badContainers []string
-	visitContainers(podSpec, opts, func(container *corev1.Container, path *field.Path) {
+	var badContainers, badSidecars []string
+	visitContainers(podSpec, opts, func(container *corev1.Container, kind ContainerKind, path *field.Path) {
 		if container.SecurityContext != nil && container.SecurityContext.SELinuxOptions != nil {
 			if !validSELinuxOptions(container.SecurityContext.SELinuxOptions, path, false) {
 				badContainers = append(badContainers, container.Name)
+				if kind == ContainerKindSidecar {
+					badSidecars = append(badSidecars, container.Name)
+				}
 			}
 		}
 	})
 
 	if len(badContainers) > 0 {
-		badSetters.Add(
-			fmt.Sprintf(
-				"%s %s",
-				pluralize("container", "containers", len(badContainers)),
-				joinQuote(badContainers),
-			),
-			badContainersErrs...,
-		)
+		badSetters.Add(describeContainers(badContainers, badSidecars), badContainersErrs...)
 	}
 
 	if !badSetters.Empty() {
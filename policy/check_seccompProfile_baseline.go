@@ -88,7 +88,7 @@ func validSeccompAnnotationValue(v string) bool {
 // seccompProfileBaselineV1Dot0 checks baseline policy on seccomp alpha annotation
 func seccompProfileBaselineV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
 	m := map[string]field.ErrorList{}
-	badSetters := NewViolations(opts.withFieldErrors)
+	badSetters := newViolations(opts)
 
 	if val, ok := podMetadata.Annotations[annotationKeyPod]; ok {
 		if !validSeccompAnnotationValue(val) {
@@ -97,7 +97,7 @@ func seccompProfileBaselineV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev
 		}
 	}
 
-	visitContainers(podSpec, opts, func(c *corev1.Container, path *field.Path) {
+	visitContainers(podSpec, opts, func(c *corev1.Container, _ ContainerKind, path *field.Path) {
 		annotation := annotationKeyContainerPrefix + c.Name
 		if val, ok := podMetadata.Annotations[annotation]; ok {
 			if !validSeccompAnnotationValue(val) {
@@ -132,7 +132,7 @@ func seccompProfileBaselineV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev
 // seccompProfileBaselineV1Dot19 checks baseline policy on securityContext.seccompProfile field
 func seccompProfileBaselineV1Dot19(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
 	// things that explicitly set seccompProfile.type to a bad value
-	badSetters := NewViolations(opts.withFieldErrors)
+	badSetters := newViolations(opts)
 	badValues := sets.NewString()
 
 	if podSpec.SecurityContext != nil && podSpec.SecurityContext.SeccompProfile != nil {
@@ -147,15 +147,15 @@ func seccompProfileBaselineV1Dot19(podMetadata *metav1.ObjectMeta, podSpec *core
 	}
 
 	// containers that explicitly set seccompProfile.type to a bad value
-	explicitlyBadContainers := NewViolations(opts.withFieldErrors)
+	explicitlyBadContainers := newViolations(opts)
 	var explicitlyErrs field.ErrorList
 
-	visitContainers(podSpec, opts, func(c *corev1.Container, path *field.Path) {
+	visitContainers(podSpec, opts, func(c *corev1.Container, kind ContainerKind, path *field.Path) {
 		if c.SecurityContext != nil && c.SecurityContext.SeccompProfile != nil {
 			// container explicitly set seccompProfile
 			if !validSeccomp(c.SecurityContext.SeccompProfile.Type) {
 				// container explicitly set seccompProfile to a bad value
-				explicitlyBadContainers.Add(c.Name)
+				explicitlyBadContainers.AddContainer(c.Name, kind)
 				explicitlyErrs = append(explicitlyErrs, withBadValue(forbidden(path.Child("securityContext", "seccompProfile", "type")), string(c.SecurityContext.SeccompProfile.Type)))
 				badValues.Insert(string(c.SecurityContext.SeccompProfile.Type))
 			}
@@ -164,11 +164,7 @@ func seccompProfileBaselineV1Dot19(podMetadata *metav1.ObjectMeta, podSpec *core
 
 	if !explicitlyBadContainers.Empty() {
 		badSetters.Add(
-			fmt.Sprintf(
-				"%s %s",
-				pluralize("container", "containers", explicitlyBadContainers.Len()),
-				joinQuote(explicitlyBadContainers.Data()),
-			),
+			explicitlyBadContainers.DescribeContainers(),
 			explicitlyErrs...,
 		)
 	}
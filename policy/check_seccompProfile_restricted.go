@@ -48,12 +48,13 @@ func init() {
 
 func CheckSeccompProfileRestricted() Check {
 	return Check{
-		ID:    "seccompProfile_restricted",
-		Level: api.LevelRestricted,
+		ID:       "seccompProfile_restricted",
+		Level:    api.LevelRestricted,
+		SpecOnly: true,
 		Versions: []VersionedCheck{
 			{
 				MinimumVersion:   api.MajorMinorVersion(1, 19),
-				CheckPod:         withOptions(seccompProfileRestrictedV1Dot19),
+				CheckPod:         withOptions(linuxOnly(seccompProfileRestrictedV1Dot19, false)),
 				OverrideCheckIDs: []CheckID{checkSeccompBaselineID},
 			},
 			// Starting 1.25, windows pods would be exempted from this check using pod.spec.os field when set to windows.
@@ -69,10 +70,12 @@ func CheckSeccompProfileRestricted() Check {
 // seccompProfileRestrictedV1Dot19 checks restricted policy on securityContext.seccompProfile field
 func seccompProfileRestrictedV1Dot19(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
 	// things that explicitly set seccompProfile.type to a bad value
-	badSetters := NewViolations(opts.withFieldErrors)
+	badSetters := newViolations(opts)
 	badValues := sets.NewString()
 
 	podSeccompSet := false
+	// the default seccompProfile.type of the RuntimeClass, applying to containers if the pod doesn't set seccompProfile
+	var runtimeClassSeccomp corev1.SeccompProfileType
 
 	if podSpec.SecurityContext != nil && podSpec.SecurityContext.SeccompProfile != nil {
 		if !validSeccomp(podSpec.SecurityContext.SeccompProfile.Type) {
@@ -85,20 +88,24 @@ func seccompProfileRestrictedV1Dot19(podMetadata *metav1.ObjectMeta, podSpec *co
 		} else {
 			podSeccompSet = true
 		}
+	} else if t := runtimeClassSeccompProfileType(podSpec, opts); validSeccomp(t) {
+		runtimeClassSeccomp = t
 	}
 
 	// containers that explicitly set seccompProfile.type to a bad value
-	explicitlyBadContainers := NewViolations(opts.withFieldErrors)
+	explicitlyBadContainers := newViolations(opts)
 	// containers that didn't set seccompProfile and aren't caught by a pod-level seccompProfile
-	implicitlyBadContainers := NewViolations(opts.withFieldErrors)
+	implicitlyBadContainers := newViolations(opts)
+	// containers that didn't set seccompProfile and rely on the default of the RuntimeClass
+	var runtimeClassContainers []string
 	var explicitlyErrs field.ErrorList
 
-	visitContainers(podSpec, opts, func(c *corev1.Container, path *field.Path) {
+	visitContainers(podSpec, opts, func(c *corev1.Container, kind ContainerKind, path *field.Path) {
 		if c.SecurityContext != nil && c.SecurityContext.SeccompProfile != nil {
 			// container explicitly set seccompProfile
 			if !validSeccomp(c.SecurityContext.SeccompProfile.Type) {
 				// container explicitly set seccompProfile to a bad value
-				explicitlyBadContainers.Add(c.Name)
+				explicitlyBadContainers.AddContainer(c.Name, kind)
 				if opts.withFieldErrors {
 					explicitlyErrs = append(explicitlyErrs, withBadValue(forbidden(path.Child("securityContext", "seccompProfile", "type")), string(c.SecurityContext.SeccompProfile.Type)))
 				}
@@ -106,12 +113,15 @@ func seccompProfileRestrictedV1Dot19(podMetadata *metav1.ObjectMeta, podSpec *co
 			}
 		} else {
 			// container did not explicitly set seccompProfile
-			if !podSeccompSet {
+			if runtimeClassSeccomp != "" {
+				// the RuntimeClass applies a valid seccompProfile to this container
+				runtimeClassContainers = append(runtimeClassContainers, c.Name)
+			} else if !podSeccompSet {
 				// no valid pod-level seccompProfile, so this container implicitly has a bad value
 				if opts.withFieldErrors {
-					implicitlyBadContainers.Add(c.Name, required(path.Child("securityContext", "seccompProfile", "type")))
+					implicitlyBadContainers.AddContainer(c.Name, kind, required(path.Child("securityContext", "seccompProfile", "type")))
 				} else {
-					implicitlyBadContainers.Add(c.Name)
+					implicitlyBadContainers.AddContainer(c.Name, kind)
 				}
 			}
 		}
@@ -119,11 +129,7 @@ func seccompProfileRestrictedV1Dot19(podMetadata *metav1.ObjectMeta, podSpec *co
 
 	if !explicitlyBadContainers.Empty() {
 		badSetters.Add(
-			fmt.Sprintf(
-				"%s %s",
-				pluralize("container", "containers", explicitlyBadContainers.Len()),
-				joinQuote(explicitlyBadContainers.Data()),
-			),
+			explicitlyBadContainers.DescribeContainers(),
 			explicitlyErrs...,
 		)
 	}
@@ -147,14 +153,22 @@ func seccompProfileRestrictedV1Dot19(podMetadata *metav1.ObjectMeta, podSpec *co
 			Allowed:         false,
 			ForbiddenReason: "seccompProfile",
 			ForbiddenDetail: fmt.Sprintf(
-				`pod or %s %s must set securityContext.seccompProfile.type to "RuntimeDefault" or "Localhost"`,
-				pluralize("container", "containers", implicitlyBadContainers.Len()),
-				joinQuote(implicitlyBadContainers.Data()),
+				`pod or %s must set securityContext.seccompProfile.type to "RuntimeDefault" or "Localhost"`,
+				implicitlyBadContainers.DescribeContainers(),
 			),
 			ErrList: implicitlyBadContainers.Errs(),
 		}
 	}
 
+	if len(runtimeClassContainers) > 0 {
+		return CheckResult{Allowed: true, Resolutions: []ProfileResolution{{
+			RuntimeClassName: *podSpec.RuntimeClassName,
+			Profile:          "seccomp",
+			Type:             string(runtimeClassSeccomp),
+			Containers:       runtimeClassContainers,
+		}}}
+	}
+
 	return CheckResult{Allowed: true}
 }
 
@@ -163,8 +177,5 @@ func seccompProfileRestrictedV1Dot19(podMetadata *metav1.ObjectMeta, podSpec *co
 func seccompProfileRestrictedV1Dot25(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
 	// Pod API validation would have failed if podOS == Windows and if secCompProfile has been set.
 	// We can admit the Windows pod even if seccompProfile has not been set.
-	if podSpec.OS != nil && podSpec.OS.Name == corev1.Windows {
-		return CheckResult{Allowed: true}
-	}
-	return seccompProfileRestrictedV1Dot19(podMetadata, podSpec, opts)
+	return linuxOnly(seccompProfileRestrictedV1Dot19, true)(podMetadata, podSpec, opts)
 }
@@ -397,3 +397,111 @@ func TestSeccompProfileRestricted_1_19(t *testing.T) {
 		})
 	}
 }
+
+// testRuntimeClassDefaults holds the defaults of RuntimeClasses by name.
+type testRuntimeClassDefaults map[string]RuntimeClassDefaults
+
+func (d testRuntimeClassDefaults) RuntimeClassDefaults(runtimeClassName string) (RuntimeClassDefaults, bool) {
+	defaults, ok := d[runtimeClassName]
+	return defaults, ok
+}
+
+func TestSeccompProfileRestrictedRuntimeClassDefaults(t *testing.T) {
+	resolver := testRuntimeClassDefaults{
+		"runc":       {SeccompProfileType: corev1.SeccompProfileTypeRuntimeDefault},
+		"unconfined": {SeccompProfileType: corev1.SeccompProfileTypeUnconfined},
+	}
+	runtimeClass := func(name string) *string { return &name }
+
+	tests := []struct {
+		name              string
+		pod               *corev1.Pod
+		noResolver        bool
+		expectDetail      string
+		expectResolutions []ProfileResolution
+	}{
+		{
+			name: "runtime default",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				RuntimeClassName: runtimeClass("runc"),
+				Containers: []corev1.Container{
+					{Name: "a"},
+					{Name: "b", SecurityContext: &corev1.SecurityContext{SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeLocalhost}}},
+				},
+			}},
+			expectResolutions: []ProfileResolution{{RuntimeClassName: "runc", Profile: "seccomp", Type: "RuntimeDefault", Containers: []string{"a"}}},
+		},
+		{
+			name: "no resolver",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				RuntimeClassName: runtimeClass("runc"),
+				Containers:       []corev1.Container{{Name: "a"}},
+			}},
+			noResolver:   true,
+			expectDetail: `pod or container "a" must set securityContext.seccompProfile.type to "RuntimeDefault" or "Localhost"`,
+		},
+		{
+			name: "unknown runtime class",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				RuntimeClassName: runtimeClass("kata"),
+				Containers:       []corev1.Container{{Name: "a"}},
+			}},
+			expectDetail: `pod or container "a" must set securityContext.seccompProfile.type to "RuntimeDefault" or "Localhost"`,
+		},
+		{
+			name: "unconfined default",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				RuntimeClassName: runtimeClass("unconfined"),
+				Containers:       []corev1.Container{{Name: "a"}},
+			}},
+			expectDetail: `pod or container "a" must set securityContext.seccompProfile.type to "RuntimeDefault" or "Localhost"`,
+		},
+		{
+			name: "explicit bad container",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				RuntimeClassName: runtimeClass("runc"),
+				Containers: []corev1.Container{
+					{Name: "a"},
+					{Name: "b", SecurityContext: &corev1.SecurityContext{SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined}}},
+				},
+			}},
+			expectDetail: `container "b" must not set securityContext.seccompProfile.type to "Unconfined"`,
+		},
+		{
+			name: "pod-level profile",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				RuntimeClassName: runtimeClass("runc"),
+				SecurityContext:  &corev1.PodSecurityContext{SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}},
+				Containers:       []corev1.Container{{Name: "a"}},
+			}},
+		},
+		{
+			name: "bad pod-level profile",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				RuntimeClassName: runtimeClass("runc"),
+				SecurityContext:  &corev1.PodSecurityContext{SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined}},
+				Containers:       []corev1.Container{{Name: "a"}},
+			}},
+			expectDetail: `pod must not set securityContext.seccompProfile.type to "Unconfined"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := options{}
+			if !tc.noResolver {
+				opts.runtimeClassDefaultsResolver = resolver
+			}
+			result := seccompProfileRestrictedV1Dot25(&tc.pod.ObjectMeta, &tc.pod.Spec, opts)
+			if e, a := tc.expectDetail == "", result.Allowed; e != a {
+				t.Fatalf("expected allowed=%v, got %v", e, a)
+			}
+			if e, a := tc.expectDetail, result.ForbiddenDetail; e != a {
+				t.Errorf("expected\n%s\ngot\n%s", e, a)
+			}
+			if diff := cmp.Diff(tc.expectResolutions, result.Resolutions); diff != "" {
+				t.Errorf("unexpected resolutions (-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/pod-security-admission/api"
+)
+
+/*
+Membership in the root group (GID 0) undermines runAsNonRoot,
+and should be forbidden. This check is experimental and not part of the Pod Security Standards,
+and is evaluated once assigned to policy versions with AssignExperimentalCheck.
+
+**Restricted Fields:**
+
+spec.securityContext.supplementalGroups[*]
+spec.securityContext.fsGroup
+
+**Allowed Values:**
+non-zero values
+undefined/null
+
+**Restricted Fields:**
+
+spec.securityContext.supplementalGroupsPolicy
+
+**Allowed Values:**
+any value, or the value configured with WithRequiredSupplementalGroupsPolicy
+(only evaluated when the SupplementalGroupsPolicy feature is enabled, and the field exists in the vendored k8s.io/api)
+
+**Restricted Fields:**
+
+spec.securityContext.fsGroupChangePolicy
+
+**Allowed Values:**
+any value, or the values configured with WithAllowedFSGroupChangePolicies
+*/
+
+func init() {
+	addCheck(CheckSupplementalGroups)
+}
+
+const checkSupplementalGroupsID CheckID = "supplementalGroups"
+
+// CheckSupplementalGroups returns an experimental restricted level check
+// that forbids membership in the root group
+func CheckSupplementalGroups() Check {
+	return Check{
+		ID:       checkSupplementalGroupsID,
+		Level:    api.LevelRestricted,
+		SpecOnly: true,
+		Versions: []VersionedCheck{
+			{
+				// not yet assigned to a policy version, see AssignExperimentalCheck
+				CheckPod: withOptions(supplementalGroupsExperimental),
+			},
+		},
+	}
+}
+
+// WithRequiredSupplementalGroupsPolicy configures the supplementalGroups check to require
+// the given securityContext.supplementalGroupsPolicy (e.g. "Strict") when the SupplementalGroupsPolicy feature is enabled.
+// The policy is not enforced if the field does not exist in the vendored k8s.io/api, since no pod could comply.
+func WithRequiredSupplementalGroupsPolicy(policy string) Option {
+	return func(opt options) options {
+		opt.requiredSupplementalGroupsPolicy = policy
+		return opt
+	}
+}
+
+// WithAllowedFSGroupChangePolicies configures the supplementalGroups check to only allow
+// the given securityContext.fsGroupChangePolicy values. Unset is always allowed.
+func WithAllowedFSGroupChangePolicies(policies ...corev1.PodFSGroupChangePolicy) Option {
+	return func(opt options) options {
+		opt.allowedFSGroupChangePolicies = policies
+		return opt
+	}
+}
+
+func supplementalGroupsExperimental(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
+	sc := podSpec.SecurityContext
+	if sc == nil {
+		sc = &corev1.PodSecurityContext{}
+	}
+
+	badSetters := newViolations(opts)
+
+	var rootGroupErrs []*field.Error
+	for i, group := range sc.SupplementalGroups {
+		if group == 0 {
+			rootGroupErrs = append(rootGroupErrs, withBadValue(forbidden(supplementalGroupsPath.Index(i)), int64(0)))
+		}
+	}
+	if len(rootGroupErrs) > 0 {
+		badSetters.Add("securityContext.supplementalGroups must not include 0", rootGroupErrs...)
+	}
+
+	if sc.FSGroup != nil && *sc.FSGroup == 0 {
+		badSetters.Add("securityContext.fsGroup=0", withBadValue(forbidden(fsGroupPath), int64(0)))
+	}
+
+	if opts.requiredSupplementalGroupsPolicy != "" && opts.featureEnabled(SupplementalGroupsPolicy) {
+		if policy, set, supported := podSupplementalGroupsPolicy(podSpec); !supported {
+			// pods cannot set the field, and are not denied for lacking it
+		} else if !set {
+			badSetters.Add(fmt.Sprintf("securityContext.supplementalGroupsPolicy must be %q", opts.requiredSupplementalGroupsPolicy), required(supplementalGroupsPolicyPath))
+		} else if policy != opts.requiredSupplementalGroupsPolicy {
+			badSetters.Add(fmt.Sprintf("securityContext.supplementalGroupsPolicy=%q", policy), withBadValue(forbidden(supplementalGroupsPolicyPath), policy))
+		}
+	}
+
+	if len(opts.allowedFSGroupChangePolicies) > 0 && sc.FSGroupChangePolicy != nil {
+		allowed := sets.New[corev1.PodFSGroupChangePolicy](opts.allowedFSGroupChangePolicies...)
+		if !allowed.Has(*sc.FSGroupChangePolicy) {
+			badSetters.Add(fmt.Sprintf("securityContext.fsGroupChangePolicy=%q", string(*sc.FSGroupChangePolicy)), withBadValue(forbidden(fsGroupChangePolicyPath), string(*sc.FSGroupChangePolicy)))
+		}
+	}
+
+	return supplementalGroupsResult(badSetters)
+}
+
+func supplementalGroupsResult(badSetters Violations) CheckResult {
+	if badSetters.Empty() {
+		return CheckResult{Allowed: true}
+	}
+	return CheckResult{
+		Allowed:         false,
+		ForbiddenReason: "root group",
+		ForbiddenDetail: "pod " + strings.Join(badSetters.Data(), " and "),
+		ErrList:         badSetters.Errs(),
+	}
+}
+
+// podSupplementalGroupsPolicy returns the value of securityContext.supplementalGroupsPolicy, whether it is set,
+// and whether the field exists in the compiled PodSecurityContext type. It is a variable so tests can
+// evaluate pods setting the field with a vendored k8s.io/api that does not have it yet.
+var podSupplementalGroupsPolicy = func(podSpec *corev1.PodSpec) (policy string, set, supported bool) {
+	if !podSecurityContextHasField("SupplementalGroupsPolicy") {
+		return "", false, false
+	}
+	f, ok := podSecurityContextField(podSpec, "SupplementalGroupsPolicy")
+	if !ok || f.Kind() != reflect.Ptr || f.Elem().Kind() != reflect.String {
+		return "", false, true
+	}
+	return f.Elem().String(), true, true
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/component-base/featuregate"
+	"k8s.io/utils/pointer"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestSupplementalGroups(t *testing.T) {
+	onRootMismatch := corev1.FSGroupChangeOnRootMismatch
+	always := corev1.FSGroupChangeAlways
+
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		opts options
+		// supplementalGroupsPolicy simulates a vendored k8s.io/api with securityContext.supplementalGroupsPolicy,
+		// set to the given value if not empty.
+		supplementalGroupsPolicy *string
+		allowed                  bool
+		expectReason             string
+		expectDetail             string
+		expectErrList            field.ErrorList
+	}{
+		{
+			name:    "no securityContext",
+			pod:     &corev1.Pod{Spec: corev1.PodSpec{}},
+			allowed: true,
+		},
+		{
+			name: "non-root groups",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{
+					SupplementalGroups: []int64{1000, 2000},
+					FSGroup:            pointer.Int64(1000),
+				},
+			}},
+			allowed: true,
+		},
+		{
+			name: "root supplemental group",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{
+					SupplementalGroups: []int64{1000, 0},
+				},
+			}},
+			allowed:      false,
+			expectReason: `root group`,
+			expectDetail: `pod securityContext.supplementalGroups must not include 0`,
+		},
+		{
+			name: "root supplemental group and fsGroup, enable field error list",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{
+					SupplementalGroups: []int64{0, 1000, 0},
+					FSGroup:            pointer.Int64(0),
+				},
+			}},
+			opts: options{
+				withFieldErrors: true,
+			},
+			allowed:      false,
+			expectReason: `root group`,
+			expectDetail: `pod securityContext.supplementalGroups must not include 0 and securityContext.fsGroup=0`,
+			expectErrList: field.ErrorList{
+				{Type: field.ErrorTypeForbidden, Field: "spec.securityContext.supplementalGroups[0]", BadValue: int64(0)},
+				{Type: field.ErrorTypeForbidden, Field: "spec.securityContext.supplementalGroups[2]", BadValue: int64(0)},
+				{Type: field.ErrorTypeForbidden, Field: "spec.securityContext.fsGroup", BadValue: int64(0)},
+			},
+		},
+		{
+			name: "fsGroupChangePolicy unrestricted by default",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{
+					FSGroupChangePolicy: &always,
+				},
+			}},
+			allowed: true,
+		},
+		{
+			name: "fsGroupChangePolicy allowed",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{
+					FSGroupChangePolicy: &onRootMismatch,
+				},
+			}},
+			opts: options{
+				allowedFSGroupChangePolicies: []corev1.PodFSGroupChangePolicy{corev1.FSGroupChangeOnRootMismatch},
+			},
+			allowed: true,
+		},
+		{
+			name: "fsGroupChangePolicy forbidden, enable field error list",
+			pod: &corev1.Pod{Spec: corev1.PodSpec{
+				SecurityContext: &corev1.PodSecurityContext{
+					FSGroupChangePolicy: &always,
+				},
+			}},
+			opts: options{
+				withFieldErrors:              true,
+				allowedFSGroupChangePolicies: []corev1.PodFSGroupChangePolicy{corev1.FSGroupChangeOnRootMismatch},
+			},
+			allowed:      false,
+			expectReason: `root group`,
+			expectDetail: `pod securityContext.fsGroupChangePolicy="Always"`,
+			expectErrList: field.ErrorList{
+				{Type: field.ErrorTypeForbidden, Field: "spec.securityContext.fsGroupChangePolicy", BadValue: "Always"},
+			},
+		},
+		{
+			name: "required supplementalGroupsPolicy ignored with feature disabled",
+			pod:  &corev1.Pod{Spec: corev1.PodSpec{}},
+			opts: options{
+				requiredSupplementalGroupsPolicy: "Strict",
+			},
+			allowed: true,
+		},
+		{
+			name: "required supplementalGroupsPolicy ignored without the field",
+			pod:  &corev1.Pod{Spec: corev1.PodSpec{}},
+			opts: options{
+				features:                         map[featuregate.Feature]bool{SupplementalGroupsPolicy: true},
+				requiredSupplementalGroupsPolicy: "Strict",
+			},
+			allowed: true,
+		},
+		{
+			name: "required supplementalGroupsPolicy set",
+			pod:  &corev1.Pod{Spec: corev1.PodSpec{}},
+			opts: options{
+				features:                         map[featuregate.Feature]bool{SupplementalGroupsPolicy: true},
+				requiredSupplementalGroupsPolicy: "Strict",
+			},
+			supplementalGroupsPolicy: pointer.String("Strict"),
+			allowed:                  true,
+		},
+		{
+			name: "other supplementalGroupsPolicy, enable field error list",
+			pod:  &corev1.Pod{Spec: corev1.PodSpec{}},
+			opts: options{
+				withFieldErrors:                  true,
+				features:                         map[featuregate.Feature]bool{SupplementalGroupsPolicy: true},
+				requiredSupplementalGroupsPolicy: "Strict",
+			},
+			supplementalGroupsPolicy: pointer.String("Merge"),
+			allowed:                  false,
+			expectReason:             `root group`,
+			expectDetail:             `pod securityContext.supplementalGroupsPolicy="Merge"`,
+			expectErrList: field.ErrorList{
+				{Type: field.ErrorTypeForbidden, Field: "spec.securityContext.supplementalGroupsPolicy", BadValue: "Merge"},
+			},
+		},
+		{
+			name: "required supplementalGroupsPolicy unset, enable field error list",
+			pod:  &corev1.Pod{Spec: corev1.PodSpec{}},
+			opts: options{
+				withFieldErrors:                  true,
+				features:                         map[featuregate.Feature]bool{SupplementalGroupsPolicy: true},
+				requiredSupplementalGroupsPolicy: "Strict",
+			},
+			supplementalGroupsPolicy: pointer.String(""),
+			allowed:                  false,
+			expectReason:             `root group`,
+			expectDetail:             `pod securityContext.supplementalGroupsPolicy must be "Strict"`,
+			expectErrList: field.ErrorList{
+				{Type: field.ErrorTypeRequired, Field: "spec.securityContext.supplementalGroupsPolicy", BadValue: ""},
+			},
+		},
+	}
+
+	cmpOpts := []cmp.Option{cmpopts.IgnoreFields(field.Error{}, "Detail"), cmpopts.SortSlices(func(a, b *field.Error) bool { return a.Error() < b.Error() })}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.supplementalGroupsPolicy != nil {
+				defer func(f func(*corev1.PodSpec) (string, bool, bool)) { podSupplementalGroupsPolicy = f }(podSupplementalGroupsPolicy)
+				podSupplementalGroupsPolicy = func(*corev1.PodSpec) (string, bool, bool) {
+					return *tc.supplementalGroupsPolicy, *tc.supplementalGroupsPolicy != "", true
+				}
+			}
+			result := supplementalGroupsExperimental(&tc.pod.ObjectMeta, &tc.pod.Spec, tc.opts)
+			if !tc.allowed {
+				if result.Allowed {
+					t.Fatal("expected disallowed")
+				}
+				if e, a := tc.expectReason, result.ForbiddenReason; e != a {
+					t.Errorf("expected\n%s\ngot\n%s", e, a)
+				}
+				if e, a := tc.expectDetail, result.ForbiddenDetail; e != a {
+					t.Errorf("expected\n%s\ngot\n%s", e, a)
+				}
+				if result.ErrList != nil {
+					if diff := cmp.Diff(tc.expectErrList, *result.ErrList, cmpOpts...); diff != "" {
+						t.Errorf("unexpected field errors (-want,+got):\n%s", diff)
+					}
+				}
+			} else if !result.Allowed {
+				t.Fatal("expected allowed")
+			}
+		})
+	}
+}
@@ -58,8 +58,9 @@ func init() {
 // that limits the value of sysctls in 1.0+
 func CheckSysctls() Check {
 	return Check{
-		ID:    "sysctls",
-		Level: api.LevelBaseline,
+		ID:       "sysctls",
+		Level:    api.LevelBaseline,
+		SpecOnly: true,
 		Versions: []VersionedCheck{
 			{
 				MinimumVersion: api.MajorMinorVersion(1, 0),
@@ -119,7 +120,7 @@ func sysctlsV1Dot29(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opt
 }
 
 func sysctls(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, sysctlsAllowedSet sets.Set[string], opts options) CheckResult {
-	forbiddenSysctls := NewViolations(opts.withFieldErrors)
+	forbiddenSysctls := newViolations(opts)
 
 	if podSpec.SecurityContext != nil {
 		for i, sysctl := range podSpec.SecurityContext.Sysctls {
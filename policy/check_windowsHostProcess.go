@@ -46,8 +46,10 @@ func init() {
 // that forbids hostProcess=true in 1.0+
 func CheckWindowsHostProcess() Check {
 	return Check{
-		ID:    "windowsHostProcess",
-		Level: api.LevelBaseline,
+		ID:       "windowsHostProcess",
+		Level:    api.LevelBaseline,
+		SpecOnly: true,
+		Severity: SeverityCritical,
 		Versions: []VersionedCheck{
 			{
 				MinimumVersion: api.MajorMinorVersion(1, 0),
@@ -58,14 +60,17 @@ func CheckWindowsHostProcess() Check {
 }
 
 func windowsHostProcessV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
-	var badContainers []string
+	var badContainers, badSidecars []string
 	var errs field.ErrorList
-	visitContainers(podSpec, opts, func(container *corev1.Container, path *field.Path) {
+	visitContainers(podSpec, opts, func(container *corev1.Container, kind ContainerKind, path *field.Path) {
 		if container.SecurityContext != nil &&
 			container.SecurityContext.WindowsOptions != nil &&
 			container.SecurityContext.WindowsOptions.HostProcess != nil &&
 			*container.SecurityContext.WindowsOptions.HostProcess {
 			badContainers = append(badContainers, container.Name)
+			if kind == ContainerKindSidecar {
+				badSidecars = append(badSidecars, container.Name)
+			}
 			if opts.withFieldErrors {
 				errs = append(errs, withBadValue(forbidden(path.Child("securityContext", "windowsOptions", "hostProcess")), true))
 			}
@@ -81,7 +86,7 @@ func windowsHostProcessV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.Po
 	}
 
 	// pod or containers explicitly set hostProcess=true
-	forbiddenSetters := NewViolations(opts.withFieldErrors)
+	forbiddenSetters := newViolations(opts)
 	if podSpecForbidden {
 		if opts.withFieldErrors {
 			forbiddenSetters.Add("pod", withBadValue(forbidden(hostProcessPath), true))
@@ -91,14 +96,7 @@ func windowsHostProcessV1Dot0(podMetadata *metav1.ObjectMeta, podSpec *corev1.Po
 
 	}
 	if len(badContainers) > 0 {
-		forbiddenSetters.Add(
-			fmt.Sprintf(
-				"%s %s",
-				pluralize("container", "containers", len(badContainers)),
-				joinQuote(badContainers),
-			),
-			errs...,
-		)
+		forbiddenSetters.Add(describeContainers(badContainers, badSidecars), errs...)
 	}
 	if !forbiddenSetters.Empty() {
 		return CheckResult{
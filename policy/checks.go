@@ -17,11 +17,13 @@ limitations under the License.
 package policy
 
 import (
+	"fmt"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/component-base/featuregate"
 	"k8s.io/pod-security-admission/api"
 )
 
@@ -37,6 +39,21 @@ type Check struct {
 	// If the check is not yet assigned to a version, this must be a single-item list with a MinimumVersion of "".
 	// Otherwise, MinimumVersion of items must represent strictly increasing versions.
 	Versions []VersionedCheck
+	// RequiredFeatures is an optional list of feature gates the check depends on.
+	// The check is only registered by NewEvaluator if all the features are enabled
+	// in the feature gate passed with WithFeatureGate.
+	RequiredFeatures []featuregate.Feature
+	// Source optionally names the module or organization registering a custom check, like example.com/site-policies,
+	// so operators reading its violations can tell site-specific rules from the Pod Security Standards.
+	// It defaults to the domain prefix of the ID of custom checks, and is ignored for builtin checks (see BuiltinCheckSource).
+	Source string
+	// Severity optionally classifies the violations of the check. It defaults to SeverityHigh for baseline checks,
+	// and to SeverityMedium for restricted checks.
+	Severity Severity
+	// SpecOnly indicates that the results of the check only depend on the PodSpec and the options of the Evaluator,
+	// and not on the ObjectMeta of the pod, like annotations or the namespace, so that caching layers can key
+	// its results on the PodSpec alone. It defaults to false, so that custom checks are assumed to read the ObjectMeta.
+	SpecOnly bool
 }
 
 type VersionedCheck struct {
@@ -47,7 +64,8 @@ type VersionedCheck struct {
 	// CheckPod determines if the pod is allowed.
 	CheckPod CheckPodFn
 	// OverrideCheckIDs is an optional list of checks that should be skipped when this check is run.
-	// Overrides may only be set on restricted checks, and may only override baseline checks.
+	// Overrides may only override baseline checks. A baseline check overriding another baseline check replaces it,
+	// and is in turn skipped by the restricted checks overriding the replaced check.
 	OverrideCheckIDs []CheckID
 }
 
@@ -65,6 +83,9 @@ type CheckID string
 //	When checking an individual pod:
 //	  disallowed by policy "baseline": host ports (8080, 9090), privileged containers, non-default capabilities (CAP_NET_RAW)
 type CheckResult struct {
+	// ID is the ID of the check that produced the result, which also identifies its origin (see CheckID.Origin).
+	// It is set by the Evaluator returned by NewEvaluator, and may be empty otherwise.
+	ID CheckID
 	// Allowed indicates if the check allowed the pod.
 	Allowed bool
 	// ForbiddenReason must be set if Allowed is false.
@@ -84,6 +105,32 @@ type CheckResult struct {
 	// ErrList should only be set if Allowed is false, and is optional.
 	// ErrList is a detailed list of restricted field errors.
 	ErrList *field.ErrorList
+	// Warning may only be set if Allowed is true, and is optional.
+	// Warning describes a violation that is not enforced, e.g. by the Linux-only checks of Windows pods with WindowsPodModeWarn.
+	Warning string
+	// Version is the policy version the check was evaluated at.
+	// It is set by the Evaluator returned by NewEvaluator, and may be empty otherwise.
+	Version api.Version
+	// Containers are the names of the containers the ErrList applies to, in the order of the errors.
+	// They are set by the Evaluator returned by NewEvaluator if ErrList is set, and may be empty otherwise.
+	Containers []string
+	// SidecarContainers are the names of the sidecar containers among Containers, i.e. init containers with
+	// restartPolicy=Always, which are often injected into pods rather than set by their authors.
+	SidecarContainers []string
+	// Resolutions may only be set if Allowed is true, and are optional.
+	// Resolutions record the profiles the pod relied on that were resolved outside the pod spec, e.g. WithRuntimeClassDefaults.
+	Resolutions []ProfileResolution
+	// Source is the source of the check that produced the result (see Check.Source).
+	// It is set by the Evaluator returned by NewEvaluator, and may be empty otherwise.
+	Source string
+	// Severity is the severity of the check that produced the result (see Check.Severity).
+	// It is set by the Evaluator returned by NewEvaluator, and may be empty otherwise.
+	Severity Severity
+	// Error is set if the check failed to evaluate the pod, e.g. because it panicked, exceeded its deadline
+	// (see WithCheckDeadline) or could not interpret the pod. Such results are neither allowed nor violations:
+	// Allowed must be false, so callers unaware of errors fail closed, and AggregateCheckResults reports them
+	// in Errors rather than in the violations.
+	Error error
 }
 
 // AggergateCheckResult holds the aggregate result of running CheckPod across multiple checks.
@@ -96,8 +143,47 @@ type AggregateCheckResult struct {
 	// ForbiddenDetails is a slice of the forbidden details from all the forbidden checks. It may include empty strings.
 	// ForbiddenReasons and ForbiddenDetails must have the same number of elements, and the indexes are for the same check.
 	ForbiddenDetails []string
+	// ForbiddenSources is a slice of the sources of the forbidden checks, in the order of ForbiddenReasons.
+	// It includes empty strings for builtin checks and for results without a source.
+	ForbiddenSources []string
 	// ErrLists is a slice of the field errors from all the forbidden checks.
 	ErrLists map[string]field.ErrorList
+	// Warnings is a slice of the warnings from all the allowed checks.
+	Warnings []string
+	// Violations is a structured representation of all the forbidden checks, in the order of ForbiddenReasons.
+	// The result is marshaled to JSON with its Violations, Warnings and Resolutions.
+	Violations []CheckViolation
+	// Resolutions is a slice of the profile resolutions from all the allowed checks.
+	Resolutions []ProfileResolution
+	// Errors is a slice of the errors of the checks that failed to evaluate the pod (see CheckResult.Error).
+	// They are not included in the forbidden reasons and violations, but Allowed is false if any is set.
+	Errors []CheckError
+}
+
+// CheckError is the error of a check that failed to evaluate a pod.
+type CheckError struct {
+	// Check is the ID of the check.
+	Check CheckID
+	// Err is the error of the check.
+	Err error
+}
+
+func (e CheckError) Error() string {
+	return fmt.Sprintf("check %s: %v", e.Check, e.Err)
+}
+
+func (e CheckError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorDetail returns a comma-separated string of the errors of the checks that failed to evaluate the pod.
+// Example: check example.com/registry-allowlist: deadline of 100ms exceeded
+func (a *AggregateCheckResult) ErrorDetail() string {
+	details := make([]string, len(a.Errors))
+	for i, err := range a.Errors {
+		details[i] = err.Error()
+	}
+	return strings.Join(details, ", ")
 }
 
 // ForbiddenReason returns a comma-separated string of the forbidden reasons.
@@ -107,8 +193,8 @@ func (a *AggregateCheckResult) ForbiddenReason() string {
 }
 
 // ForbiddenDetail returns a detailed forbidden message, with non-empty details formatted in
-// parentheses with the associated reason.
-// Example: host ports (8080, 9090), privileged containers, non-default capabilities (NET_RAW)
+// parentheses with the associated reason, followed by the source of custom checks in brackets.
+// Example: host ports (8080, 9090), privileged containers, read-only root filesystem (container "a") [example.com/site-policies]
 func (a *AggregateCheckResult) ForbiddenDetail() string {
 	var b strings.Builder
 	for i := 0; i < len(a.ForbiddenReasons); i++ {
@@ -118,6 +204,11 @@ func (a *AggregateCheckResult) ForbiddenDetail() string {
 			b.WriteString(a.ForbiddenDetails[i])
 			b.WriteString(")")
 		}
+		if i < len(a.ForbiddenSources) && a.ForbiddenSources[i] != "" {
+			b.WriteString(" [")
+			b.WriteString(a.ForbiddenSources[i])
+			b.WriteString("]")
+		}
 		if i != len(a.ForbiddenReasons)-1 {
 			b.WriteString(", ")
 		}
@@ -132,11 +223,26 @@ const UnknownForbiddenReason = "unknown forbidden reason"
 // The aggregated reason is a comma-separated
 func AggregateCheckResults(results []CheckResult) AggregateCheckResult {
 	var (
-		reasons  []string
-		details  []string
-		errLists = make(map[string]field.ErrorList)
+		reasons     []string
+		details     []string
+		sources     []string
+		warnings    []string
+		violations  []CheckViolation
+		resolutions []ProfileResolution
+		errs        []CheckError
+		errLists    = make(map[string]field.ErrorList)
 	)
 	for _, result := range results {
+		if result.Allowed && result.Warning != "" {
+			warnings = append(warnings, result.Warning)
+		}
+		if result.Allowed {
+			resolutions = append(resolutions, result.Resolutions...)
+		}
+		if result.Error != nil {
+			errs = append(errs, CheckError{Check: result.ID, Err: result.Error})
+			continue
+		}
 		if !result.Allowed {
 			if len(result.ForbiddenReason) == 0 {
 				reasons = append(reasons, UnknownForbiddenReason)
@@ -150,19 +256,31 @@ func AggregateCheckResults(results []CheckResult) AggregateCheckResult {
 				}
 			}
 			details = append(details, result.ForbiddenDetail)
+			if result.ID.Origin() == CheckOriginCustom {
+				sources = append(sources, result.Source)
+			} else {
+				sources = append(sources, "")
+			}
+			violations = append(violations, newCheckViolation(result, reasons[len(reasons)-1]))
 		}
 	}
 	return AggregateCheckResult{
-		Allowed:          len(reasons) == 0,
+		Allowed:          len(reasons) == 0 && len(errs) == 0,
 		ForbiddenReasons: reasons,
 		ForbiddenDetails: details,
+		ForbiddenSources: sources,
 		ErrLists:         errLists,
+		Warnings:         warnings,
+		Violations:       violations,
+		Resolutions:      resolutions,
+		Errors:           errs,
 	}
 }
 
 var (
 	defaultChecks      []func() Check
 	experimentalChecks []func() Check
+	optionalChecks     []func() Check
 )
 
 func addCheck(f func() Check) {
@@ -175,6 +293,11 @@ func addCheck(f func() Check) {
 	}
 }
 
+// addOptionalCheck registers a versioned check that is only evaluated when explicitly opted into.
+func addOptionalCheck(f func() Check) {
+	optionalChecks = append(optionalChecks, f)
+}
+
 // DefaultChecks returns checks that are expected to be enabled by default.
 // The results are mutually exclusive with ExperimentalChecks.
 // It returns a new copy of checks on each invocation and is expected to be called once at setup time.
@@ -196,3 +319,31 @@ func ExperimentalChecks() []Check {
 	}
 	return retval
 }
+
+// AssignExperimentalCheck returns a copy of the experimental check assigned to the policy versions
+// starting at the given version, so it can be passed to NewEvaluator. Experimental checks are not yet
+// assigned to policy versions (see ExperimentalChecks), and are rejected by NewEvaluator otherwise.
+func AssignExperimentalCheck(check Check, minimumVersion api.Version) (Check, error) {
+	if len(check.Versions) != 1 || check.Versions[0].MinimumVersion != (api.Version{}) {
+		return Check{}, fmt.Errorf("check %s: not experimental", check.ID)
+	}
+	if minimumVersion == (api.Version{}) || minimumVersion.Latest() {
+		return Check{}, fmt.Errorf("check %s: invalid version %s", check.ID, minimumVersion)
+	}
+	assigned := check
+	assigned.Versions = []VersionedCheck{check.Versions[0]}
+	assigned.Versions[0].MinimumVersion = minimumVersion
+	return assigned, nil
+}
+
+// OptionalChecks returns versioned checks that are not part of the Pod Security Standards
+// and are only evaluated when explicitly passed to NewEvaluator.
+// The results are mutually exclusive with DefaultChecks and ExperimentalChecks.
+// It returns a new copy of checks on each invocation and is expected to be called once at setup time.
+func OptionalChecks() []Check {
+	retval := make([]Check, 0, len(optionalChecks))
+	for _, f := range optionalChecks {
+		retval = append(retval, f())
+	}
+	return retval
+}
@@ -19,12 +19,22 @@ package policy
 import (
 	"testing"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/utils/pointer"
+
 	"github.com/stretchr/testify/assert"
 )
 
 // TestValidChecks ensures that all registered checks are valid.
 func TestValidChecks(t *testing.T) {
-	allChecks := append(DefaultChecks(), ExperimentalChecks()...)
+	allChecks := append(DefaultChecks(), OptionalChecks()...)
+	for _, check := range ExperimentalChecks() {
+		assigned, err := AssignExperimentalCheck(check, api.MajorMinorVersion(1, 0))
+		assert.NoError(t, err)
+		allChecks = append(allChecks, assigned)
+	}
 
 	assert.NoError(t, validateChecks(allChecks))
 
@@ -41,3 +51,71 @@ func TestValidChecks(t *testing.T) {
 		}
 	}
 }
+
+func TestAssignExperimentalCheck(t *testing.T) {
+	var experimentalIDs []CheckID
+	for _, check := range ExperimentalChecks() {
+		experimentalIDs = append(experimentalIDs, check.ID)
+	}
+	experimental := CheckSupplementalGroups()
+	assert.Contains(t, experimentalIDs, experimental.ID)
+	_, err := NewEvaluator([]Check{experimental})
+	assert.ErrorContains(t, err, "undefined version")
+
+	assigned, err := AssignExperimentalCheck(experimental, api.MajorMinorVersion(1, 30))
+	assert.NoError(t, err)
+	assert.Equal(t, api.MajorMinorVersion(1, 30), assigned.Versions[0].MinimumVersion)
+	assert.Equal(t, api.Version{}, experimental.Versions[0].MinimumVersion, "the experimental check should not be modified")
+	evaluator, err := NewEvaluator([]Check{assigned})
+	assert.NoError(t, err)
+	pod := &corev1.Pod{Spec: corev1.PodSpec{SecurityContext: &corev1.PodSecurityContext{FSGroup: pointer.Int64(0)}}}
+	assert.Empty(t, evaluator.EvaluatePod(api.LevelVersion{Level: api.LevelRestricted, Version: api.MajorMinorVersion(1, 29)}, &pod.ObjectMeta, &pod.Spec))
+	results := evaluator.EvaluatePod(api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()}, &pod.ObjectMeta, &pod.Spec)
+	if assert.Len(t, results, 1) {
+		assert.False(t, results[0].Allowed)
+	}
+
+	_, err = AssignExperimentalCheck(assigned, api.MajorMinorVersion(1, 31))
+	assert.ErrorContains(t, err, "not experimental")
+	_, err = AssignExperimentalCheck(experimental, api.LatestVersion())
+	assert.ErrorContains(t, err, "invalid version")
+}
+
+// TestSpecOnlyChecks ensures that the results of the checks marked SpecOnly do not depend on the pod metadata.
+func TestSpecOnlyChecks(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{
+		HostNetwork: true,
+		SecurityContext: &corev1.PodSecurityContext{
+			SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined},
+		},
+		Volumes: []corev1.Volume{{Name: "host", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/"}}}},
+		Containers: []corev1.Container{{
+			Name:            "a",
+			SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(true)},
+			VolumeMounts:    []corev1.VolumeMount{{Name: "host"}},
+		}},
+	}}
+	metadata := &metav1.ObjectMeta{
+		Namespace: "ns",
+		Annotations: map[string]string{
+			corev1.SeccompPodAnnotationKey:                                  corev1.SeccompProfileRuntimeDefault,
+			corev1.DeprecatedAppArmorBetaContainerAnnotationKeyPrefix + "a": corev1.DeprecatedAppArmorBetaProfileNameUnconfined,
+		},
+	}
+
+	allChecks := append(DefaultChecks(), ExperimentalChecks()...)
+	allChecks = append(allChecks, OptionalChecks()...)
+	for _, check := range allChecks {
+		if !check.SpecOnly {
+			continue
+		}
+		for _, v := range check.Versions {
+			for _, opts := range [][]Option{nil, {WithFieldErrors()}} {
+				assert.Equal(t,
+					v.CheckPod(&metav1.ObjectMeta{}, &pod.Spec, opts...),
+					v.CheckPod(metadata, &pod.Spec, opts...),
+					"check %s %s: results depend on the pod metadata", check.ID, v.MinimumVersion)
+			}
+		}
+	}
+}
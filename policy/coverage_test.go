@@ -0,0 +1,222 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// coverageMatrixEnv is the environment variable naming the file the coverage matrix is written to.
+//
+// POD_SECURITY_COVERAGE_MATRIX=/tmp/matrix.md go test k8s.io/pod-security-admission/policy -run TestCoverageMatrix
+const coverageMatrixEnv = "POD_SECURITY_COVERAGE_MATRIX"
+
+// coverageRow is a single check version in the coverage matrix.
+type coverageRow struct {
+	check    CheckID
+	version  string
+	function string
+	// tests are the test functions referencing the versioned check function.
+	tests []string
+	// fieldErrors is true if at least one of the tests enables field errors.
+	fieldErrors bool
+	// os is the set of pod operating systems set in the tests.
+	os sets.Set[string]
+}
+
+// TestCoverageMatrix scans the check registrations and test files of this package,
+// and fails if a versioned check function is untested or lacks field error tests.
+func TestCoverageMatrix(t *testing.T) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, ok := pkgs["policy"]
+	if !ok {
+		t.Fatal("policy package not found")
+	}
+
+	funcs := map[string]*ast.FuncDecl{}
+	var testFuncs []*ast.FuncDecl
+	for name, file := range pkg.Files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil {
+				continue
+			}
+			if strings.HasSuffix(name, "_test.go") {
+				testFuncs = append(testFuncs, fn)
+			} else {
+				funcs[fn.Name.Name] = fn
+			}
+		}
+	}
+
+	var rows []*coverageRow
+	registered := append(append([]func() Check{}, defaultChecks...), optionalChecks...)
+	for _, f := range registered {
+		check := f()
+		constructor := funcName(f)
+		decl, ok := funcs[constructor]
+		if !ok {
+			t.Errorf("check %s: constructor %s not found", check.ID, constructor)
+			continue
+		}
+		checkPods := versionedCheckFuncs(decl)
+		if len(checkPods) != len(check.Versions) {
+			t.Errorf("check %s: found %d CheckPod functions in %s, expected %d", check.ID, len(checkPods), constructor, len(check.Versions))
+			continue
+		}
+		for i, v := range check.Versions {
+			rows = append(rows, &coverageRow{
+				check:    check.ID,
+				version:  v.MinimumVersion.String(),
+				function: checkPods[i],
+				os:       sets.New[string](),
+			})
+		}
+	}
+
+	for _, fn := range testFuncs {
+		refs, fieldErrors, podOS := scanTestFunc(fn)
+		for _, row := range rows {
+			if !refs.Has(row.function) {
+				continue
+			}
+			row.tests = append(row.tests, fn.Name.Name)
+			row.fieldErrors = row.fieldErrors || fieldErrors
+			row.os = row.os.Union(podOS)
+		}
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].check != rows[j].check {
+			return rows[i].check < rows[j].check
+		}
+		return rows[i].function < rows[j].function
+	})
+	for _, row := range rows {
+		sort.Strings(row.tests)
+		switch {
+		case len(row.tests) == 0:
+			t.Errorf("check %s %s: %s is not tested", row.check, row.version, row.function)
+		case !row.fieldErrors:
+			t.Errorf("check %s %s: %s is not tested with field errors (tested by %s)", row.check, row.version, row.function, strings.Join(row.tests, ", "))
+		}
+	}
+
+	matrix := formatCoverageMatrix(rows)
+	t.Log("\n" + matrix)
+	if path := os.Getenv(coverageMatrixEnv); path != "" {
+		if err := os.WriteFile(path, []byte(matrix), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// funcName returns the unqualified name of the function.
+func funcName(f interface{}) string {
+	name := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// versionedCheckFuncs returns the names of the functions passed as CheckPod in the given check constructor, in order.
+func versionedCheckFuncs(decl *ast.FuncDecl) []string {
+	var names []string
+	ast.Inspect(decl, func(n ast.Node) bool {
+		kv, ok := n.(*ast.KeyValueExpr)
+		if !ok {
+			return true
+		}
+		if key, ok := kv.Key.(*ast.Ident); !ok || key.Name != "CheckPod" {
+			return true
+		}
+		value := kv.Value
+		// Unwrap withOptions(fn) and linuxOnly(fn, skipByDefault).
+		for {
+			call, ok := value.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				break
+			}
+			value = call.Args[0]
+		}
+		if ident, ok := value.(*ast.Ident); ok {
+			names = append(names, ident.Name)
+		} else {
+			names = append(names, "")
+		}
+		return false
+	})
+	return names
+}
+
+// scanTestFunc returns the identifiers referenced by the test function,
+// whether it enables field errors, and the pod operating systems it sets.
+func scanTestFunc(fn *ast.FuncDecl) (refs sets.Set[string], fieldErrors bool, podOS sets.Set[string]) {
+	refs = sets.New[string]()
+	podOS = sets.New[string]()
+	ast.Inspect(fn, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Ident:
+			refs.Insert(n.Name)
+			if n.Name == "WithFieldErrors" {
+				fieldErrors = true
+			}
+		case *ast.KeyValueExpr:
+			if key, ok := n.Key.(*ast.Ident); ok && key.Name == "withFieldErrors" {
+				if value, ok := n.Value.(*ast.Ident); ok && value.Name == "true" {
+					fieldErrors = true
+				}
+			}
+		case *ast.SelectorExpr:
+			switch n.Sel.Name {
+			case "Linux":
+				podOS.Insert("linux")
+			case "Windows":
+				podOS.Insert("windows")
+			}
+		}
+		return true
+	})
+	return refs, fieldErrors, podOS
+}
+
+func formatCoverageMatrix(rows []*coverageRow) string {
+	var b strings.Builder
+	b.WriteString("| Check | Version | Function | Tests | Field errors | OS |\n")
+	b.WriteString("|---|---|---|---|---|---|\n")
+	for _, row := range rows {
+		podOS := "any"
+		if row.os.Len() > 0 {
+			podOS = strings.Join(sets.List(row.os), ", ")
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %d | %t | %s |\n", row.check, row.version, row.function, len(row.tests), row.fieldErrors, podOS)
+	}
+	return b.String()
+}
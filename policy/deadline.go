@@ -0,0 +1,157 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EvaluationErrorReason is the forbidden reason of the results of checks that failed to evaluate the pod,
+// e.g. because they exceeded their deadline (see CheckResult.Error).
+const EvaluationErrorReason = "evaluation error"
+
+// WithCheckDeadline bounds the execution of the checks with the given IDs, which must be registered, to deadline,
+// or the execution of all the other checks if no IDs are given, so a slow check, like a custom check calling out
+// to an external service, cannot exceed the latency budget of admission. It applies to the checks evaluated by
+// the Evaluator returned by NewEvaluator. Checks exceeding their deadline do not allow the pod, and their result
+// has a CheckDeadlineExceededError. They keep running in the background until they return, so they must not mutate
+// the pod. A deadline of 0 does not bound the execution of the checks.
+func WithCheckDeadline(deadline time.Duration, ids ...CheckID) Option {
+	return func(opt options) options {
+		if len(ids) == 0 {
+			opt.defaultCheckDeadline = deadline
+			return opt
+		}
+		// copy the deadlines, since options are passed by value
+		deadlines := make(map[CheckID]time.Duration, len(opt.checkDeadlines)+len(ids))
+		for id, d := range opt.checkDeadlines {
+			deadlines[id] = d
+		}
+		for _, id := range ids {
+			deadlines[id] = deadline
+		}
+		opt.checkDeadlines = deadlines
+		return opt
+	}
+}
+
+// CheckDeadlineExceededError is the Error of the results of checks that exceeded their deadline (see WithCheckDeadline).
+type CheckDeadlineExceededError struct {
+	// ID is the ID of the check.
+	ID CheckID
+	// Deadline is the deadline of the check.
+	Deadline time.Duration
+}
+
+func (e *CheckDeadlineExceededError) Error() string {
+	return fmt.Sprintf("deadline of %s exceeded", e.Deadline)
+}
+
+func (e *CheckDeadlineExceededError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// validateCheckDeadlines returns an error if the deadlines configured by the options are negative,
+// or if any of them is for a check that is not one of the checks.
+func validateCheckDeadlines(checks []Check, opts options) error {
+	if opts.defaultCheckDeadline < 0 {
+		return fmt.Errorf("check deadline must not be negative")
+	}
+	ids := make(map[CheckID]bool, len(checks))
+	for _, c := range checks {
+		ids[c.ID] = true
+	}
+	for id, deadline := range opts.checkDeadlines {
+		if !ids[id] {
+			return fmt.Errorf("cannot set deadline of unknown check %s", id)
+		}
+		if deadline < 0 {
+			return fmt.Errorf("check %s: deadline must not be negative", id)
+		}
+	}
+	return nil
+}
+
+// checkDeadline returns the deadline of the check with the given ID configured by the options,
+// or 0 if its execution is not bounded.
+func (opt options) checkDeadline(id CheckID) time.Duration {
+	if deadline, ok := opt.checkDeadlines[id]; ok {
+		return deadline
+	}
+	return opt.defaultCheckDeadline
+}
+
+// withDeadline wraps the CheckPodFn to return an evaluation error if it does not return within deadline, if positive,
+// or if it panics.
+func withDeadline(id CheckID, deadline time.Duration, checkPod CheckPodFn) CheckPodFn {
+	checkPod = withRecover(checkPod)
+	if deadline <= 0 {
+		return checkPod
+	}
+	return func(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts ...Option) CheckResult {
+		results := make(chan CheckResult, 1)
+		go func() {
+			results <- checkPod(podMetadata, podSpec, opts...)
+		}()
+
+		timer := time.NewTimer(deadline)
+		defer timer.Stop()
+		select {
+		case result := <-results:
+			return result
+		case <-timer.C:
+			return evaluationErrorResult(&CheckDeadlineExceededError{ID: id, Deadline: deadline})
+		}
+	}
+}
+
+// withRecover wraps the CheckPodFn to return its panics as evaluation errors.
+func withRecover(checkPod CheckPodFn) CheckPodFn {
+	return func(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts ...Option) (result CheckResult) {
+		defer func() {
+			if r := recover(); r != nil {
+				result = evaluationErrorResult(fmt.Errorf("panicked: %v", r))
+			}
+		}()
+		return checkPod(podMetadata, podSpec, opts...)
+	}
+}
+
+// evaluationErrorResult returns the result of a check that failed to evaluate the pod with the given error.
+func evaluationErrorResult(err error) CheckResult {
+	return CheckResult{
+		Allowed:         false,
+		ForbiddenReason: EvaluationErrorReason,
+		ForbiddenDetail: err.Error(),
+		Error:           err,
+	}
+}
+
+// hasEvaluationErrors returns true if any of the checks failed to evaluate the pod.
+func hasEvaluationErrors(results []CheckResult) bool {
+	for _, result := range results {
+		if result.Error != nil {
+			return true
+		}
+	}
+	return false
+}
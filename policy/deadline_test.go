@@ -0,0 +1,171 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/pod-security-admission/api"
+)
+
+func TestWithCheckDeadline(t *testing.T) {
+	unblocked := make(chan struct{})
+	defer close(unblocked)
+	slowCheck := Check{
+		ID:    testCheckDomain + "slow",
+		Level: api.LevelBaseline,
+		Versions: []VersionedCheck{{
+			MinimumVersion: api.MajorMinorVersion(1, 0),
+			CheckPod: func(_ *metav1.ObjectMeta, _ *corev1.PodSpec, _ ...Option) CheckResult {
+				<-unblocked
+				return CheckResult{Allowed: true}
+			},
+		}},
+	}
+	panickingCheck := Check{
+		ID:    testCheckDomain + "panicking",
+		Level: api.LevelBaseline,
+		Versions: []VersionedCheck{{
+			MinimumVersion: api.MajorMinorVersion(1, 0),
+			CheckPod: func(_ *metav1.ObjectMeta, _ *corev1.PodSpec, _ ...Option) CheckResult {
+				panic("boom")
+			},
+		}},
+	}
+	lv := api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}
+	pod := &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "a"}}}}
+
+	evaluator, err := NewEvaluator([]Check{CheckPrivileged(), slowCheck, panickingCheck},
+		WithCheckDeadline(time.Second),
+		WithCheckDeadline(10*time.Millisecond, slowCheck.ID),
+	)
+	require.NoError(t, err)
+	results := evaluator.EvaluatePod(lv, &pod.ObjectMeta, &pod.Spec)
+	require.Len(t, results, 3)
+	byID := map[CheckID]CheckResult{}
+	for _, result := range results {
+		byID[result.ID] = result
+	}
+
+	assert.True(t, byID["privileged"].Allowed)
+	assert.NoError(t, byID["privileged"].Error)
+
+	slow := byID[slowCheck.ID]
+	assert.False(t, slow.Allowed)
+	assert.Equal(t, EvaluationErrorReason, slow.ForbiddenReason)
+	assert.Equal(t, "deadline of 10ms exceeded", slow.ForbiddenDetail)
+	assert.True(t, errors.Is(slow.Error, context.DeadlineExceeded))
+	var deadlineErr *CheckDeadlineExceededError
+	require.True(t, errors.As(slow.Error, &deadlineErr))
+	assert.Equal(t, slowCheck.ID, deadlineErr.ID)
+	assert.Equal(t, "example.com", slow.Source, "the result should describe the check")
+
+	panicking := byID[panickingCheck.ID]
+	assert.False(t, panicking.Allowed)
+	assert.EqualError(t, panicking.Error, "panicked: boom")
+
+	aggregate := AggregateCheckResults(results)
+	assert.False(t, aggregate.Allowed)
+	require.Len(t, aggregate.Errors, 2)
+	assert.Empty(t, aggregate.ForbiddenReasons, "evaluation errors are not violations")
+	assert.Equal(t, "check example.com/panicking: panicked: boom, check example.com/slow: deadline of 10ms exceeded", aggregate.ErrorDetail())
+	assert.True(t, errors.Is(aggregate.Errors[1], context.DeadlineExceeded))
+}
+
+func TestPanickingCheckWithoutDeadline(t *testing.T) {
+	panickingCheck := Check{
+		ID:    testCheckDomain + "panicking",
+		Level: api.LevelBaseline,
+		Versions: []VersionedCheck{{
+			MinimumVersion: api.MajorMinorVersion(1, 0),
+			CheckPod: func(_ *metav1.ObjectMeta, _ *corev1.PodSpec, _ ...Option) CheckResult {
+				panic("boom")
+			},
+		}},
+	}
+	lv := api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}
+	pod := &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "a"}}}}
+
+	evaluator, err := NewEvaluator([]Check{CheckPrivileged(), panickingCheck})
+	require.NoError(t, err)
+	var results []CheckResult
+	require.NotPanics(t, func() {
+		results = evaluator.EvaluatePod(lv, &pod.ObjectMeta, &pod.Spec)
+	})
+	require.Len(t, results, 2)
+	byID := map[CheckID]CheckResult{}
+	for _, result := range results {
+		byID[result.ID] = result
+	}
+
+	assert.True(t, byID["privileged"].Allowed)
+	panicking := byID[panickingCheck.ID]
+	assert.False(t, panicking.Allowed)
+	assert.Equal(t, EvaluationErrorReason, panicking.ForbiddenReason)
+	assert.EqualError(t, panicking.Error, "panicked: boom")
+}
+
+func TestWithCheckDeadlineValidation(t *testing.T) {
+	_, err := NewEvaluator(DefaultChecks(), WithCheckDeadline(-time.Second))
+	assert.EqualError(t, err, "check deadline must not be negative")
+	_, err = NewEvaluator(DefaultChecks(), WithCheckDeadline(-time.Second, "privileged"))
+	assert.EqualError(t, err, "check privileged: deadline must not be negative")
+	_, err = NewEvaluator(DefaultChecks(), WithCheckDeadline(time.Second, "unknown"))
+	assert.EqualError(t, err, "cannot set deadline of unknown check unknown")
+
+	// deadlines are applied in order
+	opts := resolveOptions([]Option{WithCheckDeadline(time.Second, "privileged"), WithCheckDeadline(0, "privileged"), WithCheckDeadline(time.Minute)})
+	assert.Equal(t, time.Duration(0), opts.checkDeadline("privileged"))
+	assert.Equal(t, time.Minute, opts.checkDeadline("hostPorts"))
+}
+
+func TestResultCacheSkipsEvaluationErrors(t *testing.T) {
+	var calls atomic.Int32
+	slowOnce := Check{
+		ID:    testCheckDomain + "slow-once",
+		Level: api.LevelBaseline,
+		Versions: []VersionedCheck{{
+			MinimumVersion: api.MajorMinorVersion(1, 0),
+			CheckPod: func(_ *metav1.ObjectMeta, _ *corev1.PodSpec, _ ...Option) CheckResult {
+				if calls.Add(1) == 1 {
+					time.Sleep(100 * time.Millisecond)
+				}
+				return CheckResult{Allowed: true}
+			},
+		}},
+	}
+	evaluator, err := NewEvaluator([]Check{slowOnce}, WithCheckDeadline(10*time.Millisecond), WithResultCache(10, nil))
+	require.NoError(t, err)
+	lv := api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}
+	pod := &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "a"}}}}
+
+	assert.False(t, AggregateCheckResults(evaluator.EvaluatePod(lv, &pod.ObjectMeta, &pod.Spec)).Allowed)
+	// wait for the first evaluation to return before counting
+	time.Sleep(150 * time.Millisecond)
+	assert.True(t, AggregateCheckResults(evaluator.EvaluatePod(lv, &pod.ObjectMeta, &pod.Spec)).Allowed)
+	assert.True(t, AggregateCheckResults(evaluator.EvaluatePod(lv, &pod.ObjectMeta, &pod.Spec)).Allowed)
+	assert.Equal(t, int32(2), calls.Load(), "only the allowed results should be cached")
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// DeprecatedField is a deprecated field set in a pod, which is still evaluated by the checks.
+type DeprecatedField struct {
+	// Field identifies the deprecated field independently of the pod, e.g. for metrics.
+	// It is the annotation key, or the annotation key prefix for per-container annotations.
+	Field string
+	// Path is the path of the field in the pod.
+	Path *field.Path
+	// Replacement is the path of the field replacing the deprecated field.
+	// It is nil for per-container annotations of containers missing from the pod.
+	Replacement *field.Path
+}
+
+// DeprecatedFields returns the deprecated fields set in the pod, sorted by path:
+// the seccomp alpha annotations, replaced by securityContext.seccompProfile,
+// and the AppArmor beta annotations, replaced by securityContext.appArmorProfile.
+// The fields are returned whether or not their values are allowed by the checks.
+func DeprecatedFields(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec) []DeprecatedField {
+	if podMetadata == nil || len(podMetadata.Annotations) == 0 {
+		return nil
+	}
+	var containerPaths map[string]*field.Path
+	containerPath := func(name string) *field.Path {
+		if containerPaths == nil {
+			containerPaths = map[string]*field.Path{}
+			visitContainers(podSpec, options{withFieldErrors: true}, func(container *corev1.Container, _ ContainerKind, path *field.Path) {
+				containerPaths[container.Name] = path
+			})
+		}
+		return containerPaths[name]
+	}
+
+	var fields []DeprecatedField
+	for key := range podMetadata.Annotations {
+		var deprecated DeprecatedField
+		switch {
+		case key == annotationKeyPod:
+			deprecated = DeprecatedField{Field: key, Replacement: securityContextPath.Child("seccompProfile")}
+		case strings.HasPrefix(key, annotationKeyContainerPrefix):
+			deprecated = DeprecatedField{Field: annotationKeyContainerPrefix}
+			if path := containerPath(strings.TrimPrefix(key, annotationKeyContainerPrefix)); path != nil {
+				deprecated.Replacement = path.Child("securityContext", "seccompProfile")
+			}
+		case strings.HasPrefix(key, corev1.DeprecatedAppArmorBetaContainerAnnotationKeyPrefix):
+			deprecated = DeprecatedField{Field: corev1.DeprecatedAppArmorBetaContainerAnnotationKeyPrefix}
+			if path := containerPath(strings.TrimPrefix(key, corev1.DeprecatedAppArmorBetaContainerAnnotationKeyPrefix)); path != nil {
+				deprecated.Replacement = path.Child("securityContext", "appArmorProfile")
+			}
+		default:
+			continue
+		}
+		deprecated.Path = annotationsPath.Key(key)
+		fields = append(fields, deprecated)
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Path.String() < fields[j].Path.String() })
+	return fields
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDeprecatedFields(t *testing.T) {
+	podSpec := &corev1.PodSpec{
+		InitContainers: []corev1.Container{{Name: "init"}},
+		Containers:     []corev1.Container{{Name: "a"}, {Name: "b"}},
+	}
+	podMetadata := &metav1.ObjectMeta{Annotations: map[string]string{
+		"seccomp.security.alpha.kubernetes.io/pod":               "runtime/default",
+		"container.seccomp.security.alpha.kubernetes.io/init":    "unconfined",
+		"container.apparmor.security.beta.kubernetes.io/b":       "runtime/default",
+		"container.apparmor.security.beta.kubernetes.io/missing": "runtime/default",
+		"example.com/unrelated":                                  "",
+	}}
+
+	type deprecated struct{ field, path, replacement string }
+	var actual []deprecated
+	for _, f := range DeprecatedFields(podMetadata, podSpec) {
+		d := deprecated{field: f.Field, path: f.Path.String()}
+		if f.Replacement != nil {
+			d.replacement = f.Replacement.String()
+		}
+		actual = append(actual, d)
+	}
+	assert.Equal(t, []deprecated{
+		{"container.apparmor.security.beta.kubernetes.io/", "metadata.annotations[container.apparmor.security.beta.kubernetes.io/b]", "spec.containers[1].securityContext.appArmorProfile"},
+		{"container.apparmor.security.beta.kubernetes.io/", "metadata.annotations[container.apparmor.security.beta.kubernetes.io/missing]", ""},
+		{"container.seccomp.security.alpha.kubernetes.io/", "metadata.annotations[container.seccomp.security.alpha.kubernetes.io/init]", "spec.initContainers[0].securityContext.seccompProfile"},
+		{"seccomp.security.alpha.kubernetes.io/pod", "metadata.annotations[seccomp.security.alpha.kubernetes.io/pod]", "spec.securityContext.seccompProfile"},
+	}, actual)
+
+	assert.Empty(t, DeprecatedFields(&metav1.ObjectMeta{}, podSpec))
+}
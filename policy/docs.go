@@ -0,0 +1,76 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"k8s.io/component-base/featuregate"
+)
+
+//go:generate go run ./internal/checkdocgen . zz_generated.checkdocs.go
+
+// checkComment is the parsed doc comment of a builtin check, generated from its check_<id>.go file.
+type checkComment struct {
+	description   string
+	allowedValues []string
+}
+
+// CheckVersionDoc describes a VersionedCheck.
+type CheckVersionDoc struct {
+	MinimumVersion   string    `json:"minimumVersion"`
+	OverrideCheckIDs []CheckID `json:"overrideCheckIDs,omitempty"`
+}
+
+// CheckDoc describes a check, e.g. to generate documentation or policies for other engines.
+// The description and allowed values are parsed from the doc comments of the builtin checks,
+// and are empty for custom checks.
+type CheckDoc struct {
+	CheckInfo `json:",inline"`
+
+	Description      string                `json:"description,omitempty"`
+	Versions         []CheckVersionDoc     `json:"versions"`
+	RequiredFeatures []featuregate.Feature `json:"requiredFeatures,omitempty"`
+	// RestrictedFields are the field paths restricted by the check, see RestrictedFields.
+	RestrictedFields []string `json:"restrictedFields,omitempty"`
+	// AllowedValues are the values allowed in the restricted fields, for each group of fields documented by the check.
+	AllowedValues []string `json:"allowedValues,omitempty"`
+}
+
+// CheckDocs returns the CheckDoc of the checks, sorted by ID.
+func CheckDocs(checks []Check) []CheckDoc {
+	byID := make(map[CheckID]Check, len(checks))
+	for _, c := range checks {
+		byID[c.ID] = c
+	}
+	docs := make([]CheckDoc, 0, len(checks))
+	for _, info := range checkCatalog(checks) {
+		c := byID[info.ID]
+		doc := CheckDoc{CheckInfo: info, RequiredFeatures: c.RequiredFeatures}
+		for _, v := range c.Versions {
+			doc.Versions = append(doc.Versions, CheckVersionDoc{MinimumVersion: v.MinimumVersion.String(), OverrideCheckIDs: v.OverrideCheckIDs})
+		}
+		if info.Origin == CheckOriginBuiltin {
+			comment := checkComments[c.ID]
+			doc.Description = comment.description
+			doc.AllowedValues = comment.allowedValues
+			for _, path := range restrictedFields[c.ID] {
+				doc.RestrictedFields = append(doc.RestrictedFields, path.String())
+			}
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/pod-security-admission/api"
+)
+
+func TestCheckDocs(t *testing.T) {
+	var checks []Check
+	for _, c := range [][]Check{DefaultChecks(), ExperimentalChecks(), OptionalChecks()} {
+		checks = append(checks, c...)
+	}
+	docs := CheckDocs(checks)
+	require.Len(t, docs, len(checks))
+	for i, doc := range docs {
+		if i > 0 {
+			assert.Less(t, docs[i-1].ID, doc.ID, "sorted by ID")
+		}
+		assert.NotEmpty(t, doc.Description, doc.ID)
+		assert.NotEmpty(t, doc.AllowedValues, doc.ID)
+		assert.NotEmpty(t, doc.RestrictedFields, doc.ID)
+		assert.NotEmpty(t, doc.Versions, doc.ID)
+	}
+	assert.Len(t, checkComments, len(checks), "doc comments of unregistered checks")
+
+	custom := generateCheck("custom", api.LevelRestricted, []string{"v1.0", "v1.5"})
+	docs = CheckDocs([]Check{withOverrides(custom, []CheckID{"a"})})
+	require.Len(t, docs, 1)
+	assert.Equal(t, CheckDoc{
+		CheckInfo: CheckInfo{ID: testCheckDomain + "custom", Level: api.LevelRestricted, Origin: CheckOriginCustom, Source: "example.com", Severity: SeverityMedium},
+		Versions: []CheckVersionDoc{
+			{MinimumVersion: "v1.0", OverrideCheckIDs: []CheckID{testCheckDomain + "a"}},
+			{MinimumVersion: "v1.5", OverrideCheckIDs: []CheckID{testCheckDomain + "a"}},
+		},
+	}, docs[0])
+
+	data, err := json.Marshal(CheckDocs([]Check{CheckPrivileged()}))
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{
+		"id": "privileged",
+		"level": "baseline",
+		"origin": "builtin",
+		"source": "k8s.io/pod-security-admission",
+		"severity": "critical",
+		"specOnly": true,
+		"description": "Privileged Pods disable most security mechanisms and must be disallowed.",
+		"versions": [{"minimumVersion": "v1.0"}],
+		"restrictedFields": ["spec.initContainers[*].securityContext.privileged", "spec.containers[*].securityContext.privileged", "spec.ephemeralContainers[*].securityContext.privileged"],
+		"allowedValues": ["false, undefined/null"]
+	}]`, string(data))
+}
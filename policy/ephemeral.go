@@ -0,0 +1,168 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/pod-security-admission/api"
+)
+
+// EvaluateEphemeralContainers evaluates the ephemeral containers of the pod at the indexes, like the containers added
+// by the ephemeralcontainers subresource, e.g. by kubectl debug, against the level & version. The other containers
+// are not evaluated, and the violations of the pod-level fields, which ephemeral container updates cannot change, are
+// allowed unless the evaluated containers add to them. The field errors of the results, set if the evaluator is created
+// WithFieldErrors, have the paths of the ephemeral containers in the pod, e.g. spec.ephemeralContainers[2].
+// The checks relating containers to each other, like duplicateContainers, are evaluated against all the containers,
+// and only report the violations added by the evaluated containers, e.g. reusing the name of another container.
+func EvaluateEphemeralContainers(evaluator Evaluator, lv api.LevelVersion, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, indexes []int) []CheckResult {
+	podLevelSpec := *podSpec
+	podLevelSpec.Containers = nil
+	podLevelSpec.InitContainers = nil
+	podLevelSpec.EphemeralContainers = nil
+	scopedSpec := podLevelSpec
+	scopedSpec.EphemeralContainers = make([]corev1.EphemeralContainer, 0, len(indexes))
+	for _, i := range indexes {
+		scopedSpec.EphemeralContainers = append(scopedSpec.EphemeralContainers, podSpec.EphemeralContainers[i])
+	}
+
+	results := evaluator.EvaluatePod(lv, podMetadata, &scopedSpec)
+	// the checks evaluated for a level & version do not depend on the pod, so the results of both evaluations are in the same order
+	podLevelResults := evaluator.EvaluatePod(lv, podMetadata, &podLevelSpec)
+	scoped := make([]CheckResult, 0, len(results))
+	for i, result := range results {
+		if crossContainerChecks.Has(result.ID) {
+			// evaluated against all the containers by evaluateCrossContainerChecks
+			continue
+		}
+		if result.Allowed || result.Error != nil {
+			scoped = append(scoped, result)
+			continue
+		}
+		if i < len(podLevelResults) && podLevelResults[i].ID == result.ID && podLevelViolation(podLevelResults[i], result) {
+			scoped = append(scoped, CheckResult{
+				ID:       result.ID,
+				Allowed:  true,
+				Version:  result.Version,
+				Source:   result.Source,
+				Severity: result.Severity,
+			})
+			continue
+		}
+		if result.ErrList != nil {
+			errs := ephemeralContainerFieldErrors(*result.ErrList, indexes)
+			result.ErrList = &errs
+		}
+		scoped = append(scoped, result)
+	}
+	return evaluateCrossContainerChecks(evaluator, lv, podMetadata, podSpec, indexes, results, scoped)
+}
+
+// crossContainerChecks are the checks relating the containers of pods to each other.
+var crossContainerChecks = sets.New(checkDuplicateContainersID)
+
+// evaluateCrossContainerChecks replaces the violations of the crossContainerChecks in the results scoped to the ephemeral
+// containers at the indexes with the violations the containers add to the whole pod, comparing the evaluations
+// of the pod with and without them. Their field errors already have the paths of the containers in the pod.
+func evaluateCrossContainerChecks(evaluator Evaluator, lv api.LevelVersion, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, indexes []int, results, scoped []CheckResult) []CheckResult {
+	evaluate := false
+	for _, result := range results {
+		if crossContainerChecks.Has(result.ID) {
+			evaluate = true
+			break
+		}
+	}
+	if !evaluate {
+		return scoped
+	}
+
+	evaluated := sets.New(indexes...)
+	otherSpec := *podSpec
+	otherSpec.EphemeralContainers = nil
+	for i, c := range podSpec.EphemeralContainers {
+		if !evaluated.Has(i) {
+			otherSpec.EphemeralContainers = append(otherSpec.EphemeralContainers, c)
+		}
+	}
+	podResults := resultsByID(evaluator.EvaluatePod(lv, podMetadata, podSpec))
+	otherResults := resultsByID(evaluator.EvaluatePod(lv, podMetadata, &otherSpec))
+	for i, result := range results {
+		if !crossContainerChecks.Has(result.ID) {
+			continue
+		}
+		podResult, ok := podResults[result.ID]
+		if !ok {
+			podResult = result
+		}
+		if otherResult, ok := otherResults[result.ID]; ok && !podResult.Allowed && podResult.Error == nil && podLevelViolation(otherResult, podResult) {
+			podResult = CheckResult{
+				ID:       podResult.ID,
+				Allowed:  true,
+				Version:  podResult.Version,
+				Source:   podResult.Source,
+				Severity: podResult.Severity,
+			}
+		}
+		// the results were appended to scoped in order, skipping the cross-container checks
+		scoped = append(scoped[:i], append([]CheckResult{podResult}, scoped[i:]...)...)
+	}
+	return scoped
+}
+
+// resultsByID returns the results by check ID.
+func resultsByID(results []CheckResult) map[CheckID]CheckResult {
+	byID := make(map[CheckID]CheckResult, len(results))
+	for _, result := range results {
+		byID[result.ID] = result
+	}
+	return byID
+}
+
+// podLevelViolation returns true if the violation of the evaluated containers is the violation of the pod-level fields,
+// i.e. the containers do not add to it.
+func podLevelViolation(podLevel, result CheckResult) bool {
+	return !podLevel.Allowed && podLevel.Error == nil &&
+		podLevel.ForbiddenReason == result.ForbiddenReason && podLevel.ForbiddenDetail == result.ForbiddenDetail
+}
+
+// ephemeralContainerFieldErrors returns copies of the field errors of the evaluated ephemeral containers,
+// with the index of each container in the paths replaced by its index in the pod.
+func ephemeralContainerFieldErrors(errs field.ErrorList, indexes []int) field.ErrorList {
+	prefix := ephemeralContainersFldPath.String() + "["
+	mapped := make(field.ErrorList, 0, len(errs))
+	for _, err := range errs {
+		if err == nil {
+			mapped = append(mapped, nil)
+			continue
+		}
+		mappedErr := *err
+		if rest, ok := strings.CutPrefix(err.Field, prefix); ok {
+			if index, rest, ok := strings.Cut(rest, "]"); ok {
+				if i, convErr := strconv.Atoi(index); convErr == nil && i >= 0 && i < len(indexes) {
+					mappedErr.Field = ephemeralContainersFldPath.Index(indexes[i]).String() + rest
+				}
+			}
+		}
+		mapped = append(mapped, &mappedErr)
+	}
+	return mapped
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/utils/pointer"
+)
+
+func TestEvaluateEphemeralContainers(t *testing.T) {
+	debugContainer := func(name string, securityContext *corev1.SecurityContext) corev1.EphemeralContainer {
+		return corev1.EphemeralContainer{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: name, Image: "busybox", SecurityContext: securityContext}}
+	}
+	podSpec := &corev1.PodSpec{
+		HostNetwork: true,
+		SecurityContext: &corev1.PodSecurityContext{
+			SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined},
+		},
+		Containers: []corev1.Container{{
+			Name:            "app",
+			SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(true)},
+		}},
+		EphemeralContainers: []corev1.EphemeralContainer{
+			debugContainer("debugger-old", &corev1.SecurityContext{Privileged: pointer.Bool(true)}),
+			debugContainer("debugger-new", &corev1.SecurityContext{
+				Capabilities: &corev1.Capabilities{Add: []corev1.Capability{"SYS_ADMIN"}},
+			}),
+		},
+	}
+	evaluator, err := NewEvaluator(DefaultChecks(), WithFieldErrors())
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name             string
+		level            api.Level
+		indexes          []int
+		expectViolations map[CheckID][]string
+	}{{
+		name:    "added container",
+		level:   api.LevelBaseline,
+		indexes: []int{1},
+		// the hostNetwork, seccompProfile and privileged violations of the pod and its other containers are not reported
+		expectViolations: map[CheckID][]string{
+			"capabilities_baseline": {"spec.ephemeralContainers[1].securityContext.capabilities.add"},
+		},
+	}, {
+		name:    "added containers",
+		level:   api.LevelBaseline,
+		indexes: []int{0, 1},
+		expectViolations: map[CheckID][]string{
+			"capabilities_baseline": {"spec.ephemeralContainers[1].securityContext.capabilities.add"},
+			"privileged":            {"spec.ephemeralContainers[0].securityContext.privileged"},
+		},
+	}, {
+		name:    "restricted",
+		level:   api.LevelRestricted,
+		indexes: []int{1},
+		// the pod-level seccompProfile violation is not reported, and the capabilities_baseline check is overridden
+		expectViolations: map[CheckID][]string{
+			"capabilities_restricted":  {"spec.ephemeralContainers[1].securityContext.capabilities.drop", "spec.ephemeralContainers[1].securityContext.capabilities.add"},
+			"allowPrivilegeEscalation": {"spec.ephemeralContainers[1].securityContext.allowPrivilegeEscalation"},
+			"runAsNonRoot":             {"spec.ephemeralContainers[1].securityContext.runAsNonRoot"},
+		},
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lv := api.LevelVersion{Level: tc.level, Version: api.LatestVersion()}
+			results := EvaluateEphemeralContainers(evaluator, lv, &metav1.ObjectMeta{}, podSpec, tc.indexes)
+			violations := map[CheckID][]string{}
+			for _, result := range results {
+				require.NoError(t, result.Error)
+				if result.Allowed {
+					continue
+				}
+				var paths []string
+				for _, err := range *result.ErrList {
+					paths = append(paths, err.Field)
+				}
+				violations[result.ID] = paths
+			}
+			assert.Equal(t, tc.expectViolations, violations)
+		})
+	}
+}
+
+func TestEvaluateEphemeralContainersDuplicateNames(t *testing.T) {
+	debugContainer := func(name string) corev1.EphemeralContainer {
+		return corev1.EphemeralContainer{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: name, Image: "busybox"}}
+	}
+	evaluator, err := NewEvaluator(append(DefaultChecks(), CheckDuplicateContainers()), WithFieldErrors())
+	require.NoError(t, err)
+	lv := api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}
+
+	testCases := []struct {
+		name             string
+		podSpec          *corev1.PodSpec
+		indexes          []int
+		expectViolations map[CheckID][]string
+	}{{
+		name: "unique name",
+		podSpec: &corev1.PodSpec{
+			Containers:          []corev1.Container{{Name: "app"}},
+			EphemeralContainers: []corev1.EphemeralContainer{debugContainer("debugger")},
+		},
+		indexes:          []int{0},
+		expectViolations: map[CheckID][]string{},
+	}, {
+		name: "name of a container",
+		podSpec: &corev1.PodSpec{
+			InitContainers:      []corev1.Container{{Name: "init"}},
+			Containers:          []corev1.Container{{Name: "app"}},
+			EphemeralContainers: []corev1.EphemeralContainer{debugContainer("debugger"), debugContainer("app")},
+		},
+		indexes: []int{1},
+		expectViolations: map[CheckID][]string{
+			checkDuplicateContainersID: {"spec.ephemeralContainers[1].name"},
+		},
+	}, {
+		name: "name of an init container",
+		podSpec: &corev1.PodSpec{
+			InitContainers:      []corev1.Container{{Name: "init"}},
+			Containers:          []corev1.Container{{Name: "app"}},
+			EphemeralContainers: []corev1.EphemeralContainer{debugContainer("init")},
+		},
+		indexes: []int{0},
+		expectViolations: map[CheckID][]string{
+			checkDuplicateContainersID: {"spec.ephemeralContainers[0].name"},
+		},
+	}, {
+		name: "existing duplicates",
+		podSpec: &corev1.PodSpec{
+			InitContainers:      []corev1.Container{{Name: "app"}},
+			Containers:          []corev1.Container{{Name: "app"}},
+			EphemeralContainers: []corev1.EphemeralContainer{debugContainer("debugger")},
+		},
+		// the duplicate names of the other containers cannot be changed by the update
+		indexes:          []int{0},
+		expectViolations: map[CheckID][]string{},
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			results := EvaluateEphemeralContainers(evaluator, lv, &metav1.ObjectMeta{}, tc.podSpec, tc.indexes)
+			assert.Len(t, results, len(evaluator.EvaluatePod(lv, &metav1.ObjectMeta{}, tc.podSpec)))
+			violations := map[CheckID][]string{}
+			for _, result := range results {
+				require.NoError(t, result.Error)
+				if result.Allowed {
+					continue
+				}
+				var paths []string
+				for _, err := range *result.ErrList {
+					paths = append(paths, err.Field)
+				}
+				violations[result.ID] = paths
+			}
+			assert.Equal(t, tc.expectViolations, violations)
+		})
+	}
+}
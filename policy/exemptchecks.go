@@ -0,0 +1,69 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"k8s.io/pod-security-admission/api"
+)
+
+// FilterExemptChecks splits the check IDs listed in the exempt-checks annotation of a namespace (see api.ExemptChecks)
+// into the checks exempted for its pods, and the checks ignored because they are evaluated at the level of the enforce floor,
+// if set, so namespace annotations cannot relax the floor. Checks of unknown levels, e.g. of evaluators not created with
+// NewEvaluator, are evaluated at the floor.
+func FilterExemptChecks(evaluator Evaluator, enforceFloor *api.LevelVersion, ids []string) (exempt, ignored []CheckID) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var levels map[CheckID]api.Level
+	if enforceFloor != nil && enforceFloor.Level != api.LevelPrivileged {
+		levels = map[CheckID]api.Level{}
+		for _, check := range EvaluatorChecks(evaluator) {
+			levels[check.ID] = check.Level
+		}
+	}
+	for _, id := range ids {
+		checkID := CheckID(id)
+		if levels != nil {
+			if level, ok := levels[checkID]; !ok || api.CompareLevels(level, enforceFloor.Level) <= 0 {
+				ignored = append(ignored, checkID)
+				continue
+			}
+		}
+		exempt = append(exempt, checkID)
+	}
+	return exempt, ignored
+}
+
+// UnknownExemptChecks returns the check IDs listed in the exempt-checks annotation of a namespace (see api.ExemptChecks)
+// that are not registered in the evaluator, or nil if the evaluator was not created with NewEvaluator.
+func UnknownExemptChecks(evaluator Evaluator, ids []string) []string {
+	checks := EvaluatorChecks(evaluator)
+	if len(ids) == 0 || checks == nil {
+		return nil
+	}
+	known := make(map[CheckID]bool, len(checks))
+	for _, check := range checks {
+		known[check.ID] = true
+	}
+	var unknown []string
+	for _, id := range ids {
+		if !known[CheckID(id)] {
+			unknown = append(unknown, id)
+		}
+	}
+	return unknown
+}
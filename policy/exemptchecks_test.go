@@ -0,0 +1,58 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	"k8s.io/pod-security-admission/api"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterExemptChecks(t *testing.T) {
+	evaluator, err := NewEvaluator(DefaultChecks())
+	require.NoError(t, err)
+	ids := []string{"privileged", "runAsNonRoot", "example.com/unknown"}
+
+	exempt, ignored := FilterExemptChecks(evaluator, nil, ids)
+	assert.Equal(t, []CheckID{"privileged", "runAsNonRoot", "example.com/unknown"}, exempt)
+	assert.Empty(t, ignored)
+
+	exempt, ignored = FilterExemptChecks(evaluator, &api.LevelVersion{Level: api.LevelPrivileged, Version: api.LatestVersion()}, ids)
+	assert.Equal(t, []CheckID{"privileged", "runAsNonRoot", "example.com/unknown"}, exempt)
+	assert.Empty(t, ignored)
+
+	exempt, ignored = FilterExemptChecks(evaluator, &api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}, ids)
+	assert.Equal(t, []CheckID{"runAsNonRoot"}, exempt)
+	assert.Equal(t, []CheckID{"privileged", "example.com/unknown"}, ignored)
+
+	exempt, ignored = FilterExemptChecks(evaluator, nil, nil)
+	assert.Nil(t, exempt)
+	assert.Nil(t, ignored)
+}
+
+func TestUnknownExemptChecks(t *testing.T) {
+	evaluator, err := NewEvaluator(DefaultChecks())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"example.com/unknown", "hostPort"}, UnknownExemptChecks(evaluator, []string{"example.com/unknown", "hostPort", "hostPorts"}))
+	assert.Nil(t, UnknownExemptChecks(evaluator, []string{"hostPorts"}))
+	assert.Nil(t, UnknownExemptChecks(evaluator, nil))
+	assert.Nil(t, UnknownExemptChecks(nil, []string{"example.com/unknown"}), "checks of other evaluators are unknown")
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"k8s.io/component-base/featuregate"
+)
+
+const (
+	// UserNamespacesSupport mirrors the Kubernetes feature gate enabling pods to opt
+	// into user namespaces by setting hostUsers: false.
+	UserNamespacesSupport featuregate.Feature = "UserNamespacesSupport"
+
+	// UserNamespacesPodSecurityStandards relaxes the runAsUser / runAsNonRoot restricted
+	// checks for pods running in a user namespace.
+	// This should only be enabled in clusters where the administrator ensures
+	// all nodes in the cluster enable the user namespace feature.
+	UserNamespacesPodSecurityStandards featuregate.Feature = "UserNamespacesPodSecurityStandards"
+
+	// SupplementalGroupsPolicy mirrors the Kubernetes feature gate adding
+	// securityContext.supplementalGroupsPolicy to pods.
+	SupplementalGroupsPolicy featuregate.Feature = "SupplementalGroupsPolicy"
+
+	// PodLevelCapabilities makes the capabilities checks consult a pod-level securityContext.capabilities
+	// default for containers that do not set capabilities, once the field is added to pods.
+	PodLevelCapabilities featuregate.Feature = "PodLevelCapabilities"
+)
+
+// defaultFeatureGates holds the specs of the feature gates checks may depend on.
+var defaultFeatureGates = map[featuregate.Feature]featuregate.FeatureSpec{
+	UserNamespacesSupport:              {Default: false, PreRelease: featuregate.Beta},
+	UserNamespacesPodSecurityStandards: {Default: false, PreRelease: featuregate.Alpha},
+	SupplementalGroupsPolicy:           {Default: false, PreRelease: featuregate.Alpha},
+	PodLevelCapabilities:               {Default: false, PreRelease: featuregate.Alpha},
+}
+
+// AddFeatureGates registers the feature gates checks may depend on with the given feature gate.
+// Callers that already register the Kubernetes feature gates of the same name do not need to call this.
+func AddFeatureGates(gate featuregate.MutableFeatureGate) error {
+	return gate.Add(defaultFeatureGates)
+}
+
+// WithFeatureGate evaluates checks with the features enabled in the given feature gate.
+// The enabled state of every feature known to the gate is captured when the option is applied.
+//
+// When passed to NewEvaluator, checks with RequiredFeatures that are not all enabled are not registered,
+// and checks with behavior that depends on a feature (like UserNamespacesPodSecurityStandards) use the
+// enabled state captured at construction.
+func WithFeatureGate(gate featuregate.FeatureGate) Option {
+	enabled := map[featuregate.Feature]bool{}
+	if gate != nil {
+		for feature := range gate.DeepCopy().GetAll() {
+			enabled[feature] = gate.Enabled(feature)
+		}
+	}
+	return func(opt options) options {
+		opt.features = enabled
+		return opt
+	}
+}
+
+// featureEnabled returns true if the given feature was enabled in the feature gate the options were constructed with.
+func (o options) featureEnabled(feature featuregate.Feature) bool {
+	return o.features[feature]
+}
+
+// featuresEnabled returns true if all the given features are enabled.
+func (o options) featuresEnabled(features []featuregate.Feature) bool {
+	for _, feature := range features {
+		if !o.featureEnabled(feature) {
+			return false
+		}
+	}
+	return true
+}
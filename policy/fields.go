@@ -0,0 +1,181 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// anyIndex is the key standing for any list index or map key in restricted field paths.
+const anyIndex = "*"
+
+// restrictedFields are the fields restricted by each check, built from the paths of the field errors returned by the checks.
+// Container fields are listed for spec.initContainers[*], spec.containers[*] and spec.ephemeralContainers[*].
+var restrictedFields = map[CheckID][]*field.Path{
+	"allowPrivilegeEscalation": containerFields("securityContext", "allowPrivilegeEscalation"),
+	"appArmorProfile": append(containerFields("securityContext", "appArmorProfile", "type"),
+		appArmorProfileTypePath,
+		annotationsPath.Key(corev1.DeprecatedAppArmorBetaContainerAnnotationKeyPrefix+anyIndex),
+	),
+	checkCapabilitiesBaselineID: append(containerFields("securityContext", "capabilities", "add"),
+		securityContextPath.Child("capabilities", "add"),
+	),
+	"capabilities_restricted": append(containerFields("securityContext", "capabilities", "add"),
+		append(containerFields("securityContext", "capabilities", "drop"),
+			securityContextPath.Child("capabilities", "add"),
+			securityContextPath.Child("capabilities", "drop"),
+		)...,
+	),
+	checkDuplicateContainersID: append(containerFields("name"),
+		initContainersFldPath.Key(anyIndex).Child("securityContext"),
+	),
+	checkLocalhostProfilesID: append(containerFields("securityContext", "seccompProfile", "localhostProfile"),
+		append(containerFields("securityContext", "appArmorProfile", "localhostProfile"),
+			securityContextPath.Child("seccompProfile", "localhostProfile"),
+			securityContextPath.Child("appArmorProfile", "localhostProfile"),
+			annotationsPath.Key(annotationKeyPod),
+			annotationsPath.Key(annotationKeyContainerPrefix+anyIndex),
+			annotationsPath.Key(corev1.DeprecatedAppArmorBetaContainerAnnotationKeyPrefix+anyIndex),
+		)...,
+	),
+	checkHostBreakoutCommandsID: append(containerFields("command"), containerFields("args")...),
+	"hostNamespaces":            {hostNetworkPath, hostPIDPath, hostIPCPath},
+	checkHostPathVolumesID:      {volumesPath.Key(anyIndex).Child("hostPath")},
+	"hostPorts":                 hostPortFields(),
+	checkImagePolicyID:          containerFields("image"),
+	"privileged":                containerFields("securityContext", "privileged"),
+	"procMount":                 containerFields("securityContext", "procMount"),
+	checkReadOnlyHostPathVolumesID: append(volumeMountFields("readOnly"),
+		volumesPath.Key(anyIndex).Child("hostPath", "path"),
+		volumesPath.Key(anyIndex).Child("hostPath", "type"),
+	),
+	checkReadOnlyRootFilesystemID: containerFields("securityContext", "readOnlyRootFilesystem"),
+	checkResourceClaimsID:         {resourceClaimsPath.Key(anyIndex)},
+	checkResourceLimitsID: {
+		initContainersFldPath.Key(anyIndex).Child("resources", "limits"),
+		containersFldPath.Key(anyIndex).Child("resources", "limits"),
+	},
+	"restrictedVolumes": volumeFields(
+		"hostPath", "gcePersistentDisk", "awsElasticBlockStore", "gitRepo", "nfs", "iscsi", "glusterfs", "rbd",
+		"flexVolume", "cinder", "cephfs", "flocker", "fc", "azureFile", "vsphereVolume", "quobyte", "azureDisk",
+		"portworxVolume", "photonPersistentDisk", "scaleIO", "storageos",
+	),
+	checkRootExecCommandsID: append(containerFields("livenessProbe", "exec", "command"),
+		append(containerFields("readinessProbe", "exec", "command"),
+			append(containerFields("startupProbe", "exec", "command"),
+				append(containerFields("lifecycle", "postStart", "exec", "command"),
+					containerFields("lifecycle", "preStop", "exec", "command")...,
+				)...,
+			)...,
+		)...,
+	),
+	"runAsNonRoot": append(containerFields("securityContext", "runAsNonRoot"), runAsNonRootPath),
+	"runAsUser":    append(containerFields("securityContext", "runAsUser"), runAsUserPath),
+	"seLinuxOptions": append(containerFields("securityContext", "seLinuxOptions", "type"),
+		append(containerFields("securityContext", "seLinuxOptions", "user"),
+			append(containerFields("securityContext", "seLinuxOptions", "role"),
+				seLinuxOptionsTypePath,
+				seLinuxOptionsUserPath,
+				seLinuxOptionsRolePath,
+			)...,
+		)...,
+	),
+	checkSeccompBaselineID: append(containerFields("securityContext", "seccompProfile", "type"),
+		seccompProfileTypePath,
+		annotationsPath.Key(annotationKeyPod),
+		annotationsPath.Key(annotationKeyContainerPrefix+anyIndex),
+	),
+	"seccompProfile_restricted": append(containerFields("securityContext", "seccompProfile", "type"), seccompProfileTypePath),
+	checkSupplementalGroupsID: {
+		supplementalGroupsPath.Key(anyIndex),
+		fsGroupPath,
+		supplementalGroupsPolicyPath,
+		fsGroupChangePolicyPath,
+	},
+	"sysctls":            {sysctlsPath.Key(anyIndex).Child("name")},
+	"windowsHostProcess": append(containerFields("securityContext", "windowsOptions", "hostProcess"), hostProcessPath),
+}
+
+// containerFields returns the paths of the given container field in all container lists.
+func containerFields(name string, moreNames ...string) []*field.Path {
+	return []*field.Path{
+		initContainersFldPath.Key(anyIndex).Child(name, moreNames...),
+		containersFldPath.Key(anyIndex).Child(name, moreNames...),
+		ephemeralContainersFldPath.Key(anyIndex).Child(name, moreNames...),
+	}
+}
+
+// hostPortFields returns the paths of the host ports in all container lists.
+func hostPortFields() []*field.Path {
+	var paths []*field.Path
+	for _, ports := range containerFields("ports") {
+		paths = append(paths, ports.Key(anyIndex).Child("hostPort"))
+	}
+	return paths
+}
+
+// volumeMountFields returns the paths of the given volume mount field in all container lists.
+func volumeMountFields(name string) []*field.Path {
+	var paths []*field.Path
+	for _, mounts := range containerFields("volumeMounts") {
+		paths = append(paths, mounts.Key(anyIndex).Child(name))
+	}
+	return paths
+}
+
+// volumeFields returns the paths of the given volume sources.
+func volumeFields(sources ...string) []*field.Path {
+	paths := make([]*field.Path, 0, len(sources))
+	for _, source := range sources {
+		paths = append(paths, volumesPath.Key(anyIndex).Child(source))
+	}
+	return paths
+}
+
+// RestrictedFields returns the paths of the fields restricted by the checks, by check ID.
+// List indices and the variable part of map keys are represented as "*",
+// e.g. spec.containers[*].securityContext.privileged or
+// metadata.annotations[container.seccomp.security.alpha.kubernetes.io/*].
+// The paths are the ones reported in field errors by evaluators with WithFieldErrors,
+// so external mutating webhooks can default or strip them to achieve compliance.
+// It returns a new copy of the paths on each invocation.
+func RestrictedFields() map[CheckID][]*field.Path {
+	retval := make(map[CheckID][]*field.Path, len(restrictedFields))
+	for id, paths := range restrictedFields {
+		retval[id] = append([]*field.Path(nil), paths...)
+	}
+	return retval
+}
+
+// ResourceChecks returns the IDs of the checks restricting the resources of containers, which are changed by in-place resizes.
+func ResourceChecks() []CheckID {
+	var ids []CheckID
+	for id, paths := range restrictedFields {
+		for _, path := range paths {
+			if strings.Contains(path.String()+".", ".resources.") {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
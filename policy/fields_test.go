@@ -0,0 +1,102 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/pod-security-admission/api"
+	"sigs.k8s.io/yaml"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestrictedFieldsChecks(t *testing.T) {
+	ids := sets.New[CheckID]()
+	for _, c := range append(append(DefaultChecks(), ExperimentalChecks()...), OptionalChecks()...) {
+		ids.Insert(c.ID)
+	}
+	fields := RestrictedFields()
+	for id := range fields {
+		assert.True(t, ids.Has(id), "restricted fields for unknown check %s", id)
+	}
+	for _, id := range sets.List(ids) {
+		assert.NotEmpty(t, fields[id], "missing restricted fields for check %s", id)
+	}
+
+	fields[checkHostPathVolumesID][0] = nil
+	assert.NotNil(t, RestrictedFields()[checkHostPathVolumesID][0], "returned paths should be a copy")
+}
+
+// TestRestrictedFieldsFixtures ensures the field errors returned for the fixtures are at restricted fields of the failed checks.
+func TestRestrictedFieldsFixtures(t *testing.T) {
+	evaluator, err := NewEvaluator(DefaultChecks(), WithFieldErrors())
+	require.NoError(t, err)
+
+	patterns := map[CheckID][]*regexp.Regexp{}
+	for id, paths := range RestrictedFields() {
+		for _, path := range paths {
+			// "*" matches any index or key, and nested fields are covered by their parent
+			pattern := strings.ReplaceAll(regexp.QuoteMeta(path.String()), regexp.QuoteMeta(anyIndex), `[^\]]*`)
+			patterns[id] = append(patterns[id], regexp.MustCompile(`^`+pattern+`($|[.\[])`))
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join("..", "test", "testdata", "*", "*", "fail", "*.yaml"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	for _, file := range files {
+		// test/testdata/<level>/<version>/fail/<name>.yaml
+		parts := strings.Split(filepath.ToSlash(file), "/")
+		level, err := api.ParseLevel(parts[len(parts)-4])
+		require.NoError(t, err)
+		version, err := api.ParseVersion(parts[len(parts)-3])
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		pod := &corev1.Pod{}
+		require.NoError(t, yaml.Unmarshal(data, pod), file)
+
+		for _, result := range evaluator.EvaluatePod(api.LevelVersion{Level: level, Version: version}, &pod.ObjectMeta, &pod.Spec) {
+			if result.Allowed || result.ErrList == nil {
+				continue
+			}
+			for _, fieldErr := range *result.ErrList {
+				covered := false
+				for _, pattern := range patterns[result.ID] {
+					if pattern.MatchString(fieldErr.Field) {
+						covered = true
+						break
+					}
+				}
+				assert.True(t, covered, "%s: %s is not a restricted field of check %s", file, fieldErr.Field, result.ID)
+			}
+		}
+	}
+}
+
+func TestResourceChecks(t *testing.T) {
+	assert.Equal(t, []CheckID{checkResourceLimitsID}, ResourceChecks())
+}
@@ -0,0 +1,291 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/pod-security-admission/api"
+)
+
+// Fixer mutates pods to satisfy a policy, e.g. to build a mutating webhook.
+// It is implemented by the Evaluator returned by NewEvaluator.
+type Fixer interface {
+	// FixPod returns a copy of the pod spec mutated to satisfy the checks of the level & version the pod violates,
+	// and the results of the checks the mutated pod spec still violates.
+	FixPod(lv api.LevelVersion, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec) (*corev1.PodSpec, []CheckResult)
+}
+
+var defaultFixer = sync.OnceValue(func() Fixer {
+	evaluator, err := NewEvaluator(DefaultChecks())
+	if err != nil {
+		panic(err)
+	}
+	return evaluator.(Fixer)
+})
+
+// Fix returns a copy of the pod spec mutated to satisfy the default checks of the level & version, like FixPod.
+//
+// Only fields the checks restrict to known safe values are fixed, e.g. forbidden capabilities are dropped, runAsNonRoot
+// is set, and the seccomp profile defaults to RuntimeDefault. Violations that cannot be fixed without changing the
+// behavior of the workload, like hostPath volumes, runAsUser=0, or the annotations of the pod metadata, are returned.
+// The Linux-only fields of Windows pods are not set, since they are rejected by the API server.
+func Fix(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, lv api.LevelVersion) (*corev1.PodSpec, []CheckResult) {
+	return defaultFixer().FixPod(lv, podMetadata, podSpec)
+}
+
+func (r *checkRegistry) FixPod(lv api.LevelVersion, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec) (*corev1.PodSpec, []CheckResult) {
+	fixed := podSpec.DeepCopy()
+	for _, result := range r.EvaluatePod(lv, podMetadata, fixed) {
+		if fix, ok := podFixes[result.ID]; ok && !result.Allowed {
+			fix(fixed, lv.Version)
+		}
+	}
+	var violations []CheckResult
+	for _, result := range r.EvaluatePod(lv, podMetadata, fixed) {
+		if !result.Allowed {
+			violations = append(violations, result)
+		}
+	}
+	return fixed, violations
+}
+
+// fixPodFn mutates the pod spec to satisfy the check at the given version.
+type fixPodFn func(podSpec *corev1.PodSpec, version api.Version)
+
+// podFixes are the fixes of the checks, by check ID.
+var podFixes = map[CheckID]fixPodFn{
+	"privileged":                fixPrivileged,
+	"hostNamespaces":            fixHostNamespaces,
+	"hostPorts":                 fixHostPorts,
+	"windowsHostProcess":        fixWindowsHostProcess,
+	"procMount":                 fixProcMount,
+	"seLinuxOptions":            fixSELinuxOptions,
+	"sysctls":                   fixSysctls,
+	"appArmorProfile":           fixAppArmorProfile,
+	checkCapabilitiesBaselineID: fixCapabilitiesBaseline,
+	checkSeccompBaselineID:      fixSeccompProfileBaseline,
+	"allowPrivilegeEscalation":  linuxOnlyFix(fixAllowPrivilegeEscalation),
+	"capabilities_restricted":   linuxOnlyFix(fixCapabilitiesRestricted),
+	"runAsNonRoot":              fixRunAsNonRoot,
+	"seccompProfile_restricted": linuxOnlyFix(fixSeccompProfileRestricted),
+}
+
+// linuxOnlyFix skips the fix for Windows pods, since the API server rejects Windows pods setting Linux-only fields.
+func linuxOnlyFix(fix fixPodFn) fixPodFn {
+	return func(podSpec *corev1.PodSpec, version api.Version) {
+		if podSpec.OS != nil && podSpec.OS.Name == corev1.Windows {
+			return
+		}
+		fix(podSpec, version)
+	}
+}
+
+// visitContainerSecurityContexts invokes the visitor with the securityContext of every container setting one.
+func visitContainerSecurityContexts(podSpec *corev1.PodSpec, visitor func(*corev1.SecurityContext)) {
+	visitContainers(podSpec, options{}, func(container *corev1.Container, _ ContainerKind, _ *field.Path) {
+		if container.SecurityContext != nil {
+			visitor(container.SecurityContext)
+		}
+	})
+}
+
+func fixPrivileged(podSpec *corev1.PodSpec, _ api.Version) {
+	visitContainerSecurityContexts(podSpec, func(sc *corev1.SecurityContext) {
+		if sc.Privileged != nil && *sc.Privileged {
+			sc.Privileged = nil
+		}
+	})
+}
+
+func fixHostNamespaces(podSpec *corev1.PodSpec, _ api.Version) {
+	podSpec.HostNetwork = false
+	podSpec.HostPID = false
+	podSpec.HostIPC = false
+}
+
+func fixHostPorts(podSpec *corev1.PodSpec, _ api.Version) {
+	visitContainers(podSpec, options{}, func(container *corev1.Container, _ ContainerKind, _ *field.Path) {
+		for i := range container.Ports {
+			container.Ports[i].HostPort = 0
+		}
+	})
+}
+
+func fixWindowsHostProcess(podSpec *corev1.PodSpec, _ api.Version) {
+	fix := func(windowsOptions *corev1.WindowsSecurityContextOptions) {
+		if windowsOptions != nil && windowsOptions.HostProcess != nil && *windowsOptions.HostProcess {
+			windowsOptions.HostProcess = nil
+		}
+	}
+	if podSpec.SecurityContext != nil {
+		fix(podSpec.SecurityContext.WindowsOptions)
+	}
+	visitContainerSecurityContexts(podSpec, func(sc *corev1.SecurityContext) {
+		fix(sc.WindowsOptions)
+	})
+}
+
+func fixProcMount(podSpec *corev1.PodSpec, _ api.Version) {
+	visitContainerSecurityContexts(podSpec, func(sc *corev1.SecurityContext) {
+		if sc.ProcMount != nil && *sc.ProcMount != corev1.DefaultProcMount {
+			sc.ProcMount = nil
+		}
+	})
+}
+
+func fixSELinuxOptions(podSpec *corev1.PodSpec, _ api.Version) {
+	fix := func(seLinuxOptions *corev1.SELinuxOptions) {
+		if seLinuxOptions == nil {
+			return
+		}
+		if !selinux_allowed_types_1_0.Has(seLinuxOptions.Type) {
+			seLinuxOptions.Type = ""
+		}
+		seLinuxOptions.User = ""
+		seLinuxOptions.Role = ""
+	}
+	if podSpec.SecurityContext != nil {
+		fix(podSpec.SecurityContext.SELinuxOptions)
+	}
+	visitContainerSecurityContexts(podSpec, func(sc *corev1.SecurityContext) {
+		fix(sc.SELinuxOptions)
+	})
+}
+
+func fixSysctls(podSpec *corev1.PodSpec, version api.Version) {
+	if podSpec.SecurityContext == nil {
+		return
+	}
+	allowed := sysctlsAllowedV1Dot0
+	if !version.Older(api.MajorMinorVersion(1, 29)) {
+		allowed = sysctlsAllowedV1Dot29
+	} else if !version.Older(api.MajorMinorVersion(1, 27)) {
+		allowed = sysctlsAllowedV1Dot27
+	}
+	var sysctls []corev1.Sysctl
+	for _, sysctl := range podSpec.SecurityContext.Sysctls {
+		if allowed.Has(sysctl.Name) {
+			sysctls = append(sysctls, sysctl)
+		}
+	}
+	podSpec.SecurityContext.Sysctls = sysctls
+}
+
+func fixAppArmorProfile(podSpec *corev1.PodSpec, _ api.Version) {
+	fix := func(profile *corev1.AppArmorProfile) {
+		if profile != nil && !allowedProfileType(profile.Type) {
+			*profile = corev1.AppArmorProfile{Type: corev1.AppArmorProfileTypeRuntimeDefault}
+		}
+	}
+	if podSpec.SecurityContext != nil {
+		fix(podSpec.SecurityContext.AppArmorProfile)
+	}
+	visitContainerSecurityContexts(podSpec, func(sc *corev1.SecurityContext) {
+		fix(sc.AppArmorProfile)
+	})
+}
+
+func fixCapabilitiesBaseline(podSpec *corev1.PodSpec, _ api.Version) {
+	visitContainerSecurityContexts(podSpec, func(sc *corev1.SecurityContext) {
+		if sc.Capabilities == nil {
+			return
+		}
+		var add []corev1.Capability
+		for _, c := range sc.Capabilities.Add {
+			if capabilities_allowed_1_0.Has(string(c)) {
+				add = append(add, c)
+			}
+		}
+		sc.Capabilities.Add = add
+	})
+}
+
+func fixSeccompProfileBaseline(podSpec *corev1.PodSpec, _ api.Version) {
+	fix := func(profile *corev1.SeccompProfile) {
+		if profile != nil && !validSeccomp(profile.Type) {
+			*profile = corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+		}
+	}
+	if podSpec.SecurityContext != nil {
+		fix(podSpec.SecurityContext.SeccompProfile)
+	}
+	visitContainerSecurityContexts(podSpec, func(sc *corev1.SecurityContext) {
+		fix(sc.SeccompProfile)
+	})
+}
+
+func fixAllowPrivilegeEscalation(podSpec *corev1.PodSpec, _ api.Version) {
+	visitContainers(podSpec, options{}, func(container *corev1.Container, _ ContainerKind, _ *field.Path) {
+		if container.SecurityContext == nil {
+			container.SecurityContext = &corev1.SecurityContext{}
+		}
+		allowPrivilegeEscalation := false
+		container.SecurityContext.AllowPrivilegeEscalation = &allowPrivilegeEscalation
+	})
+}
+
+func fixCapabilitiesRestricted(podSpec *corev1.PodSpec, _ api.Version) {
+	visitContainers(podSpec, options{}, func(container *corev1.Container, _ ContainerKind, _ *field.Path) {
+		if container.SecurityContext == nil {
+			container.SecurityContext = &corev1.SecurityContext{}
+		}
+		if container.SecurityContext.Capabilities == nil {
+			container.SecurityContext.Capabilities = &corev1.Capabilities{}
+		}
+		capabilities := container.SecurityContext.Capabilities
+		var add []corev1.Capability
+		for _, c := range capabilities.Add {
+			if c == capabilityNetBindService {
+				add = append(add, c)
+			}
+		}
+		capabilities.Add = add
+		capabilities.Drop = []corev1.Capability{capabilityAll}
+	})
+}
+
+func fixRunAsNonRoot(podSpec *corev1.PodSpec, _ api.Version) {
+	if podSpec.SecurityContext == nil {
+		podSpec.SecurityContext = &corev1.PodSecurityContext{}
+	}
+	runAsNonRoot := true
+	podSpec.SecurityContext.RunAsNonRoot = &runAsNonRoot
+	visitContainerSecurityContexts(podSpec, func(sc *corev1.SecurityContext) {
+		if sc.RunAsNonRoot != nil && !*sc.RunAsNonRoot {
+			sc.RunAsNonRoot = nil
+		}
+	})
+}
+
+func fixSeccompProfileRestricted(podSpec *corev1.PodSpec, _ api.Version) {
+	if podSpec.SecurityContext == nil {
+		podSpec.SecurityContext = &corev1.PodSecurityContext{}
+	}
+	if podSpec.SecurityContext.SeccompProfile == nil || !validSeccomp(podSpec.SecurityContext.SeccompProfile.Type) {
+		podSpec.SecurityContext.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+	}
+	// containers inherit the pod seccompProfile
+	visitContainerSecurityContexts(podSpec, func(sc *corev1.SecurityContext) {
+		if sc.SeccompProfile != nil && !validSeccomp(sc.SeccompProfile.Type) {
+			sc.SeccompProfile = nil
+		}
+	})
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/utils/pointer"
+)
+
+func TestFix(t *testing.T) {
+	violatingPod := func() *corev1.Pod {
+		return &corev1.Pod{Spec: corev1.PodSpec{
+			HostNetwork: true,
+			SecurityContext: &corev1.PodSecurityContext{
+				SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined},
+				SELinuxOptions: &corev1.SELinuxOptions{Type: "spc_t", Level: "s0:c123,c456"},
+				Sysctls: []corev1.Sysctl{
+					{Name: "kernel.shm_rmid_forced", Value: "0"},
+					{Name: "net.ipv4.ip_local_reserved_ports", Value: "1024"},
+					{Name: "kernel.msgmax", Value: "65536"},
+				},
+			},
+			Containers: []corev1.Container{{
+				Name:  "a",
+				Ports: []corev1.ContainerPort{{ContainerPort: 80, HostPort: 80}},
+				SecurityContext: &corev1.SecurityContext{
+					Privileged:   pointer.Bool(true),
+					RunAsNonRoot: pointer.Bool(false),
+					ProcMount:    func() *corev1.ProcMountType { p := corev1.UnmaskedProcMount; return &p }(),
+					Capabilities: &corev1.Capabilities{Add: []corev1.Capability{"SYS_ADMIN", "NET_BIND_SERVICE", "CHOWN"}},
+				},
+			}},
+			InitContainers: []corev1.Container{{Name: "init"}},
+		}}
+	}
+
+	testCases := []struct {
+		name             string
+		lv               api.LevelVersion
+		pod              *corev1.Pod
+		expectViolations []CheckID
+		expectFixed      func(t *testing.T, spec *corev1.PodSpec)
+	}{{
+		name: "privileged",
+		lv:   api.LevelVersion{Level: api.LevelPrivileged, Version: api.LatestVersion()},
+		pod:  violatingPod(),
+		expectFixed: func(t *testing.T, spec *corev1.PodSpec) {
+			assert.Equal(t, violatingPod().Spec, *spec)
+		},
+	}, {
+		name: "baseline",
+		lv:   api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()},
+		pod:  violatingPod(),
+		expectFixed: func(t *testing.T, spec *corev1.PodSpec) {
+			assert.False(t, spec.HostNetwork)
+			assert.Zero(t, spec.Containers[0].Ports[0].HostPort)
+			assert.Equal(t, []corev1.Capability{"NET_BIND_SERVICE", "CHOWN"}, spec.Containers[0].SecurityContext.Capabilities.Add)
+			assert.Nil(t, spec.Containers[0].SecurityContext.Privileged)
+			assert.Nil(t, spec.Containers[0].SecurityContext.ProcMount)
+			assert.Equal(t, &corev1.SELinuxOptions{Level: "s0:c123,c456"}, spec.SecurityContext.SELinuxOptions)
+			assert.Equal(t, corev1.SeccompProfileTypeRuntimeDefault, spec.SecurityContext.SeccompProfile.Type)
+			assert.Equal(t, []corev1.Sysctl{
+				{Name: "kernel.shm_rmid_forced", Value: "0"},
+				{Name: "net.ipv4.ip_local_reserved_ports", Value: "1024"},
+			}, spec.SecurityContext.Sysctls)
+			// restricted fields are not fixed
+			assert.Equal(t, pointer.Bool(false), spec.Containers[0].SecurityContext.RunAsNonRoot)
+			assert.Nil(t, spec.InitContainers[0].SecurityContext)
+		},
+	}, {
+		name: "baseline v1.26 sysctls",
+		lv:   api.LevelVersion{Level: api.LevelBaseline, Version: api.MajorMinorVersion(1, 26)},
+		pod:  violatingPod(),
+		expectFixed: func(t *testing.T, spec *corev1.PodSpec) {
+			assert.Equal(t, []corev1.Sysctl{{Name: "kernel.shm_rmid_forced", Value: "0"}}, spec.SecurityContext.Sysctls)
+		},
+	}, {
+		name: "restricted",
+		lv:   api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()},
+		pod: func() *corev1.Pod {
+			pod := violatingPod()
+			pod.Spec.Volumes = []corev1.Volume{{Name: "host", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/"}}}}
+			pod.Spec.Containers[0].SecurityContext.RunAsUser = pointer.Int64(0)
+			return pod
+		}(),
+		expectViolations: []CheckID{"restrictedVolumes", "runAsUser"},
+		expectFixed: func(t *testing.T, spec *corev1.PodSpec) {
+			for _, c := range []corev1.Container{spec.InitContainers[0], spec.Containers[0]} {
+				assert.Equal(t, pointer.Bool(false), c.SecurityContext.AllowPrivilegeEscalation, c.Name)
+				assert.Equal(t, []corev1.Capability{"ALL"}, c.SecurityContext.Capabilities.Drop, c.Name)
+				assert.Nil(t, c.SecurityContext.RunAsNonRoot, c.Name)
+			}
+			assert.Equal(t, []corev1.Capability{"NET_BIND_SERVICE"}, spec.Containers[0].SecurityContext.Capabilities.Add)
+			assert.Equal(t, pointer.Bool(true), spec.SecurityContext.RunAsNonRoot)
+			assert.Equal(t, corev1.SeccompProfileTypeRuntimeDefault, spec.SecurityContext.SeccompProfile.Type)
+		},
+	}, {
+		name: "restricted windows v1.24",
+		lv:   api.LevelVersion{Level: api.LevelRestricted, Version: api.MajorMinorVersion(1, 24)},
+		pod: &corev1.Pod{Spec: corev1.PodSpec{
+			OS:         &corev1.PodOS{Name: corev1.Windows},
+			Containers: []corev1.Container{{Name: "a"}},
+		}},
+		expectViolations: []CheckID{"allowPrivilegeEscalation", "capabilities_restricted", "seccompProfile_restricted"},
+		expectFixed: func(t *testing.T, spec *corev1.PodSpec) {
+			assert.Nil(t, spec.Containers[0].SecurityContext)
+			assert.Equal(t, &corev1.PodSecurityContext{RunAsNonRoot: pointer.Bool(true)}, spec.SecurityContext)
+		},
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			original := tc.pod.DeepCopy()
+			fixed, violations := Fix(&tc.pod.ObjectMeta, &tc.pod.Spec, tc.lv)
+			assert.Equal(t, original, tc.pod, "the pod must not be mutated")
+
+			var violatedIDs []CheckID
+			for _, v := range violations {
+				violatedIDs = append(violatedIDs, v.ID)
+			}
+			assert.ElementsMatch(t, tc.expectViolations, violatedIDs)
+			tc.expectFixed(t, fixed)
+		})
+	}
+}
+
+func TestFixAllChecks(t *testing.T) {
+	evaluator, err := NewEvaluator(DefaultChecks())
+	require.NoError(t, err)
+	// checks that cannot be fixed without changing the behavior of the workload
+	unfixable := map[CheckID]bool{checkHostPathVolumesID: true, "restrictedVolumes": true, "runAsUser": true}
+	for _, check := range DefaultChecks() {
+		if !unfixable[check.ID] {
+			assert.Contains(t, podFixes, check.ID, "check %s has no fix", check.ID)
+		}
+	}
+	_, ok := evaluator.(Fixer)
+	assert.True(t, ok)
+
+	// a pod fixed for the latest restricted policy is allowed by every version
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p"}, Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "a"}}}}
+	fixed, violations := Fix(&pod.ObjectMeta, &pod.Spec, api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()})
+	require.Empty(t, violations)
+	for minor := 0; minor <= evaluator.(*checkRegistry).maxVersion.Minor(); minor++ {
+		lv := api.LevelVersion{Level: api.LevelRestricted, Version: api.MajorMinorVersion(1, minor)}
+		for _, result := range evaluator.EvaluatePod(lv, &pod.ObjectMeta, fixed) {
+			assert.True(t, result.Allowed, "%s: %s", lv, result.ID)
+		}
+	}
+}
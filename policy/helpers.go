@@ -17,10 +17,12 @@ limitations under the License.
 package policy
 
 import (
+	"reflect"
 	"strings"
 	"sync/atomic"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
 func joinQuote(items []string) string {
@@ -45,12 +47,82 @@ var relaxPolicyForUserNamespacePods = &atomic.Bool{}
 // nodes.
 // This should only be opted into in clusters where the administrator ensures
 // all nodes in the cluster enable the user namespace feature.
+//
+// It applies to all the checks and evaluators of the process that are not
+// constructed with WithRelaxedUserNamespacePods. Evaluators constructed with
+// WithFeatureGate relax the policies when the UserNamespacesPodSecurityStandards
+// feature is enabled, regardless of this setting.
+//
+// Deprecated: use WithRelaxedUserNamespacePods, which applies to a single Evaluator.
 func RelaxPolicyForUserNamespacePods(relax bool) {
 	relaxPolicyForUserNamespacePods.Store(relax)
 }
 
+// WithRelaxedUserNamespacePods determines if runAsUser / runAsNonRoot restricted
+// policies are relaxed for user namespace pods, overriding the process-wide
+// RelaxPolicyForUserNamespacePods, so evaluators with different settings can be
+// hosted in the same process. Like RelaxPolicyForUserNamespacePods, it should only
+// relax policies in clusters where the administrator ensures all nodes enable the
+// user namespace feature. Policies are relaxed when the UserNamespacesPodSecurityStandards
+// feature is enabled by WithFeatureGate, regardless of this option.
+func WithRelaxedUserNamespacePods(relax bool) Option {
+	return func(opt options) options {
+		opt.relaxUserNamespacePods = &relax
+		return opt
+	}
+}
+
 // relaxPolicyForUserNamespacePod returns true if a policy should be relaxed
 // because of enabled user namespaces in the provided pod spec.
-func relaxPolicyForUserNamespacePod(podSpec *corev1.PodSpec) bool {
-	return relaxPolicyForUserNamespacePods.Load() && podSpec != nil && podSpec.HostUsers != nil && !*podSpec.HostUsers
+func relaxPolicyForUserNamespacePod(podSpec *corev1.PodSpec, opts options) bool {
+	return relaxUserNamespacePods(opts) && podSpec != nil && podSpec.HostUsers != nil && !*podSpec.HostUsers
+}
+
+// relaxUserNamespacePods returns true if policies are relaxed for pods with enabled user namespaces.
+func relaxUserNamespacePods(opts options) bool {
+	if opts.featureEnabled(UserNamespacesPodSecurityStandards) {
+		return true
+	}
+	if opts.relaxUserNamespacePods != nil {
+		return *opts.relaxUserNamespacePods
+	}
+	return relaxPolicyForUserNamespacePods.Load()
+}
+
+// podSecurityContextField returns the value of the named pod securityContext field, if the field exists
+// in the compiled PodSecurityContext type and is set. Fields are looked up by name so checks can
+// evaluate fields added to pods after the vendored k8s.io/api.
+func podSecurityContextField(podSpec *corev1.PodSpec, name string) (reflect.Value, bool) {
+	if podSpec.SecurityContext == nil {
+		return reflect.Value{}, false
+	}
+	f := reflect.ValueOf(podSpec.SecurityContext).Elem().FieldByName(name)
+	if !f.IsValid() || f.IsZero() {
+		return reflect.Value{}, false
+	}
+	return f, true
+}
+
+// podSecurityContextHasField returns true if the named field exists in the compiled PodSecurityContext type.
+func podSecurityContextHasField(name string) bool {
+	_, ok := reflect.TypeOf(corev1.PodSecurityContext{}).FieldByName(name)
+	return ok
+}
+
+// containerCapabilities returns the capabilities in effect for the container, and the path they are set at.
+// Capabilities set on the container take precedence. When the PodLevelCapabilities feature is enabled,
+// the pod-level securityContext.capabilities are used for containers that do not set capabilities.
+func containerCapabilities(podSpec *corev1.PodSpec, container *corev1.Container, path *field.Path, opts options) (*corev1.Capabilities, *field.Path) {
+	containerPath := path.Child("securityContext", "capabilities")
+	if container.SecurityContext != nil && container.SecurityContext.Capabilities != nil {
+		return container.SecurityContext.Capabilities, containerPath
+	}
+	if opts.featureEnabled(PodLevelCapabilities) {
+		if f, ok := podSecurityContextField(podSpec, "Capabilities"); ok {
+			if capabilities, ok := f.Interface().(*corev1.Capabilities); ok {
+				return capabilities, securityContextPath.Child("capabilities")
+			}
+		}
+	}
+	return nil, containerPath
 }
@@ -0,0 +1,189 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// checkdocgen generates the table of check doc comments returned by policy.CheckDocs,
+// from the block comments documenting the builtin checks in the check_<id>.go files of the policy package.
+//
+// Usage: checkdocgen <policy package dir> <output file>
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const header = `/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by checkdocgen. DO NOT EDIT.
+
+package policy
+
+// checkComments are the parsed doc comments of the builtin checks.
+var checkComments = map[CheckID]checkComment{
+`
+
+var (
+	restrictedFieldsRegexp = regexp.MustCompile(`^\**Restricted Fields:\**`)
+	allowedValuesRegexp    = regexp.MustCompile(`^\**Allowed Values:\**\s*`)
+	// versionHeaderRegexp matches the headers of sections applying to some versions, e.g. "v1.19+:".
+	versionHeaderRegexp = regexp.MustCompile(`^v\d+\.\d+.*:$`)
+)
+
+// checkComment is the parsed doc comment of a check.
+type checkComment struct {
+	id            string
+	description   string
+	allowedValues []string
+}
+
+func main() {
+	if len(os.Args) != 3 {
+		fmt.Fprintln(os.Stderr, "usage: checkdocgen <policy package dir> <output file>")
+		os.Exit(2)
+	}
+	out, err := generate(os.Args[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(os.Args[2], out, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// generate returns the formatted source of the checkComments table, parsed from the check files in dir.
+func generate(dir string) ([]byte, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "check_*.go"))
+	if err != nil {
+		return nil, err
+	}
+	var comments []checkComment
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		c, err := parseCheckFile(file)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+	sort.Slice(comments, func(i, j int) bool { return comments[i].id < comments[j].id })
+
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	for _, c := range comments {
+		fmt.Fprintf(&buf, "%q: {\ndescription: %q,\nallowedValues: []string{\n", c.id, c.description)
+		for _, v := range c.allowedValues {
+			fmt.Fprintf(&buf, "%q,\n", v)
+		}
+		buf.WriteString("},\n},\n")
+	}
+	buf.WriteString("}\n")
+	return format.Source(buf.Bytes())
+}
+
+// parseCheckFile parses the doc comment of the check in the check_<id>.go file,
+// the first block comment following the package clause.
+func parseCheckFile(file string) (checkComment, error) {
+	id := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), "check_"), ".go")
+	f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.ParseComments|parser.PackageClauseOnly)
+	if err != nil {
+		return checkComment{}, err
+	}
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return checkComment{}, err
+	}
+	// PackageClauseOnly stops before the doc comment, so find it in the rest of the source.
+	rest := string(src[f.Name.End():])
+	start := strings.Index(rest, "/*")
+	end := strings.Index(rest, "*/")
+	if start < 0 || end < start {
+		return checkComment{}, fmt.Errorf("%s: missing check doc comment", file)
+	}
+	c := parseComment(id, rest[start+2:end])
+	if len(c.allowedValues) == 0 {
+		return checkComment{}, fmt.Errorf("%s: check doc comment has no Allowed Values", file)
+	}
+	return c, nil
+}
+
+// parseComment parses the description, the text preceding the first Restricted Fields section without version headers,
+// and the text of each Allowed Values section, ending at the next blank line.
+func parseComment(id, text string) checkComment {
+	c := checkComment{id: id}
+	var (
+		description []string
+		inFields    bool
+		values      []string
+		inValues    bool
+	)
+	endValues := func() {
+		if inValues && len(values) > 0 {
+			c.allowedValues = append(c.allowedValues, strings.Join(values, "\n"))
+		}
+		values, inValues = nil, false
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case restrictedFieldsRegexp.MatchString(line):
+			endValues()
+			inFields = true
+		case allowedValuesRegexp.MatchString(line):
+			endValues()
+			inValues = true
+			if v := allowedValuesRegexp.ReplaceAllString(line, ""); v != "" {
+				values = append(values, v)
+			}
+		case inValues:
+			if line == "" {
+				endValues()
+			} else {
+				values = append(values, line)
+			}
+		case !inFields && !versionHeaderRegexp.MatchString(line):
+			description = append(description, line)
+		}
+	}
+	endValues()
+	c.description = strings.TrimSpace(strings.Join(description, "\n"))
+	return c
+}
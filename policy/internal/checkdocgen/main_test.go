@@ -0,0 +1,60 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerated(t *testing.T) {
+	expected, err := generate("../..")
+	require.NoError(t, err)
+	actual, err := os.ReadFile("../../zz_generated.checkdocs.go")
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(actual), "run go generate k8s.io/pod-security-admission/policy")
+}
+
+func TestParseComment(t *testing.T) {
+	c := parseComment("example", `
+Examples must be
+forbidden.
+
+v1.0+:
+**Restricted Fields:**
+spec.example
+
+**Allowed Values:** false
+
+Restricted Fields:
+spec.containers[*].example
+
+Allowed Values:
+undefined
+true, if spec.example is false
+
+Notes are not allowed values.
+`)
+	assert.Equal(t, checkComment{
+		id:            "example",
+		description:   "Examples must be\nforbidden.",
+		allowedValues: []string{"false", "undefined\ntrue, if spec.example is false"},
+	}, c)
+}
@@ -0,0 +1,456 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/pod-security-admission/api"
+)
+
+// JSONSchemaDraft is the JSON Schema dialect of the schemas generated by a SchemaGenerator.
+const JSONSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// JSONSchema is a JSON Schema (draft-07), restricted to the keywords needed to express the checks.
+type JSONSchema struct {
+	Schema      string `json:"$schema,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	Type              string                 `json:"type,omitempty"`
+	Properties        map[string]*JSONSchema `json:"properties,omitempty"`
+	PatternProperties map[string]*JSONSchema `json:"patternProperties,omitempty"`
+	Required          []string               `json:"required,omitempty"`
+	Items             *JSONSchema            `json:"items,omitempty"`
+	Contains          *JSONSchema            `json:"contains,omitempty"`
+	Enum              []interface{}          `json:"enum,omitempty"`
+	Pattern           string                 `json:"pattern,omitempty"`
+
+	Not   *JSONSchema   `json:"not,omitempty"`
+	AllOf []*JSONSchema `json:"allOf,omitempty"`
+	AnyOf []*JSONSchema `json:"anyOf,omitempty"`
+	If    *JSONSchema   `json:"if,omitempty"`
+	Then  *JSONSchema   `json:"then,omitempty"`
+	Else  *JSONSchema   `json:"else,omitempty"`
+}
+
+// SchemaGenerator generates JSON Schemas from the checks of a policy, e.g. to flag violations while authoring manifests.
+// It is implemented by the Evaluator returned by NewEvaluator.
+type SchemaGenerator interface {
+	// PodJSONSchema returns a JSON Schema of the pods satisfying the checks of the level & version,
+	// and the IDs of the checks evaluated at the level & version that cannot be expressed by the schema,
+	// e.g. custom checks. The schema does not enforce these checks.
+	PodJSONSchema(lv api.LevelVersion) (*JSONSchema, []CheckID)
+}
+
+var defaultSchemaGenerator = sync.OnceValue(func() SchemaGenerator {
+	evaluator, err := NewEvaluator(DefaultChecks())
+	if err != nil {
+		panic(err)
+	}
+	return evaluator.(SchemaGenerator)
+})
+
+// PodJSONSchema returns a JSON Schema of the pods satisfying the default checks of the level & version, like the
+// PodJSONSchema of a SchemaGenerator. The schemas are generated from the registered checks, so they change with them.
+//
+// The schemas are not stricter than the checks, except for the container seccomp annotations of versions before 1.19,
+// which are constrained for every container name.
+func PodJSONSchema(lv api.LevelVersion) (*JSONSchema, []CheckID) {
+	return defaultSchemaGenerator().PodJSONSchema(lv)
+}
+
+func (r *checkRegistry) PodJSONSchema(lv api.LevelVersion) (*JSONSchema, []CheckID) {
+	opts := resolveOptions(r.checkOptions)
+	schema := &JSONSchema{
+		Schema:      JSONSchemaDraft,
+		Title:       fmt.Sprintf("Pod Security Standards %s", lv.String()),
+		Description: fmt.Sprintf("Pods satisfying the %s policy.", lv.String()),
+	}
+	var unsupported []CheckID
+	// The checks evaluated at the level & version are the checks returning a result for an empty pod.
+	for _, result := range r.EvaluatePod(lv, &metav1.ObjectMeta{}, &corev1.PodSpec{}) {
+		var checkSchema *JSONSchema
+		if fn, ok := podSchemas[result.ID]; ok {
+			checkSchema = fn(lv.Version, opts)
+		}
+		if checkSchema == nil {
+			unsupported = append(unsupported, result.ID)
+			continue
+		}
+		checkSchema.Title = string(result.ID)
+		schema.AllOf = append(schema.AllOf, checkSchema)
+	}
+	return schema, unsupported
+}
+
+// workloadKindTemplatePaths are the paths of the pod templates of the kinds supported by ExtractPodTemplate.
+var workloadKindTemplatePaths = map[string]*field.Path{
+	"Pod":                   nil,
+	"PodTemplate":           podTemplatePath,
+	"ReplicationController": workloadTemplatePath,
+	"ReplicaSet":            workloadTemplatePath,
+	"Deployment":            workloadTemplatePath,
+	"DaemonSet":             workloadTemplatePath,
+	"StatefulSet":           workloadTemplatePath,
+	"Job":                   workloadTemplatePath,
+	"CronJob":               cronJobTemplatePath,
+}
+
+// WorkloadKinds returns the kinds supported by WorkloadJSONSchema, sorted.
+func WorkloadKinds() []string {
+	kinds := make([]string, 0, len(workloadKindTemplatePaths))
+	for kind := range workloadKindTemplatePaths {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// WorkloadJSONSchema returns a JSON Schema of the objects of the kind whose pod template satisfies the pod schema,
+// e.g. nesting the pod schema at spec.template for a Deployment. The kind must be one of WorkloadKinds.
+func WorkloadJSONSchema(kind string, pod *JSONSchema) (*JSONSchema, error) {
+	path, ok := workloadKindTemplatePaths[kind]
+	if !ok {
+		return nil, fmt.Errorf("unexpected kind: %s", kind)
+	}
+	template := *pod
+	template.Schema, template.Title, template.Description = "", "", ""
+	schema := &template
+	if path != nil {
+		segments := strings.Split(path.String(), ".")
+		for i := len(segments) - 1; i >= 0; i-- {
+			schema = &JSONSchema{Properties: map[string]*JSONSchema{segments[i]: schema}}
+		}
+	}
+	schema.Schema = pod.Schema
+	schema.Title = fmt.Sprintf("%s (%s)", pod.Title, kind)
+	schema.Description = pod.Description
+	return schema, nil
+}
+
+// podSchemaFn returns the JSON Schema of the pods satisfying the check at the given version, evaluated with the options,
+// or nil if the check cannot be expressed by a schema with the options.
+type podSchemaFn func(version api.Version, opts options) *JSONSchema
+
+// podSchemas are the JSON Schemas of the checks, by check ID.
+var podSchemas = map[CheckID]podSchemaFn{
+	"privileged":                schemaPrivileged,
+	"hostNamespaces":            schemaHostNamespaces,
+	"hostPorts":                 schemaHostPorts,
+	"windowsHostProcess":        schemaWindowsHostProcess,
+	"procMount":                 schemaProcMount,
+	"seLinuxOptions":            schemaSELinuxOptions,
+	"sysctls":                   schemaSysctls,
+	"appArmorProfile":           schemaAppArmorProfile,
+	checkCapabilitiesBaselineID: schemaCapabilitiesBaseline,
+	checkSeccompBaselineID:      schemaSeccompProfileBaseline,
+	checkHostPathVolumesID:      schemaHostPathVolumes,
+	"restrictedVolumes":         schemaRestrictedVolumes,
+	"allowPrivilegeEscalation":  linuxOnlySchema(schemaAllowPrivilegeEscalation, api.MajorMinorVersion(1, 25)),
+	"capabilities_restricted":   linuxOnlySchema(schemaCapabilitiesRestricted, api.MajorMinorVersion(1, 25)),
+	"runAsNonRoot":              linuxOnlySchema(userNamespaceSchema(schemaRunAsNonRoot), api.MajorMinorVersion(1, 30)),
+	"runAsUser":                 userNamespaceSchema(schemaRunAsUser),
+	"seccompProfile_restricted": linuxOnlySchema(schemaSeccompProfileRestricted, api.MajorMinorVersion(1, 25)),
+}
+
+// linuxOnlySchema allows Windows pods in the schema of a check of Linux-only fields, like linuxOnly.
+// WindowsPodModeDefault allows Windows pods starting skipByDefaultSince.
+func linuxOnlySchema(fn podSchemaFn, skipByDefaultSince api.Version) podSchemaFn {
+	return func(version api.Version, opts options) *JSONSchema {
+		schema := fn(version, opts)
+		if schema == nil {
+			return nil
+		}
+		switch opts.windowsPodMode {
+		case WindowsPodModeSkip, WindowsPodModeWarn:
+		case WindowsPodModeEnforce:
+			return schema
+		default:
+			if version.Older(skipByDefaultSince) {
+				return schema
+			}
+		}
+		windowsPod := requiredField("spec", requiredField("os", requiredField("name", enumSchema(string(corev1.Windows)))))
+		return &JSONSchema{If: windowsPod, Else: schema}
+	}
+}
+
+// userNamespaceSchema allows pods with enabled user namespaces in the schema of a check,
+// if policies are relaxed for them like relaxPolicyForUserNamespacePod.
+func userNamespaceSchema(fn podSchemaFn) podSchemaFn {
+	return func(version api.Version, opts options) *JSONSchema {
+		schema := fn(version, opts)
+		if schema == nil || !relaxUserNamespacePods(opts) {
+			return schema
+		}
+		return &JSONSchema{If: requiredField("spec", requiredField("hostUsers", enumSchema(false))), Else: schema}
+	}
+}
+
+// enumSchema returns a schema allowing the given values. A nil value allows null, i.e. an unset field.
+func enumSchema(values ...interface{}) *JSONSchema {
+	return &JSONSchema{Enum: values}
+}
+
+// unsetOrEnumSchema returns a schema allowing null and the given strings.
+func unsetOrEnumSchema(values []string) *JSONSchema {
+	schema := enumSchema(nil)
+	for _, v := range values {
+		schema.Enum = append(schema.Enum, v)
+	}
+	return schema
+}
+
+// requiredField returns a schema of objects requiring the field, whose value satisfies the schema.
+func requiredField(name string, schema *JSONSchema) *JSONSchema {
+	return &JSONSchema{
+		Type:       "object",
+		Required:   []string{name},
+		Properties: map[string]*JSONSchema{name: schema},
+	}
+}
+
+// profileSchema returns a schema of seccomp or AppArmor profiles that are unset or of one of the types.
+func profileSchema(types ...interface{}) *JSONSchema {
+	return &JSONSchema{
+		Required:   []string{"type"},
+		Properties: map[string]*JSONSchema{"type": enumSchema(types...)},
+	}
+}
+
+// specSchema returns a schema of pods whose spec has properties satisfying the schemas.
+func specSchema(properties map[string]*JSONSchema) *JSONSchema {
+	return &JSONSchema{Properties: map[string]*JSONSchema{"spec": {Properties: properties}}}
+}
+
+// annotationsSchema returns a schema of pods whose annotations satisfy the schemas.
+func annotationsSchema(properties, patternProperties map[string]*JSONSchema) *JSONSchema {
+	return &JSONSchema{Properties: map[string]*JSONSchema{
+		"metadata": {Properties: map[string]*JSONSchema{
+			"annotations": {Properties: properties, PatternProperties: patternProperties},
+		}},
+	}}
+}
+
+// containersSchema returns a schema of pods whose containers, init containers and ephemeral containers satisfy the schema.
+func containersSchema(container *JSONSchema) *JSONSchema {
+	return specSchema(map[string]*JSONSchema{
+		"initContainers":      {Items: container},
+		"containers":          {Items: container},
+		"ephemeralContainers": {Items: container},
+	})
+}
+
+// podSecurityContextSchema returns a schema of pods whose securityContext has properties satisfying the schemas.
+func podSecurityContextSchema(properties map[string]*JSONSchema) *JSONSchema {
+	return specSchema(map[string]*JSONSchema{"securityContext": {Properties: properties}})
+}
+
+// containerSecurityContextSchema returns a schema of pods whose containers have a securityContext with properties
+// satisfying the schemas.
+func containerSecurityContextSchema(properties map[string]*JSONSchema) *JSONSchema {
+	return containersSchema(&JSONSchema{Properties: map[string]*JSONSchema{"securityContext": {Properties: properties}}})
+}
+
+// securityContextsSchema returns a schema of pods whose pod and container securityContexts have properties
+// satisfying the schemas.
+func securityContextsSchema(properties map[string]*JSONSchema) *JSONSchema {
+	return &JSONSchema{AllOf: []*JSONSchema{
+		podSecurityContextSchema(properties),
+		containerSecurityContextSchema(properties),
+	}}
+}
+
+// podOrContainersSchema returns a schema of pods whose securityContext requires the field satisfying the schema,
+// or whose containers all do. It expresses checks allowing containers to inherit a field of the pod securityContext.
+func podOrContainersSchema(name string, schema *JSONSchema) *JSONSchema {
+	return &JSONSchema{AnyOf: []*JSONSchema{
+		requiredField("spec", requiredField("securityContext", requiredField(name, schema))),
+		containersSchema(requiredField("securityContext", requiredField(name, schema))),
+	}}
+}
+
+func schemaPrivileged(_ api.Version, _ options) *JSONSchema {
+	return containerSecurityContextSchema(map[string]*JSONSchema{"privileged": enumSchema(nil, false)})
+}
+
+func schemaHostNamespaces(_ api.Version, _ options) *JSONSchema {
+	return specSchema(map[string]*JSONSchema{
+		"hostNetwork": enumSchema(nil, false),
+		"hostPID":     enumSchema(nil, false),
+		"hostIPC":     enumSchema(nil, false),
+	})
+}
+
+func schemaHostPorts(_ api.Version, _ options) *JSONSchema {
+	return containersSchema(&JSONSchema{Properties: map[string]*JSONSchema{
+		"ports": {Items: &JSONSchema{Properties: map[string]*JSONSchema{"hostPort": enumSchema(nil, 0)}}},
+	}})
+}
+
+func schemaWindowsHostProcess(_ api.Version, _ options) *JSONSchema {
+	return securityContextsSchema(map[string]*JSONSchema{
+		"windowsOptions": {Properties: map[string]*JSONSchema{"hostProcess": enumSchema(nil, false)}},
+	})
+}
+
+func schemaProcMount(_ api.Version, _ options) *JSONSchema {
+	return containerSecurityContextSchema(map[string]*JSONSchema{"procMount": enumSchema(nil, string(corev1.DefaultProcMount))})
+}
+
+func schemaSELinuxOptions(_ api.Version, _ options) *JSONSchema {
+	return securityContextsSchema(map[string]*JSONSchema{
+		"seLinuxOptions": {Properties: map[string]*JSONSchema{
+			"type": unsetOrEnumSchema(selinux_allowed_types_1_0.List()),
+			"user": enumSchema(nil, ""),
+			"role": enumSchema(nil, ""),
+		}},
+	})
+}
+
+func schemaSysctls(version api.Version, _ options) *JSONSchema {
+	allowed := sysctlsAllowedV1Dot0
+	if !version.Older(api.MajorMinorVersion(1, 29)) {
+		allowed = sysctlsAllowedV1Dot29
+	} else if !version.Older(api.MajorMinorVersion(1, 27)) {
+		allowed = sysctlsAllowedV1Dot27
+	}
+	name := &JSONSchema{}
+	for _, sysctl := range sets.List(allowed) {
+		name.Enum = append(name.Enum, sysctl)
+	}
+	return podSecurityContextSchema(map[string]*JSONSchema{
+		"sysctls": {Items: &JSONSchema{Properties: map[string]*JSONSchema{"name": name}}},
+	})
+}
+
+func schemaAppArmorProfile(_ api.Version, _ options) *JSONSchema {
+	return &JSONSchema{AllOf: []*JSONSchema{
+		annotationsSchema(nil, map[string]*JSONSchema{
+			"^" + regexp.QuoteMeta(corev1.DeprecatedAppArmorBetaContainerAnnotationKeyPrefix): {AnyOf: []*JSONSchema{
+				enumSchema("", corev1.DeprecatedAppArmorBetaProfileRuntimeDefault),
+				{Pattern: "^" + regexp.QuoteMeta(corev1.DeprecatedAppArmorBetaProfileNamePrefix)},
+			}},
+		}),
+		securityContextsSchema(map[string]*JSONSchema{
+			"appArmorProfile": profileSchema(
+				string(corev1.AppArmorProfileTypeRuntimeDefault),
+				string(corev1.AppArmorProfileTypeLocalhost),
+			),
+		}),
+	}}
+}
+
+func schemaCapabilitiesBaseline(_ api.Version, opts options) *JSONSchema {
+	if opts.featureEnabled(PodLevelCapabilities) {
+		// Containers inheriting the pod-level capabilities cannot be expressed.
+		return nil
+	}
+	add := &JSONSchema{}
+	for _, capability := range capabilities_allowed_1_0.List() {
+		add.Enum = append(add.Enum, capability)
+	}
+	return containerSecurityContextSchema(map[string]*JSONSchema{
+		"capabilities": {Properties: map[string]*JSONSchema{"add": {Items: add}}},
+	})
+}
+
+func schemaSeccompProfileBaseline(version api.Version, _ options) *JSONSchema {
+	if version.Older(api.MajorMinorVersion(1, 19)) {
+		annotation := &JSONSchema{AnyOf: []*JSONSchema{
+			enumSchema(corev1.SeccompProfileRuntimeDefault, corev1.DeprecatedSeccompProfileDockerDefault),
+			{Pattern: "^" + regexp.QuoteMeta(corev1.SeccompLocalhostProfileNamePrefix)},
+		}}
+		return annotationsSchema(
+			map[string]*JSONSchema{annotationKeyPod: annotation},
+			map[string]*JSONSchema{"^" + regexp.QuoteMeta(annotationKeyContainerPrefix): annotation},
+		)
+	}
+	return securityContextsSchema(map[string]*JSONSchema{"seccompProfile": validSeccompProfileSchema()})
+}
+
+// validSeccompProfileSchema returns a schema of seccompProfiles that are unset or of a type allowed by validSeccomp.
+func validSeccompProfileSchema() *JSONSchema {
+	return profileSchema(
+		string(corev1.SeccompProfileTypeRuntimeDefault),
+		string(corev1.SeccompProfileTypeLocalhost),
+	)
+}
+
+func schemaHostPathVolumes(_ api.Version, _ options) *JSONSchema {
+	return specSchema(map[string]*JSONSchema{
+		"volumes": {Items: &JSONSchema{Properties: map[string]*JSONSchema{"hostPath": enumSchema(nil)}}},
+	})
+}
+
+func schemaRestrictedVolumes(_ api.Version, opts options) *JSONSchema {
+	volume := &JSONSchema{}
+	for _, source := range append([]string{
+		"configMap",
+		"csi",
+		"downwardAPI",
+		"emptyDir",
+		"ephemeral",
+		"persistentVolumeClaim",
+		"projected",
+		"secret",
+	}, additionalAllowedVolumeTypes(opts).List()...) {
+		volume.AnyOf = append(volume.AnyOf, requiredField(source, &JSONSchema{Type: "object"}))
+	}
+	return specSchema(map[string]*JSONSchema{"volumes": {Items: volume}})
+}
+
+func schemaAllowPrivilegeEscalation(_ api.Version, _ options) *JSONSchema {
+	return containersSchema(requiredField("securityContext", requiredField("allowPrivilegeEscalation", enumSchema(false))))
+}
+
+func schemaCapabilitiesRestricted(_ api.Version, opts options) *JSONSchema {
+	if opts.featureEnabled(PodLevelCapabilities) {
+		// Containers inheriting the pod-level capabilities cannot be expressed.
+		return nil
+	}
+	capabilities := requiredField("drop", &JSONSchema{Type: "array", Contains: enumSchema(string(capabilityAll))})
+	capabilities.Properties["add"] = &JSONSchema{Items: enumSchema(string(capabilityNetBindService))}
+	return containersSchema(requiredField("securityContext", requiredField("capabilities", capabilities)))
+}
+
+func schemaRunAsNonRoot(_ api.Version, _ options) *JSONSchema {
+	return &JSONSchema{AllOf: []*JSONSchema{
+		securityContextsSchema(map[string]*JSONSchema{"runAsNonRoot": enumSchema(nil, true)}),
+		podOrContainersSchema("runAsNonRoot", enumSchema(true)),
+	}}
+}
+
+func schemaRunAsUser(_ api.Version, _ options) *JSONSchema {
+	return securityContextsSchema(map[string]*JSONSchema{"runAsUser": {Not: enumSchema(0)}})
+}
+
+func schemaSeccompProfileRestricted(_ api.Version, _ options) *JSONSchema {
+	return &JSONSchema{AllOf: []*JSONSchema{
+		securityContextsSchema(map[string]*JSONSchema{"seccompProfile": validSeccompProfileSchema()}),
+		podOrContainersSchema("seccompProfile", &JSONSchema{Type: "object"}),
+	}}
+}
@@ -0,0 +1,331 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/component-base/featuregate"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPodJSONSchemaCoverage ensures every default check can be expressed by a schema, so new checks add one.
+func TestPodJSONSchemaCoverage(t *testing.T) {
+	latest := api.LatestVersion()
+	for _, level := range []api.Level{api.LevelBaseline, api.LevelRestricted} {
+		for minor := 0; minor <= latest.Minor(); minor++ {
+			lv := api.LevelVersion{Level: level, Version: api.MajorMinorVersion(1, minor)}
+			schema, unsupported := PodJSONSchema(lv)
+			assert.Empty(t, unsupported, lv.String())
+			assert.NotEmpty(t, schema.AllOf, lv.String())
+		}
+	}
+
+	schema, unsupported := PodJSONSchema(api.LevelVersion{Level: api.LevelPrivileged, Version: api.LatestVersion()})
+	assert.Empty(t, unsupported)
+	assert.Empty(t, schema.AllOf)
+}
+
+// TestPodJSONSchemaFixtures ensures the schemas allow exactly the fixtures the checks allow, keeping them in sync.
+func TestPodJSONSchemaFixtures(t *testing.T) {
+	evaluator, err := NewEvaluator(DefaultChecks())
+	require.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join("..", "test", "testdata", "*", "*", "*", "*.yaml"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	schemas := map[api.LevelVersion]interface{}{}
+	for _, file := range files {
+		// test/testdata/<level>/<version>/<pass|fail>/<name>.yaml
+		parts := strings.Split(filepath.ToSlash(file), "/")
+		level, err := api.ParseLevel(parts[len(parts)-4])
+		require.NoError(t, err)
+		version, err := api.ParseVersion(parts[len(parts)-3])
+		require.NoError(t, err)
+		lv := api.LevelVersion{Level: level, Version: version}
+		if _, ok := schemas[lv]; !ok {
+			schema, _ := PodJSONSchema(lv)
+			schemas[lv] = decodeJSON(t, schema)
+		}
+
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		pod := &corev1.Pod{}
+		require.NoError(t, yaml.Unmarshal(data, pod), file)
+		var object interface{}
+		require.NoError(t, yaml.Unmarshal(data, &object), file)
+
+		// Fixtures are compared to the checks rather than their pass/fail directory,
+		// since some pass only once defaulted by the API server, e.g. volumes without a source.
+		allowed := AggregateCheckResults(evaluator.EvaluatePod(lv, &pod.ObjectMeta, &pod.Spec)).Allowed
+		assert.Equal(t, allowed, validJSONSchema(schemas[lv], object), file)
+	}
+}
+
+func TestPodJSONSchemaOptions(t *testing.T) {
+	windowsPod := &corev1.Pod{Spec: corev1.PodSpec{
+		OS:         &corev1.PodOS{Name: corev1.Windows},
+		Containers: []corev1.Container{{Name: "a"}},
+	}}
+	userNamespacePod := &corev1.Pod{Spec: corev1.PodSpec{
+		HostUsers: ptr.To(false),
+		SecurityContext: &corev1.PodSecurityContext{
+			SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+		},
+		Containers: []corev1.Container{{
+			Name: "a",
+			SecurityContext: &corev1.SecurityContext{
+				AllowPrivilegeEscalation: ptr.To(false),
+				Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{capabilityAll}},
+				RunAsUser:                ptr.To[int64](0),
+			},
+		}},
+	}}
+	podLevelCapabilities := &corev1.Pod{Spec: corev1.PodSpec{
+		Containers: []corev1.Container{{Name: "a"}},
+	}}
+	newGate := func(feature featuregate.Feature) featuregate.FeatureGate {
+		gate := featuregate.NewFeatureGate()
+		require.NoError(t, AddFeatureGates(gate))
+		require.NoError(t, gate.SetFromMap(map[string]bool{string(feature): true}))
+		return gate
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		pod  *corev1.Pod
+		// unsupported are the checks of the latest restricted policy that cannot be expressed by the schema.
+		unsupported []CheckID
+	}{{
+		name: "default windows pod",
+		pod:  windowsPod,
+	}, {
+		name: "windows pod skipped",
+		opts: []Option{WithWindowsPodMode(WindowsPodModeSkip)},
+		pod:  windowsPod,
+	}, {
+		name: "windows pod enforced",
+		opts: []Option{WithWindowsPodMode(WindowsPodModeEnforce)},
+		pod:  windowsPod,
+	}, {
+		name: "default user namespace pod",
+		pod:  userNamespacePod,
+	}, {
+		name: "relaxed user namespace pod",
+		opts: []Option{WithFeatureGate(newGate(UserNamespacesPodSecurityStandards))},
+		pod:  userNamespacePod,
+	}, {
+		name:        "pod level capabilities",
+		opts:        []Option{WithFeatureGate(newGate(PodLevelCapabilities))},
+		pod:         podLevelCapabilities,
+		unsupported: []CheckID{"capabilities_restricted"},
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			evaluator, err := NewEvaluator(DefaultChecks(), tc.opts...)
+			require.NoError(t, err)
+			data, err := json.Marshal(tc.pod)
+			require.NoError(t, err)
+			var object interface{}
+			require.NoError(t, json.Unmarshal(data, &object))
+
+			for _, version := range []api.Version{api.MajorMinorVersion(1, 24), api.LatestVersion()} {
+				lv := api.LevelVersion{Level: api.LevelRestricted, Version: version}
+				schema, unsupported := evaluator.(SchemaGenerator).PodJSONSchema(lv)
+				allowed := AggregateCheckResults(evaluator.EvaluatePod(lv, &tc.pod.ObjectMeta, &tc.pod.Spec)).Allowed
+				if len(tc.unsupported) > 0 {
+					assert.ElementsMatch(t, tc.unsupported, unsupported, version.String())
+					continue
+				}
+				assert.Empty(t, unsupported, version.String())
+				assert.Equal(t, allowed, validJSONSchema(decodeJSON(t, schema), object), version.String())
+			}
+		})
+	}
+}
+
+func TestWorkloadJSONSchema(t *testing.T) {
+	pod, _ := PodJSONSchema(api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()})
+	privileged := corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+		Name:            "a",
+		SecurityContext: &corev1.SecurityContext{Privileged: ptr.To(true)},
+	}}}}
+
+	for _, kind := range WorkloadKinds() {
+		t.Run(kind, func(t *testing.T) {
+			schema, err := WorkloadJSONSchema(kind, pod)
+			require.NoError(t, err)
+			assert.Equal(t, JSONSchemaDraft, schema.Schema)
+			decoded := decodeJSON(t, schema)
+
+			var object interface{}
+			switch kind {
+			case "Pod":
+				object = map[string]interface{}{"spec": decodeJSON(t, privileged.Spec)}
+			case "PodTemplate":
+				object = map[string]interface{}{"template": decodeJSON(t, privileged)}
+			case "CronJob":
+				object = map[string]interface{}{"spec": map[string]interface{}{"jobTemplate": map[string]interface{}{
+					"spec": map[string]interface{}{"template": decodeJSON(t, privileged)},
+				}}}
+			default:
+				object = map[string]interface{}{"spec": map[string]interface{}{"template": decodeJSON(t, privileged)}}
+			}
+			assert.False(t, validJSONSchema(decoded, object))
+			assert.True(t, validJSONSchema(decoded, map[string]interface{}{"metadata": map[string]interface{}{"name": "a"}}))
+		})
+	}
+
+	_, err := WorkloadJSONSchema("Service", pod)
+	assert.EqualError(t, err, "unexpected kind: Service")
+}
+
+// decodeJSON returns the JSON encoding of the object decoded to generic values, e.g. to validate a schema as encoded.
+func decodeJSON(t *testing.T, obj interface{}) interface{} {
+	data, err := json.Marshal(obj)
+	require.NoError(t, err)
+	var value interface{}
+	require.NoError(t, json.Unmarshal(data, &value))
+	return value
+}
+
+// validJSONSchema validates the value against a decoded JSON Schema, supporting the keywords of JSONSchema.
+func validJSONSchema(schema, value interface{}) bool {
+	s := schema.(map[string]interface{})
+	object, isObject := value.(map[string]interface{})
+	array, isArray := value.([]interface{})
+
+	if typ, ok := s["type"]; ok {
+		switch typ {
+		case "object":
+			if !isObject {
+				return false
+			}
+		case "array":
+			if !isArray {
+				return false
+			}
+		default:
+			panic("unsupported type " + typ.(string))
+		}
+	}
+	if enum, ok := s["enum"]; ok {
+		found := false
+		for _, v := range enum.([]interface{}) {
+			if reflect.DeepEqual(v, value) {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if pattern, ok := s["pattern"]; ok {
+		if str, isString := value.(string); isString && !regexp.MustCompile(pattern.(string)).MatchString(str) {
+			return false
+		}
+	}
+	if isObject {
+		if required, ok := s["required"]; ok {
+			for _, name := range required.([]interface{}) {
+				if _, ok := object[name.(string)]; !ok {
+					return false
+				}
+			}
+		}
+		if properties, ok := s["properties"]; ok {
+			for name, property := range properties.(map[string]interface{}) {
+				if v, ok := object[name]; ok && !validJSONSchema(property, v) {
+					return false
+				}
+			}
+		}
+		if patternProperties, ok := s["patternProperties"]; ok {
+			for pattern, property := range patternProperties.(map[string]interface{}) {
+				for name, v := range object {
+					if regexp.MustCompile(pattern).MatchString(name) && !validJSONSchema(property, v) {
+						return false
+					}
+				}
+			}
+		}
+	}
+	if isArray {
+		if items, ok := s["items"]; ok {
+			for _, v := range array {
+				if !validJSONSchema(items, v) {
+					return false
+				}
+			}
+		}
+		if contains, ok := s["contains"]; ok {
+			found := false
+			for _, v := range array {
+				if validJSONSchema(contains, v) {
+					found = true
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+	if not, ok := s["not"]; ok && validJSONSchema(not, value) {
+		return false
+	}
+	if allOf, ok := s["allOf"]; ok {
+		for _, sub := range allOf.([]interface{}) {
+			if !validJSONSchema(sub, value) {
+				return false
+			}
+		}
+	}
+	if anyOf, ok := s["anyOf"]; ok {
+		found := false
+		for _, sub := range anyOf.([]interface{}) {
+			if validJSONSchema(sub, value) {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if ifSchema, ok := s["if"]; ok {
+		branch := "else"
+		if validJSONSchema(ifSchema, value) {
+			branch = "then"
+		}
+		if sub, ok := s[branch]; ok && !validJSONSchema(sub, value) {
+			return false
+		}
+	}
+	return true
+}
@@ -17,31 +17,116 @@ limitations under the License.
 package policy
 
 import (
+	"regexp"
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/component-base/featuregate"
 )
 
 type options struct {
 	withFieldErrors bool
+	// maxFieldErrors bounds the field errors collected by each check, if set.
+	maxFieldErrors int
+	// fieldPathPrefix roots the paths of the field errors of the evaluated pods, if set.
+	fieldPathPrefix *field.Path
+	// features holds the enabled state of known feature gates.
+	features map[featuregate.Feature]bool
+	// relaxUserNamespacePods overrides RelaxPolicyForUserNamespacePods, if set (see WithRelaxedUserNamespacePods).
+	relaxUserNamespacePods *bool
+
+	// requiredSupplementalGroupsPolicy is the supplementalGroupsPolicy required by the supplementalGroups check, if set.
+	requiredSupplementalGroupsPolicy string
+	// allowedFSGroupChangePolicies restricts the fsGroupChangePolicy values allowed by the supplementalGroups check, if set.
+	allowedFSGroupChangePolicies []corev1.PodFSGroupChangePolicy
+	// allowedDeviceClasses are the device classes allowed by the resourceClaims check.
+	allowedDeviceClasses []string
+	// deviceClassResolver looks up the device classes of resource claims for the resourceClaims check, if set.
+	deviceClassResolver DeviceClassResolver
+	// localhostProfileCatalog looks up the Localhost profiles referenced by pods for the localhostProfiles check, if set.
+	localhostProfileCatalog LocalhostProfileCatalog
+	// runtimeClassDefaultsResolver looks up the default profiles of RuntimeClasses for the seccompProfile_restricted check, if set.
+	runtimeClassDefaultsResolver RuntimeClassDefaultsResolver
+	// windowsPodMode determines how the checks of Linux-only fields evaluate Windows pods.
+	windowsPodMode WindowsPodMode
+	// hostBreakoutCommandPatterns are the commands forbidden by the hostBreakoutCommands check, if set.
+	hostBreakoutCommandPatterns []*regexp.Regexp
+	// rootExecCommandPatterns are the commands forbidden by the rootExecCommands check, if set.
+	rootExecCommandPatterns []*regexp.Regexp
+	// requireImageDigests requires images pinned to a digest in the imagePolicy check.
+	requireImageDigests bool
+	// requiredResourceLimits are the resources whose limits are required by the resourceLimits check, if set.
+	requiredResourceLimits []corev1.ResourceName
+	// readOnlyHostPaths are the host paths allowed by the readOnlyHostPathVolumes check.
+	readOnlyHostPaths []string
+	// additionalAllowedVolumeTypes are the restricted volume types allowed by the restrictedVolumes check.
+	additionalAllowedVolumeTypes []string
+	// resultCacheSize is the number of pods whose results are cached by the Evaluator, if set (see WithResultCache).
+	resultCacheSize int
+	// recordResultCacheLookup is called for each lookup of the result cache, if set.
+	recordResultCacheLookup func(hit bool)
+	// withoutBadValues are the IDs of the checks whose field errors have no BadValue (see WithoutBadValues).
+	withoutBadValues []CheckID
+	// defaultCheckDeadline bounds the execution of the checks without a deadline in checkDeadlines, if positive
+	// (see WithCheckDeadline).
+	defaultCheckDeadline time.Duration
+	// checkDeadlines bound the execution of the checks with the given IDs, if positive (see WithCheckDeadline).
+	checkDeadlines map[CheckID]time.Duration
+
+	// scratch holds buffers reused for the evaluation of a single check, if set by withOptions.
+	scratch *scratch
 }
 
 type Option func(options) options
 
 func withOptions(f func(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult) CheckPodFn {
 	return func(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts ...Option) CheckResult {
-		var opt options
-		for _, o := range opts {
-			if o != nil {
-				opt = o(opt)
-			}
-		}
+		opt := resolveOptions(opts)
+		opt.scratch = acquireScratch()
+		defer opt.scratch.release()
 		return f(podMetadata, podSpec, opt)
 	}
 }
 
+// resolveOptions applies the given options in order.
+func resolveOptions(opts []Option) options {
+	var opt options
+	for _, o := range opts {
+		if o != nil {
+			opt = o(opt)
+		}
+	}
+	return opt
+}
+
 func WithFieldErrors() Option {
 	return func(opt options) options {
 		opt.withFieldErrors = true
 		return opt
 	}
 }
+
+// WithFieldPathPrefix roots the spec and metadata paths of the field errors collected WithFieldErrors at the prefix,
+// the path of the evaluated pod template in an embedding object, e.g. spec.jobTemplate.spec.template for a CronJob,
+// so controllers validating nested templates report errors like spec.jobTemplate.spec.template.spec.containers[0].
+// It is applied to the results of all the checks by the Evaluator returned by NewEvaluator, and must not be combined
+// with EvaluateWorkload, which already roots the errors at the pod template of the workload.
+func WithFieldPathPrefix(prefix *field.Path) Option {
+	return func(opt options) options {
+		opt.fieldPathPrefix = prefix
+		return opt
+	}
+}
+
+// WithMaxFieldErrors limits the field errors collected by each check with WithFieldErrors to max,
+// keeping memory bounded for pods that would produce thousands of errors.
+// Additional field errors are counted instead, and reported by a final error of type field.ErrorTypeTooMany
+// in the ErrList of the check result. A max of 0 collects all field errors.
+func WithMaxFieldErrors(max int) Option {
+	return func(opt options) options {
+		opt.maxFieldErrors = max
+		return opt
+	}
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/pod-security-admission/api"
+)
+
+// CheckOrigin is the origin of a check, builtin or custom.
+type CheckOrigin string
+
+const (
+	// CheckOriginBuiltin is the origin of the checks returned by DefaultChecks, ExperimentalChecks and OptionalChecks.
+	CheckOriginBuiltin CheckOrigin = "builtin"
+	// CheckOriginCustom is the origin of other checks, which must use a domain-prefixed ID like example.com/no-root-fs.
+	CheckOriginCustom CheckOrigin = "custom"
+)
+
+// BuiltinCheckSource is the Source of builtin checks.
+const BuiltinCheckSource = "k8s.io/pod-security-admission"
+
+// checkSource returns the Source of the check, BuiltinCheckSource for builtin checks,
+// and the domain prefix of the ID for custom checks without a Source.
+func checkSource(c Check) string {
+	if c.ID.Origin() == CheckOriginBuiltin {
+		return BuiltinCheckSource
+	}
+	if c.Source != "" {
+		return c.Source
+	}
+	return strings.SplitN(string(c.ID), "/", 2)[0]
+}
+
+// Origin returns the origin of the check with the ID. IDs without a domain prefix are reserved for builtin checks.
+func (id CheckID) Origin() CheckOrigin {
+	if strings.Contains(string(id), "/") {
+		return CheckOriginCustom
+	}
+	return CheckOriginBuiltin
+}
+
+var (
+	builtinCheckIDsOnce sync.Once
+	builtinCheckIDs     map[CheckID]bool
+)
+
+// isBuiltinCheckID returns true if the ID is the ID of a default, experimental or optional check.
+func isBuiltinCheckID(id CheckID) bool {
+	builtinCheckIDsOnce.Do(func() {
+		builtinCheckIDs = map[CheckID]bool{}
+		for _, checks := range [][]Check{DefaultChecks(), ExperimentalChecks(), OptionalChecks()} {
+			for _, c := range checks {
+				builtinCheckIDs[c.ID] = true
+			}
+		}
+	})
+	return builtinCheckIDs[id]
+}
+
+// validateCheckID requires custom checks to use a domain-prefixed ID, like example.com/no-root-fs,
+// so they cannot conflict with builtin checks added later.
+func validateCheckID(id CheckID) error {
+	if id.Origin() == CheckOriginBuiltin {
+		if !isBuiltinCheckID(id) {
+			return fmt.Errorf("check %s: custom checks must use a domain-prefixed ID, like example.com/%s", id, id)
+		}
+		return nil
+	}
+	if errs := validation.IsQualifiedName(string(id)); len(errs) > 0 {
+		return fmt.Errorf("check %s: invalid ID: %s", id, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// CheckInfo describes a check registered in an Evaluator.
+type CheckInfo struct {
+	ID       CheckID     `json:"id"`
+	Level    api.Level   `json:"level"`
+	Origin   CheckOrigin `json:"origin"`
+	Source   string      `json:"source"`
+	Severity Severity    `json:"severity"`
+	// SpecOnly is true if the results of the check only depend on the PodSpec, see Check.SpecOnly.
+	SpecOnly bool `json:"specOnly"`
+}
+
+// checkCatalog returns the CheckInfo of the checks, sorted by ID.
+func checkCatalog(checks []Check) []CheckInfo {
+	catalog := make([]CheckInfo, 0, len(checks))
+	for _, c := range checks {
+		catalog = append(catalog, CheckInfo{ID: c.ID, Level: c.Level, Origin: c.ID.Origin(), Source: checkSource(c), Severity: checkSeverity(c), SpecOnly: c.SpecOnly})
+	}
+	sort.Slice(catalog, func(i, j int) bool { return catalog[i].ID < catalog[j].ID })
+	return catalog
+}
+
+// EvaluatorChecks returns the CheckInfo of the checks registered in an Evaluator constructed by NewEvaluator,
+// sorted by ID, or nil for other Evaluator implementations.
+func EvaluatorChecks(evaluator Evaluator) []CheckInfo {
+	if r, ok := evaluator.(*checkRegistry); ok {
+		return append([]CheckInfo(nil), r.catalog...)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/utils/pointer"
+)
+
+func TestCheckIDOrigin(t *testing.T) {
+	assert.Equal(t, CheckOriginBuiltin, CheckID("privileged").Origin())
+	assert.Equal(t, CheckOriginCustom, CheckID("example.com/no-root-fs").Origin())
+}
+
+func TestNewEvaluatorCustomCheckIDs(t *testing.T) {
+	tests := []struct {
+		id          CheckID
+		expectedErr string
+	}{
+		{id: "example.com/no-root-fs"},
+		{id: "sub.example.com/NoRootFS"},
+		{id: "no-root-fs", expectedErr: "check no-root-fs: custom checks must use a domain-prefixed ID, like example.com/no-root-fs"},
+		{id: "Example.com/no-root-fs", expectedErr: "check Example.com/no-root-fs: invalid ID: prefix part"},
+		{id: "example.com/", expectedErr: "check example.com/: invalid ID: name part must be non-empty"},
+		{id: "example.com/no/root", expectedErr: "check example.com/no/root: invalid ID: a qualified name must consist of"},
+	}
+	for _, tc := range tests {
+		t.Run(string(tc.id), func(t *testing.T) {
+			check := generateCheck("", api.LevelBaseline, []string{"v1.0"})
+			check.ID = tc.id
+			_, err := NewEvaluator([]Check{check})
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			}
+		})
+	}
+
+	t.Run("builtin ID", func(t *testing.T) {
+		_, err := NewEvaluator([]Check{CheckPrivileged()})
+		assert.NoError(t, err)
+	})
+
+	t.Run("conflicting IDs", func(t *testing.T) {
+		custom := generateCheck("privileged", api.LevelBaseline, []string{"v1.0"})
+		_, err := NewEvaluator([]Check{custom, custom})
+		assert.EqualError(t, err, "multiple checks registered for ID example.com/privileged")
+	})
+}
+
+func TestEvaluatorChecks(t *testing.T) {
+	evaluator, err := NewEvaluator([]Check{
+		generateCheck("no-root-fs", api.LevelRestricted, []string{"v1.0"}),
+		CheckPrivileged(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []CheckInfo{
+		{ID: "example.com/no-root-fs", Level: api.LevelRestricted, Origin: CheckOriginCustom, Source: "example.com", Severity: SeverityMedium},
+		{ID: "privileged", Level: api.LevelBaseline, Origin: CheckOriginBuiltin, Source: BuiltinCheckSource, Severity: SeverityCritical, SpecOnly: true},
+	}, EvaluatorChecks(evaluator))
+	assert.Nil(t, EvaluatorChecks(nil))
+}
+
+func TestCheckSource(t *testing.T) {
+	custom := generateCheck("no-root-fs", api.LevelBaseline, []string{"v1.0"})
+	custom.Source = "example.com/site-policies"
+	builtin := CheckPrivileged()
+	builtin.Source = "example.com/site-policies"
+	evaluator, err := NewEvaluator([]Check{custom, builtin})
+	require.NoError(t, err)
+
+	pod := &corev1.Pod{Spec: corev1.PodSpec{
+		Containers: []corev1.Container{{Name: "a", SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(true)}}},
+	}}
+	results := evaluator.EvaluatePod(api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}, &pod.ObjectMeta, &pod.Spec)
+	sources := map[CheckID]string{}
+	for _, result := range results {
+		sources[result.ID] = result.Source
+	}
+	assert.Equal(t, map[CheckID]string{
+		"example.com/no-root-fs": "example.com/site-policies",
+		"privileged":             BuiltinCheckSource,
+	}, sources)
+
+	aggregate := AggregateCheckResults(results)
+	assert.Equal(t, []string{"example.com/site-policies", ""}, aggregate.ForbiddenSources)
+	assert.Equal(t, `no-root-fs:v1.0 [example.com/site-policies], privileged (container "a" must not set securityContext.privileged=true)`, aggregate.ForbiddenDetail())
+	assert.Equal(t, "example.com/site-policies", aggregate.Violations[0].Source)
+}
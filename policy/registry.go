@@ -19,15 +19,19 @@ package policy
 import (
 	"fmt"
 	"sort"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/pod-security-admission/api"
 )
 
 // Evaluator holds the Checks that are used to validate a policy.
 type Evaluator interface {
 	// EvaluatePod evaluates the pod against the policy for the given level & version.
+	// It returns a result for every check evaluated at the level & version, including the checks allowing the pod,
+	// so callers can report the checks that were evaluated and passed. No checks are evaluated at the privileged level.
 	EvaluatePod(lv api.LevelVersion, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec) []CheckResult
 }
 
@@ -35,9 +39,25 @@ type Evaluator interface {
 type checkRegistry struct {
 	// The checks are a map policy version to a slice of checks registered for that version.
 	baselineChecks, restrictedChecks map[api.Version][]CheckPodFn
+	// The resolved checks describe the checks registered for each version, in the same order (see ResolvePolicy).
+	baselineResolved, restrictedResolved map[api.Version][]ResolvedCheck
 	// maxVersion is the maximum version that is cached, guaranteed to be at least
 	// the max MinimumVersion of all registered checks.
 	maxVersion api.Version
+	// checkOptions are passed to every check that is evaluated.
+	checkOptions []Option
+	// fieldPathPrefix roots the field errors of the results, if set (see WithFieldPathPrefix).
+	fieldPathPrefix *field.Path
+	// withoutBadValues are the IDs of the checks whose field errors have no BadValue (see WithoutBadValues).
+	withoutBadValues map[CheckID]bool
+	// checkDeadline returns the deadline of the check with the given ID (see WithCheckDeadline).
+	checkDeadline func(CheckID) time.Duration
+	// schemaVersion is the SchemaVersion of the registered checks and the options of the Evaluator (see EvaluatorSchemaVersion).
+	schemaVersion string
+	// catalog describes the registered checks.
+	catalog []CheckInfo
+	// cache holds the results of evaluated pods, if enabled (see WithResultCache).
+	cache *resultCache
 }
 
 // NewEvaluator constructs a new Evaluator instance from the list of checks. If the provided checks are invalid,
@@ -46,15 +66,42 @@ type checkRegistry struct {
 // 2. Check.Level must be either Baseline or Restricted
 // 3. Checks must have a non-empty set of versions, sorted in a strictly increasing order
 // 4. Check.Versions cannot include 'latest'
-func NewEvaluator(checks []Check) (Evaluator, error) {
+// 5. Check.ID of custom checks must be prefixed with a domain, like example.com/no-root-fs (see CheckID.Origin)
+//
+// The provided options are passed to every check evaluated by the returned Evaluator.
+// Checks with RequiredFeatures that are not enabled by the options are not registered.
+func NewEvaluator(checks []Check, opts ...Option) (Evaluator, error) {
 	if err := validateChecks(checks); err != nil {
 		return nil, err
 	}
 	r := &checkRegistry{
 		baselineChecks:   map[api.Version][]CheckPodFn{},
 		restrictedChecks: map[api.Version][]CheckPodFn{},
+
+		baselineResolved:   map[api.Version][]ResolvedCheck{},
+		restrictedResolved: map[api.Version][]ResolvedCheck{},
+
+		checkOptions: opts,
+	}
+	resolved := resolveOptions(opts)
+	enabled := enabledChecks(checks, resolved)
+	cache, err := newResultCache(enabled, resolved)
+	if err != nil {
+		return nil, err
 	}
-	populate(r, checks)
+	r.cache = cache
+	r.fieldPathPrefix = resolved.fieldPathPrefix
+	r.withoutBadValues, err = withoutBadValuesSet(checks, resolved)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateCheckDeadlines(checks, resolved); err != nil {
+		return nil, err
+	}
+	r.checkDeadline = resolved.checkDeadline
+	populate(r, enabled)
+	r.schemaVersion = schemaVersion(enabled, optionsSchema(resolved))
+	r.catalog = checkCatalog(enabled)
 	return r, nil
 }
 
@@ -65,18 +112,62 @@ func (r *checkRegistry) EvaluatePod(lv api.LevelVersion, podMetadata *metav1.Obj
 	if r.maxVersion.Older(lv.Version) {
 		lv.Version = r.maxVersion
 	}
+	if r.cache == nil {
+		return r.evaluatePod(lv, podMetadata, podSpec)
+	}
+	key, ok := r.cache.key(lv, podMetadata, podSpec)
+	if !ok {
+		return r.evaluatePod(lv, podMetadata, podSpec)
+	}
+	if results, ok := r.cache.get(key); ok {
+		return results
+	}
+	results := r.evaluatePod(lv, podMetadata, podSpec)
+	if !hasEvaluationErrors(results) {
+		// evaluation errors like exceeded deadlines are not cached, since they may not recur
+		r.cache.add(key, results)
+	}
+	return results
+}
+
+// evaluatePod evaluates the pod against the checks registered for the level & version, which must be registered.
+func (r *checkRegistry) evaluatePod(lv api.LevelVersion, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec) []CheckResult {
+	return r.evaluateChecks(lv, podMetadata, podSpec, nil, false)
+}
 
+// evaluateChecks evaluates the pod against the checks registered for the level & version, which must be registered,
+// skipping the checks for which skip returns true, if set. If untilDenied is set, it stops at the first check
+// that does not allow the pod.
+func (r *checkRegistry) evaluateChecks(lv api.LevelVersion, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, skip func(CheckID) bool, untilDenied bool) []CheckResult {
 	var checks []CheckPodFn
+	var resolved []ResolvedCheck
 	if lv.Level == api.LevelBaseline {
-		checks = r.baselineChecks[lv.Version]
+		checks, resolved = r.baselineChecks[lv.Version], r.baselineResolved[lv.Version]
 	} else {
 		// includes non-overridden baseline checks
-		checks = r.restrictedChecks[lv.Version]
+		checks, resolved = r.restrictedChecks[lv.Version], r.restrictedResolved[lv.Version]
 	}
 
-	var results []CheckResult
-	for _, check := range checks {
-		results = append(results, check(podMetadata, podSpec))
+	results := make([]CheckResult, 0, len(checks))
+	for i, check := range checks {
+		if skip != nil && skip(resolved[i].ID) {
+			continue
+		}
+		result := check(podMetadata, podSpec, r.checkOptions...)
+		result.Version = lv.Version
+		if r.fieldPathPrefix != nil && result.ErrList != nil {
+			// the offending containers are resolved by the check from the unrooted errors
+			errs := rootFieldErrors(r.fieldPathPrefix, *result.ErrList)
+			result.ErrList = &errs
+		}
+		if r.withoutBadValues[result.ID] && result.ErrList != nil {
+			errs := clearBadValues(*result.ErrList)
+			result.ErrList = &errs
+		}
+		results = append(results, result)
+		if untilDenied && !result.Allowed {
+			break
+		}
 	}
 	return results
 }
@@ -87,10 +178,16 @@ func validateChecks(checks []Check) error {
 		if _, ok := ids[check.ID]; ok {
 			return fmt.Errorf("multiple checks registered for ID %s", check.ID)
 		}
+		if err := validateCheckID(check.ID); err != nil {
+			return err
+		}
 		ids[check.ID] = check.Level
 		if check.Level != api.LevelBaseline && check.Level != api.LevelRestricted {
 			return fmt.Errorf("check %s: invalid level %s", check.ID, check.Level)
 		}
+		if _, err := ParseSeverity(string(check.Severity)); check.Severity != "" && err != nil {
+			return fmt.Errorf("check %s: invalid severity %s: %w", check.ID, check.Severity, err)
+		}
 		if len(check.Versions) == 0 {
 			return fmt.Errorf("check %s: empty", check.ID)
 		}
@@ -118,9 +215,6 @@ func validateChecks(checks []Check) error {
 				continue
 			}
 
-			if check.Level != api.LevelRestricted {
-				return fmt.Errorf("check %s: only restricted checks may set overrides", check.ID)
-			}
 			for _, override := range c.OverrideCheckIDs {
 				if overriddenLevel, ok := ids[override]; ok && overriddenLevel != api.LevelBaseline {
 					return fmt.Errorf("check %s: overrides %s check %s", check.ID, overriddenLevel, override)
@@ -131,6 +225,17 @@ func validateChecks(checks []Check) error {
 	return nil
 }
 
+// enabledChecks filters out checks that require features that are not enabled.
+func enabledChecks(checks []Check, opts options) []Check {
+	enabled := make([]Check, 0, len(checks))
+	for _, c := range checks {
+		if opts.featuresEnabled(c.RequiredFeatures) {
+			enabled = append(enabled, c)
+		}
+	}
+	return enabled
+}
+
 func populate(r *checkRegistry, validChecks []Check) {
 	// Find the max(MinimumVersion) across all checks.
 	for _, c := range validChecks {
@@ -145,8 +250,15 @@ func populate(r *checkRegistry, validChecks []Check) {
 		baselineVersionedChecks   = map[api.Version]map[CheckID]VersionedCheck{}
 
 		baselineIDs, restrictedIDs []CheckID
+
+		sources    = map[CheckID]string{}
+		severities = map[CheckID]Severity{}
+		levels     = map[CheckID]api.Level{}
 	)
 	for _, c := range validChecks {
+		levels[c.ID] = c.Level
+		sources[c.ID] = checkSource(c)
+		severities[c.ID] = checkSeverity(c)
 		if c.Level == api.LevelRestricted {
 			restrictedIDs = append(restrictedIDs, c.ID)
 			inflateVersions(c, restrictedVersionedChecks, r.maxVersion)
@@ -162,13 +274,26 @@ func populate(r *checkRegistry, validChecks []Check) {
 	orderedIDs := append(baselineIDs, restrictedIDs...) // Baseline checks first, then restricted.
 
 	for v := api.MajorMinorVersion(1, 0); v.Older(nextMinor(r.maxVersion)); v = nextMinor(v) {
-		// Aggregate all the overridden baseline check ids.
+		// Drop the baseline checks replaced by other baseline checks.
+		for _, c := range baselineVersionedChecks[v] {
+			for _, override := range c.OverrideCheckIDs {
+				delete(baselineVersionedChecks[v], override)
+			}
+		}
+		// Aggregate all the overridden baseline check ids, including the checks replacing them.
 		overrides := map[CheckID]bool{}
 		for _, c := range restrictedVersionedChecks[v] {
 			for _, override := range c.OverrideCheckIDs {
 				overrides[override] = true
 			}
 		}
+		for id, c := range baselineVersionedChecks[v] {
+			for _, override := range c.OverrideCheckIDs {
+				if overrides[override] {
+					overrides[id] = true
+				}
+			}
+		}
 		// Add the filtered baseline checks to restricted.
 		for id, c := range baselineVersionedChecks[v] {
 			if overrides[id] {
@@ -180,8 +305,10 @@ func populate(r *checkRegistry, validChecks []Check) {
 			restrictedVersionedChecks[v][id] = c
 		}
 
-		r.restrictedChecks[v] = mapCheckPodFns(restrictedVersionedChecks[v], orderedIDs)
-		r.baselineChecks[v] = mapCheckPodFns(baselineVersionedChecks[v], orderedIDs)
+		r.restrictedChecks[v] = mapCheckPodFns(restrictedVersionedChecks[v], orderedIDs, sources, severities, r.checkDeadline)
+		r.baselineChecks[v] = mapCheckPodFns(baselineVersionedChecks[v], orderedIDs, sources, severities, r.checkDeadline)
+		r.restrictedResolved[v] = resolvedChecks(restrictedVersionedChecks[v], orderedIDs, levels)
+		r.baselineResolved[v] = resolvedChecks(baselineVersionedChecks[v], orderedIDs, levels)
 	}
 }
 
@@ -207,16 +334,52 @@ func inflateVersions(check Check, versions map[api.Version]map[CheckID]Versioned
 
 // mapCheckPodFns converts the versioned check map to an ordered slice of CheckPodFn,
 // using the order specified by orderedIDs. All checks must have a corresponding ID in orderedIDs.
-func mapCheckPodFns(checks map[CheckID]VersionedCheck, orderedIDs []CheckID) []CheckPodFn {
+// The returned functions set the check ID, source and severity, and the offending containers of field errors, on their results,
+// and return an evaluation error if the check exceeds its deadline, if set.
+func mapCheckPodFns(checks map[CheckID]VersionedCheck, orderedIDs []CheckID, sources map[CheckID]string, severities map[CheckID]Severity, deadlines func(CheckID) time.Duration) []CheckPodFn {
 	fns := make([]CheckPodFn, 0, len(checks))
 	for _, id := range orderedIDs {
 		if check, ok := checks[id]; ok {
-			fns = append(fns, check.CheckPod)
+			var deadline time.Duration
+			if deadlines != nil {
+				deadline = deadlines(id)
+			}
+			fns = append(fns, withCheckID(id, sources[id], severities[id], withDeadline(id, deadline, check.CheckPod)))
 		}
 	}
 	return fns
 }
 
+// resolvedChecks describes the versioned check map as an ordered slice of ResolvedCheck,
+// using the order specified by orderedIDs, like mapCheckPodFns.
+func resolvedChecks(checks map[CheckID]VersionedCheck, orderedIDs []CheckID, levels map[CheckID]api.Level) []ResolvedCheck {
+	resolved := make([]ResolvedCheck, 0, len(checks))
+	for _, id := range orderedIDs {
+		if check, ok := checks[id]; ok {
+			resolved = append(resolved, ResolvedCheck{ID: id, Level: levels[id], MinimumVersion: check.MinimumVersion})
+		}
+	}
+	return resolved
+}
+
+// withCheckID wraps the CheckPodFn to set the given ID, source and severity, and the offending containers of field errors,
+// on its results, and the given ID on their resolutions.
+func withCheckID(id CheckID, source string, severity Severity, checkPod CheckPodFn) CheckPodFn {
+	return func(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts ...Option) CheckResult {
+		result := checkPod(podMetadata, podSpec, opts...)
+		result.ID = id
+		result.Source = source
+		result.Severity = severity
+		if result.ErrList != nil {
+			result.Containers, result.SidecarContainers = offendingContainers(podSpec, *result.ErrList)
+		}
+		for i := range result.Resolutions {
+			result.Resolutions[i].Check = id
+		}
+		return result
+	}
+}
+
 // nextMinor increments the minor version
 func nextMinor(v api.Version) api.Version {
 	if v.Latest() {
@@ -18,11 +18,16 @@ package policy
 
 import (
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/component-base/featuregate"
 	"k8s.io/pod-security-admission/api"
+	"k8s.io/utils/pointer"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -40,8 +45,8 @@ func TestCheckRegistry(t *testing.T) {
 		withOverrides(generateCheck("h", api.LevelRestricted, []string{"v1.0"}), []CheckID{"b"}),
 	}
 	multiOverride := generateCheck("i", api.LevelRestricted, []string{"v1.10", "v1.21"})
-	multiOverride.Versions[0].OverrideCheckIDs = []CheckID{"c"}
-	multiOverride.Versions[1].OverrideCheckIDs = []CheckID{"d"}
+	multiOverride.Versions[0].OverrideCheckIDs = []CheckID{testCheckDomain + "c"}
+	multiOverride.Versions[1].OverrideCheckIDs = []CheckID{testCheckDomain + "d"}
 	checks = append(checks, multiOverride)
 
 	reg, err := NewEvaluator(checks)
@@ -69,6 +74,34 @@ func TestCheckRegistry(t *testing.T) {
 	}
 }
 
+func TestCheckRegistry_BaselineOverrides(t *testing.T) {
+	checks := []Check{
+		generateCheck("a", api.LevelBaseline, []string{"v1.0"}),
+		generateCheck("b", api.LevelBaseline, []string{"v1.0"}),
+		withOverrides(generateCheck("c", api.LevelBaseline, []string{"v1.5"}), []CheckID{"a"}),
+		withOverrides(generateCheck("d", api.LevelRestricted, []string{"v1.0"}), []CheckID{"a"}),
+	}
+
+	reg, err := NewEvaluator(checks)
+	require.NoError(t, err)
+
+	levelCases := []registryTestCase{
+		{api.LevelBaseline, "v1.0", []string{"a:v1.0", "b:v1.0"}},
+		{api.LevelBaseline, "v1.5", []string{"b:v1.0", "c:v1.5"}},
+		{api.LevelRestricted, "v1.0", []string{"b:v1.0", "d:v1.0"}},
+		{api.LevelRestricted, "v1.5", []string{"b:v1.0", "d:v1.0"}},
+	}
+	for _, test := range levelCases {
+		test.Run(t, reg)
+	}
+
+	_, err = NewEvaluator([]Check{
+		generateCheck("a", api.LevelRestricted, []string{"v1.0"}),
+		withOverrides(generateCheck("b", api.LevelBaseline, []string{"v1.0"}), []CheckID{"a"}),
+	})
+	assert.Error(t, err, "baseline checks must not override restricted checks")
+}
+
 func TestCheckRegistry_NoBaseline(t *testing.T) {
 	checks := []Check{
 		generateCheck("e", api.LevelRestricted, []string{"v1.0"}),
@@ -142,6 +175,138 @@ func TestCheckRegistry_Empty(t *testing.T) {
 	}
 }
 
+func TestCheckRegistry_RequiredFeatures(t *testing.T) {
+	const testFeature featuregate.Feature = "TestFeature"
+	gated := generateCheck("b", api.LevelBaseline, []string{"v1.0"})
+	gated.RequiredFeatures = []featuregate.Feature{testFeature}
+	checks := []Check{
+		generateCheck("a", api.LevelBaseline, []string{"v1.0"}),
+		gated,
+	}
+
+	newGate := func(enabled bool) featuregate.FeatureGate {
+		gate := featuregate.NewFeatureGate()
+		require.NoError(t, gate.Add(map[featuregate.Feature]featuregate.FeatureSpec{testFeature: {Default: false, PreRelease: featuregate.Alpha}}))
+		require.NoError(t, gate.SetFromMap(map[string]bool{string(testFeature): enabled}))
+		return gate
+	}
+
+	reg, err := NewEvaluator(checks)
+	require.NoError(t, err)
+	(&registryTestCase{api.LevelBaseline, "latest", []string{"a:v1.0"}}).Run(t, reg)
+
+	reg, err = NewEvaluator(checks, WithFeatureGate(newGate(false)))
+	require.NoError(t, err)
+	(&registryTestCase{api.LevelBaseline, "latest", []string{"a:v1.0"}}).Run(t, reg)
+
+	reg, err = NewEvaluator(checks, WithFeatureGate(newGate(true)))
+	require.NoError(t, err)
+	(&registryTestCase{api.LevelBaseline, "latest", []string{"a:v1.0", "b:v1.0"}}).Run(t, reg)
+}
+
+func TestSchemaVersion(t *testing.T) {
+	a := generateCheck("a", api.LevelBaseline, []string{"v1.0"})
+	b := generateCheck("b", api.LevelRestricted, []string{"v1.0", "v1.5"})
+
+	version := SchemaVersion([]Check{a, b})
+	assert.NotEmpty(t, version)
+	assert.Equal(t, version, SchemaVersion([]Check{b, a}), "order of checks should not matter")
+	assert.NotEqual(t, version, SchemaVersion([]Check{a}))
+	assert.NotEqual(t, version, SchemaVersion([]Check{a, generateCheck("b", api.LevelRestricted, []string{"v1.0", "v1.6"})}))
+	assert.NotEqual(t, version, SchemaVersion([]Check{a, withOverrides(generateCheck("b", api.LevelRestricted, []string{"v1.0", "v1.5"}), []CheckID{"a"})}))
+
+	reg, err := NewEvaluator([]Check{a, b})
+	require.NoError(t, err)
+	assert.Equal(t, version, EvaluatorSchemaVersion(reg))
+	assert.Empty(t, EvaluatorSchemaVersion(nil))
+
+	// options changing decisions or field errors change the schema version
+	gate := featuregate.NewFeatureGate()
+	require.NoError(t, AddFeatureGates(gate))
+	require.NoError(t, gate.SetFromMap(map[string]bool{string(SupplementalGroupsPolicy): true}))
+	versions := map[string]string{"none": version}
+	for name, opts := range map[string][]Option{
+		"field errors":                {WithFieldErrors()},
+		"field path prefix":           {WithFieldErrors(), WithFieldPathPrefix(field.NewPath("spec", "template"))},
+		"deadline":                    {WithCheckDeadline(time.Second)},
+		"check deadline":              {WithCheckDeadline(time.Second, a.ID)},
+		"supplemental groups policy":  {WithRequiredSupplementalGroupsPolicy("Strict")},
+		"additional volume types":     {WithAdditionalAllowedVolumeTypes("hostPath")},
+		"windows pod mode":            {WithWindowsPodMode(WindowsPodModeSkip)},
+		"relaxed user namespace pods": {WithRelaxedUserNamespacePods(true)},
+		"feature":                     {WithFeatureGate(gate)},
+	} {
+		reg, err := NewEvaluator([]Check{a, b}, opts...)
+		require.NoError(t, err)
+		v := EvaluatorSchemaVersion(reg)
+		for other, otherVersion := range versions {
+			assert.NotEqual(t, otherVersion, v, "options %q and %q should have different schema versions", name, other)
+		}
+		versions[name] = v
+
+		reg, err = NewEvaluator([]Check{b, a}, opts...)
+		require.NoError(t, err)
+		assert.Equal(t, v, EvaluatorSchemaVersion(reg), "options %q should have a stable schema version", name)
+	}
+	reg, err = NewEvaluator([]Check{a, b}, WithResultCache(10, nil))
+	require.NoError(t, err)
+	assert.Equal(t, version, EvaluatorSchemaVersion(reg), "the result cache should not change the schema version")
+}
+
+func TestEvaluatorFeatureGateRelaxesUserNamespacePods(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{
+		HostUsers:       pointer.Bool(false),
+		SecurityContext: &corev1.PodSecurityContext{RunAsUser: pointer.Int64(0)},
+		Containers:      []corev1.Container{{Name: "a"}},
+	}}
+	lv := api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()}
+	checks := []Check{CheckRunAsNonRoot(), CheckRunAsUser()}
+
+	gate := featuregate.NewFeatureGate()
+	require.NoError(t, AddFeatureGates(gate))
+
+	disabled, err := NewEvaluator(checks, WithFeatureGate(gate))
+	require.NoError(t, err)
+
+	require.NoError(t, gate.SetFromMap(map[string]bool{string(UserNamespacesPodSecurityStandards): true}))
+	enabled, err := NewEvaluator(checks, WithFeatureGate(gate))
+	require.NoError(t, err)
+
+	assert.False(t, AggregateCheckResults(disabled.EvaluatePod(lv, &pod.ObjectMeta, &pod.Spec)).Allowed, "expected disallowed without the feature")
+	assert.True(t, AggregateCheckResults(enabled.EvaluatePod(lv, &pod.ObjectMeta, &pod.Spec)).Allowed, "expected allowed with the feature")
+}
+
+func TestEvaluatorRelaxedUserNamespacePods(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{
+		HostUsers:       pointer.Bool(false),
+		SecurityContext: &corev1.PodSecurityContext{RunAsUser: pointer.Int64(0)},
+		Containers:      []corev1.Container{{Name: "a"}},
+	}}
+	lv := api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()}
+	checks := []Check{CheckRunAsNonRoot(), CheckRunAsUser()}
+	allowed := func(evaluator Evaluator) bool {
+		return AggregateCheckResults(evaluator.EvaluatePod(lv, &pod.ObjectMeta, &pod.Spec)).Allowed
+	}
+
+	// evaluators with different settings can be hosted in the same process
+	relaxed, err := NewEvaluator(checks, WithRelaxedUserNamespacePods(true))
+	require.NoError(t, err)
+	unrelaxed, err := NewEvaluator(checks, WithRelaxedUserNamespacePods(false))
+	require.NoError(t, err)
+	unset, err := NewEvaluator(checks)
+	require.NoError(t, err)
+	assert.True(t, allowed(relaxed), "expected allowed when relaxed")
+	assert.False(t, allowed(unrelaxed), "expected disallowed when not relaxed")
+	assert.False(t, allowed(unset), "expected disallowed by default")
+	assert.Nil(t, relaxed.(UserNamespaceRelaxationChecker).UnrelaxedUserNamespaceChecks(&pod.Spec, nil))
+
+	// the deprecated process-wide setting only applies to evaluators without the option
+	RelaxPolicyForUserNamespacePods(true)
+	defer RelaxPolicyForUserNamespacePods(false)
+	assert.True(t, allowed(unset), "expected allowed by the process-wide setting")
+	assert.False(t, allowed(unrelaxed), "expected the option to override the process-wide setting")
+}
+
 type registryTestCase struct {
 	level           api.Level
 	version         string
@@ -156,14 +321,21 @@ func (tc *registryTestCase) Run(t *testing.T, registry Evaluator) {
 		var actualReasons []string
 		for _, result := range results {
 			actualReasons = append(actualReasons, result.ForbiddenReason)
+			// The reason is prefixed by the ID of the check that produced it, without the testCheckDomain.
+			assert.True(t, strings.HasPrefix(result.ForbiddenReason, strings.TrimPrefix(string(result.ID), testCheckDomain)+":"), "unexpected ID %q for result %q", result.ID, result.ForbiddenReason)
 		}
 		assert.Equal(t, tc.expectedReasons, actualReasons)
 	})
 }
 
+// testCheckDomain prefixes the IDs of the custom checks generated by generateCheck.
+const testCheckDomain = "example.com/"
+
+// generateCheck generates a custom check with the ID prefixed by testCheckDomain,
+// returning the given ID and the version in the forbidden reason.
 func generateCheck(id CheckID, level api.Level, versions []string) Check {
 	c := Check{
-		ID:    id,
+		ID:    testCheckDomain + id,
 		Level: level,
 	}
 	for _, ver := range versions {
@@ -181,8 +353,12 @@ func generateCheck(id CheckID, level api.Level, versions []string) Check {
 }
 
 func withOverrides(c Check, overrides []CheckID) Check {
+	prefixed := make([]CheckID, len(overrides))
+	for i, id := range overrides {
+		prefixed[i] = testCheckDomain + id
+	}
 	for i := range c.Versions {
-		c.Versions[i].OverrideCheckIDs = overrides
+		c.Versions[i].OverrideCheckIDs = prefixed
 	}
 	return c
 }
@@ -194,3 +370,61 @@ func versionOrPanic(v string) api.Version {
 	}
 	return ver
 }
+
+func TestEvaluatePodPassingResults(t *testing.T) {
+	evaluator, err := NewEvaluator(DefaultChecks())
+	require.NoError(t, err)
+
+	pod := &corev1.Pod{Spec: corev1.PodSpec{HostNetwork: true, Containers: []corev1.Container{{Name: "a"}}}}
+	lv := api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}
+	results := evaluator.EvaluatePod(lv, &pod.ObjectMeta, &pod.Spec)
+
+	var passed, failed []CheckID
+	for _, result := range results {
+		require.NotEmpty(t, result.ID, "results should be identified by check ID")
+		if result.Allowed {
+			passed = append(passed, result.ID)
+		} else {
+			failed = append(failed, result.ID)
+		}
+	}
+	assert.Equal(t, []CheckID{"hostNamespaces"}, failed)
+	assert.Contains(t, passed, CheckID("privileged"), "passing checks should be returned")
+
+	assert.Empty(t, evaluator.EvaluatePod(api.LevelVersion{Level: api.LevelPrivileged, Version: api.LatestVersion()}, &pod.ObjectMeta, &pod.Spec))
+}
+
+// BenchmarkEvaluatePod evaluates a pod violating several restricted checks concurrently, like a webhook at high QPS.
+func BenchmarkEvaluatePod(b *testing.B) {
+	for _, withFieldErrors := range []bool{false, true} {
+		b.Run(fmt.Sprintf("withFieldErrors=%t", withFieldErrors), func(b *testing.B) {
+			var opts []Option
+			if withFieldErrors {
+				opts = append(opts, WithFieldErrors())
+			}
+			evaluator, err := NewEvaluator(DefaultChecks(), opts...)
+			require.NoError(b, err)
+			lv := api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()}
+			podMetadata := &metav1.ObjectMeta{Name: "test-pod"}
+			podSpec := &corev1.PodSpec{HostNetwork: true}
+			for i := 0; i < 4; i++ {
+				podSpec.Containers = append(podSpec.Containers, corev1.Container{
+					Name: fmt.Sprintf("c%d", i),
+					SecurityContext: &corev1.SecurityContext{
+						Privileged:   pointer.Bool(true),
+						Capabilities: &corev1.Capabilities{Add: []corev1.Capability{"SYS_ADMIN"}},
+					},
+					Ports: []corev1.ContainerPort{{HostPort: 8080}},
+				})
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					AggregateCheckResults(evaluator.EvaluatePod(lv, podMetadata, podSpec))
+				}
+			})
+		})
+	}
+}
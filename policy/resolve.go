@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"sort"
+
+	"k8s.io/pod-security-admission/api"
+)
+
+// ResolvedCheck is a check applying at a resolved policy level and version.
+type ResolvedCheck struct {
+	ID CheckID
+	// Level is the level of the check. Baseline checks also apply at the restricted level, unless overridden.
+	Level api.Level
+	// MinimumVersion is the minimum version of the VersionedCheck applying at the resolved version.
+	MinimumVersion api.Version
+}
+
+// ResolvedPolicy describes the checks evaluated for a requested policy level and version.
+type ResolvedPolicy struct {
+	// LevelVersion is the level and version whose checks are evaluated.
+	LevelVersion api.LevelVersion
+	// Checks are the checks evaluated at LevelVersion, baseline checks first, each sorted by ID.
+	Checks []ResolvedCheck
+}
+
+// ResolvePolicy resolves the policy evaluated by an Evaluator constructed by NewEvaluator for the requested level
+// and version, e.g. from a namespace label, on a cluster at clusterVersion (see api.ResolveVersion).
+// Versions newer than the registered checks resolve to the newest version of the checks, like in EvaluatePod.
+// ok is false for other Evaluator implementations. No checks apply at the privileged level.
+func ResolvePolicy(evaluator Evaluator, clusterVersion api.Version, requested api.LevelVersion) (resolved ResolvedPolicy, ok bool) {
+	r, ok := evaluator.(*checkRegistry)
+	if !ok {
+		return ResolvedPolicy{}, false
+	}
+	lv := requested
+	lv.Version = api.ResolveVersion(lv.Version, clusterVersion)
+	if r.maxVersion.Older(lv.Version) {
+		lv.Version = r.maxVersion
+	}
+	resolved.LevelVersion = lv
+	switch lv.Level {
+	case api.LevelBaseline:
+		resolved.Checks = append([]ResolvedCheck(nil), r.baselineResolved[lv.Version]...)
+	case api.LevelRestricted:
+		resolved.Checks = append([]ResolvedCheck(nil), r.restrictedResolved[lv.Version]...)
+	}
+	return resolved, true
+}
+
+// CheckChange is a difference between the checks of two resolved policies.
+type CheckChange struct {
+	ID CheckID
+	// From is the check in the old policy, or nil if the check was added.
+	From *ResolvedCheck
+	// To is the check in the new policy, or nil if the check was removed.
+	To *ResolvedCheck
+}
+
+// PolicyChanges returns the checks added, removed, or updated to a different VersionedCheck between the policies,
+// sorted by ID, e.g. to explain what changes when updating a namespace from restricted v1.25 to v1.29.
+func PolicyChanges(from, to ResolvedPolicy) []CheckChange {
+	fromChecks := map[CheckID]ResolvedCheck{}
+	for _, c := range from.Checks {
+		fromChecks[c.ID] = c
+	}
+	var changes []CheckChange
+	for i := range to.Checks {
+		c := &to.Checks[i]
+		old, ok := fromChecks[c.ID]
+		delete(fromChecks, c.ID)
+		switch {
+		case !ok:
+			changes = append(changes, CheckChange{ID: c.ID, To: c})
+		case old.Level != c.Level || old.MinimumVersion != c.MinimumVersion:
+			changes = append(changes, CheckChange{ID: c.ID, From: &old, To: c})
+		}
+	}
+	for id := range fromChecks {
+		old := fromChecks[id]
+		changes = append(changes, CheckChange{ID: id, From: &old})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].ID < changes[j].ID })
+	return changes
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/pod-security-admission/api"
+)
+
+func TestResolvePolicy(t *testing.T) {
+	evaluator, err := NewEvaluator([]Check{
+		generateCheck("a", api.LevelBaseline, []string{"v1.0", "v1.5"}),
+		generateCheck("b", api.LevelBaseline, []string{"v1.2"}),
+		withOverrides(generateCheck("c", api.LevelRestricted, []string{"v1.3"}), []CheckID{"a"}),
+	})
+	require.NoError(t, err)
+
+	resolve := func(clusterVersion, level, version string) ResolvedPolicy {
+		t.Helper()
+		resolved, ok := ResolvePolicy(evaluator, versionOrPanic(clusterVersion), api.LevelVersion{Level: api.Level(level), Version: versionOrPanic(version)})
+		require.True(t, ok)
+		return resolved
+	}
+	check := func(id CheckID, level api.Level, minimumVersion string) ResolvedCheck {
+		return ResolvedCheck{ID: testCheckDomain + id, Level: level, MinimumVersion: versionOrPanic(minimumVersion)}
+	}
+
+	testCases := []struct {
+		desc           string
+		clusterVersion string
+		level          string
+		version        string
+		expectVersion  string
+		expectChecks   []ResolvedCheck
+	}{
+		{
+			desc:           "baseline",
+			clusterVersion: "latest",
+			level:          "baseline",
+			version:        "v1.4",
+			expectVersion:  "v1.4",
+			expectChecks:   []ResolvedCheck{check("a", api.LevelBaseline, "v1.0"), check("b", api.LevelBaseline, "v1.2")},
+		},
+		{
+			desc:           "restricted overrides",
+			clusterVersion: "latest",
+			level:          "restricted",
+			version:        "v1.4",
+			expectVersion:  "v1.4",
+			expectChecks:   []ResolvedCheck{check("b", api.LevelBaseline, "v1.2"), check("c", api.LevelRestricted, "v1.3")},
+		},
+		{
+			desc:           "restricted before override",
+			clusterVersion: "latest",
+			level:          "restricted",
+			version:        "v1.2",
+			expectVersion:  "v1.2",
+			expectChecks:   []ResolvedCheck{check("a", api.LevelBaseline, "v1.0"), check("b", api.LevelBaseline, "v1.2")},
+		},
+		{
+			desc:           "latest resolves to cluster version",
+			clusterVersion: "v1.3",
+			level:          "baseline",
+			version:        "latest",
+			expectVersion:  "v1.3",
+			expectChecks:   []ResolvedCheck{check("a", api.LevelBaseline, "v1.0"), check("b", api.LevelBaseline, "v1.2")},
+		},
+		{
+			desc:           "newer than checks",
+			clusterVersion: "v1.30",
+			level:          "baseline",
+			version:        "v1.29",
+			expectVersion:  "v1.5",
+			expectChecks:   []ResolvedCheck{check("a", api.LevelBaseline, "v1.5"), check("b", api.LevelBaseline, "v1.2")},
+		},
+		{
+			desc:           "privileged",
+			clusterVersion: "latest",
+			level:          "privileged",
+			version:        "latest",
+			expectVersion:  "v1.5",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			resolved := resolve(tc.clusterVersion, tc.level, tc.version)
+			assert.Equal(t, tc.expectVersion, resolved.LevelVersion.Version.String())
+			assert.Equal(t, api.Level(tc.level), resolved.LevelVersion.Level)
+			assert.Equal(t, tc.expectChecks, resolved.Checks)
+		})
+	}
+
+	t.Run("changes", func(t *testing.T) {
+		changes := PolicyChanges(resolve("latest", "restricted", "v1.1"), resolve("latest", "restricted", "v1.5"))
+		a, b, c := check("a", api.LevelBaseline, "v1.0"), check("b", api.LevelBaseline, "v1.2"), check("c", api.LevelRestricted, "v1.3")
+		assert.Equal(t, []CheckChange{
+			{ID: a.ID, From: &a},
+			{ID: b.ID, To: &b},
+			{ID: c.ID, To: &c},
+		}, changes)
+
+		a5 := check("a", api.LevelBaseline, "v1.5")
+		assert.Equal(t, []CheckChange{{ID: a.ID, From: &a, To: &a5}}, PolicyChanges(resolve("latest", "baseline", "v1.4"), resolve("latest", "baseline", "v1.5")))
+		assert.Empty(t, PolicyChanges(resolve("latest", "baseline", "v1.2"), resolve("latest", "baseline", "v1.4")))
+	})
+
+	t.Run("builtin", func(t *testing.T) {
+		evaluator, err := NewEvaluator(DefaultChecks())
+		require.NoError(t, err)
+		from, ok := ResolvePolicy(evaluator, api.LatestVersion(), api.LevelVersion{Level: api.LevelRestricted, Version: api.MajorMinorVersion(1, 24)})
+		require.True(t, ok)
+		to, ok := ResolvePolicy(evaluator, api.LatestVersion(), api.LevelVersion{Level: api.LevelRestricted, Version: api.MajorMinorVersion(1, 25)})
+		require.True(t, ok)
+		var ids []CheckID
+		for _, change := range PolicyChanges(from, to) {
+			ids = append(ids, change.ID)
+			assert.Equal(t, api.MajorMinorVersion(1, 25), change.To.MinimumVersion, change.ID)
+		}
+		assert.Equal(t, []CheckID{"allowPrivilegeEscalation", "capabilities_restricted", "seccompProfile_restricted"}, ids)
+	})
+
+	_, ok := ResolvePolicy(nil, api.LatestVersion(), api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()})
+	assert.False(t, ok)
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// RuntimeClassDefaults are the security profiles a RuntimeClass applies to the containers that don't set their own.
+// AppArmor profiles need no defaults, since no check requires pods to set them explicitly.
+type RuntimeClassDefaults struct {
+	// SeccompProfileType is the type of the seccomp profile applied by the runtime handler, if any.
+	SeccompProfileType corev1.SeccompProfileType
+}
+
+// RuntimeClassDefaultsResolver looks up the default security profiles of RuntimeClasses.
+// Implementations are called during evaluation, and are expected to read from a local cache.
+type RuntimeClassDefaultsResolver interface {
+	// RuntimeClassDefaults returns the defaults of the named RuntimeClass, and false if it is unknown.
+	RuntimeClassDefaults(runtimeClassName string) (RuntimeClassDefaults, bool)
+}
+
+// WithRuntimeClassDefaults configures the resolver used by the seccompProfile_restricted check to evaluate
+// the effective seccomp profile of pods that set a RuntimeClass and don't set a pod-level profile.
+// Containers relying on a RuntimeDefault or Localhost profile of their RuntimeClass are allowed,
+// and the resolution is recorded in the Resolutions of the check result.
+func WithRuntimeClassDefaults(resolver RuntimeClassDefaultsResolver) Option {
+	return func(opt options) options {
+		opt.runtimeClassDefaultsResolver = resolver
+		return opt
+	}
+}
+
+// ProfileResolution records that containers were allowed by the default security profile of their RuntimeClass,
+// rather than by a profile set in the pod spec.
+type ProfileResolution struct {
+	// Check is the ID of the check that resolved the profile.
+	// It is set by the Evaluator returned by NewEvaluator, and may be empty otherwise.
+	Check CheckID `json:"check,omitempty"`
+	// RuntimeClassName is the name of the RuntimeClass of the pod.
+	RuntimeClassName string `json:"runtimeClassName"`
+	// Profile is the kind of the resolved profile, e.g. seccomp.
+	Profile string `json:"profile"`
+	// Type is the type of the resolved profile, e.g. RuntimeDefault.
+	Type string `json:"type"`
+	// Containers are the names of the containers relying on the profile.
+	Containers []string `json:"containers,omitempty"`
+}
+
+// runtimeClassSeccompProfileType returns the default seccomp profile type of the RuntimeClass of the pod,
+// or an empty type if the pod has no RuntimeClass, or the RuntimeClass is unknown to the resolver of the options.
+func runtimeClassSeccompProfileType(podSpec *corev1.PodSpec, opts options) corev1.SeccompProfileType {
+	if opts.runtimeClassDefaultsResolver == nil || podSpec.RuntimeClassName == nil || *podSpec.RuntimeClassName == "" {
+		return ""
+	}
+	defaults, ok := opts.runtimeClassDefaultsResolver.RuntimeClassDefaults(*podSpec.RuntimeClassName)
+	if !ok {
+		return ""
+	}
+	return defaults.SeccompProfileType
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"reflect"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// checkedAnnotationPrefixes are the prefixes of the annotations read by the checks.
+var checkedAnnotationPrefixes = []string{
+	annotationKeyPod,
+	annotationKeyContainerPrefix,
+	corev1.DeprecatedAppArmorBetaContainerAnnotationKeyPrefix,
+}
+
+// SanitizePod returns copies of the pod metadata and spec pruned to the fields read by the registered checks,
+// including the optional checks, in a canonical form:
+//   - the namespace, and the seccomp and AppArmor annotations
+//   - the host namespaces, hostUsers, os, runtimeClassName and securityContext of the pod
+//   - the name, image, command, args, restartPolicy, ports, volume mounts, securityContext, the commands of
+//     the exec probes and lifecycle hooks, and the names of the resource limits of containers, whose quantities
+//     are zeroed, so in-place resizes changing the resources and resizePolicy of containers keep the sanitized pod
+//   - the name and source type of volumes, keeping the hostPath source
+//   - the resource claims
+//   - empty maps and slices are replaced with nil
+//   - empty capabilities, SELinux and Windows options and OS are replaced with nil
+//
+// Evaluating the sanitized pod returns the same results as evaluating the original pod, which makes it
+// the single normalized form used for cache keys (see PodHash), decision logs, and exports of violating pods
+// that must not disclose unrelated data like environment variables. The order of containers, volumes and other
+// lists is kept, since it is reported by the field paths of the results.
+func SanitizePod(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec) (*metav1.ObjectMeta, *corev1.PodSpec) {
+	sanitizedMetadata := &metav1.ObjectMeta{}
+	if podMetadata != nil {
+		sanitizedMetadata.Namespace = podMetadata.Namespace
+		for k, v := range podMetadata.Annotations {
+			if checkedAnnotation(k) {
+				if sanitizedMetadata.Annotations == nil {
+					sanitizedMetadata.Annotations = map[string]string{}
+				}
+				sanitizedMetadata.Annotations[k] = v
+			}
+		}
+	}
+	if podSpec == nil {
+		return sanitizedMetadata, nil
+	}
+
+	sanitizedSpec := &corev1.PodSpec{
+		HostNetwork: podSpec.HostNetwork,
+		HostPID:     podSpec.HostPID,
+		HostIPC:     podSpec.HostIPC,
+		HostUsers:   copyBool(podSpec.HostUsers),
+		OS:          podSpec.OS.DeepCopy(),
+
+		RuntimeClassName: copyString(podSpec.RuntimeClassName),
+
+		SecurityContext: podSpec.SecurityContext.DeepCopy(),
+	}
+	for _, c := range podSpec.Containers {
+		sanitizedSpec.Containers = append(sanitizedSpec.Containers, sanitizeContainer(c))
+	}
+	for _, c := range podSpec.InitContainers {
+		sanitizedSpec.InitContainers = append(sanitizedSpec.InitContainers, sanitizeContainer(c))
+	}
+	for _, c := range podSpec.EphemeralContainers {
+		sanitizedSpec.EphemeralContainers = append(sanitizedSpec.EphemeralContainers, corev1.EphemeralContainer{
+			EphemeralContainerCommon: corev1.EphemeralContainerCommon(sanitizeContainer(corev1.Container(c.EphemeralContainerCommon))),
+		})
+	}
+	for _, v := range podSpec.Volumes {
+		sanitizedSpec.Volumes = append(sanitizedSpec.Volumes, sanitizeVolume(v))
+	}
+	for _, c := range podSpec.ResourceClaims {
+		sanitizedSpec.ResourceClaims = append(sanitizedSpec.ResourceClaims, *c.DeepCopy())
+	}
+	canonicalize(reflect.ValueOf(sanitizedSpec).Elem())
+	return sanitizedMetadata, sanitizedSpec
+}
+
+func checkedAnnotation(key string) bool {
+	for _, prefix := range checkedAnnotationPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func sanitizeContainer(c corev1.Container) corev1.Container {
+	sanitized := corev1.Container{
+		Name:            c.Name,
+		Image:           c.Image,
+		Command:         append([]string(nil), c.Command...),
+		Args:            append([]string(nil), c.Args...),
+		SecurityContext: c.SecurityContext.DeepCopy(),
+		LivenessProbe:   sanitizeProbe(c.LivenessProbe),
+		ReadinessProbe:  sanitizeProbe(c.ReadinessProbe),
+		StartupProbe:    sanitizeProbe(c.StartupProbe),
+	}
+	if c.RestartPolicy != nil {
+		restartPolicy := *c.RestartPolicy
+		sanitized.RestartPolicy = &restartPolicy
+	}
+	if c.Ports != nil {
+		sanitized.Ports = append([]corev1.ContainerPort(nil), c.Ports...)
+	}
+	for _, m := range c.VolumeMounts {
+		sanitized.VolumeMounts = append(sanitized.VolumeMounts, corev1.VolumeMount{Name: m.Name, ReadOnly: m.ReadOnly})
+	}
+	for name := range c.Resources.Limits {
+		// only the presence of limits is read by the checks
+		if sanitized.Resources.Limits == nil {
+			sanitized.Resources.Limits = corev1.ResourceList{}
+		}
+		sanitized.Resources.Limits[name] = resource.Quantity{}
+	}
+	if c.Lifecycle != nil {
+		sanitized.Lifecycle = &corev1.Lifecycle{
+			PostStart: sanitizeLifecycleHandler(c.Lifecycle.PostStart),
+			PreStop:   sanitizeLifecycleHandler(c.Lifecycle.PreStop),
+		}
+	}
+	return sanitized
+}
+
+// sanitizeProbe keeps the command of an exec probe, which is the only probe field read by the checks.
+func sanitizeProbe(p *corev1.Probe) *corev1.Probe {
+	if p == nil || p.Exec == nil {
+		return nil
+	}
+	return &corev1.Probe{ProbeHandler: corev1.ProbeHandler{Exec: p.Exec.DeepCopy()}}
+}
+
+// sanitizeLifecycleHandler keeps the command of an exec lifecycle hook, which is the only hook field read by the checks.
+func sanitizeLifecycleHandler(h *corev1.LifecycleHandler) *corev1.LifecycleHandler {
+	if h == nil || h.Exec == nil {
+		return nil
+	}
+	return &corev1.LifecycleHandler{Exec: h.Exec.DeepCopy()}
+}
+
+// sanitizeVolume keeps the name and source type of the volume, replacing the source with an empty value.
+// The hostPath source is kept, since its path is reported by the checks.
+func sanitizeVolume(v corev1.Volume) corev1.Volume {
+	sanitized := corev1.Volume{Name: v.Name}
+	if v.HostPath != nil {
+		sanitized.HostPath = v.HostPath.DeepCopy()
+		return sanitized
+	}
+	source := reflect.ValueOf(v.VolumeSource)
+	sanitizedSource := reflect.ValueOf(&sanitized.VolumeSource).Elem()
+	for i := 0; i < source.NumField(); i++ {
+		if f := source.Field(i); f.Kind() == reflect.Ptr && !f.IsNil() {
+			sanitizedSource.Field(i).Set(reflect.New(f.Type().Elem()))
+		}
+	}
+	return sanitized
+}
+
+func copyString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	return &c
+}
+
+func copyBool(b *bool) *bool {
+	if b == nil {
+		return nil
+	}
+	c := *b
+	return &c
+}
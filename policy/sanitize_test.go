@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/pod-security-admission/api"
+	"sigs.k8s.io/yaml"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizePod(t *testing.T) {
+	podMetadata := &metav1.ObjectMeta{
+		Name: "test",
+		Annotations: map[string]string{
+			annotationKeyPod: corev1.SeccompProfileNameUnconfined,
+			corev1.DeprecatedAppArmorBetaContainerAnnotationKeyPrefix + "a": "unconfined",
+			"example.com/owner": "team-a",
+		},
+	}
+	podSpec := &corev1.PodSpec{
+		HostPID: true,
+		Containers: []corev1.Container{{
+			Name:         "a",
+			Image:        "registry.example.com/app",
+			Command:      []string{"run", "--token=secret"},
+			Env:          []corev1.EnvVar{{Name: "PASSWORD", Value: "secret"}},
+			Ports:        []corev1.ContainerPort{{ContainerPort: 80, HostPort: 8080}},
+			VolumeMounts: []corev1.VolumeMount{{Name: "host", MountPath: "/host", ReadOnly: true}},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+				Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			},
+			// resized in place, and must only keep the names of the limits
+			ResizePolicy:  []corev1.ContainerResizePolicy{{ResourceName: corev1.ResourceCPU, RestartPolicy: corev1.NotRequired}},
+			LivenessProbe: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{Exec: &corev1.ExecAction{Command: []string{"cat", "/tmp/healthy"}}}},
+			ReadinessProbe: &corev1.Probe{
+				ProbeHandler:  corev1.ProbeHandler{HTTPGet: &corev1.HTTPGetAction{Path: "/ready", HTTPHeaders: []corev1.HTTPHeader{{Name: "Authorization", Value: "secret"}}}},
+				PeriodSeconds: 5,
+			},
+		}},
+		Volumes: []corev1.Volume{
+			{Name: "host", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/run"}}},
+			{Name: "secret", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "credentials"}}},
+		},
+	}
+
+	sanitizedMetadata, sanitizedSpec := SanitizePod(podMetadata, podSpec)
+	assert.Equal(t, &metav1.ObjectMeta{Annotations: map[string]string{
+		annotationKeyPod: corev1.SeccompProfileNameUnconfined,
+		corev1.DeprecatedAppArmorBetaContainerAnnotationKeyPrefix + "a": "unconfined",
+	}}, sanitizedMetadata)
+	assert.Equal(t, &corev1.PodSpec{
+		HostPID: true,
+		Containers: []corev1.Container{{
+			Name:          "a",
+			Image:         "registry.example.com/app",
+			Command:       []string{"run", "--token=secret"},
+			Ports:         []corev1.ContainerPort{{ContainerPort: 80, HostPort: 8080}},
+			VolumeMounts:  []corev1.VolumeMount{{Name: "host", ReadOnly: true}},
+			Resources:     corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceCPU: resource.Quantity{}}},
+			LivenessProbe: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{Exec: &corev1.ExecAction{Command: []string{"cat", "/tmp/healthy"}}}},
+		}},
+		Volumes: []corev1.Volume{
+			{Name: "host", VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/run"}}},
+			{Name: "secret", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{}}},
+		},
+	}, sanitizedSpec)
+}
+
+// TestSanitizePodFixtures ensures sanitized fixtures evaluate to the same results as the original fixtures,
+// including with the optional checks.
+func TestSanitizePodFixtures(t *testing.T) {
+	evaluator, err := NewEvaluator(append(DefaultChecks(), OptionalChecks()...), WithFieldErrors())
+	require.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join("..", "test", "testdata", "*", "*", "*", "*.yaml"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	for _, file := range files {
+		// test/testdata/<level>/<version>/<pass|fail>/<name>.yaml
+		parts := strings.Split(filepath.ToSlash(file), "/")
+		level, err := api.ParseLevel(parts[len(parts)-4])
+		require.NoError(t, err)
+		version, err := api.ParseVersion(parts[len(parts)-3])
+		require.NoError(t, err)
+		lv := api.LevelVersion{Level: level, Version: version}
+
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		pod := &corev1.Pod{}
+		require.NoError(t, yaml.Unmarshal(data, pod), file)
+
+		expected := evaluator.EvaluatePod(lv, &pod.ObjectMeta, &pod.Spec)
+		sanitizedMetadata, sanitizedSpec := SanitizePod(&pod.ObjectMeta, &pod.Spec)
+		assert.Equal(t, expected, evaluator.EvaluatePod(lv, sanitizedMetadata, sanitizedSpec), file)
+	}
+}
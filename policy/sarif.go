@@ -0,0 +1,202 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+
+	"k8s.io/pod-security-admission/api"
+)
+
+const (
+	// SARIFVersion is the version of the SARIF format of SARIFLog.
+	SARIFVersion = "2.1.0"
+	// SARIFSchema is the JSON Schema of the SARIF format of SARIFLog.
+	SARIFSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// SARIFLog is a SARIF log, restricted to the properties needed to report violations,
+// e.g. to the code scanning dashboards of CI systems.
+type SARIFLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+// SARIFRun is a run of a tool in a SARIFLog.
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+// SARIFTool describes the tool of a SARIFRun.
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+// SARIFDriver describes the tool of a SARIFRun and the rules its results refer to.
+type SARIFDriver struct {
+	Name  string      `json:"name"`
+	Rules []SARIFRule `json:"rules"`
+}
+
+// SARIFRule is a rule the results of a SARIFRun refer to.
+type SARIFRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name,omitempty"`
+	ShortDescription SARIFMessage `json:"shortDescription"`
+}
+
+// SARIFMessage is the text of a SARIFRule or SARIFResult.
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+// SARIFResult is a violation of a rule.
+type SARIFResult struct {
+	RuleID string `json:"ruleId"`
+	// RuleIndex is the index of the rule in the rules of the driver, if known.
+	RuleIndex *int            `json:"ruleIndex,omitempty"`
+	Level     string          `json:"level"`
+	Message   SARIFMessage    `json:"message"`
+	Locations []SARIFLocation `json:"locations"`
+}
+
+// SARIFLocation is the location of a SARIFResult, in the manifest of the object if known.
+type SARIFLocation struct {
+	PhysicalLocation *SARIFPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []SARIFLogicalLocation `json:"logicalLocations"`
+}
+
+// SARIFPhysicalLocation is the file of a SARIFLocation.
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+}
+
+// SARIFArtifactLocation is the URI of a file, e.g. the path of a manifest relative to the repository root.
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// SARIFLogicalLocation is a logical location of a SARIFLocation, e.g. an object or the path of one of its fields.
+type SARIFLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName,omitempty"`
+	Kind               string `json:"kind"`
+}
+
+// SARIFPodResult is the evaluation result of a pod or workload reported by NewSARIFLog.
+type SARIFPodResult struct {
+	// Name identifies the evaluated object, e.g. namespace/name or Deployment/name.
+	Name string
+	// URI is the URI of the manifest of the object, e.g. its path relative to the repository root, if known.
+	URI string
+	// LevelVersion is the policy level & version the object was evaluated against.
+	LevelVersion api.LevelVersion
+	// Result is the aggregated result of the checks, e.g. AggregateCheckResults of the results of EvaluatePod.
+	// Its offending fields are reported as logical locations when the checks are evaluated WithFieldErrors.
+	Result AggregateCheckResult
+}
+
+// SARIFRuleID returns the SARIF rule ID of a violation, keyed by check ID and policy version, e.g. privileged/v1.29.
+// Violations without a check ID, e.g. from custom evaluators, are keyed by their forbidden reason.
+// The version of the violation is used if set, or else the given version the pod was evaluated at.
+func SARIFRuleID(violation CheckViolation, version api.Version) string {
+	v := violation.Version
+	if len(v) == 0 {
+		v = version.String()
+	}
+	if len(violation.Check) == 0 {
+		return violation.Reason + "/" + v
+	}
+	return string(violation.Check) + "/" + v
+}
+
+// NewSARIFLog returns a SARIF log of a run of the named tool, with a result of level error for each violation of the pods.
+// Rules are keyed by check ID and policy version (see SARIFRuleID) and described by the forbidden reason.
+// Results are located at the offending fields of the pods, or at the pods if the fields are unknown.
+// Warnings of allowed checks are not reported.
+func NewSARIFLog(tool string, pods ...SARIFPodResult) *SARIFLog {
+	run := SARIFRun{
+		Tool:    SARIFTool{Driver: SARIFDriver{Name: tool, Rules: []SARIFRule{}}},
+		Results: []SARIFResult{},
+	}
+	ruleIndexes := map[string]int{}
+	for _, pod := range pods {
+		for _, violation := range pod.Result.Violations {
+			ruleID := SARIFRuleID(violation, pod.LevelVersion.Version)
+			index, ok := ruleIndexes[ruleID]
+			if !ok {
+				index = len(run.Tool.Driver.Rules)
+				ruleIndexes[ruleID] = index
+				run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, SARIFRule{
+					ID:               ruleID,
+					Name:             string(violation.Check),
+					ShortDescription: SARIFMessage{Text: violation.Reason},
+				})
+			}
+
+			reason := violation.Reason
+			if len(violation.Detail) > 0 {
+				reason = fmt.Sprintf("%s (%s)", violation.Reason, violation.Detail)
+			}
+			run.Results = append(run.Results, SARIFResult{
+				RuleID:    ruleID,
+				RuleIndex: &index,
+				Level:     "error",
+				Message:   SARIFMessage{Text: fmt.Sprintf("%s violates PodSecurity %q: %s", pod.Name, pod.LevelVersion.String(), reason)},
+				Locations: sarifLocations(pod, violation),
+			})
+		}
+	}
+	return &SARIFLog{
+		Schema:  SARIFSchema,
+		Version: SARIFVersion,
+		Runs:    []SARIFRun{run},
+	}
+}
+
+// sarifLocations returns a location for each offending field of the violation, or the location of the pod
+// if the fields are unknown.
+func sarifLocations(pod SARIFPodResult, violation CheckViolation) []SARIFLocation {
+	var physicalLocation *SARIFPhysicalLocation
+	if len(pod.URI) > 0 {
+		physicalLocation = &SARIFPhysicalLocation{ArtifactLocation: SARIFArtifactLocation{URI: pod.URI}}
+	}
+	var locations []SARIFLocation
+	for _, f := range violation.Fields {
+		// skip the error counting the omitted field errors
+		if len(f.Path) == 0 {
+			continue
+		}
+		locations = append(locations, SARIFLocation{
+			PhysicalLocation: physicalLocation,
+			LogicalLocations: []SARIFLogicalLocation{{
+				Name:               f.Path,
+				FullyQualifiedName: pod.Name + "/" + f.Path,
+				Kind:               "member",
+			}},
+		})
+	}
+	if len(locations) == 0 {
+		locations = append(locations, SARIFLocation{
+			PhysicalLocation: physicalLocation,
+			LogicalLocations: []SARIFLogicalLocation{{Name: pod.Name, Kind: "object"}},
+		})
+	}
+	return locations
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/utils/ptr"
+)
+
+func TestNewSARIFLog(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{
+		HostNetwork: true,
+		Containers: []corev1.Container{{
+			Name:            "a",
+			SecurityContext: &corev1.SecurityContext{Privileged: ptr.To(true)},
+		}, {
+			Name:            "b",
+			SecurityContext: &corev1.SecurityContext{Privileged: ptr.To(true)},
+		}},
+	}}
+	lv := api.LevelVersion{Level: api.LevelBaseline, Version: api.MajorMinorVersion(1, 29)}
+	withFieldErrors, err := NewEvaluator(DefaultChecks(), WithFieldErrors())
+	require.NoError(t, err)
+	withoutFieldErrors, err := NewEvaluator(DefaultChecks())
+	require.NoError(t, err)
+
+	log := NewSARIFLog("podsecurity",
+		SARIFPodResult{
+			Name:         "ns/with-fields",
+			URI:          "manifests/pod.yaml",
+			LevelVersion: lv,
+			Result:       AggregateCheckResults(withFieldErrors.EvaluatePod(lv, &pod.ObjectMeta, &pod.Spec)),
+		},
+		SARIFPodResult{
+			Name:         "ns/without-fields",
+			LevelVersion: lv,
+			Result:       AggregateCheckResults(withoutFieldErrors.EvaluatePod(lv, &pod.ObjectMeta, &pod.Spec)),
+		},
+	)
+	assert.Equal(t, SARIFVersion, log.Version)
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	assert.Equal(t, "podsecurity", run.Tool.Driver.Name)
+
+	// rules are shared by the violations of both pods
+	assert.Equal(t, []SARIFRule{
+		{ID: "hostNamespaces/v1.29", Name: "hostNamespaces", ShortDescription: SARIFMessage{Text: "host namespaces"}},
+		{ID: "privileged/v1.29", Name: "privileged", ShortDescription: SARIFMessage{Text: "privileged"}},
+	}, run.Tool.Driver.Rules)
+	require.Len(t, run.Results, 4)
+	for _, result := range run.Results {
+		assert.Equal(t, "error", result.Level)
+		require.NotNil(t, result.RuleIndex)
+		assert.Equal(t, run.Tool.Driver.Rules[*result.RuleIndex].ID, result.RuleID)
+	}
+
+	privileged := run.Results[1]
+	assert.Equal(t, "privileged/v1.29", privileged.RuleID)
+	assert.Equal(t, `ns/with-fields violates PodSecurity "baseline:v1.29": privileged (containers "a", "b" must not set securityContext.privileged=true)`, privileged.Message.Text)
+	assert.Equal(t, []SARIFLocation{{
+		PhysicalLocation: &SARIFPhysicalLocation{ArtifactLocation: SARIFArtifactLocation{URI: "manifests/pod.yaml"}},
+		LogicalLocations: []SARIFLogicalLocation{{Name: "spec.containers[0].securityContext.privileged", FullyQualifiedName: "ns/with-fields/spec.containers[0].securityContext.privileged", Kind: "member"}},
+	}, {
+		PhysicalLocation: &SARIFPhysicalLocation{ArtifactLocation: SARIFArtifactLocation{URI: "manifests/pod.yaml"}},
+		LogicalLocations: []SARIFLogicalLocation{{Name: "spec.containers[1].securityContext.privileged", FullyQualifiedName: "ns/with-fields/spec.containers[1].securityContext.privileged", Kind: "member"}},
+	}}, privileged.Locations)
+
+	// without field errors, results are located at the pod
+	assert.Equal(t, []SARIFLocation{{
+		LogicalLocations: []SARIFLogicalLocation{{Name: "ns/without-fields", Kind: "object"}},
+	}}, run.Results[3].Locations)
+
+	data, err := json.Marshal(log)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"$schema":"`+SARIFSchema+`"`)
+}
+
+func TestSARIFRuleID(t *testing.T) {
+	version := api.MajorMinorVersion(1, 25)
+	assert.Equal(t, "privileged/v1.29", SARIFRuleID(CheckViolation{Check: "privileged", Version: "v1.29", Reason: "privileged"}, version))
+	assert.Equal(t, "privileged/v1.25", SARIFRuleID(CheckViolation{Check: "privileged", Reason: "privileged"}, version))
+	assert.Equal(t, "custom reason/v1.25", SARIFRuleID(CheckViolation{Reason: "custom reason"}, version))
+}
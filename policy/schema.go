@@ -0,0 +1,166 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SchemaVersion returns a stable hash of the IDs, levels, versions, overrides and required features of the given checks,
+// independent of their order. Admission replicas evaluating the same checks report the same schema version,
+// which allows verifying that a fleet enforces identical logic.
+// Changes to the implementation of a check version are not reflected, and must be accompanied by a new version.
+func SchemaVersion(checks []Check) string {
+	return schemaVersion(checks, nil)
+}
+
+// schemaVersion returns the SchemaVersion of the checks, also hashing the given option lines, if any.
+func schemaVersion(checks []Check, optionLines []string) string {
+	lines := make([]string, 0, len(checks)+len(optionLines))
+	for _, c := range checks {
+		var b strings.Builder
+		fmt.Fprintf(&b, "%s:%s", c.ID, c.Level)
+		for _, v := range c.Versions {
+			fmt.Fprintf(&b, ":%s", v.MinimumVersion)
+			if len(v.OverrideCheckIDs) > 0 {
+				overrides := make([]string, len(v.OverrideCheckIDs))
+				for i, id := range v.OverrideCheckIDs {
+					overrides[i] = string(id)
+				}
+				sort.Strings(overrides)
+				fmt.Fprintf(&b, "[%s]", strings.Join(overrides, ","))
+			}
+		}
+		if len(c.RequiredFeatures) > 0 {
+			features := make([]string, len(c.RequiredFeatures))
+			for i, f := range c.RequiredFeatures {
+				features[i] = string(f)
+			}
+			sort.Strings(features)
+			fmt.Fprintf(&b, ":%s", strings.Join(features, ","))
+		}
+		lines = append(lines, b.String())
+	}
+	lines = append(lines, optionLines...)
+	sort.Strings(lines)
+	sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// EvaluatorSchemaVersion returns the SchemaVersion of the checks registered in an Evaluator
+// constructed by NewEvaluator, or an empty string for other Evaluator implementations.
+// The options of the Evaluator changing the decisions or the reported field errors of the checks are also hashed,
+// so replicas configured differently report different schema versions, while an Evaluator constructed without
+// options reports the SchemaVersion of its checks. Resolvers and catalogs are only hashed by type. The result cache,
+// and choices of the callers that do not change decisions, like EvaluatePodUntilDenied, are not hashed.
+func EvaluatorSchemaVersion(evaluator Evaluator) string {
+	if r, ok := evaluator.(*checkRegistry); ok {
+		return r.schemaVersion
+	}
+	return ""
+}
+
+// optionsSchema returns the lines describing the options changing the decisions or the field errors of the checks,
+// leaving out the options set to their default, in no particular order.
+func optionsSchema(opts options) []string {
+	var lines []string
+	add := func(name string, value interface{}) {
+		lines = append(lines, fmt.Sprintf("option:%s=%v", name, value))
+	}
+	if opts.withFieldErrors {
+		add("fieldErrors", true)
+	}
+	if opts.maxFieldErrors != 0 {
+		add("maxFieldErrors", opts.maxFieldErrors)
+	}
+	if opts.fieldPathPrefix != nil {
+		add("fieldPathPrefix", opts.fieldPathPrefix.String())
+	}
+	for feature, enabled := range opts.features {
+		if enabled {
+			add("feature", feature)
+		}
+	}
+	if relaxUserNamespacePods(opts) {
+		add("relaxUserNamespacePods", true)
+	}
+	if opts.requiredSupplementalGroupsPolicy != "" {
+		add("requiredSupplementalGroupsPolicy", opts.requiredSupplementalGroupsPolicy)
+	}
+	if len(opts.allowedFSGroupChangePolicies) > 0 {
+		add("allowedFSGroupChangePolicies", sortedStrings(opts.allowedFSGroupChangePolicies))
+	}
+	if len(opts.allowedDeviceClasses) > 0 {
+		add("allowedDeviceClasses", sortedStrings(opts.allowedDeviceClasses))
+	}
+	if opts.deviceClassResolver != nil {
+		add("deviceClassResolver", fmt.Sprintf("%T", opts.deviceClassResolver))
+	}
+	if opts.localhostProfileCatalog != nil {
+		add("localhostProfileCatalog", fmt.Sprintf("%T", opts.localhostProfileCatalog))
+	}
+	if opts.runtimeClassDefaultsResolver != nil {
+		add("runtimeClassDefaultsResolver", fmt.Sprintf("%T", opts.runtimeClassDefaultsResolver))
+	}
+	if opts.windowsPodMode != WindowsPodModeDefault {
+		add("windowsPodMode", opts.windowsPodMode)
+	}
+	if opts.hostBreakoutCommandPatterns != nil {
+		add("hostBreakoutCommandPatterns", sortedStrings(opts.hostBreakoutCommandPatterns))
+	}
+	if opts.rootExecCommandPatterns != nil {
+		add("rootExecCommandPatterns", sortedStrings(opts.rootExecCommandPatterns))
+	}
+	if opts.requireImageDigests {
+		add("requireImageDigests", true)
+	}
+	if len(opts.requiredResourceLimits) > 0 {
+		add("requiredResourceLimits", sortedStrings(opts.requiredResourceLimits))
+	}
+	if len(opts.readOnlyHostPaths) > 0 {
+		add("readOnlyHostPaths", sortedStrings(opts.readOnlyHostPaths))
+	}
+	if len(opts.additionalAllowedVolumeTypes) > 0 {
+		add("additionalAllowedVolumeTypes", sortedStrings(opts.additionalAllowedVolumeTypes))
+	}
+	if len(opts.withoutBadValues) > 0 {
+		add("withoutBadValues", sortedStrings(opts.withoutBadValues))
+	}
+	if opts.defaultCheckDeadline > 0 {
+		add("checkDeadline", opts.defaultCheckDeadline)
+	}
+	for id, deadline := range opts.checkDeadlines {
+		if deadline > 0 {
+			add("checkDeadline:"+string(id), deadline)
+		}
+	}
+	return lines
+}
+
+// sortedStrings returns the sorted, comma-separated string forms of the values.
+func sortedStrings[T any](values []T) string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = fmt.Sprint(v)
+	}
+	sort.Strings(s)
+	return strings.Join(s, ",")
+}
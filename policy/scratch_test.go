@@ -0,0 +1,48 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScratch(t *testing.T) {
+	s := &scratch{strings: make([]string, 2*scratchChunk)}
+
+	a := s.stringSlice()
+	b := s.stringSlice()
+	assert.Equal(t, 0, len(a))
+	assert.Equal(t, scratchChunk, cap(a))
+	assert.Nil(t, s.stringSlice(), "exhausted scratch")
+
+	// appending past the capacity of a slice must not overwrite the next slice
+	for i := 0; i <= scratchChunk; i++ {
+		a = append(a, "a")
+	}
+	b = append(b, "b")
+	assert.Equal(t, "b", b[0])
+	assert.Equal(t, []string{"a", "a", "a", "a", "a", "a", "a", "a"}, s.strings[:scratchChunk])
+
+	var nilScratch *scratch
+	assert.Nil(t, nilScratch.stringSlice())
+
+	s.release()
+	assert.Equal(t, 0, s.next)
+	assert.Equal(t, make([]string, 2*scratchChunk), s.strings, "released strings are cleared")
+}
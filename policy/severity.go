@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+
+	"k8s.io/pod-security-admission/api"
+)
+
+// Severity classifies the violations of a check, so violations can be triaged by their impact.
+type Severity string
+
+const (
+	// SeverityCritical is the severity of checks of fields that let containers break out to the host,
+	// like privileged containers and hostPath volumes.
+	SeverityCritical Severity = "critical"
+	// SeverityHigh is the default severity of the other baseline checks.
+	SeverityHigh Severity = "high"
+	// SeverityMedium is the default severity of restricted checks, hardening pods beyond the baseline.
+	SeverityMedium Severity = "medium"
+)
+
+// severityRank orders severities from the least to the most severe.
+var severityRank = map[Severity]int{
+	SeverityMedium:   1,
+	SeverityHigh:     2,
+	SeverityCritical: 3,
+}
+
+// Severities returns the severities from the most to the least severe.
+func Severities() []Severity {
+	return []Severity{SeverityCritical, SeverityHigh, SeverityMedium}
+}
+
+// ParseSeverity returns the Severity for the given string.
+// severity must be "critical", "high", or "medium".
+func ParseSeverity(severity string) (Severity, error) {
+	if _, ok := severityRank[Severity(severity)]; !ok {
+		return "", fmt.Errorf("must be one of critical, high, medium")
+	}
+	return Severity(severity), nil
+}
+
+// MoreSevere returns true if s is more severe than other. Unknown severities are the least severe.
+func (s Severity) MoreSevere(other Severity) bool {
+	return severityRank[s] > severityRank[other]
+}
+
+// checkSeverity returns the Severity of the check, defaulting to SeverityHigh for baseline checks
+// and to SeverityMedium for restricted checks.
+func checkSeverity(c Check) Severity {
+	if c.Severity != "" {
+		return c.Severity
+	}
+	if c.Level == api.LevelBaseline {
+		return SeverityHigh
+	}
+	return SeverityMedium
+}
+
+// MaxSeverity returns the most severe Severity of the violations, or an empty string if none has a severity.
+func (a *AggregateCheckResult) MaxSeverity() Severity {
+	var max Severity
+	for _, v := range a.Violations {
+		if v.Severity.MoreSevere(max) {
+			max = v.Severity
+		}
+	}
+	return max
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/utils/pointer"
+)
+
+func TestParseSeverity(t *testing.T) {
+	for _, severity := range Severities() {
+		parsed, err := ParseSeverity(string(severity))
+		assert.NoError(t, err)
+		assert.Equal(t, severity, parsed)
+	}
+	_, err := ParseSeverity("Critical")
+	assert.Error(t, err)
+	_, err = ParseSeverity("")
+	assert.Error(t, err)
+}
+
+func TestSeverityMoreSevere(t *testing.T) {
+	assert.True(t, SeverityCritical.MoreSevere(SeverityHigh))
+	assert.True(t, SeverityHigh.MoreSevere(SeverityMedium))
+	assert.True(t, SeverityMedium.MoreSevere(""))
+	assert.False(t, SeverityMedium.MoreSevere(SeverityMedium))
+	assert.False(t, SeverityHigh.MoreSevere(SeverityCritical))
+}
+
+func TestCheckSeverity(t *testing.T) {
+	assert.Equal(t, SeverityCritical, checkSeverity(CheckPrivileged()))
+	assert.Equal(t, SeverityCritical, checkSeverity(CheckHostPathVolumes()))
+	assert.Equal(t, SeverityHigh, checkSeverity(CheckHostPorts()))
+	assert.Equal(t, SeverityMedium, checkSeverity(CheckSeccompProfileRestricted()))
+
+	custom := generateCheck("no-root-fs", api.LevelRestricted, []string{"v1.0"})
+	custom.Severity = SeverityHigh
+	assert.Equal(t, SeverityHigh, checkSeverity(custom))
+
+	custom.Severity = "urgent"
+	_, err := NewEvaluator([]Check{custom})
+	assert.EqualError(t, err, "check example.com/no-root-fs: invalid severity urgent: must be one of critical, high, medium")
+}
+
+func TestResultSeverity(t *testing.T) {
+	evaluator, err := NewEvaluator(DefaultChecks())
+	require.NoError(t, err)
+	pod := &corev1.Pod{Spec: corev1.PodSpec{
+		Containers: []corev1.Container{{Name: "a", SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(true)}}},
+	}}
+	lv := api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()}
+	result := AggregateCheckResults(evaluator.EvaluatePod(lv, &metav1.ObjectMeta{}, &pod.Spec))
+	require.False(t, result.Allowed)
+	assert.Equal(t, SeverityCritical, result.MaxSeverity())
+	for _, violation := range result.Violations {
+		if violation.Check == "privileged" {
+			assert.Equal(t, SeverityCritical, violation.Severity)
+		} else {
+			assert.Equal(t, SeverityMedium, violation.Severity, violation.Check)
+		}
+	}
+
+	assert.Empty(t, (&AggregateCheckResult{}).MaxSeverity())
+}
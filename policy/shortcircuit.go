@@ -0,0 +1,75 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/pod-security-admission/api"
+)
+
+// ShortCircuitEvaluator is implemented by Evaluators that can stop evaluating a pod at the first check disallowing it,
+// like the Evaluator returned by NewEvaluator, for callers that only need the allow/deny decision.
+type ShortCircuitEvaluator interface {
+	// EvaluatePodUntilDenied evaluates the pod like EvaluatePod, skipping the checks for which skip returns true, if set,
+	// and stops at the first check that does not allow the pod. The results are the results of all the checks that are
+	// not skipped if the pod is allowed, and end with the only result disallowing the pod otherwise.
+	EvaluatePodUntilDenied(lv api.LevelVersion, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, skip func(CheckID) bool) []CheckResult
+}
+
+var _ ShortCircuitEvaluator = &checkRegistry{}
+
+func (r *checkRegistry) EvaluatePodUntilDenied(lv api.LevelVersion, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, skip func(CheckID) bool) []CheckResult {
+	if lv.Level == api.LevelPrivileged {
+		return nil
+	}
+	if r.maxVersion.Older(lv.Version) {
+		lv.Version = r.maxVersion
+	}
+	if r.cache == nil {
+		return r.evaluateChecks(lv, podMetadata, podSpec, skip, true)
+	}
+	key, ok := r.cache.key(lv, podMetadata, podSpec)
+	if !ok {
+		return r.evaluateChecks(lv, podMetadata, podSpec, skip, true)
+	}
+	if results, ok := r.cache.get(key); ok {
+		return resultsUntilDenied(results, skip)
+	}
+	results := r.evaluateChecks(lv, podMetadata, podSpec, skip, true)
+	if skip == nil && (len(results) == 0 || results[len(results)-1].Allowed) {
+		// the pod is allowed, so all the checks were evaluated
+		r.cache.add(key, results)
+	}
+	return results
+}
+
+// resultsUntilDenied returns the results for which skip does not return true, if set,
+// up to the first result disallowing the pod.
+func resultsUntilDenied(results []CheckResult, skip func(CheckID) bool) []CheckResult {
+	filtered := make([]CheckResult, 0, len(results))
+	for _, result := range results {
+		if skip != nil && skip(result.ID) {
+			continue
+		}
+		filtered = append(filtered, result)
+		if !result.Allowed {
+			break
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/utils/pointer"
+)
+
+func TestEvaluatePodUntilDenied(t *testing.T) {
+	restricted := api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()}
+	metadata := &metav1.ObjectMeta{Name: "pod", Namespace: "ns"}
+	allowedSpec := &corev1.PodSpec{
+		SecurityContext: &corev1.PodSecurityContext{
+			RunAsNonRoot:   pointer.Bool(true),
+			SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+		},
+		Containers: []corev1.Container{{
+			Name: "app",
+			SecurityContext: &corev1.SecurityContext{
+				AllowPrivilegeEscalation: pointer.Bool(false),
+				Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+			},
+		}},
+	}
+	deniedSpec := &corev1.PodSpec{
+		HostNetwork: true,
+		Containers: []corev1.Container{{
+			Name:            "app",
+			SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(true)},
+		}},
+	}
+
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{
+		{name: "uncached"},
+		{name: "cached", opts: []Option{WithResultCache(10, nil)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			evaluator, err := NewEvaluator(DefaultChecks(), tc.opts...)
+			require.NoError(t, err)
+			shortCircuit := evaluator.(ShortCircuitEvaluator)
+
+			// allowed pods are evaluated against all the checks
+			full := evaluator.EvaluatePod(restricted, metadata, allowedSpec)
+			require.True(t, AggregateCheckResults(full).Allowed)
+			assert.Equal(t, full, shortCircuit.EvaluatePodUntilDenied(restricted, metadata, allowedSpec, nil))
+
+			// denied pods are evaluated until the first violated check
+			for i := 0; i < 2; i++ {
+				results := shortCircuit.EvaluatePodUntilDenied(restricted, metadata, deniedSpec, nil)
+				require.NotEmpty(t, results)
+				assert.Equal(t, CheckID("hostNamespaces"), results[len(results)-1].ID)
+				assert.False(t, results[len(results)-1].Allowed)
+				assert.Equal(t, violatedCheckIDs(evaluator.EvaluatePod(restricted, metadata, deniedSpec))[:1], violatedCheckIDs(results))
+			}
+
+			// skipped checks are not evaluated
+			skip := func(id CheckID) bool { return id == "hostNamespaces" }
+			results := shortCircuit.EvaluatePodUntilDenied(restricted, metadata, deniedSpec, skip)
+			assert.Equal(t, []CheckID{"privileged"}, violatedCheckIDs(results))
+			for _, result := range results {
+				assert.NotEqual(t, CheckID("hostNamespaces"), result.ID)
+			}
+
+			// privileged pods are not evaluated
+			assert.Empty(t, shortCircuit.EvaluatePodUntilDenied(api.LevelVersion{Level: api.LevelPrivileged, Version: api.LatestVersion()}, metadata, deniedSpec, nil))
+		})
+	}
+}
+
+func violatedCheckIDs(results []CheckResult) []CheckID {
+	var ids []CheckID
+	for _, result := range results {
+		if !result.Allowed {
+			ids = append(ids, result.ID)
+		}
+	}
+	return ids
+}
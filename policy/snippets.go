@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/pod-security-admission/api"
+	"sigs.k8s.io/yaml"
+)
+
+// CheckSnippet is a minimal example of the fields satisfying a check, e.g. to help pod authors fix a denied pod.
+type CheckSnippet struct {
+	// Container is true if the snippet is set in the offending containers, and false if it is set in the pod spec.
+	Container bool
+	// YAML is the snippet, as YAML.
+	YAML string
+}
+
+// String returns the snippet, introduced by where it is set.
+func (s CheckSnippet) String() string {
+	if s.Container {
+		return "in each offending container:\n" + s.YAML
+	}
+	return "in the pod spec:\n" + s.YAML
+}
+
+// checkSnippets are the snippets of the builtin checks, by check ID, derived from their fixes (see Fix) applied to an empty pod:
+// the snippet of a check is the set of fields its fix sets, in the pod spec or in the container. Checks whose fixes only remove
+// forbidden values, like privileged, or cannot fix the pod, like runAsUser, have no snippet.
+var checkSnippets = sync.OnceValue(func() map[CheckID]CheckSnippet {
+	snippets := make(map[CheckID]CheckSnippet, len(podFixes))
+	for id, fix := range podFixes {
+		podSpec := &corev1.PodSpec{Containers: []corev1.Container{{}}}
+		fix(podSpec, api.LatestVersion())
+		podFields := setFields(podSpec, &corev1.PodSpec{Containers: podSpec.Containers})
+		containerFields := setFields(&podSpec.Containers[0], &corev1.Container{})
+		var snippet CheckSnippet
+		var fields map[string]interface{}
+		switch {
+		case len(podFields) > 0 && len(containerFields) == 0:
+			fields = podFields
+		case len(containerFields) > 0 && len(podFields) == 0:
+			snippet.Container = true
+			fields = containerFields
+		default:
+			continue
+		}
+		data, err := yaml.Marshal(fields)
+		if err != nil {
+			panic(fmt.Errorf("failed to marshal the snippet of check %s: %w", id, err))
+		}
+		snippet.YAML = strings.TrimSpace(string(data))
+		snippets[id] = snippet
+	}
+	return snippets
+})
+
+// setFields returns the fields of obj that differ from the fields of empty, by their JSON name.
+func setFields(obj, empty interface{}) map[string]interface{} {
+	fields, emptyFields := toFields(obj), toFields(empty)
+	for name, value := range fields {
+		if reflect.DeepEqual(value, emptyFields[name]) {
+			delete(fields, name)
+		}
+	}
+	return fields
+}
+
+// toFields returns the fields of obj, by their JSON name.
+func toFields(obj interface{}) map[string]interface{} {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		panic(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		panic(err)
+	}
+	return fields
+}
+
+// CheckSnippetFor returns the snippet of the builtin check, if it has one. Checks whose fixes only remove forbidden
+// values, like privileged or sysctls, and custom checks have no snippet.
+func CheckSnippetFor(id CheckID) (CheckSnippet, bool) {
+	snippet, ok := checkSnippets()[id]
+	return snippet, ok
+}
+
+// SnippetCheckIDs returns the sorted IDs of the builtin checks that have a snippet.
+func SnippetCheckIDs() []CheckID {
+	ids := make([]CheckID, 0, len(checkSnippets()))
+	for id := range checkSnippets() {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
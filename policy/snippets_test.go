@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/pod-security-admission/api"
+	"sigs.k8s.io/yaml"
+)
+
+func TestCheckSnippets(t *testing.T) {
+	evaluator, err := NewEvaluator(DefaultChecks())
+	require.NoError(t, err)
+	builtinChecks := map[CheckID]bool{}
+	for _, c := range DefaultChecks() {
+		builtinChecks[c.ID] = true
+	}
+
+	for _, id := range SnippetCheckIDs() {
+		t.Run(string(id), func(t *testing.T) {
+			require.True(t, builtinChecks[id], "snippet of unknown check")
+			snippet, ok := CheckSnippetFor(id)
+			require.True(t, ok)
+
+			// a pod setting only the snippet satisfies the check at every version
+			podSpec := &corev1.PodSpec{}
+			if snippet.Container {
+				container := corev1.Container{}
+				require.NoError(t, yaml.UnmarshalStrict([]byte(snippet.YAML), &container))
+				container.Name = "app"
+				podSpec.Containers = []corev1.Container{container}
+			} else {
+				require.NoError(t, yaml.UnmarshalStrict([]byte(snippet.YAML), podSpec))
+				podSpec.Containers = []corev1.Container{{Name: "app"}}
+			}
+			evaluated := false
+			for _, level := range []api.Level{api.LevelBaseline, api.LevelRestricted} {
+				for _, version := range []api.Version{api.MajorMinorVersion(1, 0), api.LatestVersion()} {
+					lv := api.LevelVersion{Level: level, Version: version}
+					for _, result := range evaluator.EvaluatePod(lv, &metav1.ObjectMeta{}, podSpec) {
+						if result.ID == id {
+							evaluated = true
+							assert.True(t, result.Allowed, "%s: %s", lv, result.ForbiddenDetail)
+						}
+					}
+				}
+			}
+			assert.True(t, evaluated, "check not evaluated")
+		})
+	}
+
+	// the snippets are the fields set by the fixes of the checks
+	assert.Equal(t, []CheckID{"allowPrivilegeEscalation", "capabilities_restricted", "runAsNonRoot", "seccompProfile_restricted"}, SnippetCheckIDs())
+	for _, id := range SnippetCheckIDs() {
+		assert.Contains(t, podFixes, id)
+	}
+	snippet, ok := CheckSnippetFor("runAsNonRoot")
+	require.True(t, ok)
+	assert.Equal(t, CheckSnippet{YAML: "securityContext:\n  runAsNonRoot: true"}, snippet)
+
+	_, ok = CheckSnippetFor("sysctls")
+	assert.False(t, ok, "fixes only removing forbidden values have no snippet")
+	_, ok = CheckSnippetFor("privileged")
+	assert.False(t, ok, "fixes only removing forbidden values have no snippet")
+}
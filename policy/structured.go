@@ -0,0 +1,149 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"encoding/json"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/pod-security-admission/api"
+)
+
+// CheckViolation is a machine-readable representation of a forbidden CheckResult,
+// so reports and dashboards can consume violations without parsing the forbidden reason and detail.
+type CheckViolation struct {
+	// Check is the ID of the forbidding check, if set on the CheckResult.
+	Check CheckID `json:"check,omitempty"`
+	// Version is the policy version the check was evaluated at, if set on the CheckResult.
+	Version string `json:"version,omitempty"`
+	// Source is the source of the forbidding check, if set on the CheckResult (see Check.Source).
+	Source string `json:"source,omitempty"`
+	// Severity is the severity of the forbidding check, if set on the CheckResult (see Check.Severity).
+	Severity Severity `json:"severity,omitempty"`
+	// Reason is the forbidden reason of the check.
+	Reason string `json:"reason"`
+	// Detail is the forbidden detail of the check.
+	Detail string `json:"detail,omitempty"`
+	// Containers are the names of the offending containers, if any.
+	// They are only set when the check is evaluated WithFieldErrors.
+	Containers []string `json:"containers,omitempty"`
+	// SidecarContainers are the names of the sidecar containers among Containers, i.e. init containers with
+	// restartPolicy=Always. They are only set when the check is evaluated WithFieldErrors.
+	SidecarContainers []string `json:"sidecarContainers,omitempty"`
+	// Fields are the offending fields. They are only set when the check is evaluated WithFieldErrors.
+	Fields []FieldViolation `json:"fields,omitempty"`
+}
+
+// FieldViolation is an offending field of a CheckViolation.
+type FieldViolation struct {
+	// Path is the path of the field, e.g. spec.containers[0].securityContext.privileged.
+	// It is empty for the field.ErrorTypeTooMany error counting the field errors omitted because of WithMaxFieldErrors.
+	Path string `json:"path,omitempty"`
+	// Type is the type of the field error, e.g. FieldValueForbidden.
+	Type field.ErrorType `json:"type"`
+	// BadValue is the offending value of the field, if known.
+	BadValue interface{} `json:"badValue,omitempty"`
+	// Detail describes the violation of the field, if set.
+	Detail string `json:"detail,omitempty"`
+}
+
+// newCheckViolation returns the CheckViolation of a forbidden result with the given reason.
+func newCheckViolation(result CheckResult, reason string) CheckViolation {
+	violation := CheckViolation{
+		Check:      result.ID,
+		Source:     result.Source,
+		Severity:   result.Severity,
+		Reason:     reason,
+		Detail:     result.ForbiddenDetail,
+		Containers: result.Containers,
+
+		SidecarContainers: result.SidecarContainers,
+	}
+	if result.Version != (api.Version{}) {
+		violation.Version = result.Version.String()
+	}
+	if result.ErrList != nil {
+		for _, err := range *result.ErrList {
+			if err == nil {
+				continue
+			}
+			fv := FieldViolation{Path: err.Field, Type: err.Type, Detail: err.Detail}
+			if _, omitted := err.BadValue.(field.OmitValueType); !omitted {
+				fv.BadValue = err.BadValue
+			}
+			violation.Fields = append(violation.Fields, fv)
+		}
+	}
+	return violation
+}
+
+// offendingContainers returns the names of the containers of the pod spec that the field errors apply to,
+// in the order of the errors, and the names of the sidecar containers among them.
+func offendingContainers(podSpec *corev1.PodSpec, errs field.ErrorList) (names, sidecars []string) {
+	if len(errs) == 0 {
+		return nil, nil
+	}
+	seen := map[string]bool{}
+	visitContainers(podSpec, options{withFieldErrors: true}, func(container *corev1.Container, kind ContainerKind, path *field.Path) {
+		prefix := path.String()
+		for _, err := range errs {
+			if err == nil || seen[container.Name] {
+				continue
+			}
+			if err.Field == prefix || strings.HasPrefix(err.Field, prefix+".") {
+				seen[container.Name] = true
+				names = append(names, container.Name)
+				if kind == ContainerKindSidecar {
+					sidecars = append(sidecars, container.Name)
+				}
+			}
+		}
+	})
+	return names, sidecars
+}
+
+// aggregateCheckResultJSON is the JSON representation of an AggregateCheckResult.
+type aggregateCheckResultJSON struct {
+	Allowed     bool                `json:"allowed"`
+	Violations  []CheckViolation    `json:"violations,omitempty"`
+	Warnings    []string            `json:"warnings,omitempty"`
+	Resolutions []ProfileResolution `json:"resolutions,omitempty"`
+	Errors      []checkErrorJSON    `json:"errors,omitempty"`
+}
+
+// checkErrorJSON is the JSON representation of a CheckError.
+type checkErrorJSON struct {
+	Check CheckID `json:"check"`
+	Error string  `json:"error"`
+}
+
+// MarshalJSON encodes the result with its structured Violations, rather than its forbidden reason and detail strings.
+func (a AggregateCheckResult) MarshalJSON() ([]byte, error) {
+	var errs []checkErrorJSON
+	for _, err := range a.Errors {
+		errs = append(errs, checkErrorJSON{Check: err.Check, Error: err.Err.Error()})
+	}
+	return json.Marshal(aggregateCheckResultJSON{
+		Allowed:     a.Allowed,
+		Violations:  a.Violations,
+		Warnings:    a.Warnings,
+		Resolutions: a.Resolutions,
+		Errors:      errs,
+	})
+}
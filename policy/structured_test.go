@@ -0,0 +1,157 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/utils/pointer"
+)
+
+func TestCheckViolations(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{
+		HostNetwork: true,
+		InitContainers: []corev1.Container{{
+			Name:  "init",
+			Ports: []corev1.ContainerPort{{ContainerPort: 80, HostPort: 8080}},
+		}},
+		Containers: []corev1.Container{{
+			Name: "a",
+		}, {
+			Name:            "b",
+			SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(true)},
+		}},
+	}}
+	lv := api.LevelVersion{Level: api.LevelBaseline, Version: api.MajorMinorVersion(1, 29)}
+
+	t.Run("with field errors", func(t *testing.T) {
+		evaluator, err := NewEvaluator(DefaultChecks(), WithFieldErrors())
+		require.NoError(t, err)
+		result := AggregateCheckResults(evaluator.EvaluatePod(lv, &pod.ObjectMeta, &pod.Spec))
+		require.False(t, result.Allowed)
+		require.Len(t, result.Violations, len(result.ForbiddenReasons))
+
+		violations := map[CheckID]CheckViolation{}
+		for i, v := range result.Violations {
+			assert.Equal(t, result.ForbiddenReasons[i], v.Reason)
+			assert.Equal(t, result.ForbiddenDetails[i], v.Detail)
+			assert.Equal(t, "v1.29", v.Version)
+			violations[v.Check] = v
+		}
+
+		assert.Empty(t, violations["hostNamespaces"].Containers)
+		assert.Equal(t, []FieldViolation{{Path: "spec.hostNetwork", Type: field.ErrorTypeForbidden, BadValue: true}}, violations["hostNamespaces"].Fields)
+		assert.Equal(t, []string{"init"}, violations["hostPorts"].Containers)
+		assert.Equal(t, []FieldViolation{{Path: "spec.initContainers[0].ports[0].hostPort", Type: field.ErrorTypeForbidden, BadValue: 8080}}, violations["hostPorts"].Fields)
+		assert.Equal(t, []string{"b"}, violations["privileged"].Containers)
+		assert.Equal(t, []FieldViolation{{Path: "spec.containers[1].securityContext.privileged", Type: field.ErrorTypeForbidden, BadValue: true}}, violations["privileged"].Fields)
+		assert.Empty(t, violations["privileged"].SidecarContainers)
+	})
+
+	t.Run("sidecar containers", func(t *testing.T) {
+		always := corev1.ContainerRestartPolicyAlways
+		evaluator, err := NewEvaluator(DefaultChecks(), WithFieldErrors())
+		require.NoError(t, err)
+		result := AggregateCheckResults(evaluator.EvaluatePod(lv, &metav1.ObjectMeta{}, &corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "istio-proxy", RestartPolicy: &always, SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(true)}}},
+			Containers:     []corev1.Container{{Name: "b", SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(true)}}},
+		}))
+		require.Len(t, result.Violations, 1)
+		assert.Equal(t, `container "b" and sidecar container "istio-proxy" must not set securityContext.privileged=true`, result.Violations[0].Detail)
+		assert.Equal(t, []string{"istio-proxy", "b"}, result.Violations[0].Containers)
+		assert.Equal(t, []string{"istio-proxy"}, result.Violations[0].SidecarContainers)
+	})
+
+	t.Run("without field errors", func(t *testing.T) {
+		evaluator, err := NewEvaluator(DefaultChecks())
+		require.NoError(t, err)
+		result := AggregateCheckResults(evaluator.EvaluatePod(lv, &pod.ObjectMeta, &pod.Spec))
+		require.Len(t, result.Violations, len(result.ForbiddenReasons))
+		for _, v := range result.Violations {
+			assert.NotEmpty(t, v.Check)
+			assert.Empty(t, v.Containers)
+			assert.Empty(t, v.Fields)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		evaluator, err := NewEvaluator(DefaultChecks(), WithFieldErrors())
+		require.NoError(t, err)
+		result := AggregateCheckResults(evaluator.EvaluatePod(lv, &metav1.ObjectMeta{}, &corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "b", SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(true)}}},
+		}))
+		data, err := json.Marshal(result)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"allowed": false,
+			"violations": [{
+				"check": "privileged",
+				"source": "k8s.io/pod-security-admission",
+				"severity": "critical",
+				"version": "v1.29",
+				"reason": "privileged",
+				"detail": "container \"b\" must not set securityContext.privileged=true",
+				"containers": ["b"],
+				"fields": [{"path": "spec.containers[0].securityContext.privileged", "type": "FieldValueForbidden", "badValue": true}]
+			}]
+		}`, string(data))
+
+		runtimeClassName := "runc"
+		evaluator, err = NewEvaluator(DefaultChecks(), WithRuntimeClassDefaults(testRuntimeClassDefaults{
+			"runc": {SeccompProfileType: corev1.SeccompProfileTypeRuntimeDefault},
+		}))
+		require.NoError(t, err)
+		restricted := api.LevelVersion{Level: api.LevelRestricted, Version: api.MajorMinorVersion(1, 29)}
+		result = AggregateCheckResults(evaluator.EvaluatePod(restricted, &metav1.ObjectMeta{}, &corev1.PodSpec{
+			RuntimeClassName: &runtimeClassName,
+			Containers: []corev1.Container{{Name: "a", SecurityContext: &corev1.SecurityContext{
+				AllowPrivilegeEscalation: pointer.Bool(false),
+				RunAsNonRoot:             pointer.Bool(true),
+				Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+			}}},
+		}))
+		data, err = json.Marshal(result)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"allowed": true,
+			"resolutions": [{
+				"check": "seccompProfile_restricted",
+				"runtimeClassName": "runc",
+				"profile": "seccomp",
+				"type": "RuntimeDefault",
+				"containers": ["a"]
+			}]
+		}`, string(data))
+
+		data, err = json.Marshal(AggregateCheckResults(nil))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"allowed": true}`, string(data))
+
+		data, err = json.Marshal(AggregateCheckResults([]CheckResult{{ID: "example.com/slow", Error: fmt.Errorf("deadline of 1s exceeded")}}))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"allowed": false, "errors": [{"check": "example.com/slow", "error": "deadline of 1s exceeded"}]}`, string(data))
+	})
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"reflect"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// reviewedPodSecurityContextFields are the pod securityContext fields the checks were written against,
+// whether they are restricted by a check or deliberately allowed.
+var reviewedPodSecurityContextFields = sets.New(
+	"seLinuxOptions",
+	"windowsOptions",
+	"runAsUser",
+	"runAsGroup",
+	"runAsNonRoot",
+	"supplementalGroups",
+	"fsGroup",
+	"sysctls",
+	"fsGroupChangePolicy",
+	"seccompProfile",
+	"appArmorProfile",
+)
+
+// reviewedContainerSecurityContextFields are the container securityContext fields the checks were written against,
+// whether they are restricted by a check or deliberately allowed.
+var reviewedContainerSecurityContextFields = sets.New(
+	"capabilities",
+	"privileged",
+	"seLinuxOptions",
+	"windowsOptions",
+	"runAsUser",
+	"runAsGroup",
+	"runAsNonRoot",
+	"readOnlyRootFilesystem",
+	"allowPrivilegeEscalation",
+	"procMount",
+	"seccompProfile",
+	"appArmorProfile",
+)
+
+// UnevaluatedFields returns the paths of the pod and container securityContext fields set in the pod spec
+// that the checks were not written against. This happens when the package is compiled against a newer
+// k8s.io/api than the checks were reviewed for, and the fields are not evaluated by any policy version.
+func UnevaluatedFields(podSpec *corev1.PodSpec) []*field.Path {
+	var paths []*field.Path
+	if podSpec.SecurityContext != nil {
+		paths = appendUnevaluatedFields(paths, securityContextPath, podSpec.SecurityContext, reviewedPodSecurityContextFields)
+	}
+	visitContainers(podSpec, options{withFieldErrors: true}, func(container *corev1.Container, _ ContainerKind, path *field.Path) {
+		if container.SecurityContext != nil {
+			paths = appendUnevaluatedFields(paths, path.Child("securityContext"), container.SecurityContext, reviewedContainerSecurityContextFields)
+		}
+	})
+	return paths
+}
+
+// appendUnevaluatedFields appends the paths of the fields set in the struct pointed to by obj that are not reviewed.
+func appendUnevaluatedFields(paths []*field.Path, path *field.Path, obj interface{}, reviewed sets.Set[string]) []*field.Path {
+	v := reflect.ValueOf(obj).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name := jsonName(v.Type().Field(i))
+		if name == "" || reviewed.Has(name) || v.Field(i).IsZero() {
+			continue
+		}
+		paths = append(paths, path.Child(name))
+	}
+	return paths
+}
+
+// jsonName returns the serialized name of the struct field, or "" if it is not serialized.
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
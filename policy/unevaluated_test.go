@@ -0,0 +1,72 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/pointer"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReviewedFields fails when k8s.io/api adds securityContext fields, so that the checks are reviewed
+// against the new fields before the reviewed field lists are updated.
+func TestReviewedFields(t *testing.T) {
+	for _, tc := range []struct {
+		obj      interface{}
+		reviewed []string
+	}{
+		{obj: corev1.PodSecurityContext{}, reviewed: sets.List(reviewedPodSecurityContextFields)},
+		{obj: corev1.SecurityContext{}, reviewed: sets.List(reviewedContainerSecurityContextFields)},
+	} {
+		typ := reflect.TypeOf(tc.obj)
+		var fields []string
+		for i := 0; i < typ.NumField(); i++ {
+			if name := jsonName(typ.Field(i)); name != "" {
+				fields = append(fields, name)
+			}
+		}
+		assert.ElementsMatch(t, tc.reviewed, fields, "unreviewed fields in %s", typ.Name())
+	}
+}
+
+func TestUnevaluatedFields(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{
+		SecurityContext: &corev1.PodSecurityContext{RunAsNonRoot: pointer.Bool(true)},
+		Containers: []corev1.Container{{
+			Name:            "a",
+			SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(false)},
+		}},
+	}}
+	assert.Empty(t, UnevaluatedFields(&pod.Spec))
+
+	// Simulate fields added to the API after the checks were reviewed.
+	reviewedPodSecurityContextFields.Delete("runAsNonRoot")
+	reviewedContainerSecurityContextFields.Delete("privileged")
+	defer reviewedPodSecurityContextFields.Insert("runAsNonRoot")
+	defer reviewedContainerSecurityContextFields.Insert("privileged")
+
+	var paths []string
+	for _, path := range UnevaluatedFields(&pod.Spec) {
+		paths = append(paths, path.String())
+	}
+	assert.Equal(t, []string{"spec.securityContext.runAsNonRoot", "spec.containers[0].securityContext.privileged"}, paths)
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EvaluatedFieldsChanged returns true unless an update of a pod, or of the pod template of a controller,
+// only changes mutable fields that are never read by the checks, in which case evaluating the updated pod
+// returns the same results as evaluating the old pod. The fields that are not read by the checks are:
+//   - the metadata, except the namespace and the seccomp and AppArmor annotations
+//   - spec.activeDeadlineSeconds, spec.terminationGracePeriodSeconds, spec.tolerations and spec.schedulingGates
+//   - the resources and resizePolicy of containers and init containers, except the names of their resource limits,
+//     read by the resourceLimits check
+//
+// Container images are read by the duplicateContainers check, so image updates change evaluated fields.
+func EvaluatedFieldsChanged(oldPodMetadata *metav1.ObjectMeta, oldPodSpec *corev1.PodSpec, podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec) bool {
+	if (oldPodSpec == nil) != (podSpec == nil) {
+		return true
+	}
+	if evaluatedMetadataChanged(oldPodMetadata, podMetadata) {
+		return true
+	}
+	if podSpec == nil {
+		return false
+	}
+	return !equality.Semantic.DeepEqual(evaluatedSpec(oldPodSpec), evaluatedSpec(podSpec))
+}
+
+func evaluatedMetadataChanged(oldPodMetadata, podMetadata *metav1.ObjectMeta) bool {
+	if oldPodMetadata == nil {
+		oldPodMetadata = &metav1.ObjectMeta{}
+	}
+	if podMetadata == nil {
+		podMetadata = &metav1.ObjectMeta{}
+	}
+	if oldPodMetadata.Namespace != podMetadata.Namespace {
+		return true
+	}
+	checked := 0
+	for k, v := range podMetadata.Annotations {
+		if !checkedAnnotation(k) {
+			continue
+		}
+		checked++
+		if oldValue, ok := oldPodMetadata.Annotations[k]; !ok || oldValue != v {
+			return true
+		}
+	}
+	for k := range oldPodMetadata.Annotations {
+		if checkedAnnotation(k) {
+			checked--
+		}
+	}
+	return checked != 0
+}
+
+// evaluatedSpec returns a shallow copy of the pod spec without the mutable fields that are not read by the checks.
+func evaluatedSpec(podSpec *corev1.PodSpec) *corev1.PodSpec {
+	spec := *podSpec
+	spec.ActiveDeadlineSeconds = nil
+	spec.TerminationGracePeriodSeconds = nil
+	spec.Tolerations = nil
+	spec.SchedulingGates = nil
+	spec.Containers = evaluatedContainers(podSpec.Containers)
+	spec.InitContainers = evaluatedContainers(podSpec.InitContainers)
+	return &spec
+}
+
+func evaluatedContainers(containers []corev1.Container) []corev1.Container {
+	if containers == nil {
+		return nil
+	}
+	evaluated := make([]corev1.Container, len(containers))
+	for i, c := range containers {
+		var limits corev1.ResourceList
+		for name := range c.Resources.Limits {
+			if limits == nil {
+				limits = corev1.ResourceList{}
+			}
+			limits[name] = resource.Quantity{}
+		}
+		c.Resources = corev1.ResourceRequirements{Limits: limits}
+		c.ResizePolicy = nil
+		evaluated[i] = c
+	}
+	return evaluated
+}
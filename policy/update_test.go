@@ -0,0 +1,126 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func TestEvaluatedFieldsChanged(t *testing.T) {
+	newPod := func() (*metav1.ObjectMeta, *corev1.PodSpec) {
+		return &metav1.ObjectMeta{
+			Name:        "test-pod",
+			Namespace:   "ns",
+			Labels:      map[string]string{"app": "test"},
+			Annotations: map[string]string{"note": "a", annotationKeyPod: "runtime/default"},
+		}, &corev1.PodSpec{
+			ActiveDeadlineSeconds: pointer.Int64(60),
+			Tolerations:           []corev1.Toleration{{Key: "a"}},
+			SchedulingGates:       []corev1.PodSchedulingGate{{Name: "gate"}},
+			InitContainers:        []corev1.Container{{Name: "init", Image: "init:1"}},
+			Containers: []corev1.Container{{
+				Name:      "app",
+				Image:     "app:1",
+				Resources: corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+				SecurityContext: &corev1.SecurityContext{
+					AllowPrivilegeEscalation: pointer.Bool(false),
+				},
+			}},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		update   func(*metav1.ObjectMeta, *corev1.PodSpec)
+		expected bool
+	}{
+		// mutable fields not read by the checks
+		{name: "unchanged", update: func(*metav1.ObjectMeta, *corev1.PodSpec) {}},
+		{name: "labels", update: func(m *metav1.ObjectMeta, _ *corev1.PodSpec) { m.Labels["app"] = "other" }},
+		{name: "unchecked annotation", update: func(m *metav1.ObjectMeta, _ *corev1.PodSpec) { m.Annotations["note"] = "b" }},
+		{name: "resourceVersion", update: func(m *metav1.ObjectMeta, _ *corev1.PodSpec) { m.ResourceVersion = "2" }},
+		{name: "activeDeadlineSeconds", update: func(_ *metav1.ObjectMeta, s *corev1.PodSpec) { s.ActiveDeadlineSeconds = pointer.Int64(30) }},
+		{name: "terminationGracePeriodSeconds", update: func(_ *metav1.ObjectMeta, s *corev1.PodSpec) { s.TerminationGracePeriodSeconds = pointer.Int64(1) }},
+		{name: "tolerations", update: func(_ *metav1.ObjectMeta, s *corev1.PodSpec) {
+			s.Tolerations = append(s.Tolerations, corev1.Toleration{Key: "b"})
+		}},
+		{name: "schedulingGates", update: func(_ *metav1.ObjectMeta, s *corev1.PodSpec) { s.SchedulingGates = nil }},
+		{name: "container resources", update: func(_ *metav1.ObjectMeta, s *corev1.PodSpec) {
+			s.Containers[0].Resources.Limits[corev1.ResourceCPU] = resource.MustParse("2")
+		}},
+		{name: "init container resizePolicy", update: func(_ *metav1.ObjectMeta, s *corev1.PodSpec) {
+			s.InitContainers[0].ResizePolicy = []corev1.ContainerResizePolicy{{ResourceName: corev1.ResourceCPU}}
+		}},
+		{name: "container resizePolicy", update: func(_ *metav1.ObjectMeta, s *corev1.PodSpec) {
+			s.Containers[0].ResizePolicy = []corev1.ContainerResizePolicy{{ResourceName: corev1.ResourceMemory, RestartPolicy: corev1.RestartContainer}}
+		}},
+		{name: "init container resources", update: func(_ *metav1.ObjectMeta, s *corev1.PodSpec) {
+			s.InitContainers[0].Resources.Requests = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Mi")}
+		}},
+
+		// fields read by the checks
+		{name: "container resource limit added", update: func(_ *metav1.ObjectMeta, s *corev1.PodSpec) {
+			s.Containers[0].Resources.Limits[corev1.ResourceMemory] = resource.MustParse("64Mi")
+		}, expected: true},
+		{name: "container resource limit removed", update: func(_ *metav1.ObjectMeta, s *corev1.PodSpec) {
+			delete(s.Containers[0].Resources.Limits, corev1.ResourceCPU)
+		}, expected: true},
+		{name: "container image", update: func(_ *metav1.ObjectMeta, s *corev1.PodSpec) { s.Containers[0].Image = "app:2" }, expected: true},
+		{name: "init container image", update: func(_ *metav1.ObjectMeta, s *corev1.PodSpec) { s.InitContainers[0].Image = "init:2" }, expected: true},
+		{name: "checked annotation", update: func(m *metav1.ObjectMeta, _ *corev1.PodSpec) {
+			m.Annotations[annotationKeyPod] = "unconfined"
+		}, expected: true},
+		{name: "checked annotation removed", update: func(m *metav1.ObjectMeta, _ *corev1.PodSpec) {
+			delete(m.Annotations, annotationKeyPod)
+		}, expected: true},
+		{name: "checked annotation added", update: func(m *metav1.ObjectMeta, _ *corev1.PodSpec) {
+			m.Annotations[annotationKeyContainerPrefix+"app"] = "unconfined"
+		}, expected: true},
+		{name: "namespace", update: func(m *metav1.ObjectMeta, _ *corev1.PodSpec) { m.Namespace = "other" }, expected: true},
+		{name: "securityContext", update: func(_ *metav1.ObjectMeta, s *corev1.PodSpec) {
+			s.Containers[0].SecurityContext.AllowPrivilegeEscalation = pointer.Bool(true)
+		}, expected: true},
+		{name: "ephemeral container added", update: func(_ *metav1.ObjectMeta, s *corev1.PodSpec) {
+			s.EphemeralContainers = append(s.EphemeralContainers, corev1.EphemeralContainer{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debug"}})
+		}, expected: true},
+		{name: "container added", update: func(_ *metav1.ObjectMeta, s *corev1.PodSpec) {
+			s.Containers = append(s.Containers, corev1.Container{Name: "sidecar"})
+		}, expected: true},
+		{name: "hostNetwork", update: func(_ *metav1.ObjectMeta, s *corev1.PodSpec) { s.HostNetwork = true }, expected: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			oldMetadata, oldSpec := newPod()
+			podMetadata, podSpec := newPod()
+			tc.update(podMetadata, podSpec)
+			assert.Equal(t, tc.expected, EvaluatedFieldsChanged(oldMetadata, oldSpec, podMetadata, podSpec))
+			assert.Equal(t, tc.expected, EvaluatedFieldsChanged(podMetadata, podSpec, oldMetadata, oldSpec), "reversed update")
+		})
+	}
+
+	t.Run("nil spec", func(t *testing.T) {
+		podMetadata, podSpec := newPod()
+		assert.True(t, EvaluatedFieldsChanged(podMetadata, nil, podMetadata, podSpec))
+		assert.False(t, EvaluatedFieldsChanged(nil, nil, nil, nil))
+	})
+}
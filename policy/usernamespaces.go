@@ -0,0 +1,53 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// userNamespaceRelaxedChecks are the checks allowing pods with hostUsers=false when policies are relaxed for them
+// (see relaxPolicyForUserNamespacePod).
+var userNamespaceRelaxedChecks = map[CheckID]bool{
+	"runAsNonRoot": true,
+	"runAsUser":    true,
+}
+
+// UserNamespaceRelaxationChecker reports the violations of pods in user namespaces that are only forbidden
+// because policies are not relaxed for them, e.g. to explain the violations of pods setting hostUsers=false
+// to benefit from a relaxation the evaluator does not enable.
+// It is implemented by the Evaluator returned by NewEvaluator.
+type UserNamespaceRelaxationChecker interface {
+	// UnrelaxedUserNamespaceChecks returns the IDs of the checks of the violations that would allow the pod
+	// if policies were relaxed for pods with hostUsers=false, with the UserNamespacesPodSecurityStandards feature
+	// or WithRelaxedUserNamespacePods. It returns nil if the pod does not set hostUsers=false,
+	// or if policies are already relaxed for it.
+	UnrelaxedUserNamespaceChecks(podSpec *corev1.PodSpec, violations []CheckViolation) []CheckID
+}
+
+func (r *checkRegistry) UnrelaxedUserNamespaceChecks(podSpec *corev1.PodSpec, violations []CheckViolation) []CheckID {
+	if podSpec == nil || podSpec.HostUsers == nil || *podSpec.HostUsers || relaxUserNamespacePods(resolveOptions(r.checkOptions)) {
+		return nil
+	}
+	var ids []CheckID
+	for _, v := range violations {
+		if userNamespaceRelaxedChecks[v.Check] {
+			ids = append(ids, v.Check)
+		}
+	}
+	return ids
+}
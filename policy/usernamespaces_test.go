@@ -0,0 +1,55 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/component-base/featuregate"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/utils/ptr"
+)
+
+func TestUnrelaxedUserNamespaceChecks(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{
+		HostUsers:  ptr.To(false),
+		Containers: []corev1.Container{{Name: "a", SecurityContext: &corev1.SecurityContext{RunAsUser: ptr.To[int64](0)}}},
+	}}
+	lv := api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()}
+
+	evaluator, err := NewEvaluator(DefaultChecks())
+	require.NoError(t, err)
+	checker := evaluator.(UserNamespaceRelaxationChecker)
+	violations := AggregateCheckResults(evaluator.EvaluatePod(lv, &pod.ObjectMeta, &pod.Spec)).Violations
+	assert.Equal(t, []CheckID{"runAsNonRoot", "runAsUser"}, checker.UnrelaxedUserNamespaceChecks(&pod.Spec, violations))
+
+	// pods in the host user namespace are never relaxed
+	hostUsers := pod.Spec.DeepCopy()
+	hostUsers.HostUsers = nil
+	assert.Empty(t, checker.UnrelaxedUserNamespaceChecks(hostUsers, violations))
+
+	gate := featuregate.NewFeatureGate()
+	require.NoError(t, AddFeatureGates(gate))
+	require.NoError(t, gate.SetFromMap(map[string]bool{string(UserNamespacesPodSecurityStandards): true}))
+	relaxed, err := NewEvaluator(DefaultChecks(), WithFeatureGate(gate))
+	require.NoError(t, err)
+	assert.Empty(t, relaxed.(UserNamespaceRelaxationChecker).UnrelaxedUserNamespaceChecks(&pod.Spec, violations))
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package policy
 
 import (
+	"fmt"
+
 	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
@@ -26,6 +28,25 @@ type Violations struct {
 	data            []string
 	errs            *field.ErrorList
 	withFieldErrors bool
+	// errEnds are the number of collected field errors after adding each entry of data,
+	// which delimit the field errors of the entries returned by Entries. It is only set with field errors.
+	errEnds []int
+	// sidecars are the names of the sidecar containers added with AddContainer.
+	sidecars []string
+	// maxErrs bounds the collected field errors, if set.
+	maxErrs int
+	// collected is the number of collected field errors.
+	collected int
+	// overflow is the number of field errors dropped once maxErrs was reached.
+	overflow int
+}
+
+// ViolationEntry is a single violation description with its associated field errors.
+type ViolationEntry struct {
+	// Data is the description of the violation, as returned by Data.
+	Data string
+	// Errs holds the field errors added with Data. It is only populated when field errors are enabled.
+	Errs field.ErrorList
 }
 
 func NewViolations(withFieldErrors bool) Violations {
@@ -38,17 +59,61 @@ func NewViolations(withFieldErrors bool) Violations {
 	return violations
 }
 
+// newViolations returns Violations collecting field errors as configured by the options.
+// The data of the violations is backed by the scratch of the options, if set, and must not be retained by checks.
+func newViolations(opts options) Violations {
+	violations := NewViolations(opts.withFieldErrors)
+	violations.maxErrs = opts.maxFieldErrors
+	violations.data = opts.scratch.stringSlice()
+	return violations
+}
+
 func (v *Violations) Add(data string, errs ...*field.Error) {
 	v.data = append(v.data, data)
 	if v.withFieldErrors {
 		for _, err := range errs {
-			if err != nil {
-				*v.errs = append(*v.errs, err)
+			if err == nil {
+				continue
+			}
+			if v.maxErrs > 0 && v.collected >= v.maxErrs {
+				v.addOverflow()
+				continue
 			}
+			v.collected++
+			*v.errs = append(*v.errs, err)
 		}
+		v.errEnds = append(v.errEnds, v.collected)
 	}
 }
 
+// addOverflow counts a dropped field error, and reports the count in the last error of Errs.
+func (v *Violations) addOverflow() {
+	v.overflow++
+	if v.overflow == 1 {
+		*v.errs = append(*v.errs, nil)
+	}
+	(*v.errs)[len(*v.errs)-1] = &field.Error{
+		Type:     field.ErrorTypeTooMany,
+		BadValue: v.collected + v.overflow,
+		Detail:   fmt.Sprintf("must have at most %d field errors, %d field errors omitted", v.maxErrs, v.overflow),
+	}
+}
+
+// AddContainer adds the name of a container, tracking sidecar containers so that DescribeContainers
+// describes them separately.
+func (v *Violations) AddContainer(name string, kind ContainerKind, errs ...*field.Error) {
+	if kind == ContainerKindSidecar {
+		v.sidecars = append(v.sidecars, name)
+	}
+	v.Add(name, errs...)
+}
+
+// DescribeContainers returns the quoted names of the containers added with AddContainer, e.g.
+// containers "a", "b", or container "a" and sidecar container "istio-proxy".
+func (v *Violations) DescribeContainers() string {
+	return describeContainers(v.data, v.sidecars)
+}
+
 func (v *Violations) Empty() bool {
 	return len(v.data) == 0
 }
@@ -65,6 +130,33 @@ func (v *Violations) Errs() *field.ErrorList {
 	return v.errs
 }
 
+// Overflow returns the number of field errors dropped after reaching the limit set with WithMaxFieldErrors.
+func (v *Violations) Overflow() int {
+	return v.overflow
+}
+
+// Entries returns the violations in the order they were added, each with its associated field errors.
+// Field errors dropped after reaching the limit set with WithMaxFieldErrors are not associated with an entry.
+// The entries are built on each call, so checks only pay for them when they are used.
+func (v *Violations) Entries() []ViolationEntry {
+	if len(v.data) == 0 {
+		return nil
+	}
+	entries := make([]ViolationEntry, len(v.data))
+	start := 0
+	for i, data := range v.data {
+		entries[i].Data = data
+		if i < len(v.errEnds) {
+			end := v.errEnds[i]
+			if end > start {
+				entries[i].Errs = append(field.ErrorList(nil), (*v.errs)[start:end]...)
+			}
+			start = end
+		}
+	}
+	return entries
+}
+
 func withBadValue(err *field.Error, badValue interface{}) *field.Error {
 	if err == nil {
 		return nil
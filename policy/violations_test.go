@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestViolationsEntries(t *testing.T) {
+	aPath := containersFldPath.Index(0).Child("securityContext", "privileged")
+	bPath := containersFldPath.Index(1).Child("securityContext", "privileged")
+
+	t.Run("with field errors", func(t *testing.T) {
+		v := NewViolations(true)
+		v.Add(`container "a"`, forbidden(aPath))
+		v.Add(`container "b"`, forbidden(bPath), nil)
+		v.Add("no errors")
+
+		assert.Equal(t, []string{`container "a"`, `container "b"`, "no errors"}, v.Data())
+		assert.Equal(t, &field.ErrorList{forbidden(aPath), forbidden(bPath)}, v.Errs())
+		assert.Equal(t, []ViolationEntry{
+			{Data: `container "a"`, Errs: field.ErrorList{forbidden(aPath)}},
+			{Data: `container "b"`, Errs: field.ErrorList{forbidden(bPath)}},
+			{Data: "no errors"},
+		}, v.Entries())
+	})
+
+	t.Run("without field errors", func(t *testing.T) {
+		v := NewViolations(false)
+		v.Add(`container "a"`, forbidden(aPath))
+
+		assert.Equal(t, []string{`container "a"`}, v.Data())
+		assert.Nil(t, v.Errs())
+		assert.Equal(t, []ViolationEntry{{Data: `container "a"`}}, v.Entries())
+	})
+}
+
+func TestViolationsMaxErrs(t *testing.T) {
+	path := func(i int) *field.Path { return containersFldPath.Index(i).Child("securityContext", "privileged") }
+
+	v := newViolations(resolveOptions([]Option{WithFieldErrors(), WithMaxFieldErrors(2)}))
+	v.Add(`container "a"`, forbidden(path(0)))
+	v.Add(`container "b"`, forbidden(path(1)))
+	v.Add(`container "c"`, forbidden(path(2)))
+	v.Add(`container "d"`, forbidden(path(3)))
+
+	assert.Equal(t, 4, v.Len())
+	assert.Equal(t, 2, v.Overflow())
+	errs := *v.Errs()
+	if assert.Len(t, errs, 3) {
+		assert.Equal(t, forbidden(path(0)), errs[0])
+		assert.Equal(t, forbidden(path(1)), errs[1])
+		assert.Equal(t, field.ErrorTypeTooMany, errs[2].Type)
+		assert.Equal(t, 4, errs[2].BadValue)
+		assert.Contains(t, errs[2].Detail, "2 field errors omitted")
+	}
+	entries := v.Entries()
+	assert.Equal(t, field.ErrorList{forbidden(path(1))}, entries[1].Errs)
+	assert.Empty(t, entries[3].Errs)
+
+	unbounded := newViolations(resolveOptions([]Option{WithFieldErrors()}))
+	for i := 0; i < 4; i++ {
+		unbounded.Add("container", forbidden(path(i)))
+	}
+	assert.Len(t, *unbounded.Errs(), 4)
+	assert.Zero(t, unbounded.Overflow())
+}
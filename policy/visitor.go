@@ -18,11 +18,25 @@ package policy
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
-// ContainerVisitor is called with each container and the field.Path to that container.
-type ContainerVisitor func(container *corev1.Container, path *field.Path)
+// ContainerKind classifies the containers of a pod.
+type ContainerKind string
+
+const (
+	// ContainerKindContainer is a regular container, or an init container running to completion.
+	ContainerKindContainer ContainerKind = "container"
+	// ContainerKindSidecar is an init container with restartPolicy=Always, running alongside the regular containers,
+	// which is often injected into pods rather than set by their authors.
+	ContainerKindSidecar ContainerKind = "sidecar"
+	// ContainerKindEphemeral is an ephemeral container.
+	ContainerKindEphemeral ContainerKind = "ephemeral"
+)
+
+// ContainerVisitor is called with each container, its kind and the field.Path to that container.
+type ContainerVisitor func(container *corev1.Container, kind ContainerKind, path *field.Path)
 
 // visitContainers invokes the visitor function with a pointer to the spec
 // of every container in the given pod spec.
@@ -32,20 +46,50 @@ func visitContainers(podSpec *corev1.PodSpec, opts options, visitor ContainerVis
 		if opts.withFieldErrors {
 			fldPath = initContainersFldPath.Index(i)
 		}
-		visitor(&podSpec.InitContainers[i], fldPath)
+		kind := ContainerKindContainer
+		if isSidecar(&podSpec.InitContainers[i]) {
+			kind = ContainerKindSidecar
+		}
+		visitor(&podSpec.InitContainers[i], kind, fldPath)
 	}
 	for i := range podSpec.Containers {
 		var fldPath *field.Path
 		if opts.withFieldErrors {
 			fldPath = containersFldPath.Index(i)
 		}
-		visitor(&podSpec.Containers[i], fldPath)
+		visitor(&podSpec.Containers[i], ContainerKindContainer, fldPath)
 	}
 	for i := range podSpec.EphemeralContainers {
 		var fldPath *field.Path
 		if opts.withFieldErrors {
 			fldPath = ephemeralContainersFldPath.Index(i)
 		}
-		visitor((*corev1.Container)(&podSpec.EphemeralContainers[i].EphemeralContainerCommon), fldPath)
+		visitor((*corev1.Container)(&podSpec.EphemeralContainers[i].EphemeralContainerCommon), ContainerKindEphemeral, fldPath)
+	}
+}
+
+// isSidecar returns true if the init container is restartable, i.e. a sidecar container.
+func isSidecar(initContainer *corev1.Container) bool {
+	return initContainer.RestartPolicy != nil && *initContainer.RestartPolicy == corev1.ContainerRestartPolicyAlways
+}
+
+// describeContainers returns the quoted names of the containers, preceded by "container" or "containers",
+// with the sidecar containers among them described separately, e.g.
+// container "a" and sidecar container "istio-proxy".
+func describeContainers(names, sidecars []string) string {
+	if len(sidecars) == 0 {
+		return pluralize("container", "containers", len(names)) + " " + joinQuote(names)
+	}
+	sidecarsDescription := pluralize("sidecar container", "sidecar containers", len(sidecars)) + " " + joinQuote(sidecars)
+	sidecarNames := sets.NewString(sidecars...)
+	others := make([]string, 0, len(names))
+	for _, name := range names {
+		if !sidecarNames.Has(name) {
+			others = append(others, name)
+		}
+	}
+	if len(others) == 0 {
+		return sidecarsDescription
 	}
+	return pluralize("container", "containers", len(others)) + " " + joinQuote(others) + " and " + sidecarsDescription
 }
@@ -0,0 +1,95 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WindowsPodMode determines how the restricted checks of Linux-only fields evaluate pods with spec.os.name=windows:
+// allowPrivilegeEscalation, capabilities_restricted, runAsNonRoot and seccompProfile_restricted,
+// and the optional readOnlyRootFilesystem check.
+type WindowsPodMode string
+
+const (
+	// WindowsPodModeDefault evaluates Windows pods as defined by each check version:
+	// allowPrivilegeEscalation, capabilities_restricted and seccompProfile_restricted allow Windows pods starting 1.25,
+	// runAsNonRoot allows Windows pods starting 1.30, and readOnlyRootFilesystem allows Windows pods at every version.
+	WindowsPodModeDefault WindowsPodMode = ""
+	// WindowsPodModeSkip allows Windows pods in the Linux-only checks at every version.
+	WindowsPodModeSkip WindowsPodMode = "Skip"
+	// WindowsPodModeWarn allows Windows pods in the Linux-only checks at every version,
+	// and sets the Warning of the check result if the check would have disallowed the pod.
+	WindowsPodModeWarn WindowsPodMode = "Warn"
+	// WindowsPodModeEnforce evaluates Windows pods like Linux pods in the Linux-only checks at every version.
+	WindowsPodModeEnforce WindowsPodMode = "Enforce"
+)
+
+// ParseWindowsPodMode returns the WindowsPodMode for the given string.
+// mode must be "", "Default", "Skip", "Warn", or "Enforce".
+func ParseWindowsPodMode(mode string) (WindowsPodMode, error) {
+	switch WindowsPodMode(mode) {
+	case WindowsPodModeDefault, "Default":
+		return WindowsPodModeDefault, nil
+	case WindowsPodModeSkip, WindowsPodModeWarn, WindowsPodModeEnforce:
+		return WindowsPodMode(mode), nil
+	default:
+		return WindowsPodModeDefault, fmt.Errorf("must be one of Default, Skip, Warn, Enforce")
+	}
+}
+
+// WithWindowsPodMode evaluates Windows pods in the restricted checks of Linux-only fields with the given mode.
+func WithWindowsPodMode(mode WindowsPodMode) Option {
+	return func(opt options) options {
+		opt.windowsPodMode = mode
+		return opt
+	}
+}
+
+// linuxOnly wraps the CheckPod function of a check of Linux-only fields, evaluating Windows pods
+// according to the WindowsPodMode of the options. skipByDefault is the behavior of WindowsPodModeDefault.
+func linuxOnly(check func(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult, skipByDefault bool) func(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
+	return func(podMetadata *metav1.ObjectMeta, podSpec *corev1.PodSpec, opts options) CheckResult {
+		if podSpec.OS == nil || podSpec.OS.Name != corev1.Windows {
+			return check(podMetadata, podSpec, opts)
+		}
+		switch opts.windowsPodMode {
+		case WindowsPodModeSkip:
+			return CheckResult{Allowed: true}
+		case WindowsPodModeWarn:
+			result := check(podMetadata, podSpec, opts)
+			if result.Allowed {
+				return result
+			}
+			violation := result.ForbiddenReason
+			if result.ForbiddenDetail != "" {
+				violation = fmt.Sprintf("%s (%s)", violation, result.ForbiddenDetail)
+			}
+			return CheckResult{Allowed: true, Warning: violation + " is not enforced for Windows pods"}
+		case WindowsPodModeEnforce:
+			return check(podMetadata, podSpec, opts)
+		default:
+			if skipByDefault {
+				return CheckResult{Allowed: true}
+			}
+			return check(podMetadata, podSpec, opts)
+		}
+	}
+}
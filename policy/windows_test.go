@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/pod-security-admission/api"
+)
+
+func TestWindowsPodMode(t *testing.T) {
+	windowsPod := &corev1.Pod{Spec: corev1.PodSpec{
+		OS:         &corev1.PodOS{Name: corev1.Windows},
+		Containers: []corev1.Container{{Name: "a"}},
+	}}
+	latest := api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()}
+	v1Dot24 := api.LevelVersion{Level: api.LevelRestricted, Version: api.MajorMinorVersion(1, 24)}
+	v1Dot29 := api.LevelVersion{Level: api.LevelRestricted, Version: api.MajorMinorVersion(1, 29)}
+	linuxOnlyChecks := []CheckID{"allowPrivilegeEscalation", "capabilities_restricted", "runAsNonRoot", "seccompProfile_restricted"}
+
+	tests := []struct {
+		name           string
+		mode           WindowsPodMode
+		lv             api.LevelVersion
+		expectViolated []CheckID
+		expectWarnings int
+	}{
+		{name: "default", lv: latest},
+		{name: "default before 1.30", lv: v1Dot29, expectViolated: []CheckID{"runAsNonRoot"}},
+		{name: "default before 1.25", lv: v1Dot24, expectViolated: linuxOnlyChecks},
+		{name: "skip", mode: WindowsPodModeSkip, lv: latest},
+		{name: "skip before 1.25", mode: WindowsPodModeSkip, lv: v1Dot24},
+		{name: "warn", mode: WindowsPodModeWarn, lv: latest, expectWarnings: 4},
+		{name: "enforce", mode: WindowsPodModeEnforce, lv: latest, expectViolated: linuxOnlyChecks},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			evaluator, err := NewEvaluator(DefaultChecks(), WithWindowsPodMode(tc.mode))
+			require.NoError(t, err)
+			results := evaluator.EvaluatePod(tc.lv, &windowsPod.ObjectMeta, &windowsPod.Spec)
+			var violated []CheckID
+			for _, result := range results {
+				if !result.Allowed {
+					violated = append(violated, result.ID)
+				}
+			}
+			assert.ElementsMatch(t, tc.expectViolated, violated)
+			assert.Len(t, AggregateCheckResults(results).Warnings, tc.expectWarnings)
+		})
+	}
+
+	t.Run("warning", func(t *testing.T) {
+		result := seccompProfileRestrictedV1Dot25(&metav1.ObjectMeta{}, &windowsPod.Spec, options{windowsPodMode: WindowsPodModeWarn})
+		assert.True(t, result.Allowed)
+		assert.Equal(t, `seccompProfile (pod or container "a" must set securityContext.seccompProfile.type to "RuntimeDefault" or "Localhost") is not enforced for Windows pods`, result.Warning)
+	})
+
+	t.Run("linux pod", func(t *testing.T) {
+		linuxPod := &corev1.PodSpec{Containers: []corev1.Container{{Name: "a"}}}
+		result := seccompProfileRestrictedV1Dot25(&metav1.ObjectMeta{}, linuxPod, options{windowsPodMode: WindowsPodModeSkip})
+		assert.False(t, result.Allowed)
+	})
+}
+
+func TestParseWindowsPodMode(t *testing.T) {
+	for _, mode := range []string{"", "Default", "Skip", "Warn", "Enforce"} {
+		_, err := ParseWindowsPodMode(mode)
+		assert.NoError(t, err, mode)
+	}
+	mode, err := ParseWindowsPodMode("Default")
+	assert.NoError(t, err)
+	assert.Equal(t, WindowsPodModeDefault, mode)
+	_, err = ParseWindowsPodMode("skip")
+	assert.Error(t, err)
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/pod-security-admission/api"
+)
+
+var (
+	podTemplatePath         = field.NewPath("template")
+	workloadTemplatePath    = specPath.Child("template")
+	cronJobTemplatePath     = specPath.Child("jobTemplate", "spec", "template")
+	podTemplateMetadataPath = field.NewPath("metadata")
+)
+
+// PodTemplate is the pod metadata and spec of a pod or workload, with the path of the pod template in the object.
+type PodTemplate struct {
+	// Path is the path of the pod template in the object, e.g. spec.template for a Deployment,
+	// or spec.jobTemplate.spec.template for a CronJob. It is nil for a Pod.
+	Path *field.Path
+	// Metadata is the metadata of the pod template. It is nil if the object has no pod template.
+	Metadata *metav1.ObjectMeta
+	// Spec is the spec of the pod template. It is nil if the object has no pod template.
+	Spec *corev1.PodSpec
+}
+
+// ExtractPodTemplate returns the pod template of a Pod, PodTemplate, ReplicationController, ReplicaSet,
+// Deployment, DaemonSet, StatefulSet, Job or CronJob.
+func ExtractPodTemplate(obj runtime.Object) (PodTemplate, error) {
+	switch o := obj.(type) {
+	case *corev1.Pod:
+		return PodTemplate{Metadata: &o.ObjectMeta, Spec: &o.Spec}, nil
+	case *corev1.PodTemplate:
+		return podTemplate(podTemplatePath, &o.Template), nil
+	case *corev1.ReplicationController:
+		return podTemplate(workloadTemplatePath, o.Spec.Template), nil
+	case *appsv1.ReplicaSet:
+		return podTemplate(workloadTemplatePath, &o.Spec.Template), nil
+	case *appsv1.Deployment:
+		return podTemplate(workloadTemplatePath, &o.Spec.Template), nil
+	case *appsv1.DaemonSet:
+		return podTemplate(workloadTemplatePath, &o.Spec.Template), nil
+	case *appsv1.StatefulSet:
+		return podTemplate(workloadTemplatePath, &o.Spec.Template), nil
+	case *batchv1.Job:
+		return podTemplate(workloadTemplatePath, &o.Spec.Template), nil
+	case *batchv1.CronJob:
+		return podTemplate(cronJobTemplatePath, &o.Spec.JobTemplate.Spec.Template), nil
+	default:
+		return PodTemplate{}, fmt.Errorf("unexpected object type: %s", obj.GetObjectKind().GroupVersionKind().String())
+	}
+}
+
+func podTemplate(path *field.Path, template *corev1.PodTemplateSpec) PodTemplate {
+	if template == nil {
+		return PodTemplate{Path: path}
+	}
+	return PodTemplate{Path: path, Metadata: &template.ObjectMeta, Spec: &template.Spec}
+}
+
+// EvaluateWorkload evaluates the pod template of a pod or workload supported by ExtractPodTemplate
+// against the level & version. The field errors of the results, set if the evaluator is created WithFieldErrors,
+// are rooted at the object, e.g. spec.template.spec.containers[0] for a Deployment.
+// Objects without a pod template return no results.
+func EvaluateWorkload(evaluator Evaluator, lv api.LevelVersion, obj runtime.Object) (PodTemplate, []CheckResult, error) {
+	template, err := ExtractPodTemplate(obj)
+	if err != nil {
+		return PodTemplate{}, nil, err
+	}
+	if template.Spec == nil {
+		return template, nil, nil
+	}
+	results := evaluator.EvaluatePod(lv, template.Metadata, template.Spec)
+	if template.Path != nil {
+		for i := range results {
+			if results[i].ErrList != nil {
+				errs := rootFieldErrors(template.Path, *results[i].ErrList)
+				results[i].ErrList = &errs
+			}
+		}
+	}
+	return template, results, nil
+}
+
+// rootFieldErrors returns copies of the field errors of a pod, with their spec and metadata paths rooted at the pod template path.
+func rootFieldErrors(templatePath *field.Path, errs field.ErrorList) field.ErrorList {
+	rooted := make(field.ErrorList, 0, len(errs))
+	for _, err := range errs {
+		if err == nil {
+			rooted = append(rooted, nil)
+			continue
+		}
+		rootedErr := *err
+		for _, path := range []*field.Path{specPath, podTemplateMetadataPath} {
+			prefix := path.String()
+			if rest, ok := strings.CutPrefix(err.Field, prefix); ok && (rest == "" || rest[0] == '.' || rest[0] == '[') {
+				rootedErr.Field = templatePath.Child(prefix).String() + rest
+				break
+			}
+		}
+		rooted = append(rooted, &rootedErr)
+	}
+	return rooted
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/utils/pointer"
+)
+
+func TestEvaluateWorkload(t *testing.T) {
+	template := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"container.apparmor.security.beta.kubernetes.io/a": "unconfined"}},
+		Spec: corev1.PodSpec{
+			HostNetwork: true,
+			Containers: []corev1.Container{{
+				Name:            "a",
+				SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(true)},
+			}},
+		},
+	}
+	evaluator, err := NewEvaluator(DefaultChecks(), WithFieldErrors())
+	require.NoError(t, err)
+	lv := api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}
+
+	testCases := []struct {
+		name             string
+		obj              runtime.Object
+		expectPath       string
+		expectErrorPaths []string
+	}{{
+		name:             "pod",
+		obj:              &corev1.Pod{ObjectMeta: template.ObjectMeta, Spec: template.Spec},
+		expectErrorPaths: []string{"spec.hostNetwork", "spec.containers[0].securityContext.privileged", "metadata.annotations[container.apparmor.security.beta.kubernetes.io/a]"},
+	}, {
+		name:             "pod template",
+		obj:              &corev1.PodTemplate{Template: template},
+		expectPath:       "template",
+		expectErrorPaths: []string{"template.spec.hostNetwork", "template.spec.containers[0].securityContext.privileged", "template.metadata.annotations[container.apparmor.security.beta.kubernetes.io/a]"},
+	}, {
+		name:             "deployment",
+		obj:              &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Template: template}},
+		expectPath:       "spec.template",
+		expectErrorPaths: []string{"spec.template.spec.hostNetwork", "spec.template.spec.containers[0].securityContext.privileged", "spec.template.metadata.annotations[container.apparmor.security.beta.kubernetes.io/a]"},
+	}, {
+		name:             "statefulset",
+		obj:              &appsv1.StatefulSet{Spec: appsv1.StatefulSetSpec{Template: template}},
+		expectPath:       "spec.template",
+		expectErrorPaths: []string{"spec.template.spec.hostNetwork", "spec.template.spec.containers[0].securityContext.privileged", "spec.template.metadata.annotations[container.apparmor.security.beta.kubernetes.io/a]"},
+	}, {
+		name:             "cronjob",
+		obj:              &batchv1.CronJob{Spec: batchv1.CronJobSpec{JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: template}}}},
+		expectPath:       "spec.jobTemplate.spec.template",
+		expectErrorPaths: []string{"spec.jobTemplate.spec.template.spec.hostNetwork", "spec.jobTemplate.spec.template.spec.containers[0].securityContext.privileged", "spec.jobTemplate.spec.template.metadata.annotations[container.apparmor.security.beta.kubernetes.io/a]"},
+	}, {
+		name:       "replication controller without template",
+		obj:        &corev1.ReplicationController{},
+		expectPath: "spec.template",
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			podTemplate, results, err := EvaluateWorkload(evaluator, lv, tc.obj)
+			require.NoError(t, err)
+			if tc.expectPath == "" {
+				assert.Nil(t, podTemplate.Path)
+			} else {
+				assert.Equal(t, tc.expectPath, podTemplate.Path.String())
+			}
+
+			var errorPaths []string
+			for _, result := range results {
+				if result.ErrList == nil {
+					continue
+				}
+				for _, err := range *result.ErrList {
+					errorPaths = append(errorPaths, err.Field)
+				}
+			}
+			assert.ElementsMatch(t, tc.expectErrorPaths, errorPaths)
+		})
+	}
+
+	// Evaluating a workload reports the same violations as its pod.
+	_, podResults, err := EvaluateWorkload(evaluator, lv, &corev1.Pod{ObjectMeta: template.ObjectMeta, Spec: template.Spec})
+	require.NoError(t, err)
+	_, deploymentResults, err := EvaluateWorkload(evaluator, lv, &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Template: template}})
+	require.NoError(t, err)
+	podResult, deploymentResult := AggregateCheckResults(podResults), AggregateCheckResults(deploymentResults)
+	assert.Equal(t, podResult.ForbiddenDetail(), deploymentResult.ForbiddenDetail())
+
+	_, _, err = EvaluateWorkload(evaluator, lv, &corev1.Service{})
+	assert.EqualError(t, err, "unexpected object type: /, Kind=")
+}
+
+func TestWithFieldPathPrefix(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"container.apparmor.security.beta.kubernetes.io/a": "unconfined"}},
+		Spec: corev1.PodSpec{
+			HostNetwork: true,
+			Containers: []corev1.Container{{
+				Name:            "a",
+				SecurityContext: &corev1.SecurityContext{Privileged: pointer.Bool(true)},
+			}},
+		},
+	}
+	evaluator, err := NewEvaluator(DefaultChecks(), WithFieldErrors(), WithFieldPathPrefix(field.NewPath("spec", "jobTemplate", "spec", "template")))
+	require.NoError(t, err)
+	lv := api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}
+
+	var errorPaths []string
+	for _, result := range evaluator.EvaluatePod(lv, &pod.ObjectMeta, &pod.Spec) {
+		if result.ErrList == nil {
+			continue
+		}
+		for _, err := range *result.ErrList {
+			errorPaths = append(errorPaths, err.Field)
+		}
+		if result.ID == "privileged" {
+			assert.Equal(t, []string{"a"}, result.Containers)
+		}
+	}
+	assert.ElementsMatch(t, []string{
+		"spec.jobTemplate.spec.template.spec.hostNetwork",
+		"spec.jobTemplate.spec.template.spec.containers[0].securityContext.privileged",
+		"spec.jobTemplate.spec.template.metadata.annotations[container.apparmor.security.beta.kubernetes.io/a]",
+	}, errorPaths)
+}
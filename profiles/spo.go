@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package profiles
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/pod-security-admission/policy"
+)
+
+var (
+	// SeccompProfilesResource is the resource of the SeccompProfile objects of security-profiles-operator.
+	SeccompProfilesResource = schema.GroupVersionResource{Group: "security-profiles-operator.x-k8s.io", Version: "v1beta1", Resource: "seccompprofiles"}
+	// AppArmorProfilesResource is the resource of the AppArmorProfile objects of security-profiles-operator.
+	AppArmorProfilesResource = schema.GroupVersionResource{Group: "security-profiles-operator.x-k8s.io", Version: "v1alpha1", Resource: "apparmorprofiles"}
+)
+
+// spoProfileInstalled is the status of profiles installed on the nodes by security-profiles-operator.
+const spoProfileInstalled = "Installed"
+
+// NewSecurityProfilesOperatorCatalog returns a policy.LocalhostProfileCatalog of the profiles reconciled by
+// security-profiles-operator, listed from the SeccompProfilesResource and AppArmorProfilesResource objects
+// of the given listers, e.g. from a dynamic shared informer factory. Either lister may be nil,
+// in which case every profile of that type is reported as not found.
+//
+// Profiles are matched by the status.localhostProfile field, or by name for AppArmor profiles without one,
+// and are ready once their status.status is Installed.
+// Profiles are looked up across namespaces, since Localhost profiles are installed on the nodes for all pods.
+func NewSecurityProfilesOperatorCatalog(seccompProfiles, appArmorProfiles cache.GenericLister) policy.LocalhostProfileCatalog {
+	return &spoCatalog{seccompProfiles: seccompProfiles, appArmorProfiles: appArmorProfiles}
+}
+
+type spoCatalog struct {
+	seccompProfiles  cache.GenericLister
+	appArmorProfiles cache.GenericLister
+}
+
+func (c *spoCatalog) LocalhostProfileStatus(namespace string, profileType policy.LocalhostProfileType, localhostProfile string) policy.LocalhostProfileStatus {
+	lister := c.seccompProfiles
+	if profileType == policy.LocalhostProfileTypeAppArmor {
+		lister = c.appArmorProfiles
+	}
+	if lister == nil {
+		return policy.LocalhostProfileNotFound
+	}
+	objs, err := lister.List(labels.Everything())
+	if err != nil {
+		return policy.LocalhostProfileNotFound
+	}
+	status := policy.LocalhostProfileNotFound
+	for _, obj := range objs {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(u.Object, "status", "localhostProfile")
+		if name == "" && profileType == policy.LocalhostProfileTypeAppArmor {
+			name = u.GetName()
+		}
+		if name != localhostProfile {
+			continue
+		}
+		if installed, _, _ := unstructured.NestedString(u.Object, "status", "status"); installed == spoProfileInstalled {
+			return policy.LocalhostProfileReady
+		}
+		status = policy.LocalhostProfileNotReady
+	}
+	return status
+}
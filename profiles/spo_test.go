@@ -0,0 +1,83 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package profiles
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/pod-security-admission/policy"
+)
+
+func newLister(t *testing.T, resource schema.GroupVersionResource, objs ...map[string]interface{}) cache.GenericLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, obj := range objs {
+		require.NoError(t, indexer.Add(&unstructured.Unstructured{Object: obj}))
+	}
+	return cache.NewGenericLister(indexer, resource.GroupResource())
+}
+
+func profile(namespace, name, localhostProfile, status string) map[string]interface{} {
+	obj := map[string]interface{}{
+		"metadata": map[string]interface{}{"namespace": namespace, "name": name},
+		"status":   map[string]interface{}{"status": status},
+	}
+	if localhostProfile != "" {
+		obj["status"].(map[string]interface{})["localhostProfile"] = localhostProfile
+	}
+	return obj
+}
+
+func TestSecurityProfilesOperatorCatalog(t *testing.T) {
+	seccompProfiles := newLister(t, SeccompProfilesResource,
+		profile("ns1", "ready", "operator/ns1/ready.json", "Installed"),
+		profile("ns1", "pending", "operator/ns1/pending.json", "Pending"),
+		profile("ns2", "partial", "operator/ns2/partial.json", "Partial"),
+		profile("ns3", "partial", "operator/ns2/partial.json", "Installed"),
+	)
+	appArmorProfiles := newLister(t, AppArmorProfilesResource,
+		profile("", "ready", "", "Installed"),
+		profile("", "named", "custom-name", "Installed"),
+	)
+	catalog := NewSecurityProfilesOperatorCatalog(seccompProfiles, appArmorProfiles)
+
+	testCases := []struct {
+		profileType      policy.LocalhostProfileType
+		localhostProfile string
+		expect           policy.LocalhostProfileStatus
+	}{
+		{policy.LocalhostProfileTypeSeccomp, "operator/ns1/ready.json", policy.LocalhostProfileReady},
+		{policy.LocalhostProfileTypeSeccomp, "operator/ns1/pending.json", policy.LocalhostProfileNotReady},
+		{policy.LocalhostProfileTypeSeccomp, "operator/ns2/partial.json", policy.LocalhostProfileReady},
+		{policy.LocalhostProfileTypeSeccomp, "operator/ns1/missing.json", policy.LocalhostProfileNotFound},
+		{policy.LocalhostProfileTypeAppArmor, "ready", policy.LocalhostProfileReady},
+		{policy.LocalhostProfileTypeAppArmor, "custom-name", policy.LocalhostProfileReady},
+		{policy.LocalhostProfileTypeAppArmor, "named", policy.LocalhostProfileNotFound},
+		{policy.LocalhostProfileTypeAppArmor, "operator/ns1/ready.json", policy.LocalhostProfileNotFound},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expect, catalog.LocalhostProfileStatus("ns1", tc.profileType, tc.localhostProfile), "%s profile %s", tc.profileType, tc.localhostProfile)
+	}
+
+	catalog = NewSecurityProfilesOperatorCatalog(seccompProfiles, nil)
+	assert.Equal(t, policy.LocalhostProfileNotFound, catalog.LocalhostProfileStatus("ns1", policy.LocalhostProfileTypeAppArmor, "ready"))
+}
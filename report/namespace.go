@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// NamespaceReport summarizes the policy violations of the pods in a single namespace.
+type NamespaceReport struct {
+	// Namespace is the name of the namespace.
+	Namespace string
+	// LevelVersion is the policy the pods were evaluated against.
+	LevelVersion api.LevelVersion
+	// Pods is the number of pods evaluated.
+	Pods int
+	// ViolatingPods is the number of pods disallowed by at least one check.
+	ViolatingPods int
+	// Violations is the number of pods disallowed by each check.
+	// Checks that allowed all pods are omitted.
+	Violations map[policy.CheckID]int
+	// Passes is the number of pods allowed by each check, showing the checks that were evaluated
+	// and passed for compliance reports. Checks that disallowed all pods are omitted.
+	Passes map[policy.CheckID]int
+}
+
+// NewNamespaceReport returns an empty report for the given namespace and policy.
+func NewNamespaceReport(namespace string, lv api.LevelVersion) *NamespaceReport {
+	return &NamespaceReport{
+		Namespace:    namespace,
+		LevelVersion: lv,
+		Violations:   map[policy.CheckID]int{},
+		Passes:       map[policy.CheckID]int{},
+	}
+}
+
+// AddPod records the results of evaluating a single pod.
+// Results are expected to be returned by an Evaluator constructed by policy.NewEvaluator,
+// so that they are identified by check ID.
+func (r *NamespaceReport) AddPod(results []policy.CheckResult) {
+	r.Pods++
+	if countViolations(results, r.Violations) {
+		r.ViolatingPods++
+	}
+	for _, result := range results {
+		if result.Allowed {
+			r.Passes[result.ID]++
+		}
+	}
+}
+
+// countViolations increments the violations of the checks that disallowed the pod,
+// and returns true if any check disallowed the pod.
+func countViolations(results []policy.CheckResult, violations map[policy.CheckID]int) bool {
+	violating := false
+	for _, result := range results {
+		if result.Allowed {
+			continue
+		}
+		violating = true
+		violations[result.ID]++
+	}
+	return violating
+}
+
+// EvaluateNamespace evaluates the pods against the given policy and returns a report of the violations.
+func EvaluateNamespace(evaluator policy.Evaluator, namespace string, lv api.LevelVersion, pods []*corev1.Pod) *NamespaceReport {
+	r := NewNamespaceReport(namespace, lv)
+	for _, pod := range pods {
+		r.AddPod(evaluator.EvaluatePod(lv, &pod.ObjectMeta, &pod.Spec))
+	}
+	return r
+}
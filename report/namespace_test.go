@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateNamespace(t *testing.T) {
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+	require.NoError(t, err)
+
+	lv := api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}
+	pods := []*corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "allowed"},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "a"}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "host-network"},
+			Spec:       corev1.PodSpec{HostNetwork: true, Containers: []corev1.Container{{Name: "a"}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "host-network-and-ports"},
+			Spec: corev1.PodSpec{HostNetwork: true, Containers: []corev1.Container{{
+				Name:  "a",
+				Ports: []corev1.ContainerPort{{HostPort: 8080}},
+			}}},
+		},
+	}
+
+	r := EvaluateNamespace(evaluator, "ns", lv, pods)
+	assert.Equal(t, &NamespaceReport{
+		Namespace:     "ns",
+		LevelVersion:  lv,
+		Pods:          3,
+		ViolatingPods: 2,
+		Violations: map[policy.CheckID]int{
+			"hostNamespaces": 2,
+			"hostPorts":      1,
+		},
+		Passes: map[policy.CheckID]int{
+			"appArmorProfile":         3,
+			"capabilities_baseline":   3,
+			"hostNamespaces":          1,
+			"hostPathVolumes":         3,
+			"hostPorts":               2,
+			"privileged":              3,
+			"procMount":               3,
+			"seLinuxOptions":          3,
+			"seccompProfile_baseline": 3,
+			"sysctls":                 3,
+			"windowsHostProcess":      3,
+		},
+	}, r)
+}
@@ -0,0 +1,356 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/pod-security-admission/policy"
+)
+
+// OSCALVersion is the version of the OSCAL assessment results model produced by NewAssessmentResults.
+const OSCALVersion = "1.1.2"
+
+// oscalPropNamespace is the namespace of the properties of the assessment results, which are not defined by OSCAL.
+const oscalPropNamespace = "https://pod-security.kubernetes.io/ns/oscal"
+
+// podSecurityStandardsHref is the href of the Pod Security Standards, imported as the default assessment plan.
+const podSecurityStandardsHref = "https://kubernetes.io/docs/concepts/security/pod-security-standards/"
+
+// Control is a control of a compliance framework, evaluated by one or more checks.
+type Control struct {
+	// ID is the OSCAL control-id of the control, like "pss-baseline-host-namespaces".
+	ID string
+	// Title is the name of the control, like "Host Namespaces".
+	Title string
+}
+
+// PodSecurityStandardsControls returns the controls of the Pod Security Standards evaluated by the checks
+// of policy.DefaultChecks, by check ID.
+func PodSecurityStandardsControls() map[policy.CheckID]Control {
+	return map[policy.CheckID]Control{
+		"windowsHostProcess":      {ID: "pss-baseline-hostprocess", Title: "HostProcess"},
+		"hostNamespaces":          {ID: "pss-baseline-host-namespaces", Title: "Host Namespaces"},
+		"privileged":              {ID: "pss-baseline-privileged-containers", Title: "Privileged Containers"},
+		"capabilities_baseline":   {ID: "pss-baseline-capabilities", Title: "Capabilities"},
+		"hostPathVolumes":         {ID: "pss-baseline-hostpath-volumes", Title: "HostPath Volumes"},
+		"hostPorts":               {ID: "pss-baseline-host-ports", Title: "Host Ports"},
+		"appArmorProfile":         {ID: "pss-baseline-apparmor", Title: "AppArmor"},
+		"seLinuxOptions":          {ID: "pss-baseline-selinux", Title: "SELinux"},
+		"procMount":               {ID: "pss-baseline-proc-mount-type", Title: "/proc Mount Type"},
+		"seccompProfile_baseline": {ID: "pss-baseline-seccomp", Title: "Seccomp"},
+		"sysctls":                 {ID: "pss-baseline-sysctls", Title: "Sysctls"},
+
+		"restrictedVolumes":         {ID: "pss-restricted-volume-types", Title: "Volume Types"},
+		"allowPrivilegeEscalation":  {ID: "pss-restricted-privilege-escalation", Title: "Privilege Escalation"},
+		"runAsNonRoot":              {ID: "pss-restricted-running-as-non-root", Title: "Running as Non-root"},
+		"runAsUser":                 {ID: "pss-restricted-running-as-non-root-user", Title: "Running as Non-root user"},
+		"seccompProfile_restricted": {ID: "pss-restricted-seccomp", Title: "Seccomp"},
+		"capabilities_restricted":   {ID: "pss-restricted-capabilities", Title: "Capabilities"},
+	}
+}
+
+// AssessmentResultsOptions configures the assessment results returned by NewAssessmentResults.
+type AssessmentResultsOptions struct {
+	// Title is the title of the assessment results. Defaults to "PodSecurity assessment results".
+	Title string
+	// Version is the version of the assessment results document. Defaults to "1".
+	Version string
+	// AssessmentPlanHref is the href of the imported assessment plan. Defaults to the Pod Security Standards.
+	AssessmentPlanHref string
+	// Controls maps the evaluated checks to controls. Defaults to PodSecurityStandardsControls.
+	// Checks without a control, like custom checks, are mapped to a control identified by the check ID.
+	Controls map[policy.CheckID]Control
+	// Time is the time the namespaces were evaluated, which also seeds the UUIDs of the document.
+	// Defaults to the current time.
+	Time time.Time
+}
+
+// AssessmentResults is an OSCAL assessment results document, as defined by
+// https://pages.nist.gov/OSCAL/reference/1.1.2/assessment-results/json-outline/.
+// Only the fields needed to report PodSecurity evaluations are included.
+type AssessmentResults struct {
+	AssessmentResults AssessmentResultsBody `json:"assessment-results"`
+}
+
+// AssessmentResultsBody is the body of an OSCAL assessment results document.
+type AssessmentResultsBody struct {
+	UUID     string        `json:"uuid"`
+	Metadata OSCALMetadata `json:"metadata"`
+	ImportAP OSCALImport   `json:"import-ap"`
+	Results  []OSCALResult `json:"results"`
+}
+
+// OSCALMetadata is the metadata of an OSCAL document.
+type OSCALMetadata struct {
+	Title        string    `json:"title"`
+	LastModified time.Time `json:"last-modified"`
+	Version      string    `json:"version"`
+	OSCALVersion string    `json:"oscal-version"`
+}
+
+// OSCALImport references the assessment plan of the assessment results.
+type OSCALImport struct {
+	Href string `json:"href"`
+}
+
+// OSCALResult is the result of a single assessment.
+type OSCALResult struct {
+	UUID             string                `json:"uuid"`
+	Title            string                `json:"title"`
+	Description      string                `json:"description"`
+	Start            time.Time             `json:"start"`
+	ReviewedControls OSCALReviewedControls `json:"reviewed-controls"`
+	Observations     []OSCALObservation    `json:"observations,omitempty"`
+	Findings         []OSCALFinding        `json:"findings,omitempty"`
+}
+
+// OSCALReviewedControls lists the controls reviewed by an assessment.
+type OSCALReviewedControls struct {
+	ControlSelections []OSCALControlSelection `json:"control-selections"`
+}
+
+// OSCALControlSelection selects the controls reviewed by an assessment.
+type OSCALControlSelection struct {
+	IncludeControls []OSCALControlRef `json:"include-controls,omitempty"`
+}
+
+// OSCALControlRef references a control by ID.
+type OSCALControlRef struct {
+	ControlID string `json:"control-id"`
+}
+
+// OSCALObservation is the evaluation of a check against the pods of a namespace.
+type OSCALObservation struct {
+	UUID        string         `json:"uuid"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Props       []OSCALProp    `json:"props,omitempty"`
+	Methods     []string       `json:"methods"`
+	Subjects    []OSCALSubject `json:"subjects,omitempty"`
+	Collected   time.Time      `json:"collected"`
+}
+
+// OSCALSubject identifies the subject of an observation, like a namespace.
+type OSCALSubject struct {
+	SubjectUUID string      `json:"subject-uuid"`
+	Type        string      `json:"type"`
+	Title       string      `json:"title,omitempty"`
+	Props       []OSCALProp `json:"props,omitempty"`
+}
+
+// OSCALFinding is the status of a control for the pods of a namespace.
+type OSCALFinding struct {
+	UUID                string                    `json:"uuid"`
+	Title               string                    `json:"title"`
+	Description         string                    `json:"description"`
+	Target              OSCALFindingTarget        `json:"target"`
+	RelatedObservations []OSCALRelatedObservation `json:"related-observations,omitempty"`
+}
+
+// OSCALFindingTarget is the control of a finding, and its status.
+type OSCALFindingTarget struct {
+	Type     string            `json:"type"`
+	TargetID string            `json:"target-id"`
+	Status   OSCALTargetStatus `json:"status"`
+}
+
+// OSCALTargetStatus is the status of the target of a finding, "satisfied" or "not-satisfied".
+type OSCALTargetStatus struct {
+	State string `json:"state"`
+}
+
+// OSCALRelatedObservation references an observation supporting a finding.
+type OSCALRelatedObservation struct {
+	ObservationUUID string `json:"observation-uuid"`
+}
+
+// OSCALProp is a name & value property.
+type OSCALProp struct {
+	Name  string `json:"name"`
+	NS    string `json:"ns,omitempty"`
+	Value string `json:"value"`
+}
+
+// NewAssessmentResults maps the namespace reports to OSCAL assessment results, so the policy posture of a cluster
+// can be imported by governance, risk and compliance tools. Reports for the same namespace are merged together.
+//
+// Each evaluated check of a namespace is recorded as an observation, and each control of the checks as a finding,
+// not satisfied if any check of the control disallowed a pod of the namespace. Namespaces are only assessed against
+// the checks of their evaluated level, so privileged namespaces have no findings. The reports must include the checks
+// that allowed all pods (see NamespaceReport.Passes) for their controls to be reported as satisfied.
+func NewAssessmentResults(reports []*NamespaceReport, opts AssessmentResultsOptions) *AssessmentResults {
+	if opts.Title == "" {
+		opts.Title = "PodSecurity assessment results"
+	}
+	if opts.Version == "" {
+		opts.Version = "1"
+	}
+	if opts.AssessmentPlanHref == "" {
+		opts.AssessmentPlanHref = podSecurityStandardsHref
+	}
+	if opts.Controls == nil {
+		opts.Controls = PodSecurityStandardsControls()
+	}
+	if opts.Time.IsZero() {
+		opts.Time = time.Now()
+	}
+	now := opts.Time.UTC().Truncate(time.Second)
+	seed := now.Format(time.RFC3339)
+
+	result := OSCALResult{
+		UUID:        nameUUID(seed, "result"),
+		Title:       "PodSecurity evaluation",
+		Description: "Evaluation of the pods of each namespace against the PodSecurity policy of the namespace.",
+		Start:       now,
+	}
+	reviewed := map[string]bool{}
+	for _, r := range Merge(reports) {
+		namespaceSubject := OSCALSubject{
+			SubjectUUID: nameUUID(seed, "namespace", r.Namespace),
+			Type:        "component",
+			Title:       "Namespace " + r.Namespace,
+			Props: []OSCALProp{
+				{Name: "namespace", NS: oscalPropNamespace, Value: r.Namespace},
+				{Name: "policy", NS: oscalPropNamespace, Value: r.LevelVersion.String()},
+			},
+		}
+
+		// group the evaluated checks of the namespace by control
+		checksByControl := map[string][]policy.CheckID{}
+		controls := map[string]Control{}
+		for _, id := range evaluatedChecks(r) {
+			control := checkControl(opts.Controls, id)
+			checksByControl[control.ID] = append(checksByControl[control.ID], id)
+			controls[control.ID] = control
+		}
+		controlIDs := make([]string, 0, len(controls))
+		for id := range controls {
+			controlIDs = append(controlIDs, id)
+			reviewed[id] = true
+		}
+		sort.Strings(controlIDs)
+
+		for _, controlID := range controlIDs {
+			control := controls[controlID]
+			finding := OSCALFinding{
+				UUID:  nameUUID(seed, "finding", r.Namespace, controlID),
+				Title: fmt.Sprintf("%s in namespace %s", control.Title, r.Namespace),
+				Target: OSCALFindingTarget{
+					Type:     "objective-id",
+					TargetID: controlID,
+					Status:   OSCALTargetStatus{State: "satisfied"},
+				},
+			}
+			violatingPods := 0
+			for _, id := range checksByControl[controlID] {
+				violations := r.Violations[id]
+				if violations > violatingPods {
+					violatingPods = violations
+				}
+				observation := OSCALObservation{
+					UUID:  nameUUID(seed, "observation", r.Namespace, string(id)),
+					Title: fmt.Sprintf("Check %s in namespace %s", id, r.Namespace),
+					Description: fmt.Sprintf("%d of %d pods in namespace %s violate check %s of PodSecurity %q.",
+						violations, r.Pods, r.Namespace, id, r.LevelVersion.String()),
+					Props: []OSCALProp{
+						{Name: "check", NS: oscalPropNamespace, Value: string(id)},
+						{Name: "pods", NS: oscalPropNamespace, Value: fmt.Sprint(r.Pods)},
+						{Name: "violating-pods", NS: oscalPropNamespace, Value: fmt.Sprint(violations)},
+					},
+					Methods:   []string{"TEST"},
+					Subjects:  []OSCALSubject{namespaceSubject},
+					Collected: now,
+				}
+				result.Observations = append(result.Observations, observation)
+				finding.RelatedObservations = append(finding.RelatedObservations, OSCALRelatedObservation{ObservationUUID: observation.UUID})
+			}
+			if violatingPods > 0 {
+				finding.Target.Status.State = "not-satisfied"
+				finding.Description = fmt.Sprintf("At least %d of %d pods in namespace %s do not satisfy control %s.",
+					violatingPods, r.Pods, r.Namespace, controlID)
+			} else {
+				finding.Description = fmt.Sprintf("All %d pods in namespace %s satisfy control %s.", r.Pods, r.Namespace, controlID)
+			}
+			result.Findings = append(result.Findings, finding)
+		}
+	}
+
+	selection := OSCALControlSelection{}
+	for id := range reviewed {
+		selection.IncludeControls = append(selection.IncludeControls, OSCALControlRef{ControlID: id})
+	}
+	sort.Slice(selection.IncludeControls, func(i, j int) bool {
+		return selection.IncludeControls[i].ControlID < selection.IncludeControls[j].ControlID
+	})
+	result.ReviewedControls.ControlSelections = []OSCALControlSelection{selection}
+
+	return &AssessmentResults{AssessmentResults: AssessmentResultsBody{
+		UUID: nameUUID(seed, "assessment-results"),
+		Metadata: OSCALMetadata{
+			Title:        opts.Title,
+			LastModified: now,
+			Version:      opts.Version,
+			OSCALVersion: OSCALVersion,
+		},
+		ImportAP: OSCALImport{Href: opts.AssessmentPlanHref},
+		Results:  []OSCALResult{result},
+	}}
+}
+
+// evaluatedChecks returns the sorted IDs of the checks evaluated in the namespace report.
+func evaluatedChecks(r *NamespaceReport) []policy.CheckID {
+	ids := make([]policy.CheckID, 0, len(r.Violations)+len(r.Passes))
+	for id := range r.Violations {
+		ids = append(ids, id)
+	}
+	for id := range r.Passes {
+		if _, ok := r.Violations[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// checkControl returns the control of the check, or a control identified by the check ID if the check is not mapped.
+func checkControl(controls map[policy.CheckID]Control, id policy.CheckID) Control {
+	if control, ok := controls[id]; ok {
+		return control
+	}
+	// OSCAL tokens start with a letter or underscore, followed by letters, digits, '.', '-' or '_'
+	controlID := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, "check-"+string(id))
+	return Control{ID: controlID, Title: string(id)}
+}
+
+// nameUUID returns a UUID derived from the SHA-1 hash of the names, formatted as a name-based (version 5) UUID,
+// so the same evaluation is exported with the same UUIDs.
+func nameUUID(names ...string) string {
+	sum := sha1.Sum([]byte(oscalPropNamespace + "\x00" + strings.Join(names, "\x00")))
+	sum[6] = (sum[6] & 0x0f) | 0x50 // version 5
+	sum[8] = (sum[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
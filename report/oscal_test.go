@@ -0,0 +1,110 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+)
+
+func TestNewAssessmentResults(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	reports := []*NamespaceReport{{
+		Namespace:     "baseline",
+		LevelVersion:  api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()},
+		Pods:          3,
+		ViolatingPods: 2,
+		Violations:    map[policy.CheckID]int{"hostNamespaces": 2},
+		Passes:        map[policy.CheckID]int{"hostNamespaces": 1, "hostPorts": 3},
+	}, {
+		Namespace:    "custom",
+		LevelVersion: api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()},
+		Pods:         1,
+		Violations:   map[policy.CheckID]int{},
+		Passes:       map[policy.CheckID]int{"example.com/no-root-fs": 1},
+	}, {
+		Namespace:    "privileged",
+		LevelVersion: api.LevelVersion{Level: api.LevelPrivileged, Version: api.LatestVersion()},
+		Pods:         2,
+		Violations:   map[policy.CheckID]int{},
+		Passes:       map[policy.CheckID]int{},
+	}}
+
+	ar := NewAssessmentResults(reports, AssessmentResultsOptions{Time: now})
+	body := ar.AssessmentResults
+	assert.Equal(t, OSCALMetadata{
+		Title:        "PodSecurity assessment results",
+		LastModified: now,
+		Version:      "1",
+		OSCALVersion: OSCALVersion,
+	}, body.Metadata)
+	assert.Equal(t, podSecurityStandardsHref, body.ImportAP.Href)
+	require.Len(t, body.Results, 1)
+	result := body.Results[0]
+
+	assert.Equal(t, []OSCALControlRef{
+		{ControlID: "check-example.com-no-root-fs"},
+		{ControlID: "pss-baseline-host-namespaces"},
+		{ControlID: "pss-baseline-host-ports"},
+	}, result.ReviewedControls.ControlSelections[0].IncludeControls)
+
+	type finding struct{ target, state, description string }
+	var findings []finding
+	for _, f := range result.Findings {
+		findings = append(findings, finding{f.Target.TargetID, f.Target.Status.State, f.Description})
+		assert.Len(t, f.RelatedObservations, 1)
+	}
+	assert.Equal(t, []finding{
+		{"pss-baseline-host-namespaces", "not-satisfied", "At least 2 of 3 pods in namespace baseline do not satisfy control pss-baseline-host-namespaces."},
+		{"pss-baseline-host-ports", "satisfied", "All 3 pods in namespace baseline satisfy control pss-baseline-host-ports."},
+		{"check-example.com-no-root-fs", "satisfied", "All 1 pods in namespace custom satisfy control check-example.com-no-root-fs."},
+	}, findings)
+
+	require.Len(t, result.Observations, 3)
+	assert.Equal(t, `2 of 3 pods in namespace baseline violate check hostNamespaces of PodSecurity "baseline:latest".`, result.Observations[0].Description)
+	assert.Equal(t, result.Observations[0].UUID, result.Findings[0].RelatedObservations[0].ObservationUUID)
+
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	assert.Regexp(t, uuidPattern, body.UUID)
+	assert.Regexp(t, uuidPattern, result.Findings[0].UUID)
+	assert.NotEqual(t, result.Findings[0].UUID, result.Findings[1].UUID)
+
+	// the same evaluation is exported identically
+	assert.Equal(t, ar, NewAssessmentResults(reports, AssessmentResultsOptions{Time: now}))
+	assert.NotEqual(t, body.UUID, NewAssessmentResults(reports, AssessmentResultsOptions{Time: now.Add(time.Hour)}).AssessmentResults.UUID)
+
+	data, err := json.Marshal(ar)
+	require.NoError(t, err)
+	var doc map[string]map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Contains(t, doc["assessment-results"], "import-ap")
+	assert.Contains(t, doc["assessment-results"], "results")
+}
+
+func TestPodSecurityStandardsControls(t *testing.T) {
+	controls := PodSecurityStandardsControls()
+	for _, check := range policy.DefaultChecks() {
+		assert.Contains(t, controls, check.ID, "check %s has no control", check.ID)
+	}
+}
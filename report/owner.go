@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// Ownership identifies the team owning a pod from its labels, so violations can be routed for remediation.
+type Ownership struct {
+	// Labels are the keys of the ownership labels in order of precedence,
+	// e.g. "team" or "app.kubernetes.io/part-of".
+	// Pod labels take precedence over namespace labels.
+	Labels []string
+}
+
+// Owner returns the owner of the pod, or an empty string if neither the pod nor the namespace
+// have an ownership label. The namespace may be nil.
+func (o Ownership) Owner(pod *corev1.Pod, namespace *corev1.Namespace) string {
+	for _, key := range o.Labels {
+		if owner := pod.Labels[key]; owner != "" {
+			return owner
+		}
+	}
+	if namespace != nil {
+		for _, key := range o.Labels {
+			if owner := namespace.Labels[key]; owner != "" {
+				return owner
+			}
+		}
+	}
+	return ""
+}
+
+// OwnerReport summarizes the policy violations of the pods owned by a single team.
+type OwnerReport struct {
+	// Owner is the value of the ownership label, empty for pods without owner.
+	Owner string
+	// Pods is the number of pods evaluated.
+	Pods int
+	// ViolatingPods is the number of pods disallowed by at least one check.
+	ViolatingPods int
+	// Violations is the number of pods disallowed by each check.
+	// Checks that allowed all pods are omitted.
+	Violations map[policy.CheckID]int
+	// Namespaces is the number of violating pods in each namespace.
+	// Namespaces without violating pods are omitted.
+	Namespaces map[string]int
+}
+
+// OwnerReports groups the policy violations of pods by owner.
+type OwnerReports struct {
+	ownership Ownership
+	reports   map[string]*OwnerReport
+}
+
+// NewOwnerReports returns empty reports grouping pods with the given ownership.
+func NewOwnerReports(ownership Ownership) *OwnerReports {
+	return &OwnerReports{ownership: ownership, reports: map[string]*OwnerReport{}}
+}
+
+// AddPod records the results of evaluating a single pod in the given namespace.
+func (o *OwnerReports) AddPod(namespace *corev1.Namespace, pod *corev1.Pod, results []policy.CheckResult) {
+	owner := o.ownership.Owner(pod, namespace)
+	r, ok := o.reports[owner]
+	if !ok {
+		r = &OwnerReport{
+			Owner:      owner,
+			Violations: map[policy.CheckID]int{},
+			Namespaces: map[string]int{},
+		}
+		o.reports[owner] = r
+	}
+	r.Pods++
+	if countViolations(results, r.Violations) {
+		r.ViolatingPods++
+		ns := pod.Namespace
+		if ns == "" && namespace != nil {
+			ns = namespace.Name
+		}
+		r.Namespaces[ns]++
+	}
+}
+
+// EvaluateNamespace evaluates the pods of the namespace against the given policy and records the results.
+func (o *OwnerReports) EvaluateNamespace(evaluator policy.Evaluator, namespace *corev1.Namespace, lv api.LevelVersion, pods []*corev1.Pod) {
+	for _, pod := range pods {
+		o.AddPod(namespace, pod, evaluator.EvaluatePod(lv, &pod.ObjectMeta, &pod.Spec))
+	}
+}
+
+// Reports returns the report of each owner, sorted by descending number of violating pods.
+func (o *OwnerReports) Reports() []*OwnerReport {
+	reports := make([]*OwnerReport, 0, len(o.reports))
+	for _, r := range o.reports {
+		reports = append(reports, r)
+	}
+	// Sort by descending count, breaking ties by name to keep the output stable.
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].ViolatingPods != reports[j].ViolatingPods {
+			return reports[i].ViolatingPods > reports[j].ViolatingPods
+		}
+		return reports[i].Owner < reports[j].Owner
+	})
+	return reports
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwnerReports(t *testing.T) {
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+	require.NoError(t, err)
+	lv := api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}
+
+	pod := func(name string, labels map[string]string, hostNetwork bool) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			Spec:       corev1.PodSpec{HostNetwork: hostNetwork, Containers: []corev1.Container{{Name: "a"}}},
+		}
+	}
+	ownership := Ownership{Labels: []string{"team", "app.kubernetes.io/part-of"}}
+	reports := NewOwnerReports(ownership)
+
+	ns1 := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns1", Labels: map[string]string{"team": "platform"}}}
+	reports.EvaluateNamespace(evaluator, ns1, lv, []*corev1.Pod{
+		pod("inherits-namespace-owner", nil, true),
+		pod("team", map[string]string{"team": "web"}, true),
+		pod("part-of", map[string]string{"app.kubernetes.io/part-of": "web"}, false),
+	})
+	ns2 := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns2"}}
+	reports.EvaluateNamespace(evaluator, ns2, lv, []*corev1.Pod{
+		pod("unowned", nil, true),
+		pod("team", map[string]string{"team": "web"}, true),
+	})
+
+	assert.Equal(t, []*OwnerReport{
+		{
+			Owner:         "web",
+			Pods:          3,
+			ViolatingPods: 2,
+			Violations:    map[policy.CheckID]int{"hostNamespaces": 2},
+			Namespaces:    map[string]int{"ns1": 1, "ns2": 1},
+		},
+		{
+			Owner:         "",
+			Pods:          1,
+			ViolatingPods: 1,
+			Violations:    map[policy.CheckID]int{"hostNamespaces": 1},
+			Namespaces:    map[string]int{"ns2": 1},
+		},
+		{
+			Owner:         "platform",
+			Pods:          1,
+			ViolatingPods: 1,
+			Violations:    map[policy.CheckID]int{"hostNamespaces": 1},
+			Namespaces:    map[string]int{"ns1": 1},
+		},
+	}, reports.Reports())
+}
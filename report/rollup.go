@@ -0,0 +1,148 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"sort"
+
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// ClusterReport rolls up the policy violations of multiple namespaces.
+type ClusterReport struct {
+	// Namespaces is the number of namespaces included in the report.
+	Namespaces int
+	// Pods is the total number of pods evaluated.
+	Pods int
+	// ViolatingPods is the total number of pods disallowed by at least one check.
+	ViolatingPods int
+	// TopChecks lists the checks that disallowed the most pods, in descending order.
+	TopChecks []CheckCount
+	// TopNamespaces lists the namespaces with the most violating pods, in descending order.
+	// Namespaces without violations are omitted.
+	TopNamespaces []NamespaceCount
+	// Levels holds the totals of the namespaces evaluated at each policy level.
+	Levels map[api.Level]LevelTotals
+}
+
+// CheckCount is the number of pods disallowed by a check.
+type CheckCount struct {
+	ID   policy.CheckID
+	Pods int
+}
+
+// NamespaceCount is the number of violating pods in a namespace.
+type NamespaceCount struct {
+	Namespace     string
+	ViolatingPods int
+}
+
+// LevelTotals holds the totals of the namespaces evaluated at a single policy level.
+type LevelTotals struct {
+	Namespaces    int
+	Pods          int
+	ViolatingPods int
+}
+
+// Rollup merges the namespace reports into a cluster-level report.
+// Reports for the same namespace are merged together.
+// If limit is positive, TopChecks and TopNamespaces contain at most limit entries.
+func Rollup(reports []*NamespaceReport, limit int) *ClusterReport {
+	merged := Merge(reports)
+
+	cr := &ClusterReport{
+		Namespaces: len(merged),
+		Levels:     map[api.Level]LevelTotals{},
+	}
+	checks := map[policy.CheckID]int{}
+	for _, r := range merged {
+		cr.Pods += r.Pods
+		cr.ViolatingPods += r.ViolatingPods
+
+		totals := cr.Levels[r.LevelVersion.Level]
+		totals.Namespaces++
+		totals.Pods += r.Pods
+		totals.ViolatingPods += r.ViolatingPods
+		cr.Levels[r.LevelVersion.Level] = totals
+
+		for id, pods := range r.Violations {
+			checks[id] += pods
+		}
+		if r.ViolatingPods > 0 {
+			cr.TopNamespaces = append(cr.TopNamespaces, NamespaceCount{Namespace: r.Namespace, ViolatingPods: r.ViolatingPods})
+		}
+	}
+	for id, pods := range checks {
+		cr.TopChecks = append(cr.TopChecks, CheckCount{ID: id, Pods: pods})
+	}
+
+	// Sort by descending count, breaking ties by name to keep the output stable.
+	sort.Slice(cr.TopChecks, func(i, j int) bool {
+		if cr.TopChecks[i].Pods != cr.TopChecks[j].Pods {
+			return cr.TopChecks[i].Pods > cr.TopChecks[j].Pods
+		}
+		return cr.TopChecks[i].ID < cr.TopChecks[j].ID
+	})
+	sort.Slice(cr.TopNamespaces, func(i, j int) bool {
+		if cr.TopNamespaces[i].ViolatingPods != cr.TopNamespaces[j].ViolatingPods {
+			return cr.TopNamespaces[i].ViolatingPods > cr.TopNamespaces[j].ViolatingPods
+		}
+		return cr.TopNamespaces[i].Namespace < cr.TopNamespaces[j].Namespace
+	})
+	if limit > 0 {
+		if len(cr.TopChecks) > limit {
+			cr.TopChecks = cr.TopChecks[:limit]
+		}
+		if len(cr.TopNamespaces) > limit {
+			cr.TopNamespaces = cr.TopNamespaces[:limit]
+		}
+	}
+	return cr
+}
+
+// Merge combines reports for the same namespace, returning one report per namespace sorted by name.
+// When reports for a namespace were evaluated against different policies, the first policy is kept.
+// The input reports are not modified.
+func Merge(reports []*NamespaceReport) []*NamespaceReport {
+	byNamespace := map[string]*NamespaceReport{}
+	for _, r := range reports {
+		if r == nil {
+			continue
+		}
+		m, ok := byNamespace[r.Namespace]
+		if !ok {
+			m = NewNamespaceReport(r.Namespace, r.LevelVersion)
+			byNamespace[r.Namespace] = m
+		}
+		m.Pods += r.Pods
+		m.ViolatingPods += r.ViolatingPods
+		for id, pods := range r.Violations {
+			m.Violations[id] += pods
+		}
+		for id, pods := range r.Passes {
+			m.Passes[id] += pods
+		}
+	}
+
+	merged := make([]*NamespaceReport, 0, len(byNamespace))
+	for _, r := range byNamespace {
+		merged = append(merged, r)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Namespace < merged[j].Namespace })
+	return merged
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"testing"
+
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollup(t *testing.T) {
+	baseline := api.LevelVersion{Level: api.LevelBaseline, Version: api.LatestVersion()}
+	restricted := api.LevelVersion{Level: api.LevelRestricted, Version: api.LatestVersion()}
+
+	reports := []*NamespaceReport{
+		{Namespace: "b", LevelVersion: restricted, Pods: 5, ViolatingPods: 3, Violations: map[policy.CheckID]int{"runAsNonRoot": 3, "seccompProfile_restricted": 1}},
+		{Namespace: "a", LevelVersion: baseline, Pods: 2, ViolatingPods: 1, Violations: map[policy.CheckID]int{"hostPorts": 1}},
+		nil,
+		{Namespace: "c", LevelVersion: baseline, Pods: 4, Violations: map[policy.CheckID]int{}},
+		// Merged with the first report for namespace b.
+		{Namespace: "b", LevelVersion: restricted, Pods: 1, ViolatingPods: 1, Violations: map[policy.CheckID]int{"hostPorts": 1}},
+	}
+
+	t.Run("unlimited", func(t *testing.T) {
+		assert.Equal(t, &ClusterReport{
+			Namespaces:    3,
+			Pods:          12,
+			ViolatingPods: 5,
+			TopChecks: []CheckCount{
+				{ID: "runAsNonRoot", Pods: 3},
+				{ID: "hostPorts", Pods: 2},
+				{ID: "seccompProfile_restricted", Pods: 1},
+			},
+			TopNamespaces: []NamespaceCount{
+				{Namespace: "b", ViolatingPods: 4},
+				{Namespace: "a", ViolatingPods: 1},
+			},
+			Levels: map[api.Level]LevelTotals{
+				api.LevelBaseline:   {Namespaces: 2, Pods: 6, ViolatingPods: 1},
+				api.LevelRestricted: {Namespaces: 1, Pods: 6, ViolatingPods: 4},
+			},
+		}, Rollup(reports, 0))
+	})
+
+	t.Run("limited", func(t *testing.T) {
+		r := Rollup(reports, 1)
+		assert.Equal(t, []CheckCount{{ID: "runAsNonRoot", Pods: 3}}, r.TopChecks)
+		assert.Equal(t, []NamespaceCount{{Namespace: "b", ViolatingPods: 4}}, r.TopNamespaces)
+	})
+
+	t.Run("merged passes", func(t *testing.T) {
+		merged := Merge([]*NamespaceReport{
+			{Namespace: "b", LevelVersion: restricted, Pods: 2, Passes: map[policy.CheckID]int{"runAsNonRoot": 2, "hostPorts": 1}},
+			{Namespace: "b", LevelVersion: restricted, Pods: 1, Passes: map[policy.CheckID]int{"hostPorts": 1}},
+		})
+		require.Len(t, merged, 1)
+		assert.Equal(t, map[policy.CheckID]int{"runAsNonRoot": 2, "hostPorts": 2}, merged[0].Passes)
+	})
+
+	t.Run("inputs not modified", func(t *testing.T) {
+		assert.Equal(t, 5, reports[0].Pods)
+		assert.Equal(t, map[policy.CheckID]int{"runAsNonRoot": 3, "seccompProfile_restricted": 1}, reports[0].Violations)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		assert.Equal(t, &ClusterReport{Levels: map[api.Level]LevelTotals{}}, Rollup(nil, 10))
+	})
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/component-base/featuregate"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// Example is a pod of the fixture corpus, passing or failing a single check at a level and version.
+// The corpus is serialized to testdata/<level>/<version>/{pass,fail}/<name>.yaml by TestFixtures.
+type Example struct {
+	// Name identifies the example within its level, version and outcome, e.g. hostports0.
+	Name string
+	// Level and Version are the policy the example is evaluated against.
+	Level   api.Level
+	Version api.Version
+	// Check is the ID of the check the example exercises.
+	Check policy.CheckID
+	// Allowed is true if the pod passes the check, and false if the check forbids it.
+	Allowed bool
+	// ExpectErrorSubstring is a substring of the error message returned for failing examples.
+	ExpectErrorSubstring string
+	// RequiresFeatures lists the feature gates that must be enabled for a failing example to be forbidden.
+	RequiresFeatures []featuregate.Feature
+	// Pod is the example pod, named Name.
+	Pod *corev1.Pod
+}
+
+// GetExamples returns the passing examples followed by the failing examples of the check
+// for the specified level and version. The returned pods may be modified by the caller.
+func GetExamples(level api.Level, version api.Version, check policy.CheckID) ([]Example, error) {
+	key := fixtureKey{level: level, version: version, check: check}
+	data, err := getFixtures(key)
+	if err != nil {
+		return nil, err
+	}
+	examples := make([]Example, 0, len(data.pass)+len(data.fail))
+	for i, pod := range data.pass {
+		examples = append(examples, newExample(key, i, true, data, pod))
+	}
+	for i, pod := range data.fail {
+		examples = append(examples, newExample(key, i, false, data, pod))
+	}
+	return examples, nil
+}
+
+// GetAllExamples returns the examples of all the checks that apply to the specified level and version,
+// in the order of policy.DefaultChecks.
+func GetAllExamples(level api.Level, version api.Version) ([]Example, error) {
+	checkIDs, err := checksForLevelAndVersion(policy.DefaultChecks(), level, version)
+	if err != nil {
+		return nil, err
+	}
+	if len(checkIDs) == 0 {
+		return nil, fmt.Errorf("no checks registered for %s/%s", level, version)
+	}
+	var examples []Example
+	for _, checkID := range checkIDs {
+		checkExamples, err := GetExamples(level, version, checkID)
+		if err != nil {
+			return nil, err
+		}
+		examples = append(examples, checkExamples...)
+	}
+	return examples, nil
+}
+
+func newExample(key fixtureKey, i int, allowed bool, data fixtureData, pod *corev1.Pod) Example {
+	example := Example{
+		Name:    fmt.Sprintf("%s%d", strings.ToLower(string(key.check)), i),
+		Level:   key.level,
+		Version: key.version,
+		Check:   key.check,
+		Allowed: allowed,
+		Pod:     pod.DeepCopy(),
+	}
+	if !allowed {
+		example.ExpectErrorSubstring = data.expectErrorSubstring
+		example.RequiresFeatures = data.failRequiresFeatures
+	}
+	example.Pod.Name = example.Name
+	return example
+}
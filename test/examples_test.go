@@ -0,0 +1,107 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// TestExamples ensures that the passing examples are allowed at their level and version,
+// and that the failing examples are forbidden by the check they exercise.
+func TestExamples(t *testing.T) {
+	checks := policy.DefaultChecks()
+	evaluator, err := policy.NewEvaluator(checks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, level := range []api.Level{api.LevelBaseline, api.LevelRestricted} {
+		for _, version := range computeVersionsToTest(t, checks) {
+			examples, err := GetAllExamples(level, version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, example := range examples {
+				if example.Level != level || example.Version != version {
+					t.Errorf("%s/%s: unexpected example %s for %s/%s", level, version, example.Name, example.Level, example.Version)
+				}
+				if example.Pod.Name != example.Name {
+					t.Errorf("%s/%s: expected example %s to name its pod, got %q", level, version, example.Name, example.Pod.Name)
+				}
+				if !example.Allowed && len(example.RequiresFeatures) > 0 {
+					continue
+				}
+
+				// The API server defaults volumes without a source to emptyDir.
+				for i, volume := range example.Pod.Spec.Volumes {
+					if volume.VolumeSource == (corev1.VolumeSource{}) {
+						example.Pod.Spec.Volumes[i].EmptyDir = &corev1.EmptyDirVolumeSource{}
+					}
+				}
+				results := evaluator.EvaluatePod(api.LevelVersion{Level: level, Version: version}, &example.Pod.ObjectMeta, &example.Pod.Spec)
+				aggregate := policy.AggregateCheckResults(results)
+				if example.Allowed {
+					if !aggregate.Allowed {
+						t.Errorf("%s/%s: expected example %s to be allowed, got %s", level, version, example.Name, aggregate.ForbiddenDetail())
+					}
+					continue
+				}
+				// Failing baseline examples may be forbidden by the restricted checks overriding their check.
+				if aggregate.Allowed {
+					t.Errorf("%s/%s: expected example %s to be forbidden by %s", level, version, example.Name, example.Check)
+					continue
+				}
+				if !strings.Contains(aggregate.ForbiddenReason()+" "+aggregate.ForbiddenDetail(), example.ExpectErrorSubstring) {
+					t.Errorf("%s/%s: expected example %s to be forbidden with %q, got %s", level, version, example.Name, example.ExpectErrorSubstring, aggregate.ForbiddenDetail())
+				}
+			}
+		}
+	}
+}
+
+func TestGetExamples(t *testing.T) {
+	examples, err := GetExamples(api.LevelBaseline, api.MajorMinorVersion(1, 0), "hostPorts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pass, fail []string
+	for _, example := range examples {
+		if example.Allowed {
+			pass = append(pass, example.Name)
+		} else {
+			fail = append(fail, example.Name)
+		}
+	}
+	if e, a := "hostports0", strings.Join(pass, ","); e != a {
+		t.Errorf("expected passing examples %s, got %s", e, a)
+	}
+	if e, a := "hostports0,hostports1,hostports2", strings.Join(fail, ","); e != a {
+		t.Errorf("expected failing examples %s, got %s", e, a)
+	}
+
+	if _, err := GetExamples(api.LevelBaseline, api.LatestVersion(), "hostPorts"); err == nil {
+		t.Error("expected error for the latest version")
+	}
+	if _, err := GetExamples(api.LevelBaseline, api.MajorMinorVersion(1, 0), "unknown"); err == nil {
+		t.Error("expected error for an unknown check")
+	}
+}
@@ -112,6 +112,8 @@ func init() {
 	restricted_1_25_windows := addWindows(restricted_1_0)
 	minimalValidWindowsPods[api.LevelRestricted][api.MajorMinorVersion(1, 25)] = restricted_1_25_windows
 
+	// 1.30+: runAsNonRoot does not apply to the pods that are explicitly Windows
+	minimalValidWindowsPods[api.LevelRestricted][api.MajorMinorVersion(1, 30)] = addWindows(baseline_1_0)
 }
 
 // GetMinimalValidPod returns a minimal valid OS neutral pod for the specified level and version.
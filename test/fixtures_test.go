@@ -88,16 +88,17 @@ func TestFixtures(t *testing.T) {
 				t.Fatal(fmt.Errorf("no checks registered for %s/1.%d", level, version))
 			}
 			for _, checkID := range checkIDs {
-				checkData, err := getFixtures(fixtureKey{level: level, version: api.MajorMinorVersion(1, version), check: checkID})
+				examples, err := GetExamples(level, api.MajorMinorVersion(1, version), checkID)
 				if err != nil {
 					t.Fatal(err)
 				}
 
-				for i, pod := range checkData.pass {
-					expectedFiles.Insert(testFixtureFile(t, passDir, fmt.Sprintf("%s%d", strings.ToLower(string(checkID)), i), pod))
-				}
-				for i, pod := range checkData.fail {
-					expectedFiles.Insert(testFixtureFile(t, failDir, fmt.Sprintf("%s%d", strings.ToLower(string(checkID)), i), pod))
+				for _, example := range examples {
+					dir := failDir
+					if example.Allowed {
+						dir = passDir
+					}
+					expectedFiles.Insert(testFixtureFile(t, dir, example.Name, example.Pod))
 				}
 			}
 		}
@@ -0,0 +1,208 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/propagation"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientset "k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/pod-security-admission/admission"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// MaxRequestSize is the maximum size of the body of the requests served by a Handler.
+const MaxRequestSize = int64(3 * 1024 * 1024)
+
+// Handler is an http.Handler serving AdmissionReview requests with a PodSecurity admission.Admission.
+type Handler struct {
+	// Admission evaluates the requests. It must not be modified once the Handler serves requests.
+	*admission.Admission
+	// admit is the Admission method called for each request.
+	admit func(context.Context, api.Attributes) *admissionv1.AdmissionResponse
+}
+
+// NewHandler returns a Handler validating AdmissionReview requests with the Admission, after completing and validating
+// its configuration. The Admission requires a NamespaceGetter and a PodLister, and a PodControllerGetter if its
+// SubresourceWarnings are enabled. If unset, the Evaluator defaults to an evaluator of policy.DefaultChecks(),
+// and the ChecksSchemaVersion to the schema version of the Evaluator.
+func NewHandler(a *admission.Admission) (*Handler, error) {
+	if a.Evaluator == nil {
+		evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+		if err != nil {
+			return nil, fmt.Errorf("could not create PodSecurityRegistry: %w", err)
+		}
+		a.Evaluator = evaluator
+	}
+	if a.ChecksSchemaVersion == "" {
+		a.ChecksSchemaVersion = policy.EvaluatorSchemaVersion(a.Evaluator)
+	}
+	if err := a.CompleteConfiguration(); err != nil {
+		return nil, fmt.Errorf("configuration error: %w", err)
+	}
+	if err := a.ValidateConfiguration(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return &Handler{Admission: a, admit: a.Validate}, nil
+}
+
+// NewHandlerForClient returns a Handler like NewHandler, whose Admission gets namespaces, lists pods and gets
+// pod controllers with the client, unless its NamespaceGetter, PodLister or PodControllerGetter is set.
+// Namespaces are fetched from the namespaceLister first, if non-nil.
+func NewHandlerForClient(a *admission.Admission, client clientset.Interface, namespaceLister corev1listers.NamespaceLister) (*Handler, error) {
+	if client == nil {
+		return nil, errors.New("client required")
+	}
+	if a.NamespaceGetter == nil {
+		if namespaceLister != nil {
+			a.NamespaceGetter = admission.NamespaceGetterFromListerAndClient(namespaceLister, client)
+		} else {
+			a.NamespaceGetter = admission.NamespaceGetterFromClient(client)
+		}
+	}
+	if a.PodLister == nil {
+		a.PodLister = admission.PodListerFromClient(client)
+	}
+	if a.PodControllerGetter == nil {
+		a.PodControllerGetter = admission.PodControllerGetterFromClient(client)
+	}
+	return NewHandler(a)
+}
+
+// Mutating returns a Handler serving the AdmissionReview requests of a mutating webhook with the same Admission,
+// annotating the pods violating their namespace enforce policy when the EnforcementAction is Annotate.
+func (h *Handler) Mutating() *Handler {
+	return &Handler{Admission: h.Admission, admit: h.Admission.MutatePod}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer utilruntime.HandleCrash(func(_ interface{}) {
+		// Assume the crash happened before the response was written.
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	})
+
+	var (
+		body   []byte
+		err    error
+		ctx    = r.Context()
+		logger = klog.FromContext(ctx)
+	)
+	// Continue the trace of the API server, if any, attaching it to the recorded denials as exemplars.
+	ctx = propagation.TraceContext{}.Extract(ctx, propagation.HeaderCarrier(r.Header))
+
+	if timeout, ok, err := parseTimeout(r); err != nil {
+		// Ignore an invalid timeout.
+		logger.V(2).Info("Invalid timeout", "error", err)
+	} else if ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if r.Body == nil || r.Body == http.NoBody {
+		err = errors.New("request body is empty")
+		logger.Error(err, "bad request")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	defer r.Body.Close()
+	limitedReader := &io.LimitedReader{R: r.Body, N: MaxRequestSize}
+	if body, err = io.ReadAll(limitedReader); err != nil {
+		logger.Error(err, "unable to read the body from the incoming request")
+		http.Error(w, "unable to read the body from the incoming request", http.StatusBadRequest)
+		return
+	}
+	if limitedReader.N <= 0 {
+		logger.Error(err, "unable to read the body from the incoming request; limit reached")
+		http.Error(w, fmt.Sprintf("request entity is too large; limit is %d bytes", MaxRequestSize), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// verify the content type is accurate
+	if contentType := r.Header.Get("Content-Type"); contentType != "application/json" {
+		err = fmt.Errorf("contentType=%s, expected application/json", contentType)
+		logger.Error(err, "unable to process a request with an unknown content type", "type", contentType)
+		http.Error(w, "unable to process a request with a non-json content type", http.StatusBadRequest)
+		return
+	}
+
+	v1AdmissionReviewKind := admissionv1.SchemeGroupVersion.WithKind("AdmissionReview")
+	reviewObject, gvk, err := codecs.UniversalDeserializer().Decode(body, &v1AdmissionReviewKind, nil)
+	if err != nil {
+		logger.Error(err, "unable to decode the request")
+		http.Error(w, "unable to decode the request", http.StatusBadRequest)
+		return
+	}
+	if *gvk != v1AdmissionReviewKind {
+		logger.Info("Unexpected AdmissionReview kind", "kind", gvk.String())
+		http.Error(w, fmt.Sprintf("unexpected AdmissionReview kind: %s", gvk.String()), http.StatusBadRequest)
+		return
+	}
+	review, ok := reviewObject.(*admissionv1.AdmissionReview)
+	if !ok {
+		logger.Info("Failed admissionv1.AdmissionReview type assertion")
+		http.Error(w, "unexpected AdmissionReview type", http.StatusBadRequest)
+	}
+	logger.V(1).Info("received request", "UID", review.Request.UID, "kind", review.Request.Kind, "resource", review.Request.Resource)
+
+	attributes := api.RequestAttributes(review.Request, codecs.UniversalDeserializer())
+	response := h.admit(ctx, attributes)
+	response.UID = review.Request.UID // Response UID must match request UID
+	review.Response = response
+	writeResponse(w, review)
+}
+
+func writeResponse(w http.ResponseWriter, review *admissionv1.AdmissionReview) {
+	// Webhooks should always respond with a 200 HTTP status code when an AdmissionResponse can be sent.
+	// In an error case, the true status code is captured in the response.result.code
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		klog.ErrorS(err, "Failed to encode response")
+		// Unable to send an AdmissionResponse, fall back to an HTTP error.
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// parseTimeout parses the given HTTP request URL and extracts the timeout query parameter
+// value if specified by the user.
+// If a timeout is not specified the function returns false and err is set to nil
+// If the value specified is malformed then the function returns false and err is set
+func parseTimeout(req *http.Request) (time.Duration, bool, error) {
+	value := req.URL.Query().Get("timeout")
+	if value == "" {
+		return 0, false, nil
+	}
+
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid timeout query: %w", err)
+	}
+
+	return timeout, true, nil
+}
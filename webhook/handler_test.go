@@ -0,0 +1,167 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/pod-security-admission/admission"
+	"k8s.io/pod-security-admission/admission/api/load"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/metrics"
+	utilpointer "k8s.io/utils/pointer"
+)
+
+var testNamespace = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+	Name:   "test-ns",
+	Labels: map[string]string{api.EnforceLevelLabel: string(api.LevelBaseline)},
+}}
+
+// testNamespaceGetter gets the test namespace.
+type testNamespaceGetter struct{}
+
+func (testNamespaceGetter) GetNamespace(_ context.Context, name string) (*corev1.Namespace, error) {
+	if name != testNamespace.Name {
+		return nil, apierrors.NewNotFound(corev1.Resource("namespaces"), name)
+	}
+	return testNamespace.DeepCopy(), nil
+}
+
+// testPodLister lists no pods.
+type testPodLister struct{}
+
+func (testPodLister) ListPods(context.Context, string) ([]*corev1.Pod, error) {
+	return nil, nil
+}
+
+// newTestAdmission returns an Admission with the default configuration and no getters.
+func newTestAdmission(t *testing.T) *admission.Admission {
+	t.Helper()
+	config, err := load.LoadFromData(nil)
+	require.NoError(t, err)
+	return &admission.Admission{
+		Configuration: config,
+		Metrics:       metrics.NewPrometheusRecorder(api.GetAPIVersion()),
+	}
+}
+
+// podCreateRequest returns the AdmissionRequest creating a pod in the test namespace, privileged if privileged is true.
+func podCreateRequest(t *testing.T, privileged bool) *admissionv1.AdmissionRequest {
+	t.Helper()
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: testNamespace.Name},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "app", Image: "app", SecurityContext: &corev1.SecurityContext{Privileged: utilpointer.Bool(privileged)},
+		}}},
+	}
+	raw, err := json.Marshal(pod)
+	require.NoError(t, err)
+	return &admissionv1.AdmissionRequest{
+		UID:       "test-uid",
+		Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		Resource:  metav1.GroupVersionResource{Version: "v1", Resource: "pods"},
+		Namespace: testNamespace.Name,
+		Name:      pod.Name,
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: raw},
+	}
+}
+
+// serveReview sends an AdmissionReview of the request to the handler, and returns its response.
+func serveReview(t *testing.T, h http.Handler, request *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	t.Helper()
+	body, err := json.Marshal(&admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: admissionv1.SchemeGroupVersion.String(), Kind: "AdmissionReview"},
+		Request:  request,
+	})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	review := &admissionv1.AdmissionReview{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), review))
+	require.NotNil(t, review.Response)
+	assert.Equal(t, request.UID, review.Response.UID)
+	return review.Response
+}
+
+func TestNewHandler(t *testing.T) {
+	a := newTestAdmission(t)
+	_, err := NewHandler(a)
+	assert.ErrorContains(t, err, "NamespaceGetter required", "the getters are not defaulted without a client")
+
+	a = newTestAdmission(t)
+	a.NamespaceGetter = testNamespaceGetter{}
+	a.PodLister = testPodLister{}
+	h, err := NewHandler(a)
+	require.NoError(t, err)
+	assert.NotNil(t, h.Evaluator, "the Evaluator should default to the default checks")
+	assert.NotEmpty(t, h.ChecksSchemaVersion, "the ChecksSchemaVersion should default to the version of the Evaluator")
+
+	assert.True(t, serveReview(t, h, podCreateRequest(t, false)).Allowed)
+	denied := serveReview(t, h, podCreateRequest(t, true))
+	assert.False(t, denied.Allowed)
+	assert.Contains(t, denied.Result.Message, `violates PodSecurity "baseline:latest"`)
+}
+
+func TestNewHandlerForClient(t *testing.T) {
+	_, err := NewHandlerForClient(newTestAdmission(t), nil, nil)
+	assert.ErrorContains(t, err, "client required")
+
+	h, err := NewHandlerForClient(newTestAdmission(t), fake.NewSimpleClientset(testNamespace.DeepCopy()), nil)
+	require.NoError(t, err)
+	assert.NotNil(t, h.PodControllerGetter)
+	assert.False(t, serveReview(t, h, podCreateRequest(t, true)).Allowed)
+
+	// getters that are set are kept
+	a := newTestAdmission(t)
+	a.NamespaceGetter = testNamespaceGetter{}
+	h, err = NewHandlerForClient(a, fake.NewSimpleClientset(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, testNamespaceGetter{}, h.NamespaceGetter)
+	assert.False(t, serveReview(t, h, podCreateRequest(t, true)).Allowed)
+}
+
+func TestMutating(t *testing.T) {
+	a := newTestAdmission(t)
+	a.NamespaceGetter = testNamespaceGetter{}
+	a.PodLister = testPodLister{}
+	a.EnforcementAction = admission.EnforcementActionAnnotate
+	h, err := NewHandler(a)
+	require.NoError(t, err)
+
+	assert.True(t, serveReview(t, h, podCreateRequest(t, true)).Allowed)
+	assert.Nil(t, serveReview(t, h, podCreateRequest(t, true)).Patch, "the validating handler should not annotate pods")
+	assert.NotNil(t, serveReview(t, h.Mutating(), podCreateRequest(t, true)).Patch, "the mutating handler should annotate violating pods")
+	assert.Nil(t, serveReview(t, h.Mutating(), podCreateRequest(t, false)).Patch)
+}